@@ -0,0 +1,88 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/badaniya/loggo/internal/loggo"
+	"github.com/badaniya/loggo/internal/util"
+	"github.com/spf13/cobra"
+)
+
+// benchCmd represents the bench command
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Benchmark the ingest and rendering pipeline",
+	Long: `Generates synthetic json log lines into an off-screen l'oGGo, reporting
+the sustained ingest rate and frame times. Without a rate, lines are generated
+as fast as they are ingested, revealing the max sustainable ingest rate:
+
+	loggo bench --size 1024 --duration 30s
+	loggo bench --rate 5000 --template my-template.yaml`,
+	Run: func(cmd *cobra.Command, args []string) {
+		templateFile := cmd.Flag("template").Value.String()
+		rate, err := strconv.Atoi(cmd.Flag("rate").Value.String())
+		if err != nil {
+			util.Log().Fatal("Invalid rate: ", err)
+		}
+		size, err := strconv.Atoi(cmd.Flag("size").Value.String())
+		if err != nil {
+			util.Log().Fatal("Invalid size: ", err)
+		}
+		duration, err := time.ParseDuration(cmd.Flag("duration").Value.String())
+		if err != nil {
+			util.Log().Fatal("Invalid duration: ", err)
+		}
+		fmt.Printf("Benchmarking %s with %d bytes lines...\n", duration, size)
+		report := loggo.RunBench(rate, size, templateFile, 3*time.Second, duration)
+
+		target := "max"
+		if rate > 0 {
+			target = fmt.Sprintf("%d lines/s", rate)
+		}
+		fmt.Printf("Target rate:    %s\n", target)
+		fmt.Printf("Lines ingested: %d (%.0f lines/s, %.2f MiB/s)\n", report.Ingested, report.IngestRate(),
+			report.IngestRate()*float64(size)/(1<<20))
+		fmt.Printf("Backlog:        %d lines\n", report.Generated-report.Ingested)
+		fmt.Printf("Frames drawn:   %d (%.1f fps)\n", report.Frames, float64(report.Frames)/duration.Seconds())
+		fmt.Printf("Frame time:     avg %v, p95 %v, max %v\n", report.FrameAvg, report.FrameP95, report.FrameMax)
+		if !report.Sustained() {
+			fmt.Println("The target rate could NOT be sustained.")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(benchCmd)
+	benchCmd.Flags().
+		IntP("rate", "r", 0, "Lines per second to generate, 0 generates as fast as ingested")
+	benchCmd.Flags().
+		IntP("size", "s", 512, "Approximate size of each generated line in bytes")
+	benchCmd.Flags().
+		DurationP("duration", "d", 10*time.Second, "Measured duration, after a short warmup")
+	benchCmd.Flags().
+		StringP("template", "t", "", "Rendering template file")
+}
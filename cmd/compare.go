@@ -0,0 +1,70 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/badaniya/loggo/internal/loggo"
+	"github.com/badaniya/loggo/internal/reader"
+	"github.com/spf13/cobra"
+)
+
+// compareCmd represents the compare command
+var compareCmd = &cobra.Command{
+	Use:   "compare",
+	Short: "Stream two log sources side by side",
+	Long: `Continuously streams two log files (or named pipes and unix sockets)
+side by side, e.g. a canary and a baseline deployment. Selecting an entry on
+one side selects the entry closest in time on the other, according to the
+first datetime key of the template; Ctrl+O switches sides. For example:
+
+	loggo compare --left canary.log --right baseline.log
+	loggo compare --left canary.log --right baseline.log --template <template yaml>`,
+	Run: func(cmd *cobra.Command, args []string) {
+		left := cmd.Flag("left").Value.String()
+		right := cmd.Flag("right").Value.String()
+		templateFile := cmd.Flag("template").Value.String()
+		if len(left) == 0 || len(right) == 0 {
+			fmt.Fprintln(os.Stderr, "both --left and --right are required")
+			os.Exit(1)
+		}
+		app := loggo.NewLoggoCompareApp(reader.MakeReader(left, nil), reader.MakeReader(right, nil),
+			left, right, templateFile)
+		notifyOptions(cmd, app, fmt.Sprintf("%s vs %s", filepath.Base(left), filepath.Base(right)))
+		app.Run()
+		printSummary(cmd)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(compareCmd)
+	compareCmd.Flags().
+		StringP("left", "l", "", "Log file streamed on the left side")
+	compareCmd.Flags().
+		StringP("right", "r", "", "Log file streamed on the right side")
+	compareCmd.Flags().
+		StringP("template", "t", "", "Rendering Template")
+}
@@ -0,0 +1,136 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/format"
+	"github.com/badaniya/loggo/internal/util"
+	"github.com/spf13/cobra"
+)
+
+const (
+	convertNDJSON = "ndjson"
+	convertCSV    = "csv"
+)
+
+var convertCmd = &cobra.Command{
+	Use:   "convert",
+	Short: "Convert a log between formats without the TUI",
+	Long: `Converts a log file (or the standard input) without launching the TUI,
+either parsing a plain text format into NDJSON entries, or NDJSON entries into
+CSV with a column per template key (inferred from the first lines if no
+template is given). For example:
+
+	loggo convert --file slow.log --format mysql-slow --output slow.ndjson
+	loggo convert --file app.log --to csv --template template.yaml --output app.csv
+	loggo convert --file slow.log --format mysql-slow --to csv > slow.csv`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fileName := cmd.Flag("file").Value.String()
+		formatName := cmd.Flag("format").Value.String()
+		to := strings.ToLower(cmd.Flag("to").Value.String())
+		templateFile := cmd.Flag("template").Value.String()
+		output := cmd.Flag("output").Value.String()
+		if to != convertNDJSON && to != convertCSV {
+			fmt.Fprintf(os.Stderr, "--to must be either %s or %s\n", convertNDJSON, convertCSV)
+			os.Exit(1)
+		}
+		if to == convertNDJSON && len(formatName) == 0 {
+			fmt.Fprintln(os.Stderr, "--format is required to convert into ndjson")
+			os.Exit(1)
+		}
+		var in io.Reader = os.Stdin
+		if len(fileName) > 0 {
+			f, err := os.Open(fileName)
+			if err != nil {
+				util.Log().Fatal("Unable to open the input file: ", err)
+			}
+			defer f.Close()
+			in = f
+		}
+		var out io.Writer = os.Stdout
+		if len(output) > 0 {
+			if util.ReadOnly() {
+				exitReadOnly("--output")
+			}
+			f, err := os.Create(output)
+			if err != nil {
+				util.Log().Fatal("Unable to create output file: ", err)
+			}
+			defer f.Close()
+			out = f
+		}
+		if len(formatName) > 0 {
+			parser, err := format.NewParser(formatName)
+			if err != nil {
+				util.Log().Fatal(err)
+			}
+			if to == convertNDJSON {
+				if _, err := format.ToNDJSON(out, in, parser); err != nil {
+					util.Log().Fatal("Unable to convert: ", err)
+				}
+				return
+			}
+			pr, pw := io.Pipe()
+			go func(raw io.Reader) {
+				_, err := format.ToNDJSON(pw, raw, parser)
+				pw.CloseWithError(err)
+			}(in)
+			in = pr
+		}
+		var keys []config.Key
+		if len(templateFile) > 0 {
+			cfg, err := config.MakeConfig(templateFile)
+			if err != nil {
+				util.Log().Fatal("Unable to read the template: ", err)
+			}
+			keys = cfg.Keys
+		} else {
+			keys, in = sampleKeys(in)
+		}
+		if _, err := format.ToCSV(out, in, keys); err != nil {
+			util.Log().Fatal("Unable to convert: ", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(convertCmd)
+	convertCmd.Flags().
+		StringP("file", "f", "", "Input log file, the standard input if omitted")
+	convertCmd.Flags().
+		StringP("format", "", "",
+			fmt.Sprintf("Parse the input from a plain text log format, one of: %s",
+				strings.Join(format.Names(), ", ")))
+	convertCmd.Flags().
+		StringP("to", "", convertNDJSON, "Output format, either ndjson or csv")
+	convertCmd.Flags().
+		StringP("template", "t", "", "Template whose keys become the CSV columns")
+	convertCmd.Flags().
+		StringP("output", "o", "", "Output file, the standard output if omitted")
+}
@@ -0,0 +1,93 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/badaniya/loggo/internal/reader"
+	"github.com/badaniya/loggo/internal/util"
+	"github.com/spf13/cobra"
+)
+
+var datadogStreamCmd = &cobra.Command{
+	Use:   "datadog-stream",
+	Short: "Continuously stream logs from the Datadog Logs search API",
+	Long: `Query Datadog logs with the standard log search syntax and keep live
+tailing new matches:
+
+	loggo datadog-stream \
+            --query 'service:checkout status:error' \
+            --site datadoghq.eu \
+            --from 30m
+
+The API and application keys are read from the DD_API_KEY and DD_APP_KEY
+environment variables unless provided as flags.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		query := cmd.Flag("query").Value.String()
+		from := cmd.Flag("from").Value.String()
+		site := envOr(cmd.Flag("site").Value.String(), "DD_SITE", "datadoghq.com")
+		apiKey := envOr(cmd.Flag("api-key").Value.String(), "DD_API_KEY", "")
+		appKey := envOr(cmd.Flag("app-key").Value.String(), "DD_APP_KEY", os.Getenv("DD_APPLICATION_KEY"))
+		templateFile := cmd.Flag("template").Value.String()
+		if len(apiKey) == 0 || len(appKey) == 0 {
+			util.Log().Fatal("Datadog API and application keys are required, see --api-key and --app-key.")
+		}
+		reader := reader.MakeDatadogReader(site, apiKey, appKey, query, reader.ParseFrom(from), nil)
+		runLoggo(cmd, reader, templateFile)
+	},
+}
+
+// envOr returns value if set, otherwise the env variable or the fallback.
+func envOr(value, env, fallback string) string {
+	if len(value) > 0 {
+		return value
+	}
+	if v := os.Getenv(env); len(v) > 0 {
+		return v
+	}
+	return fallback
+}
+
+func init() {
+	rootCmd.AddCommand(datadogStreamCmd)
+	datadogStreamCmd.Flags().
+		StringP("query", "q", "", "Datadog log search query, e.g. 'service:web status:error'")
+	datadogStreamCmd.Flags().
+		StringP("from", "d", "tail",
+			`Start streaming from:
+  Relative: Use format "1s", "1m", "1h" or "1d", where:
+            digit followed by s, m, h, d as second, minute, hour, day.
+  Fixed:    Use date format as "yyyy-MM-ddH24:mm:ss", e.g. 2022-07-30T15:00:00
+  Now:      Use "tail" to start from now`)
+	datadogStreamCmd.Flags().
+		StringP("site", "", "", `Datadog site, e.g. datadoghq.eu (defaults to DD_SITE or "datadoghq.com")`)
+	datadogStreamCmd.Flags().
+		StringP("api-key", "", "", "Datadog API key (defaults to DD_API_KEY)")
+	datadogStreamCmd.Flags().
+		StringP("app-key", "", "", "Datadog application key (defaults to DD_APP_KEY)")
+	datadogStreamCmd.Flags().
+		StringP("template", "t", "",
+			"Rendering Template")
+}
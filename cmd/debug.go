@@ -37,8 +37,7 @@ var debugCmd = &cobra.Command{
 	loggo debug`,
 	Run: func(cmd *cobra.Command, args []string) {
 		reader := reader.MakeReader(loggo.LatestLog, nil)
-		app := loggo.NewLoggoApp(reader, "")
-		app.Run()
+		runLoggo(cmd, reader, "")
 	},
 }
 
@@ -24,6 +24,8 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"os"
 	"strconv"
 	"time"
 
@@ -31,7 +33,6 @@ import (
 
 	"github.com/badaniya/loggo/internal/gcp"
 
-	"github.com/badaniya/loggo/internal/loggo"
 	"github.com/badaniya/loggo/internal/reader"
 	"github.com/spf13/cobra"
 )
@@ -59,11 +60,16 @@ from a given selected project and GCP logging filters:
 		lp, _ := strconv.ParseBool(listParams)
 		loadParams := cmd.Flag("params-load").Value.String()
 		gcp.IsGCloud, _ = strconv.ParseBool(cmd.Flag("gcloud-auth").Value.String())
+		gcp.NoBrowser, _ = strconv.ParseBool(cmd.Flag("no-browser").Value.String())
 		auth, _ := strconv.ParseBool(cmd.Flag("force-auth").Value.String())
 		if auth && gcp.IsGCloud {
 			gcp.Delete()
 		}
 		if len(saveParams) > 0 {
+			if util.ReadOnly() {
+				exitReadOnly("--params-save")
+			}
+			checkGCPFilter(filter)
 			if err := reader.Save(saveParams,
 				&reader.SavedParams{
 					From:     from,
@@ -103,18 +109,28 @@ from a given selected project and GCP logging filters:
 			if len(projectName) == 0 {
 				util.Log().Fatal("--project flag is required.")
 			}
+			checkGCPFilter(filter)
 			err := reader.CheckAuth(context.Background(), projectName)
 			if err != nil {
 				util.Log().Fatal("Unable to obtain GCP credentials. ", err)
 			}
 			time.Sleep(time.Second)
 			reader := reader.MakeGCPReader(projectName, filter, reader.ParseFrom(from), nil)
-			app := loggo.NewLoggoApp(reader, templateFile)
-			app.Run()
+			runLoggo(cmd, reader, templateFile)
 		}
 	},
 }
 
+// checkGCPFilter exits pointing at the offending position of a malformed
+// filter, before authenticating and streaming.
+func checkGCPFilter(filter string) {
+	if err := gcp.ValidateFilter(filter); err != nil {
+		util.Log().WithField("code", err).Error("Invalid GCP filter")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(gcpStreamCmd)
 	gcpStreamCmd.Flags().
@@ -157,4 +173,8 @@ if you may have a valid authentication file.`)
 			`Use the existing GCloud CLI infrastructure installed on your system for GCP
 authentication. You must have gcloud CLI installed and configured. If this 
 flag is not passed, it use l'oggo native connector.`)
+	gcpStreamCmd.Flags().
+		BoolP("no-browser", "", false,
+			`Authenticate without opening a browser on this machine, e.g. from a remote
+shell: prints an address and a code to enter from a browser on any device.`)
 }
@@ -0,0 +1,87 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"strconv"
+
+	"github.com/badaniya/loggo/internal/reader"
+	"github.com/badaniya/loggo/internal/util"
+	"github.com/spf13/cobra"
+)
+
+var graylogStreamCmd = &cobra.Command{
+	Use:   "graylog-stream",
+	Short: "Stream messages from the Graylog search API",
+	Long: `Search Graylog messages and, by default, keep following new ones:
+
+	loggo graylog-stream \
+            --url https://graylog.mycompany.com \
+            --query 'level:<=3 AND source:web*' \
+            --stream 5f1b2c3d4e5f6a7b8c9d0e1f \
+            --from 15m
+
+The access token is read from GRAYLOG_TOKEN unless provided as a flag.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		baseURL := envOr(cmd.Flag("url").Value.String(), "GRAYLOG_URL", "")
+		token := envOr(cmd.Flag("token").Value.String(), "GRAYLOG_TOKEN", "")
+		query := cmd.Flag("query").Value.String()
+		streamID := cmd.Flag("stream").Value.String()
+		from := cmd.Flag("from").Value.String()
+		follow, _ := strconv.ParseBool(cmd.Flag("follow").Value.String())
+		templateFile := cmd.Flag("template").Value.String()
+		if len(baseURL) == 0 {
+			util.Log().Fatal("--url flag is required.")
+		}
+		if len(token) == 0 {
+			util.Log().Fatal("A Graylog access token is required, see --token.")
+		}
+		reader := reader.MakeGraylogReader(baseURL, token, query, streamID, reader.ParseFrom(from), follow, nil)
+		runLoggo(cmd, reader, templateFile)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(graylogStreamCmd)
+	graylogStreamCmd.Flags().
+		StringP("url", "u", "", "Graylog base URL, e.g. https://graylog:9000 (defaults to GRAYLOG_URL)")
+	graylogStreamCmd.Flags().
+		StringP("token", "", "", "Graylog access token (defaults to GRAYLOG_TOKEN)")
+	graylogStreamCmd.Flags().
+		StringP("query", "q", "*", "Graylog search query")
+	graylogStreamCmd.Flags().
+		StringP("stream", "s", "", "Only search the given Graylog stream ID")
+	graylogStreamCmd.Flags().
+		StringP("from", "d", "5m",
+			`Start streaming from:
+  Relative: Use format "1s", "1m", "1h" or "1d", where:
+            digit followed by s, m, h, d as second, minute, hour, day.
+  Fixed:    Use date format as "yyyy-MM-ddH24:mm:ss", e.g. 2022-07-30T15:00:00
+  Now:      Use "tail" to start from now`)
+	graylogStreamCmd.Flags().
+		BoolP("follow", "f", true, "Keep polling for new messages once the search history is consumed")
+	graylogStreamCmd.Flags().
+		StringP("template", "t", "",
+			"Rendering Template")
+}
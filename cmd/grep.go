@@ -0,0 +1,125 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/filter"
+	"github.com/badaniya/loggo/internal/grep"
+	"github.com/spf13/cobra"
+)
+
+// grepCmd represents the grep command
+var grepCmd = &cobra.Command{
+	Use:   "grep [filter expression]",
+	Short: "Filter log input without the TUI",
+	Long: `Applies a l'oGGo filter expression to the standard input (through pipe)
+or a file, writing the matching entries rendered with the template's keys as
+tab separated columns, or as raw JSON lines without a template or with --raw.
+Like grep, it exits with status 1 when nothing matched. For example:
+
+	loggo grep 'severity == "ERROR" AND latency > 1s' --file app.log --template my-template.yaml
+	kubectl logs my-pod | loggo grep 'message CONTAINS "timeout"' --count
+	loggo grep '"Timeout" OR msg:refused' -w --file app.log
+
+Quoted texts and key:term searches are smart-case as in ripgrep, ignoring case
+unless holding an upper case letter; -i ignores and -s matches case, and -w
+matches whole words only.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		fileName := cmd.Flag("file").Value.String()
+		templateFile := cmd.Flag("template").Value.String()
+		opts := grep.Options{
+			Raw:    cmd.Flag("raw").Value.String() == "true",
+			Invert: cmd.Flag("invert-match").Value.String() == "true",
+			Count:  cmd.Flag("count").Value.String() == "true",
+		}
+		if len(args) > 0 && len(strings.TrimSpace(args[0])) > 0 {
+			expr, err := filter.ParseFilterExpression(args[0])
+			if err != nil {
+				exitGrep(fmt.Errorf("invalid filter expression: %w", err))
+			}
+			opts.Expression = expr
+		}
+		if len(templateFile) > 0 {
+			cfg, err := config.MakeConfig(templateFile)
+			if err != nil {
+				exitGrep(fmt.Errorf("unable to load the template: %w", err))
+			}
+			opts.Config = cfg
+		}
+		var in io.Reader = os.Stdin
+		if len(fileName) > 0 {
+			f, err := os.Open(fileName)
+			if err != nil {
+				exitGrep(err)
+			}
+			defer f.Close()
+			in = f
+		}
+		out := bufio.NewWriter(os.Stdout)
+		count, err := grep.Run(in, out, opts)
+		if opts.Count {
+			fmt.Fprintln(out, count)
+		}
+		_ = out.Flush()
+		if err != nil {
+			exitGrep(err)
+		}
+		if count == 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// exitGrep exits with status 2 as grep does on errors.
+func exitGrep(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(2)
+}
+
+func init() {
+	rootCmd.AddCommand(grepCmd)
+	grepCmd.Flags().
+		StringP("file", "f", "", "Input Log File, the standard input if omitted")
+	grepCmd.Flags().
+		StringP("template", "t", "", "Rendering Template")
+	grepCmd.Flags().
+		BoolP("raw", "r", false, "Write the matching lines as read rather than rendered with the template")
+	grepCmd.Flags().
+		BoolP("invert-match", "v", false, "Select the entries not matching the filter expression")
+	grepCmd.Flags().
+		BoolP("count", "c", false, "Only write the number of matching entries")
+	grepCmd.Flags().
+		BoolP("ignore-case", "i", false, "Match quoted texts and terms ignoring case, rather than smart case")
+	grepCmd.Flags().
+		BoolP("case-sensitive", "s", false, "Match quoted texts and terms matching case, rather than smart case")
+	grepCmd.Flags().
+		BoolP("word-regexp", "w", false, "Match quoted texts and terms as whole words only")
+}
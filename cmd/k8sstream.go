@@ -0,0 +1,97 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"strconv"
+
+	"github.com/badaniya/loggo/internal/reader"
+	"github.com/badaniya/loggo/internal/util"
+	"github.com/spf13/cobra"
+)
+
+var k8sStreamCmd = &cobra.Command{
+	Use:   "k8s-stream [workload]",
+	Short: "Continuously stream Kubernetes pod logs and events",
+	Long: `Continuously stream the logs of a Kubernetes workload (e.g. deploy/api,
+sts/db or a pod name) or of the pods matching a label selector, wrapping
+'kubectl logs' (you must have kubectl installed and configured). With --events,
+the namespace events about those pods and their owners (scheduling, probes,
+back-offs...) and container restarts, e.g. OOMKilled, are interleaved with the
+logs, as they often explain gaps:
+
+	loggo k8s-stream deploy/api \
+            --namespace shop \
+            --events \
+            --from 10m
+
+	loggo k8s-stream --selector app=api --container app --events
+`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		util.Log().WithField("code", cmd.Flags()).Info("K8s Stream Params")
+		target := ""
+		if len(args) > 0 {
+			target = args[0]
+		}
+		selector := cmd.Flag("selector").Value.String()
+		if len(target) == 0 && len(selector) == 0 {
+			util.Log().Fatal("A workload or the --selector flag is required.")
+		}
+		kubeContext := cmd.Flag("context").Value.String()
+		namespace := cmd.Flag("namespace").Value.String()
+		container := cmd.Flag("container").Value.String()
+		events, _ := strconv.ParseBool(cmd.Flag("events").Value.String())
+		from := cmd.Flag("from").Value.String()
+		templateFile := cmd.Flag("template").Value.String()
+		reader := reader.MakeK8sReader(reader.K8sArgs(kubeContext, namespace),
+			target, selector, container, reader.ParseFrom(from), events, nil)
+		runLoggo(cmd, reader, templateFile)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(k8sStreamCmd)
+	k8sStreamCmd.Flags().
+		StringP("namespace", "n", "", "Kubernetes namespace, defaults to the context's one")
+	k8sStreamCmd.Flags().
+		StringP("context", "", "", "kubeconfig context, defaults to the current one")
+	k8sStreamCmd.Flags().
+		StringP("selector", "l", "", "Follow the pods matching this label selector, e.g. app=api")
+	k8sStreamCmd.Flags().
+		StringP("container", "c", "", "Only follow this container, defaults to all the pod containers")
+	k8sStreamCmd.Flags().
+		BoolP("events", "e", false,
+			`Interleave the namespace events about the followed pods and their owners, and
+the container restarts (e.g. OOMKilled), with the logs.`)
+	k8sStreamCmd.Flags().
+		StringP("from", "d", "tail",
+			`Start streaming from:
+  Relative: Use format "1s", "1m", "1h" or "1d", where:
+            digit followed by s, m, h, d as second, minute, hour, day.
+  Fixed:    Use date format as "yyyy-MM-ddH24:mm:ss", e.g. 2022-07-30T15:00:00
+  Now:      Use "tail" to start from now`)
+	k8sStreamCmd.Flags().
+		StringP("template", "t", "",
+			"Rendering Template")
+}
@@ -0,0 +1,106 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"github.com/badaniya/loggo/internal/aws"
+	"github.com/badaniya/loggo/internal/reader"
+	"github.com/badaniya/loggo/internal/util"
+	"github.com/spf13/cobra"
+)
+
+var kinesisStreamCmd = &cobra.Command{
+	Use:   "kinesis-stream",
+	Short: "Continuously stream records from an AWS Kinesis data stream",
+	Long: `Continuously stream records from every shard of an AWS Kinesis data 
+stream. CloudWatch Logs subscription payloads are unzipped and split into one
+log entry per event. Firehose delivery streams cannot be read directly; point
+them to a Kinesis data stream (or an HTTP endpoint) instead.
+
+	loggo kinesis-stream \
+            --stream my-app-logs \
+            --region eu-west-1 \
+            --from 10m
+
+Credentials are taken from the AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY/
+AWS_SESSION_TOKEN environment variables or from the shared credentials file
+(~/.aws/credentials) for the selected profile.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		util.Log().WithField("code", cmd.Flags()).Info("Kinesis Stream Params")
+		stream := cmd.Flag("stream").Value.String()
+		from := cmd.Flag("from").Value.String()
+		profile := cmd.Flag("profile").Value.String()
+		region := aws.Region(cmd.Flag("region").Value.String(), profile)
+		consumer := cmd.Flag("consumer").Value.String()
+		endpoint := cmd.Flag("endpoint").Value.String()
+		templateFile := cmd.Flag("template").Value.String()
+		if len(stream) == 0 {
+			util.Log().Fatal("--stream flag is required.")
+		}
+		if len(region) == 0 {
+			util.Log().Fatal("Unable to determine the AWS region, use --region.")
+		}
+		creds, err := aws.LoadCredentials(profile)
+		if err != nil {
+			util.Log().Fatal("Unable to obtain AWS credentials. ", err)
+		}
+		if from != reader.FromTrimHorizon {
+			from = reader.ParseFrom(from)
+		}
+		client := aws.NewClient(creds, region, "kinesis", "Kinesis_20131202")
+		if len(endpoint) > 0 {
+			client.Endpoint = endpoint
+		}
+		reader := reader.MakeKinesisReader(client, stream, from, consumer, nil)
+		runLoggo(cmd, reader, templateFile)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(kinesisStreamCmd)
+	kinesisStreamCmd.Flags().
+		StringP("stream", "s", "", "Kinesis data stream name (required)")
+	kinesisStreamCmd.Flags().
+		StringP("region", "r", "", "AWS region, defaults to AWS_REGION or the profile region")
+	kinesisStreamCmd.Flags().
+		StringP("profile", "p", "", "AWS shared credentials profile, defaults to AWS_PROFILE or default")
+	kinesisStreamCmd.Flags().
+		StringP("from", "d", "tail",
+			`Start streaming from:
+  Relative: Use format "1s", "1m", "1h" or "1d", where:
+            digit followed by s, m, h, d as second, minute, hour, day.
+  Fixed:    Use date format as "yyyy-MM-ddH24:mm:ss", e.g. 2022-07-30T15:00:00
+  Oldest:   Use "trim-horizon" to start from the oldest retained record
+  Now:      Use "tail" to start from now`)
+	kinesisStreamCmd.Flags().
+		StringP("consumer", "c", "",
+			`Use enhanced fan-out with the given consumer name. The consumer is
+registered if needed (and deregistered on exit), granting a dedicated
+throughput per shard instead of polling with GetRecords.`)
+	kinesisStreamCmd.Flags().
+		StringP("endpoint", "", "", "Override the Kinesis endpoint, e.g. http://localhost:4566")
+	kinesisStreamCmd.Flags().
+		StringP("template", "t", "",
+			"Rendering Template")
+}
@@ -0,0 +1,89 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/badaniya/loggo/internal/reader"
+	"github.com/spf13/cobra"
+)
+
+var listenCmd = &cobra.Command{
+	Use:   "listen",
+	Short: "Listen for logs pushed by other agents",
+	Long: `Serve an endpoint other log agents or pipelines can push logs to,
+streaming the received entries.`,
+}
+
+var listenHTTPCmd = &cobra.Command{
+	Use:   "http",
+	Short: "Receive NDJSON logs over HTTP (Vector/Fluent Bit HTTP sinks)",
+	Long: `Serve an HTTP endpoint accepting newline delimited JSON or JSON array
+batches, optionally gzip encoded, as sent by the Vector and Fluent Bit HTTP
+sinks:
+
+	loggo listen http --port 8080
+
+Vector:
+
+	[sinks.loggo]
+	type = "http"
+	inputs = ["my_source"]
+	uri = "http://localhost:8080/"
+	compression = "gzip"
+	encoding.codec = "json"
+
+Fluent Bit:
+
+	[OUTPUT]
+	    Name     http
+	    Match    *
+	    Host     localhost
+	    Port     8080
+	    Format   json_lines
+	    Compress gzip
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		host := cmd.Flag("host").Value.String()
+		port := cmd.Flag("port").Value.String()
+		path := cmd.Flag("path").Value.String()
+		templateFile := cmd.Flag("template").Value.String()
+		reader := reader.MakeHTTPReader(fmt.Sprintf("%s:%s", host, port), path, nil)
+		runLoggo(cmd, reader, templateFile)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(listenCmd)
+	listenCmd.AddCommand(listenHTTPCmd)
+	listenHTTPCmd.Flags().
+		IntP("port", "p", 8080, "Port to listen on")
+	listenHTTPCmd.Flags().
+		StringP("host", "", "localhost", "Interface to listen on, use 0.0.0.0 to accept remote agents")
+	listenHTTPCmd.Flags().
+		StringP("path", "", "/", "Request path logs are posted to")
+	listenHTTPCmd.Flags().
+		StringP("template", "t", "",
+			"Rendering Template")
+}
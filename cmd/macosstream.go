@@ -0,0 +1,82 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"runtime"
+
+	"github.com/badaniya/loggo/internal/reader"
+	"github.com/badaniya/loggo/internal/util"
+	"github.com/spf13/cobra"
+)
+
+var macOSStreamCmd = &cobra.Command{
+	Use:   "macos-stream",
+	Short: "Continuously stream the macOS unified log",
+	Long: `Continuously stream the macOS unified log (what Console.app shows),
+wrapping 'log stream --style ndjson'. Predicates follow the 'log' CLI syntax,
+see 'log help predicates':
+
+	loggo macos-stream \
+            --process Safari \
+            --predicate 'eventMessage CONTAINS[c] "error"' \
+            --level info \
+            --from 10m
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if runtime.GOOS != "darwin" {
+			util.Log().Fatal("macos-stream is only available on macOS.")
+		}
+		predicate := cmd.Flag("predicate").Value.String()
+		process := cmd.Flag("process").Value.String()
+		subsystem := cmd.Flag("subsystem").Value.String()
+		level := cmd.Flag("level").Value.String()
+		from := cmd.Flag("from").Value.String()
+		templateFile := cmd.Flag("template").Value.String()
+		logArgs := reader.MacOSLogArgs(predicate, process, subsystem, level)
+		reader := reader.MakeMacOSReader(logArgs, reader.ParseFrom(from), nil)
+		runLoggo(cmd, reader, templateFile)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(macOSStreamCmd)
+	macOSStreamCmd.Flags().
+		StringP("predicate", "p", "", "Filter entries with a 'log' predicate expression")
+	macOSStreamCmd.Flags().
+		StringP("process", "", "", "Only show entries from the given process name")
+	macOSStreamCmd.Flags().
+		StringP("subsystem", "s", "", "Only show entries from the given subsystem, e.g. com.apple.network")
+	macOSStreamCmd.Flags().
+		StringP("level", "l", "", "Include entries up to the given level: default, info or debug")
+	macOSStreamCmd.Flags().
+		StringP("from", "d", "tail",
+			`Start streaming from:
+  Relative: Use format "1s", "1m", "1h" or "1d", where:
+            digit followed by s, m, h, d as second, minute, hour, day.
+  Fixed:    Use date format as "yyyy-MM-ddH24:mm:ss", e.g. 2022-07-30T15:00:00
+  Now:      Use "tail" to start from now`)
+	macOSStreamCmd.Flags().
+		StringP("template", "t", "",
+			"Rendering Template")
+}
@@ -0,0 +1,81 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"os"
+	"time"
+
+	"github.com/badaniya/loggo/internal/reader"
+	"github.com/badaniya/loggo/internal/util"
+	"github.com/spf13/cobra"
+)
+
+// ringExportCmd represents the ring-export command
+var ringExportCmd = &cobra.Command{
+	Use:   "ring-export",
+	Short: "Export the stream recorded with --record-ring",
+	Long: `Exports, oldest first, the raw lines recorded into the ring file by
+streaming with --record-ring, optionally only the ones received lately. For
+example, to keep the latest 500 MiB of a stream and later export or browse its
+last half hour:
+
+	loggo stream --file <file-path> --record-ring 500
+	loggo ring-export --since 30m --output incident.log
+	loggo ring-export --since 30m | loggo stream`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ringFile := cmd.Flag("record-file").Value.String()
+		output := cmd.Flag("output").Value.String()
+		var since time.Time
+		if s := cmd.Flag("since").Value.String(); len(s) > 0 {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				util.Log().Fatal("Invalid since duration: ", err)
+			}
+			since = time.Now().Add(-d)
+		}
+		w := os.Stdout
+		if len(output) > 0 {
+			if util.ReadOnly() {
+				exitReadOnly("--output")
+			}
+			f, err := os.Create(output)
+			if err != nil {
+				util.Log().Fatal("Unable to create output file: ", err)
+			}
+			defer f.Close()
+			w = f
+		}
+		if err := reader.ExportRing(ringFile, since, w); err != nil {
+			util.Log().Fatal("Unable to export the ring file: ", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(ringExportCmd)
+	ringExportCmd.Flags().
+		StringP("since", "s", "", "Only export the lines received within the given duration, e.g. 30m")
+	ringExportCmd.Flags().
+		StringP("output", "o", "", "Output file, the standard output if omitted")
+}
@@ -23,10 +23,24 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/badaniya/loggo/internal/color"
+	"github.com/badaniya/loggo/internal/filter"
+	"github.com/badaniya/loggo/internal/i18n"
 	"github.com/badaniya/loggo/internal/loggo"
+	"github.com/badaniya/loggo/internal/metrics"
+	"github.com/badaniya/loggo/internal/reader"
+	"github.com/badaniya/loggo/internal/util"
+	"github.com/badaniya/loggo/internal/web"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -35,6 +49,28 @@ var rootCmd = &cobra.Command{
 	Short: "Stream json logs as rich TUI",
 	Long: `l'oGGo provides a rich Terminal User Interface for streaming json based
 logs and a toolset to assist you tailoring the display format.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		applyFlagDefaults(cmd)
+		util.SetDebug(cmd.Flag("debug").Value.String() == "true")
+		util.SetReadOnly(cmd.Flag("read-only").Value.String() == "true")
+		applyTheme(cmd)
+		applyLocale(cmd)
+		applyResourceFlags(cmd)
+		applyRetryPolicy(cmd)
+		applyMatching(cmd)
+		if err := loggo.LoadKeyMap(loggo.KeysFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid keymap: %v\n", err)
+			os.Exit(1)
+		}
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		if stopProfiling == nil {
+			return
+		}
+		if err := stopProfiling(); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to write the profiles: %v\n", err)
+		}
+	},
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	// Run: func(cmd *cobra.Command, args []string) { },
@@ -50,12 +86,343 @@ func Initiate() {
 	}
 }
 
+// applyFlagDefaults sets the flags not given on the command line from the
+// LOGGO_* environment variables or the flag defaults file, see
+// util.FlagDefaults.
+func applyFlagDefaults(cmd *cobra.Command) {
+	file := defaultsFile()
+	b, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Unable to read the flag defaults: %v\n", err)
+		os.Exit(1)
+	}
+	defaults, err := util.FlagDefaults(cmd.Name(), os.Environ(), b)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid flag defaults in %s: %v\n", file, err)
+		os.Exit(1)
+	}
+	for name, value := range defaults {
+		if f := cmd.Flags().Lookup(name); f == nil || f.Changed {
+			continue
+		}
+		if err := cmd.Flags().Set(name, value); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid default for --%s: %v\n", name, err)
+			os.Exit(1)
+		}
+	}
+}
+
+// applyTheme sets the --theme the UI is rendered with, or the mono one with
+// --no-color, or whenever the NO_COLOR environment variable is set unless a
+// theme is given, see https://no-color.org.
+func applyTheme(cmd *cobra.Command) {
+	theme := cmd.Flag("theme")
+	name := theme.Value.String()
+	if cmd.Flag("no-color").Value.String() == "true" || len(os.Getenv("NO_COLOR")) > 0 && !theme.Changed {
+		name = string(color.ThemeMono)
+	}
+	if err := color.SetTheme(name); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --theme: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// applyLocale selects the --locale the UI is translated to, or that of the
+// environment, e.g. LANG, falling back to English when there's no catalog for
+// it. Catalogs in ~/.loggo/locales extend or override the embedded ones.
+func applyLocale(cmd *cobra.Command) {
+	locale := cmd.Flag("locale")
+	name := locale.Value.String()
+	if len(name) == 0 {
+		name = i18n.DetectLocale(os.Environ())
+	}
+	dir := ""
+	if home, err := os.UserHomeDir(); err == nil {
+		dir = filepath.Join(home, ".loggo", "locales")
+	}
+	err := i18n.SetLocale(name, dir)
+	if errors.Is(err, i18n.ErrUnknownLocale) && !locale.Changed {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --locale: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// stopProfiling stops recording the profiles requested by --cpu-profile and
+// --mem-profile, writing them, if any.
+var stopProfiling func() error
+
+// applyResourceFlags bounds the memory to --mem-limit and the entries to
+// those received within --retain, and starts recording the profiles
+// requested by --cpu-profile and --mem-profile.
+func applyResourceFlags(cmd *cobra.Command) {
+	memLimit, err := strconv.Atoi(cmd.Flag("mem-limit").Value.String())
+	if err != nil {
+		util.Log().Fatal("Invalid memory limit: ", err)
+	}
+	util.SetMemoryLimit(int64(memLimit) << 20)
+	retain, err := time.ParseDuration(cmd.Flag("retain").Value.String())
+	if err != nil || retain < 0 {
+		fmt.Fprintf(os.Stderr, "Invalid --retain: %s, e.g. 30m\n", cmd.Flag("retain").Value.String())
+		os.Exit(1)
+	}
+	util.SetRetention(retain)
+	cpuFile, memFile := cmd.Flag("cpu-profile").Value.String(), cmd.Flag("mem-profile").Value.String()
+	if len(cpuFile) == 0 && len(memFile) == 0 {
+		return
+	}
+	if util.ReadOnly() {
+		exitReadOnly("--cpu-profile/--mem-profile")
+	}
+	if stopProfiling, err = util.StartProfiling(cpuFile, memFile); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to profile: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// applyRetryPolicy overrides the retry policy of network readers with
+// --retries, --retry-backoff and --retry-max-backoff, which can be set per
+// source in the command sections of the flag defaults file.
+func applyRetryPolicy(cmd *cobra.Command) {
+	attempts, err := cmd.Flags().GetInt("retries")
+	if err != nil {
+		util.Log().Fatal("Invalid retries: ", err)
+	}
+	initial, err := cmd.Flags().GetDuration("retry-backoff")
+	if err != nil {
+		util.Log().Fatal("Invalid retry backoff: ", err)
+	}
+	maximum, err := cmd.Flags().GetDuration("retry-max-backoff")
+	if err != nil {
+		util.Log().Fatal("Invalid retry max backoff: ", err)
+	}
+	reader.RetryOverride = reader.RetryPolicy{Initial: initial, Max: maximum, Attempts: attempts}
+}
+
+// applyMatching sets how filters and searches match free text and terms, as
+// in ripgrep: smart case unless --ignore-case or --case-sensitive, and whole
+// words only with --word-regexp.
+func applyMatching(cmd *cobra.Command) {
+	var m filter.Matching
+	ignore := cmd.Flag("ignore-case").Value.String() == "true"
+	sensitive := cmd.Flag("case-sensitive").Value.String() == "true"
+	switch {
+	case ignore && sensitive:
+		fmt.Fprintln(os.Stderr, "--ignore-case and --case-sensitive are mutually exclusive")
+		os.Exit(1)
+	case ignore:
+		m.Case = filter.IgnoreCase
+	case sensitive:
+		m.Case = filter.MatchCase
+	}
+	m.WholeWord = cmd.Flag("word-regexp").Value.String() == "true"
+	filter.SetMatching(m)
+}
+
+// defaultsFile is the flag defaults file, ~/.loggo/config.yaml unless
+// overridden by LOGGO_CONFIG.
+func defaultsFile() string {
+	if file := os.Getenv(util.DefaultsFileEnv); len(file) > 0 {
+		return file
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".loggo", "config.yaml")
+}
+
+// runLoggo starts the metrics endpoint and the ring file recording when
+// requested and then either runs the TUI over the reader or, in headless mode,
+// just consumes the stream.
+func runLoggo(cmd *cobra.Command, r reader.Reader, templateFile string) {
+	if openStream != nil {
+		openStream(r, templateFile)
+		return
+	}
+	metricsAddr := cmd.Flag("metrics-addr").Value.String()
+	headless := cmd.Flag("headless").Value.String() == "true"
+	if headless && len(metricsAddr) == 0 {
+		util.Log().Fatal("--headless requires --metrics-addr")
+	}
+	ringSize, err := strconv.Atoi(cmd.Flag("record-ring").Value.String())
+	if err != nil {
+		util.Log().Fatal("Invalid record ring size: ", err)
+	}
+	if ringSize > 0 {
+		if util.ReadOnly() {
+			exitReadOnly("--record-ring")
+		}
+		ring, err := reader.OpenRingFile(cmd.Flag("record-file").Value.String(), int64(ringSize)<<20)
+		if err != nil {
+			util.Log().Fatal("Unable to open the ring file: ", err)
+		}
+		r = reader.WithRecording(r, ring)
+	}
+	if len(metricsAddr) > 0 {
+		go func() {
+			if err := metrics.Serve(metricsAddr); err != nil {
+				util.Log().Fatal("Unable to serve metrics: ", err)
+			}
+		}()
+	}
+	if headless {
+		if err := metrics.RunHeadless(r); err != nil {
+			util.Log().Fatal(err)
+		}
+		printSummary(cmd)
+		return
+	}
+	webAddr := cmd.Flag("web-addr").Value.String()
+	var broadcast *reader.Broadcast
+	if len(webAddr) > 0 {
+		broadcast = reader.MakeBroadcast(r, web.Backlog)
+		if err := broadcast.Start(); err != nil {
+			util.Log().Fatal("Unable to start stream: ", err)
+		}
+		r = broadcast.Subscribe()
+	}
+	app := loggo.NewLoggoApp(r, templateFile)
+	if broadcast != nil {
+		mirror := web.MakeMirror(broadcast, app.Config())
+		app.OnFilterChange(mirror.SetFilter)
+		go func() {
+			if err := mirror.Serve(webAddr); err != nil {
+				util.Log().Fatal("Unable to serve the web view: ", err)
+			}
+		}()
+	}
+	notifyOptions(cmd, app, sourceName(cmd))
+	if f := cmd.Flags().Lookup("tutorial"); f != nil && f.Value.String() == "true" {
+		app.EnableTutorial()
+	} else if cmd.Flag("remember").Value.String() == "true" {
+		if source := sourceSignature(cmd); len(source) > 0 {
+			app.RememberState(source)
+		}
+	}
+	app.Run()
+	printSummary(cmd)
+}
+
+// summaryTopErrors is the number of grouped errors listed by --summary.
+const summaryTopErrors = 5
+
+// printSummary prints the session statistics to stdout if --summary is set.
+func printSummary(cmd *cobra.Command) {
+	if cmd.Flag("summary").Value.String() != "true" {
+		return
+	}
+	if err := metrics.Default().WriteSummary(os.Stdout, summaryTopErrors); err != nil {
+		util.Log().WithField("code", err).Error("Unable to print the session summary")
+	}
+}
+
+// exitReadOnly aborts the command attempting to persist state in read-only
+// mode.
+func exitReadOnly(what string) {
+	fmt.Fprintf(os.Stderr, "%s is %v\n", what, util.ErrReadOnly)
+	os.Exit(1)
+}
+
+// notifyOptions applies the --term-title and --bell flags to the app.
+func notifyOptions(cmd *cobra.Command, app *loggo.LoggoApp, source string) {
+	if cmd.Flag("term-title").Value.String() == "true" {
+		app.EnableTerminalTitle(source)
+	}
+	if cmd.Flag("bell").Value.String() == "true" {
+		app.EnableBell()
+	}
+}
+
+// sourceName names the streamed source after the command and its input file,
+// if any, e.g. "stream app.log".
+func sourceName(cmd *cobra.Command) string {
+	if f := cmd.Flags().Lookup("file"); f != nil && len(f.Value.String()) > 0 {
+		return cmd.Name() + " " + filepath.Base(f.Value.String())
+	}
+	return cmd.Name()
+}
+
+// sourceSignature identifies the streamed source by the command and the
+// input flags set, e.g. the absolute path of the file or the GCP project and
+// filter, so its UI state is remembered across runs. The standard input has
+// no signature, its content varying every time.
+func sourceSignature(cmd *cobra.Command) string {
+	if f := cmd.Flags().Lookup("file"); f != nil && len(f.Value.String()) == 0 {
+		return ""
+	}
+	signature := []string{cmd.CommandPath()}
+	cmd.LocalNonPersistentFlags().VisitAll(func(f *pflag.Flag) {
+		if !f.Changed || f.Name == "template" {
+			return
+		}
+		value := f.Value.String()
+		if f.Name == "file" {
+			if abs, err := filepath.Abs(value); err == nil {
+				value = abs
+			}
+		}
+		signature = append(signature, fmt.Sprintf("--%s=%s", f.Name, value))
+	})
+	return strings.Join(signature, " ")
+}
+
 func init() {
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 
 	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.loggo.yaml)")
+	rootCmd.PersistentFlags().String("metrics-addr", "",
+		`Optional address (e.g. ":9090") to expose Prometheus stream metrics at /metrics`)
+	rootCmd.PersistentFlags().String("web-addr", "",
+		`Optional address (e.g. ":8080") to mirror the live stream read-only as a web page, e.g. for an incident call`)
+	rootCmd.PersistentFlags().Bool("debug", false,
+		"Increase l'oGGo's own log verbosity, see the internals tab (^d) or the debug command")
+	rootCmd.PersistentFlags().Bool("headless", false,
+		"Consume the stream without the TUI, only feeding the metrics endpoint (requires --metrics-addr)")
+	rootCmd.PersistentFlags().Int("record-ring", 0,
+		"Continuously record the latest given MiB of the stream into a ring file, see the ring-export command")
+	rootCmd.PersistentFlags().String("record-file", loggo.RingFile,
+		"The ring file used by --record-ring")
+	rootCmd.PersistentFlags().Bool("term-title", false,
+		"Keep the terminal title updated with the source and its number of ERROR entries")
+	rootCmd.PersistentFlags().Bool("bell", false,
+		"Ring the terminal bell when the stream disconnects")
+	rootCmd.PersistentFlags().Bool("summary", false,
+		"On exit, print a summary of the session: duration, lines, parse failures, severities and top errors")
+	rootCmd.PersistentFlags().Bool("read-only", false,
+		"Disable template editing, exports and any action persisting state, e.g. on shared bastions")
+	rootCmd.PersistentFlags().String("theme", string(color.ThemeDefault),
+		"Render the UI with the default, high-contrast or mono theme, the latter two flagging severities with symbols")
+	rootCmd.PersistentFlags().Bool("no-color", false,
+		"Render the UI without colors, same as --theme mono")
+	rootCmd.PersistentFlags().String("locale", "",
+		"Translate the UI to the given locale, e.g. pt_BR, instead of that of LANG; catalogs in ~/.loggo/locales extend the bundled ones")
+	rootCmd.PersistentFlags().Int("mem-limit", 0,
+		"Bound l'oGGo's memory to the given MB, evicting the oldest entries as it's neared, e.g. for streams open all day")
+	rootCmd.PersistentFlags().Duration("retain", 0,
+		"Keep only the entries received within the given period, e.g. 30m, evicting older ones alongside --mem-limit")
+	rootCmd.PersistentFlags().String("cpu-profile", "",
+		"Debug: record a CPU profile into the given file until exit, see go tool pprof")
+	rootCmd.PersistentFlags().String("mem-profile", "",
+		"Debug: write a heap profile into the given file on exit, see go tool pprof")
+	rootCmd.PersistentFlags().Int("retries", 0,
+		"Give up on a network source after the given consecutive failures, instead of its own policy, e.g. 10 for most")
+	rootCmd.PersistentFlags().Duration("retry-backoff", 0,
+		"Pause after a network source first fails, doubled upon each consecutive failure, instead of its own policy")
+	rootCmd.PersistentFlags().Duration("retry-max-backoff", 0,
+		"Longest pause between retries of a failing network source, instead of its own policy")
+	rootCmd.PersistentFlags().Bool("ignore-case", false,
+		"Match quoted texts, terms and searches ignoring case, rather than only when all lower case (smart case)")
+	rootCmd.PersistentFlags().Bool("case-sensitive", false,
+		"Match quoted texts, terms and searches matching case, rather than only when holding upper case (smart case)")
+	rootCmd.PersistentFlags().Bool("word-regexp", false,
+		"Match quoted texts, terms and searches as whole words only, e.g. \"error\" not matching \"errors\"")
+	rootCmd.PersistentFlags().Bool("remember", false,
+		"Reopen the same source where you left off: scroll position, filter, columns and detail pane")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
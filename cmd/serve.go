@@ -0,0 +1,94 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"github.com/badaniya/loggo/internal/metrics"
+	"github.com/badaniya/loggo/internal/server"
+	"github.com/badaniya/loggo/internal/util"
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve <server config file>",
+	Short: "Host curated streams for SSH sessions",
+	Long: `Run a long-lived server ingesting the configured streams and serving the
+TUI over its built-in SSH server, so a shared team instance can host curated
+log streams with their templates pre-loaded. Sessions pick a stream by name
+and replay its latest lines:
+
+	loggo serve /etc/loggo/server.yaml
+
+	ssh -t -p 2222 loggo.internal api
+
+Where the config file looks like:
+
+	ssh:
+	  addr: :2222
+	  hostKey: /etc/loggo/ssh_host_ed25519_key
+	  authorizedKeys: /etc/loggo/authorized_keys
+	backlog: 5000
+	detachTimeout: 12h
+	streams:
+	  - name: api
+	    command: kubectl logs -f deploy/api -n shop
+	    template: /etc/loggo/api.yaml
+	  - name: nginx
+	    file: /var/log/nginx/access.log
+
+Sessions are read-only: templates and files on the server can't be changed.
+Detaching with Ctrl-\ or dropping the connection leaves the session running,
+and the next ssh with the same key to the same stream reattaches to it.
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		config, err := server.LoadConfig(args[0])
+		if err != nil {
+			util.Log().Fatal("Invalid server config: ", err)
+		}
+		if addr := cmd.Flag("addr").Value.String(); len(addr) > 0 {
+			config.SSH.Addr = addr
+		}
+		util.SetReadOnly(true)
+		if metricsAddr := cmd.Flag("metrics-addr").Value.String(); len(metricsAddr) > 0 {
+			go func() {
+				if err := metrics.Serve(metricsAddr); err != nil {
+					util.Log().Fatal("Unable to serve metrics: ", err)
+				}
+			}()
+		}
+		s, err := server.MakeServer(config)
+		if err != nil {
+			util.Log().Fatal("Unable to set up the SSH server: ", err)
+		}
+		if err := s.Serve(); err != nil {
+			util.Log().Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().
+		StringP("addr", "a", "", `SSH listen address, overriding the config file's (default ":2222")`)
+}
@@ -0,0 +1,92 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"strconv"
+
+	"github.com/badaniya/loggo/internal/reader"
+	"github.com/badaniya/loggo/internal/util"
+	"github.com/spf13/cobra"
+)
+
+var splunkStreamCmd = &cobra.Command{
+	Use:   "splunk-stream",
+	Short: "Continuously stream Splunk search results",
+	Long: `Run a Splunk export search against the management endpoint and keep
+streaming new events through a real-time search:
+
+	loggo splunk-stream \
+            --url https://splunk.mycompany.com:8089 \
+            --index web --sourcetype access_combined \
+            --query 'status>=500' \
+            --from 1h
+
+The authentication token is read from SPLUNK_TOKEN unless provided as a flag.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		baseURL := envOr(cmd.Flag("url").Value.String(), "SPLUNK_URL", "")
+		token := envOr(cmd.Flag("token").Value.String(), "SPLUNK_TOKEN", "")
+		index := cmd.Flag("index").Value.String()
+		sourceType := cmd.Flag("sourcetype").Value.String()
+		query := cmd.Flag("query").Value.String()
+		from := cmd.Flag("from").Value.String()
+		insecure, _ := strconv.ParseBool(cmd.Flag("insecure").Value.String())
+		templateFile := cmd.Flag("template").Value.String()
+		if len(baseURL) == 0 {
+			util.Log().Fatal("--url flag is required.")
+		}
+		if len(token) == 0 {
+			util.Log().Fatal("A Splunk authentication token is required, see --token.")
+		}
+		search := reader.SplunkSearch(index, sourceType, query)
+		reader := reader.MakeSplunkReader(baseURL, token, search, reader.ParseFrom(from), insecure, nil)
+		runLoggo(cmd, reader, templateFile)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(splunkStreamCmd)
+	splunkStreamCmd.Flags().
+		StringP("url", "u", "", "Splunk management endpoint, e.g. https://splunk:8089 (defaults to SPLUNK_URL)")
+	splunkStreamCmd.Flags().
+		StringP("token", "", "", "Splunk authentication token (defaults to SPLUNK_TOKEN)")
+	splunkStreamCmd.Flags().
+		StringP("index", "i", "", "Splunk index to search")
+	splunkStreamCmd.Flags().
+		StringP("sourcetype", "s", "", "Splunk sourcetype to search")
+	splunkStreamCmd.Flags().
+		StringP("query", "q", "", "Additional SPL search terms, e.g. 'status>=500'")
+	splunkStreamCmd.Flags().
+		StringP("from", "d", "tail",
+			`Start streaming from:
+  Relative: Use format "1s", "1m", "1h" or "1d", where:
+            digit followed by s, m, h, d as second, minute, hour, day.
+  Fixed:    Use date format as "yyyy-MM-ddH24:mm:ss", e.g. 2022-07-30T15:00:00
+  Now:      Use "tail" to start from now (real-time search)`)
+	splunkStreamCmd.Flags().
+		BoolP("insecure", "k", false, "Skip TLS certificate verification (self-signed management ports)")
+	splunkStreamCmd.Flags().
+		StringP("template", "t", "",
+			"Rendering Template")
+}
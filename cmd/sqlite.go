@@ -0,0 +1,150 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/reader"
+	"github.com/badaniya/loggo/internal/sqlite"
+	"github.com/badaniya/loggo/internal/util"
+	"github.com/spf13/cobra"
+)
+
+// keySampleSize is the number of lines the key columns are inferred from when
+// exporting or converting without a template.
+const keySampleSize = 100
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export a captured log into an SQLite database",
+	Long: `Exports the lines of a captured log file (or of the standard input) into
+the entries table of an SQLite database, one row per line with a column per
+template key (inferred from the first lines if no template is given), for ad
+hoc SQL analysis. The sqlite3 command line shell is required. For example:
+
+	loggo export --sqlite incident.db --file incident.log --template template.yaml
+	sqlite3 incident.db 'SELECT level, count(*) FROM entries GROUP BY level'
+	loggo import --sqlite incident.db`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if util.ReadOnly() {
+			exitReadOnly("export")
+		}
+		dbFile := cmd.Flag("sqlite").Value.String()
+		fileName := cmd.Flag("file").Value.String()
+		templateFile := cmd.Flag("template").Value.String()
+		if len(dbFile) == 0 {
+			fmt.Fprintln(os.Stderr, "--sqlite is required")
+			os.Exit(1)
+		}
+		var in io.Reader = os.Stdin
+		if len(fileName) > 0 {
+			f, err := os.Open(fileName)
+			if err != nil {
+				util.Log().Fatal("Unable to open the input file: ", err)
+			}
+			defer f.Close()
+			in = f
+		}
+		var keys []config.Key
+		if len(templateFile) > 0 {
+			cfg, err := config.MakeConfig(templateFile)
+			if err != nil {
+				util.Log().Fatal("Unable to read the template: ", err)
+			}
+			keys = cfg.Keys
+		} else {
+			keys, in = sampleKeys(in)
+		}
+		n, err := sqlite.Export(dbFile, keys, in)
+		if err != nil {
+			util.Log().Fatal("Unable to export into the database: ", err)
+		}
+		fmt.Fprintf(os.Stderr, "Exported %d lines into %s\n", n, dbFile)
+	},
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Browse a log exported into an SQLite database",
+	Long: `Browses, in their original order, the lines previously exported into an
+SQLite database with the export command. The sqlite3 command line shell is
+required. For example:
+
+	loggo import --sqlite incident.db --template template.yaml`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dbFile := cmd.Flag("sqlite").Value.String()
+		templateFile := cmd.Flag("template").Value.String()
+		if len(dbFile) == 0 {
+			fmt.Fprintln(os.Stderr, "--sqlite is required")
+			os.Exit(1)
+		}
+		if _, err := os.Stat(dbFile); err != nil {
+			util.Log().Fatal("Unable to open the database: ", err)
+		}
+		runLoggo(cmd, reader.MakeSQLiteReader(dbFile, nil), templateFile)
+	},
+}
+
+// sampleKeys infers the keys from the first lines of in, returning them along
+// with a reader yielding the whole input again.
+func sampleKeys(in io.Reader) ([]config.Key, io.Reader) {
+	buf := &bytes.Buffer{}
+	br := bufio.NewReader(in)
+	var sample []map[string]interface{}
+	for len(sample) < keySampleSize {
+		line, err := br.ReadBytes('\n')
+		buf.Write(line)
+		m := make(map[string]interface{})
+		if json.Unmarshal(line, &m) == nil {
+			sample = append(sample, m)
+		}
+		if err != nil {
+			break
+		}
+	}
+	cfg, _ := config.MakeConfigFromSample(sample)
+	return cfg.Keys, io.MultiReader(buf, br)
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().
+		StringP("sqlite", "", "", "SQLite database file to export into, replacing any previous export")
+	exportCmd.Flags().
+		StringP("file", "f", "", "Input log file, the standard input if omitted")
+	exportCmd.Flags().
+		StringP("template", "t", "", "Template whose keys become the table columns")
+
+	rootCmd.AddCommand(importCmd)
+	importCmd.Flags().
+		StringP("sqlite", "", "", "SQLite database file exported with the export command")
+	importCmd.Flags().
+		StringP("template", "t", "", "Rendering Template")
+}
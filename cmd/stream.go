@@ -23,8 +23,15 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/badaniya/loggo/internal/format"
 	"github.com/badaniya/loggo/internal/loggo"
 	"github.com/badaniya/loggo/internal/reader"
+	"github.com/badaniya/loggo/internal/util"
 	"github.com/spf13/cobra"
 )
 
@@ -35,16 +42,66 @@ var streamCmd = &cobra.Command{
 	Long: `Continuously stream log entries from an input stream such
 as the standard input (through pipe) or a input file. Note that
 if it's reading from a file, it automatically detects file 
-rotation and continue to stream. For example:
+rotation and continue to stream. Named pipes and unix sockets
+are reopened whenever their writer disconnects. For example:
 
 	loggo stream --file <file-path>
-	<some arbitrary input> | loggo stream`,
+	loggo stream --file /run/app/log.sock
+	<some arbitrary input> | loggo stream
+
+Plain text formats can be parsed into structured entries, e.g.:
+
+	loggo stream --file /var/log/mysql/slow.log --format mysql-slow
+
+A complete file can be replayed at a fixed rate of lines per second, or
+at the pace of the timestamps its entries embed, scaled by speed:
+
+	loggo stream --file app.log --throttle 20
+	loggo stream --file app.log --speed 10
+
+A large file can be opened at its last lines, the older ones being
+loaded as you scroll to the top, like less:
+
+	loggo stream --file app.log --tail-lines 1000
+
+New to l'oGGo? Take the guided tour over a bundled sample log:
+
+	loggo stream --tutorial`,
 	Run: func(cmd *cobra.Command, args []string) {
 		fileName := cmd.Flag("file").Value.String()
 		templateFile := cmd.Flag("template").Value.String()
-		reader := reader.MakeReader(fileName, nil)
-		app := loggo.NewLoggoApp(reader, templateFile)
-		app.Run()
+		formatName := cmd.Flag("format").Value.String()
+		if cmd.Flag("tutorial").Value.String() == "true" {
+			if len(fileName) > 0 {
+				fmt.Fprintln(os.Stderr, "--tutorial and --file are mutually exclusive")
+				os.Exit(1)
+			}
+			runLoggo(cmd, reader.WithThrottle(reader.MakeLinesReader(loggo.TutorialLog(), nil), 0, 1), templateFile)
+			return
+		}
+		tailLines, _ := strconv.Atoi(cmd.Flag("tail-lines").Value.String())
+		if tailLines > 0 && len(fileName) == 0 {
+			fmt.Fprintln(os.Stderr, "--tail-lines requires --file")
+			os.Exit(1)
+		}
+		r := reader.MakeTailReader(fileName, tailLines, nil)
+		if len(formatName) > 0 {
+			parser, err := format.NewParser(formatName)
+			if err != nil {
+				util.Log().Fatal(err)
+			}
+			r = reader.WithFormat(r, parser)
+		}
+		rate, _ := strconv.ParseFloat(cmd.Flag("throttle").Value.String(), 64)
+		speed, _ := strconv.ParseFloat(cmd.Flag("speed").Value.String(), 64)
+		if rate > 0 && speed > 0 {
+			fmt.Fprintln(os.Stderr, "--throttle and --speed are mutually exclusive")
+			os.Exit(1)
+		}
+		if rate > 0 || speed > 0 {
+			r = reader.WithThrottle(r, rate, speed)
+		}
+		runLoggo(cmd, r, templateFile)
 	},
 }
 
@@ -54,4 +111,19 @@ func init() {
 		StringP("file", "f", "", "Input Log File")
 	streamCmd.Flags().
 		StringP("template", "t", "", "Rendering Template")
+	streamCmd.Flags().
+		StringP("format", "", "",
+			fmt.Sprintf("Parse a plain text log format into structured entries, one of: %s",
+				strings.Join(format.Names(), ", ")))
+	streamCmd.Flags().
+		Float64P("throttle", "", 0, "Replay the input at a fixed rate of lines per second")
+	streamCmd.Flags().
+		Float64P("speed", "", 0,
+			"Replay the input at the pace of the entries' timestamps, scaled by speed (e.g. 2 is twice as fast)")
+	streamCmd.Flags().
+		IntP("tail-lines", "", 0,
+			"Start the file at its last lines, loading older ones on demand when scrolling to the top")
+	streamCmd.Flags().
+		BoolP("tutorial", "", false,
+			"Take a guided tour of l'oGGo over a bundled sample log: templates, filtering, entries and auto-scroll")
 }
@@ -23,10 +23,20 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
 	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/filter"
 	"github.com/badaniya/loggo/internal/loggo"
+	"github.com/badaniya/loggo/internal/payload"
 	"github.com/badaniya/loggo/internal/util"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 // templateCmd represents the template command
@@ -42,8 +52,17 @@ To start from an existing file and update or save new from it:
 	loggo template --file <some existing template>
 To start from an example template:
 	loggo template --example=true
+To validate a template (see lint --help):
+	loggo template lint <some existing template>
+To rewrite templates of older loggo versions in the current schema:
+	loggo template migrate <some existing template>
+To convert an lnav format or jq columns into a template (see import --help):
+	loggo template import lnav <some lnav format file>
 `,
 	Run: func(cmd *cobra.Command, args []string) {
+		if util.ReadOnly() {
+			exitReadOnly("Template editing")
+		}
 		templateFile := cmd.Flag("file").Value.String()
 		example := cmd.Flag("example").Value.String() == "true"
 		var cfg *config.Config
@@ -69,12 +88,245 @@ To start from an example template:
 	},
 }
 
+// templateLintCmd represents the template lint command
+var templateLintCmd = &cobra.Command{
+	Use:   "lint <template file>",
+	Short: "Validates a template",
+	Long: `Validates a template: its schema and unknown options, duplicate keys,
+unknown types and colors, color-when patterns and conditions, watches, settings,
+severity mapping and payload decoders. Given a sample log file, it also
+reports how many entries each key of the template actually matches.
+It exits with status 1 when errors are found. For example:
+
+	loggo template lint my-template.yaml
+	loggo template lint my-template.yaml --sample app.log`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		templateFile := args[0]
+		cfg, issues := config.Lint(templateFile)
+		if cfg != nil {
+			issues = append(issues, lintConditions(cfg)...)
+		}
+		failed := false
+		for _, issue := range issues {
+			fmt.Printf("%s: %s\n", templateFile, issue)
+			failed = failed || issue.Error
+		}
+		if len(issues) == 0 {
+			fmt.Printf("%s: OK\n", templateFile)
+		}
+		if sample := cmd.Flag("sample").Value.String(); cfg != nil && len(sample) > 0 {
+			if err := lintSample(cfg, sample); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// templateMigrateCmd represents the template migrate command
+var templateMigrateCmd = &cobra.Command{
+	Use:   "migrate <template file>...",
+	Short: "Rewrites templates in the current schema version",
+	Long: `Rewrites templates written for older loggo versions in the current
+schema version, keeping their comments. Older templates are otherwise migrated
+as they are loaded, their files being left as is until saved. Templates which
+can't be read are reported along with the offending line, and left as is.
+For example:
+
+	loggo template migrate my-template.yaml`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if util.ReadOnly() {
+			exitReadOnly("Template migration")
+		}
+		failed := false
+		for _, templateFile := range args {
+			version, err := config.MigrateFile(templateFile)
+			switch {
+			case err != nil:
+				fmt.Fprintf(os.Stderr, "%s: %v\n", templateFile, err)
+				failed = true
+			case version == config.SchemaVersion:
+				fmt.Printf("%s: already version %d\n", templateFile, version)
+			default:
+				fmt.Printf("%s: migrated from version %d to %d\n", templateFile, version, config.SchemaVersion)
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// templateImportCmd represents the template import command
+var templateImportCmd = &cobra.Command{
+	Use:   "import <lnav|jq> <format file|jq filter>",
+	Short: "Converts an lnav format or jq columns into a template",
+	Long: `Converts the log format definition of an lnav format file, or the columns
+of a jq filter, into a template written to --output, or else printed out.
+What can't be converted is reported, e.g. level patterns or computed columns.
+
+lnav JSON formats lay out their line-format fields, or else their timestamp,
+level, value and body fields, typed per their kind. Regex formats extract their
+named groups out of the text lines. Files declaring several formats need the
+--name of the one to convert.
+
+jq filters lay out their columns as keys, out of an array, object or string
+built, e.g. [.ts, .level, .msg] | @tsv, alternatives (//) becoming fallbacks.
+For example:
+
+	loggo template import lnav payments.json --output payments.yaml
+	loggo template import lnav formats.json --name nginx_error
+	loggo template import jq '[.ts, .level, .msg // .message] | @tsv' -o app.yaml`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		output := cmd.Flag("output").Value.String()
+		if len(output) > 0 && util.ReadOnly() {
+			exitReadOnly("--output")
+		}
+		var cfg *config.Config
+		var notes []string
+		var err error
+		switch strings.ToLower(args[0]) {
+		case "lnav":
+			var b []byte
+			if b, err = os.ReadFile(args[1]); err == nil {
+				cfg, notes, err = config.ImportLnav(b, cmd.Flag("name").Value.String())
+			}
+		case "jq":
+			cfg, notes, err = config.ImportJQ(args[1])
+		default:
+			fmt.Fprintf(os.Stderr, "unknown tool %q, either lnav or jq\n", args[0])
+			os.Exit(1)
+		}
+		for _, note := range notes {
+			fmt.Fprintf(os.Stderr, "note: %s\n", note)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to import: %v\n", err)
+			os.Exit(1)
+		}
+		if len(output) > 0 {
+			if err := cfg.Save(output); err != nil {
+				fmt.Fprintf(os.Stderr, "Unable to save the template: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("%s: %d keys\n", output, len(cfg.Keys))
+			return
+		}
+		b, err := yaml.Marshal(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to import: %v\n", err)
+			os.Exit(1)
+		}
+		_, _ = os.Stdout.Write(b)
+	},
+}
+
+// lintConditions checks what the config package can't: color-when and watch
+// conditions, and payload decoders.
+func lintConditions(cfg *config.Config) []config.LintIssue {
+	var issues []config.LintIssue
+	for _, k := range cfg.Keys {
+		for i, cw := range k.ColorWhen {
+			if len(cw.When) == 0 {
+				continue
+			}
+			if _, err := filter.Compile(cw.When); err != nil {
+				issues = append(issues, config.LintIssue{
+					Error:   true,
+					Message: fmt.Sprintf("key %q color-when #%d when: %v", k.Name, i+1, err),
+				})
+			}
+		}
+	}
+	for i, w := range cfg.Watch {
+		if len(w.When) == 0 {
+			continue
+		}
+		if _, err := filter.Compile(w.When); err != nil {
+			issues = append(issues, config.LintIssue{
+				Error:   true,
+				Message: fmt.Sprintf("watch #%d when: %v", i+1, err),
+			})
+		}
+	}
+	if _, err := payload.MakeFieldDecoders(cfg.Decoders); err != nil {
+		issues = append(issues, config.LintIssue{Error: true, Message: fmt.Sprintf("decoders: %v", err)})
+	}
+	return issues
+}
+
+// lintSample reports how many entries of the sample log file each key of the
+// template matches.
+func lintSample(cfg *config.Config, sample string) error {
+	f, err := os.Open(sample)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	coverage := config.NewKeyCoverage()
+	entries, notJSON := 0, 0
+	br := bufio.NewReader(f)
+	for {
+		line, rerr := br.ReadString('\n')
+		if line = strings.TrimSpace(line); len(line) > 0 {
+			m := make(map[string]interface{})
+			if err := json.Unmarshal([]byte(line), &m); err != nil {
+				notJSON++
+			} else {
+				entries++
+				coverage.Observe(cfg.Keys, m)
+			}
+		}
+		if rerr == io.EOF {
+			break
+		} else if rerr != nil {
+			return rerr
+		}
+	}
+	fmt.Printf("%s: %d entries", sample, entries)
+	if notJSON > 0 {
+		fmt.Printf(", %d lines not JSON", notJSON)
+	}
+	fmt.Println()
+	if entries == 0 {
+		return nil
+	}
+	missing := make(map[string]int64)
+	for _, d := range coverage.Report() {
+		missing[d.Name] = d.Missing
+	}
+	for _, k := range cfg.Keys {
+		matched := int64(entries) - missing[k.Name]
+		fmt.Printf("  %-40s %8d %6.1f%%", k.Name, matched, float64(matched)*100/float64(entries))
+		if matched == 0 {
+			fmt.Print("  never matched")
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(templateCmd)
+	templateCmd.AddCommand(templateLintCmd)
+	templateCmd.AddCommand(templateMigrateCmd)
+	templateCmd.AddCommand(templateImportCmd)
 
 	templateCmd.Flags().
 		StringP("file", "f", "", "Input Template File")
 	templateCmd.Flags().
 		StringP("example", "e", "", "Load example log template. "+
 			"If `file` flag provided this flag is ignored.")
+	templateLintCmd.Flags().
+		StringP("sample", "s", "", "Sample log file to match the template keys against")
+	templateImportCmd.Flags().
+		StringP("output", "o", "", "Template file to write, printed out if unset")
+	templateImportCmd.Flags().
+		StringP("name", "n", "", "Format to convert, out of the lnav file declaring several")
 }
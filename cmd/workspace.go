@@ -0,0 +1,142 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/badaniya/loggo/internal/loggo"
+	"github.com/badaniya/loggo/internal/reader"
+	"github.com/badaniya/loggo/internal/util"
+	"github.com/badaniya/loggo/internal/workspace"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// openStream, when set, is handed the stream runLoggo would otherwise run, so
+// the workspace tabs can gather the streams of several commands in one app.
+var openStream func(r reader.Reader, templateFile string)
+
+var workspaceCmd = &cobra.Command{
+	Use:   "workspace <name>",
+	Short: "Launch a workspace of streams side by side",
+	Long: `Launch the streams of a workspace as tmux panes (or windows), each running
+its own l'oGGo, rather than setting up the same panes by hand every time. The
+workspace is read from ~/.loggo/workspaces/<name>.yaml, or from the given YAML
+file:
+
+	loggo workspace checkout
+
+Where the workspace file looks like:
+
+	layout: tiled
+	streams:
+	  - name: api
+	    args: [k8s-stream, deploy/api, -n, shop, -t, /home/me/api.yaml]
+	  - name: payments
+	    args: [gcp-stream, --params-load, payments]
+	  - name: nginx
+	    args: [stream, -f, /var/log/nginx/access.log]
+
+The layout is one of tiled, even-horizontal, even-vertical, main-horizontal or
+main-vertical, as the panes of a single window, or windows for a window per
+stream. Launching a workspace whose session is still running rejoins it. In
+iTerm2, the session is attached in tmux control mode for native tabs and
+splits.
+
+Without tmux, or with --tabs, the streams open as tabs of a single l'oGGo
+instead.
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		w, err := workspace.Load(args[0])
+		if err != nil {
+			util.Log().Fatal("Invalid workspace: ", err)
+		}
+		tabs := cmd.Flag("tabs").Value.String() == "true"
+		var tmux *workspace.Tmux
+		if !tabs {
+			exe, err := os.Executable()
+			if err != nil {
+				util.Log().Fatal("Unable to locate the loggo executable: ", err)
+			}
+			tmux = workspace.MakeTmux(exe)
+		}
+		if tmux == nil {
+			runWorkspaceTabs(w)
+			return
+		}
+		if err := tmux.Launch(w); err != nil {
+			util.Log().Fatal("Unable to launch the workspace: ", err)
+		}
+	},
+}
+
+// runWorkspaceTabs runs the commands of the workspace streams, opening their
+// streams as tabs of one app rather than running them.
+func runWorkspaceTabs(w *workspace.Workspace) {
+	var app *loggo.LoggoApp
+	for _, s := range w.Streams {
+		var r reader.Reader
+		templateFile := ""
+		openStream = func(sr reader.Reader, tf string) {
+			r, templateFile = sr, tf
+		}
+		rootCmd.SetArgs(s.Args)
+		c, err := rootCmd.ExecuteC()
+		if err != nil {
+			util.Log().Fatal("Unable to open stream ", s.Name, ": ", err)
+		}
+		resetFlags(c)
+		if r == nil {
+			util.Log().Fatal("Stream ", s.Name, " does not run a live stream")
+		}
+		if app == nil {
+			app = loggo.NewLoggoApp(r, templateFile)
+			app.NameStream(s.Name)
+		} else if err := app.AddStream(s.Name, r, templateFile); err != nil {
+			util.Log().Fatal("Unable to load the template of stream ", s.Name, ": ", err)
+		}
+	}
+	openStream = nil
+	app.Run()
+}
+
+// resetFlags restores the command flags to their defaults, as cobra keeps the
+// values parsed by a previous run of the same command.
+func resetFlags(cmd *cobra.Command) {
+	cmd.LocalFlags().VisitAll(func(f *pflag.Flag) {
+		if s, ok := f.Value.(pflag.SliceValue); ok {
+			_ = s.Replace(nil)
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(workspaceCmd)
+	workspaceCmd.Flags().
+		Bool("tabs", false, "Open the streams as tabs of a single l'oGGo, even when tmux is installed")
+}
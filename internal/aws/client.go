@@ -0,0 +1,128 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Client is a minimal AWS JSON protocol client (e.g. Kinesis, CloudWatch Logs)
+// signing every request with SigV4.
+type Client struct {
+	Credentials  *Credentials
+	Region       string
+	Service      string
+	Endpoint     string
+	TargetPrefix string
+	HTTP         *http.Client
+}
+
+// APIError is returned when AWS answers with a non 2xx status code.
+type APIError struct {
+	Status  int
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("aws: %s (%d): %s", e.Type, e.Status, e.Message)
+}
+
+// NewClient builds a client for the given service, using the default
+// regional endpoint, e.g. https://kinesis.eu-west-1.amazonaws.com.
+func NewClient(creds *Credentials, region, service, targetPrefix string) *Client {
+	return &Client{
+		Credentials:  creds,
+		Region:       region,
+		Service:      service,
+		Endpoint:     fmt.Sprintf("https://%s.%s.amazonaws.com", service, region),
+		TargetPrefix: targetPrefix,
+		HTTP:         http.DefaultClient,
+	}
+}
+
+// Call invokes the given action, encoding in as the JSON body and decoding the
+// response into out.
+func (c *Client) Call(ctx context.Context, action string, in, out interface{}) error {
+	resp, err := c.Do(ctx, action, in)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Do invokes the given action and returns the raw response, so streaming
+// actions (e.g. SubscribeToShard) can consume the body incrementally.
+func (c *Client) Do(ctx context.Context, action string, in interface{}) (*http.Response, error) {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", c.TargetPrefix+"."+action)
+	Sign(req, body, c.Credentials, c.Region, c.Service, time.Now())
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		apiErr := &APIError{Status: resp.StatusCode}
+		b, _ := io.ReadAll(resp.Body)
+		if err := json.Unmarshal(b, apiErr); err != nil || len(apiErr.Type) == 0 {
+			apiErr.Message = string(b)
+		}
+		return nil, apiErr
+	}
+	return resp, nil
+}
+
+// IsErrorType reports whether err is an APIError of the given type, e.g.
+// "ExpiredIteratorException". AWS may prefix the type with a namespace.
+func IsErrorType(err error, errType string) bool {
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		return false
+	}
+	t := apiErr.Type
+	if i := strings.LastIndexByte(t, '#'); i >= 0 {
+		t = t[i+1:]
+	}
+	return t == errType
+}
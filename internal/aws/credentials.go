@@ -0,0 +1,140 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package aws
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// LoadCredentials resolves the AWS credentials from the standard environment
+// variables, falling back to the shared credentials file for the given profile
+// (or AWS_PROFILE/"default" when empty).
+func LoadCredentials(profile string) (*Credentials, error) {
+	if len(profile) == 0 {
+		if id := os.Getenv("AWS_ACCESS_KEY_ID"); len(id) > 0 {
+			return &Credentials{
+				AccessKeyID:     id,
+				SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+				SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+			}, nil
+		}
+	}
+	profile = profileName(profile)
+	file := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if len(file) == 0 {
+		file = path.Join(awsDir(), "credentials")
+	}
+	sections, err := readIni(file)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := sections[profile]
+	if !ok || len(s["aws_access_key_id"]) == 0 {
+		return nil, fmt.Errorf("no aws credentials found for profile '%s'", profile)
+	}
+	return &Credentials{
+		AccessKeyID:     s["aws_access_key_id"],
+		SecretAccessKey: s["aws_secret_access_key"],
+		SessionToken:    s["aws_session_token"],
+	}, nil
+}
+
+// Region resolves the AWS region from the explicit value, the standard
+// environment variables or the shared config file, in that order.
+func Region(region, profile string) string {
+	if len(region) > 0 {
+		return region
+	}
+	for _, env := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if r := os.Getenv(env); len(r) > 0 {
+			return r
+		}
+	}
+	file := os.Getenv("AWS_CONFIG_FILE")
+	if len(file) == 0 {
+		file = path.Join(awsDir(), "config")
+	}
+	sections, err := readIni(file)
+	if err != nil {
+		return ""
+	}
+	profile = profileName(profile)
+	if s, ok := sections["profile "+profile]; ok {
+		return s["region"]
+	}
+	return sections[profile]["region"]
+}
+
+func profileName(profile string) string {
+	if len(profile) > 0 {
+		return profile
+	}
+	if p := os.Getenv("AWS_PROFILE"); len(p) > 0 {
+		return p
+	}
+	return "default"
+}
+
+func awsDir() string {
+	hd, _ := os.UserHomeDir()
+	return path.Join(hd, ".aws")
+}
+
+func readIni(file string) (map[string]map[string]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sections := make(map[string]map[string]string)
+	var current map[string]string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = make(map[string]string)
+			sections[strings.TrimSpace(line[1:len(line)-1])] = current
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if k, v, ok := strings.Cut(line, "="); ok {
+			current[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return sections, scanner.Err()
+}
@@ -0,0 +1,156 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package aws
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+const (
+	preludeLen = 12
+	crcLen     = 4
+	// maxMessageLen mirrors the AWS event stream limit of 16MB per message.
+	maxMessageLen = 16 * 1024 * 1024
+)
+
+// Message is a decoded application/vnd.amazon.eventstream frame.
+type Message struct {
+	Headers map[string]interface{}
+	Payload []byte
+}
+
+// Header returns the string value of the given header, or empty if absent.
+func (m *Message) Header(name string) string {
+	if s, ok := m.Headers[name].(string); ok {
+		return s
+	}
+	return ""
+}
+
+// EventStreamReader decodes the binary event stream framing used by
+// HTTP/2 streaming APIs such as Kinesis SubscribeToShard.
+type EventStreamReader struct {
+	r io.Reader
+}
+
+func NewEventStreamReader(r io.Reader) *EventStreamReader {
+	return &EventStreamReader{r: r}
+}
+
+// Next blocks until the next message is fully read. It returns io.EOF when
+// the stream ends cleanly between messages.
+func (e *EventStreamReader) Next() (*Message, error) {
+	prelude := make([]byte, preludeLen)
+	if _, err := io.ReadFull(e.r, prelude); err != nil {
+		return nil, err
+	}
+	totalLen := binary.BigEndian.Uint32(prelude[0:4])
+	headersLen := binary.BigEndian.Uint32(prelude[4:8])
+	if crc32.ChecksumIEEE(prelude[0:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+		return nil, fmt.Errorf("eventstream: prelude checksum mismatch")
+	}
+	if totalLen > maxMessageLen || totalLen < preludeLen+crcLen+headersLen {
+		return nil, fmt.Errorf("eventstream: invalid message length %d", totalLen)
+	}
+
+	rest := make([]byte, totalLen-preludeLen)
+	if _, err := io.ReadFull(e.r, rest); err != nil {
+		return nil, err
+	}
+	crc := crc32.NewIEEE()
+	crc.Write(prelude)
+	crc.Write(rest[:len(rest)-crcLen])
+	if crc.Sum32() != binary.BigEndian.Uint32(rest[len(rest)-crcLen:]) {
+		return nil, fmt.Errorf("eventstream: message checksum mismatch")
+	}
+
+	headers, err := decodeHeaders(rest[:headersLen])
+	if err != nil {
+		return nil, err
+	}
+	return &Message{
+		Headers: headers,
+		Payload: rest[headersLen : len(rest)-crcLen],
+	}, nil
+}
+
+func decodeHeaders(b []byte) (map[string]interface{}, error) {
+	headers := make(map[string]interface{})
+	for len(b) > 0 {
+		nameLen := int(b[0])
+		if len(b) < 1+nameLen+1 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		name := string(b[1 : 1+nameLen])
+		valueType := b[1+nameLen]
+		b = b[2+nameLen:]
+
+		var size int
+		switch valueType {
+		case 0, 1:
+			headers[name] = valueType == 0
+			continue
+		case 2:
+			size = 1
+		case 3:
+			size = 2
+		case 4:
+			size = 4
+		case 5, 8:
+			size = 8
+		case 9:
+			size = 16
+		case 6, 7:
+			if len(b) < 2 {
+				return nil, io.ErrUnexpectedEOF
+			}
+			size = int(binary.BigEndian.Uint16(b[0:2]))
+			b = b[2:]
+		default:
+			return nil, fmt.Errorf("eventstream: unknown header type %d", valueType)
+		}
+		if len(b) < size {
+			return nil, io.ErrUnexpectedEOF
+		}
+		value := b[:size]
+		switch valueType {
+		case 2:
+			headers[name] = int64(int8(value[0]))
+		case 3:
+			headers[name] = int64(int16(binary.BigEndian.Uint16(value)))
+		case 4:
+			headers[name] = int64(int32(binary.BigEndian.Uint32(value)))
+		case 5, 8:
+			headers[name] = int64(binary.BigEndian.Uint64(value))
+		case 7:
+			headers[name] = string(value)
+		default:
+			headers[name] = append([]byte(nil), value...)
+		}
+		b = b[size:]
+	}
+	return headers, nil
+}
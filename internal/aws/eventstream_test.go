@@ -0,0 +1,87 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package aws
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encodeMessage(headers map[string]string, payload []byte) []byte {
+	h := &bytes.Buffer{}
+	for k, v := range headers {
+		h.WriteByte(byte(len(k)))
+		h.WriteString(k)
+		h.WriteByte(7)
+		_ = binary.Write(h, binary.BigEndian, uint16(len(v)))
+		h.WriteString(v)
+	}
+	total := uint32(preludeLen + h.Len() + len(payload) + crcLen)
+	b := &bytes.Buffer{}
+	_ = binary.Write(b, binary.BigEndian, total)
+	_ = binary.Write(b, binary.BigEndian, uint32(h.Len()))
+	_ = binary.Write(b, binary.BigEndian, crc32.ChecksumIEEE(b.Bytes()))
+	b.Write(h.Bytes())
+	b.Write(payload)
+	_ = binary.Write(b, binary.BigEndian, crc32.ChecksumIEEE(b.Bytes()))
+	return b.Bytes()
+}
+
+func TestEventStreamReader_Next(t *testing.T) {
+	stream := &bytes.Buffer{}
+	stream.Write(encodeMessage(map[string]string{
+		":message-type": "event",
+		":event-type":   "initial-response",
+	}, []byte(`{}`)))
+	stream.Write(encodeMessage(map[string]string{
+		":message-type": "event",
+		":event-type":   "SubscribeToShardEvent",
+	}, []byte(`{"Records":[]}`)))
+
+	r := NewEventStreamReader(stream)
+	m, err := r.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "initial-response", m.Header(":event-type"))
+	assert.Equal(t, `{}`, string(m.Payload))
+
+	m, err = r.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "SubscribeToShardEvent", m.Header(":event-type"))
+	assert.Equal(t, "event", m.Header(":message-type"))
+	assert.Equal(t, `{"Records":[]}`, string(m.Payload))
+
+	_, err = r.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestEventStreamReader_Corrupted(t *testing.T) {
+	msg := encodeMessage(map[string]string{":event-type": "x"}, []byte(`{}`))
+	msg[len(msg)-5] ^= 0xff
+	_, err := NewEventStreamReader(bytes.NewReader(msg)).Next()
+	assert.Error(t, err)
+}
@@ -0,0 +1,216 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+// Package bundle exports log entries as a single self-contained HTML file, so
+// findings can be shared with people who don't run loggo.
+package bundle
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/badaniya/loggo/internal/config"
+)
+
+// Options tailors the exported bundle.
+type Options struct {
+	// Title heads the page, e.g. the source of the entries.
+	Title string
+	// Config renders the entries as the table does, with the template keys
+	// and colors.
+	Config *config.Config
+	// Raw skips humanizing the values, see config.Key.Humanize.
+	Raw bool
+	// ColorWhen tells whether a highlight rule applies to the value of the
+	// entry. Rules are ignored if nil.
+	ColorWhen func(cw *config.ColorWhen, value string, entry map[string]interface{}) bool
+}
+
+type page struct {
+	Title     string
+	Generated string
+	Headers   []string
+	Rows      []row
+}
+
+type row struct {
+	Line     int
+	Cells    []cell
+	Note     string
+	ParseErr bool
+	JSON     template.HTML
+}
+
+type cell struct {
+	Text       string
+	Foreground string
+	Background string
+	Right      bool
+}
+
+// WriteHTML writes the entries as a self-contained HTML page: a table of the
+// template keys, colored as in loggo, where each entry expands into its
+// collapsible JSON document.
+func WriteHTML(w io.Writer, entries []map[string]interface{}, opts Options) error {
+	cfg := opts.Config
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	p := page{
+		Title:     opts.Title,
+		Generated: time.Now().Format(time.RFC3339),
+	}
+	for _, k := range cfg.Keys {
+		p.Headers = append(p.Headers, k.Name)
+	}
+	for i, e := range entries {
+		r := row{Line: i + 1}
+		r.Note, _ = e[config.Note].(string)
+		if config.IsText(e) {
+			_, r.ParseErr = e[config.ParseErr]
+			r.JSON = template.HTML(fmt.Sprintf(`<pre class="text">%s</pre>`,
+				html.EscapeString(fmt.Sprintf("%v", e[config.TextPayload]))))
+		} else {
+			sb := &strings.Builder{}
+			renderJSON(sb, e, 0)
+			r.JSON = template.HTML(sb.String())
+		}
+		for j := range cfg.Keys {
+			r.Cells = append(r.Cells, makeCell(&cfg.Keys[j], e, opts))
+		}
+		p.Rows = append(p.Rows, r)
+	}
+	return pageTemplate.Execute(w, p)
+}
+
+func makeCell(k *config.Key, entry map[string]interface{}, opts Options) cell {
+	value := k.ExtractValue(entry)
+	c := cell{
+		Text:       value,
+		Foreground: k.Color.Foreground,
+		Background: k.Color.Background,
+	}
+	if len(c.Foreground) == 0 {
+		c.Foreground = k.Type.GetColorName()
+	}
+	if opts.ColorWhen != nil {
+		for i := range k.ColorWhen {
+			if cw := &k.ColorWhen[i]; opts.ColorWhen(cw, value, entry) {
+				c.Foreground, c.Background = cw.Color.Foreground, cw.Color.Background
+				break
+			}
+		}
+	}
+	switch k.Type {
+	case config.TypeNumber, config.TypeBool, config.TypeDuration, config.TypeBytes:
+		c.Right = true
+	}
+	if !opts.Raw {
+		c.Text = k.Humanize(value)
+	}
+	return c
+}
+
+// renderJSON renders v as nested collapsible HTML elements, only the top
+// level being expanded. Loggo's own keys (e.g. notes) are left out.
+func renderJSON(sb *strings.Builder, v interface{}, depth int) {
+	open := ""
+	if depth == 0 {
+		open = " open"
+	}
+	switch t := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			if !strings.HasPrefix(k, "$_") {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		fmt.Fprintf(sb, `<details%s><summary>{&hellip;} %d keys</summary><ul>`, open, len(keys))
+		for _, k := range keys {
+			fmt.Fprintf(sb, `<li><span class="k">%s</span>: `, html.EscapeString(fmt.Sprintf("%q", k)))
+			renderJSON(sb, t[k], depth+1)
+			sb.WriteString(`</li>`)
+		}
+		sb.WriteString(`</ul></details>`)
+	case []interface{}:
+		fmt.Fprintf(sb, `<details%s><summary>[&hellip;] %d items</summary><ul>`, open, len(t))
+		for _, item := range t {
+			sb.WriteString(`<li>`)
+			renderJSON(sb, item, depth+1)
+			sb.WriteString(`</li>`)
+		}
+		sb.WriteString(`</ul></details>`)
+	case string:
+		fmt.Fprintf(sb, `<span class="s">%s</span>`, html.EscapeString(fmt.Sprintf("%q", t)))
+	case nil:
+		sb.WriteString(`<span class="b">null</span>`)
+	case bool:
+		fmt.Fprintf(sb, `<span class="b">%t</span>`, t)
+	default:
+		fmt.Fprintf(sb, `<span class="n">%s</span>`, html.EscapeString(fmt.Sprintf("%v", t)))
+	}
+}
+
+var pageTemplate = template.Must(template.New("bundle").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { background: #1c1c1c; color: #d0d0d0; font: 13px/1.4 Menlo, Consolas, monospace; margin: 1em; }
+h1 { color: #ffaf00; font-size: 16px; margin: 0; }
+.meta { color: #808080; margin-bottom: 1em; }
+table { border-collapse: collapse; width: 100%; }
+th { position: sticky; top: 0; background: #303030; color: #ffff00; text-align: center; padding: 2px 6px; }
+td { border-left: 1px solid #3a3a3a; padding: 2px 6px; vertical-align: top; white-space: pre-wrap; }
+tr:hover td { background-color: #262626; }
+td.line { color: #ffff00; text-align: right; }
+td.line.err { color: #ff0000; }
+td.right { text-align: right; }
+td.entry { white-space: normal; }
+summary { cursor: pointer; color: #808080; }
+ul { list-style: none; margin: 0; padding-left: 1.5em; }
+.k { color: #ffaf00; } .s { color: #6a9f59; } .n { color: #00afff; } .b { color: #ff8700; }
+.text { margin: 0; color: #5f87ff; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="meta">{{len .Rows}} entries, exported by l'oGGo on {{.Generated}}</div>
+<table>
+<tr><th>Line #</th>{{range .Headers}}<th>{{.}}</th>{{end}}<th>Entry</th></tr>
+{{range .Rows}}<tr>
+<td class="line{{if .ParseErr}} err{{end}}"{{if .Note}} title="{{.Note}}"{{end}}>{{if .Note}}&#x1F4DD; {{end}}{{.Line}}</td>
+{{range .Cells}}<td{{if .Right}} class="right"{{end}} style="color: {{.Foreground}};{{if .Background}} background-color: {{.Background}};{{end}}">{{.Text}}</td>
+{{end}}<td class="entry">{{.JSON}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
@@ -0,0 +1,106 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package bundle
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteHTML(t *testing.T) {
+	cfg := &config.Config{Keys: []config.Key{
+		{Name: "severity", Color: config.Color{Foreground: "white"},
+			ColorWhen: []config.ColorWhen{{MatchValue: "ERROR", Color: config.Color{Foreground: "red", Background: "#202020"}}}},
+		{Name: "latency", Type: config.TypeDuration},
+		{Name: "message"},
+	}}
+	var entries []map[string]interface{}
+	for _, l := range []string{
+		`{"severity":"ERROR","latency":"0.532","message":"<script>alert(1)</script>","labels":{"pod":"api-1"},"$_note":"root cause"}`,
+		`{"severity":"INFO","latency":"2s","message":"ok","spans":[1,true,null]}`,
+	} {
+		m := make(map[string]interface{})
+		assert.NoError(t, json.Unmarshal([]byte(l), &m))
+		entries = append(entries, m)
+	}
+	entries = append(entries, map[string]interface{}{
+		config.ParseErr:    "invalid character",
+		config.TextPayload: "plain <text>",
+	})
+
+	tests := []struct {
+		name     string
+		opts     Options
+		contains []string
+		excludes []string
+	}{
+		{
+			name: "humanized and highlighted",
+			opts: Options{
+				Title:  "api.log",
+				Config: cfg,
+				ColorWhen: func(cw *config.ColorWhen, value string, entry map[string]interface{}) bool {
+					return value == cw.MatchValue
+				},
+			},
+			contains: []string{
+				"<title>api.log</title>",
+				"3 entries",
+				"<th>severity</th><th>latency</th><th>message</th>",
+				`style="color: red; background-color: #202020;">ERROR</td>`,
+				`style="color: white;">INFO</td>`,
+				`class="right" style="color: teal;">532ms</td>`,
+				"&lt;script&gt;alert(1)&lt;/script&gt;",
+				`title="root cause">&#x1F4DD; 1</td>`,
+				`<span class="k">&#34;labels&#34;</span>: <details><summary>{&hellip;} 1 keys</summary>`,
+				`<details><summary>[&hellip;] 3 items</summary><ul><li><span class="n">1</span></li>` +
+					`<li><span class="b">true</span></li><li><span class="b">null</span></li></ul></details>`,
+				`<td class="line err">3</td>`,
+				`<pre class="text">plain &lt;text&gt;</pre>`,
+			},
+			excludes: []string{"<script>", "$_note"},
+		},
+		{
+			name:     "raw values without rules",
+			opts:     Options{Config: cfg, Raw: true},
+			contains: []string{`style="color: white;">ERROR</td>`, `class="right" style="color: teal;">0.532</td>`},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sb := &strings.Builder{}
+			assert.NoError(t, WriteHTML(sb, entries, test.opts))
+			out := sb.String()
+			for _, c := range test.contains {
+				assert.Contains(t, out, c)
+			}
+			for _, e := range test.excludes {
+				assert.NotContains(t, out, e)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package char
+
+import "math"
+
+// brailleBlank is the braille pattern without dots, the other patterns adding
+// up their dots to it.
+const brailleBlank = 0x2800
+
+// brailleDots are the dots of a braille cell by row, from the top, and column.
+var brailleDots = [4][2]rune{{0x01, 0x08}, {0x02, 0x10}, {0x04, 0x20}, {0x40, 0x80}}
+
+// BrailleLine plots the values as a line over width by height cells of
+// braille dots, two values per cell across and four dots per cell upwards,
+// scaling them from lo at the bottom to hi at the top. NaN values leave gaps
+// and values past twice the width are left out.
+func BrailleLine(values []float64, lo, hi float64, width, height int) []string {
+	if width <= 0 || height <= 0 {
+		return nil
+	}
+	cells := make([][]rune, height)
+	for i := range cells {
+		cells[i] = make([]rune, width)
+	}
+	dotRows := height * 4
+	dotRow := func(v float64) int {
+		if hi <= lo {
+			return dotRows / 2
+		}
+		ratio := math.Min(math.Max((v-lo)/(hi-lo), 0), 1)
+		return dotRows - 1 - int(math.Round(ratio*float64(dotRows-1)))
+	}
+	set := func(x, y int) {
+		cells[y/4][x/2] |= brailleDots[y%4][x%2]
+	}
+	prev := -1
+	for x, v := range values[:min(len(values), width*2)] {
+		if math.IsNaN(v) {
+			prev = -1
+			continue
+		}
+		y := dotRow(v)
+		set(x, y)
+		// joins the previous value so steep changes still read as a line
+		if prev >= 0 {
+			for between := min(prev, y) + 1; between < max(prev, y); between++ {
+				set(x, between)
+			}
+		}
+		prev = y
+	}
+	lines := make([]string, height)
+	for i, row := range cells {
+		for j, dots := range row {
+			if dots == 0 {
+				row[j] = ' '
+			} else {
+				row[j] = brailleBlank + dots
+			}
+		}
+		lines[i] = string(row)
+	}
+	return lines
+}
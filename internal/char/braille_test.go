@@ -0,0 +1,49 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package char
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBrailleLine(t *testing.T) {
+	assert.Nil(t, BrailleLine([]float64{1}, 0, 1, 0, 1))
+
+	// a rising line over a single row of two cells
+	assert.Equal(t, []string{"⡠⠊"}, BrailleLine([]float64{0, 1, 2, 3}, 0, 3, 2, 1))
+
+	// steep rises are joined
+	assert.Equal(t, []string{
+		" ⡏",
+		"⣀⠇",
+	}, BrailleLine([]float64{0, 0, 7, 7}, 0, 7, 2, 2))
+
+	// gaps are left blank
+	assert.Equal(t, []string{"⡠ ⠁"}, BrailleLine([]float64{0, 1, math.NaN(), math.NaN(), 3, math.NaN()}, 0, 3, 3, 1))
+
+	// flat values sit in the middle
+	assert.Equal(t, []string{"⠤⠤"}, BrailleLine([]float64{5, 5, 5, 5, 5}, 5, 5, 2, 1))
+}
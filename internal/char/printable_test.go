@@ -0,0 +1,94 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package char
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintable(t *testing.T) {
+	tests := []struct {
+		name  string
+		given string
+		max   int
+		wants string
+	}{
+		{
+			name:  "plain",
+			given: "GET /api/v1/orders 200",
+			max:   MaxCellRunes,
+			wants: "GET /api/v1/orders 200",
+		},
+		{
+			name:  "unicode",
+			given: "café ☕ 🔥",
+			wants: "café ☕ 🔥",
+		},
+		{
+			name:  "escape sequences",
+			given: "\x1b[2J\x1b[H fake prompt\a",
+			wants: `\x1b[2J\x1b[H fake prompt\x07`,
+		},
+		{
+			name:  "line breaks and tabs",
+			given: "line 1\r\nline 2\tend",
+			wants: `line 1\r\nline 2\tend`,
+		},
+		{
+			name:  "C1 controls and bidi overrides",
+			given: "\u009bcheck user\u202e\u2066gpj.exe",
+			wants: `\u009bcheck user\u202e\u2066gpj.exe`,
+		},
+		{
+			name:  "invalid UTF-8",
+			given: "bad \xff\xfe bytes",
+			wants: `bad \xff\xfe bytes`,
+		},
+		{
+			name:  "truncated",
+			given: strings.Repeat("é", 20),
+			max:   10,
+			wants: strings.Repeat("é", 9) + "…",
+		},
+		{
+			name:  "exactly max",
+			given: strings.Repeat("é", 10),
+			max:   10,
+			wants: strings.Repeat("é", 10),
+		},
+		{
+			name:  "long ASCII",
+			given: strings.Repeat("a", 30),
+			max:   5,
+			wants: "aaaa…",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wants, Printable(tt.given, tt.max))
+		})
+	}
+}
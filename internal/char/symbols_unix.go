@@ -5,4 +5,16 @@ package char
 const (
 	SymSearch = "🔎"
 	SymKey    = "🔑"
+	SymNote   = "📝"
+	SymGap    = "⏸"
+	SymPin    = "📌"
+	SymNew    = "🆕"
+	SymSecret = "🔐"
+	SymError  = "✖"
+	SymWarn   = "▲"
+	SymInfo   = "●"
+	SymDebug  = "·"
+	SymMark   = "✔"
+	SymLeft   = "◀"
+	SymRight  = "▶"
 )
@@ -5,4 +5,16 @@ package char
 const (
 	SymSearch = "ƒ"
 	SymKey    = "≡"
+	SymNote   = "¶"
+	SymGap    = "‖"
+	SymPin    = "†"
+	SymNew    = "*"
+	SymSecret = "!"
+	SymError  = "X"
+	SymWarn   = "^"
+	SymInfo   = "o"
+	SymDebug  = "."
+	SymMark   = "√"
+	SymLeft   = "<"
+	SymRight  = ">"
 )
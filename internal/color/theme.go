@@ -0,0 +1,163 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package color
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Theme tells how the colors picked across the UI, and by templates, are
+// rendered on the terminal.
+type Theme string
+
+const (
+	// ThemeDefault renders colors as picked.
+	ThemeDefault Theme = "default"
+	// ThemeHighContrast renders text in bold and in a handful of bright
+	// colors, over black or bright backgrounds, for low vision users and
+	// washed out terminals.
+	ThemeHighContrast Theme = "high-contrast"
+	// ThemeMono renders no color at all, highlights being rendered in
+	// reverse video instead, for minimal terminals and colorblind users.
+	ThemeMono Theme = "mono"
+)
+
+// Themes lists the supported themes.
+var Themes = []Theme{ThemeDefault, ThemeHighContrast, ThemeMono}
+
+var theme atomic.Value
+
+// SetTheme sets the theme the UI is rendered with, see Themed.
+func SetTheme(name string) error {
+	for _, t := range Themes {
+		if string(t) == name {
+			theme.Store(t)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown theme %q, expected one of %v", name, Themes)
+}
+
+// CurrentTheme returns the theme the UI is rendered with.
+func CurrentTheme() Theme {
+	if t, ok := theme.Load().(Theme); ok {
+		return t
+	}
+	return ThemeDefault
+}
+
+// highContrastForegrounds and highContrastBackgrounds are the colors high
+// contrast styles are mapped into, whichever is the nearest.
+var (
+	highContrastForegrounds = []tcell.Color{
+		tcell.ColorYellow, tcell.ColorAqua, tcell.ColorLime, tcell.ColorRed, tcell.ColorFuchsia,
+	}
+	highContrastBackgrounds = []tcell.Color{
+		tcell.ColorBlack, tcell.ColorRed, tcell.ColorWhite, tcell.ColorYellow, tcell.ColorAqua, tcell.ColorLime,
+	}
+)
+
+// Restyle maps the style into the theme.
+func (t Theme) Restyle(style tcell.Style) tcell.Style {
+	fg, bg, attrs := style.Decompose()
+	switch t {
+	case ThemeMono:
+		if isSet(bg) {
+			attrs ^= tcell.AttrReverse
+		}
+		return tcell.StyleDefault.Attributes(attrs)
+	case ThemeHighContrast:
+		style = tcell.StyleDefault.Attributes(attrs | tcell.AttrBold)
+		if isSet(bg) {
+			bg = nearest(bg, highContrastBackgrounds)
+			if bg == tcell.ColorBlack || bg == tcell.ColorRed {
+				return style.Foreground(tcell.ColorWhite).Background(bg)
+			}
+			return style.Foreground(tcell.ColorBlack).Background(bg)
+		}
+		if isSet(fg) {
+			style = style.Foreground(highContrastForeground(fg))
+		}
+		return style
+	}
+	return style
+}
+
+func isSet(c tcell.Color) bool {
+	return c != tcell.ColorDefault && c != tcell.ColorReset
+}
+
+// highContrastForeground returns the bright color the nearest to c, or white
+// for dark and grey colors, which would hardly stand out.
+func highContrastForeground(c tcell.Color) tcell.Color {
+	r, g, b := c.RGB()
+	if r < 0 || max(r, g, b)-min(r, g, b) < 64 || max(r, g, b) < 96 {
+		return tcell.ColorWhite
+	}
+	return nearest(c, highContrastForegrounds)
+}
+
+func nearest(c tcell.Color, palette []tcell.Color) tcell.Color {
+	r, g, b := c.RGB()
+	best, bestDist := palette[0], int32(-1)
+	for _, p := range palette {
+		pr, pg, pb := p.RGB()
+		if d := (r-pr)*(r-pr) + (g-pg)*(g-pg) + (b-pb)*(b-pb); bestDist < 0 || d < bestDist {
+			best, bestDist = p, d
+		}
+	}
+	return best
+}
+
+// Themed wraps the screen so that whatever is drawn on it is rendered with
+// the theme.
+func (t Theme) Themed(screen tcell.Screen) tcell.Screen {
+	if t == ThemeDefault {
+		return screen
+	}
+	return &themedScreen{Screen: screen, theme: t}
+}
+
+type themedScreen struct {
+	tcell.Screen
+	theme Theme
+}
+
+func (s *themedScreen) SetContent(x, y int, primary rune, combining []rune, style tcell.Style) {
+	s.Screen.SetContent(x, y, primary, combining, s.theme.Restyle(style))
+}
+
+func (s *themedScreen) SetCell(x, y int, style tcell.Style, ch ...rune) {
+	s.Screen.SetCell(x, y, s.theme.Restyle(style), ch...)
+}
+
+func (s *themedScreen) Fill(r rune, style tcell.Style) {
+	s.Screen.Fill(r, s.theme.Restyle(style))
+}
+
+func (s *themedScreen) SetStyle(style tcell.Style) {
+	s.Screen.SetStyle(s.theme.Restyle(style))
+}
@@ -0,0 +1,161 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+// Package compress keeps texts compressed in zstd blocks, decompressing them
+// lazily as they are accessed, so long-lived buffers of text-heavy streams take
+// a fraction of the memory their lines would.
+package compress
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// BlockSize is the number of uncompressed bytes gathered before a block is
+// compressed. Larger blocks compress better, but cost more to decompress when
+// a single text is accessed.
+const BlockSize = 64 * 1024
+
+// cachedBlocks is the number of decompressed blocks kept around, so that
+// rendering the visible rows or scanning the buffer decompresses a block once.
+const cachedBlocks = 4
+
+var (
+	encoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1))
+	decoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+)
+
+// Store appends texts to the block being filled, compressing it once it holds
+// BlockSize bytes. Blocks are released as the last Text referring to them is.
+type Store struct {
+	lock  sync.Mutex
+	open  *block
+	cache []*cached
+}
+
+type block struct {
+	store *Store
+	// raw holds the texts while the block is being filled, z once compressed.
+	raw []byte
+	z   []byte
+}
+
+type cached struct {
+	b   *block
+	raw []byte
+}
+
+// Text is a text held by a Store. It renders as the text it holds with the
+// fmt verbs and encodes as a JSON string.
+type Text struct {
+	b      *block
+	offset uint32
+	length uint32
+}
+
+func NewStore() *Store {
+	return &Store{}
+}
+
+// Add appends s to the block being filled.
+func (s *Store) Add(text string) *Text {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.open == nil {
+		s.open = &block{store: s, raw: make([]byte, 0, BlockSize)}
+	}
+	b := s.open
+	t := &Text{b: b, offset: uint32(len(b.raw)), length: uint32(len(text))}
+	b.raw = append(b.raw, text...)
+	if len(b.raw) >= BlockSize {
+		b.z = encoder.EncodeAll(b.raw, make([]byte, 0, len(b.raw)/4))
+		b.raw = nil
+		s.open = nil
+	}
+	return t
+}
+
+// String decompresses the block of the text, unless cached, and returns the
+// text.
+func (t *Text) String() string {
+	var text string
+	t.read(func(b []byte) {
+		text = string(b)
+	})
+	return text
+}
+
+// AppendTo appends the text to dst, decompressing its block unless cached,
+// so that texts can be scanned one after the other without allocating.
+func (t *Text) AppendTo(dst []byte) []byte {
+	t.read(func(b []byte) {
+		dst = append(dst, b...)
+	})
+	return dst
+}
+
+// read hands the text to f, within the block it's decompressed into, which
+// f must not retain. f isn't called if the block can't be decompressed.
+func (t *Text) read(f func(b []byte)) {
+	s := t.b.store
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	raw := t.b.raw
+	if raw == nil {
+		var err error
+		if raw, err = s.decompress(t.b); err != nil {
+			return
+		}
+	}
+	f(raw[t.offset : t.offset+t.length])
+}
+
+func (t *Text) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// decompress returns the uncompressed content of the block, from the cache if
+// recently accessed.
+func (s *Store) decompress(b *block) ([]byte, error) {
+	for i, c := range s.cache {
+		if c.b == b {
+			copy(s.cache[1:i+1], s.cache[:i])
+			s.cache[0] = c
+			return c.raw, nil
+		}
+	}
+	c := &cached{}
+	if len(s.cache) == cachedBlocks {
+		// reuse the buffer of the least recently accessed block
+		c = s.cache[cachedBlocks-1]
+		s.cache = s.cache[:cachedBlocks-1]
+	}
+	raw, err := decoder.DecodeAll(b.z, c.raw[:0])
+	if err != nil {
+		return nil, err
+	}
+	c.b, c.raw = b, raw
+	s.cache = append([]*cached{c}, s.cache...)
+	return raw, nil
+}
@@ -0,0 +1,69 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package compress
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore(t *testing.T) {
+	s := NewStore()
+	var texts []*Text
+	var lines []string
+	for i := 0; i < 5000; i++ {
+		line := fmt.Sprintf("2024-10-01 12:00:%02d INFO request %d served in %dms", i%60, i, i%250)
+		lines = append(lines, line)
+		texts = append(texts, s.Add(line))
+	}
+	// the first blocks are compressed, the last is being filled
+	assert.Nil(t, texts[0].b.raw)
+	assert.Less(t, len(texts[0].b.z), BlockSize/4)
+	assert.NotNil(t, texts[len(texts)-1].b.raw)
+	for _, i := range []int{0, 4999, 1, 2500, 0, 3000, 1500, 4000, 0} {
+		assert.Equal(t, lines[i], texts[i].String())
+		assert.Equal(t, lines[i], fmt.Sprintf("%v", texts[i]))
+		assert.Equal(t, "> "+lines[i], string(texts[i].AppendTo([]byte("> "))))
+	}
+	assert.LessOrEqual(t, len(s.cache), cachedBlocks)
+}
+
+func TestText_MarshalJSON(t *testing.T) {
+	s := NewStore()
+	m := map[string]interface{}{"message": s.Add(`quoted "text"`)}
+	b, err := json.Marshal(m)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"message":"quoted \"text\""}`, string(b))
+}
+
+func TestStore_EmptyText(t *testing.T) {
+	s := NewStore()
+	assert.Equal(t, "", s.Add("").String())
+	big := strings.Repeat("x", BlockSize+1)
+	assert.Equal(t, big, s.Add(big).String())
+	assert.Nil(t, s.open)
+}
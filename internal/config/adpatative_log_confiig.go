@@ -41,40 +41,15 @@ func MakeConfigFromSample(sample []map[string]interface{}, mergeWith ...Key) (*C
 			if _, ok := keyMap[k]; ok {
 				continue
 			}
-			if k == ParseErr {
+			if k == ParseErr || k == PlainText || k == Note || k == Novel || k == Secret {
 				continue
 			}
-			if timestamp.Contains(k) {
-				keyMap[k] = timestamp.keyConfig(k)
-				continue
-			} else if logType.Contains(k) {
-				keyMap[k] = logType.keyConfig(k)
-				continue
-			} else if traceId.Contains(k) {
-				keyMap[k] = traceId.keyConfig(k)
-				continue
-			} else if message.Contains(k) {
-				keyMap[k] = message.keyConfig(k)
-				continue
-			} else if errorKey.Contains(k) {
-				keyMap[k] = errorKey.keyConfig(k)
-				continue
-			}
-			//if _, ok := v.(map[string]interface{}); ok {
-			//	continue
-			//}
-			keyMap[k] = &Key{
-				Name: k,
-				Type: TypeString,
-				Color: Color{
-					Foreground: "white",
-					Background: "default",
-				},
-				MaxWidth: 25,
-			}
-			continue
+			keyMap[k] = inferKey(k)
 		}
 	}
+	for _, k := range keyMap {
+		k.paths()
+	}
 	c := &Config{
 		Keys: []Key{},
 	}
@@ -103,6 +78,24 @@ func MakeConfigFromSample(sample []map[string]interface{}, mergeWith ...Key) (*C
 	return c, keyMap
 }
 
+// inferKey lays out the key as per its name, e.g. timestamp keys as dates.
+func inferKey(k string) *Key {
+	for _, rule := range []preBakedRule{timestamp, logType, traceId, message, errorKey} {
+		if rule.Contains(k) {
+			return rule.keyConfig(k)
+		}
+	}
+	return &Key{
+		Name: k,
+		Type: TypeString,
+		Color: Color{
+			Foreground: "white",
+			Background: "default",
+		},
+		MaxWidth: 25,
+	}
+}
+
 type preBakedRule struct {
 	keyMatchesAny map[string]bool
 	keyConfig     func(keyName string) *Key
@@ -127,7 +120,7 @@ func (p preBakedRule) Keys() []string {
 func extractKeys2ndDepth(m map[string]interface{}) []string {
 	keys := make([]string, 0)
 	for k, v := range m {
-		if strings.Contains(k, "/") {
+		if strings.Contains(k, "/") || k == Nested {
 			continue
 		}
 		if vk, ok := v.(map[string]interface{}); ok &&
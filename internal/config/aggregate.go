@@ -0,0 +1,126 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ColumnAggregate summarises the values of a key over a set of entries: the
+// count, sum and average for numeric keys (numbers, byte sizes and
+// durations), or the number of distinct values otherwise.
+type ColumnAggregate struct {
+	Key      *Key
+	Count    int64
+	Numeric  bool
+	Samples  int64
+	Sum      float64
+	Distinct int
+}
+
+// Aggregate computes the aggregate of each key over the given entries.
+func Aggregate(keys []Key, entries []map[string]interface{}) []ColumnAggregate {
+	aggs := make([]ColumnAggregate, len(keys))
+	distinct := make([]map[string]struct{}, len(keys))
+	for i := range keys {
+		aggs[i].Key = &keys[i]
+		switch keys[i].Type {
+		case TypeNumber, TypeBytes, TypeDuration:
+			aggs[i].Numeric = true
+		default:
+			distinct[i] = make(map[string]struct{})
+		}
+	}
+	for _, entry := range entries {
+		for i := range aggs {
+			a := &aggs[i]
+			v := a.Key.ExtractValue(entry)
+			if len(v) == 0 {
+				continue
+			}
+			a.Count++
+			if !a.Numeric {
+				distinct[i][v] = struct{}{}
+				continue
+			}
+			if f, ok := a.parse(v); ok {
+				a.Samples++
+				a.Sum += f
+			}
+		}
+	}
+	for i := range aggs {
+		aggs[i].Distinct = len(distinct[i])
+	}
+	return aggs
+}
+
+func (a *ColumnAggregate) parse(v string) (float64, bool) {
+	return a.Key.numericValue(v)
+}
+
+// numericValue parses the value of a numeric key, durations as nanoseconds.
+func (k *Key) numericValue(v string) (float64, bool) {
+	if k.Type == TypeDuration {
+		d, err := ParseDuration(v)
+		return float64(d), err == nil
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	return f, err == nil
+}
+
+// formatNumeric renders a numeric value of the key, humanizing byte sizes and
+// durations.
+func (k *Key) formatNumeric(f float64) string {
+	switch k.Type {
+	case TypeDuration:
+		return HumanizeDuration(time.Duration(f))
+	case TypeBytes:
+		return HumanizeBytes(f)
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// Avg is the average of the values that could be parsed as the key type.
+func (a *ColumnAggregate) Avg() float64 {
+	if a.Samples == 0 {
+		return 0
+	}
+	return a.Sum / float64(a.Samples)
+}
+
+// String renders the aggregate for display, humanizing sums and averages of
+// byte sizes and durations.
+func (a *ColumnAggregate) String() string {
+	if !a.Numeric {
+		return fmt.Sprintf("count=%d distinct=%d", a.Count, a.Distinct)
+	}
+	return fmt.Sprintf("count=%d sum=%s avg=%s", a.Count, a.format(a.Sum), a.format(a.Avg()))
+}
+
+func (a *ColumnAggregate) format(f float64) string {
+	return a.Key.formatNumeric(f)
+}
@@ -0,0 +1,82 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregate(t *testing.T) {
+	keys := []Key{
+		{Name: "severity", Type: TypeString},
+		{Name: "status", Type: TypeNumber},
+		{Name: "size", Type: TypeBytes},
+		{Name: "latency", Type: TypeDuration},
+	}
+	var entries []map[string]interface{}
+	for _, e := range []string{
+		`{"severity":"INFO","status":200,"size":1000,"latency":"0.5s"}`,
+		`{"severity":"INFO","status":404,"size":3000,"latency":"1.5"}`,
+		`{"severity":"ERROR","status":"n/a","latency":"1s"}`,
+	} {
+		m := make(map[string]interface{})
+		assert.NoError(t, json.Unmarshal([]byte(e), &m))
+		entries = append(entries, m)
+	}
+	aggs := Aggregate(keys, entries)
+	assert.Len(t, aggs, 4)
+	tests := []struct {
+		name     string
+		count    int64
+		samples  int64
+		avg      float64
+		distinct int
+		text     string
+	}{
+		{"severity", 3, 0, 0, 2, "count=3 distinct=2"},
+		{"status", 3, 2, 302, 0, "count=3 sum=604 avg=302"},
+		{"size", 2, 2, 2000, 0, "count=2 sum=4.0 kB avg=2.0 kB"},
+		{"latency", 3, 3, 1e9, 0, "count=3 sum=3s avg=1s"},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := aggs[i]
+			assert.Equal(t, tt.name, a.Key.Name)
+			assert.Equal(t, tt.count, a.Count)
+			assert.Equal(t, tt.samples, a.Samples)
+			assert.Equal(t, tt.avg, a.Avg())
+			assert.Equal(t, tt.distinct, a.Distinct)
+			assert.Equal(t, tt.text, a.String())
+		})
+	}
+}
+
+func TestAggregate_Empty(t *testing.T) {
+	aggs := Aggregate([]Key{{Name: "status", Type: TypeNumber}}, nil)
+	assert.Len(t, aggs, 1)
+	assert.Equal(t, float64(0), aggs[0].Avg())
+	assert.Equal(t, "count=0 sum=0 avg=0", aggs[0].String())
+}
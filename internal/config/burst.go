@@ -0,0 +1,193 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBurstFactor is how many times the baseline error rate the rate over
+	// the BurstWindow must exceed to flag a burst, unless the template sets
+	// burst-factor.
+	DefaultBurstFactor = 3.0
+	// BurstWindow is the sliding window the error rate is measured over.
+	BurstWindow = 30 * time.Second
+	// burstBaseline is the period, preceding the window, the baseline error
+	// rate is measured over.
+	burstBaseline = 10 * time.Minute
+	// burstMinErrors is the number of errors within the window below which no
+	// burst is flagged, however quiet the baseline.
+	burstMinErrors = 10
+)
+
+// BurstFactorValue returns the template burst-factor (e.g. "5"), or
+// DefaultBurstFactor if unset or invalid. Zero disables burst detection.
+func (c *Config) BurstFactorValue() float64 {
+	if len(c.BurstFactor) == 0 {
+		return DefaultBurstFactor
+	}
+	f, err := strconv.ParseFloat(c.BurstFactor, 64)
+	if err != nil || f < 0 {
+		return DefaultBurstFactor
+	}
+	return f
+}
+
+// Burst describes an error burst, as detected.
+type Burst struct {
+	// Start is the oldest error entry within the window.
+	Start map[string]interface{}
+	// At is when Start was observed.
+	At time.Time
+	// Errors is the number of errors within the window.
+	Errors int
+	// Ratio is the window error rate over the baseline one, +Inf if there
+	// were no errors before.
+	Ratio float64
+}
+
+type burstError struct {
+	at    time.Time
+	entry map[string]interface{}
+}
+
+// BurstDetector flags when the error rate over the last BurstWindow exceeds a
+// multiple of the baseline rate, so spikes aren't missed while scrolled up.
+// A burst is only flagged again once the rate went back below the threshold.
+type BurstDetector struct {
+	lock     sync.Mutex
+	factor   float64
+	started  time.Time
+	recent   []burstError
+	buckets  []int64
+	lastSec  int64
+	total    int64
+	bursting bool
+	burst    *Burst
+	now      func() time.Time
+}
+
+func NewBurstDetector(factor float64) *BurstDetector {
+	return &BurstDetector{
+		factor:  factor,
+		started: time.Now(),
+		buckets: make([]int64, int(burstBaseline/time.Second)),
+		now:     time.Now,
+	}
+}
+
+// SetFactor updates the multiple of the baseline rate flagging a burst, e.g.
+// once the template is loaded.
+func (b *BurstDetector) SetFactor(factor float64) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.factor = factor
+}
+
+// ObserveError records an error entry, flagging a burst if the error rate
+// over the window exceeds the baseline one by the factor.
+func (b *BurstDetector) ObserveError(entry map[string]interface{}) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	now := b.now()
+	b.count(now.Unix())
+	b.recent = append(b.recent, burstError{at: now, entry: entry})
+	i := 0
+	for i < len(b.recent) && now.Sub(b.recent[i].at) > BurstWindow {
+		i++
+	}
+	b.recent = b.recent[i:]
+	if b.factor <= 0 {
+		return
+	}
+	// the baseline needs some history before the window
+	covered := now.Sub(b.started) - BurstWindow
+	if covered > burstBaseline-BurstWindow {
+		covered = burstBaseline - BurstWindow
+	}
+	if covered < BurstWindow {
+		return
+	}
+	rate := float64(len(b.recent)) / BurstWindow.Seconds()
+	baseline := float64(b.total-int64(len(b.recent))) / covered.Seconds()
+	if baseline < 0 {
+		baseline = 0
+	}
+	if rate <= b.factor*baseline || len(b.recent) < burstMinErrors {
+		b.bursting = false
+		return
+	}
+	ratio := math.Inf(1)
+	if baseline > 0 {
+		ratio = rate / baseline
+	}
+	if !b.bursting {
+		b.bursting = true
+		b.burst = &Burst{Start: b.recent[0].entry, At: b.recent[0].at}
+	}
+	if b.burst != nil && len(b.recent) > b.burst.Errors {
+		b.burst.Errors, b.burst.Ratio = len(b.recent), ratio
+	}
+}
+
+// count adds an error to the per second counts, clearing the ones that fell
+// out of the baseline period.
+func (b *BurstDetector) count(sec int64) {
+	n := int64(len(b.buckets))
+	if b.lastSec == 0 || sec-b.lastSec >= n {
+		clear(b.buckets)
+		b.total = 0
+	} else {
+		for s := b.lastSec + 1; s <= sec; s++ {
+			b.total -= b.buckets[s%n]
+			b.buckets[s%n] = 0
+		}
+	}
+	if sec > b.lastSec {
+		b.lastSec = sec
+	}
+	b.buckets[sec%n]++
+	b.total++
+}
+
+// Burst returns a copy of the latest burst, nil if none or dismissed.
+func (b *BurstDetector) Burst() *Burst {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.burst == nil {
+		return nil
+	}
+	burst := *b.burst
+	return &burst
+}
+
+// Dismiss forgets the latest burst.
+func (b *BurstDetector) Dismiss() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.burst = nil
+}
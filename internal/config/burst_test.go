@@ -0,0 +1,141 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBurstDetector_ObserveError(t *testing.T) {
+	type errors struct {
+		count int
+		every time.Duration
+	}
+	tests := []struct {
+		name       string
+		factor     float64
+		phases     []errors
+		wantsBurst bool
+		wantsRatio float64
+	}{
+		{
+			name:       "Spike",
+			factor:     3,
+			phases:     []errors{{count: 30, every: 10 * time.Second}, {count: 20, every: 250 * time.Millisecond}},
+			wantsBurst: true,
+		},
+		{
+			name:   "Steady errors",
+			factor: 3,
+			phases: []errors{{count: 300, every: time.Second}},
+		},
+		{
+			name:   "Errors right away",
+			factor: 3,
+			phases: []errors{{count: 50, every: 100 * time.Millisecond}},
+		},
+		{
+			name:       "Quiet baseline",
+			factor:     3,
+			phases:     []errors{{count: 1, every: 2 * time.Minute}, {count: 12, every: time.Second}},
+			wantsBurst: true,
+			wantsRatio: math.Inf(1),
+		},
+		{
+			name:   "Disabled",
+			phases: []errors{{count: 30, every: 10 * time.Second}, {count: 20, every: 250 * time.Millisecond}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			now := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+			b := NewBurstDetector(test.factor)
+			b.started = now
+			b.now = func() time.Time { return now }
+			var first map[string]interface{}
+			for i, phase := range test.phases {
+				for n := 0; n < phase.count; n++ {
+					now = now.Add(phase.every)
+					entry := map[string]interface{}{"n": n}
+					if i == len(test.phases)-1 && n == 0 {
+						first = entry
+					}
+					b.ObserveError(entry)
+				}
+			}
+			burst := b.Burst()
+			if !test.wantsBurst {
+				assert.Nil(t, burst)
+				return
+			}
+			if assert.NotNil(t, burst) {
+				assert.GreaterOrEqual(t, burst.Errors, burstMinErrors)
+				assert.Greater(t, burst.Ratio, test.factor)
+				if test.wantsRatio != 0 {
+					assert.Equal(t, test.wantsRatio, burst.Ratio)
+				}
+				if test.phases[0].every > BurstWindow {
+					assert.Equal(t, first, burst.Start)
+				}
+			}
+		})
+	}
+}
+
+func TestBurstDetector_Dismiss(t *testing.T) {
+	now := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	b := NewBurstDetector(3)
+	b.started = now
+	b.now = func() time.Time { return now }
+	spike := func() {
+		for n := 0; n < 20; n++ {
+			now = now.Add(100 * time.Millisecond)
+			b.ObserveError(map[string]interface{}{})
+		}
+	}
+	now = now.Add(5 * time.Minute)
+	spike()
+	assert.NotNil(t, b.Burst())
+	b.Dismiss()
+	assert.Nil(t, b.Burst())
+	// the same burst isn't flagged again
+	spike()
+	assert.Nil(t, b.Burst())
+	// until the rate went back to normal
+	now = now.Add(time.Minute)
+	b.ObserveError(map[string]interface{}{})
+	now = now.Add(5 * time.Minute)
+	spike()
+	assert.NotNil(t, b.Burst())
+}
+
+func TestConfig_BurstFactorValue(t *testing.T) {
+	assert.Equal(t, DefaultBurstFactor, (&Config{}).BurstFactorValue())
+	assert.Equal(t, 5.0, (&Config{BurstFactor: "5"}).BurstFactorValue())
+	assert.Equal(t, 0.0, (&Config{BurstFactor: "0"}).BurstFactorValue())
+	assert.Equal(t, DefaultBurstFactor, (&Config{BurstFactor: "-1"}).BurstFactorValue())
+}
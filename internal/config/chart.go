@@ -0,0 +1,100 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"math"
+	"time"
+)
+
+// Chart averages the values of a numeric key (numbers, byte sizes and
+// durations) per time bucket, e.g. to plot a queue depth or a latency.
+type Chart struct {
+	Key      *Key
+	From, To time.Time
+	// Min and Max bound the averages.
+	Min, Max float64
+	// Values holds the average of each bucket, the oldest first, or NaN if
+	// the bucket is empty.
+	Values []float64
+	// Last is the value of the latest entry.
+	Last float64
+}
+
+// MakeChart averages the values of the key over the given number of buckets,
+// according to the entry time (see EntryTime). It returns nil unless at least
+// two entries have both a time and a value.
+func (c *Config) MakeChart(k *Key, entries []map[string]interface{}, buckets int) *Chart {
+	if buckets <= 0 {
+		return nil
+	}
+	var samples []heatmapSample
+	ch := &Chart{Key: k, Min: math.Inf(1), Max: math.Inf(-1)}
+	for _, entry := range entries {
+		v, ok := k.numericValue(k.ExtractValue(entry))
+		if !ok {
+			continue
+		}
+		at, ok := c.EntryTime(entry)
+		if !ok {
+			continue
+		}
+		samples = append(samples, heatmapSample{at: at, value: v})
+		if ch.From.IsZero() || at.Before(ch.From) {
+			ch.From = at
+		}
+		if !at.Before(ch.To) {
+			ch.To, ch.Last = at, v
+		}
+	}
+	if len(samples) < 2 {
+		return nil
+	}
+	sums := make([]float64, buckets)
+	counts := make([]int, buckets)
+	span := ch.To.Sub(ch.From)
+	for _, s := range samples {
+		bucket := 0
+		if span > 0 {
+			bucket = min(int(float64(buckets)*float64(s.at.Sub(ch.From))/float64(span)), buckets-1)
+		}
+		sums[bucket] += s.value
+		counts[bucket]++
+	}
+	ch.Values = make([]float64, buckets)
+	for i := range ch.Values {
+		if counts[i] == 0 {
+			ch.Values[i] = math.NaN()
+			continue
+		}
+		ch.Values[i] = sums[i] / float64(counts[i])
+		ch.Min, ch.Max = math.Min(ch.Min, ch.Values[i]), math.Max(ch.Max, ch.Values[i])
+	}
+	return ch
+}
+
+// Format renders a value of the chart key, humanizing byte sizes and
+// durations and rounding numbers to the hundredth.
+func (ch *Chart) Format(v float64) string {
+	return formatRounded(ch.Key, v)
+}
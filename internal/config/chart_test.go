@@ -0,0 +1,61 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_MakeChart(t *testing.T) {
+	c := &Config{Keys: []Key{
+		{Name: "timestamp", Type: TypeDateTime},
+		{Name: "depth", Type: TypeNumber},
+	}}
+	entry := func(ts, depth string) map[string]interface{} {
+		return map[string]interface{}{"timestamp": ts, "depth": depth}
+	}
+	k := &c.Keys[1]
+
+	assert.Nil(t, c.MakeChart(k, []map[string]interface{}{entry("2024-06-01T10:00:00Z", "1")}, 4))
+
+	ch := c.MakeChart(k, []map[string]interface{}{
+		entry("2024-06-01T10:00:00Z", "2"),
+		entry("2024-06-01T10:00:05Z", "4"),
+		entry("2024-06-01T10:00:10Z", "not a number"),
+		entry("2024-06-01T10:00:30Z", "9"),
+		entry("2024-06-01T10:00:40Z", "7.123"),
+	}, 5)
+	assert.Equal(t, time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC), ch.From)
+	assert.Equal(t, time.Date(2024, 6, 1, 10, 0, 40, 0, time.UTC), ch.To)
+	assert.Equal(t, 3.0, ch.Values[0])
+	assert.True(t, math.IsNaN(ch.Values[1]))
+	assert.True(t, math.IsNaN(ch.Values[2]))
+	assert.Equal(t, []float64{9, 7.123}, ch.Values[3:])
+	assert.Equal(t, 3.0, ch.Min)
+	assert.Equal(t, 9.0, ch.Max)
+	assert.Equal(t, "7.12", ch.Format(ch.Last))
+}
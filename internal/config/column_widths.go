@@ -0,0 +1,127 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"math"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	// AutoWidthMin is the narrowest an auto-width column gets.
+	AutoWidthMin = 6
+	// AutoWidthMax is the widest an auto-width column gets, unless the key
+	// declares a max-width.
+	AutoWidthMax = 80
+	// AutoWidthPercentile is the share of observed values an auto-width column
+	// fits without truncation.
+	AutoWidthPercentile = 0.95
+)
+
+// ColumnWidths keeps track of the width distribution of the values of the
+// auto-width keys, so that their columns are sized to fit most values rather
+// than the rarely long ones.
+type ColumnWidths struct {
+	lock  sync.RWMutex
+	stats map[string]*widthStats
+}
+
+type widthStats struct {
+	total int64
+	hist  [AutoWidthMax + 1]int64
+}
+
+func NewColumnWidths() *ColumnWidths {
+	return &ColumnWidths{
+		stats: make(map[string]*widthStats),
+	}
+}
+
+// Observe records the width of the (humanized) value of each auto-width key.
+// Absent values are ignored.
+func (c *ColumnWidths) Observe(keys []Key, entry map[string]interface{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for i := range keys {
+		k := &keys[i]
+		if !k.AutoWidth {
+			continue
+		}
+		v := k.ExtractValue(entry)
+		if len(v) == 0 {
+			continue
+		}
+		st, ok := c.stats[k.Name]
+		if !ok {
+			st = &widthStats{}
+			c.stats[k.Name] = st
+		}
+		w := utf8.RuneCountInString(k.Humanize(v))
+		if w > AutoWidthMax {
+			w = AutoWidthMax
+		}
+		st.hist[w]++
+		st.total++
+	}
+}
+
+// Width is the column width of the key: its max-width unless it's an
+// auto-width key, in which case it's the AutoWidthPercentile of the observed
+// widths, bounded by AutoWidthMin and the key max-width (or AutoWidthMax).
+// Zero means the column isn't limited.
+func (c *ColumnWidths) Width(k *Key) int {
+	if !k.AutoWidth {
+		return k.MaxWidth
+	}
+	upper := AutoWidthMax
+	if k.MaxWidth > 0 {
+		upper = k.MaxWidth
+	}
+	lower := AutoWidthMin
+	if lower > upper {
+		lower = upper
+	}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	st, ok := c.stats[k.Name]
+	if !ok || st.total == 0 {
+		return upper
+	}
+	target := int64(math.Ceil(float64(st.total) * AutoWidthPercentile))
+	var seen int64
+	w := 0
+	for ; w < len(st.hist); w++ {
+		seen += st.hist[w]
+		if seen >= target {
+			break
+		}
+	}
+	switch {
+	case w < lower:
+		return lower
+	case w > upper:
+		return upper
+	}
+	return w
+}
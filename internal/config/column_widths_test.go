@@ -0,0 +1,84 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColumnWidths_Width(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      Key
+		widths   []int
+		expected int
+	}{
+		{"not auto", Key{Name: "msg", MaxWidth: 40}, []int{10, 100}, 40},
+		{"no samples", Key{Name: "msg", AutoWidth: true}, nil, AutoWidthMax},
+		{"no samples with max", Key{Name: "msg", AutoWidth: true, MaxWidth: 30}, nil, 30},
+		{"p95", Key{Name: "msg", AutoWidth: true}, repeat(12, 95, 70, 5), 12},
+		{"p95 long tail", Key{Name: "msg", AutoWidth: true}, repeat(12, 90, 70, 10), 70},
+		{"min", Key{Name: "msg", AutoWidth: true}, repeat(2, 100, 0, 0), AutoWidthMin},
+		{"max", Key{Name: "msg", AutoWidth: true}, repeat(300, 100, 0, 0), AutoWidthMax},
+		{"key max", Key{Name: "msg", AutoWidth: true, MaxWidth: 20}, repeat(30, 100, 0, 0), 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cw := NewColumnWidths()
+			keys := []Key{tt.key}
+			for _, w := range tt.widths {
+				cw.Observe(keys, map[string]interface{}{"msg": strings.Repeat("x", w)})
+			}
+			assert.Equal(t, tt.expected, cw.Width(&keys[0]))
+		})
+	}
+}
+
+func TestColumnWidths_Humanized(t *testing.T) {
+	cw := NewColumnWidths()
+	keys := []Key{{Name: "size", Type: TypeBytes, AutoWidth: true}}
+	for i := 0; i < 10; i++ {
+		cw.Observe(keys, map[string]interface{}{"size": fmt.Sprintf("%d", 1234567890+i)})
+	}
+	// "1.2 GB"
+	assert.Equal(t, 6, cw.Width(&keys[0]))
+	// absent values are ignored
+	cw.Observe(keys, map[string]interface{}{})
+	assert.Equal(t, 6, cw.Width(&keys[0]))
+}
+
+// repeat yields n values of width w followed by m values of width x.
+func repeat(w, n, x, m int) []int {
+	var ws []int
+	for i := 0; i < n; i++ {
+		ws = append(ws, w)
+	}
+	for i := 0; i < m; i++ {
+		ws = append(ws, x)
+	}
+	return ws
+}
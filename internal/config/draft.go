@@ -0,0 +1,112 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// draft holds unsaved template edits along with the template file they
+// originate from, if any.
+type draft struct {
+	Template string `yaml:"template,omitempty"`
+	Config   `yaml:",inline"`
+}
+
+// DraftFile returns the file, within dir, where unsaved edits of the given
+// template file are persisted. Drafts of templates never saved go to
+// untitled.yaml.
+func DraftFile(dir, templateFile string) string {
+	if len(templateFile) == 0 {
+		return filepath.Join(dir, "untitled.yaml")
+	}
+	if abs, err := filepath.Abs(templateFile); err == nil {
+		templateFile = abs
+	}
+	base := strings.TrimSuffix(filepath.Base(templateFile), filepath.Ext(templateFile))
+	sum := sha1.Sum([]byte(templateFile))
+	return filepath.Join(dir, fmt.Sprintf("%s-%x.yaml", base, sum[:4]))
+}
+
+// SaveDraft persists the current, unsaved, state of the template within dir,
+// so it can be recovered (see LoadDraft) should the app exit unexpectedly.
+func (c *Config) SaveDraft(dir string) error {
+	b, err := yaml.Marshal(&draft{Template: c.LastSavedName, Config: *c})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(DraftFile(dir, c.LastSavedName), b)
+}
+
+// LoadDraft returns the unsaved edits of templateFile persisted within dir, or
+// nil if there are none.
+func LoadDraft(dir, templateFile string) (*Config, error) {
+	b, err := os.ReadFile(DraftFile(dir, templateFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	d := draft{}
+	if err := yaml.Unmarshal(b, &d); err != nil {
+		return nil, err
+	}
+	d.Config.LastSavedName = templateFile
+	return &d.Config, nil
+}
+
+// DiscardDraft removes the unsaved edits of templateFile persisted within dir.
+func DiscardDraft(dir, templateFile string) error {
+	if err := os.Remove(DraftFile(dir, templateFile)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// writeFileAtomic writes to a temporary sibling file first, so fileName is
+// never left truncated by a crash halfway through.
+func writeFileAtomic(fileName string, b []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(fileName), "."+filepath.Base(fileName)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(b); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Chmod(0o644); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), fileName)
+}
@@ -0,0 +1,90 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDraft(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+	}{
+		{
+			name:     "Untitled template",
+			template: "",
+		},
+		{
+			name:     "Existing template",
+			template: "../config-sample/gcp.yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			d, err := LoadDraft(dir, tt.template)
+			assert.NoError(t, err)
+			assert.Nil(t, d)
+
+			c := &Config{
+				Keys:          []Key{{Name: "message", Type: TypeString, MaxWidth: 40}},
+				LastSavedName: tt.template,
+			}
+			assert.NoError(t, c.SaveDraft(dir))
+			d, err = LoadDraft(dir, tt.template)
+			assert.NoError(t, err)
+			assert.Equal(t, c, d)
+
+			assert.NoError(t, DiscardDraft(dir, tt.template))
+			d, err = LoadDraft(dir, tt.template)
+			assert.NoError(t, err)
+			assert.Nil(t, d)
+			assert.NoError(t, DiscardDraft(dir, tt.template))
+		})
+	}
+}
+
+func TestDraftFile(t *testing.T) {
+	assert.Equal(t, filepath.Join("drafts", "untitled.yaml"), DraftFile("drafts", ""))
+	assert.NotEqual(t, DraftFile("drafts", "a/team.yaml"), DraftFile("drafts", "b/team.yaml"))
+	assert.Regexp(t, `^team-[0-9a-f]{8}\.yaml$`, filepath.Base(DraftFile("drafts", "a/team.yaml")))
+}
+
+func TestConfig_SaveLeavesNoTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	c := &Config{Keys: []Key{{Name: "message", Type: TypeString}}}
+	fileName := filepath.Join(dir, "template.yaml")
+	assert.NoError(t, c.Save(fileName))
+	assert.Equal(t, fileName, c.LastSavedName)
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	saved, err := MakeConfig(fileName)
+	assert.NoError(t, err)
+	assert.Equal(t, c.Keys, saved.Keys)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package config
+
+import "time"
+
+// EvictHeadroom is the share of the memory limit past which the oldest
+// entries are evicted, before the limit itself is reached.
+const EvictHeadroom = 0.9
+
+// EvictFraction is the share of the buffered entries evicted at once.
+const EvictFraction = 0.1
+
+// Evictions returns how many of the oldest buffered entries to evict, given
+// the memory in use and its limit, none if unlimited or well within it.
+func Evictions(entries int, inUse, limit int64) int {
+	if limit <= 0 || entries == 0 || float64(inUse) < float64(limit)*EvictHeadroom {
+		return 0
+	}
+	return max(int(float64(entries)*EvictFraction), 1)
+}
+
+// Retention tells which entries were received longer ago than a period, so
+// the buffer holds a recent time window whatever the rate. Rather than a time
+// per entry, it marks the end of the buffer (counting the entries evicted)
+// every now and then.
+type Retention struct {
+	period time.Duration
+	marks  []retentionMark
+	now    func() time.Time
+}
+
+type retentionMark struct {
+	at  time.Time
+	end int
+}
+
+func NewRetention(period time.Duration) *Retention {
+	return &Retention{period: period, now: time.Now}
+}
+
+// Mark records that the entries before end were all received by now.
+func (r *Retention) Mark(end int) {
+	r.marks = append(r.marks, retentionMark{at: r.now(), end: end})
+}
+
+// Expired returns the end of the entries received longer ago than the
+// period, false if none is known to be, forgetting the marks past it.
+func (r *Retention) Expired() (int, bool) {
+	cutoff := r.now().Add(-r.period)
+	i := 0
+	for i < len(r.marks) && !r.marks[i].at.After(cutoff) {
+		i++
+	}
+	if i == 0 {
+		return 0, false
+	}
+	end := r.marks[i-1].end
+	r.marks = r.marks[i:]
+	return end, true
+}
@@ -0,0 +1,75 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvictions(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries int
+		inUse   int64
+		limit   int64
+		want    int
+	}{
+		{name: "Unlimited", entries: 1000, inUse: 1 << 30, limit: 0, want: 0},
+		{name: "Within the limit", entries: 1000, inUse: 800, limit: 1000, want: 0},
+		{name: "Nearing the limit", entries: 1000, inUse: 900, limit: 1000, want: 100},
+		{name: "Past the limit", entries: 1000, inUse: 2000, limit: 1000, want: 100},
+		{name: "Few entries", entries: 5, inUse: 2000, limit: 1000, want: 1},
+		{name: "No entries", entries: 0, inUse: 2000, limit: 1000, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Evictions(tt.entries, tt.inUse, tt.limit))
+		})
+	}
+}
+
+func TestRetention(t *testing.T) {
+	now := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	r := NewRetention(time.Minute)
+	r.now = func() time.Time { return now }
+
+	r.Mark(100)
+	now = now.Add(30 * time.Second)
+	r.Mark(250)
+	_, ok := r.Expired()
+	assert.False(t, ok, "nothing older than the period")
+
+	now = now.Add(30 * time.Second)
+	r.Mark(300)
+	end, ok := r.Expired()
+	assert.True(t, ok)
+	assert.Equal(t, 100, end)
+
+	now = now.Add(45 * time.Second)
+	end, ok = r.Expired()
+	assert.True(t, ok)
+	assert.Equal(t, 250, end)
+	assert.Len(t, r.marks, 1)
+}
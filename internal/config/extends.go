@@ -0,0 +1,240 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// Templates may extend a base template, e.g. `extends: base.yaml`, relative to
+// the extending template, so that color rules, severity mapping and settings
+// shared by several services are declared once:
+//   - base keys come first, unless overridden by an extending key of the same
+//     name, which takes its place; extending keys follow;
+//   - decoders are merged likewise, by key;
+//   - extractions are merged, base ones first;
+//   - watches are merged likewise, by name;
+//   - the severity mapping, enrichment and settings (see settings) are
+//     inherited unless set.
+//
+// Base templates may extend others in turn.
+
+// resolveExtends loads the base template c extends, if any, merging it into c.
+// extending lists the templates along the extends chain so far, to detect
+// cycles.
+func (c *Config) resolveExtends(file string, extending []string) error {
+	if len(c.Extends) == 0 {
+		return nil
+	}
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return err
+	}
+	extending = append(extending, abs)
+	basePath := c.Extends
+	if !filepath.IsAbs(basePath) {
+		basePath = filepath.Join(filepath.Dir(abs), basePath)
+	}
+	for _, f := range extending {
+		if f == basePath {
+			return fmt.Errorf("template extends itself: %s -> %s", strings.Join(extending, " -> "), basePath)
+		}
+	}
+	base, err := loadConfig(basePath, extending)
+	if err != nil {
+		return fmt.Errorf("unable to load base template %s: %w", c.Extends, err)
+	}
+	c.inherit(base)
+	c.base, c.basePath = base, basePath
+	return nil
+}
+
+// settings lists the scalar settings inherited from base templates.
+func (c *Config) settings() []*string {
+	return []*string{
+		&c.GapThreshold, &c.Boundaries, &c.NoveltyWarmUp, &c.BurstFactor, &c.SourceKey, &c.NoisyWindow,
+		&c.Flatten,
+	}
+}
+
+func (c *Config) inherit(base *Config) {
+	own := make(map[string]Key, len(c.Keys))
+	for _, k := range c.Keys {
+		own[k.Name] = k
+	}
+	keys := make([]Key, 0, len(base.Keys)+len(c.Keys))
+	inherited := make(map[string]bool, len(base.Keys))
+	for _, k := range base.Keys {
+		if o, ok := own[k.Name]; ok {
+			k = o
+		}
+		keys = append(keys, k)
+		inherited[k.Name] = true
+	}
+	for _, k := range c.Keys {
+		if !inherited[k.Name] {
+			keys = append(keys, k)
+		}
+	}
+	c.Keys = keys
+
+	ownDecoders := make(map[string]PayloadDecoder, len(c.Decoders))
+	for _, d := range c.Decoders {
+		ownDecoders[d.Key] = d
+	}
+	var decoders []PayloadDecoder
+	inherited = make(map[string]bool, len(base.Decoders))
+	for _, d := range base.Decoders {
+		if o, ok := ownDecoders[d.Key]; ok {
+			d = o
+		}
+		decoders = append(decoders, d)
+		inherited[d.Key] = true
+	}
+	for _, d := range c.Decoders {
+		if !inherited[d.Key] {
+			decoders = append(decoders, d)
+		}
+	}
+	c.Decoders = decoders
+
+	var extract []Extraction
+	for _, x := range base.Extract {
+		if !containsExtraction(c.Extract, x) {
+			extract = append(extract, x)
+		}
+	}
+	c.Extract = append(extract, c.Extract...)
+
+	ownWatches := make(map[string]Watch, len(c.Watch))
+	for _, w := range c.Watch {
+		ownWatches[w.Name] = w
+	}
+	var watches []Watch
+	inherited = make(map[string]bool, len(base.Watch))
+	for _, w := range base.Watch {
+		if o, ok := ownWatches[w.Name]; ok {
+			w = o
+		}
+		watches = append(watches, w)
+		inherited[w.Name] = true
+	}
+	for _, w := range c.Watch {
+		if !inherited[w.Name] {
+			watches = append(watches, w)
+		}
+	}
+	c.Watch = watches
+
+	if c.Severity == nil {
+		c.Severity = base.Severity
+	}
+	if c.Enrich == nil {
+		c.Enrich = base.Enrich
+	}
+	baseSettings := base.settings()
+	for i, s := range c.settings() {
+		if len(*s) == 0 {
+			*s = *baseSettings[i]
+		}
+	}
+}
+
+// own returns what c doesn't inherit as is from its base template, to be
+// saved as fileName, which Extends is made relative to.
+func (c *Config) own(fileName string) (*Config, error) {
+	if c.base == nil {
+		return c, nil
+	}
+	o := *c
+	if !filepath.IsAbs(c.Extends) {
+		abs, err := filepath.Abs(fileName)
+		if err != nil {
+			return nil, err
+		}
+		if o.Extends, err = filepath.Rel(filepath.Dir(abs), c.basePath); err != nil {
+			return nil, err
+		}
+	}
+	baseKeys := make(map[string]Key, len(c.base.Keys))
+	for _, k := range c.base.Keys {
+		baseKeys[k.Name] = k
+	}
+	o.Keys = nil
+	for _, k := range c.Keys {
+		if b, ok := baseKeys[k.Name]; !ok || !reflect.DeepEqual(b, k) {
+			o.Keys = append(o.Keys, k)
+		}
+	}
+	baseDecoders := make(map[string]PayloadDecoder, len(c.base.Decoders))
+	for _, d := range c.base.Decoders {
+		baseDecoders[d.Key] = d
+	}
+	o.Decoders = nil
+	for _, d := range c.Decoders {
+		if b, ok := baseDecoders[d.Key]; !ok || b != d {
+			o.Decoders = append(o.Decoders, d)
+		}
+	}
+	o.Extract = nil
+	for _, x := range c.Extract {
+		if !containsExtraction(c.base.Extract, x) {
+			o.Extract = append(o.Extract, x)
+		}
+	}
+	baseWatches := make(map[string]Watch, len(c.base.Watch))
+	for _, w := range c.base.Watch {
+		baseWatches[w.Name] = w
+	}
+	o.Watch = nil
+	for _, w := range c.Watch {
+		if b, ok := baseWatches[w.Name]; !ok || !reflect.DeepEqual(b, w) {
+			o.Watch = append(o.Watch, w)
+		}
+	}
+	if reflect.DeepEqual(o.Severity, c.base.Severity) {
+		o.Severity = nil
+	}
+	if reflect.DeepEqual(o.Enrich, c.base.Enrich) {
+		o.Enrich = nil
+	}
+	baseSettings := c.base.settings()
+	for i, s := range o.settings() {
+		if *s == *baseSettings[i] {
+			*s = ""
+		}
+	}
+	return &o, nil
+}
+
+func containsExtraction(extractions []Extraction, x Extraction) bool {
+	for _, e := range extractions {
+		if e == x {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const baseTemplate = `gap-threshold: 30s
+source-key: pod
+severity:
+  keys: [lvl]
+extract:
+  - key: message
+    regex: order (?P<order_id>\d+)
+enrich:
+  user-agent: {}
+watch:
+  - name: errors
+    when: lvl == "ERROR"
+  - name: panics
+    when: message CONTAINS "panic"
+keys:
+  - name: timestamp
+    type: datetime
+  - name: lvl
+    type: string
+    color-when:
+      - match-value: ERROR
+        color:
+          foreground: white
+          background: red
+  - name: message
+    type: string
+`
+
+func writeTemplates(t *testing.T, templates map[string]string) string {
+	dir := t.TempDir()
+	for name, body := range templates {
+		file := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+		assert.NoError(t, os.WriteFile(file, []byte(body), 0o644))
+	}
+	return dir
+}
+
+func keyNames(c *Config) []string {
+	var names []string
+	for _, k := range c.Keys {
+		names = append(names, k.Name)
+	}
+	return names
+}
+
+func TestMakeConfig_Extends(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"base.yaml": baseTemplate,
+		"services/api.yaml": `extends: ../base.yaml
+gap-threshold: 5s
+extract:
+  - key: route
+    regex: ^/(?P<api_version>v\d+)/
+watch:
+  - name: errors
+    when: lvl == "ERROR"
+    capture: {after: 1m}
+keys:
+  - name: route
+    type: string
+  - name: message
+    type: string
+    max-width: 60
+`,
+		"services/api-eu.yaml": "extends: api.yaml\nkeys:\n  - name: region\n    type: string\n",
+		"loop-a.yaml":          "extends: loop-b.yaml\n",
+		"loop-b.yaml":          "extends: loop-a.yaml\n",
+		"orphan.yaml":          "extends: missing.yaml\n",
+	})
+
+	c, err := MakeConfig(filepath.Join(dir, "services/api.yaml"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"timestamp", "lvl", "message", "route"}, keyNames(c))
+		assert.Equal(t, 60, c.Keys[2].MaxWidth)
+		assert.Len(t, c.Keys[1].ColorWhen, 1)
+		assert.Equal(t, "5s", c.GapThreshold)
+		assert.Equal(t, "pod", c.SourceKey)
+		assert.Equal(t, []string{"lvl"}, c.Severity.Keys)
+		assert.Equal(t, []Extraction{
+			{Key: "message", Regex: `order (?P<order_id>\d+)`},
+			{Key: "route", Regex: `^/(?P<api_version>v\d+)/`},
+		}, c.Extract)
+		assert.Equal(t, &Enrichment{UserAgent: &UserAgentEnrichment{}}, c.Enrich)
+		assert.Equal(t, []Watch{
+			{Name: "errors", When: `lvl == "ERROR"`, Capture: &Capture{After: "1m"}},
+			{Name: "panics", When: `message CONTAINS "panic"`},
+		}, c.Watch)
+	}
+
+	c, err = MakeConfig(filepath.Join(dir, "services/api-eu.yaml"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"timestamp", "lvl", "message", "route", "region"}, keyNames(c))
+		assert.Equal(t, "5s", c.GapThreshold)
+	}
+
+	_, err = MakeConfig(filepath.Join(dir, "loop-a.yaml"))
+	assert.Error(t, err)
+	_, err = MakeConfig(filepath.Join(dir, "orphan.yaml"))
+	assert.Error(t, err)
+}
+
+func TestConfig_SaveExtending(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"base.yaml":    baseTemplate,
+		"svc/api.yaml": "extends: ../base.yaml\nkeys:\n  - name: route\n    type: string\n",
+	})
+	c, err := MakeConfig(filepath.Join(dir, "svc/api.yaml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	c.Keys[2].MaxWidth = 80
+	c.NoisyWindow = "1m"
+
+	saved := filepath.Join(dir, "api.yaml")
+	assert.NoError(t, c.Save(saved))
+	b, err := os.ReadFile(saved)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "extends: base.yaml")
+	assert.NotContains(t, string(b), "timestamp")
+	assert.NotContains(t, string(b), "gap-threshold")
+	assert.NotContains(t, string(b), "severity")
+	assert.NotContains(t, string(b), "extract")
+	assert.NotContains(t, string(b), "enrich")
+	assert.Contains(t, string(b), "noisy-window: 1m")
+
+	reloaded, err := MakeConfig(saved)
+	if assert.NoError(t, err) {
+		assert.Equal(t, keyNames(c), keyNames(reloaded))
+		assert.Equal(t, 80, reloaded.Keys[2].MaxWidth)
+		assert.Equal(t, "30s", reloaded.GapThreshold)
+		assert.Equal(t, c.Extract, reloaded.Extract)
+	}
+}
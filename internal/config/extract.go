@@ -0,0 +1,94 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Extraction pulls values out of a key, typically a free-text message, into
+// virtual keys named after the named groups of Regex, e.g. order_id out of
+// "payment failed for order 7731" with `order (?P<order_id>\d+)`. Virtual keys
+// are used as any other: as template keys, in filters, aggregates...
+type Extraction struct {
+	Key   string `json:"key" yaml:"key"`
+	Regex string `json:"regex" yaml:"regex"`
+}
+
+// Extractor adds the virtual keys of the extractions declared in a template
+// to entries, see Extraction.
+type Extractor struct {
+	rules []extractRule
+}
+
+type extractRule struct {
+	key   Key
+	regex *regexp.Regexp
+}
+
+// MakeExtractor builds the extractor declared in a template, or nil if none.
+func MakeExtractor(extractions []Extraction) (*Extractor, error) {
+	if len(extractions) == 0 {
+		return nil, nil
+	}
+	e := &Extractor{}
+	for _, x := range extractions {
+		if len(x.Key) == 0 {
+			return nil, fmt.Errorf("missing key for regex %q", x.Regex)
+		}
+		regex, err := regexp.Compile(x.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex for %q: %w", x.Key, err)
+		}
+		named := false
+		for _, name := range regex.SubexpNames() {
+			named = named || len(name) > 0
+		}
+		if !named {
+			return nil, fmt.Errorf("regex %q for %q has no named group, e.g. (?P<order_id>\\d+)", x.Regex, x.Key)
+		}
+		e.rules = append(e.rules, extractRule{key: Key{Name: x.Key}, regex: regex})
+	}
+	return e, nil
+}
+
+// Apply adds, in place, the virtual keys matched in the entry. Keys the entry
+// already holds are left untouched, as are groups matching nothing.
+func (e *Extractor) Apply(m map[string]interface{}) {
+	for _, r := range e.rules {
+		value := r.key.ExtractValue(m)
+		if len(value) == 0 {
+			continue
+		}
+		match := r.regex.FindStringSubmatch(value)
+		for i, name := range r.regex.SubexpNames() {
+			if len(name) == 0 || i >= len(match) || len(match[i]) == 0 {
+				continue
+			}
+			if _, ok := m[name]; !ok {
+				m[name] = match[i]
+			}
+		}
+	}
+}
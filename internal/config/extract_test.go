@@ -0,0 +1,125 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractor_Apply(t *testing.T) {
+	tests := []struct {
+		name        string
+		extractions []Extraction
+		given       string
+		wants       string
+	}{
+		{
+			name:        "Named group",
+			extractions: []Extraction{{Key: "message", Regex: `order (?P<order_id>\d+)`}},
+			given:       `{"message":"payment failed for order 7731"}`,
+			wants:       `{"message":"payment failed for order 7731","order_id":"7731"}`,
+		},
+		{
+			name:        "Several groups of a nested key",
+			extractions: []Extraction{{Key: "jsonPayload/msg", Regex: `(?P<method>[A-Z]+) (?P<path>\S+) took (\d+)ms`}},
+			given:       `{"jsonPayload":{"msg":"GET /api/v2/orders took 120ms"}}`,
+			wants:       `{"jsonPayload":{"msg":"GET /api/v2/orders took 120ms"},"method":"GET","path":"/api/v2/orders"}`,
+		},
+		{
+			name: "Key alternatives and several rules",
+			extractions: []Extraction{
+				{Key: "msg | message", Regex: `user=(?P<user>\w+)`},
+				{Key: "message", Regex: `tenant=(?P<tenant>\w+)`},
+			},
+			given: `{"message":"login user=bob tenant=acme"}`,
+			wants: `{"message":"login user=bob tenant=acme","user":"bob","tenant":"acme"}`,
+		},
+		{
+			name:        "No match",
+			extractions: []Extraction{{Key: "message", Regex: `order (?P<order_id>\d+)`}},
+			given:       `{"message":"all good"}`,
+			wants:       `{"message":"all good"}`,
+		},
+		{
+			name:        "Optional group not matched",
+			extractions: []Extraction{{Key: "message", Regex: `order (?P<order_id>\d+)( retry (?P<retry>\d+))?`}},
+			given:       `{"message":"order 12 failed"}`,
+			wants:       `{"message":"order 12 failed","order_id":"12"}`,
+		},
+		{
+			name:        "Existing key kept",
+			extractions: []Extraction{{Key: "message", Regex: `order (?P<order_id>\d+)`}},
+			given:       `{"message":"order 12 failed","order_id":"A-12"}`,
+			wants:       `{"message":"order 12 failed","order_id":"A-12"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := MakeExtractor(tt.extractions)
+			assert.NoError(t, err)
+			m := make(map[string]interface{})
+			assert.NoError(t, json.Unmarshal([]byte(tt.given), &m))
+			e.Apply(m)
+			wants := make(map[string]interface{})
+			assert.NoError(t, json.Unmarshal([]byte(tt.wants), &wants))
+			assert.Equal(t, wants, m)
+		})
+	}
+}
+
+func TestMakeExtractor(t *testing.T) {
+	e, err := MakeExtractor(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, e)
+
+	tests := []struct {
+		name       string
+		extraction Extraction
+		wantErr    string
+	}{
+		{
+			name:       "missing key",
+			extraction: Extraction{Regex: `(?P<id>\d+)`},
+			wantErr:    `missing key for regex "(?P<id>\\d+)"`,
+		},
+		{
+			name:       "invalid regex",
+			extraction: Extraction{Key: "message", Regex: `(?P<id>\d+`},
+			wantErr:    `invalid regex for "message": error parsing regexp: missing closing ): ` + "`(?P<id>\\d+`",
+		},
+		{
+			name:       "no named group",
+			extraction: Extraction{Key: "message", Regex: `order (\d+)`},
+			wantErr:    `regex "order (\\d+)" for "message" has no named group, e.g. (?P<order_id>\d+)`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := MakeExtractor([]Extraction{tt.extraction})
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+)
+
+// FingerprintKey is the virtual key holding the entry fingerprint, usable in
+// filter expressions (e.g. `_fingerprint == 1f0c9a2e`) and as a template key.
+const FingerprintKey = "_fingerprint"
+
+// messageKeys are the entry keys checked, in order, for the entry message.
+var messageKeys = []string{
+	"message", "msg", "error", "textPayload", "jsonPayload/message", "jsonPayload/msg",
+}
+
+// variableParts matches the parts of a message varying between occurrences of
+// the same event, e.g. ids, numbers and hashes.
+var variableParts = regexp.MustCompile(
+	`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|\b[0-9a-fA-F]{8,}\b|\d+`)
+
+// EntryMessage returns the first non-empty message of the entry, if any.
+func EntryMessage(entry map[string]interface{}) string {
+	for _, k := range messageKeys {
+		if v, ok := resolvePath(entry, parseKeyPath(k)); ok {
+			if s, ok := v.(string); ok && len(s) > 0 {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// NormalizeMessage collapses white spaces and masks the variable parts of
+// the message with '#', so occurrences of the same event compare equal, e.g.
+// "timeout calling db-3 after 500ms" -> "timeout calling db-# after #ms".
+func NormalizeMessage(msg string) string {
+	msg = strings.Join(strings.Fields(msg), " ")
+	return variableParts.ReplaceAllString(msg, "#")
+}
+
+// Fingerprint hashes the normalized entry message into a short hex string,
+// equal for entries logged by the same event. Entries without a message are
+// fingerprinted after their whole (normalized) content.
+func Fingerprint(entry map[string]interface{}) string {
+	msg := EntryMessage(entry)
+	if len(msg) == 0 {
+		content := make(map[string]interface{}, len(entry))
+		for k, v := range entry {
+			if !strings.HasPrefix(k, "$_") {
+				content[k] = v
+			}
+		}
+		b, _ := json.Marshal(content)
+		msg = string(b)
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(NormalizeMessage(msg)))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
@@ -0,0 +1,81 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntryMessage(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry map[string]interface{}
+		wants string
+	}{
+		{
+			name:  "Message",
+			entry: map[string]interface{}{"msg": "", "message": "a"},
+			wants: "a",
+		},
+		{
+			name:  "Nested message",
+			entry: map[string]interface{}{"jsonPayload": map[string]interface{}{"msg": "b"}},
+			wants: "b",
+		},
+		{
+			name:  "Not a string",
+			entry: map[string]interface{}{"error": map[string]interface{}{"code": 5}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.wants, EntryMessage(test.entry))
+		})
+	}
+}
+
+func TestNormalizeMessage(t *testing.T) {
+	assert.Equal(t, "timeout calling db-# after #ms",
+		NormalizeMessage("timeout  calling db-3\nafter 500ms"))
+	assert.Equal(t, "user # not found in #",
+		NormalizeMessage("user 123e4567-e89b-12d3-a456-426614174000 not found in deadbeef42"))
+}
+
+func TestFingerprint(t *testing.T) {
+	a := Fingerprint(map[string]interface{}{"message": "timeout calling db-3 after 500ms", "ts": 1})
+	b := Fingerprint(map[string]interface{}{"msg": "timeout calling db-7 after 1200ms", "ts": 2})
+	c := Fingerprint(map[string]interface{}{"message": "connection refused"})
+	assert.Len(t, a, 8)
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, c)
+
+	noMsg := Fingerprint(map[string]interface{}{"status": 500, "path": "/a", Note: "seen"})
+	assert.Equal(t, noMsg, Fingerprint(map[string]interface{}{"status": 503, "path": "/a"}))
+	assert.NotEqual(t, noMsg, Fingerprint(map[string]interface{}{"status": 500, "path": "/b"}))
+
+	k := &Key{Name: FingerprintKey}
+	assert.Equal(t, c, k.ExtractValue(map[string]interface{}{"message": "connection refused"}))
+	assert.Equal(t, "own", k.ExtractValue(map[string]interface{}{FingerprintKey: "own"}))
+}
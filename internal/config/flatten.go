@@ -0,0 +1,127 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Nested holds the objects an entry was flattened from, so the entry can
+// still be shown as read, see Unflatten.
+const Nested = "$_nested"
+
+// FlattenDepth returns the template flatten depth (e.g. "2"), or 0, leaving
+// entries nested, if unset or invalid, or without a template.
+func (c *Config) FlattenDepth() int {
+	if c == nil {
+		return 0
+	}
+	depth, err := strconv.Atoi(c.Flatten)
+	if err != nil || depth < 0 {
+		return 0
+	}
+	return depth
+}
+
+// literalKeys lists, within the objects kept under Nested, the dotted top
+// level keys the entry was read with, e.g. "a.b" next to an "a" object, which
+// Flatten leaves in place of the keys it would flatten into.
+const literalKeys = "$_literal"
+
+// Flatten moves the keys of the nested objects of the entry, up to the given
+// depth, into dotted top level keys, e.g. http.request.method out of
+// {"http":{"request":{"method":"GET"}}} with a depth of 2, so templates and
+// group-by can use them as any other key. The objects are kept under Nested.
+// Dotted keys the entry already holds are kept as read rather than
+// overwritten.
+func Flatten(m map[string]interface{}, depth int) {
+	if depth <= 0 {
+		return
+	}
+	nested := make(map[string]interface{})
+	for k, v := range m {
+		if obj, ok := v.(map[string]interface{}); ok && !strings.HasPrefix(k, "$_") {
+			nested[k] = obj
+		}
+	}
+	if len(nested) == 0 {
+		return
+	}
+	var literal []string
+	for k := range m {
+		if i := strings.IndexByte(k, '.'); i > 0 {
+			if _, ok := nested[k[:i]]; ok {
+				literal = append(literal, k)
+			}
+		}
+	}
+	for k, obj := range nested {
+		delete(m, k)
+		flattenInto(m, k, obj.(map[string]interface{}), depth)
+	}
+	if len(literal) > 0 {
+		nested[literalKeys] = literal
+	}
+	m[Nested] = nested
+}
+
+func flattenInto(m map[string]interface{}, prefix string, obj map[string]interface{}, depth int) {
+	for k, v := range obj {
+		key := prefix + "." + k
+		if nested, ok := v.(map[string]interface{}); ok && depth > 1 {
+			flattenInto(m, key, nested, depth-1)
+			continue
+		}
+		if _, ok := m[key]; !ok {
+			m[key] = v
+		}
+	}
+}
+
+// Unflatten returns the entry as read before Flatten, or the entry itself if
+// it wasn't flattened.
+func Unflatten(m map[string]interface{}) map[string]interface{} {
+	nested, ok := m[Nested].(map[string]interface{})
+	if !ok {
+		return m
+	}
+	literal, _ := nested[literalKeys].([]string)
+	u := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if i := strings.IndexByte(k, '.'); i > 0 && !slices.Contains(literal, k) {
+			if _, ok := nested[k[:i]]; ok {
+				continue
+			}
+		}
+		u[k] = v
+	}
+	delete(u, Nested)
+	for k, v := range nested {
+		if k != literalKeys {
+			u[k] = v
+		}
+	}
+	return u
+}
@@ -0,0 +1,92 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_FlattenDepth(t *testing.T) {
+	assert.Equal(t, 0, (&Config{}).FlattenDepth())
+	assert.Equal(t, 0, (*Config)(nil).FlattenDepth())
+	assert.Equal(t, 2, (&Config{Flatten: "2"}).FlattenDepth())
+	assert.Equal(t, 0, (&Config{Flatten: "-1"}).FlattenDepth())
+	assert.Equal(t, 0, (&Config{Flatten: "deep"}).FlattenDepth())
+}
+
+func TestFlatten(t *testing.T) {
+	read := func() map[string]interface{} {
+		return map[string]interface{}{
+			"message": "served",
+			"http": map[string]interface{}{
+				"request": map[string]interface{}{"method": "GET", "headers": map[string]interface{}{"accept": "*/*"}},
+				"status":  float64(200),
+			},
+			"tags": []interface{}{"a"},
+			Secret: "jwt",
+		}
+	}
+
+	m := read()
+	Flatten(m, 0)
+	assert.Equal(t, read(), m)
+
+	m = read()
+	Flatten(m, 2)
+	assert.Equal(t, map[string]interface{}{
+		"message":              "served",
+		"http.request.method":  "GET",
+		"http.request.headers": map[string]interface{}{"accept": "*/*"},
+		"http.status":          float64(200),
+		"tags":                 []interface{}{"a"},
+		Secret:                 "jwt",
+		Nested:                 map[string]interface{}{"http": read()["http"]},
+	}, m)
+	assert.Equal(t, read(), Unflatten(m))
+
+	m = read()
+	Flatten(m, 1)
+	assert.Equal(t, "GET", (&Key{Name: "http.request/method"}).ExtractValue(m))
+	assert.Equal(t, read(), Unflatten(m))
+
+	// dotted keys read as such are kept rather than overwritten
+	dotted := func() map[string]interface{} {
+		return map[string]interface{}{
+			"a.b": "literal",
+			"a":   map[string]interface{}{"b": "nested", "c": "nested"},
+		}
+	}
+	m = dotted()
+	Flatten(m, 1)
+	assert.Equal(t, "literal", m["a.b"])
+	assert.Equal(t, "nested", m["a.c"])
+	assert.Equal(t, dotted(), Unflatten(m))
+
+	// entries without objects are left as is
+	m = map[string]interface{}{"message": "served"}
+	Flatten(m, 2)
+	assert.Equal(t, map[string]interface{}{"message": "served"}, m)
+	assert.Equal(t, m, Unflatten(m))
+}
@@ -0,0 +1,147 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"math"
+	"time"
+)
+
+// heatmapLogRatio is the max to min value ratio from which heatmap rows are
+// spaced logarithmically, as latencies usually spread over magnitudes.
+const heatmapLogRatio = 100
+
+// Heatmap counts the values of a numeric key (numbers, byte sizes and
+// durations) per time bucket (columns) and value bucket (rows), e.g. to spot
+// latency regressions at a glance.
+type Heatmap struct {
+	Key      *Key
+	From, To time.Time
+	Min, Max float64
+	// Log tells whether rows are spaced logarithmically.
+	Log bool
+	// Counts holds the entries per row and column, row 0 holding the lowest
+	// values and column 0 the oldest entries.
+	Counts   [][]int
+	MaxCount int
+}
+
+// HeatmapKeys returns the template keys a heatmap can be made of.
+func (c *Config) HeatmapKeys() []Key {
+	var keys []Key
+	for _, k := range c.Keys {
+		switch k.Type {
+		case TypeNumber, TypeBytes, TypeDuration:
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+type heatmapSample struct {
+	at    time.Time
+	value float64
+}
+
+// MakeHeatmap buckets the values of the key over the given number of columns
+// and rows, according to the entry time (see EntryTime). It returns nil
+// unless at least two entries have both a time and a value.
+func (c *Config) MakeHeatmap(k *Key, entries []map[string]interface{}, cols, rows int) *Heatmap {
+	if cols <= 0 || rows <= 0 {
+		return nil
+	}
+	var samples []heatmapSample
+	h := &Heatmap{Key: k, Min: math.Inf(1), Max: math.Inf(-1)}
+	for _, entry := range entries {
+		v, ok := k.numericValue(k.ExtractValue(entry))
+		if !ok {
+			continue
+		}
+		at, ok := c.EntryTime(entry)
+		if !ok {
+			continue
+		}
+		samples = append(samples, heatmapSample{at: at, value: v})
+		h.Min, h.Max = math.Min(h.Min, v), math.Max(h.Max, v)
+		if h.From.IsZero() || at.Before(h.From) {
+			h.From = at
+		}
+		if at.After(h.To) {
+			h.To = at
+		}
+	}
+	if len(samples) < 2 {
+		return nil
+	}
+	h.Log = h.Min > 0 && h.Max/h.Min >= heatmapLogRatio
+	h.Counts = make([][]int, rows)
+	for r := range h.Counts {
+		h.Counts[r] = make([]int, cols)
+	}
+	span := h.To.Sub(h.From)
+	for _, s := range samples {
+		col := 0
+		if span > 0 {
+			col = min(int(float64(cols)*float64(s.at.Sub(h.From))/float64(span)), cols-1)
+		}
+		row := min(int(float64(rows)*h.position(s.value)), rows-1)
+		h.Counts[row][col]++
+		h.MaxCount = max(h.MaxCount, h.Counts[row][col])
+	}
+	return h
+}
+
+// position places the value between Min (0) and Max (1).
+func (h *Heatmap) position(v float64) float64 {
+	if h.Max <= h.Min {
+		return 0
+	}
+	if h.Log {
+		return (math.Log(v) - math.Log(h.Min)) / (math.Log(h.Max) - math.Log(h.Min))
+	}
+	return (v - h.Min) / (h.Max - h.Min)
+}
+
+// RowFloor returns the lowest value of the given row.
+func (h *Heatmap) RowFloor(row int) float64 {
+	ratio := float64(row) / float64(len(h.Counts))
+	if h.Log {
+		return math.Exp(math.Log(h.Min) + ratio*(math.Log(h.Max)-math.Log(h.Min)))
+	}
+	return h.Min + ratio*(h.Max-h.Min)
+}
+
+// Format renders a value of the heatmap key, humanizing byte sizes and
+// durations and rounding numbers to the hundredth.
+func (h *Heatmap) Format(v float64) string {
+	return formatRounded(h.Key, v)
+}
+
+// formatRounded renders a value of the numeric key, rounding numbers to the
+// hundredth.
+func formatRounded(k *Key, v float64) string {
+	if k.Type == TypeNumber {
+		v = math.Round(v*100) / 100
+	}
+	return k.formatNumeric(v)
+}
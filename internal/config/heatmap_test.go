@@ -0,0 +1,123 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_MakeHeatmap(t *testing.T) {
+	c := &Config{Keys: []Key{
+		{Name: "timestamp", Type: TypeDateTime},
+		{Name: "latency", Type: TypeDuration},
+		{Name: "msg"},
+	}}
+	entry := func(ts, latency string) map[string]interface{} {
+		return map[string]interface{}{"timestamp": ts, "latency": latency}
+	}
+	tests := []struct {
+		name    string
+		entries []map[string]interface{}
+		counts  [][]int
+		log     bool
+	}{
+		{
+			name: "linear rows",
+			entries: []map[string]interface{}{
+				entry("2024-06-01T10:00:00Z", "10ms"),
+				entry("2024-06-01T10:00:10Z", "20ms"),
+				entry("2024-06-01T10:00:20Z", "30ms"),
+				entry("2024-06-01T10:00:40Z", "40ms"),
+				entry("2024-06-01T10:00:40Z", "40ms"),
+			},
+			counts: [][]int{{2, 0, 0}, {0, 1, 2}},
+		},
+		{
+			name: "log rows",
+			entries: []map[string]interface{}{
+				entry("2024-06-01T10:00:00Z", "1ms"),
+				entry("2024-06-01T10:00:30Z", "15ms"),
+				entry("2024-06-01T10:01:00Z", "1s"),
+			},
+			counts: [][]int{{1, 1, 0}, {0, 0, 1}},
+			log:    true,
+		},
+		{
+			name: "entries without time or value are skipped",
+			entries: []map[string]interface{}{
+				entry("2024-06-01T10:00:00Z", "10ms"),
+				entry("", "20ms"),
+				entry("2024-06-01T10:00:10Z", "n/a"),
+				entry("2024-06-01T10:00:20Z", "10ms"),
+			},
+			counts: [][]int{{1, 0, 1}, {0, 0, 0}},
+		},
+		{
+			name: "not enough samples",
+			entries: []map[string]interface{}{
+				entry("2024-06-01T10:00:00Z", "10ms"),
+				{"msg": "hello"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h := c.MakeHeatmap(&c.Keys[1], test.entries, 3, 2)
+			if test.counts == nil {
+				assert.Nil(t, h)
+				return
+			}
+			if assert.NotNil(t, h) {
+				assert.Equal(t, test.counts, h.Counts)
+				assert.Equal(t, test.log, h.Log)
+			}
+		})
+	}
+}
+
+func TestHeatmap_RowFloor(t *testing.T) {
+	h := &Heatmap{Key: &Key{Type: TypeNumber}, Min: 10, Max: 1000, Log: true, Counts: make([][]int, 2)}
+	assert.InDelta(t, 10, h.RowFloor(0), 1e-9)
+	assert.InDelta(t, 100, h.RowFloor(1), 1e-9)
+	h.Log = false
+	assert.InDelta(t, 505, h.RowFloor(1), 1e-9)
+	assert.Equal(t, "505", h.Format(h.RowFloor(1)))
+	assert.Equal(t, "31.62", h.Format(31.6227766))
+}
+
+func TestConfig_HeatmapKeys(t *testing.T) {
+	c := &Config{Keys: []Key{
+		{Name: "timestamp", Type: TypeDateTime},
+		{Name: "latency", Type: TypeDuration},
+		{Name: "size", Type: TypeBytes},
+		{Name: "msg"},
+		{Name: "status", Type: TypeNumber},
+	}}
+	var names []string
+	for _, k := range c.HeatmapKeys() {
+		names = append(names, k.Name)
+	}
+	assert.Equal(t, []string{"latency", "size", "status"}, names)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import "sync"
+
+// DefaultHistoryLimit is the number of edits a view keeps undoable.
+const DefaultHistoryLimit = 100
+
+// History is a bounded undo/redo stack of edits, e.g. to the filter or the
+// template of a view, the oldest ones being forgotten past its limit.
+type History[T any] struct {
+	lock  sync.Mutex
+	limit int
+	undo  []T
+	redo  []T
+}
+
+func NewHistory[T any](limit int) *History[T] {
+	return &History[T]{limit: limit}
+}
+
+// Push records an edit, discarding the undone ones.
+func (h *History[T]) Push(edit T) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.undo = append(h.undo, edit)
+	if len(h.undo) > h.limit {
+		h.undo = h.undo[len(h.undo)-h.limit:]
+	}
+	h.redo = nil
+}
+
+// Undo pops the latest edit, which becomes redoable, if any.
+func (h *History[T]) Undo() (T, bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	var edit T
+	if len(h.undo) == 0 {
+		return edit, false
+	}
+	edit = h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	h.redo = append(h.redo, edit)
+	return edit, true
+}
+
+// Redo pops the latest undone edit, which becomes undoable again, if any.
+func (h *History[T]) Redo() (T, bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	var edit T
+	if len(h.redo) == 0 {
+		return edit, false
+	}
+	edit = h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = append(h.undo, edit)
+	return edit, true
+}
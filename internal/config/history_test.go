@@ -0,0 +1,78 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistory(t *testing.T) {
+	h := NewHistory[string](DefaultHistoryLimit)
+	_, ok := h.Undo()
+	assert.False(t, ok)
+
+	h.Push("a")
+	h.Push("b")
+	edit, ok := h.Undo()
+	assert.True(t, ok)
+	assert.Equal(t, "b", edit)
+	edit, ok = h.Redo()
+	assert.True(t, ok)
+	assert.Equal(t, "b", edit)
+	_, ok = h.Redo()
+	assert.False(t, ok)
+
+	edit, _ = h.Undo()
+	assert.Equal(t, "b", edit)
+	edit, _ = h.Undo()
+	assert.Equal(t, "a", edit)
+	_, ok = h.Undo()
+	assert.False(t, ok)
+
+	h.Redo()
+	h.Push("c")
+	_, ok = h.Redo()
+	assert.False(t, ok, "pushing discards the undone edits")
+	edit, _ = h.Undo()
+	assert.Equal(t, "c", edit)
+	edit, _ = h.Undo()
+	assert.Equal(t, "a", edit)
+}
+
+func TestHistory_Limit(t *testing.T) {
+	h := NewHistory[int](3)
+	for i := 1; i <= 5; i++ {
+		h.Push(i)
+	}
+	var undone []int
+	for {
+		edit, ok := h.Undo()
+		if !ok {
+			break
+		}
+		undone = append(undone, edit)
+	}
+	assert.Equal(t, []int{5, 4, 3}, undone)
+}
@@ -0,0 +1,292 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// jqFormatters are the jq filters formatting the columns, which have no
+// bearing on the template.
+var jqFormatters = regexp.MustCompile(`^(@\w+|tostring|tojson|ascii_downcase|ascii_upcase|join\(.*\))$`)
+
+var jqIdentifierReg = regexp.MustCompile(`^[A-Za-z_]\w*`)
+
+// ImportJQ converts the columns of a jq filter into template keys laid out as
+// per their names, e.g. `[.ts, .level, .msg] | @tsv`, `{time: .ts, msg}` or
+// `"\(.ts) \(.msg)"`. Paths become key paths, e.g. `.spans[0].name`
+// spans[0]/name, and alternatives key fallbacks, e.g. `.msg // .message`
+// msg | message. Columns which aren't paths are skipped.
+func ImportJQ(filter string) (*Config, []string, error) {
+	columns, err := jqColumns(strings.TrimSpace(filter))
+	if err != nil {
+		return nil, nil, err
+	}
+	var notes []string
+	c := &Config{Version: SchemaVersion}
+	seen := make(map[string]bool)
+	for _, column := range columns {
+		var names []string
+		for _, alt := range splitJQ(column, "//") {
+			alt = jqStage(strings.TrimSpace(alt))
+			if isJQLiteral(alt) {
+				continue
+			}
+			name, err := jqPath(alt)
+			if err != nil {
+				notes = append(notes, fmt.Sprintf("column %q skipped, %v", strings.TrimSpace(column), err))
+				names = nil
+				break
+			}
+			names = append(names, name)
+		}
+		if len(names) == 0 || seen[strings.Join(names, " | ")] {
+			continue
+		}
+		k := inferKey(names[0])
+		k.Name = strings.Join(names, " | ")
+		seen[k.Name] = true
+		c.Keys = append(c.Keys, *k)
+	}
+	if len(c.Keys) == 0 {
+		return nil, notes, fmt.Errorf("no column could be converted out of %q", filter)
+	}
+	return c, notes, nil
+}
+
+// jqColumns splits the filter into the expressions of its columns: the items
+// of the array or object built, the interpolations of the string built, or
+// else the filter itself.
+func jqColumns(filter string) ([]string, error) {
+	stages := splitJQ(filter, "|")
+	build := ""
+	for i, stage := range stages {
+		stage = strings.TrimSpace(stage)
+		if len(build) > 0 {
+			if !jqFormatters.MatchString(stage) {
+				return nil, fmt.Errorf("unsupported filter %q after the columns", stage)
+			}
+			continue
+		}
+		if strings.HasPrefix(stage, "[") || strings.HasPrefix(stage, "{") || strings.HasPrefix(stage, `"`) || i == len(stages)-1 {
+			build = stage
+		}
+	}
+	switch {
+	case len(build) == 0:
+		return nil, fmt.Errorf("no columns in %q", filter)
+	case strings.HasPrefix(build, "[") && strings.HasSuffix(build, "]"):
+		return splitJQ(build[1:len(build)-1], ","), nil
+	case strings.HasPrefix(build, "{") && strings.HasSuffix(build, "}"):
+		var columns []string
+		for _, item := range splitJQ(build[1:len(build)-1], ",") {
+			label, value, found := cutJQ(item, ":")
+			if !found {
+				// {msg} is short for {msg: .msg}
+				value = "." + strings.Trim(strings.TrimSpace(label), `"`)
+			}
+			columns = append(columns, value)
+		}
+		return columns, nil
+	case strings.HasPrefix(build, `"`):
+		var columns []string
+		for rest := build; ; {
+			i := strings.Index(rest, `\(`)
+			if i < 0 {
+				return columns, nil
+			}
+			rest = rest[i+2:]
+			end := closingJQ(rest)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated interpolation in %q", build)
+			}
+			columns = append(columns, rest[:end])
+			rest = rest[end+1:]
+		}
+	}
+	return []string{build}, nil
+}
+
+// jqStage returns the expression a column is formatted out of, e.g. .a out of
+// (.a | tostring).
+func jqStage(expr string) string {
+	for strings.HasPrefix(expr, "(") && closingJQ(expr[1:]) == len(expr)-2 {
+		expr = strings.TrimSpace(expr[1 : len(expr)-1])
+	}
+	stages := splitJQ(expr, "|")
+	for _, s := range stages[1:] {
+		if !jqFormatters.MatchString(strings.TrimSpace(s)) {
+			return expr
+		}
+	}
+	return strings.TrimSpace(stages[0])
+}
+
+func isJQLiteral(expr string) bool {
+	if expr == "null" || expr == "true" || expr == "false" || strings.HasPrefix(expr, `"`) {
+		return true
+	}
+	_, err := strconv.ParseFloat(expr, 64)
+	return err == nil
+}
+
+// jqPath converts a jq path, e.g. .a["b c"][0].d?, into a key path, e.g.
+// a/b c[0]/d.
+func jqPath(expr string) (string, error) {
+	if !strings.HasPrefix(expr, ".") || expr == "." {
+		return "", fmt.Errorf("not a path")
+	}
+	var segments []string
+	for rest := expr; len(rest) > 0; {
+		switch {
+		case rest[0] == '?':
+			rest = rest[1:]
+		case strings.HasPrefix(rest, ".") && len(rest) > 1 && rest[1] != '[':
+			rest = rest[1:]
+			if rest[0] == '"' {
+				s, n, err := unquoteJQ(rest)
+				if err != nil {
+					return "", err
+				}
+				segments, rest = append(segments, s), rest[n:]
+				continue
+			}
+			id := jqIdentifierReg.FindString(rest)
+			if len(id) == 0 {
+				return "", fmt.Errorf("not a path")
+			}
+			segments, rest = append(segments, id), rest[len(id):]
+		case strings.HasPrefix(rest, "[") || strings.HasPrefix(rest, ".["):
+			rest = strings.TrimPrefix(rest, ".")
+			end := closingJQ(rest[1:])
+			if end < 0 {
+				return "", fmt.Errorf("unterminated [")
+			}
+			inner := strings.TrimSpace(rest[1 : end+1])
+			rest = rest[end+2:]
+			if strings.HasPrefix(inner, `"`) {
+				s, n, err := unquoteJQ(inner)
+				if err != nil || n != len(inner) {
+					return "", fmt.Errorf("not a path")
+				}
+				segments = append(segments, s)
+				continue
+			}
+			if _, err := strconv.Atoi(inner); err != nil || len(segments) == 0 {
+				return "", fmt.Errorf("iterators and slices have no key")
+			}
+			segments[len(segments)-1] += "[" + inner + "]"
+		default:
+			return "", fmt.Errorf("not a path")
+		}
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+// unquoteJQ unquotes the string s starts with, returning its length.
+func unquoteJQ(s string) (string, int, error) {
+	n := scanJQString(s)
+	if n < 0 {
+		return "", 0, fmt.Errorf("unterminated string")
+	}
+	u, err := strconv.Unquote(s[:n])
+	return u, n, err
+}
+
+// scanJQString returns the length of the string s starts with, along with
+// its interpolations, -1 if unterminated.
+func scanJQString(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], `\(`):
+			end := closingJQ(s[i+2:])
+			if end < 0 {
+				return -1
+			}
+			i += end + 2
+		case s[i] == '\\':
+			i++
+		case s[i] == '"':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// splitJQ splits the expression on sep, outside of strings and brackets.
+func splitJQ(expr, sep string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(expr); i++ {
+		switch c := expr[i]; {
+		case c == '"':
+			if n := scanJQString(expr[i:]); n > 0 {
+				i += n - 1
+			}
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case depth == 0 && strings.HasPrefix(expr[i:], sep):
+			parts = append(parts, expr[start:i])
+			i += len(sep) - 1
+			start = i + 1
+		}
+	}
+	return append(parts, expr[start:])
+}
+
+// cutJQ cuts the expression around the first sep outside of strings and
+// brackets.
+func cutJQ(expr, sep string) (string, string, bool) {
+	parts := splitJQ(expr, sep)
+	if len(parts) == 1 {
+		return expr, "", false
+	}
+	return parts[0], strings.Join(parts[1:], sep), true
+}
+
+// closingJQ returns the index of the bracket closing the one s follows, -1
+// if none.
+func closingJQ(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			if n := scanJQString(s[i:]); n > 0 {
+				i += n - 1
+			}
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
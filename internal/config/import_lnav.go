@@ -0,0 +1,292 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Importers convert the log formats or column definitions of other tools into
+// templates, along with notes on what couldn't be converted, so that moving
+// over to l'oGGo doesn't start from a blank canvas.
+
+// lnavFormat is the part of an lnav log format definition, see
+// https://docs.lnav.org/en/latest/formats.html, converted into a template.
+type lnavFormat struct {
+	JSON            bool                   `json:"json"`
+	Regex           map[string]lnavRegex   `json:"regex"`
+	LevelField      string                 `json:"level-field"`
+	Level           map[string]interface{} `json:"level"`
+	TimestampField  string                 `json:"timestamp-field"`
+	TimestampFormat []string               `json:"timestamp-format"`
+	BodyField       string                 `json:"body-field"`
+	LineFormat      []json.RawMessage      `json:"line-format"`
+	Value           map[string]lnavValue   `json:"value"`
+}
+
+type lnavRegex struct {
+	Pattern string `json:"pattern"`
+}
+
+type lnavValue struct {
+	Kind   string `json:"kind"`
+	Hidden bool   `json:"hidden"`
+}
+
+type lnavLineField struct {
+	Field    string `json:"field"`
+	MaxWidth int    `json:"max-width"`
+}
+
+// lnavLevels maps the lnav levels onto the canonical severities.
+var lnavLevels = map[string]string{
+	"trace": SeverityDebug, "debug": SeverityDebug, "debug2": SeverityDebug, "debug3": SeverityDebug,
+	"debug4": SeverityDebug, "debug5": SeverityDebug,
+	"info": SeverityInfo, "stats": SeverityInfo, "notice": SeverityInfo,
+	"warning": SeverityWarn,
+	"error":   SeverityError, "critical": SeverityError, "fatal": SeverityError,
+}
+
+var literalAlternativesReg = regexp.MustCompile(`^[\w.-]+(\|[\w.-]+)*$`)
+
+// ImportLnav converts the named format of an lnav format file, which may be
+// omitted if the file declares a single one. JSON formats lay out their
+// line-format fields, or else their timestamp, level, values and body fields;
+// regex formats extract their named groups out of the text lines.
+func ImportLnav(b []byte, name string) (*Config, []string, error) {
+	var file map[string]json.RawMessage
+	if err := json.Unmarshal(b, &file); err != nil {
+		return nil, nil, err
+	}
+	var names []string
+	for n := range file {
+		if !strings.HasPrefix(n, "$") {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	if len(name) == 0 {
+		if len(names) != 1 {
+			return nil, nil, fmt.Errorf("the file declares %d formats, name one of: %s", len(names), strings.Join(names, ", "))
+		}
+		name = names[0]
+	}
+	raw, ok := file[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("no format %q, name one of: %s", name, strings.Join(names, ", "))
+	}
+	f := lnavFormat{}
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, nil, fmt.Errorf("format %q: %w", name, err)
+	}
+	var notes []string
+	c := &Config{Version: SchemaVersion}
+	if f.JSON {
+		f.importKeys(c, &notes)
+	} else {
+		f.importRegex(c, &notes)
+	}
+	f.importLevels(c, &notes)
+	return c, notes, nil
+}
+
+// importKeys lays out the fields of a JSON format.
+func (f *lnavFormat) importKeys(c *Config, notes *[]string) {
+	if len(f.TimestampField) == 0 {
+		f.TimestampField = "timestamp"
+	}
+	if len(f.BodyField) == 0 {
+		f.BodyField = "body"
+	}
+	seen := make(map[string]bool)
+	add := func(field string, maxWidth int) {
+		switch field {
+		case "__timestamp__":
+			field = f.TimestampField
+		case "__level__":
+			field = f.LevelField
+		}
+		if len(field) == 0 || seen[field] {
+			return
+		}
+		seen[field] = true
+		k := f.key(field, notes)
+		if maxWidth > 0 {
+			k.MaxWidth = maxWidth
+		}
+		c.Keys = append(c.Keys, *k)
+	}
+	for _, raw := range f.LineFormat {
+		lf := lnavLineField{}
+		if err := json.Unmarshal(raw, &lf); err == nil {
+			add(lf.Field, lf.MaxWidth)
+		}
+	}
+	if len(c.Keys) > 0 {
+		return
+	}
+	add(f.TimestampField, 0)
+	add(f.LevelField, 0)
+	values := make([]string, 0, len(f.Value))
+	for v := range f.Value {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	for _, v := range values {
+		if !f.Value[v].Hidden && v != f.BodyField {
+			add(v, 0)
+		}
+	}
+	add(f.BodyField, 0)
+}
+
+// importRegex extracts the named groups of the regexes out of the text lines.
+func (f *lnavFormat) importRegex(c *Config, notes *[]string) {
+	if len(f.TimestampField) == 0 {
+		f.TimestampField = "timestamp"
+	}
+	if len(f.BodyField) == 0 {
+		f.BodyField = "body"
+	}
+	names := make([]string, 0, len(f.Regex))
+	for n := range f.Regex {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	seen := make(map[string]bool)
+	for _, n := range names {
+		pattern := f.Regex[n].Pattern
+		reg, err := regexp.Compile(pattern)
+		if err != nil {
+			*notes = append(*notes, fmt.Sprintf("regex %q skipped, unsupported: %v", n, err))
+			continue
+		}
+		c.Extract = append(c.Extract, Extraction{Key: TextPayload, Regex: pattern})
+		for _, group := range reg.SubexpNames() {
+			if len(group) == 0 || seen[group] {
+				continue
+			}
+			seen[group] = true
+			if group == "level" && len(f.LevelField) == 0 {
+				f.LevelField = group
+			}
+			c.Keys = append(c.Keys, *f.key(group, notes))
+		}
+	}
+	if len(c.Extract) == 0 {
+		*notes = append(*notes, "no regex could be converted, lines are shown as is")
+	}
+}
+
+// key lays out the field as per its role in the format, or its value kind.
+func (f *lnavFormat) key(field string, notes *[]string) *Key {
+	switch field {
+	case f.TimestampField:
+		k := timestamp.keyConfig(field)
+		if len(f.TimestampFormat) > 0 {
+			layout, err := strftimeLayout(f.TimestampFormat[0])
+			if err != nil {
+				*notes = append(*notes, fmt.Sprintf("timestamp-format %q: %v, RFC3339 is assumed", f.TimestampFormat[0], err))
+			}
+			k.Layout = layout
+		}
+		return k
+	case f.LevelField:
+		return logType.keyConfig(field)
+	case f.BodyField:
+		return message.keyConfig(field)
+	}
+	k := inferKey(field)
+	switch f.Value[field].Kind {
+	case "integer", "float":
+		k.Type = TypeNumber
+	case "boolean":
+		k.Type = TypeBool
+	}
+	return k
+}
+
+// importLevels maps the literal level values of the format onto the
+// canonical severities.
+func (f *lnavFormat) importLevels(c *Config, notes *[]string) {
+	if len(f.LevelField) == 0 {
+		return
+	}
+	c.Severity = &SeverityMapping{Keys: []string{f.LevelField}}
+	levels := make([]string, 0, len(f.Level))
+	for l := range f.Level {
+		levels = append(levels, l)
+	}
+	sort.Strings(levels)
+	for _, l := range levels {
+		severity, ok := lnavLevels[l]
+		if !ok {
+			*notes = append(*notes, fmt.Sprintf("level %q skipped, unknown", l))
+			continue
+		}
+		value := fmt.Sprintf("%v", f.Level[l])
+		if !literalAlternativesReg.MatchString(value) {
+			*notes = append(*notes, fmt.Sprintf("level %q skipped, pattern %q isn't a list of values", l, value))
+			continue
+		}
+		if c.Severity.Values == nil {
+			c.Severity.Values = make(map[string]string)
+		}
+		for _, v := range strings.Split(value, "|") {
+			c.Severity.Values[strings.ToLower(v)] = severity
+		}
+	}
+}
+
+// strftimeDirectives maps the strftime directives onto Go layout elements.
+var strftimeDirectives = map[byte]string{
+	'Y': "2006", 'y': "06", 'm': "01", 'd': "02", 'e': "_2", 'H': "15", 'I': "03", 'M': "04", 'S': "05",
+	'p': "PM", 'L': "000", 'f': "000000", 'N': "000000000", 'z': "-0700", 'Z': "MST",
+	'b': "Jan", 'h': "Jan", 'B': "January", 'a': "Mon", 'A': "Monday", 'j': "002",
+	'T': "15:04:05", 'F': "2006-01-02", 'D': "01/02/06", 'R': "15:04", '%': "%",
+}
+
+// strftimeLayout converts a strftime format, e.g. "%Y-%m-%d %H:%M:%S", into a
+// Go time layout.
+func strftimeLayout(format string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			b.WriteByte(format[i])
+			continue
+		}
+		if i++; i == len(format) {
+			return "", fmt.Errorf("dangling %%")
+		}
+		element, ok := strftimeDirectives[format[i]]
+		if !ok {
+			return "", fmt.Errorf("unsupported directive %%%c", format[i])
+		}
+		b.WriteString(element)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,184 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const lnavJSONFormat = `{
+  "$schema": "https://lnav.org/schemas/format-v1.schema.json",
+  "payments_log": {
+    "title": "Payments",
+    "json": true,
+    "level-field": "lvl",
+    "level": {"error": "ERROR|FATAL", "warning": "WARN", "info": "INFO", "debug": "^D.*"},
+    "timestamp-field": "ts",
+    "timestamp-format": ["%Y-%m-%dT%H:%M:%S.%L%z"],
+    "body-field": "msg",
+    "line-format": [
+      {"field": "__timestamp__"}, " ",
+      {"field": "__level__"}, " ",
+      {"field": "order/id", "max-width": 12}, " ",
+      {"field": "latency"},
+      {"field": "msg"}
+    ],
+    "value": {
+      "order/id": {"kind": "string", "identifier": true},
+      "latency": {"kind": "float"}
+    }
+  }
+}`
+
+const lnavRegexFormat = `{
+  "nginx_error": {
+    "regex": {
+      "std": {"pattern": "^(?<timestamp>\\d{4}/\\d{2}/\\d{2} \\d{2}:\\d{2}:\\d{2}) \\[(?<level>\\w+)\\] (?<pid>\\d+)#\\d+: (?<body>.*)$"},
+      "pcre": {"pattern": "^(?<timestamp>\\S+) (?=x)(?<body>.*)$"}
+    },
+    "timestamp-format": ["%Y/%m/%d %H:%M:%S"],
+    "level": {"error": "error|crit", "warning": "warn", "stats": "notice", "lost": "x"},
+    "value": {"pid": {"kind": "integer"}}
+  },
+  "other_log": {"json": true}
+}`
+
+func TestImportLnav(t *testing.T) {
+	c, notes, err := ImportLnav([]byte(lnavJSONFormat), "")
+	if assert.NoError(t, err) {
+		assert.Equal(t, SchemaVersion, c.Version)
+		assert.Equal(t, []string{"ts", "lvl", "order/id", "latency", "msg"}, keyNames(c))
+		assert.Equal(t, Type(TypeDateTime), c.Keys[0].Type)
+		assert.Equal(t, "2006-01-02T15:04:05.000-0700", c.Keys[0].Layout)
+		assert.NotEmpty(t, c.Keys[1].ColorWhen)
+		assert.Equal(t, 12, c.Keys[2].MaxWidth)
+		assert.Equal(t, Type(TypeNumber), c.Keys[3].Type)
+		assert.Equal(t, &SeverityMapping{
+			Keys: []string{"lvl"},
+			Values: map[string]string{
+				"error": SeverityError, "fatal": SeverityError, "warn": SeverityWarn, "info": SeverityInfo,
+			},
+		}, c.Severity)
+		assert.Equal(t, []string{`level "debug" skipped, pattern "^D.*" isn't a list of values`}, notes)
+	}
+
+	_, _, err = ImportLnav([]byte(lnavRegexFormat), "")
+	assert.EqualError(t, err, "the file declares 2 formats, name one of: nginx_error, other_log")
+	_, _, err = ImportLnav([]byte(lnavRegexFormat), "apache")
+	assert.Error(t, err)
+
+	c, notes, err = ImportLnav([]byte(lnavRegexFormat), "nginx_error")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"timestamp", "level", "pid", "body"}, keyNames(c))
+		assert.Equal(t, "2006/01/02 15:04:05", c.Keys[0].Layout)
+		assert.Equal(t, Type(TypeNumber), c.Keys[2].Type)
+		if assert.Len(t, c.Extract, 1) {
+			assert.Equal(t, TextPayload, c.Extract[0].Key)
+			_, err := MakeExtractor(c.Extract)
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, map[string]string{
+			"error": SeverityError, "crit": SeverityError, "warn": SeverityWarn, "notice": SeverityInfo,
+		}, c.Severity.Values)
+		if assert.Len(t, notes, 2) {
+			assert.Contains(t, notes[0], `regex "pcre" skipped, unsupported`)
+			assert.Equal(t, `level "lost" skipped, unknown`, notes[1])
+		}
+	}
+}
+
+func TestStrftimeLayout(t *testing.T) {
+	layout, err := strftimeLayout("%d/%b/%Y:%H:%M:%S %z")
+	assert.NoError(t, err)
+	assert.Equal(t, "02/Jan/2006:15:04:05 -0700", layout)
+	_, err = strftimeLayout("%s")
+	assert.EqualError(t, err, "unsupported directive %s")
+}
+
+func TestImportJQ(t *testing.T) {
+	tests := []struct {
+		name       string
+		filter     string
+		wantsKeys  []string
+		wantsNotes int
+		wantsError bool
+	}{
+		{
+			name:      "array",
+			filter:    `[.timestamp, .level, .spans[0].name, .msg // .message // "-"] | @tsv`,
+			wantsKeys: []string{"timestamp", "level", "spans[0]/name", "msg | message"},
+		},
+		{
+			name:      "object",
+			filter:    `.[] | {time: .time, level, "user agent": .http["user-agent"]?, n: (.count | tostring)}`,
+			wantsKeys: []string{"time", "level", "http/user-agent", "count"},
+		},
+		{
+			name:      "interpolation",
+			filter:    `"\(.ts) [\(.lvl // "INFO")] \(.msg)"`,
+			wantsKeys: []string{"ts", "lvl", "msg"},
+		},
+		{
+			name:       "unsupported columns",
+			filter:     `[.ts, (.latency * 1000), .tags[], .msg]`,
+			wantsKeys:  []string{"ts", "msg"},
+			wantsNotes: 2,
+		},
+		{
+			name:      "single path",
+			filter:    `.jsonPayload.message`,
+			wantsKeys: []string{"jsonPayload/message"},
+		},
+		{
+			name:       "unsupported filter",
+			filter:     `[.ts, .msg] | map(select(. != null))`,
+			wantsError: true,
+		},
+		{
+			name:       "no path",
+			filter:     `[1, "a"]`,
+			wantsError: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, notes, err := ImportJQ(test.filter)
+			if test.wantsError {
+				assert.Error(t, err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, test.wantsKeys, keyNames(c))
+				assert.Len(t, notes, test.wantsNotes)
+			}
+		})
+	}
+
+	c, _, err := ImportJQ(`[.timestamp, .message]`)
+	if assert.NoError(t, err) {
+		assert.Equal(t, Type(TypeDateTime), c.Keys[0].Type)
+		assert.Equal(t, 60, c.Keys[1].MaxWidth)
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"sort"
+	"sync"
+)
+
+const (
+	// DriftMinSamples is the number of entries required before drift is reported.
+	DriftMinSamples = 20
+	// DriftThreshold is the ratio of entries lacking a key from which it's considered drifting.
+	DriftThreshold = 0.25
+)
+
+// KeyCoverage keeps track of how often the keys of a template are absent from
+// the streamed entries, assisting the detection of log schema changes.
+type KeyCoverage struct {
+	lock  sync.RWMutex
+	stats map[string]*keyStats
+}
+
+type keyStats struct {
+	observed int64
+	missing  int64
+}
+
+// KeyDrift reports the ratio of observed entries that lacked a given key.
+type KeyDrift struct {
+	Name         string
+	Observed     int64
+	Missing      int64
+	MissingRatio float64
+}
+
+func NewKeyCoverage() *KeyCoverage {
+	return &KeyCoverage{
+		stats: make(map[string]*keyStats),
+	}
+}
+
+// Observe records, for each key, whether the entry yields a value for it.
+func (c *KeyCoverage) Observe(keys []Key, entry map[string]interface{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for i := range keys {
+		k := &keys[i]
+		st, ok := c.stats[k.Name]
+		if !ok {
+			st = &keyStats{}
+			c.stats[k.Name] = st
+		}
+		st.observed++
+		if len(k.ExtractValue(entry)) == 0 {
+			st.missing++
+		}
+	}
+}
+
+// Reset forgets the observations, e.g. once another template is applied,
+// whose keys aren't comparable to the former ones. It's a no-op on a nil
+// coverage, as snapshots don't track one.
+func (c *KeyCoverage) Reset() {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.stats = make(map[string]*keyStats)
+}
+
+// Report lists every key that has been missing at least once, sorted by the
+// highest missing ratio first.
+func (c *KeyCoverage) Report() []KeyDrift {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	var report []KeyDrift
+	for name, st := range c.stats {
+		if st.missing == 0 {
+			continue
+		}
+		report = append(report, KeyDrift{
+			Name:         name,
+			Observed:     st.observed,
+			Missing:      st.missing,
+			MissingRatio: float64(st.missing) / float64(st.observed),
+		})
+	}
+	sort.SliceStable(report, func(i, j int) bool {
+		if report[i].MissingRatio == report[j].MissingRatio {
+			return report[i].Name < report[j].Name
+		}
+		return report[i].MissingRatio > report[j].MissingRatio
+	})
+	return report
+}
+
+// Drifting lists the keys that have been observed enough times and are
+// missing from at least DriftThreshold of the entries.
+func (c *KeyCoverage) Drifting() []KeyDrift {
+	var drifting []KeyDrift
+	for _, d := range c.Report() {
+		if d.Observed >= DriftMinSamples && d.MissingRatio >= DriftThreshold {
+			drifting = append(drifting, d)
+		}
+	}
+	return drifting
+}
@@ -0,0 +1,74 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyCoverage_Drifting(t *testing.T) {
+	keys := []Key{
+		{Name: "severity"},
+		{Name: "jsonPayload/message"},
+		{Name: "trace"},
+	}
+	cov := NewKeyCoverage()
+	for i := 0; i < 40; i++ {
+		var entry string
+		if i%2 == 0 {
+			entry = fmt.Sprintf(`{"severity":"INFO","jsonPayload":{"message":"m%d"},"trace":"t"}`, i)
+		} else {
+			entry = fmt.Sprintf(`{"severity":"INFO","message":"m%d","trace":"t"}`, i)
+		}
+		m := make(map[string]interface{})
+		assert.NoError(t, json.Unmarshal([]byte(entry), &m))
+		cov.Observe(keys, m)
+	}
+	drift := cov.Drifting()
+	assert.Len(t, drift, 1)
+	assert.Equal(t, "jsonPayload/message", drift[0].Name)
+	assert.Equal(t, int64(40), drift[0].Observed)
+	assert.Equal(t, int64(20), drift[0].Missing)
+	assert.Equal(t, 0.5, drift[0].MissingRatio)
+}
+
+func TestKeyCoverage_Reset(t *testing.T) {
+	cov := NewKeyCoverage()
+	cov.Observe([]Key{{Name: "absent"}}, map[string]interface{}{"other": "value"})
+	cov.Reset()
+	assert.Empty(t, cov.Report())
+	cov.Observe([]Key{{Name: "other"}}, map[string]interface{}{"other": "value"})
+	assert.Empty(t, cov.Report())
+	(*KeyCoverage)(nil).Reset()
+}
+
+func TestKeyCoverage_NotEnoughSamples(t *testing.T) {
+	cov := NewKeyCoverage()
+	cov.Observe([]Key{{Name: "absent"}}, map[string]interface{}{"other": "value"})
+	assert.Len(t, cov.Report(), 1)
+	assert.Empty(t, cov.Drifting())
+}
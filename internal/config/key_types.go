@@ -0,0 +1,145 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeLayouts are attempted, in order, on datetime keys declaring no layout.
+var timeLayouts = []string{time.RFC3339Nano, time.RFC3339, "2006-01-02T15:04:05-0700", "2006-01-02 15:04:05"}
+
+// ParseDuration parses Go durations (e.g. "532ms", "1m30s") and bare numbers,
+// taken as seconds as GCP does (e.g. "0.532" or "0.532s").
+func ParseDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(f * float64(time.Second)), nil
+	}
+	return time.ParseDuration(value)
+}
+
+// ParseTime parses value with the given layout, or with the common RFC3339
+// like layouts if none is given.
+func ParseTime(layout, value string) (time.Time, error) {
+	if len(layout) > 0 {
+		return time.Parse(layout, value)
+	}
+	var err error
+	for _, l := range timeLayouts {
+		var t time.Time
+		if t, err = time.Parse(l, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// Compare orders two values of the key according to its type, e.g.
+// numerically for numbers and durations. Values which can't be parsed
+// as the key type sort first, lexically among themselves.
+func (k *Key) Compare(a, b string) int {
+	var pa, pb float64
+	var ea, eb error
+	switch k.Type {
+	case TypeNumber, TypeBytes:
+		pa, ea = strconv.ParseFloat(strings.TrimSpace(a), 64)
+		pb, eb = strconv.ParseFloat(strings.TrimSpace(b), 64)
+	case TypeDuration:
+		var da, db time.Duration
+		da, ea = ParseDuration(a)
+		db, eb = ParseDuration(b)
+		pa, pb = float64(da), float64(db)
+	case TypeDateTime:
+		var ta, tb time.Time
+		ta, ea = ParseTime(k.Layout, a)
+		tb, eb = ParseTime(k.Layout, b)
+		pa, pb = float64(ta.UnixNano()), float64(tb.UnixNano())
+	default:
+		return strings.Compare(a, b)
+	}
+	switch {
+	case ea != nil && eb != nil:
+		return strings.Compare(a, b)
+	case ea != nil:
+		return -1
+	case eb != nil:
+		return 1
+	case pa < pb:
+		return -1
+	case pa > pb:
+		return 1
+	}
+	return 0
+}
+
+// Humanize renders the value for display according to the key type, e.g.
+// durations as "532ms" whether they come as "0.532", "0.532s" or "532ms", and
+// byte sizes as "1.5 MB". Values which can't be parsed as the key type are
+// returned as is.
+func (k *Key) Humanize(value string) string {
+	switch k.Type {
+	case TypeDuration:
+		if d, err := ParseDuration(value); err == nil {
+			return HumanizeDuration(d)
+		}
+	case TypeBytes:
+		if b, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+			return HumanizeBytes(b)
+		}
+	}
+	return value
+}
+
+// HumanizeDuration rounds the duration to 3 significant decimals of its unit,
+// e.g. "1.235s" rather than "1.234567891s".
+func HumanizeDuration(d time.Duration) string {
+	abs := d
+	if abs < 0 {
+		abs = -abs
+	}
+	switch {
+	case abs >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case abs >= time.Millisecond:
+		return d.Round(time.Microsecond).String()
+	}
+	return d.String()
+}
+
+var byteUnits = []string{"B", "kB", "MB", "GB", "TB", "PB", "EB"}
+
+// HumanizeBytes renders a byte size with decimal (SI) units, e.g. "1.5 MB".
+func HumanizeBytes(b float64) string {
+	unit := 0
+	for (b >= 1000 || b <= -1000) && unit < len(byteUnits)-1 {
+		b /= 1000
+		unit++
+	}
+	if unit == 0 {
+		return strconv.FormatFloat(b, 'f', -1, 64) + " " + byteUnits[unit]
+	}
+	return strconv.FormatFloat(b, 'f', 1, 64) + " " + byteUnits[unit]
+}
@@ -0,0 +1,107 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		given      string
+		wants      time.Duration
+		wantsError bool
+	}{
+		{given: "532ms", wants: 532 * time.Millisecond},
+		{given: "0.532s", wants: 532 * time.Millisecond},
+		{given: "0.532", wants: 532 * time.Millisecond},
+		{given: " 2 ", wants: 2 * time.Second},
+		{given: "1m30s", wants: 90 * time.Second},
+		{given: "fast", wantsError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.given, func(t *testing.T) {
+			d, err := ParseDuration(tt.given)
+			if tt.wantsError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wants, d)
+		})
+	}
+}
+
+func TestKey_Compare(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   Key
+		a, b  string
+		wants int
+	}{
+		{name: "Lexical strings", key: Key{Type: TypeString}, a: "9", b: "10", wants: 1},
+		{name: "Numeric numbers", key: Key{Type: TypeNumber}, a: "9", b: "10", wants: -1},
+		{name: "Numeric bytes", key: Key{Type: TypeBytes}, a: "900", b: "1000", wants: -1},
+		{name: "Equal numbers", key: Key{Type: TypeNumber}, a: "1.0", b: "1", wants: 0},
+		{name: "Durations across units", key: Key{Type: TypeDuration}, a: "900ms", b: "1s", wants: -1},
+		{name: "Bare duration seconds", key: Key{Type: TypeDuration}, a: "2", b: "1500ms", wants: 1},
+		{name: "Datetime with layout", key: Key{Type: TypeDateTime, Layout: "2006-01-02"}, a: "2023-02-01", b: "2023-01-31", wants: 1},
+		{name: "Datetime without layout", key: Key{Type: TypeDateTime}, a: "2023-01-01T10:00:00+02:00", b: "2023-01-01T09:00:00Z", wants: -1},
+		{name: "Unparsable first", key: Key{Type: TypeNumber}, a: "n/a", b: "1", wants: -1},
+		{name: "Unparsable last", key: Key{Type: TypeNumber}, a: "1", b: "", wants: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wants, tt.key.Compare(tt.a, tt.b))
+		})
+	}
+}
+
+func TestKey_Humanize(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   Key
+		given string
+		wants string
+	}{
+		{name: "Bare seconds duration", key: Key{Type: TypeDuration}, given: "0.532", wants: "532ms"},
+		{name: "GCP duration", key: Key{Type: TypeDuration}, given: "1.5s", wants: "1.5s"},
+		{name: "Go duration", key: Key{Type: TypeDuration}, given: "90s", wants: "1m30s"},
+		{name: "Unparsable duration", key: Key{Type: TypeDuration}, given: "slow", wants: "slow"},
+		{name: "Rounded duration", key: Key{Type: TypeDuration}, given: "1.234567891", wants: "1.235s"},
+		{name: "Sub millisecond duration", key: Key{Type: TypeDuration}, given: "0.000532", wants: "532µs"},
+		{name: "Megabytes", key: Key{Type: TypeBytes}, given: "1532423", wants: "1.5 MB"},
+		{name: "Kilobytes", key: Key{Type: TypeBytes}, given: "2048", wants: "2.0 kB"},
+		{name: "Bytes", key: Key{Type: TypeBytes}, given: "512", wants: "512 B"},
+		{name: "Unparsable bytes", key: Key{Type: TypeBytes}, given: "-", wants: "-"},
+		{name: "String", key: Key{Type: TypeString}, given: "0.532", wants: "0.532"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wants, tt.key.Humanize(tt.given))
+		})
+	}
+}
@@ -0,0 +1,59 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ParseLine routes a line to its parser: lines opening a JSON object are
+// decoded, keeping malformed ones as their text payload flagged with the
+// parse error returned, while other lines, e.g. startup banners or panics in
+// a JSON stream, are kept as plain text entries, see IsText.
+func ParseLine(line string) (map[string]interface{}, error) {
+	if !strings.HasPrefix(strings.TrimSpace(line), "{") {
+		return map[string]interface{}{
+			PlainText:   true,
+			TextPayload: line,
+		}, nil
+	}
+	m := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(line), &m); err != nil {
+		return map[string]interface{}{
+			ParseErr:    err.Error(),
+			TextPayload: line,
+		}, err
+	}
+	return m, nil
+}
+
+// IsText tells whether the entry holds a line as read rather than its keys,
+// either plain text or malformed JSON.
+func IsText(m map[string]interface{}) bool {
+	if _, ok := m[PlainText]; ok {
+		return true
+	}
+	_, ok := m[ParseErr]
+	return ok
+}
@@ -0,0 +1,50 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLine(t *testing.T) {
+	m, err := ParseLine(` {"level":"INFO","n":1}`)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"level": "INFO", "n": float64(1)}, m)
+	assert.False(t, IsText(m))
+
+	m, err = ParseLine("panic: runtime error: index out of range [3] with length 3")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		PlainText:   true,
+		TextPayload: "panic: runtime error: index out of range [3] with length 3",
+	}, m)
+	assert.True(t, IsText(m))
+
+	m, err = ParseLine(`{"level":"INFO",`)
+	assert.Error(t, err)
+	assert.Equal(t, err.Error(), m[ParseErr])
+	assert.Equal(t, `{"level":"INFO",`, m[TextPayload])
+	assert.True(t, IsText(m))
+}
@@ -0,0 +1,203 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// LintIssue is a problem found in a template (see Lint). Errors break, or are
+// silently ignored by, l'oGGo, whereas warnings are likely mistakes.
+type LintIssue struct {
+	Error   bool
+	Message string
+}
+
+func (i LintIssue) String() string {
+	if i.Error {
+		return "error: " + i.Message
+	}
+	return "warning: " + i.Message
+}
+
+// Lint validates the template file: its schema version and unknown options,
+// duplicate or unnamed keys, unknown types and colors, invalid color-when
+// patterns, watches, settings and severity mapping. It returns the template, along with the
+// base templates it extends, unless it couldn't be loaded at all.
+func Lint(file string) (*Config, []LintIssue) {
+	var issues []LintIssue
+	fail := func(format string, args ...interface{}) {
+		issues = append(issues, LintIssue{Error: true, Message: fmt.Sprintf(format, args...)})
+	}
+	warn := func(format string, args ...interface{}) {
+		issues = append(issues, LintIssue{Message: fmt.Sprintf(format, args...)})
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		fail("%v", err)
+		return nil, issues
+	}
+	own := &Config{}
+	if _, err := parseTemplate(b, own); errors.Is(err, errEmptyTemplate) {
+		warn("the template is empty")
+	} else if se := (*SchemaError)(nil); errors.As(err, &se) {
+		for _, issue := range se.Issues {
+			fail("%s", issue)
+		}
+	} else if err != nil {
+		fail("%v", err)
+		return nil, issues
+	}
+
+	names := make(map[string]bool, len(own.Keys))
+	for i, k := range own.Keys {
+		name := strings.TrimSpace(k.Name)
+		if len(name) == 0 {
+			fail("key #%d has no name", i+1)
+			continue
+		}
+		if names[name] {
+			fail("key %q is declared more than once", name)
+		}
+		names[name] = true
+		switch k.Type {
+		case TypeString, TypeBool, TypeNumber, TypeDateTime, TypeDuration, TypeBytes:
+		case "":
+			warn("key %q has no type, defaulting to %s", name, TypeString)
+		default:
+			fail("key %q has unknown type %q", name, k.Type)
+		}
+		lintColor(fail, fmt.Sprintf("key %q", name), k.Color)
+		for j, cw := range k.ColorWhen {
+			what := fmt.Sprintf("key %q color-when #%d", name, j+1)
+			if len(cw.MatchValue) == 0 && len(cw.When) == 0 {
+				warn("%s has neither match-value nor when", what)
+			}
+			if len(cw.MatchValue) > 0 {
+				if _, err := regexp.Compile(cw.MatchValue); err != nil {
+					fail("%s match-value: %v", what, err)
+				}
+			}
+			lintColor(fail, what, cw.Color)
+		}
+	}
+
+	c := own
+	if len(own.Extends) > 0 {
+		if c, err = MakeConfig(file); err != nil {
+			fail("%v", err)
+			c = own
+		}
+	}
+	for _, s := range []struct{ name, value string }{
+		{"gap-threshold", c.GapThreshold},
+		{"novelty-warm-up", c.NoveltyWarmUp},
+		{"noisy-window", c.NoisyWindow},
+	} {
+		if _, err := ParseDuration(s.value); len(s.value) > 0 && err != nil {
+			fail("%s %q is not a duration, e.g. 30s", s.name, s.value)
+		}
+	}
+	if f, err := strconv.ParseFloat(c.BurstFactor, 64); len(c.BurstFactor) > 0 && (err != nil || f < 0) {
+		fail("burst-factor %q is not a positive number", c.BurstFactor)
+	}
+	if d, err := strconv.Atoi(c.Flatten); len(c.Flatten) > 0 && (err != nil || d < 0) {
+		fail("flatten %q is not a positive depth", c.Flatten)
+	}
+	switch c.Boundaries {
+	case "", BoundaryDay, BoundaryHour:
+	default:
+		fail("boundaries %q is neither %s nor %s", c.Boundaries, BoundaryDay, BoundaryHour)
+	}
+	if _, err := MakeSeverityMapper(c.Severity); err != nil {
+		fail("severity: %v", err)
+	}
+	if _, err := MakeExtractor(c.Extract); err != nil {
+		fail("extract: %v", err)
+	}
+	watches := make(map[string]bool, len(c.Watch))
+	for i, w := range c.Watch {
+		name := strings.TrimSpace(w.Name)
+		if len(name) == 0 {
+			fail("watch #%d has no name", i+1)
+		} else if watches[name] {
+			fail("watch %q is declared more than once", name)
+		}
+		watches[name] = true
+		if len(strings.TrimSpace(w.When)) == 0 {
+			fail("watch #%d has no when expression", i+1)
+		}
+		if w.Capture == nil {
+			continue
+		}
+		for _, s := range []struct{ name, value string }{
+			{"before", w.Capture.Before},
+			{"after", w.Capture.After},
+		} {
+			if d, err := ParseDuration(s.value); len(s.value) > 0 && (err != nil || d < 0) {
+				fail("watch #%d capture %s %q is not a duration, e.g. 30s", i+1, s.name, s.value)
+			}
+		}
+		if len(w.Capture.Dir) > 0 {
+			if fi, err := os.Stat(w.Capture.Dir); err != nil || !fi.IsDir() {
+				fail("watch #%d capture dir %q is not a directory", i+1, w.Capture.Dir)
+			}
+		}
+	}
+	if c.Enrich != nil && c.Enrich.GeoIP != nil {
+		if len(c.Enrich.GeoIP.Databases) == 0 {
+			fail("enrich: geoip has no databases")
+		}
+		for _, db := range c.Enrich.GeoIP.Databases {
+			if _, err := os.Stat(db); err != nil {
+				fail("enrich: geoip database %q is unreadable", db)
+			}
+		}
+	}
+	if c.Enrich != nil && c.Enrich.DNS != nil && len(c.Enrich.DNS.Names) > 0 {
+		if _, err := os.Stat(c.Enrich.DNS.Names); err != nil {
+			fail("enrich: dns names %q is unreadable", c.Enrich.DNS.Names)
+		}
+	}
+	return c, issues
+}
+
+func lintColor(fail func(string, ...interface{}), what string, c Color) {
+	for _, name := range []string{c.Foreground, c.Background} {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := tcell.ColorNames[name]; ok || len(name) == 0 || name == "default" {
+			continue
+		}
+		if strings.HasPrefix(name, "#") && tcell.GetColor(name) != tcell.ColorDefault {
+			continue
+		}
+		fail("%s has unknown color %q", what, name)
+	}
+}
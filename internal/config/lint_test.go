@@ -0,0 +1,156 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"base.yaml": baseTemplate,
+		"clean.yaml": `extends: base.yaml
+keys:
+  - name: status
+    type: number
+    color:
+      foreground: "#ff8800"
+`,
+		"broken.yaml": `gap-threshold: soon
+burst-factor: -1
+boundaries: week
+colour: red
+keys:
+  - name: level
+    type: string
+    color:
+      foreground: reddish
+    color-when:
+      - match-value: "ERR("
+      - when: level == "ERROR"
+        color:
+          background: red
+      - color:
+          background: red
+  - name: level
+    type: text
+  - type: number
+severity:
+  values:
+    oops: BAD
+extract:
+  - key: message
+    regex: order \d+
+watch:
+  - name: 5xx
+    when: status >= 500
+    capture:
+      before: soon
+  - name: 5xx
+enrich:
+  geoip:
+    databases: [missing.mmdb]
+  dns:
+    names: missing.hosts
+`,
+		"empty.yaml":   "",
+		"invalid.yaml": "keys: [",
+		"orphan.yaml":  "extends: missing.yaml\n",
+	})
+	tests := []struct {
+		name   string
+		file   string
+		loaded bool
+		issues []string
+	}{
+		{
+			name:   "sample template",
+			file:   "../config-sample/gcp.yaml",
+			loaded: true,
+		},
+		{
+			name:   "extending template",
+			file:   filepath.Join(dir, "clean.yaml"),
+			loaded: true,
+		},
+		{
+			name:   "broken template",
+			file:   filepath.Join(dir, "broken.yaml"),
+			loaded: true,
+			issues: []string{
+				"error: line 4: unknown option colour",
+				`error: key "level" has unknown color "reddish"`,
+				"error: key \"level\" color-when #1 match-value: error parsing regexp: missing closing ): `ERR(`",
+				`warning: key "level" color-when #3 has neither match-value nor when`,
+				`error: key "level" is declared more than once`,
+				`error: key "level" has unknown type "text"`,
+				"error: key #3 has no name",
+				`error: gap-threshold "soon" is not a duration, e.g. 30s`,
+				`error: burst-factor "-1" is not a positive number`,
+				`error: boundaries "week" is neither day nor hour`,
+				`error: severity: unknown severity "BAD" for "oops", expected one of DEBUG, INFO, WARN or ERROR`,
+				`error: extract: regex "order \\d+" for "message" has no named group, e.g. (?P<order_id>\d+)`,
+				`error: watch #1 capture before "soon" is not a duration, e.g. 30s`,
+				`error: watch "5xx" is declared more than once`,
+				`error: watch #2 has no when expression`,
+				`error: enrich: geoip database "missing.mmdb" is unreadable`,
+				`error: enrich: dns names "missing.hosts" is unreadable`,
+			},
+		},
+		{
+			name:   "empty template",
+			file:   filepath.Join(dir, "empty.yaml"),
+			loaded: true,
+			issues: []string{"warning: the template is empty"},
+		},
+		{
+			name:   "invalid YAML",
+			file:   filepath.Join(dir, "invalid.yaml"),
+			issues: []string{"error: yaml: line 1: did not find expected node content"},
+		},
+		{
+			name:   "missing base template",
+			file:   filepath.Join(dir, "orphan.yaml"),
+			loaded: true,
+			issues: []string{"error: unable to load base template missing.yaml"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, issues := Lint(test.file)
+			assert.Equal(t, test.loaded, c != nil)
+			var got []string
+			for _, i := range issues {
+				got = append(got, i.String())
+			}
+			if assert.Len(t, got, len(test.issues)) {
+				for i := range got {
+					assert.Contains(t, got[i], test.issues[i])
+				}
+			}
+		})
+	}
+}
@@ -24,9 +24,12 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/gdamore/tcell/v2"
 
@@ -35,24 +38,99 @@ import (
 
 const (
 	ParseErr    = "$_parseErr"
+	PlainText   = "$_plain"
+	Note        = "$_note"
+	Novel       = "$_novel"
+	Secret      = "$_secret"
 	TextPayload = "message"
 )
 
 type Config struct {
-	Keys          []Key  `json:"keys" yaml:"keys"`
-	LastSavedName string `json:"-" yaml:"-"`
+	Version       int              `json:"version,omitempty" yaml:"version,omitempty"`
+	Extends       string           `json:"extends,omitempty" yaml:"extends,omitempty"`
+	Keys          []Key            `json:"keys" yaml:"keys"`
+	Decoders      []PayloadDecoder `json:"decoders,omitempty" yaml:"decoders,omitempty"`
+	Severity      *SeverityMapping `json:"severity,omitempty" yaml:"severity,omitempty"`
+	Extract       []Extraction     `json:"extract,omitempty" yaml:"extract,omitempty"`
+	Enrich        *Enrichment      `json:"enrich,omitempty" yaml:"enrich,omitempty"`
+	GapThreshold  string           `json:"gap-threshold,omitempty" yaml:"gap-threshold,omitempty"`
+	Boundaries    string           `json:"boundaries,omitempty" yaml:"boundaries,omitempty"`
+	NoveltyWarmUp string           `json:"novelty-warm-up,omitempty" yaml:"novelty-warm-up,omitempty"`
+	BurstFactor   string           `json:"burst-factor,omitempty" yaml:"burst-factor,omitempty"`
+	SourceKey     string           `json:"source-key,omitempty" yaml:"source-key,omitempty"`
+	NoisyWindow   string           `json:"noisy-window,omitempty" yaml:"noisy-window,omitempty"`
+	Flatten       string           `json:"flatten,omitempty" yaml:"flatten,omitempty"`
+	Watch         []Watch          `json:"watch,omitempty" yaml:"watch,omitempty"`
+	LastSavedName string           `json:"-" yaml:"-"`
+	// base is the resolved template Extends refers to, if any.
+	base *Config
+	// basePath is the absolute path of the base template.
+	basePath string
 }
 
+const (
+	FormatProtobuf = "protobuf"
+	FormatAvro     = "avro"
+)
+
+// PayloadDecoder declares a base64 encoded binary payload held by Key and how to
+// decode it for rendering. Schema is a FileDescriptorSet file for protobuf, in
+// which case Message is the fully qualified message name, or a JSON schema
+// (.avsc) file for avro.
+type PayloadDecoder struct {
+	Key     string `json:"key" yaml:"key"`
+	Format  string `json:"format" yaml:"format"`
+	Schema  string `json:"schema" yaml:"schema"`
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// Enrichment declares the processors annotating entries with extra keys as
+// they are ingested, e.g. to analyze access logs.
+type Enrichment struct {
+	GeoIP     *GeoIPEnrichment     `json:"geoip,omitempty" yaml:"geoip,omitempty"`
+	UserAgent *UserAgentEnrichment `json:"user-agent,omitempty" yaml:"user-agent,omitempty"`
+	DNS       *DNSEnrichment       `json:"dns,omitempty" yaml:"dns,omitempty"`
+}
+
+// GeoIPEnrichment annotates the IP addresses held by Keys with their country,
+// city and autonomous system, looked up in local MaxMind databases, e.g.
+// GeoLite2-City.mmdb and GeoLite2-ASN.mmdb. Keys default to the usual IP keys.
+type GeoIPEnrichment struct {
+	Databases []string `json:"databases" yaml:"databases"`
+	Keys      []string `json:"keys,omitempty" yaml:"keys,omitempty"`
+}
+
+// UserAgentEnrichment annotates the user-agent strings held by Keys with
+// their browser and operating system. Keys default to the usual user-agent
+// keys.
+type UserAgentEnrichment struct {
+	Keys []string `json:"keys,omitempty" yaml:"keys,omitempty"`
+}
+
+// DNSEnrichment annotates the IP addresses held by Keys with their host
+// name, read from the Names file first, either a hosts file or the JSON output
+// of kubectl get pods,services -o json, then resolved through reverse DNS
+// lookups unless Offline, so that no address leaves the machine. Keys default
+// to the usual IP keys.
+type DNSEnrichment struct {
+	Names   string   `json:"names,omitempty" yaml:"names,omitempty"`
+	Offline bool     `json:"offline,omitempty" yaml:"offline,omitempty"`
+	Keys    []string `json:"keys,omitempty" yaml:"keys,omitempty"`
+}
+
+// Save writes the template to fileName. Templates extending a base one only
+// get what they don't inherit as is written (see Extends).
 func (c *Config) Save(fileName string) error {
-	b, err := yaml.Marshal(c)
+	own, err := c.own(fileName)
 	if err != nil {
 		return err
 	}
-	f, err := os.Create(fileName)
+	own.Version = SchemaVersion
+	b, err := yaml.Marshal(own)
 	if err != nil {
 		return err
 	}
-	if _, err := f.Write(b); err != nil {
+	if err := writeFileAtomic(fileName, b); err != nil {
 		return err
 	}
 	c.LastSavedName = fileName
@@ -68,6 +146,16 @@ func (c *Config) KeyMap() map[string]*Key {
 	return nk
 }
 
+// HasKey tells whether the template lays out the named key.
+func (c *Config) HasKey(name string) bool {
+	for i := range c.Keys {
+		if c.Keys[i].Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 type Color struct {
 	Foreground string `json:"foreground" yaml:"foreground"`
 	Background string `json:"background" yaml:"background"`
@@ -97,8 +185,12 @@ type Match struct {
 	Color Color  `json:"color" yaml:"color,omitempty"`
 }
 
+// ColorWhen colours the key value when it matches the MatchValue regex or,
+// if set, when the entry satisfies the When filter expression (e.g.
+// `status >= 500 AND latency > 1s`).
 type ColorWhen struct {
 	MatchValue string `json:"match-value" yaml:"match-value,omitempty"`
+	When       string `json:"when,omitempty" yaml:"when,omitempty"`
 	Color      Color  `json:"color" yaml:"color,omitempty"`
 }
 
@@ -108,9 +200,38 @@ type Key struct {
 	Layout    string      `json:"layout,omitempty" yaml:"layout,omitempty"`
 	Color     Color       `json:"color,omitempty" yaml:"color,omitempty"`
 	MaxWidth  int         `json:"max-width,omitempty" yaml:"max-width"`
+	AutoWidth bool        `json:"auto-width,omitempty" yaml:"auto-width,omitempty"`
 	ColorWhen []ColorWhen `json:"color-when,omitempty" yaml:"color-when,omitempty"`
 }
 
+// parsedPaths caches the alternatives of key names parsed into paths, so that
+// ExtractValue, which is on the rendering hot path, doesn't parse them on
+// every call. It's keyed by name rather than held by Key, which keeps keys
+// comparable whether loaded or built in code.
+var parsedPaths sync.Map
+
+// paths returns the alternatives of the key name parsed into paths, parsing
+// them once per name.
+func (k *Key) paths() [][]pathStep {
+	if p, ok := parsedPaths.Load(k.Name); ok {
+		return p.([][]pathStep)
+	}
+	var alts [][]pathStep
+	for _, alt := range strings.Split(k.Name, "|") {
+		alts = append(alts, parseKeyPath(strings.TrimSpace(alt)))
+	}
+	p, _ := parsedPaths.LoadOrStore(k.Name, alts)
+	return p.([][]pathStep)
+}
+
+// parseKeyPaths parses the names of the keys as they are loaded, see
+// Key.paths.
+func (c *Config) parseKeyPaths() {
+	for i := range c.Keys {
+		c.Keys[i].paths()
+	}
+}
+
 func GetForegroundColorName(colorable func() *Color, colorIfNone string) string {
 	k := colorable()
 	if k == nil || len(k.Foreground) < 0 {
@@ -127,30 +248,147 @@ func GetBackgroundColorName(colorable func() *Color, colorIfNone string) string
 	return k.Background
 }
 
+// ExtractValue resolves the key against the given entry and returns its string
+// representation. The key name supports:
+//   - nested paths separated by "/", e.g. "jsonPayload/message";
+//   - array selectors, e.g. "spans[0]/name" or "spans[0].name" ("[-1]" picks the last item);
+//   - dotted paths as a fallback when no literal key matches, e.g. "error.message";
+//   - alternatives separated by "|", e.g. "error.message | message | msg", where the
+//     first alternative yielding a non-empty value wins.
+//
+// The virtual FingerprintKey resolves to the entry Fingerprint.
 func (k *Key) ExtractValue(m map[string]interface{}) string {
-	kList := strings.Split(k.Name, "/")
-	var val string
-	level := m
-	for i, levelKey := range kList {
-		lv := level[levelKey]
-		if lv == nil {
+	if k.Name == FingerprintKey {
+		if _, ok := m[FingerprintKey]; !ok {
+			return Fingerprint(m)
+		}
+	}
+	for _, path := range k.paths() {
+		lv, ok := resolvePath(m, path)
+		if !ok || lv == nil {
+			continue
+		}
+		var val string
+		if v, ok := lv.(map[string]interface{}); ok {
+			b, err := json.Marshal(v)
+			if err == nil {
+				val = string(b)
+			}
+		} else {
+			val = fmt.Sprintf("%+v", lv)
+		}
+		if len(val) > 0 {
 			return val
 		}
-		if i == len(kList)-1 {
-			if v, ok := lv.(map[string]interface{}); ok {
-				b, err := json.Marshal(v)
-				if err == nil {
-					return string(b)
-				}
+	}
+	return ""
+}
+
+type pathStep struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+func parseKeyPath(name string) []pathStep {
+	var steps []pathStep
+	sb := strings.Builder{}
+	flushKey := func() {
+		if sb.Len() > 0 {
+			steps = append(steps, pathStep{key: sb.String()})
+			sb.Reset()
+		}
+	}
+	for i := 0; i < len(name); i++ {
+		switch c := name[i]; c {
+		case '/':
+			flushKey()
+		case '[':
+			end := strings.IndexByte(name[i:], ']')
+			if end < 0 {
+				sb.WriteString(name[i:])
+				i = len(name)
+				continue
+			}
+			idx, err := strconv.Atoi(name[i+1 : i+end])
+			if err != nil {
+				sb.WriteString(name[i : i+end+1])
+				i += end
+				continue
+			}
+			flushKey()
+			steps = append(steps, pathStep{index: idx, isIndex: true})
+			i += end
+			if i+1 < len(name) && name[i+1] == '.' {
+				i++
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	flushKey()
+	return steps
+}
+
+func resolvePath(m map[string]interface{}, steps []pathStep) (interface{}, bool) {
+	var level interface{} = m
+	for _, step := range steps {
+		if step.isIndex {
+			arr, ok := level.([]interface{})
+			if !ok {
+				return nil, false
+			}
+			idx := step.index
+			if idx < 0 {
+				idx = len(arr) + idx
 			}
-			return fmt.Sprintf("%+v", lv)
+			if idx < 0 || idx >= len(arr) {
+				return nil, false
+			}
+			level = arr[idx]
+			continue
+		}
+		obj, ok := level.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if level, ok = resolveKey(obj, step.key); !ok {
+			return nil, false
 		}
-		level = lv.(map[string]interface{})
 	}
-	return val
+	return level, true
 }
 
+// resolveKey looks up a literal key first, falling back to treating dots as
+// nesting separators when the literal key is absent.
+func resolveKey(m map[string]interface{}, key string) (interface{}, bool) {
+	if v, ok := m[key]; ok {
+		return v, true
+	}
+	if !strings.Contains(key, ".") {
+		return nil, false
+	}
+	var level interface{} = m
+	for _, part := range strings.Split(key, ".") {
+		obj, ok := level.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if level, ok = obj[part]; !ok {
+			return nil, false
+		}
+	}
+	return level, true
+}
+
+// MakeConfig loads the template file, along with the base templates it
+// extends, if any (see Extends). The default, empty, template is returned
+// when no file is given.
 func MakeConfig(file string) (*Config, error) {
+	return loadConfig(file, nil)
+}
+
+func loadConfig(file string, extending []string) (*Config, error) {
 	var yamlBytes []byte
 	config := Config{}
 	if len(file) > 0 {
@@ -162,7 +400,13 @@ func MakeConfig(file string) (*Config, error) {
 	} else {
 		yamlBytes = []byte("")
 	}
-	if err := yaml.Unmarshal(yamlBytes, &config); err != nil {
+	if _, err := parseTemplate(yamlBytes, &config); err != nil && !errors.Is(err, errEmptyTemplate) {
+		if len(file) > 0 {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		return nil, err
+	}
+	if err := config.resolveExtends(file, extending); err != nil {
 		return nil, err
 	}
 	config.LastSavedName = file
@@ -181,6 +425,10 @@ func (t Type) GetColorName() string {
 		return "orange"
 	case TypeDateTime:
 		return "purple"
+	case TypeDuration:
+		return "teal"
+	case TypeBytes:
+		return "aqua"
 	}
 	return "default"
 }
@@ -194,6 +442,8 @@ const (
 	TypeBool     = "bool"
 	TypeNumber   = "number"
 	TypeDateTime = "datetime"
+	TypeDuration = "duration"
+	TypeBytes    = "bytes"
 )
 
 const defaultConfig = `keys:
@@ -104,6 +104,62 @@ func TestKey_ExtractValue(t *testing.T) {
 			givenJson: []byte(`{"a":{"b":{"value": 1}}}`),
 			wantValue: "1",
 		},
+		{
+			name: "Array selector with dotted child",
+			givenKey: &Key{
+				Name: "spans[0].name",
+			},
+			givenJson: []byte(`{"spans":[{"name":"first"},{"name":"second"}]}`),
+			wantValue: "first",
+		},
+		{
+			name: "Negative array selector",
+			givenKey: &Key{
+				Name: "spans[-1]/name",
+			},
+			givenJson: []byte(`{"spans":[{"name":"first"},{"name":"second"}]}`),
+			wantValue: "second",
+		},
+		{
+			name: "Out of range array selector",
+			givenKey: &Key{
+				Name: "spans[5]/name",
+			},
+			givenJson: []byte(`{"spans":[{"name":"first"}]}`),
+			wantValue: "",
+		},
+		{
+			name: "Dotted path fallback",
+			givenKey: &Key{
+				Name: "error.message",
+			},
+			givenJson: []byte(`{"error":{"message":"boom"}}`),
+			wantValue: "boom",
+		},
+		{
+			name: "Literal dotted key wins",
+			givenKey: &Key{
+				Name: "logging.googleapis.com/trace",
+			},
+			givenJson: []byte(`{"logging.googleapis.com":{"trace":"abc"}}`),
+			wantValue: "abc",
+		},
+		{
+			name: "First non-empty alternative wins",
+			givenKey: &Key{
+				Name: "error.message | message | msg",
+			},
+			givenJson: []byte(`{"message":"","msg":"hello"}`),
+			wantValue: "hello",
+		},
+		{
+			name: "Non-object intermediate",
+			givenKey: &Key{
+				Name: "a/b/value",
+			},
+			givenJson: []byte(`{"a":"flat"}`),
+			wantValue: "",
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -116,7 +172,16 @@ func TestKey_ExtractValue(t *testing.T) {
 	}
 }
 
+func TestKey_ExtractValueRenamed(t *testing.T) {
+	m := map[string]interface{}{"error": map[string]interface{}{"message": "boom"}, "msg": "hello"}
+	k := &Key{Name: "error/message | msg"}
+	assert.Equal(t, "boom", k.ExtractValue(m))
+	k.Name = "msg | error/message"
+	assert.Equal(t, "hello", k.ExtractValue(m))
+}
+
 var defConfig = Config{
+	Version: SchemaVersion,
 	Keys: []Key{
 		{
 			Name:   "timestamp",
@@ -0,0 +1,124 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import "time"
+
+// severityRanks orders the canonical severities, unknown ones ranking lowest.
+var severityRanks = map[string]int{SeverityDebug: 1, SeverityInfo: 2, SeverityWarn: 3, SeverityError: 4}
+
+// Minimap spreads the entries over rows from the oldest to the latest, by
+// entry time when known (see EntryTime) or by position otherwise, e.g. to draw
+// a ruler of a multi-hour capture.
+type Minimap struct {
+	// From and To are the times of the first and last rows, zero when the
+	// entries are spread by position.
+	From, To time.Time
+	Rows     []MinimapRow
+	MaxCount int
+}
+
+// MinimapRow holds the entries of a row of the minimap.
+type MinimapRow struct {
+	Count int
+	// Severity is the worst severity of the entries, if any has one.
+	Severity string
+	// First is the index of the first entry of the row, -1 if empty.
+	First int
+}
+
+// MakeMinimap spreads the entries over the given number of rows, reading
+// their severity with the mapper (see SeverityMapper.Severity). Entries
+// without a time are placed along the entry before. It returns nil without
+// entries.
+func (c *Config) MakeMinimap(entries []map[string]interface{}, severities *SeverityMapper, rows int) *Minimap {
+	if rows <= 0 || len(entries) == 0 {
+		return nil
+	}
+	m := &Minimap{Rows: make([]MinimapRow, rows)}
+	for i := range m.Rows {
+		m.Rows[i].First = -1
+	}
+	times := make([]time.Time, len(entries))
+	timed := 0
+	for i, entry := range entries {
+		if at, ok := c.EntryTime(entry); ok {
+			times[i] = at
+			timed++
+			if m.From.IsZero() || at.Before(m.From) {
+				m.From = at
+			}
+			if at.After(m.To) {
+				m.To = at
+			}
+		} else if i > 0 {
+			times[i] = times[i-1]
+		}
+	}
+	span := m.To.Sub(m.From)
+	if timed < 2 || span <= 0 {
+		m.From, m.To = time.Time{}, time.Time{}
+	}
+	for i, entry := range entries {
+		var row int
+		switch {
+		case m.From.IsZero():
+			row = i * rows / len(entries)
+		case times[i].IsZero():
+			row = 0
+		default:
+			row = min(int(float64(rows)*float64(times[i].Sub(m.From))/float64(span)), rows-1)
+		}
+		r := &m.Rows[row]
+		r.Count++
+		m.MaxCount = max(m.MaxCount, r.Count)
+		if r.First < 0 {
+			r.First = i
+		}
+		if s := severities.Severity(entry); severityRanks[s] > severityRanks[r.Severity] {
+			r.Severity = s
+		}
+	}
+	return m
+}
+
+// RowTime returns the time the row starts at, zero when the entries are
+// spread by position.
+func (m *Minimap) RowTime(row int) time.Time {
+	if m.From.IsZero() {
+		return time.Time{}
+	}
+	return m.From.Add(m.To.Sub(m.From) * time.Duration(row) / time.Duration(len(m.Rows)))
+}
+
+// RowOf returns the row holding the entry at the given index, i.e. the last
+// row starting at or before it.
+func (m *Minimap) RowOf(index int) int {
+	row := 0
+	for i, r := range m.Rows {
+		if r.First >= 0 && r.First <= index {
+			row = i
+		}
+	}
+	return row
+}
@@ -0,0 +1,76 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_MakeMinimap(t *testing.T) {
+	c := &Config{Keys: []Key{{Name: "timestamp", Type: TypeDateTime}, {Name: "msg"}}}
+	entry := func(ts, level string) map[string]interface{} {
+		m := map[string]interface{}{"level": level}
+		if len(ts) > 0 {
+			m["timestamp"] = ts
+		}
+		return m
+	}
+	rows := func(m *Minimap) [][3]interface{} {
+		var r [][3]interface{}
+		for _, row := range m.Rows {
+			r = append(r, [3]interface{}{row.Count, row.Severity, row.First})
+		}
+		return r
+	}
+
+	assert.Nil(t, c.MakeMinimap(nil, nil, 4))
+
+	m := c.MakeMinimap([]map[string]interface{}{
+		entry("2024-06-01T10:00:00Z", "info"),
+		entry("2024-06-01T10:00:10Z", "error"),
+		entry("", "warn"),
+		entry("2024-06-01T10:00:50Z", "debug"),
+		entry("2024-06-01T10:01:20Z", "info"),
+	}, nil, 4)
+	assert.Equal(t, [][3]interface{}{
+		{3, SeverityError, 0},
+		{0, "", -1},
+		{1, SeverityDebug, 3},
+		{1, SeverityInfo, 4},
+	}, rows(m))
+	assert.Equal(t, 0, m.RowOf(2))
+	assert.Equal(t, 2, m.RowOf(3))
+	assert.Equal(t, 3, m.RowOf(4))
+	assert.Equal(t, 3, m.MaxCount)
+	assert.Equal(t, time.Date(2024, 6, 1, 10, 0, 40, 0, time.UTC), m.RowTime(2))
+
+	// without times, entries are spread by position
+	m = c.MakeMinimap([]map[string]interface{}{
+		entry("", "info"), entry("", "info"), entry("", "warn"),
+	}, nil, 2)
+	assert.Equal(t, [][3]interface{}{{2, SeverityInfo, 0}, {1, SeverityWarn, 2}}, rows(m))
+	assert.True(t, m.RowTime(1).IsZero())
+}
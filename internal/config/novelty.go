@@ -0,0 +1,92 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultNoveltyWarmUp is how long the fingerprints of the streamed entries
+	// are learned before new ones are flagged, unless the template sets
+	// novelty-warm-up.
+	DefaultNoveltyWarmUp = 2 * time.Minute
+	// maxFingerprints caps the fingerprints remembered, past which no entry
+	// is flagged anymore.
+	maxFingerprints = 100_000
+)
+
+// NoveltyWarmUpDuration returns the template novelty-warm-up (e.g. "5m"), or
+// DefaultNoveltyWarmUp if unset or invalid. Zero disables new-message hints.
+func (c *Config) NoveltyWarmUpDuration() time.Duration {
+	if len(c.NoveltyWarmUp) == 0 {
+		return DefaultNoveltyWarmUp
+	}
+	d, err := ParseDuration(c.NoveltyWarmUp)
+	if err != nil {
+		return DefaultNoveltyWarmUp
+	}
+	return d
+}
+
+// NoveltyTracker learns the fingerprints (see Fingerprint) of the streamed
+// entries during a warm-up window, after which the first entry of any unseen
+// fingerprint is new, e.g. a novel error showing up amid familiar noise while
+// watching a deploy.
+type NoveltyTracker struct {
+	lock    sync.Mutex
+	warmUp  time.Duration
+	started time.Time
+	seen    map[string]bool
+	now     func() time.Time
+}
+
+func NewNoveltyTracker(warmUp time.Duration) *NoveltyTracker {
+	return &NoveltyTracker{
+		warmUp: warmUp,
+		seen:   make(map[string]bool),
+		now:    time.Now,
+	}
+}
+
+// Observe records the entry fingerprint and tells whether it's new, i.e.
+// never seen before and past the warm-up window, which starts with the first
+// observed entry.
+func (n *NoveltyTracker) Observe(entry map[string]interface{}) bool {
+	if n.warmUp <= 0 {
+		return false
+	}
+	fingerprint := Fingerprint(entry)
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	now := n.now()
+	if n.started.IsZero() {
+		n.started = now
+	}
+	if n.seen[fingerprint] || len(n.seen) >= maxFingerprints {
+		return false
+	}
+	n.seen[fingerprint] = true
+	return now.Sub(n.started) >= n.warmUp
+}
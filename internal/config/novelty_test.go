@@ -0,0 +1,65 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoveltyTracker_Observe(t *testing.T) {
+	at := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		warmUp  time.Duration
+		after   time.Duration
+		message string
+		wants   bool
+	}{
+		{name: "Learning", warmUp: time.Minute, after: 30 * time.Second, message: "connection refused"},
+		{name: "Seen while learning", warmUp: time.Minute, after: 2 * time.Minute, message: "request 7 done in 12ms"},
+		{name: "New", warmUp: time.Minute, after: 2 * time.Minute, message: "connection refused", wants: true},
+		{name: "Disabled", after: 2 * time.Minute, message: "connection refused"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			n := NewNoveltyTracker(test.warmUp)
+			now := at
+			n.now = func() time.Time { return now }
+			assert.False(t, n.Observe(map[string]interface{}{"message": "request 1 done in 5ms"}))
+			now = at.Add(test.after)
+			assert.Equal(t, test.wants, n.Observe(map[string]interface{}{"message": test.message}))
+			// only the first occurrence is new
+			assert.False(t, n.Observe(map[string]interface{}{"message": test.message}))
+		})
+	}
+}
+
+func TestConfig_NoveltyWarmUpDuration(t *testing.T) {
+	assert.Equal(t, DefaultNoveltyWarmUp, (&Config{}).NoveltyWarmUpDuration())
+	assert.Equal(t, 5*time.Minute, (&Config{NoveltyWarmUp: "5m"}).NoveltyWarmUpDuration())
+	assert.Equal(t, time.Duration(0), (&Config{NoveltyWarmUp: "0"}).NoveltyWarmUpDuration())
+	assert.Equal(t, DefaultNoveltyWarmUp, (&Config{NoveltyWarmUp: "soon"}).NoveltyWarmUpDuration())
+}
@@ -0,0 +1,237 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaVersion is the version of the template schema this build reads and
+// writes. Templates declare theirs with `version`, those without predate
+// versioning and are read as version 0. Older templates are migrated as they
+// are loaded (see migrations), whereas newer ones are refused rather than
+// misread.
+const SchemaVersion = 1
+
+// migrations upgrade a template document from the version of their index to
+// the next one, working on the document nodes so that reported line numbers
+// still point at the file read.
+var migrations = []func(root *yaml.Node) error{
+	// 0 to 1: versioning was introduced, the schema is unchanged
+	func(*yaml.Node) error { return nil },
+}
+
+// SchemaError lists the problems found reading a template, each prefixed
+// with its line number, e.g. unknown options or values of the wrong type.
+type SchemaError struct {
+	Issues []string
+}
+
+func (e *SchemaError) Error() string {
+	return strings.Join(e.Issues, "; ")
+}
+
+// errEmptyTemplate is returned reading a template without any document.
+var errEmptyTemplate = errors.New("the template is empty")
+
+// parseTemplate reads the template document into c, migrated to
+// SchemaVersion, and returns the version it was written in.
+func parseTemplate(b []byte, c *Config) (int, error) {
+	root, version, err := readTemplate(b)
+	if err != nil {
+		return version, err
+	}
+	return version, decodeTemplate(root, c)
+}
+
+// readTemplate parses the template document, migrated to SchemaVersion, and
+// returns its root node along with the version it was written in.
+func readTemplate(b []byte) (*yaml.Node, int, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, 0, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, SchemaVersion, errEmptyTemplate
+	}
+	root := doc.Content[0]
+	version, err := migrate(root)
+	return root, version, err
+}
+
+// decodeTemplate decodes the template document root into c, reporting the
+// options c has no room for rather than silently ignoring them.
+func decodeTemplate(root *yaml.Node, c *Config) error {
+	var issues []string
+	checkOptions(root, reflect.TypeOf(c), &issues)
+	if err := root.Decode(c); err != nil {
+		te := (*yaml.TypeError)(nil)
+		if !errors.As(err, &te) {
+			return err
+		}
+		issues = append(issues, te.Errors...)
+	}
+	c.parseKeyPaths()
+	if len(issues) > 0 {
+		return &SchemaError{Issues: issues}
+	}
+	return nil
+}
+
+// migrate upgrades the template document to SchemaVersion, returning the
+// version it was written in.
+func migrate(root *yaml.Node) (int, error) {
+	if root.Kind != yaml.MappingNode {
+		// left for decoding to report
+		return SchemaVersion, nil
+	}
+	version := 0
+	node := mappingValue(root, "version")
+	if node != nil {
+		if err := node.Decode(&version); err != nil || version < 0 {
+			return 0, fmt.Errorf("line %d: version %q is not a schema version, e.g. %d", node.Line, node.Value, SchemaVersion)
+		}
+	}
+	if version > SchemaVersion {
+		return version, fmt.Errorf("line %d: version %d is newer than the version %d this loggo reads, please upgrade loggo",
+			node.Line, version, SchemaVersion)
+	}
+	for v := version; v < SchemaVersion; v++ {
+		if err := migrations[v](root); err != nil {
+			return version, fmt.Errorf("unable to migrate from version %d: %w", v, err)
+		}
+	}
+	if node == nil {
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"}
+		if len(root.Content) > 0 {
+			// the comment heading the template stays on top
+			key.HeadComment, root.Content[0].HeadComment = root.Content[0].HeadComment, ""
+		}
+		node = &yaml.Node{Kind: yaml.ScalarNode}
+		root.Content = append([]*yaml.Node{key, node}, root.Content...)
+	}
+	node.Tag, node.Value = "!!int", strconv.Itoa(SchemaVersion)
+	return version, nil
+}
+
+// mappingValue returns the value of the key within the mapping node, nil if
+// it has none.
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// checkOptions reports the options of the node unknown to the type it's
+// decoded into, recursing into the known ones.
+func checkOptions(n *yaml.Node, t reflect.Type, issues *[]string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t.Kind() == reflect.Slice && n.Kind == yaml.SequenceNode:
+		for _, item := range n.Content {
+			checkOptions(item, t.Elem(), issues)
+		}
+	case t.Kind() == reflect.Struct && n.Kind == yaml.MappingNode:
+		options := yamlOptions(t)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k := n.Content[i]
+			if k.Value == "<<" {
+				continue
+			}
+			option, ok := options[k.Value]
+			if !ok {
+				*issues = append(*issues, fmt.Sprintf("line %d: unknown option %s", k.Line, k.Value))
+				continue
+			}
+			checkOptions(n.Content[i+1], option, issues)
+		}
+	}
+}
+
+// yamlOptions maps the options of the struct type, as named by their yaml
+// tags, to their types.
+func yamlOptions(t reflect.Type) map[string]reflect.Type {
+	options := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, flags, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		switch {
+		case name == "-":
+		case strings.Contains(flags, "inline"):
+			for k, v := range yamlOptions(f.Type) {
+				options[k] = v
+			}
+		case len(name) == 0:
+			options[strings.ToLower(f.Name)] = f.Type
+		default:
+			options[name] = f.Type
+		}
+	}
+	return options
+}
+
+// MigrateFile rewrites the template file in the current SchemaVersion,
+// keeping its comments, unless it's already in it. It returns the version
+// the template was written in, and leaves it as is if it can't be read.
+func MigrateFile(file string) (int, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return 0, err
+	}
+	root, version, err := readTemplate(b)
+	if err != nil {
+		return version, err
+	}
+	if err := decodeTemplate(root, &Config{}); err != nil {
+		return version, err
+	}
+	if version == SchemaVersion {
+		return version, nil
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return version, err
+	}
+	if err := enc.Close(); err != nil {
+		return version, err
+	}
+	return version, writeFileAtomic(file, buf.Bytes())
+}
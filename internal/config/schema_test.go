@@ -0,0 +1,168 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMakeConfig_Schema(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"unversioned.yaml": "keys:\n  - name: level\n    type: string\n",
+		"current.yaml":     "version: 1\nkeys:\n  - name: level\n    type: string\n",
+		"newer.yaml":       "version: 7\nkeys: []\n",
+		"bad-version.yaml": "version: latest\n",
+		"unknown.yaml": `keys:
+  - name: level
+    type: string
+    colour:
+      foreground: red
+    color-when:
+      - match-value: ERROR
+        color: {fg: red}
+burst-factor: 3
+noisy_window: 1m
+`,
+		"mistyped.yaml": "keys:\n  - name: level\n    max-width: wide\n",
+	})
+	tests := []struct {
+		name        string
+		file        string
+		wantsError  string
+		wantsIssues []string
+	}{
+		{name: "unversioned", file: "unversioned.yaml"},
+		{name: "current", file: "current.yaml"},
+		{
+			name:       "newer",
+			file:       "newer.yaml",
+			wantsError: "line 1: version 7 is newer than the version 1 this loggo reads, please upgrade loggo",
+		},
+		{
+			name:       "bad version",
+			file:       "bad-version.yaml",
+			wantsError: `line 1: version "latest" is not a schema version, e.g. 1`,
+		},
+		{
+			name: "unknown options",
+			file: "unknown.yaml",
+			wantsIssues: []string{
+				"line 4: unknown option colour",
+				"line 8: unknown option fg",
+				"line 10: unknown option noisy_window",
+			},
+		},
+		{
+			name:        "mistyped value",
+			file:        "mistyped.yaml",
+			wantsIssues: []string{"line 3: cannot unmarshal !!str `wide` into int"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file := filepath.Join(dir, test.file)
+			c, err := MakeConfig(file)
+			switch {
+			case len(test.wantsError) > 0:
+				assert.EqualError(t, err, file+": "+test.wantsError)
+			case len(test.wantsIssues) > 0:
+				se := (*SchemaError)(nil)
+				if assert.ErrorAs(t, err, &se) {
+					assert.Equal(t, test.wantsIssues, se.Issues)
+				}
+				assert.ErrorContains(t, err, file+": line ")
+			default:
+				if assert.NoError(t, err) {
+					assert.Equal(t, SchemaVersion, c.Version)
+					assert.Equal(t, "level", c.Keys[0].Name)
+				}
+			}
+		})
+	}
+}
+
+func TestMakeConfig_Migrations(t *testing.T) {
+	defer func(m []func(*yaml.Node) error) { migrations = m }(migrations)
+	migrations = []func(*yaml.Node) error{
+		func(root *yaml.Node) error {
+			if n := mappingValue(root, "keys"); n != nil {
+				for _, k := range n.Content {
+					for i := 0; i < len(k.Content); i += 2 {
+						if k.Content[i].Value == "width" {
+							k.Content[i].Value = "max-width"
+						}
+					}
+				}
+			}
+			return nil
+		},
+	}
+	dir := writeTemplates(t, map[string]string{
+		"old.yaml":     "keys:\n  - name: level\n    width: 10\n",
+		"current.yaml": "version: 1\nkeys:\n  - name: level\n    width: 10\n",
+	})
+	c, err := MakeConfig(filepath.Join(dir, "old.yaml"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, 10, c.Keys[0].MaxWidth)
+	}
+	_, err = MakeConfig(filepath.Join(dir, "current.yaml"))
+	assert.ErrorContains(t, err, "line 4: unknown option width")
+}
+
+func TestMigrateFile(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"old.yaml":   "# shared by the payment services\nkeys:\n  - name: level # the severity\n    type: string\n",
+		"newer.yaml": "version: 7\n",
+	})
+	file := filepath.Join(dir, "old.yaml")
+	version, err := MigrateFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, version)
+	b, err := os.ReadFile(file)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "# shared by the payment services\nversion: 1\nkeys:\n  - name: level # the severity\n    type: string\n",
+			string(b))
+	}
+
+	version, err = MigrateFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, SchemaVersion, version)
+
+	_, err = MigrateFile(filepath.Join(dir, "newer.yaml"))
+	assert.Error(t, err)
+}
+
+func TestConfig_SaveVersion(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "saved.yaml")
+	c := &Config{Keys: []Key{{Name: "level", Type: TypeString}}}
+	assert.NoError(t, c.Save(file))
+	b, err := os.ReadFile(file)
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(b), "version: 1\n")
+	}
+}
@@ -0,0 +1,176 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Canonical severities, colorised by the default templates.
+const (
+	SeverityDebug = "DEBUG"
+	SeverityInfo  = "INFO"
+	SeverityWarn  = "WARN"
+	SeverityError = "ERROR"
+)
+
+// SeverityMapping declares how level values spelled differently across
+// services map onto the canonical severities. Keys lists the entry keys
+// holding the level, and Values maps raw values (case-insensitive) to a
+// canonical severity, on top of the usual syslog, bunyan/pino and
+// log4j spellings.
+type SeverityMapping struct {
+	Keys   []string          `json:"keys,omitempty" yaml:"keys,omitempty"`
+	Values map[string]string `json:"values,omitempty" yaml:"values,omitempty"`
+}
+
+var defaultSeverityKeys = []string{"severity", "level", "lvl", "loglevel", "jsonPayload/severity", "jsonPayload/level"}
+
+var defaultSeverityValues = map[string]string{
+	"trace": SeverityDebug, "debug": SeverityDebug, "dbg": SeverityDebug, "verbose": SeverityDebug,
+	"10": SeverityDebug, "20": SeverityDebug, "7": SeverityDebug,
+	"info": SeverityInfo, "information": SeverityInfo, "notice": SeverityInfo, "default": SeverityInfo,
+	"30": SeverityInfo, "6": SeverityInfo, "5": SeverityInfo,
+	"warn": SeverityWarn, "warning": SeverityWarn, "wrn": SeverityWarn, "40": SeverityWarn, "4": SeverityWarn,
+	"error": SeverityError, "err": SeverityError, "fatal": SeverityError, "critical": SeverityError,
+	"crit": SeverityError, "alert": SeverityError, "emerg": SeverityError, "emergency": SeverityError,
+	"panic": SeverityError, "severe": SeverityError,
+	"50": SeverityError, "60": SeverityError, "3": SeverityError, "2": SeverityError, "1": SeverityError, "0": SeverityError,
+}
+
+// SeverityMapper rewrites the level values of entries to the canonical
+// severities, see SeverityMapping.
+type SeverityMapper struct {
+	keys   [][]pathStep
+	values map[string]string
+}
+
+// MakeSeverityMapper builds the mapper declared in a template, or nil if none.
+func MakeSeverityMapper(mapping *SeverityMapping) (*SeverityMapper, error) {
+	if mapping == nil {
+		return nil, nil
+	}
+	keys := mapping.Keys
+	if len(keys) == 0 {
+		keys = defaultSeverityKeys
+	}
+	s := &SeverityMapper{values: make(map[string]string, len(defaultSeverityValues)+len(mapping.Values))}
+	for _, k := range keys {
+		s.keys = append(s.keys, parseKeyPath(k))
+	}
+	for k, v := range defaultSeverityValues {
+		s.values[k] = v
+	}
+	for k, v := range mapping.Values {
+		canonical := strings.ToUpper(strings.TrimSpace(v))
+		switch canonical {
+		case SeverityDebug, SeverityInfo, SeverityWarn, SeverityError:
+		default:
+			return nil, fmt.Errorf("unknown severity %q for %q, expected one of %s, %s, %s or %s",
+				v, k, SeverityDebug, SeverityInfo, SeverityWarn, SeverityError)
+		}
+		s.values[strings.ToLower(strings.TrimSpace(k))] = canonical
+	}
+	return s, nil
+}
+
+// Apply rewrites, in place, the level values of the entry found under the
+// mapped keys. Unknown values are left untouched.
+func (s *SeverityMapper) Apply(m map[string]interface{}) {
+	for _, steps := range s.keys {
+		obj, name, raw, ok := lookupLevel(m, steps)
+		if !ok {
+			continue
+		}
+		if canonical, ok := s.values[strings.ToLower(strings.TrimSpace(raw))]; ok {
+			obj[name] = canonical
+		}
+	}
+}
+
+// defaultSeverityMapper maps the usual level keys and spellings.
+var defaultSeverityMapper, _ = MakeSeverityMapper(&SeverityMapping{})
+
+// Severity returns the canonical severity of the entry read from the first
+// mapped key holding a known level, or an empty string. A nil mapper reads
+// the usual level keys and spellings.
+func (s *SeverityMapper) Severity(m map[string]interface{}) string {
+	if s == nil {
+		s = defaultSeverityMapper
+	}
+	for _, steps := range s.keys {
+		_, _, raw, ok := lookupLevel(m, steps)
+		if !ok {
+			continue
+		}
+		raw = strings.ToLower(strings.TrimSpace(raw))
+		if canonical, ok := s.values[raw]; ok {
+			return canonical
+		}
+		switch canonical := strings.ToUpper(raw); canonical {
+		case SeverityDebug, SeverityInfo, SeverityWarn, SeverityError:
+			// already rewritten by Apply
+			return canonical
+		}
+	}
+	return ""
+}
+
+// lookupLevel resolves the object holding the level key along with its raw
+// value.
+func lookupLevel(m map[string]interface{}, steps []pathStep) (map[string]interface{}, string, string, bool) {
+	if len(steps) == 0 || steps[len(steps)-1].isIndex {
+		return nil, "", "", false
+	}
+	parent, ok := resolvePath(m, steps[:len(steps)-1])
+	if !ok {
+		return nil, "", "", false
+	}
+	obj, ok := parent.(map[string]interface{})
+	if !ok {
+		return nil, "", "", false
+	}
+	name := steps[len(steps)-1].key
+	v, ok := obj[name]
+	if !ok && strings.Contains(name, ".") {
+		// dotted path fallback, see resolveKey
+		idx := strings.LastIndex(name, ".")
+		if parent, found := resolveKey(obj, name[:idx]); found {
+			obj, _ = parent.(map[string]interface{})
+			name = name[idx+1:]
+			v, ok = obj[name]
+		}
+	}
+	if !ok {
+		return nil, "", "", false
+	}
+	switch t := v.(type) {
+	case string:
+		return obj, name, t, true
+	case float64:
+		return obj, name, strconv.FormatFloat(t, 'f', -1, 64), true
+	}
+	return nil, "", "", false
+}
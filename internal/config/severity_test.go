@@ -0,0 +1,123 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeverityMapper_Apply(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping *SeverityMapping
+		given   string
+		wants   string
+	}{
+		{
+			name:    "Warning spelled out",
+			mapping: &SeverityMapping{},
+			given:   `{"level":"warning"}`,
+			wants:   `{"level":"WARN"}`,
+		},
+		{
+			name:    "Pino numeric level",
+			mapping: &SeverityMapping{},
+			given:   `{"level":50,"msg":"boom"}`,
+			wants:   `{"level":"ERROR","msg":"boom"}`,
+		},
+		{
+			name:    "Nested GCP payload",
+			mapping: &SeverityMapping{},
+			given:   `{"jsonPayload":{"severity":"Notice"}}`,
+			wants:   `{"jsonPayload":{"severity":"INFO"}}`,
+		},
+		{
+			name:    "Custom value",
+			mapping: &SeverityMapping{Values: map[string]string{"Oops": "error", "fatal": "warn"}},
+			given:   `{"severity":"OOPS","lvl":"FATAL"}`,
+			wants:   `{"severity":"ERROR","lvl":"WARN"}`,
+		},
+		{
+			name:    "Custom keys",
+			mapping: &SeverityMapping{Keys: []string{"log.level"}},
+			given:   `{"log":{"level":"warn"},"level":"warning"}`,
+			wants:   `{"log":{"level":"WARN"},"level":"warning"}`,
+		},
+		{
+			name:    "Unknown value left untouched",
+			mapping: &SeverityMapping{},
+			given:   `{"level":"chatty","severity":true}`,
+			wants:   `{"level":"chatty","severity":true}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := MakeSeverityMapper(tt.mapping)
+			assert.NoError(t, err)
+			m := make(map[string]interface{})
+			assert.NoError(t, json.Unmarshal([]byte(tt.given), &m))
+			s.Apply(m)
+			wants := make(map[string]interface{})
+			assert.NoError(t, json.Unmarshal([]byte(tt.wants), &wants))
+			assert.Equal(t, wants, m)
+		})
+	}
+}
+
+func TestMakeSeverityMapper(t *testing.T) {
+	s, err := MakeSeverityMapper(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, s)
+
+	_, err = MakeSeverityMapper(&SeverityMapping{Values: map[string]string{"x": "LOUD"}})
+	assert.Error(t, err)
+}
+
+func TestSeverityMapper_Severity(t *testing.T) {
+	custom, err := MakeSeverityMapper(&SeverityMapping{Keys: []string{"status"}, Values: map[string]string{"bad": "error"}})
+	assert.NoError(t, err)
+	tests := []struct {
+		name   string
+		mapper *SeverityMapper
+		given  string
+		wants  string
+	}{
+		{name: "Default keys", given: `{"level":"err"}`, wants: SeverityError},
+		{name: "Already canonical", given: `{"severity":"WARN"}`, wants: SeverityWarn},
+		{name: "Pino numeric level", given: `{"level":30}`, wants: SeverityInfo},
+		{name: "Nested GCP payload", given: `{"jsonPayload":{"level":"fatal"}}`, wants: SeverityError},
+		{name: "Unknown value", given: `{"level":"chatty","severity":"debug"}`, wants: SeverityDebug},
+		{name: "No level", given: `{"msg":"hi"}`, wants: ""},
+		{name: "Custom mapping", mapper: custom, given: `{"status":"BAD","level":"info"}`, wants: SeverityError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := make(map[string]interface{})
+			assert.NoError(t, json.Unmarshal([]byte(test.given), &m))
+			assert.Equal(t, test.wants, test.mapper.Severity(m))
+		})
+	}
+}
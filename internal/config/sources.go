@@ -0,0 +1,182 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultNoisyWindow is the period the lines of each source are counted
+	// over, unless the template sets noisy-window.
+	DefaultNoisyWindow = 5 * time.Minute
+	// maxSourcesPerMinute caps the distinct sources counted each minute.
+	maxSourcesPerMinute = 10_000
+)
+
+// sourceKeys are the entry keys checked, in order, for the component logging
+// the entry, unless the template sets source-key.
+var sourceKeys = []string{
+	"resource/labels/pod_name", "kubernetes/pod_name", "pod", "pod_name",
+	"dyno", "source", "file", "filename", "logName", "service", "host",
+}
+
+// NoisyWindowDuration returns the template noisy-window (e.g. "10m"), or
+// DefaultNoisyWindow if unset or invalid.
+func (c *Config) NoisyWindowDuration() time.Duration {
+	d, err := ParseDuration(c.NoisyWindow)
+	if len(c.NoisyWindow) == 0 || err != nil || d <= 0 {
+		return DefaultNoisyWindow
+	}
+	return d
+}
+
+// SourceCount is the number of lines logged by a source within the window.
+type SourceCount struct {
+	// Key is the entry key holding the source, e.g. "resource/labels/pod_name".
+	Key   string
+	Value string
+	Lines int64
+	// Share is the ratio of all the lines within the window.
+	Share float64
+}
+
+type sourceID struct {
+	key   string
+	value string
+}
+
+// SourceStats counts, per minute, the lines logged by each source (pod, file,
+// logName...) so that chatty components drowning the stream stand out.
+type SourceStats struct {
+	lock    sync.Mutex
+	key     string
+	window  time.Duration
+	minutes map[int64]map[sourceID]int64
+	now     func() time.Time
+}
+
+func NewSourceStats(window time.Duration) *SourceStats {
+	return &SourceStats{
+		window:  window,
+		minutes: make(map[int64]map[sourceID]int64),
+		now:     time.Now,
+	}
+}
+
+// Configure sets the key holding the source, e.g. "k8s/pod", or the usual
+// ones if empty, and the window lines are counted over.
+func (s *SourceStats) Configure(key string, window time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.key = key
+	s.window = window
+}
+
+// Window returns the period lines are counted over.
+func (s *SourceStats) Window() time.Duration {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.window
+}
+
+// Observe counts the entry for its source, if any.
+func (s *SourceStats) Observe(entry map[string]interface{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	keys := sourceKeys
+	if len(s.key) > 0 {
+		keys = []string{s.key}
+	}
+	var id sourceID
+	for _, k := range keys {
+		if v := (&Key{Name: k}).ExtractValue(entry); len(v) > 0 {
+			id = sourceID{key: k, value: v}
+			break
+		}
+	}
+	if len(id.key) == 0 {
+		return
+	}
+	minute := s.now().Unix() / 60
+	counts, ok := s.minutes[minute]
+	if !ok {
+		counts = make(map[sourceID]int64)
+		s.minutes[minute] = counts
+		s.expire(minute)
+	}
+	if _, ok := counts[id]; ok || len(counts) < maxSourcesPerMinute {
+		counts[id]++
+	}
+}
+
+// expire drops the minutes that fell out of the window.
+func (s *SourceStats) expire(minute int64) {
+	for m := range s.minutes {
+		if m <= minute-s.windowMinutes() {
+			delete(s.minutes, m)
+		}
+	}
+}
+
+func (s *SourceStats) windowMinutes() int64 {
+	if n := int64((s.window + time.Minute - 1) / time.Minute); n > 0 {
+		return n
+	}
+	return 1
+}
+
+// Top returns the n sources logging the most lines within the window, the
+// noisiest first.
+func (s *SourceStats) Top(n int) []SourceCount {
+	s.lock.Lock()
+	minute := s.now().Unix() / 60
+	totals := make(map[sourceID]int64)
+	var all int64
+	for m, counts := range s.minutes {
+		if m <= minute-s.windowMinutes() {
+			continue
+		}
+		for id, c := range counts {
+			totals[id] += c
+			all += c
+		}
+	}
+	s.lock.Unlock()
+	top := make([]SourceCount, 0, len(totals))
+	for id, c := range totals {
+		top = append(top, SourceCount{Key: id.key, Value: id.value, Lines: c, Share: float64(c) / float64(all)})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Lines != top[j].Lines {
+			return top[i].Lines > top[j].Lines
+		}
+		return top[i].Value < top[j].Value
+	})
+	if len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
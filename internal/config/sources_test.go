@@ -0,0 +1,89 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceStats_Top(t *testing.T) {
+	pod := func(name string) map[string]interface{} {
+		return map[string]interface{}{"resource": map[string]interface{}{"labels": map[string]interface{}{"pod_name": name}}}
+	}
+	tests := []struct {
+		name    string
+		key     string
+		entries []map[string]interface{}
+		wants   []SourceCount
+	}{
+		{
+			name:    "Usual keys",
+			entries: []map[string]interface{}{pod("api-1"), pod("api-1"), pod("api-1"), {"file": "app.log"}, {"msg": "no source"}},
+			wants: []SourceCount{
+				{Key: "resource/labels/pod_name", Value: "api-1", Lines: 3, Share: 0.75},
+				{Key: "file", Value: "app.log", Lines: 1, Share: 0.25},
+			},
+		},
+		{
+			name:    "Template source key",
+			key:     "svc",
+			entries: []map[string]interface{}{{"svc": "a", "file": "x"}, {"file": "x"}},
+			wants:   []SourceCount{{Key: "svc", Value: "a", Lines: 1, Share: 1}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := NewSourceStats(time.Minute)
+			s.Configure(test.key, time.Minute)
+			for _, e := range test.entries {
+				s.Observe(e)
+			}
+			assert.Equal(t, test.wants, s.Top(5))
+		})
+	}
+}
+
+func TestSourceStats_Window(t *testing.T) {
+	now := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	s := NewSourceStats(2 * time.Minute)
+	s.now = func() time.Time { return now }
+	s.Observe(map[string]interface{}{"source": "old"})
+	now = now.Add(time.Minute)
+	s.Observe(map[string]interface{}{"source": "recent"})
+	assert.Len(t, s.Top(5), 2)
+
+	now = now.Add(time.Minute)
+	s.Observe(map[string]interface{}{"source": "new"})
+	top := s.Top(1)
+	assert.Equal(t, []SourceCount{{Key: "source", Value: "new", Lines: 1, Share: 0.5}}, top)
+	assert.Len(t, s.minutes, 2)
+}
+
+func TestConfig_NoisyWindowDuration(t *testing.T) {
+	assert.Equal(t, DefaultNoisyWindow, (&Config{}).NoisyWindowDuration())
+	assert.Equal(t, 10*time.Minute, (&Config{NoisyWindow: "10m"}).NoisyWindowDuration())
+	assert.Equal(t, DefaultNoisyWindow, (&Config{NoisyWindow: "0"}).NoisyWindowDuration())
+}
@@ -0,0 +1,117 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"time"
+)
+
+const (
+	// BoundaryDay marks where entries of a new day start.
+	BoundaryDay = "day"
+	// BoundaryHour marks where entries of a new hour start.
+	BoundaryHour = "hour"
+)
+
+// DefaultGapThreshold is the quiet period between consecutive entries past
+// which a time gap is flagged, unless the template sets gap-threshold.
+const DefaultGapThreshold = time.Minute
+
+// GapThresholdDuration returns the template gap-threshold (e.g. "30s"), or
+// DefaultGapThreshold if unset or invalid. Zero disables time gaps.
+func (c *Config) GapThresholdDuration() time.Duration {
+	if len(c.GapThreshold) == 0 {
+		return DefaultGapThreshold
+	}
+	d, err := ParseDuration(c.GapThreshold)
+	if err != nil {
+		return DefaultGapThreshold
+	}
+	return d
+}
+
+// TimeGap returns the time elapsed between two consecutive entries according
+// to the first datetime key of the template, and whether it exceeds the gap
+// threshold, e.g. a quiet period or a service restart.
+func (c *Config) TimeGap(prev, next map[string]interface{}) (time.Duration, bool) {
+	threshold := c.GapThresholdDuration()
+	if threshold <= 0 {
+		return 0, false
+	}
+	from, to, ok := c.entryTimes(prev, next)
+	if !ok {
+		return 0, false
+	}
+	gap := to.Sub(from)
+	if gap < 0 {
+		// entries streamed newest first
+		gap = -gap
+	}
+	return gap, gap > threshold
+}
+
+// TimeBoundary tells whether a day (or hour) boundary, as set by the template
+// boundaries, lies between two consecutive entries, returning the label of
+// the one next starts, e.g. "2024-06-02" or "2024-06-02 14:00". Boundaries
+// are taken in the time zone the entries are logged in.
+func (c *Config) TimeBoundary(prev, next map[string]interface{}) (string, bool) {
+	var layout string
+	switch c.Boundaries {
+	case BoundaryDay:
+		layout = "2006-01-02"
+	case BoundaryHour:
+		layout = "2006-01-02 15:00"
+	default:
+		return "", false
+	}
+	from, to, ok := c.entryTimes(prev, next)
+	if !ok {
+		return "", false
+	}
+	label := to.Format(layout)
+	return label, from.Format(layout) != label
+}
+
+// entryTimes parses the first datetime key of the template out of both
+// entries.
+func (c *Config) entryTimes(prev, next map[string]interface{}) (time.Time, time.Time, bool) {
+	from, ok := c.EntryTime(prev)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	to, ok := c.EntryTime(next)
+	return from, to, ok
+}
+
+// EntryTime parses the first datetime key of the template out of the entry.
+func (c *Config) EntryTime(entry map[string]interface{}) (time.Time, bool) {
+	for i := range c.Keys {
+		k := &c.Keys[i]
+		if k.Type != TypeDateTime {
+			continue
+		}
+		t, err := ParseTime(k.Layout, k.ExtractValue(entry))
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,204 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_TimeGap(t *testing.T) {
+	keys := []Key{
+		{Name: "severity"},
+		{Name: "timestamp", Type: TypeDateTime},
+	}
+	tests := []struct {
+		name      string
+		threshold string
+		keys      []Key
+		prev      string
+		next      string
+		gap       time.Duration
+		exceeds   bool
+	}{
+		{
+			name:    "within default threshold",
+			keys:    keys,
+			prev:    "2024-06-01T10:00:00Z",
+			next:    "2024-06-01T10:00:30Z",
+			gap:     30 * time.Second,
+			exceeds: false,
+		},
+		{
+			name:    "beyond default threshold",
+			keys:    keys,
+			prev:    "2024-06-01T10:00:00Z",
+			next:    "2024-06-01T10:05:00Z",
+			gap:     5 * time.Minute,
+			exceeds: true,
+		},
+		{
+			name:    "newest first",
+			keys:    keys,
+			prev:    "2024-06-01T10:05:00Z",
+			next:    "2024-06-01T10:00:00Z",
+			gap:     5 * time.Minute,
+			exceeds: true,
+		},
+		{
+			name:      "custom threshold",
+			threshold: "10s",
+			keys:      keys,
+			prev:      "2024-06-01T10:00:00Z",
+			next:      "2024-06-01T10:00:30Z",
+			gap:       30 * time.Second,
+			exceeds:   true,
+		},
+		{
+			name:      "disabled",
+			threshold: "0",
+			keys:      keys,
+			prev:      "2024-06-01T10:00:00Z",
+			next:      "2024-06-01T10:05:00Z",
+		},
+		{
+			name:    "layout",
+			keys:    []Key{{Name: "timestamp", Type: TypeDateTime, Layout: "02/01/2006 15:04:05"}},
+			prev:    "01/06/2024 10:00:00",
+			next:    "01/06/2024 12:00:00",
+			gap:     2 * time.Hour,
+			exceeds: true,
+		},
+		{
+			name: "unparsable",
+			keys: keys,
+			prev: "2024-06-01T10:00:00Z",
+			next: "",
+		},
+		{
+			name: "no datetime key",
+			keys: []Key{{Name: "timestamp"}},
+			prev: "2024-06-01T10:00:00Z",
+			next: "2024-06-01T10:05:00Z",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &Config{Keys: test.keys, GapThreshold: test.threshold}
+			gap, exceeds := c.TimeGap(
+				map[string]interface{}{"timestamp": test.prev},
+				map[string]interface{}{"timestamp": test.next})
+			assert.Equal(t, test.gap, gap)
+			assert.Equal(t, test.exceeds, exceeds)
+		})
+	}
+}
+
+func TestConfig_TimeBoundary(t *testing.T) {
+	keys := []Key{{Name: "timestamp", Type: TypeDateTime}}
+	tests := []struct {
+		name       string
+		boundaries string
+		prev       string
+		next       string
+		label      string
+		crosses    bool
+	}{
+		{
+			name:       "same day",
+			boundaries: BoundaryDay,
+			prev:       "2024-06-01T10:00:00Z",
+			next:       "2024-06-01T23:59:59Z",
+			label:      "2024-06-01",
+		},
+		{
+			name:       "next day",
+			boundaries: BoundaryDay,
+			prev:       "2024-06-01T23:59:59Z",
+			next:       "2024-06-02T00:00:01Z",
+			label:      "2024-06-02",
+			crosses:    true,
+		},
+		{
+			name:       "logged time zone",
+			boundaries: BoundaryDay,
+			prev:       "2024-06-01T23:00:00+10:00",
+			next:       "2024-06-02T01:00:00+10:00",
+			label:      "2024-06-02",
+			crosses:    true,
+		},
+		{
+			name:       "next hour",
+			boundaries: BoundaryHour,
+			prev:       "2024-06-01T10:59:59Z",
+			next:       "2024-06-01T11:00:00Z",
+			label:      "2024-06-01 11:00",
+			crosses:    true,
+		},
+		{
+			name:       "same hour",
+			boundaries: BoundaryHour,
+			prev:       "2024-06-01T10:00:00Z",
+			next:       "2024-06-01T10:59:59Z",
+			label:      "2024-06-01 10:00",
+		},
+		{
+			name: "disabled",
+			prev: "2024-06-01T23:59:59Z",
+			next: "2024-06-02T00:00:01Z",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &Config{Keys: keys, Boundaries: test.boundaries}
+			label, crosses := c.TimeBoundary(
+				map[string]interface{}{"timestamp": test.prev},
+				map[string]interface{}{"timestamp": test.next})
+			assert.Equal(t, test.label, label)
+			assert.Equal(t, test.crosses, crosses)
+		})
+	}
+}
+
+func TestConfig_EntryTime(t *testing.T) {
+	c := &Config{Keys: []Key{
+		{Name: "severity"},
+		{Name: "timestamp", Type: TypeDateTime},
+		{Name: "receivedAt", Type: TypeDateTime},
+	}}
+	at, ok := c.EntryTime(map[string]interface{}{
+		"timestamp":  "2024-06-01T10:00:00Z",
+		"receivedAt": "2024-06-01T10:00:05Z",
+	})
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC), at)
+
+	_, ok = c.EntryTime(map[string]interface{}{"receivedAt": "2024-06-01T10:00:05Z"})
+	assert.False(t, ok)
+	_, ok = (&Config{Keys: []Key{{Name: "timestamp"}}}).EntryTime(map[string]interface{}{
+		"timestamp": "2024-06-01T10:00:00Z",
+	})
+	assert.False(t, ok)
+}
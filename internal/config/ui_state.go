@@ -0,0 +1,81 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package config
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UIState is where the user left the view over a source, restored when
+// reopening the same source.
+type UIState struct {
+	Source    string `yaml:"source"`
+	Filter    string `yaml:"filter,omitempty"`
+	Keys      []Key  `yaml:"keys,omitempty"`
+	Row       int    `yaml:"row,omitempty"`
+	Offset    int    `yaml:"offset,omitempty"`
+	Following bool   `yaml:"following,omitempty"`
+	Detail    bool   `yaml:"detail,omitempty"`
+}
+
+// UIStateFile returns the file, within dir, where the UI state over the source
+// of the given signature is persisted.
+func UIStateFile(dir, signature string) string {
+	sum := sha1.Sum([]byte(signature))
+	return filepath.Join(dir, fmt.Sprintf("%x.yaml", sum[:8]))
+}
+
+// SaveUIState persists the UI state over the source of the given signature
+// within dir, replacing the previous one.
+func SaveUIState(dir, signature string, s *UIState) error {
+	s.Source = signature
+	b, err := yaml.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(UIStateFile(dir, signature), b)
+}
+
+// LoadUIState returns the UI state over the source of the given signature
+// persisted within dir, or nil if there is none.
+func LoadUIState(dir, signature string) (*UIState, error) {
+	b, err := os.ReadFile(UIStateFile(dir, signature))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	s := &UIState{}
+	if err := yaml.Unmarshal(b, s); err != nil {
+		return nil, err
+	}
+	if s.Source != signature {
+		// unlikely hash collision, not our source
+		return nil, nil
+	}
+	return s, nil
+}
@@ -0,0 +1,56 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUIState(t *testing.T) {
+	dir := t.TempDir()
+	s, err := LoadUIState(dir, "stream /var/log/app.log")
+	assert.NoError(t, err)
+	assert.Nil(t, s)
+
+	saved := &UIState{
+		Filter: `severity == "ERROR"`,
+		Keys:   []Key{{Name: "message", Type: TypeString, MaxWidth: 40}},
+		Row:    42,
+		Offset: 30,
+		Detail: true,
+	}
+	assert.NoError(t, SaveUIState(dir, "stream /var/log/app.log", saved))
+	s, err = LoadUIState(dir, "stream /var/log/app.log")
+	assert.NoError(t, err)
+	assert.Equal(t, saved, s)
+
+	s, err = LoadUIState(dir, "stream /var/log/other.log")
+	assert.NoError(t, err)
+	assert.Nil(t, s)
+}
+
+func TestUIStateFile(t *testing.T) {
+	assert.NotEqual(t, UIStateFile("state", "stream a.log"), UIStateFile("state", "stream b.log"))
+	assert.Equal(t, UIStateFile("state", "stream a.log"), UIStateFile("state", "stream a.log"))
+}
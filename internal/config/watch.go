@@ -0,0 +1,233 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultCaptureWindow is the period captured before and after a match,
+// unless the capture sets before or after.
+const DefaultCaptureWindow = 30 * time.Second
+
+// Watch is a rule flagging the entries matching its filter expression, e.g.
+// `when: status >= 500`. Watches with a capture snapshot the entries
+// surrounding each match into a file, so that rare intermittent errors are
+// caught along with their context while nobody is looking.
+type Watch struct {
+	Name    string   `json:"name" yaml:"name"`
+	When    string   `json:"when" yaml:"when"`
+	Capture *Capture `json:"capture,omitempty" yaml:"capture,omitempty"`
+}
+
+// Capture sets the periods snapshot before and after a match, e.g. "1m", and
+// the directory the snapshots are written into, the working one if unset.
+type Capture struct {
+	Before string `json:"before,omitempty" yaml:"before,omitempty"`
+	After  string `json:"after,omitempty" yaml:"after,omitempty"`
+	Dir    string `json:"dir,omitempty" yaml:"dir,omitempty"`
+}
+
+// Window returns the periods captured before and after a match, or
+// DefaultCaptureWindow for the ones unset or invalid.
+func (c *Capture) Window() (before, after time.Duration) {
+	period := func(value string) time.Duration {
+		d, err := ParseDuration(value)
+		if len(value) == 0 || err != nil || d < 0 {
+			return DefaultCaptureWindow
+		}
+		return d
+	}
+	return period(c.Before), period(c.After)
+}
+
+// Captured is the snapshot of the entries surrounding a watch match.
+type Captured struct {
+	Watch   string
+	Dir     string
+	At      time.Time
+	Entries []map[string]interface{}
+}
+
+type arrival struct {
+	at    time.Time
+	entry map[string]interface{}
+}
+
+type openCapture struct {
+	Captured
+	until time.Time
+}
+
+// CaptureBuffer keeps the entries received over the longest period captured
+// before a match, so a capture starts with them, then collects the entries
+// received until the period after it elapses. A watch matching again while
+// its capture is open doesn't open another one.
+type CaptureBuffer struct {
+	lock   sync.Mutex
+	keep   time.Duration
+	recent []arrival
+	open   []*openCapture
+	now    func() time.Time
+}
+
+// NewCaptureBuffer buffers enough entries for the captures of the watches,
+// or returns nil if none captures.
+func NewCaptureBuffer(watches []Watch) *CaptureBuffer {
+	var b *CaptureBuffer
+	for _, w := range watches {
+		if w.Capture == nil {
+			continue
+		}
+		if b == nil {
+			b = &CaptureBuffer{now: time.Now}
+		}
+		before, _ := w.Capture.Window()
+		b.keep = max(b.keep, before)
+	}
+	return b
+}
+
+// Observe records an entry received, adding it to the open captures.
+func (b *CaptureBuffer) Observe(entry map[string]interface{}) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	now := b.now()
+	b.recent = append(b.recent, arrival{at: now, entry: entry})
+	i := 0
+	for i < len(b.recent) && now.Sub(b.recent[i].at) > b.keep {
+		i++
+	}
+	b.recent = b.recent[i:]
+	for _, c := range b.open {
+		c.Entries = append(c.Entries, entry)
+	}
+}
+
+// Trigger opens a capture for the watch, starting with the entries received
+// within its period before, the entry matching being the latest observed. It
+// tells whether a capture was opened.
+func (b *CaptureBuffer) Trigger(w *Watch) bool {
+	if w.Capture == nil {
+		return false
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for _, c := range b.open {
+		if c.Watch == w.Name {
+			return false
+		}
+	}
+	now := b.now()
+	before, after := w.Capture.Window()
+	c := &openCapture{
+		Captured: Captured{Watch: w.Name, Dir: w.Capture.Dir, At: now},
+		until:    now.Add(after),
+	}
+	for _, a := range b.recent {
+		if now.Sub(a.at) <= before {
+			c.Entries = append(c.Entries, a.entry)
+		}
+	}
+	b.open = append(b.open, c)
+	return true
+}
+
+// Due returns the captures whose period after the match elapsed, closing
+// them.
+func (b *CaptureBuffer) Due() []Captured {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	now := b.now()
+	var due []Captured
+	open := b.open[:0]
+	for _, c := range b.open {
+		if now.Before(c.until) {
+			open = append(open, c)
+			continue
+		}
+		due = append(due, c.Captured)
+	}
+	clear(b.open[len(open):])
+	b.open = open
+	return due
+}
+
+// WatchCount is the tally of the entries a watch matched.
+type WatchCount struct {
+	Name    string
+	Matches int64
+	// Last is when the latest match was observed, zero if none.
+	Last time.Time
+}
+
+// WatchCounter tallies the matches of each watch since it started counting,
+// e.g. to tell a rule stayed clean throughout a deploy.
+type WatchCounter struct {
+	lock    sync.Mutex
+	started time.Time
+	counts  []WatchCount
+	index   map[string]int
+	now     func() time.Time
+}
+
+func NewWatchCounter(watches []Watch) *WatchCounter {
+	c := &WatchCounter{index: make(map[string]int, len(watches)), now: time.Now}
+	for _, w := range watches {
+		if _, ok := c.index[w.Name]; !ok {
+			c.index[w.Name] = len(c.counts)
+			c.counts = append(c.counts, WatchCount{Name: w.Name})
+		}
+	}
+	c.started = c.now()
+	return c
+}
+
+// Match counts a match of the watch.
+func (c *WatchCounter) Match(name string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if i, ok := c.index[name]; ok {
+		c.counts[i].Matches++
+		c.counts[i].Last = c.now()
+	}
+}
+
+// Counts returns the tallies, in the order the watches are declared, along
+// with when the counting started.
+func (c *WatchCounter) Counts() ([]WatchCount, time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]WatchCount(nil), c.counts...), c.started
+}
+
+// Reset starts counting over.
+func (c *WatchCounter) Reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for i := range c.counts {
+		c.counts[i].Matches, c.counts[i].Last = 0, time.Time{}
+	}
+	c.started = c.now()
+}
@@ -0,0 +1,111 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapture_Window(t *testing.T) {
+	before, after := (&Capture{Before: "1m", After: "soon"}).Window()
+	assert.Equal(t, time.Minute, before)
+	assert.Equal(t, DefaultCaptureWindow, after)
+}
+
+func TestNewCaptureBuffer(t *testing.T) {
+	assert.Nil(t, NewCaptureBuffer([]Watch{{Name: "errors", When: `level == "ERROR"`}}))
+	b := NewCaptureBuffer([]Watch{
+		{Name: "errors", When: `level == "ERROR"`, Capture: &Capture{Before: "10s"}},
+		{Name: "panics", When: `message CONTAINS "panic"`, Capture: &Capture{Before: "2m"}},
+	})
+	if assert.NotNil(t, b) {
+		assert.Equal(t, 2*time.Minute, b.keep)
+	}
+}
+
+func TestCaptureBuffer(t *testing.T) {
+	now := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	watch := Watch{Name: "errors", When: `level == "ERROR"`, Capture: &Capture{Before: "10s", After: "5s"}}
+	b := NewCaptureBuffer([]Watch{watch})
+	b.now = func() time.Time { return now }
+	observe := func(n int) {
+		b.Observe(map[string]interface{}{"n": n})
+		now = now.Add(time.Second)
+	}
+	numbers := func(c Captured) []int {
+		var ns []int
+		for _, e := range c.Entries {
+			ns = append(ns, e["n"].(int))
+		}
+		return ns
+	}
+
+	for n := 0; n < 20; n++ {
+		observe(n)
+	}
+	// the entry matching is the latest observed
+	now = now.Add(-time.Second)
+	assert.True(t, b.Trigger(&watch))
+	now = now.Add(time.Second)
+	assert.False(t, b.Trigger(&watch), "the capture is open")
+	assert.False(t, b.Trigger(&Watch{Name: "info", When: `level == "INFO"`}), "the watch doesn't capture")
+	assert.Len(t, b.recent, 11)
+
+	for n := 20; n < 23; n++ {
+		observe(n)
+	}
+	assert.Empty(t, b.Due())
+	observe(23)
+	due := b.Due()
+	if assert.Len(t, due, 1) {
+		assert.Equal(t, "errors", due[0].Watch)
+		assert.Equal(t, []int{9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23}, numbers(due[0]))
+	}
+	assert.Empty(t, b.Due())
+	assert.True(t, b.Trigger(&watch), "the capture was closed")
+}
+
+func TestWatchCounter(t *testing.T) {
+	now := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	started := now
+	c := NewWatchCounter([]Watch{{Name: "5xx"}, {Name: "panics"}})
+	c.now = func() time.Time { return now }
+	c.started = started
+
+	now = now.Add(time.Minute)
+	c.Match("5xx")
+	now = now.Add(time.Minute)
+	c.Match("5xx")
+	c.Match("unknown")
+	counts, since := c.Counts()
+	assert.Equal(t, []WatchCount{{Name: "5xx", Matches: 2, Last: now}, {Name: "panics"}}, counts)
+	assert.Equal(t, started, since)
+
+	c.Reset()
+	counts, since = c.Counts()
+	assert.Equal(t, []WatchCount{{Name: "5xx"}, {Name: "panics"}}, counts)
+	assert.Equal(t, now, since)
+}
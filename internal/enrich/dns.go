@@ -0,0 +1,188 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package enrich
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/badaniya/loggo/internal/config"
+)
+
+// dnsCacheSize bounds the resolved addresses kept, failed lookups included so
+// that unknown addresses aren't resolved over and over.
+const dnsCacheSize = 10000
+
+// dnsTimeout bounds reverse lookups, which hold the ingestion back.
+const dnsTimeout = 500 * time.Millisecond
+
+// dnsNames annotates IP addresses with their host name (_host key), read from
+// a names file, e.g. mapping pod IPs to pod names, or resolved through reverse
+// DNS lookups.
+type dnsNames struct {
+	sources    []source
+	names      map[string]string
+	offline    bool
+	lookupAddr func(ctx context.Context, addr string) ([]string, error)
+	lock       sync.Mutex
+	cache      map[string]string
+}
+
+func makeDNS(e *config.DNSEnrichment) (*dnsNames, error) {
+	d := &dnsNames{
+		sources:    makeSources(e.Keys, defaultIPKeys),
+		names:      make(map[string]string),
+		offline:    e.Offline,
+		lookupAddr: net.DefaultResolver.LookupAddr,
+		cache:      make(map[string]string),
+	}
+	if len(e.Names) > 0 {
+		b, err := os.ReadFile(e.Names)
+		if err != nil {
+			return nil, fmt.Errorf("dns: %w", err)
+		}
+		if err := d.readNames(b); err != nil {
+			return nil, fmt.Errorf("dns: %s: %w", e.Names, err)
+		}
+	}
+	return d, nil
+}
+
+func (d *dnsNames) apply(m map[string]interface{}) {
+	for _, s := range d.sources {
+		value := s.key.ExtractValue(m)
+		if len(value) == 0 {
+			continue
+		}
+		set(m, s.prefix+"host", d.lookup(value))
+	}
+}
+
+func (d *dnsNames) lookup(value string) string {
+	ip := parseIP(value)
+	if ip == nil {
+		return ""
+	}
+	addr := ip.String()
+	if name, ok := d.names[addr]; ok || d.offline {
+		return name
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if name, ok := d.cache[addr]; ok {
+		return name
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dnsTimeout)
+	defer cancel()
+	name := ""
+	if names, err := d.lookupAddr(ctx, addr); err == nil && len(names) > 0 {
+		name = strings.TrimSuffix(names[0], ".")
+	}
+	if len(d.cache) >= dnsCacheSize {
+		d.cache = make(map[string]string)
+	}
+	d.cache[addr] = name
+	return name
+}
+
+// readNames reads either a hosts file, with an address followed by its names
+// per line, or the JSON output of kubectl get pods,services -o json, naming
+// pods and services IPs namespace/name.
+func (d *dnsNames) readNames(b []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		return d.readKubernetes(b)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		ip := net.ParseIP(fields[0])
+		if ip == nil {
+			return fmt.Errorf("invalid address %q", fields[0])
+		}
+		d.names[ip.String()] = fields[1]
+	}
+	return scanner.Err()
+}
+
+type kubernetesList struct {
+	Items []struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Spec struct {
+			ClusterIPs  []string `json:"clusterIPs"`
+			HostNetwork bool     `json:"hostNetwork"`
+		} `json:"spec"`
+		Status struct {
+			PodIPs []struct {
+				IP string `json:"ip"`
+			} `json:"podIPs"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+func (d *dnsNames) readKubernetes(b []byte) error {
+	list := kubernetesList{}
+	if err := json.Unmarshal(b, &list); err != nil {
+		return err
+	}
+	for _, item := range list.Items {
+		name := item.Metadata.Name
+		if len(item.Metadata.Namespace) > 0 {
+			name = item.Metadata.Namespace + "/" + name
+		}
+		var ips []string
+		switch item.Kind {
+		case "Pod":
+			// host network pods share the node address.
+			if item.Spec.HostNetwork {
+				continue
+			}
+			for _, p := range item.Status.PodIPs {
+				ips = append(ips, p.IP)
+			}
+		case "Service":
+			ips = item.Spec.ClusterIPs
+		}
+		for _, s := range ips {
+			if ip := net.ParseIP(s); ip != nil {
+				d.names[ip.String()] = name
+			}
+		}
+	}
+	return nil
+}
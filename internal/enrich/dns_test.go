@@ -0,0 +1,148 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+const kubernetesNames = `{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [
+    {
+      "kind": "Pod",
+      "metadata": {"name": "checkout-7d9f8", "namespace": "shop"},
+      "spec": {},
+      "status": {"podIP": "10.1.2.3", "podIPs": [{"ip": "10.1.2.3"}, {"ip": "fd00::3"}]}
+    },
+    {
+      "kind": "Pod",
+      "metadata": {"name": "node-exporter-x2k", "namespace": "monitoring"},
+      "spec": {"hostNetwork": true},
+      "status": {"podIPs": [{"ip": "192.168.0.10"}]}
+    },
+    {
+      "kind": "Service",
+      "metadata": {"name": "checkout", "namespace": "shop"},
+      "spec": {"clusterIP": "10.96.0.20", "clusterIPs": ["10.96.0.20"]}
+    },
+    {
+      "kind": "Service",
+      "metadata": {"name": "checkout-headless", "namespace": "shop"},
+      "spec": {"clusterIP": "None", "clusterIPs": ["None"]}
+    }
+  ]
+}`
+
+func TestDNSNames_Lookup(t *testing.T) {
+	dir := t.TempDir()
+	hosts := filepath.Join(dir, "hosts")
+	assert.NoError(t, os.WriteFile(hosts, []byte("# gateways\n10.0.0.1 gw gw.internal\n\n2001:db8::1  edge # v6\n"), 0o644))
+	pods := filepath.Join(dir, "pods.json")
+	assert.NoError(t, os.WriteFile(pods, []byte(kubernetesNames), 0o644))
+
+	tests := []struct {
+		name    string
+		dns     config.DNSEnrichment
+		given   map[string]string
+		wants   map[string]string
+		lookups int
+	}{
+		{
+			name:  "hosts file",
+			dns:   config.DNSEnrichment{Names: hosts, Offline: true},
+			given: map[string]string{"10.0.0.1:8080": "gw", "[2001:db8:0::1]:443": "edge", "10.0.0.2": ""},
+		},
+		{
+			name: "kubernetes pods and services",
+			dns:  config.DNSEnrichment{Names: pods, Offline: true},
+			given: map[string]string{
+				"10.1.2.3": "shop/checkout-7d9f8", "fd00::3": "shop/checkout-7d9f8",
+				"10.96.0.20": "shop/checkout", "192.168.0.10": "",
+			},
+		},
+		{
+			name:    "reverse lookups cached",
+			dns:     config.DNSEnrichment{Names: hosts},
+			given:   map[string]string{"10.0.0.1": "gw", "198.51.100.7": "host-198.51.100.7.example.com", "203.0.113.9": ""},
+			lookups: 2,
+		},
+		{
+			name:  "offline",
+			dns:   config.DNSEnrichment{Offline: true},
+			given: map[string]string{"198.51.100.7": "", "not an address": ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := makeDNS(&tt.dns)
+			if !assert.NoError(t, err) {
+				return
+			}
+			lookups := 0
+			d.lookupAddr = func(_ context.Context, addr string) ([]string, error) {
+				lookups++
+				if addr == "198.51.100.7" {
+					return []string{"host-198.51.100.7.example.com."}, nil
+				}
+				return nil, fmt.Errorf("lookup %s: no such host", addr)
+			}
+			for range 2 {
+				for value, want := range tt.given {
+					assert.Equal(t, want, d.lookup(value), value)
+				}
+			}
+			assert.Equal(t, tt.lookups, lookups)
+		})
+	}
+}
+
+func TestDNSNames_Apply(t *testing.T) {
+	d, err := makeDNS(&config.DNSEnrichment{Offline: true, Keys: []string{"peer/addr"}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	d.names["10.1.2.3"] = "shop/checkout-7d9f8"
+	m := map[string]interface{}{"peer": map[string]interface{}{"addr": "10.1.2.3:5000"}}
+	d.apply(m)
+	assert.Equal(t, "shop/checkout-7d9f8", m["addr_host"])
+}
+
+func TestMakeDNS_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	hosts := filepath.Join(dir, "hosts")
+	assert.NoError(t, os.WriteFile(hosts, []byte("gw 10.0.0.1\n"), 0o644))
+
+	_, err := makeDNS(&config.DNSEnrichment{Names: hosts})
+	assert.EqualError(t, err, fmt.Sprintf(`dns: %s: invalid address "gw"`, hosts))
+	_, err = makeDNS(&config.DNSEnrichment{Names: filepath.Join(dir, "missing")})
+	assert.ErrorContains(t, err, "dns: open")
+}
@@ -0,0 +1,101 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package enrich
+
+import (
+	"strings"
+
+	"github.com/badaniya/loggo/internal/config"
+)
+
+// Enricher annotates entries, as they are ingested, with the extra keys of
+// the processors declared in a template, see config.Enrichment.
+type Enricher struct {
+	processors []processor
+}
+
+type processor interface {
+	apply(m map[string]interface{})
+}
+
+// MakeEnricher builds the enricher declared in a template, or nil if none.
+func MakeEnricher(enrichment *config.Enrichment) (*Enricher, error) {
+	if enrichment == nil {
+		return nil, nil
+	}
+	e := &Enricher{}
+	if enrichment.GeoIP != nil {
+		g, err := makeGeoIP(enrichment.GeoIP)
+		if err != nil {
+			return nil, err
+		}
+		e.processors = append(e.processors, g)
+	}
+	if enrichment.UserAgent != nil {
+		e.processors = append(e.processors, makeUserAgent(enrichment.UserAgent))
+	}
+	if enrichment.DNS != nil {
+		d, err := makeDNS(enrichment.DNS)
+		if err != nil {
+			return nil, err
+		}
+		e.processors = append(e.processors, d)
+	}
+	return e, nil
+}
+
+// Apply adds, in place, the extra keys of the entry. Keys the entry already
+// holds are left untouched.
+func (e *Enricher) Apply(m map[string]interface{}) {
+	for _, p := range e.processors {
+		p.apply(m)
+	}
+}
+
+// source is a key a processor reads, along with the prefix of the keys it
+// adds, named after the key last path segment, e.g. remote_ip_country for
+// http_request/remote_ip.
+type source struct {
+	key    config.Key
+	prefix string
+}
+
+func makeSources(keys, defaults []string) []source {
+	if len(keys) == 0 {
+		keys = defaults
+	}
+	sources := make([]source, 0, len(keys))
+	for _, k := range keys {
+		sources = append(sources, source{key: config.Key{Name: k}, prefix: k[strings.LastIndex(k, "/")+1:] + "_"})
+	}
+	return sources
+}
+
+func set(m map[string]interface{}, key, value string) {
+	if len(value) == 0 {
+		return
+	}
+	if _, ok := m[key]; !ok {
+		m[key] = value
+	}
+}
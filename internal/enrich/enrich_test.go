@@ -0,0 +1,109 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package enrich
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnricher_Apply(t *testing.T) {
+	dir := t.TempDir()
+	city := filepath.Join(dir, "city.mmdb")
+	asn := filepath.Join(dir, "asn.mmdb")
+	assert.NoError(t, os.WriteFile(city, testDB{
+		ipVersion: 6, recordSize: 28, dbType: "GeoLite2-City", networks: cityRecords,
+	}.build(t), 0o644))
+	assert.NoError(t, os.WriteFile(asn, testDB{
+		ipVersion: 6, recordSize: 24, dbType: "GeoLite2-ASN",
+		networks: map[string]map[string]interface{}{
+			"203.0.113.0/24": {"autonomous_system_number": uint32(3320), "autonomous_system_organization": "Deutsche Telekom AG"},
+		},
+	}.build(t), 0o644))
+
+	tests := []struct {
+		name       string
+		enrichment *config.Enrichment
+		given      string
+		wants      string
+	}{
+		{
+			name:       "GeoIP default keys",
+			enrichment: &config.Enrichment{GeoIP: &config.GeoIPEnrichment{Databases: []string{city, asn}}},
+			given:      `{"client_ip":"203.0.113.7"}`,
+			wants: `{"client_ip":"203.0.113.7","client_ip_country":"DE","client_ip_city":"Berlin",
+				"client_ip_asn":"AS3320","client_ip_org":"Deutsche Telekom AG"}`,
+		},
+		{
+			name: "GeoIP nested key with port",
+			enrichment: &config.Enrichment{GeoIP: &config.GeoIPEnrichment{
+				Databases: []string{city}, Keys: []string{"http_request/remote_ip"},
+			}},
+			given: `{"http_request":{"remote_ip":"[2001:db8::1]:443"}}`,
+			wants: `{"http_request":{"remote_ip":"[2001:db8::1]:443"},"remote_ip_country":"FR"}`,
+		},
+		{
+			name:       "GeoIP forwarded list and unknown address",
+			enrichment: &config.Enrichment{GeoIP: &config.GeoIPEnrichment{Databases: []string{city}, Keys: []string{"xff", "ip"}}},
+			given:      `{"xff":"198.51.100.200, 10.0.0.1","ip":"192.0.2.1"}`,
+			wants:      `{"xff":"198.51.100.200, 10.0.0.1","ip":"192.0.2.1","xff_country":"DE","xff_city":"Munich"}`,
+		},
+		{
+			name:       "User agent",
+			enrichment: &config.Enrichment{UserAgent: &config.UserAgentEnrichment{}},
+			given:      `{"user_agent":"curl/8.7.1","user_agent_os":"kept"}`,
+			wants:      `{"user_agent":"curl/8.7.1","user_agent_os":"kept","user_agent_browser":"curl 8"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := MakeEnricher(tt.enrichment)
+			if !assert.NoError(t, err) {
+				return
+			}
+			m := make(map[string]interface{})
+			assert.NoError(t, json.Unmarshal([]byte(tt.given), &m))
+			e.Apply(m)
+			wants := make(map[string]interface{})
+			assert.NoError(t, json.Unmarshal([]byte(tt.wants), &wants))
+			assert.Equal(t, wants, m)
+		})
+	}
+}
+
+func TestMakeEnricher(t *testing.T) {
+	e, err := MakeEnricher(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, e)
+
+	_, err = MakeEnricher(&config.Enrichment{GeoIP: &config.GeoIPEnrichment{}})
+	assert.EqualError(t, err, "geoip: no databases configured")
+
+	_, err = MakeEnricher(&config.Enrichment{GeoIP: &config.GeoIPEnrichment{Databases: []string{"missing.mmdb"}}})
+	assert.ErrorContains(t, err, "geoip: open missing.mmdb")
+}
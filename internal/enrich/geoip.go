@@ -0,0 +1,157 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package enrich
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/badaniya/loggo/internal/config"
+)
+
+// geoIPCacheSize bounds the looked up addresses kept, access logs usually
+// coming from a limited set of clients.
+const geoIPCacheSize = 10000
+
+var defaultIPKeys = []string{
+	"ip", "client_ip", "remote_ip", "remote_addr", "httpRequest/remoteIp", "http_request/remote_ip",
+}
+
+// geoIP annotates IP addresses with their country, city and autonomous system
+// (_country, _city, _asn and _org keys), looked up in local MaxMind databases.
+type geoIP struct {
+	dbs     []*mmdb
+	sources []source
+	lock    sync.Mutex
+	cache   map[string]geoInfo
+}
+
+type geoInfo struct {
+	country, city, asn, org string
+}
+
+func makeGeoIP(e *config.GeoIPEnrichment) (*geoIP, error) {
+	if len(e.Databases) == 0 {
+		return nil, fmt.Errorf("geoip: no databases configured")
+	}
+	g := &geoIP{
+		sources: makeSources(e.Keys, defaultIPKeys),
+		cache:   make(map[string]geoInfo),
+	}
+	for _, file := range e.Databases {
+		db, err := openMMDB(file)
+		if err != nil {
+			return nil, fmt.Errorf("geoip: %w", err)
+		}
+		g.dbs = append(g.dbs, db)
+	}
+	return g, nil
+}
+
+func (g *geoIP) apply(m map[string]interface{}) {
+	for _, s := range g.sources {
+		value := s.key.ExtractValue(m)
+		if len(value) == 0 {
+			continue
+		}
+		info := g.lookup(value)
+		set(m, s.prefix+"country", info.country)
+		set(m, s.prefix+"city", info.city)
+		set(m, s.prefix+"asn", info.asn)
+		set(m, s.prefix+"org", info.org)
+	}
+}
+
+func (g *geoIP) lookup(value string) geoInfo {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if info, ok := g.cache[value]; ok {
+		return info
+	}
+	info := geoInfo{}
+	if ip := parseIP(value); ip != nil {
+		for _, db := range g.dbs {
+			record, err := db.lookup(ip)
+			if err != nil {
+				continue
+			}
+			info.merge(record)
+		}
+	}
+	if len(g.cache) >= geoIPCacheSize {
+		g.cache = make(map[string]geoInfo)
+	}
+	g.cache[value] = info
+	return info
+}
+
+// parseIP reads the address of values such as "203.0.113.7",
+// "203.0.113.7:51234", "[2001:db8::1]:443" or a X-Forwarded-For list, where
+// the first address is the client's.
+func parseIP(value string) net.IP {
+	value = strings.TrimSpace(strings.Split(value, ",")[0])
+	if ip := net.ParseIP(value); ip != nil {
+		return ip
+	}
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		return net.ParseIP(host)
+	}
+	return nil
+}
+
+// merge reads the fields of a City, Country or ASN database record.
+func (i *geoInfo) merge(record interface{}) {
+	r, ok := record.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for _, k := range []string{"country", "registered_country"} {
+		if code := lookupString(r, k, "iso_code"); len(i.country) == 0 {
+			i.country = code
+		}
+	}
+	if len(i.city) == 0 {
+		i.city = lookupString(r, "city", "names", "en")
+	}
+	if n, ok := r["autonomous_system_number"].(uint64); ok && len(i.asn) == 0 {
+		i.asn = fmt.Sprintf("AS%d", n)
+	}
+	if len(i.org) == 0 {
+		i.org, _ = r["autonomous_system_organization"].(string)
+	}
+}
+
+func lookupString(r map[string]interface{}, path ...string) string {
+	var v interface{} = r
+	for _, p := range path {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		v = m[p]
+	}
+	s, _ := v.(string)
+	return s
+}
@@ -0,0 +1,294 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package enrich
+
+import (
+	"encoding/binary"
+	"net"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testDB builds MaxMind DB files, mapping networks to records, as the
+// maxmind writers do, only encoding the types needed here.
+type testDB struct {
+	ipVersion  int
+	recordSize int
+	dbType     string
+	networks   map[string]map[string]interface{}
+	// pointers lists record keys whose values are written once and pointed
+	// to afterwards.
+	pointers map[string]bool
+}
+
+func (d testDB) build(t *testing.T) []byte {
+	const empty = -1
+	type node [2]int
+	nodes := []node{{empty, empty}}
+	var data []byte
+	written := make(map[string]int)
+	enc := &encoder{}
+	var cidrs []string
+	for cidr := range d.networks {
+		cidrs = append(cidrs, cidr)
+	}
+	sort.Strings(cidrs)
+	leaves := make(map[[2]int]int)
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		assert.NoError(t, err)
+		ones, _ := network.Mask.Size()
+		ip := network.IP.To16()
+		if d.ipVersion == 4 {
+			ip = network.IP.To4()
+		} else if ip4 := network.IP.To4(); ip4 != nil {
+			// IPv4 networks live under ::/96 in IPv6 trees.
+			ip = append(make(net.IP, 12), ip4...)
+			ones += 96
+		}
+		record := d.networks[cidr]
+		offset := len(data)
+		var keys []string
+		for k := range record {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		data = enc.header(data, 7, len(record))
+		for _, k := range keys {
+			data = enc.value(data, k)
+			if at, ok := written[k]; ok && d.pointers[k] {
+				data = enc.pointer(data, at)
+				continue
+			}
+			written[k] = len(data)
+			data = enc.value(data, record[k])
+		}
+		n := 0
+		for i := 0; i < ones; i++ {
+			bit := int(ip[i>>3]>>(7-uint(i&7))) & 1
+			if i == ones-1 {
+				leaves[[2]int{n, bit}] = offset
+				break
+			}
+			if nodes[n][bit] == empty {
+				nodes = append(nodes, node{empty, empty})
+				nodes[n][bit] = len(nodes) - 1
+			}
+			n = nodes[n][bit]
+		}
+	}
+	count := len(nodes)
+	var buf []byte
+	for i, n := range nodes {
+		var records [2]uint32
+		for bit, child := range n {
+			switch offset, leaf := leaves[[2]int{i, bit}]; {
+			case leaf:
+				records[bit] = uint32(count + dataSectionSeparator + offset)
+			case child == empty:
+				records[bit] = uint32(count)
+			default:
+				records[bit] = uint32(child)
+			}
+		}
+		switch d.recordSize {
+		case 24:
+			buf = append(buf, byte(records[0]>>16), byte(records[0]>>8), byte(records[0]),
+				byte(records[1]>>16), byte(records[1]>>8), byte(records[1]))
+		case 28:
+			buf = append(buf, byte(records[0]>>16), byte(records[0]>>8), byte(records[0]),
+				byte(records[0]>>20&0xF0|records[1]>>24&0x0F),
+				byte(records[1]>>16), byte(records[1]>>8), byte(records[1]))
+		default:
+			buf = binary.BigEndian.AppendUint32(buf, records[0])
+			buf = binary.BigEndian.AppendUint32(buf, records[1])
+		}
+	}
+	buf = append(buf, make([]byte, dataSectionSeparator)...)
+	buf = append(buf, data...)
+	buf = append(buf, metadataMarker...)
+	meta := &encoder{}
+	var m []byte
+	m = meta.header(m, 7, 4)
+	m = meta.value(m, "node_count")
+	m = meta.value(m, uint32(count))
+	m = meta.value(m, "record_size")
+	m = meta.value(m, uint16(d.recordSize))
+	m = meta.value(m, "ip_version")
+	m = meta.value(m, uint16(d.ipVersion))
+	m = meta.value(m, "database_type")
+	m = meta.value(m, d.dbType)
+	return append(buf, m...)
+}
+
+type encoder struct{}
+
+func (e *encoder) header(b []byte, kind, size int) []byte {
+	ctrl := byte(0)
+	if kind < 8 {
+		ctrl = byte(kind << 5)
+	}
+	var ext []byte
+	switch {
+	case size < 29:
+		ctrl |= byte(size)
+	case size < 285:
+		ctrl |= 29
+		ext = []byte{byte(size - 29)}
+	default:
+		ctrl |= 30
+		ext = []byte{byte((size - 285) >> 8), byte(size - 285)}
+	}
+	b = append(b, ctrl)
+	if kind >= 8 {
+		b = append(b, byte(kind-7))
+	}
+	return append(b, ext...)
+}
+
+func (e *encoder) pointer(b []byte, offset int) []byte {
+	return append(b, byte(1<<5|(offset>>8)&0x7), byte(offset))
+}
+
+func (e *encoder) value(b []byte, v interface{}) []byte {
+	switch t := v.(type) {
+	case string:
+		return append(e.header(b, 2, len(t)), t...)
+	case uint16:
+		return binary.BigEndian.AppendUint16(e.header(b, 5, 2), t)
+	case uint32:
+		return binary.BigEndian.AppendUint32(e.header(b, 6, 4), t)
+	case bool:
+		s := 0
+		if t {
+			s = 1
+		}
+		return e.header(b, 14, s)
+	case []interface{}:
+		b = e.header(b, 11, len(t))
+		for _, i := range t {
+			b = e.value(b, i)
+		}
+		return b
+	case map[string]interface{}:
+		var keys []string
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b = e.header(b, 7, len(t))
+		for _, k := range keys {
+			b = e.value(e.value(b, k), t[k])
+		}
+		return b
+	}
+	panic("unsupported type")
+}
+
+var cityRecords = map[string]map[string]interface{}{
+	"203.0.113.0/24": {
+		"country": map[string]interface{}{"iso_code": "DE", "names": map[string]interface{}{"en": "Germany"}},
+		"city":    map[string]interface{}{"names": map[string]interface{}{"en": "Berlin"}},
+	},
+	"198.51.100.128/25": {
+		"country":      map[string]interface{}{"iso_code": "DE", "names": map[string]interface{}{"en": "Germany"}},
+		"city":         map[string]interface{}{"names": map[string]interface{}{"en": "Munich"}},
+		"is_anycast":   true,
+		"subdivisions": []interface{}{map[string]interface{}{"iso_code": "BY"}},
+	},
+	"2001:db8::/32": {
+		"registered_country": map[string]interface{}{"iso_code": "FR"},
+	},
+}
+
+func TestMMDB_Lookup(t *testing.T) {
+	tests := []struct {
+		name       string
+		ipVersion  int
+		recordSize int
+	}{
+		{name: "ipv6 tree, 24 bits records", ipVersion: 6, recordSize: 24},
+		{name: "ipv6 tree, 28 bits records", ipVersion: 6, recordSize: 28},
+		{name: "ipv6 tree, 32 bits records", ipVersion: 6, recordSize: 32},
+		{name: "ipv4 tree", ipVersion: 4, recordSize: 24},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			networks := cityRecords
+			if tt.ipVersion == 4 {
+				networks = map[string]map[string]interface{}{
+					"203.0.113.0/24":    cityRecords["203.0.113.0/24"],
+					"198.51.100.128/25": cityRecords["198.51.100.128/25"],
+				}
+			}
+			buf := testDB{
+				ipVersion:  tt.ipVersion,
+				recordSize: tt.recordSize,
+				dbType:     "GeoLite2-City",
+				networks:   networks,
+				pointers:   map[string]bool{"country": true},
+			}.build(t)
+			db, err := makeMMDB(buf)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, "GeoLite2-City", db.dbType)
+
+			record, err := db.lookup(net.ParseIP("203.0.113.7"))
+			assert.NoError(t, err)
+			assert.Equal(t, map[string]interface{}{
+				"country": map[string]interface{}{"iso_code": "DE", "names": map[string]interface{}{"en": "Germany"}},
+				"city":    map[string]interface{}{"names": map[string]interface{}{"en": "Berlin"}},
+			}, record)
+
+			record, err = db.lookup(net.ParseIP("198.51.100.200"))
+			assert.NoError(t, err)
+			assert.Equal(t, "Munich", lookupString(record.(map[string]interface{}), "city", "names", "en"))
+			assert.Equal(t, "DE", lookupString(record.(map[string]interface{}), "country", "iso_code"))
+			assert.Equal(t, true, record.(map[string]interface{})["is_anycast"])
+
+			record, err = db.lookup(net.ParseIP("198.51.100.7"))
+			assert.NoError(t, err)
+			assert.Nil(t, record)
+
+			record, err = db.lookup(net.ParseIP("2001:db8::1"))
+			assert.NoError(t, err)
+			if tt.ipVersion == 6 {
+				assert.Equal(t, "FR", lookupString(record.(map[string]interface{}), "registered_country", "iso_code"))
+			} else {
+				assert.Nil(t, record)
+			}
+		})
+	}
+}
+
+func TestMakeMMDB_Invalid(t *testing.T) {
+	_, err := makeMMDB([]byte("not a database"))
+	assert.EqualError(t, err, "not a MaxMind DB file")
+
+	buf := testDB{ipVersion: 6, recordSize: 20, networks: cityRecords}.build(t)
+	_, err = makeMMDB(buf)
+	assert.EqualError(t, err, "unsupported record size 20")
+}
@@ -0,0 +1,111 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package enrich
+
+import (
+	"regexp"
+
+	"github.com/badaniya/loggo/internal/config"
+)
+
+var defaultUserAgentKeys = []string{
+	"user_agent", "userAgent", "http_user_agent", "httpRequest/userAgent", "http_request/user_agent",
+}
+
+// userAgent annotates user-agent strings with a summary of their browser (or
+// client) and operating system (_browser and _os keys).
+type userAgent struct {
+	sources []source
+}
+
+func makeUserAgent(e *config.UserAgentEnrichment) *userAgent {
+	return &userAgent{sources: makeSources(e.Keys, defaultUserAgentKeys)}
+}
+
+func (u *userAgent) apply(m map[string]interface{}) {
+	for _, s := range u.sources {
+		value := s.key.ExtractValue(m)
+		if len(value) == 0 {
+			continue
+		}
+		browser, os := parseUserAgent(value)
+		set(m, s.prefix+"browser", browser)
+		set(m, s.prefix+"os", os)
+	}
+}
+
+type uaRule struct {
+	name  string
+	regex *regexp.Regexp
+}
+
+// browserRules are checked in order, as most browsers also claim to be the
+// ones they derive from, e.g. Edge holds "Chrome/" and "Safari/". The first
+// group, if any, is the version whose major part is kept.
+var browserRules = []uaRule{
+	{"Bot", regexp.MustCompile(`(?i)bot\b|crawler|spider|slurp`)},
+	{"curl", regexp.MustCompile(`^curl/(\d+)`)},
+	{"Wget", regexp.MustCompile(`^Wget/(\d+)`)},
+	{"python-requests", regexp.MustCompile(`^python-requests/(\d+)`)},
+	{"Go", regexp.MustCompile(`^Go-http-client/(\d+)`)},
+	{"okhttp", regexp.MustCompile(`^okhttp/(\d+)`)},
+	{"Postman", regexp.MustCompile(`^PostmanRuntime/(\d+)`)},
+	{"Edge", regexp.MustCompile(`Edg(?:e|A|iOS)?/(\d+)`)},
+	{"Opera", regexp.MustCompile(`(?:OPR|Opera)/(\d+)`)},
+	{"Samsung Internet", regexp.MustCompile(`SamsungBrowser/(\d+)`)},
+	{"Firefox", regexp.MustCompile(`(?:Firefox|FxiOS)/(\d+)`)},
+	{"Chrome", regexp.MustCompile(`(?:Chrome|CriOS)/(\d+)`)},
+	{"Safari", regexp.MustCompile(`Version/(\d+)[.\d]* (?:Mobile/\S+ )?Safari/`)},
+	{"Internet Explorer", regexp.MustCompile(`MSIE (\d+)|Trident/.*rv:(\d+)`)},
+}
+
+var osRules = []uaRule{
+	{"iOS", regexp.MustCompile(`(?:iPhone|iPad|iPod).* OS (\d+)`)},
+	{"Android", regexp.MustCompile(`Android (\d+)`)},
+	{"ChromeOS", regexp.MustCompile(`CrOS`)},
+	{"macOS", regexp.MustCompile(`Mac OS X`)},
+	{"Windows", regexp.MustCompile(`Windows`)},
+	{"Linux", regexp.MustCompile(`Linux`)},
+}
+
+// parseUserAgent summarizes the browser and operating system of a
+// user-agent string, e.g. "Chrome 126" and "Windows".
+func parseUserAgent(ua string) (string, string) {
+	return matchRule(browserRules, ua), matchRule(osRules, ua)
+}
+
+func matchRule(rules []uaRule, ua string) string {
+	for _, r := range rules {
+		match := r.regex.FindStringSubmatch(ua)
+		if match == nil {
+			continue
+		}
+		for _, version := range match[1:] {
+			if len(version) > 0 {
+				return r.name + " " + version
+			}
+		}
+		return r.name
+	}
+	return ""
+}
@@ -0,0 +1,192 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var cloudLoggingIdentReg = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// cloudLoggingNestedFields are the LogEntry fields whose nested keys are also
+// LogEntry (proto) fields, thus camel cased in the Cloud Logging language.
+var cloudLoggingNestedFields = map[string]bool{
+	"httpRequest":    true,
+	"sourceLocation": true,
+	"operation":      true,
+	"split":          true,
+}
+
+// ToCloudLogging translates the expression into a Google Cloud Logging
+// filter, so it can be applied server side. Keys are expected to follow the
+// shape of the entries streamed by gcp-stream, e.g. "resource/labels/pod_name".
+// Array selectors and fallbacks have no equivalent and return an error.
+func (e *Expression) ToCloudLogging() (string, error) {
+	return e.ToCloudLoggingMatching(CurrentMatching())
+}
+
+// ToCloudLoggingMatching translates the expression into a Google Cloud Logging
+// filter, terms being matched as m tells, see ToCloudLogging.
+func (e *Expression) ToCloudLoggingMatching(m Matching) (string, error) {
+	terms := make([]*Term, 0, len(e.Right)+1)
+	terms = append(terms, e.Left)
+	for _, r := range e.Right {
+		terms = append(terms, r.Term)
+	}
+	parts := make([]string, 0, len(terms))
+	for _, t := range terms {
+		s, err := t.toCloudLogging(m)
+		if err != nil {
+			return "", err
+		}
+		// OR has a higher precedence than AND in the Cloud Logging language.
+		if len(terms) > 1 && len(t.Right) > 0 {
+			s = "(" + s + ")"
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, " OR "), nil
+}
+
+func (t *Term) toCloudLogging(m Matching) (string, error) {
+	elements := make([]*ConditionElement, 0, len(t.Right)+1)
+	elements = append(elements, t.Left)
+	for _, r := range t.Right {
+		elements = append(elements, r.ConditionElement)
+	}
+	parts := make([]string, 0, len(elements))
+	for _, c := range elements {
+		s, err := c.toCloudLogging(m)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, " AND "), nil
+}
+
+func (c *ConditionElement) toCloudLogging(m Matching) (string, error) {
+	switch {
+	case c.Condition != nil:
+		return c.Condition.toCloudLogging()
+	case c.ScopedToken != nil:
+		field, err := cloudLoggingField(c.ScopedToken.Key())
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s =~ %s", field, strconv.Quote(c.ScopedToken.PatternMatching(m))), nil
+	case c.GlobalToken != nil:
+		return strconv.Quote(*c.GlobalToken.String), nil
+	default:
+		s, err := c.Subexpression.ToCloudLoggingMatching(m)
+		if err != nil {
+			return "", err
+		}
+		return "(" + s + ")", nil
+	}
+}
+
+func (c *Condition) toCloudLogging() (string, error) {
+	field, err := cloudLoggingField(c.Operand)
+	if err != nil {
+		return "", err
+	}
+	value := cloudLoggingValue(c.Value)
+	str := c.Value.ToString()
+	switch strings.ToUpper(c.Operator) {
+	case "==":
+		return fmt.Sprintf("%s = %s", field, value), nil
+	case "=":
+		if field == "severity" || c.Value.Number != nil {
+			return fmt.Sprintf("%s = %s", field, strings.ToUpper(value)), nil
+		}
+		return fmt.Sprintf("%s =~ %s", field, strconv.Quote("(?i)^"+regexp.QuoteMeta(str)+"$")), nil
+	case "<>", "!=":
+		return fmt.Sprintf("%s != %s", field, value), nil
+	case "<", "<=", ">", ">=":
+		return fmt.Sprintf("%s %s %s", field, c.Operator, value), nil
+	case "CONTAINS":
+		return fmt.Sprintf("%s =~ %s", field, strconv.Quote(regexp.QuoteMeta(str))), nil
+	case "CONTAINSIC":
+		return fmt.Sprintf("%s : %s", field, strconv.Quote(str)), nil
+	case "MATCH":
+		return fmt.Sprintf("%s =~ %s", field, strconv.Quote(str)), nil
+	case "BETWEEN":
+		if c.Value2 == nil {
+			return "", fmt.Errorf("missing upper bound for %s BETWEEN", c.Operand)
+		}
+		return fmt.Sprintf("(%s >= %s AND %s <= %s)", field, value, field, cloudLoggingValue(c.Value2)), nil
+	}
+	return "", fmt.Errorf("unrecognised operator %s", c.Operator)
+}
+
+// cloudLoggingField converts a key path such as "http_request/request_method"
+// into the Cloud Logging field "httpRequest.requestMethod". User defined keys
+// (e.g. under jsonPayload or labels) are kept as is.
+func cloudLoggingField(key string) (string, error) {
+	if strings.ContainsAny(key, "[]|") {
+		return "", fmt.Errorf("key '%s' can't be translated to a Cloud Logging field", key)
+	}
+	segments := strings.FieldsFunc(key, func(r rune) bool { return r == '/' || r == '.' })
+	if len(segments) == 0 {
+		return "", fmt.Errorf("empty key")
+	}
+	segments[0] = camelCase(segments[0])
+	if cloudLoggingNestedFields[segments[0]] {
+		for i := 1; i < len(segments); i++ {
+			segments[i] = camelCase(segments[i])
+		}
+	}
+	for i, s := range segments {
+		if !cloudLoggingIdentReg.MatchString(s) {
+			segments[i] = strconv.Quote(s)
+		}
+	}
+	return strings.Join(segments, "."), nil
+}
+
+func cloudLoggingValue(v *Value) string {
+	if v.Duration != nil {
+		if d, err := time.ParseDuration(*v.Duration); err == nil {
+			return strconv.Quote(strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s")
+		}
+	}
+	if v.Number != nil {
+		return strconv.FormatFloat(*v.Number, 'f', -1, 64)
+	}
+	return strconv.Quote(*v.String)
+}
+
+func camelCase(s string) string {
+	parts := strings.Split(s, "_")
+	for i := 1; i < len(parts); i++ {
+		if len(parts[i]) > 0 {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
@@ -0,0 +1,92 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpression_ToCloudLogging(t *testing.T) {
+	tests := []struct {
+		name            string
+		givenExpression string
+		wantsFilter     string
+		wantsError      bool
+	}{
+		{
+			name:            "equals and resource labels",
+			givenExpression: `resource/labels/namespace_name == "prod" AND severity = "error"`,
+			wantsFilter:     `resource.labels.namespace_name = "prod" AND severity = "ERROR"`,
+		},
+		{
+			name:            "case insensitive equals",
+			givenExpression: `jsonPayload/user = "Bob.S"`,
+			wantsFilter:     `jsonPayload.user =~ "(?i)^Bob\\.S$"`,
+		},
+		{
+			name:            "or binds tighter than and",
+			givenExpression: `a == "1" AND b == "2" OR c > 5`,
+			wantsFilter:     `(a = "1" AND b = "2") OR c > 5`,
+		},
+		{
+			name:            "sub expressions and between",
+			givenExpression: `(http_request/status BETWEEN 500 AND 599 OR text_payload CONTAINSIC "timeout") AND "panic"`,
+			wantsFilter:     `((httpRequest.status >= 500 AND httpRequest.status <= 599) OR textPayload : "timeout") AND "panic"`,
+		},
+		{
+			name:            "contains and match",
+			givenExpression: `jsonPayload/message CONTAINS "a.b" AND jsonPayload/message MATCH "^x+"`,
+			wantsFilter:     `jsonPayload.message =~ "a\\.b" AND jsonPayload.message =~ "^x+"`,
+		},
+		{
+			name:            "durations",
+			givenExpression: `http_request/latency > 500ms`,
+			wantsFilter:     `httpRequest.latency > "0.5s"`,
+		},
+		{
+			name:            "scoped searches",
+			givenExpression: `jsonPayload/message:/time(d )?out/ AND http_request/request_url:^/api/v2`,
+			wantsFilter:     `jsonPayload.message =~ "time(d )?out" AND httpRequest.requestUrl =~ "(?i)^/api/v2"`,
+		},
+		{
+			name:            "array selectors are not translatable",
+			givenExpression: `jsonPayload/spans[0].name == "x"`,
+			wantsError:      true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			exp, err := ParseFilterExpression(test.givenExpression)
+			assert.NoError(t, err)
+			f, err := exp.ToCloudLogging()
+			if test.wantsError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantsFilter, f)
+		})
+	}
+}
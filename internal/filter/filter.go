@@ -167,12 +167,15 @@ func GreaterOrEqualThan(key string, expression string) *greaterOrEqualThan {
 }
 
 func MatchesRegex(key string, expression string) *matchRegex {
+	reg, err := regexp.Compile(expression)
 	return &matchRegex{
 		Predicate: Predicate{
 			KeyName:       key,
 			KeyExpression: []string{expression},
 			Operation:     OpMatchesRegex,
 		},
+		regex: reg,
+		err:   err,
 	}
 }
 
@@ -212,10 +215,14 @@ func (f *equals) Apply(value string, key map[string]*config.Key) (bool, error) {
 	switch tp {
 	case config.TypeString:
 		return f.KeyExpression[0] == value, nil
-	case config.TypeNumber:
+	case config.TypeNumber, config.TypeBytes:
 		return f.parseNumberAndCheck(value, func(number, expression float64) (bool, error) {
 			return number == expression, nil
 		})
+	case config.TypeDuration:
+		return f.parseDurationAndCheck(value, func(number, expression float64) (bool, error) {
+			return number == expression, nil
+		})
 	case config.TypeBool:
 		return f.parseBoolAndCheck(value, func(value, expression bool) (bool, error) {
 			return value == expression, nil
@@ -259,10 +266,14 @@ func (f *equalsIgnoreCase) Apply(value string, key map[string]*config.Key) (bool
 	switch tp {
 	case config.TypeString:
 		return strings.ToLower(f.KeyExpression[0]) == strings.ToLower(value), nil
-	case config.TypeNumber:
+	case config.TypeNumber, config.TypeBytes:
 		return f.parseNumberAndCheck(value, func(number, expression float64) (bool, error) {
 			return number == expression, nil
 		})
+	case config.TypeDuration:
+		return f.parseDurationAndCheck(value, func(number, expression float64) (bool, error) {
+			return number == expression, nil
+		})
 	case config.TypeBool:
 		return f.parseBoolAndCheck(value, func(value, expression bool) (bool, error) {
 			return value == expression, nil
@@ -285,14 +296,16 @@ func (f *containsIgnoreCase) Apply(value string, key map[string]*config.Key) (bo
 
 type matchRegex struct {
 	Predicate
+	// regex is compiled once, as filters are cached and applied to every entry.
+	regex *regexp.Regexp
+	err   error
 }
 
 func (f *matchRegex) Apply(value string, key map[string]*config.Key) (bool, error) {
-	reg, err := regexp.Compile(f.KeyExpression[0])
-	if err != nil {
-		return false, err
+	if f.err != nil {
+		return false, f.err
 	}
-	return reg.Match([]byte(value)), nil
+	return f.regex.MatchString(value), nil
 }
 
 type lowerThan struct {
@@ -309,10 +322,14 @@ func (f *lowerThan) Apply(value string, key map[string]*config.Key) (bool, error
 	switch tp {
 	case config.TypeString:
 		return strings.Compare(value, f.KeyExpression[0]) < 0, nil
-	case config.TypeNumber:
+	case config.TypeNumber, config.TypeBytes:
 		return f.parseNumberAndCheck(value, func(number, expression float64) (bool, error) {
 			return number < expression, nil
 		})
+	case config.TypeDuration:
+		return f.parseDurationAndCheck(value, func(number, expression float64) (bool, error) {
+			return number < expression, nil
+		})
 	case config.TypeDateTime:
 		return f.parseDateTimeAndCheck(value, k, func(value, expression time.Time) (bool, error) {
 			return value.Before(expression), nil
@@ -335,10 +352,14 @@ func (f *greaterThan) Apply(value string, key map[string]*config.Key) (bool, err
 	switch tp {
 	case config.TypeString:
 		return strings.Compare(value, f.KeyExpression[0]) > 0, nil
-	case config.TypeNumber:
+	case config.TypeNumber, config.TypeBytes:
 		return f.parseNumberAndCheck(value, func(number, expression float64) (bool, error) {
 			return number > expression, nil
 		})
+	case config.TypeDuration:
+		return f.parseDurationAndCheck(value, func(number, expression float64) (bool, error) {
+			return number > expression, nil
+		})
 	case config.TypeDateTime:
 		return f.parseDateTimeAndCheck(value, k, func(value, expression time.Time) (bool, error) {
 			return value.After(expression), nil
@@ -361,10 +382,14 @@ func (f *lowerOrEqualThan) Apply(value string, key map[string]*config.Key) (bool
 	switch tp {
 	case config.TypeString:
 		return strings.Compare(value, f.KeyExpression[0]) <= 0, nil
-	case config.TypeNumber:
+	case config.TypeNumber, config.TypeBytes:
 		return f.parseNumberAndCheck(value, func(number, expression float64) (bool, error) {
 			return number <= expression, nil
 		})
+	case config.TypeDuration:
+		return f.parseDurationAndCheck(value, func(number, expression float64) (bool, error) {
+			return number <= expression, nil
+		})
 	case config.TypeDateTime:
 		return f.parseDateTimeAndCheck(value, k, func(value, expression time.Time) (bool, error) {
 			return value.Before(expression) || value.Equal(expression), nil
@@ -387,10 +412,14 @@ func (f *greaterOrEqualThan) Apply(value string, key map[string]*config.Key) (bo
 	switch tp {
 	case config.TypeString:
 		return strings.Compare(value, f.KeyExpression[0]) >= 0, nil
-	case config.TypeNumber:
+	case config.TypeNumber, config.TypeBytes:
 		return f.parseNumberAndCheck(value, func(number, expression float64) (bool, error) {
 			return number >= expression, nil
 		})
+	case config.TypeDuration:
+		return f.parseDurationAndCheck(value, func(number, expression float64) (bool, error) {
+			return number >= expression, nil
+		})
 	case config.TypeDateTime:
 		return f.parseDateTimeAndCheck(value, k, func(value, expression time.Time) (bool, error) {
 			return value.After(expression) || value.Equal(expression), nil
@@ -413,10 +442,14 @@ func (f *between) Apply(value string, key map[string]*config.Key) (bool, error)
 	switch tp {
 	case config.TypeString:
 		return strings.Compare(value, f.KeyExpression[0]) > 0 && strings.Compare(value, f.KeyExpression[1]) < 0, nil
-	case config.TypeNumber:
+	case config.TypeNumber, config.TypeBytes:
 		return f.parseNumberAndCheck(value, func(number, expression, expression2 float64) (bool, error) {
 			return number > expression && number < expression2, nil
 		})
+	case config.TypeDuration:
+		return f.parseDurationAndCheck(value, func(number, expression, expression2 float64) (bool, error) {
+			return number > expression && number < expression2, nil
+		})
 	case config.TypeDateTime:
 		return f.parseDateTimeAndCheck(value, k, func(value, expression, expression2 time.Time) (bool, error) {
 			return value.After(expression) && value.Before(expression2), nil
@@ -439,10 +472,14 @@ func (f *betweenInclusive) Apply(value string, key map[string]*config.Key) (bool
 	switch tp {
 	case config.TypeString:
 		return strings.Compare(value, f.KeyExpression[0]) >= 0 && strings.Compare(value, f.KeyExpression[1]) <= 0, nil
-	case config.TypeNumber:
+	case config.TypeNumber, config.TypeBytes:
 		return f.parseNumberAndCheck(value, func(number, expression, expression2 float64) (bool, error) {
 			return number >= expression && number <= expression2, nil
 		})
+	case config.TypeDuration:
+		return f.parseDurationAndCheck(value, func(number, expression, expression2 float64) (bool, error) {
+			return number >= expression && number <= expression2, nil
+		})
 	case config.TypeDateTime:
 		return f.parseDateTimeAndCheck(value, k, func(value, expression, expression2 time.Time) (bool, error) {
 			return (value.After(expression) || value.Equal(expression)) &&
@@ -489,6 +526,39 @@ func (f *between) parseNumberAndCheck(value string, check func(number, expressio
 	return false, err
 }
 
+func (p *Predicate) parseDurationAndCheck(value string, check func(number, expression float64) (bool, error)) (bool, error) {
+	if len(strings.TrimSpace(value)) == 0 {
+		value = "0"
+	}
+	v, err := config.ParseDuration(value)
+	if err == nil {
+		var e time.Duration
+		e, err = config.ParseDuration(p.KeyExpression[0])
+		if err == nil {
+			return check(float64(v), float64(e))
+		}
+	}
+	return false, err
+}
+
+func (f *between) parseDurationAndCheck(value string, check func(number, expression, expression2 float64) (bool, error)) (bool, error) {
+	if len(strings.TrimSpace(value)) == 0 {
+		value = "0"
+	}
+	v, err := config.ParseDuration(value)
+	if err == nil {
+		var e, e2 time.Duration
+		e, err = config.ParseDuration(f.KeyExpression[0])
+		if err == nil {
+			e2, err = config.ParseDuration(f.KeyExpression[1])
+			if err == nil {
+				return check(float64(v), float64(e), float64(e2))
+			}
+		}
+	}
+	return false, err
+}
+
 func (p *Predicate) parseBoolAndCheck(value string, check func(value, expression bool) (bool, error)) (bool, error) {
 	var v, e bool
 	var err error
@@ -505,9 +575,9 @@ func (p *Predicate) parseBoolAndCheck(value string, check func(value, expression
 func (p *Predicate) parseDateTimeAndCheck(value string, key *config.Key, check func(value, expression time.Time) (bool, error)) (bool, error) {
 	var v, e time.Time
 	var err error
-	v, err = time.Parse(key.Layout, value)
+	v, err = config.ParseTime(key.Layout, value)
 	if err == nil {
-		e, err = time.Parse(key.Layout, p.KeyExpression[0])
+		e, err = config.ParseTime(key.Layout, p.KeyExpression[0])
 		if err == nil {
 			return check(v, e)
 		}
@@ -518,11 +588,11 @@ func (p *Predicate) parseDateTimeAndCheck(value string, key *config.Key, check f
 func (f *between) parseDateTimeAndCheck(value string, key *config.Key, check func(value, expression, expression2 time.Time) (bool, error)) (bool, error) {
 	var v, e, e2 time.Time
 	var err error
-	v, err = time.Parse(key.Layout, value)
+	v, err = config.ParseTime(key.Layout, value)
 	if err == nil {
-		e, err = time.Parse(key.Layout, f.KeyExpression[0])
+		e, err = config.ParseTime(key.Layout, f.KeyExpression[0])
 		if err == nil {
-			e2, err = time.Parse(key.Layout, f.KeyExpression[1])
+			e2, err = config.ParseTime(key.Layout, f.KeyExpression[1])
 			if err == nil {
 				return check(v, e, e2)
 			}
@@ -55,6 +55,14 @@ var keySet = map[string]*config.Key{
 		Type:   config.TypeDateTime,
 		Layout: "2006-01-02T15:04:05-0700",
 	},
+	"rfc3339Key": {
+		Name: "rfc3339Key",
+		Type: config.TypeDateTime,
+	},
+	"durationKey": {
+		Name: "durationKey",
+		Type: config.TypeDuration,
+	},
 }
 
 func TestEqual_Apply(t *testing.T) {
@@ -907,3 +915,62 @@ func testFilterFunc(t *testing.T, test testFilter) {
 		assert.Equal(t, test.shouldMatch, got)
 	}
 }
+
+func TestDuration_Apply(t *testing.T) {
+	tests := []testFilter{
+		{
+			name:        "Greater than milliseconds",
+			filter:      GreaterThan("durationKey", "500ms"),
+			whenValue:   "1.2s",
+			shouldMatch: true,
+		},
+		{
+			name:        "Bare seconds lower than milliseconds",
+			filter:      GreaterThan("durationKey", "500ms"),
+			whenValue:   "0.2",
+			shouldMatch: false,
+		},
+		{
+			name:        "Numerically rather than lexically",
+			filter:      LowerThan("durationKey", "10s"),
+			whenValue:   "9s",
+			shouldMatch: true,
+		},
+		{
+			name:        "Equal across units",
+			filter:      Equals("durationKey", "1m30s"),
+			whenValue:   "90",
+			shouldMatch: true,
+		},
+		{
+			name:        "Between",
+			filter:      BetweenInclusive("durationKey", "100ms", "1s"),
+			whenValue:   "0.532s",
+			shouldMatch: true,
+		},
+		{
+			name:        "Wants BAD duration value",
+			filter:      GreaterOrEqualThan("durationKey", "1s"),
+			whenValue:   "bananas",
+			shouldMatch: false,
+			wantError:   true,
+		},
+		{
+			name:        "Absent duration taken as zero",
+			filter:      LowerThan("durationKey", "1s"),
+			whenValue:   "",
+			shouldMatch: true,
+		},
+		{
+			name:        "Datetime without layout",
+			filter:      GreaterThan("rfc3339Key", "2023-01-02T15:04:05Z"),
+			whenValue:   "2023-01-02T15:04:05.5+00:00",
+			shouldMatch: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testFilterFunc(t, test)
+		})
+	}
+}
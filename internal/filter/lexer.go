@@ -23,9 +23,11 @@ THE SOFTWARE.
 package filter
 
 import (
-	"encoding/json"
+	"container/list"
 	"fmt"
+	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/alecthomas/participle/v2"
 	"github.com/alecthomas/participle/v2/lexer"
@@ -41,8 +43,10 @@ const (
 
 var (
 	sqlLexer = lexer.MustSimple([]lexer.SimpleRule{
+		{`Scoped`, `[a-zA-Z_][a-zA-Z0-9_./]*(\[-?\d+\](\.?[a-zA-Z0-9_./]+)?)*:(/(\\.|[^/\\])*/|'[^']*'|"[^"]*"|[^\s()'"]+)`},
 		{`Keyword`, `(?i)\b(MATCH|CONTAINSIC|CONTAINS|BETWEEN|AND|OR)\b`},
-		{`Ident`, `[a-zA-Z_][a-zA-Z0-9_./]*`},
+		{`Ident`, `[a-zA-Z_][a-zA-Z0-9_./]*(\[-?\d+\](\.?[a-zA-Z0-9_./]+)?)*`},
+		{`Duration`, `[-+]?(\d*\.?\d+(ns|us|µs|ms|s|h|m))+`},
 		{`Number`, `[-+]?\d*\.?\d+([eE][-+]?\d+)?`},
 		{`String`, `'[^']*'|"[^"]*"`},
 		{`Operators`, `<>|!=|<=|>=|==|[()=<>]`},
@@ -51,6 +55,12 @@ var (
 
 	cachedDef = make(map[string]Filter)
 
+	compiledLock sync.Mutex
+	compiled     = make(map[string]*list.Element)
+	// compiledOrder lists the compiled expressions, the most recently used
+	// first, the least one being evicted past maxCompiled
+	compiledOrder = list.New()
+
 	parser = participle.MustBuild[Expression](
 		participle.Lexer(sqlLexer),
 		participle.Unquote("String"),
@@ -62,6 +72,39 @@ func ParseFilterExpression(exp string) (*Expression, error) {
 	return parser.ParseString("", exp)
 }
 
+// maxCompiled is the number of expressions Compile keeps parsed, well beyond
+// the conditions of a template, so that expressions typed in as the filter
+// don't pile up.
+const maxCompiled = 256
+
+type compiledExpression struct {
+	source     string
+	expression *Expression
+	err        error
+}
+
+// Compile parses the expression once, caching the outcome, so that the filter
+// language can serve as the condition language wherever entries are evaluated
+// repeatedly, e.g. highlight rules (see config.ColorWhen). Only the most
+// recently used expressions are kept, see maxCompiled.
+func Compile(exp string) (*Expression, error) {
+	compiledLock.Lock()
+	defer compiledLock.Unlock()
+	if e, ok := compiled[exp]; ok {
+		compiledOrder.MoveToFront(e)
+		c := e.Value.(*compiledExpression)
+		return c.expression, c.err
+	}
+	c := &compiledExpression{source: exp}
+	c.expression, c.err = ParseFilterExpression(exp)
+	compiled[exp] = compiledOrder.PushFront(c)
+	if compiledOrder.Len() > maxCompiled {
+		oldest := compiledOrder.Remove(compiledOrder.Back()).(*compiledExpression)
+		delete(compiled, oldest.source)
+	}
+	return c.expression, c.err
+}
+
 func cachedOperation(op Operation, key string, v ...string) Filter {
 	ck := fmt.Sprintf(`[%s:%s]:%+v`, op, key, v)
 	if v, ok := cachedDef[ck]; ok {
@@ -110,6 +153,7 @@ type Expression struct {
 
 type ConditionElement struct {
 	Condition     *Condition   ` @@`
+	ScopedToken   *ScopedToken `| @@`
 	GlobalToken   *GlobalToken `| @@ `
 	Subexpression *Expression  `| "(" @@ ")"`
 }
@@ -118,6 +162,68 @@ type GlobalToken struct {
 	String *string `@String`
 }
 
+// ScopedToken searches a single key rather than the whole entry, avoiding
+// false positives from e.g. IDs in unrelated fields: msg:/time(d )?out/ for a
+// regular expression, or msg:timeout for a case-insensitive term, which ^ and
+// $ anchor to the start or end of the value, e.g. path:^/api/v2. Terms with
+// spaces, or starting and ending with a slash, are quoted, e.g.
+// msg:"connection refused".
+type ScopedToken struct {
+	Token string `@Scoped`
+}
+
+// Key is the searched key.
+func (s *ScopedToken) Key() string {
+	return s.Token[:strings.Index(s.Token, ":")]
+}
+
+// Pattern is the regular expression the key value is matched against, terms
+// being matched as CurrentMatching tells.
+func (s *ScopedToken) Pattern() string {
+	return s.PatternMatching(CurrentMatching())
+}
+
+// PatternMatching is the regular expression the key value is matched against,
+// terms being matched as m tells.
+func (s *ScopedToken) PatternMatching(m Matching) string {
+	v := s.Token[strings.Index(s.Token, ":")+1:]
+	switch {
+	case len(v) > 1 && v[0] == '/' && v[len(v)-1] == '/':
+		return strings.ReplaceAll(v[1:len(v)-1], `\/`, "/")
+	case len(v) > 1 && (v[0] == '"' || v[0] == '\''):
+		return termPattern("", v[1:len(v)-1], "", m)
+	}
+	prefix, suffix := "", ""
+	if strings.HasPrefix(v, "^") {
+		prefix, v = "^", v[1:]
+	}
+	if strings.HasSuffix(v, "$") {
+		suffix, v = "$", v[:len(v)-1]
+	}
+	return termPattern(prefix, v, suffix, m)
+}
+
+// wordStart and wordEnd bound whole word terms: the start or end of the value,
+// or a character that isn't a letter, digit or underscore.
+const (
+	wordStart = `(^|[^\p{L}\p{N}_])`
+	wordEnd   = `([^\p{L}\p{N}_]|$)`
+)
+
+func termPattern(prefix, term, suffix string, m Matching) string {
+	flags := ""
+	if !m.Sensitive(term) {
+		flags = "(?i)"
+	}
+	if m.WholeWord && len(prefix) == 0 {
+		prefix = wordStart
+	}
+	if m.WholeWord && len(suffix) == 0 {
+		suffix = wordEnd
+	}
+	return flags + prefix + regexp.QuoteMeta(term) + suffix
+}
+
 type Condition struct {
 	Operand  string `@Ident`
 	Operator string `@( "<>" | "<=" | ">=" | "=" | "==" | "<" | ">" | "!=" | "BETWEEN" | "CONTAINS" | "CONTAINSIC" | "MATCH" )`
@@ -126,7 +232,9 @@ type Condition struct {
 }
 
 func (v *Value) ToString() string {
-	if v.Number == nil {
+	if v.Duration != nil {
+		return *v.Duration
+	} else if v.Number == nil {
 		return *v.String
 	} else {
 		return fmt.Sprintf(`%f`, *v.Number)
@@ -134,8 +242,9 @@ func (v *Value) ToString() string {
 }
 
 type Value struct {
-	Number *float64 `( @Number`
-	String *string  ` | @String )`
+	Duration *string  `( @Duration`
+	Number   *float64 ` | @Number`
+	String   *string  ` | @String )`
 }
 
 type OpValue struct {
@@ -163,24 +272,33 @@ func (c LogicalOperator) Apply(l, r bool) bool {
 	return false
 }
 
-func (c *ConditionElement) Apply(row map[string]interface{}, key map[string]*config.Key) (bool, error) {
+func (c *ConditionElement) Apply(row map[string]interface{}, line []byte, key map[string]*config.Key, m Matching) (bool, error) {
 	switch {
 	case c.Condition != nil:
 		return c.Condition.Apply(row, key)
+	case c.ScopedToken != nil:
+		return c.ScopedToken.Apply(row, key, m)
 	case c.GlobalToken != nil:
-		return c.GlobalToken.Apply(row)
+		return c.GlobalToken.Apply(row, line, m)
 	default:
-		return c.Subexpression.Apply(row, key)
+		return c.Subexpression.ApplyLine(row, line, key, m)
 	}
 }
 
-func (g *GlobalToken) Apply(row map[string]interface{}) (bool, error) {
-	b, err := json.Marshal(row)
-	if err != nil {
-		return false, err
+func (s *ScopedToken) Apply(row map[string]interface{}, key map[string]*config.Key, m Matching) (bool, error) {
+	fi := cachedOperation(OpMatchesRegex, s.Key(), s.PatternMatching(m))
+	k, ok := key[fi.Name()]
+	if !ok {
+		k = &config.Key{
+			Name: fi.Name(),
+			Type: config.TypeString,
+		}
 	}
-	str := strings.ToLower(string(b))
-	return strings.Contains(str, strings.ToLower(*g.String)), nil
+	return fi.Apply(k.ExtractValue(row), key)
+}
+
+func (g *GlobalToken) Apply(row map[string]interface{}, line []byte, m Matching) (bool, error) {
+	return containsLiteral(row, line, *g.String, m)
 }
 
 func (c *Condition) Apply(row map[string]interface{}, key map[string]*config.Key) (bool, error) {
@@ -225,16 +343,35 @@ func (c *Condition) Apply(row map[string]interface{}, key map[string]*config.Key
 			Type: config.TypeString,
 		}
 	}
-	return fi.Apply(k.ExtractValue(row), key)
+	value := k.ExtractValue(row)
+	if k.Type == config.TypeString && c.isDuration() && isDurationValue(value) {
+		// an untyped key compared to a duration literal, e.g. latency > 1s, is
+		// compared as a duration rather than as text.
+		key = map[string]*config.Key{k.Name: {Name: k.Name, Type: config.TypeDuration}}
+	}
+	return fi.Apply(value, key)
+}
+
+// isDuration tells whether the condition compares to duration literals only.
+func (c *Condition) isDuration() bool {
+	return c.Value.Duration != nil && (c.Value2 == nil || c.Value2.Duration != nil)
 }
 
-func (c *Term) Apply(row map[string]interface{}, key map[string]*config.Key) (bool, error) {
-	lv, le := c.Left.Apply(row, key)
+func isDurationValue(value string) bool {
+	if len(strings.TrimSpace(value)) == 0 {
+		return true
+	}
+	_, err := config.ParseDuration(value)
+	return err == nil
+}
+
+func (c *Term) Apply(row map[string]interface{}, line []byte, key map[string]*config.Key, m Matching) (bool, error) {
+	lv, le := c.Left.Apply(row, line, key, m)
 	if le != nil {
 		return false, le
 	}
 	for _, r := range c.Right {
-		rv, re := r.ConditionElement.Apply(row, key)
+		rv, re := r.ConditionElement.Apply(row, line, key, m)
 		if re != nil {
 			return false, re
 		}
@@ -243,13 +380,29 @@ func (c *Term) Apply(row map[string]interface{}, key map[string]*config.Key) (bo
 	return lv, nil
 }
 
+// Apply tells whether the entry matches the expression, free text and terms
+// being matched as CurrentMatching tells.
 func (c *Expression) Apply(row map[string]interface{}, key map[string]*config.Key) (bool, error) {
-	lv, le := c.Left.Apply(row, key)
+	return c.ApplyMatching(row, key, CurrentMatching())
+}
+
+// ApplyMatching tells whether the entry matches the expression, free text and
+// terms being matched as m tells, e.g. as toggled in a single view.
+func (c *Expression) ApplyMatching(row map[string]interface{}, key map[string]*config.Key, m Matching) (bool, error) {
+	return c.ApplyLine(row, nil, key, m)
+}
+
+// ApplyLine tells whether the entry matches the expression as ApplyMatching
+// does, free text being searched in the line the entry was read from if
+// given, which must hold the keys and values of the entry as they are, see
+// containsLiteral.
+func (c *Expression) ApplyLine(row map[string]interface{}, line []byte, key map[string]*config.Key, m Matching) (bool, error) {
+	lv, le := c.Left.Apply(row, line, key, m)
 	if le != nil {
 		return false, le
 	}
 	for _, r := range c.Right {
-		rv, re := r.Term.Apply(row, key)
+		rv, re := r.Term.Apply(row, line, key, m)
 		if re != nil {
 			return false, re
 		}
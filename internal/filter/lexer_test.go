@@ -24,6 +24,7 @@ package filter
 
 import (
 	"encoding/json"
+	"fmt"
 	"testing"
 
 	"github.com/badaniya/loggo/internal/config"
@@ -85,7 +86,7 @@ func TestParseFilterExpression(t *testing.T) {
 					Type: config.TypeNumber,
 				},
 			},
-			givenExpression: `((a/b = "x" OR a/b = "y") AND "ME")`,
+			givenExpression: `((a/b = "x" OR a/b = "y") AND "some")`,
 			wantsResult:     true,
 		},
 		{
@@ -314,6 +315,173 @@ func TestParseFilterExpression(t *testing.T) {
 			},
 			wantsResult: true,
 		},
+		{
+			name: `wants true - array selector`,
+			whenJsonRow: `
+					{
+						"spans": [{"name": "first"}, {"name": "second"}]
+					}`,
+			givenExpression: `spans[1].name = "second"`,
+			keySet:          map[string]*config.Key{},
+			wantsResult:     true,
+		},
+		{
+			name: `wants true - durations compared numerically`,
+			whenJsonRow: `
+					{
+						"latency": "0.9s"
+					}`,
+			givenExpression: `latency > 500ms AND latency < 1s`,
+			keySet: map[string]*config.Key{
+				"latency": {
+					Name: "latency",
+					Type: config.TypeDuration,
+				},
+			},
+			wantsResult: true,
+		},
+		{
+			name: `wants true - virtual fingerprint key`,
+			whenJsonRow: `
+					{
+						"message": "timeout calling db-7 after 1200ms"
+					}`,
+			givenExpression: `_fingerprint == "93176471"`,
+			keySet:          map[string]*config.Key{},
+			wantsResult:     true,
+		},
+		{
+			name: `wants true - scoped regex`,
+			whenJsonRow: `
+					{
+						"msg": "Timeout calling db-7",
+						"path": "/api/v2/orders",
+						"trace": "timeout-42"
+					}`,
+			givenExpression: `msg:/out calling db-\d/`,
+			keySet:          map[string]*config.Key{},
+			wantsResult:     true,
+		},
+		{
+			name: `wants false - scoped regex is case sensitive`,
+			whenJsonRow: `
+					{
+						"msg": "Timeout calling db-7",
+						"path": "/api/v2/orders",
+						"trace": "timeout-42"
+					}`,
+			givenExpression: `msg:/^timeout/`,
+			keySet:          map[string]*config.Key{},
+			wantsResult:     false,
+		},
+		{
+			name: `wants true - lower case scoped term ignores case`,
+			whenJsonRow: `
+					{
+						"msg": "Timeout calling db-7",
+						"path": "/api/v2/orders",
+						"trace": "timeout-42"
+					}`,
+			givenExpression: `msg:timeout`,
+			keySet:          map[string]*config.Key{},
+			wantsResult:     true,
+		},
+		{
+			name: `wants false - upper case scoped term matches case`,
+			whenJsonRow: `
+					{
+						"msg": "Timeout calling db-7",
+						"path": "/api/v2/orders",
+						"trace": "timeout-42"
+					}`,
+			givenExpression: `msg:TIMEOUT`,
+			keySet:          map[string]*config.Key{},
+			wantsResult:     false,
+		},
+		{
+			name: `wants true - scoped term anchored at start`,
+			whenJsonRow: `
+					{
+						"msg": "Timeout calling db-7",
+						"path": "/api/v2/orders",
+						"trace": "timeout-42"
+					}`,
+			givenExpression: `path:^/api/v2`,
+			keySet:          map[string]*config.Key{},
+			wantsResult:     true,
+		},
+		{
+			name: `wants false - scoped term anchored at end`,
+			whenJsonRow: `
+					{
+						"msg": "Timeout calling db-7",
+						"path": "/api/v2/orders",
+						"trace": "timeout-42"
+					}`,
+			givenExpression: `path:/api$`,
+			keySet:          map[string]*config.Key{},
+			wantsResult:     false,
+		},
+		{
+			name: `wants false - scoped term not found in other keys`,
+			whenJsonRow: `
+					{
+						"msg": "Timeout calling db-7",
+						"path": "/api/v2/orders",
+						"trace": "timeout-42"
+					}`,
+			givenExpression: `path:timeout`,
+			keySet:          map[string]*config.Key{},
+			wantsResult:     false,
+		},
+		{
+			name: `wants true - quoted scoped term`,
+			whenJsonRow: `
+					{
+						"msg": "Timeout calling db-7",
+						"path": "/api/v2/orders",
+						"trace": "timeout-42"
+					}`,
+			givenExpression: `msg:"calling db" AND trace:'-42'`,
+			keySet:          map[string]*config.Key{},
+			wantsResult:     true,
+		},
+		{
+			name: `wants true - scoped terms combined`,
+			whenJsonRow: `
+					{
+						"msg": "Timeout calling db-7",
+						"path": "/api/v2/orders",
+						"trace": "timeout-42"
+					}`,
+			givenExpression: `(msg:/nope/ OR path:orders$) AND level:"" OR trace:^timeout`,
+			keySet:          map[string]*config.Key{},
+			wantsResult:     true,
+		},
+		{
+			name: `wants true - scoped escaped slash`,
+			whenJsonRow: `
+					{
+						"msg": "Timeout calling db-7",
+						"path": "/api/v2/orders",
+						"trace": "timeout-42"
+					}`,
+			givenExpression: `path:/^\/api\/v2\//`,
+			keySet:          map[string]*config.Key{},
+			wantsResult:     true,
+		},
+		{
+			name: `wants error - invalid scoped regex`,
+			whenJsonRow: `
+					{
+						"msg": "Timeout calling db-7",
+						"path": "/api/v2/orders",
+						"trace": "timeout-42"
+					}`,
+			givenExpression: `msg:/(timeout/`,
+			keySet:          map[string]*config.Key{},
+			wantsError:      true,
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -333,3 +501,119 @@ func TestParseFilterExpression(t *testing.T) {
 		})
 	}
 }
+
+func TestScopedToken_Pattern(t *testing.T) {
+	defer SetMatching(CurrentMatching())
+	tests := []struct {
+		token    string
+		matching Matching
+		want     string
+	}{
+		{`msg:timeout`, Matching{}, `(?i)timeout`},
+		{`msg:Timeout`, Matching{}, `Timeout`},
+		{`msg:Timeout`, Matching{Case: IgnoreCase}, `(?i)Timeout`},
+		{`msg:timeout`, Matching{Case: MatchCase}, `timeout`},
+		{`msg:"db-7"`, Matching{WholeWord: true}, `(?i)(^|[^\p{L}\p{N}_])db-7([^\p{L}\p{N}_]|$)`},
+		{`path:^/api$`, Matching{WholeWord: true}, `(?i)^/api$`},
+		{`path:^/api`, Matching{WholeWord: true}, `(?i)^/api([^\p{L}\p{N}_]|$)`},
+		{`msg:/Time(d )?out/`, Matching{Case: IgnoreCase, WholeWord: true}, `Time(d )?out`},
+	}
+	for _, test := range tests {
+		t.Run(test.token, func(t *testing.T) {
+			SetMatching(test.matching)
+			s := &ScopedToken{Token: test.token}
+			assert.Equal(t, test.want, s.Pattern())
+		})
+	}
+	SetMatching(Matching{WholeWord: true})
+	exp, err := ParseFilterExpression(`msg:time AND "calling"`)
+	assert.NoError(t, err)
+	ok, err := exp.Apply(map[string]interface{}{"msg": "timeout calling db"}, nil)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = exp.Apply(map[string]interface{}{"msg": "time calling db"}, nil)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestExpression_ApplyMatching(t *testing.T) {
+	defer SetMatching(CurrentMatching())
+	SetMatching(Matching{Case: IgnoreCase})
+	exp, err := ParseFilterExpression(`msg:Time AND "Calling"`)
+	assert.NoError(t, err)
+	row := map[string]interface{}{"msg": "timeout calling db"}
+	ok, err := exp.ApplyMatching(row, nil, Matching{Case: MatchCase})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = exp.ApplyMatching(row, nil, Matching{Case: IgnoreCase})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = exp.Apply(row, nil)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestCompile(t *testing.T) {
+	row := map[string]interface{}{"status": "503", "latency": "1.5s"}
+	keys := map[string]*config.Key{
+		"status":  {Name: "status", Type: config.TypeNumber},
+		"latency": {Name: "latency", Type: config.TypeDuration},
+	}
+	expr, err := Compile(`status >= 500 AND latency > 1s`)
+	assert.NoError(t, err)
+	again, err := Compile(`status >= 500 AND latency > 1s`)
+	assert.NoError(t, err)
+	assert.Same(t, expr, again)
+	ok, err := expr.Apply(row, keys)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = Compile(`status >=`)
+	assert.Error(t, err)
+}
+
+func TestCompile_Evicts(t *testing.T) {
+	first, err := Compile(`status = 1`)
+	assert.NoError(t, err)
+	for i := 0; i < maxCompiled; i++ {
+		// the first one, being used, isn't the least recently used
+		_, err = Compile(`status = 1`)
+		assert.NoError(t, err)
+		_, err = Compile(fmt.Sprintf(`status = %d`, i+2))
+		assert.NoError(t, err)
+	}
+	assert.LessOrEqual(t, len(compiled), maxCompiled)
+	again, err := Compile(`status = 1`)
+	assert.NoError(t, err)
+	assert.Same(t, first, again)
+	_, ok := compiled[`status = 2`]
+	assert.False(t, ok)
+}
+
+func TestCondition_ApplyUntypedDuration(t *testing.T) {
+	tests := []struct {
+		name     string
+		exp      string
+		latency  interface{}
+		expected bool
+	}{
+		{name: "lower unit", exp: `latency > 1s`, latency: "900ms", expected: false},
+		{name: "greater", exp: `latency > 1s`, latency: "1.5s", expected: true},
+		{name: "seconds as number", exp: `latency > 1s`, latency: 2, expected: true},
+		{name: "between", exp: `latency BETWEEN 100ms AND 1s`, latency: "900ms", expected: true},
+		{name: "not a duration", exp: `latency > 1s`, latency: "slow", expected: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			exp, err := ParseFilterExpression(test.exp)
+			assert.NoError(t, err)
+			ok, err := exp.Apply(map[string]interface{}{"latency": test.latency}, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, ok)
+			ok, err = exp.Apply(map[string]interface{}{"latency": test.latency},
+				map[string]*config.Key{"latency": {Name: "latency", Type: config.TypeString}})
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, ok)
+		})
+	}
+}
@@ -0,0 +1,224 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package filter
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/badaniya/loggo/internal/config"
+)
+
+// jsonPunctuation are the characters a literal spanning several keys or
+// values of an entry, e.g. "level":"error", holds.
+const jsonPunctuation = `":,{}[]\`
+
+// containsLiteral reports whether a key or value of the entry holds the
+// literal, as matched by m. It's the hot path of free text searches, e.g. by
+// a request ID over a large buffer, so the line the entry was read from, if
+// given, is searched as is, the note taken on the entry aside. Otherwise the
+// entry is walked rather than encoded, the text being compared in place
+// unless non-ASCII. Only literals holding JSON punctuation are searched in
+// the JSON encoding of the entry.
+func containsLiteral(row map[string]interface{}, line []byte, literal string, m Matching) (bool, error) {
+	l := newLiteral(literal, m)
+	if strings.ContainsAny(literal, jsonPunctuation) {
+		b, err := json.Marshal(row)
+		if err != nil {
+			return false, err
+		}
+		return l.in(string(b)), nil
+	}
+	if line != nil {
+		if l.inLine(line) {
+			return true, nil
+		}
+		note, _ := row[config.Note].(string)
+		return l.in(note), nil
+	}
+	return l.inValue(row)
+}
+
+// literal is a searched text.
+type literal struct {
+	text  string
+	lower string
+	ascii bool
+	// fold tells case is ignored and there are letters to fold
+	fold bool
+	word bool
+}
+
+func newLiteral(text string, m Matching) *literal {
+	l := &literal{text: text, lower: strings.ToLower(text), ascii: true, word: m.WholeWord}
+	letters := false
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if c >= utf8.RuneSelf {
+			l.ascii = false
+		} else if c|0x20 >= 'a' && c|0x20 <= 'z' {
+			letters = true
+		}
+	}
+	l.fold = !m.Sensitive(text) && (letters || !l.ascii)
+	return l
+}
+
+func (l *literal) inValue(v interface{}) (bool, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			if l.in(k) {
+				return true, nil
+			}
+			if ok, err := l.inValue(e); ok || err != nil {
+				return ok, err
+			}
+		}
+		return false, nil
+	case []interface{}:
+		for _, e := range v {
+			if ok, err := l.inValue(e); ok || err != nil {
+				return ok, err
+			}
+		}
+		return false, nil
+	case string:
+		return l.in(v), nil
+	case float64:
+		if abs := math.Abs(v); abs == 0 || abs >= 1e-6 && abs < 1e21 {
+			return l.in(strconv.FormatFloat(v, 'f', -1, 64)), nil
+		}
+	case bool:
+		return l.in(strconv.FormatBool(v)), nil
+	case nil:
+		return l.in("null"), nil
+	}
+	// any other value as it's encoded, e.g. exponent floats
+	b, err := json.Marshal(v)
+	if err != nil {
+		return false, err
+	}
+	return l.in(string(b)), nil
+}
+
+// inLine reports whether the line holds the literal, comparing its bytes in
+// place unless whole words or non-ASCII text are matched.
+func (l *literal) inLine(line []byte) bool {
+	switch {
+	case l.word || l.fold && !l.ascii:
+		return l.in(string(line))
+	case l.fold:
+		return indexFoldLine(line, l.lower) >= 0
+	default:
+		return bytes.Contains(line, []byte(l.text))
+	}
+}
+
+// in reports whether s holds the literal.
+func (l *literal) in(s string) bool {
+	needle, index := l.text, strings.Index
+	switch {
+	case !l.fold:
+		// digits and punctuation, e.g. IDs, or matching case
+	case !l.ascii:
+		s, needle = strings.ToLower(s), l.lower
+	default:
+		needle, index = l.lower, indexFoldASCII
+	}
+	if !l.word {
+		return index(s, needle) >= 0
+	}
+	for i := 0; i <= len(s)-len(needle); {
+		j := index(s[i:], needle)
+		if j < 0 {
+			return false
+		}
+		if j += i; WordBounded(s, j, j+len(needle)) {
+			return true
+		}
+		_, n := utf8.DecodeRuneInString(s[j:])
+		i = j + max(n, 1)
+	}
+	return false
+}
+
+// indexFoldASCII is the index of the lower case needle in s, folding the
+// ASCII letters of s in place.
+func indexFoldASCII(s, needle string) int {
+	n := len(needle)
+	for i := 0; i+n <= len(s); i++ {
+		j := 0
+		for j < n && lowerASCII(s[i+j]) == needle[j] {
+			j++
+		}
+		if j == n {
+			return i
+		}
+	}
+	return -1
+}
+
+// indexFoldLine is indexFoldASCII over the bytes of a line, jumping from one
+// occurrence to the next of the first byte of the needle that isn't a letter,
+// e.g. a digit of a request ID.
+func indexFoldLine(line []byte, needle string) int {
+	anchor := 0
+	for anchor < len(needle) && needle[anchor]|0x20 >= 'a' && needle[anchor]|0x20 <= 'z' {
+		anchor++
+	}
+	n := len(needle)
+	for i := anchor; i <= len(line); {
+		if anchor < n {
+			j := bytes.IndexByte(line[i:], needle[anchor])
+			if j < 0 {
+				return -1
+			}
+			i += j
+		}
+		start := i - anchor
+		if start+n > len(line) {
+			return -1
+		}
+		j := 0
+		for j < n && lowerASCII(line[start+j]) == needle[j] {
+			j++
+		}
+		if j == n {
+			return start
+		}
+		i++
+	}
+	return -1
+}
+
+func lowerASCII(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
@@ -0,0 +1,194 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package filter
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainsLiteral(t *testing.T) {
+	row := map[string]interface{}{
+		"message":    "Payment FAILED for order 42",
+		"request_id": "3f2b9c1e-77aa-4d1b-9d0e-5c1f0a2b3c4d",
+		"user":       map[string]interface{}{"name": "José Ñúñez", "html": "<b>"},
+	}
+	tests := []struct {
+		literal string
+		want    bool
+	}{
+		{"payment failed", true},
+		{"PAYMENT failed", true},
+		{"3F2B9C1E", true},
+		{"9c1e-77AA", true},
+		{"42", true},
+		{"-77aa-", true},
+		{`"message":"payment`, true},
+		{"josé ñúñez", true},
+		{"JOSÉ", true},
+		{"REQUEST_ID", true},
+		{"name", true},
+		{"1.5", true},
+		{"true", true},
+		{"null", true},
+		{"1e+21", true},
+		{"<b>", true},
+		// spanning keys and values, the entry is searched as encoded
+		{`"html":"<b>"`, false},
+		{`"html":"\u003cb\u003e"`, true},
+		{"43", false},
+		{"refunded", false},
+		{"order 42 request", false},
+		{"", true},
+	}
+	row["ratio"], row["ok"], row["none"], row["huge"] = 1.5, true, nil, 1e21
+	for _, test := range tests {
+		t.Run(test.literal, func(t *testing.T) {
+			got, err := containsLiteral(row, nil, test.literal, Matching{Case: IgnoreCase})
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+	_, err := containsLiteral(map[string]interface{}{"f": func() {}}, nil, `"f"`, Matching{})
+	assert.Error(t, err)
+}
+
+func TestContainsLiteral_Matching(t *testing.T) {
+	row := map[string]interface{}{
+		"message": "Payment FAILED for order_42: errors ahead",
+		"user":    map[string]interface{}{"name": "José Ñúñez"},
+	}
+	tests := []struct {
+		literal  string
+		matching Matching
+		want     bool
+	}{
+		{"failed", Matching{}, true},
+		{"Failed", Matching{}, false},
+		{"FAILED", Matching{}, true},
+		{"ñúñez", Matching{}, true},
+		{"ÑÚÑEZ", Matching{}, false},
+		{"Failed", Matching{Case: IgnoreCase}, true},
+		{"failed", Matching{Case: MatchCase}, false},
+		{"FAILED for", Matching{Case: MatchCase}, true},
+		{"error", Matching{WholeWord: true}, false},
+		{"errors", Matching{WholeWord: true}, true},
+		{"order", Matching{WholeWord: true}, false},
+		{"42", Matching{WholeWord: true}, false},
+		{"order_42", Matching{WholeWord: true}, true},
+		{"jos", Matching{WholeWord: true}, false},
+		{"josé", Matching{WholeWord: true}, true},
+		{"message", Matching{WholeWord: true}, true},
+		{"payment failed", Matching{WholeWord: true}, true},
+		{`"message":"payment`, Matching{WholeWord: true}, true},
+		{`"message":"pay`, Matching{WholeWord: true}, false},
+	}
+	line := []byte(`{"message":"Payment FAILED for order_42: errors ahead","user":{"name":"José Ñúñez"}}`)
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s %s %t", test.literal, test.matching.Case, test.matching.WholeWord), func(t *testing.T) {
+			got, err := containsLiteral(row, nil, test.literal, test.matching)
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, got)
+			// the same in the line the entry was read from
+			got, err = containsLiteral(row, line, test.literal, test.matching)
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestIndexFoldLine(t *testing.T) {
+	for _, test := range []struct {
+		line, needle string
+		want         int
+	}{
+		{"Request 3F2B-9c1e", "3f2b-9c1e", 8},
+		{"3f2b 3F2B-9C1E", "3f2b-9c1e", 5},
+		{"ends with TIMEOUT", "timeout", 10},
+		{"TIME", "timeout", -1},
+		{"x1", "x1", 0},
+		{"x", "x1", -1},
+		{"", "", 0},
+	} {
+		assert.Equal(t, test.want, indexFoldLine([]byte(test.line), test.needle), test.line)
+		assert.Equal(t, test.want, indexFoldASCII(test.line, test.needle), test.line)
+	}
+}
+
+func TestContainsLiteral_Line(t *testing.T) {
+	line := []byte(`{"status":1.50,"message":"timeout"}`)
+	row := map[string]interface{}{"status": 1.5, "message": "timeout", config.Note: "Flaky Upstream"}
+	for literal, want := range map[string]bool{"1.50": true, "TIMEOUT": true, "flaky": true, "upstream dns": false} {
+		got, err := containsLiteral(row, line, literal, Matching{Case: IgnoreCase})
+		assert.NoError(t, err)
+		assert.Equal(t, want, got, literal)
+	}
+}
+
+func BenchmarkGlobalToken_Apply(b *testing.B) {
+	exp, err := ParseFilterExpression(`"3f2b9c1e-77aa"`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	rows := make([]map[string]interface{}, 1000)
+	for i := range rows {
+		rows[i] = map[string]interface{}{
+			"timestamp":  "2024-06-01T10:00:00Z",
+			"severity":   "INFO",
+			"message":    fmt.Sprintf("GET /api/v2/orders/%d completed in 12ms", i),
+			"request_id": fmt.Sprintf("%08x-77aa-4d1b-9d0e-5c1f0a2b3c4d", i),
+			"labels":     map[string]interface{}{"pod": "checkout-7d9f", "namespace": "shop"},
+		}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := exp.Apply(rows[i%len(rows)], nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGlobalToken_ApplyLine(b *testing.B) {
+	exp, err := ParseFilterExpression(`"3f2b9c1e-77aa"`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	lines := make([][]byte, 1000)
+	for i := range lines {
+		lines[i] = []byte(fmt.Sprintf(`{"timestamp":"2024-06-01T10:00:00Z","severity":"INFO",`+
+			`"message":"GET /api/v2/orders/%d completed in 12ms","request_id":"%08x-77aa-4d1b-9d0e-5c1f0a2b3c4d",`+
+			`"labels":{"pod":"checkout-7d9f","namespace":"shop"}}`, i, i))
+	}
+	row := map[string]interface{}{}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := exp.ApplyLine(row, lines[i%len(lines)], nil, Matching{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package filter
+
+import (
+	"strings"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Case is how letter case is matched, as ripgrep's --ignore-case,
+// --case-sensitive and --smart-case options do.
+type Case int
+
+const (
+	// SmartCase ignores case unless the text holds an upper case letter.
+	SmartCase Case = iota
+	// IgnoreCase ignores case.
+	IgnoreCase
+	// MatchCase matches case.
+	MatchCase
+)
+
+func (c Case) String() string {
+	switch c {
+	case IgnoreCase:
+		return "ignore case"
+	case MatchCase:
+		return "match case"
+	}
+	return "smart case"
+}
+
+// Next is the option cycled to after c: smart, ignore then match case.
+func (c Case) Next() Case {
+	return (c + 1) % 3
+}
+
+// Matching are the options free text, e.g. "timeout", and scoped terms, e.g.
+// msg:timeout, are matched with. Regular expressions are matched as written.
+type Matching struct {
+	Case Case
+	// WholeWord only matches text not within a word, as ripgrep's
+	// --word-regexp does, e.g. "error" rather than "errors".
+	WholeWord bool
+}
+
+var matching atomic.Pointer[Matching]
+
+// CurrentMatching returns the options filters and searches match with by
+// default, e.g. as set by flags, smart case unless set. Views toggling their
+// own options apply them with Expression.ApplyMatching.
+func CurrentMatching() Matching {
+	if m := matching.Load(); m != nil {
+		return *m
+	}
+	return Matching{}
+}
+
+// SetMatching replaces the options filters and searches match with by default,
+// which already parsed expressions apply from then on.
+func SetMatching(m Matching) {
+	matching.Store(&m)
+}
+
+// Sensitive reports whether text is matched case-sensitively.
+func (m Matching) Sensitive(text string) bool {
+	switch m.Case {
+	case IgnoreCase:
+		return false
+	case MatchCase:
+		return true
+	}
+	return strings.IndexFunc(text, unicode.IsUpper) >= 0
+}
+
+// WordBounded reports whether s[i:j] is neither preceded nor followed by a
+// word character, i.e. a letter, digit or underscore.
+func WordBounded(s string, i, j int) bool {
+	if r, _ := utf8.DecodeLastRuneInString(s[:i]); i > 0 && isWordRune(r) {
+		return false
+	}
+	if r, _ := utf8.DecodeRuneInString(s[j:]); j < len(s) && isWordRune(r) {
+		return false
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,102 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package format
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+
+	"github.com/badaniya/loggo/internal/config"
+)
+
+// ToNDJSON parses the raw lines read from r with the parser, writing each
+// entry as a JSON line to w. It returns the number of written entries.
+func ToNDJSON(w io.Writer, r io.Reader, parser Parser) (int, error) {
+	bw := bufio.NewWriter(w)
+	n := 0
+	emit := func(entries []map[string]interface{}) error {
+		for _, e := range entries {
+			b, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			b = append(b, '\n')
+			if _, err := bw.Write(b); err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if err := emit(parser.Feed(scanner.Text())); err != nil {
+			return n, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return n, err
+	}
+	if err := emit(parser.Flush()); err != nil {
+		return n, err
+	}
+	return n, bw.Flush()
+}
+
+// ToCSV writes the JSON lines read from r as CSV to w: a header with the key
+// names followed by a record per entry with the keys' values. Lines which
+// aren't JSON are skipped. It returns the number of written records.
+func ToCSV(w io.Writer, r io.Reader, keys []config.Key) (int, error) {
+	cw := csv.NewWriter(w)
+	record := make([]string, len(keys))
+	for i, k := range keys {
+		record[i] = k.Name
+	}
+	if err := cw.Write(record); err != nil {
+		return 0, err
+	}
+	n := 0
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		m := make(map[string]interface{})
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			continue
+		}
+		for i := range keys {
+			record[i] = keys[i].ExtractValue(m)
+		}
+		if err := cw.Write(record); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if err := scanner.Err(); err != nil {
+		return n, err
+	}
+	cw.Flush()
+	return n, cw.Error()
+}
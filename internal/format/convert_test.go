@@ -0,0 +1,80 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package format
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToNDJSON(t *testing.T) {
+	log := "2024-01-30 15:00:01.000 UTC [1235] ERROR:  relation \"x\" does not exist\n" +
+		"2024-01-30 15:00:01.000 UTC [1235] STATEMENT:  select * from x;\n" +
+		"2024-01-30 15:00:02.000 UTC [1236] LOG:  checkpoint starting: time"
+
+	sb := &strings.Builder{}
+	n, err := ToNDJSON(sb, strings.NewReader(log), &postgresParser{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	lines := strings.Split(strings.TrimSuffix(sb.String(), "\n"), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"query":"select * from x;"`)
+	assert.Contains(t, lines[1], `"message":"checkpoint starting: time"`)
+}
+
+func TestToCSV(t *testing.T) {
+	tests := []struct {
+		name  string
+		keys  []config.Key
+		input string
+		wants string
+		count int
+	}{
+		{
+			name:  "Template columns",
+			keys:  []config.Key{{Name: "level"}, {Name: "jsonPayload/message"}},
+			input: `{"level":"INFO","jsonPayload":{"message":"a, \"quoted\" b"}}` + "\n" + `{"level":"ERROR"}`,
+			wants: "level,jsonPayload/message\nINFO,\"a, \"\"quoted\"\" b\"\nERROR,\n",
+			count: 2,
+		},
+		{
+			name:  "Skips plain text",
+			keys:  []config.Key{{Name: "level"}},
+			input: "starting up\n" + `{"level":"WARN"}`,
+			wants: "level\nWARN\n",
+			count: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sb := &strings.Builder{}
+			n, err := ToCSV(sb, strings.NewReader(test.input), test.keys)
+			assert.NoError(t, err)
+			assert.Equal(t, test.count, n)
+			assert.Equal(t, test.wants, sb.String())
+		})
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package format
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Parser turns raw text lines into structured entries. Formats such as slow
+// query logs span several lines per entry, so an entry is only returned once
+// the line starting the next one is fed, or upon Flush.
+type Parser interface {
+	// Feed consumes a single raw line and returns the entries it completed.
+	Feed(line string) []map[string]interface{}
+	// Flush returns the pending entry, if any.
+	Flush() []map[string]interface{}
+}
+
+const (
+	MySQLSlow   = "mysql-slow"
+	PostgresLog = "postgres"
+	PostgresCSV = "postgres-csv"
+)
+
+var parsers = map[string]func() Parser{
+	MySQLSlow:   func() Parser { return &mysqlSlowParser{} },
+	PostgresLog: func() Parser { return &postgresParser{} },
+	PostgresCSV: func() Parser { return &postgresCSVParser{} },
+}
+
+// Names lists the supported format names.
+func Names() []string {
+	names := make([]string, 0, len(parsers))
+	for k := range parsers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewParser builds the parser for the given format name.
+func NewParser(name string) (Parser, error) {
+	p, ok := parsers[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return nil, fmt.Errorf("unknown format '%s', use one of: %s", name, strings.Join(Names(), ", "))
+	}
+	return p(), nil
+}
+
+// NameOf returns the format name of the parser, empty if not one of the
+// supported formats.
+func NameOf(p Parser) string {
+	for name, make := range parsers {
+		if reflect.TypeOf(make()) == reflect.TypeOf(p) {
+			return name
+		}
+	}
+	return ""
+}
+
+var (
+	sqlStringReg = regexp.MustCompile(`'(?:[^'\\]|\\.|'')*'`)
+	sqlNumberReg = regexp.MustCompile(`(^|[^\w$.])-?\d+(?:\.\d+)?`)
+	sqlListReg   = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)+\s*\)`)
+	spacesReg    = regexp.MustCompile(`\s+`)
+)
+
+// NormalizeQuery replaces literals with placeholders and collapses white
+// spaces, so that similar statements share the same normalized form, e.g.
+// "SELECT * FROM t WHERE id IN (1, 2, 3)" -> "SELECT * FROM t WHERE id IN (?+)".
+func NormalizeQuery(query string) string {
+	q := sqlStringReg.ReplaceAllString(query, "?")
+	q = sqlNumberReg.ReplaceAllString(q, "${1}?")
+	q = sqlListReg.ReplaceAllString(q, "(?+)")
+	q = spacesReg.ReplaceAllString(q, " ")
+	return strings.TrimRight(strings.TrimSpace(q), ";")
+}
+
+func parseNumber(s string) interface{} {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
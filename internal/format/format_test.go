@@ -0,0 +1,174 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package format
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func feedAll(p Parser, text string) []map[string]interface{} {
+	var out []map[string]interface{}
+	for _, l := range strings.Split(text, "\n") {
+		out = append(out, p.Feed(l)...)
+	}
+	return append(out, p.Flush()...)
+}
+
+func TestNormalizeQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		wants string
+	}{
+		{
+			name:  "Literals",
+			query: "SELECT * FROM t1 WHERE id = 5 AND name = 'it''s' AND v > -1.5;",
+			wants: "SELECT * FROM t1 WHERE id = ? AND name = ? AND v > ?",
+		},
+		{
+			name:  "Lists and whitespaces",
+			query: "SELECT *\n\tFROM t\n\tWHERE id IN (1, 2,3)",
+			wants: "SELECT * FROM t WHERE id IN (?+)",
+		},
+		{
+			name:  "Positional parameters",
+			query: "SELECT * FROM t WHERE id = $1 LIMIT 10",
+			wants: "SELECT * FROM t WHERE id = $1 LIMIT ?",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.wants, NormalizeQuery(test.query))
+		})
+	}
+}
+
+func TestNewParser(t *testing.T) {
+	_, err := NewParser("mysql-slow")
+	assert.NoError(t, err)
+	_, err = NewParser("nope")
+	assert.Error(t, err)
+}
+
+func TestNameOf(t *testing.T) {
+	for _, name := range Names() {
+		p, err := NewParser(name)
+		assert.NoError(t, err)
+		assert.Equal(t, name, NameOf(p))
+	}
+	assert.Empty(t, NameOf(nil))
+}
+
+func TestMySQLSlowParser(t *testing.T) {
+	log := `/usr/sbin/mysqld, Version: 8.0.36 (MySQL Community Server - GPL). started with:
+Tcp port: 3306  Unix socket: /var/run/mysqld/mysqld.sock
+Time                 Id Command    Argument
+# Time: 2024-01-30T15:00:00.123456Z
+# User@Host: app[app] @ localhost [127.0.0.1]  Id:    12
+# Query_time: 2.500000  Lock_time: 0.000100 Rows_sent: 1  Rows_examined: 100000
+use shop;
+SET timestamp=1706626800;
+SELECT * FROM orders
+WHERE id = 5;
+# User@Host: report[report] @ db-host [10.0.0.2]  Id:    13
+# Query_time: 1.000000  Lock_time: 0.000000 Rows_sent: 10  Rows_examined: 10
+SET timestamp=1706626800;
+SELECT name FROM customers WHERE name = 'bob';`
+
+	entries := feedAll(&mysqlSlowParser{}, log)
+	assert.Len(t, entries, 2)
+	e := entries[0]
+	assert.Equal(t, time.Unix(1706626800, 0).Local().Format(time.RFC3339), e["timestamp"])
+	assert.Equal(t, "app", e["user"])
+	assert.Equal(t, "localhost", e["host"])
+	assert.Equal(t, "127.0.0.1", e["client_ip"])
+	assert.Equal(t, int64(12), e["thread_id"])
+	assert.Equal(t, "shop", e["database"])
+	assert.Equal(t, 2.5, e["query_time"])
+	assert.Equal(t, 2500.0, e["duration_ms"])
+	assert.Equal(t, int64(100000), e["rows_examined"])
+	assert.Equal(t, "SELECT * FROM orders\nWHERE id = 5;", e["query"])
+	assert.Equal(t, "SELECT * FROM orders WHERE id = ?", e["normalized_query"])
+
+	e = entries[1]
+	assert.Equal(t, "report", e["user"])
+	assert.Equal(t, "10.0.0.2", e["client_ip"])
+	assert.Equal(t, "SELECT name FROM customers WHERE name = ?", e["normalized_query"])
+}
+
+func TestPostgresParser(t *testing.T) {
+	log := "2024-01-30 15:00:00.123 UTC [1234] app@shop LOG:  duration: 2001.500 ms  statement: SELECT *\n" +
+		"\tFROM orders WHERE id = 5;\n" +
+		"2024-01-30 15:00:01.000 UTC [1235] ERROR:  relation \"x\" does not exist at character 15\n" +
+		"2024-01-30 15:00:01.000 UTC [1235] STATEMENT:  select * from x;\n" +
+		"2024-01-30 15:00:02.000 UTC [1236] LOG:  checkpoint starting: time"
+
+	entries := feedAll(&postgresParser{}, log)
+	assert.Len(t, entries, 3)
+	e := entries[0]
+	assert.Equal(t, time.Date(2024, 1, 30, 15, 0, 0, 123000000, time.UTC).Local().Format(time.RFC3339), e["timestamp"])
+	assert.Equal(t, int64(1234), e["pid"])
+	assert.Equal(t, "app", e["user"])
+	assert.Equal(t, "shop", e["database"])
+	assert.Equal(t, "LOG", e["severity"])
+	assert.Equal(t, 2001.5, e["duration_ms"])
+	assert.Equal(t, "SELECT *\n\tFROM orders WHERE id = 5;", e["query"])
+	assert.Equal(t, "SELECT * FROM orders WHERE id = ?", e["normalized_query"])
+
+	e = entries[1]
+	assert.Equal(t, "ERROR", e["severity"])
+	assert.Equal(t, "select * from x;", e["query"])
+	assert.Equal(t, "select * from x", e["normalized_query"])
+
+	e = entries[2]
+	assert.Equal(t, "checkpoint starting: time", e["message"])
+	assert.Nil(t, e["query"])
+}
+
+func TestPostgresCSVParser(t *testing.T) {
+	log := `2024-01-30 15:00:00.123 UTC,"app","shop",1234,"10.0.0.1:5555",65b9,1,"SELECT",2024-01-30 14:00:00 UTC,3/1,0,LOG,00000,"duration: 12.5 ms  statement: SELECT *
+FROM orders WHERE id = 7",,,,,,,,,"psql","client backend",,0
+2024-01-30 15:00:01.000 UTC,"app","shop",1234,"10.0.0.1:5555",65b9,2,"",2024-01-30 14:00:00 UTC,3/2,0,ERROR,42P01,"relation ""x"" does not exist",,,,,,"select * from x",15,,"psql","client backend",,0`
+
+	entries := feedAll(&postgresCSVParser{}, log)
+	assert.Len(t, entries, 2)
+	e := entries[0]
+	assert.Equal(t, "app", e["user"])
+	assert.Equal(t, "shop", e["database"])
+	assert.Equal(t, int64(1234), e["pid"])
+	assert.Equal(t, "LOG", e["severity"])
+	assert.Equal(t, 12.5, e["duration_ms"])
+	assert.Equal(t, "SELECT *\nFROM orders WHERE id = 7", e["query"])
+	assert.Equal(t, "SELECT * FROM orders WHERE id = ?", e["normalized_query"])
+	assert.Equal(t, "psql", e["application_name"])
+
+	e = entries[1]
+	assert.Equal(t, "ERROR", e["severity"])
+	assert.Equal(t, "42P01", e["sql_state"])
+	assert.Equal(t, `relation "x" does not exist`, e["message"])
+	assert.Equal(t, "select * from x", e["query"])
+}
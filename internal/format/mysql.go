@@ -0,0 +1,151 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package format
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	mysqlTimeReg     = regexp.MustCompile(`^# Time:\s+(.+)$`)
+	mysqlUserHostReg = regexp.MustCompile(`^# User@Host:\s+(\S*?)\[[^\]]*\]\s+@\s+(\S*)\s*\[([^\]]*)\](?:\s+Id:\s+(\d+))?`)
+	mysqlStatsReg    = regexp.MustCompile(`(\w+):\s+(\S+)`)
+	mysqlUseReg      = regexp.MustCompile(`(?i)^use\s+` + "`?" + `([^;` + "`" + `]+)` + "`?" + `;$`)
+	mysqlSetTsReg    = regexp.MustCompile(`(?i)^SET timestamp=(\d+);$`)
+)
+
+// mysqlSlowParser parses the MySQL/MariaDB slow query log, e.g.:
+//
+//	# Time: 2024-01-30T15:00:00.123456Z
+//	# User@Host: app[app] @ localhost [127.0.0.1]  Id:    12
+//	# Query_time: 2.000123  Lock_time: 0.000100 Rows_sent: 1  Rows_examined: 100000
+//	SET timestamp=1706626800;
+//	SELECT * FROM t
+//	WHERE id = 5;
+type mysqlSlowParser struct {
+	entry     map[string]interface{}
+	query     []string
+	lastTime  string
+	hasHeader bool
+}
+
+func (p *mysqlSlowParser) Feed(line string) []map[string]interface{} {
+	line = strings.TrimRight(line, "\r\n")
+	trimmed := strings.TrimSpace(line)
+	if len(trimmed) == 0 {
+		return nil
+	}
+
+	if m := mysqlTimeReg.FindStringSubmatch(trimmed); m != nil {
+		out := p.Flush()
+		p.lastTime = parseMySQLTime(m[1])
+		p.start()
+		return out
+	}
+	if m := mysqlUserHostReg.FindStringSubmatch(trimmed); m != nil {
+		var out []map[string]interface{}
+		// "# Time" is only logged when the second changes, so a User@Host
+		// line may open a new entry on its own.
+		if p.hasHeader {
+			out = p.Flush()
+			p.start()
+		} else if p.entry == nil {
+			p.start()
+		}
+		p.hasHeader = true
+		p.entry["user"] = m[1]
+		p.entry["host"] = m[2]
+		p.entry["client_ip"] = m[3]
+		if len(m[4]) > 0 {
+			p.entry["thread_id"] = parseNumber(m[4])
+		}
+		return out
+	}
+	if strings.HasPrefix(trimmed, "#") {
+		if p.entry == nil {
+			return nil
+		}
+		for _, m := range mysqlStatsReg.FindAllStringSubmatch(trimmed, -1) {
+			p.entry[strings.ToLower(m[1])] = parseNumber(m[2])
+		}
+		return nil
+	}
+	if p.entry == nil {
+		// server startup banner, e.g. "Tcp port: 3306  Unix socket: ..."
+		return nil
+	}
+	if m := mysqlSetTsReg.FindStringSubmatch(trimmed); m != nil && len(p.query) == 0 {
+		if ts, err := strconv.ParseInt(m[1], 10, 64); err == nil {
+			p.entry["timestamp"] = time.Unix(ts, 0).Local().Format(time.RFC3339)
+		}
+		return nil
+	}
+	if m := mysqlUseReg.FindStringSubmatch(trimmed); m != nil && len(p.query) == 0 {
+		p.entry["database"] = m[1]
+		return nil
+	}
+	p.query = append(p.query, line)
+	return nil
+}
+
+func (p *mysqlSlowParser) start() {
+	p.entry = map[string]interface{}{}
+	p.query = nil
+	p.hasHeader = false
+	if len(p.lastTime) > 0 {
+		p.entry["timestamp"] = p.lastTime
+	}
+}
+
+func (p *mysqlSlowParser) Flush() []map[string]interface{} {
+	if p.entry == nil || (len(p.query) == 0 && !p.hasHeader) {
+		return nil
+	}
+	e := p.entry
+	query := strings.TrimSpace(strings.Join(p.query, "\n"))
+	e["query"] = query
+	e["normalized_query"] = NormalizeQuery(query)
+	switch qt := e["query_time"].(type) {
+	case float64:
+		e["duration_ms"] = qt * 1000
+	case int64:
+		e["duration_ms"] = float64(qt * 1000)
+	}
+	p.entry = nil
+	p.query = nil
+	p.hasHeader = false
+	return []map[string]interface{}{e}
+}
+
+func parseMySQLTime(s string) string {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC3339Nano, "060102 15:04:05", "060102  15:04:05"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Local().Format(time.RFC3339)
+		}
+	}
+	return s
+}
@@ -0,0 +1,205 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package format
+
+import (
+	"encoding/csv"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	postgresLineReg = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}(?:\.\d+)?(?: [A-Za-z]{2,5}| ?[+-]\d{2}(?::?\d{2})?)?)\s+\[(\d+)\](?:-\d+)?:?\s+(?:(\S*)@(\S*)\s+)?` +
+		`(DEBUG[1-5]?|INFO|NOTICE|WARNING|ERROR|LOG|FATAL|PANIC|DETAIL|HINT|CONTEXT|STATEMENT|QUERY|LOCATION):\s+(.*)$`)
+	postgresDurationReg  = regexp.MustCompile(`(?s)^duration: ([\d.]+) ms(?:\s+(?:statement|(?:execute|parse|bind) [^:]*):\s+(.*))?$`)
+	postgresStatementReg = regexp.MustCompile(`(?s)^(?:statement|(?:execute|parse|bind) [^:]*):\s+(.*)$`)
+	postgresTimeLayouts  = []string{
+		"2006-01-02 15:04:05.999999999 MST",
+		"2006-01-02 15:04:05.999999999 -07",
+		"2006-01-02 15:04:05.999999999 -0700",
+		"2006-01-02 15:04:05.999999999-07",
+		"2006-01-02 15:04:05.999999999",
+	}
+)
+
+// postgresCSVColumns lists the csvlog columns in order; older versions simply
+// have fewer of them.
+var postgresCSVColumns = []string{
+	"timestamp", "user", "database", "pid", "client", "session_id", "session_line",
+	"command_tag", "session_start", "virtual_transaction_id", "transaction_id",
+	"severity", "sql_state", "message", "detail", "hint", "internal_query",
+	"internal_query_pos", "context", "query", "query_pos", "location",
+	"application_name", "backend_type", "leader_pid", "query_id",
+}
+
+// postgresParser parses the Postgres stderr log with the default
+// log_line_prefix ('%m [%p] '), optionally followed by '%u@%d ', e.g.:
+//
+//	2024-01-30 15:00:00.123 UTC [1234] app@shop LOG:  duration: 2001.123 ms  statement: SELECT *
+//		FROM orders WHERE id = 5;
+//
+// DETAIL, HINT, STATEMENT (etc.) lines are attached to the entry they follow.
+type postgresParser struct {
+	entry   map[string]interface{}
+	lastKey string
+}
+
+func (p *postgresParser) Feed(line string) []map[string]interface{} {
+	line = strings.TrimRight(line, "\r\n")
+	m := postgresLineReg.FindStringSubmatch(line)
+	if m == nil {
+		if p.entry != nil && len(strings.TrimSpace(line)) > 0 {
+			p.entry[p.lastKey] = p.entry[p.lastKey].(string) + "\n" + line
+		}
+		return nil
+	}
+
+	level, msg := m[5], m[6]
+	pid := parseNumber(m[2])
+	switch level {
+	case "DETAIL", "HINT", "CONTEXT", "STATEMENT", "QUERY", "LOCATION":
+		if p.entry != nil && p.entry["pid"] == pid {
+			p.lastKey = strings.ToLower(level)
+			p.entry[p.lastKey] = msg
+			return nil
+		}
+	}
+
+	out := p.Flush()
+	p.entry = map[string]interface{}{
+		"timestamp": parsePostgresTime(m[1]),
+		"pid":       pid,
+		"severity":  level,
+		"message":   msg,
+	}
+	if len(m[3]) > 0 {
+		p.entry["user"] = m[3]
+	}
+	if len(m[4]) > 0 {
+		p.entry["database"] = m[4]
+	}
+	p.lastKey = "message"
+	return out
+}
+
+func (p *postgresParser) Flush() []map[string]interface{} {
+	if p.entry == nil {
+		return nil
+	}
+	e := p.entry
+	p.entry = nil
+	if stmt, ok := e["statement"].(string); ok {
+		e["query"] = stmt
+		delete(e, "statement")
+	}
+	return []map[string]interface{}{finishPostgresEntry(e)}
+}
+
+// postgresCSVParser parses the Postgres csvlog format. Quoted fields may span
+// several lines, so lines are buffered until the quotes are balanced.
+type postgresCSVParser struct {
+	buf    strings.Builder
+	quotes int
+}
+
+func (p *postgresCSVParser) Feed(line string) []map[string]interface{} {
+	line = strings.TrimRight(line, "\r\n")
+	if p.buf.Len() == 0 && len(strings.TrimSpace(line)) == 0 {
+		return nil
+	}
+	if p.buf.Len() > 0 {
+		p.buf.WriteString("\n")
+	}
+	p.buf.WriteString(line)
+	p.quotes += strings.Count(line, `"`)
+	if p.quotes%2 != 0 {
+		return nil
+	}
+	return p.Flush()
+}
+
+func (p *postgresCSVParser) Flush() []map[string]interface{} {
+	if p.buf.Len() == 0 {
+		return nil
+	}
+	record := p.buf.String()
+	p.buf.Reset()
+	p.quotes = 0
+
+	r := csv.NewReader(strings.NewReader(record))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	fields, err := r.Read()
+	if err != nil {
+		return []map[string]interface{}{{"message": record, "parse_error": err.Error()}}
+	}
+	e := make(map[string]interface{})
+	for i, v := range fields {
+		if i >= len(postgresCSVColumns) || len(v) == 0 {
+			continue
+		}
+		e[postgresCSVColumns[i]] = v
+	}
+	if ts, ok := e["timestamp"].(string); ok {
+		e["timestamp"] = parsePostgresTime(ts)
+	}
+	for _, k := range []string{"pid", "session_line", "leader_pid"} {
+		if v, ok := e[k].(string); ok {
+			e[k] = parseNumber(v)
+		}
+	}
+	return []map[string]interface{}{finishPostgresEntry(e)}
+}
+
+// finishPostgresEntry extracts the duration and statement logged by
+// log_min_duration_statement/log_statement and normalizes the query.
+func finishPostgresEntry(e map[string]interface{}) map[string]interface{} {
+	msg, _ := e["message"].(string)
+	if m := postgresDurationReg.FindStringSubmatch(msg); m != nil {
+		if d, err := strconv.ParseFloat(m[1], 64); err == nil {
+			e["duration_ms"] = d
+		}
+		if len(m[2]) > 0 {
+			e["query"] = m[2]
+		}
+	} else if m := postgresStatementReg.FindStringSubmatch(msg); m != nil {
+		e["query"] = m[1]
+	}
+	if q, ok := e["query"].(string); ok {
+		q = strings.TrimSpace(q)
+		e["query"] = q
+		e["normalized_query"] = NormalizeQuery(q)
+	}
+	return e
+}
+
+func parsePostgresTime(s string) string {
+	for _, layout := range postgresTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Local().Format(time.RFC3339)
+		}
+	}
+	return s
+}
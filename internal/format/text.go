@@ -0,0 +1,52 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package format
+
+import (
+	"strings"
+
+	"github.com/badaniya/loggo/internal/config"
+)
+
+// ParseText parses log lines held in memory, e.g. pasted off a ticket, into
+// entries. Lines are fed to the parser if any, otherwise each is parsed as
+// streamed lines are, see config.ParseLine. Blank lines are skipped.
+func ParseText(text string, parser Parser) []map[string]interface{} {
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		if parser != nil {
+			entries = append(entries, parser.Feed(line)...)
+			continue
+		}
+		m, _ := config.ParseLine(line)
+		entries = append(entries, m)
+	}
+	if parser != nil {
+		entries = append(entries, parser.Flush()...)
+	}
+	return entries
+}
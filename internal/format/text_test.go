@@ -0,0 +1,80 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package format
+
+import (
+	"testing"
+
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseText(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		parser   Parser
+		messages []interface{}
+		parseErr []bool
+	}{
+		{
+			name:     "JSON lines",
+			text:     "{\"message\":\"a\"}\r\n\n  \n{\"message\":\"b\"}\n",
+			messages: []interface{}{"a", "b"},
+			parseErr: []bool{false, false},
+		},
+		{
+			name:     "plain text and malformed lines",
+			text:     "{\"message\":\"a\"}\nsomething went wrong\n{\"message\":\n",
+			messages: []interface{}{"a", "something went wrong", "{\"message\":"},
+			parseErr: []bool{false, false, true},
+		},
+		{
+			name: "parsed format",
+			text: "2024-01-30 15:00:01.000 UTC [1235] ERROR:  relation \"x\" does not exist\n" +
+				"2024-01-30 15:00:01.000 UTC [1235] STATEMENT:  select * from x;\n" +
+				"2024-01-30 15:00:02.000 UTC [1236] LOG:  checkpoint starting: time",
+			parser:   &postgresParser{},
+			messages: []interface{}{"relation \"x\" does not exist", "checkpoint starting: time"},
+			parseErr: []bool{false, false},
+		},
+		{
+			name: "nothing but blanks",
+			text: "\n \n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entries := ParseText(test.text, test.parser)
+			var messages []interface{}
+			var parseErr []bool
+			for _, e := range entries {
+				messages = append(messages, e[config.TextPayload])
+				_, ok := e[config.ParseErr]
+				parseErr = append(parseErr, ok)
+			}
+			assert.Equal(t, test.messages, messages)
+			assert.Equal(t, test.parseErr, parseErr)
+		})
+	}
+}
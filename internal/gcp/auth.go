@@ -34,6 +34,10 @@ import (
 
 var IsGCloud = false
 
+// NoBrowser logs in printing a device code to enter from any browser, rather
+// than opening one on this machine.
+var NoBrowser = false
+
 type Auth struct {
 	ClientId     string `json:"client_id"`
 	ClientSecret string `json:"client_secret"`
@@ -43,12 +47,45 @@ type Auth struct {
 
 func LoggingClient(ctx context.Context) (*logging.Client, error) {
 	if !IsGCloud {
-		return logging.NewClient(ctx, option.WithCredentialsFile(authFile()))
+		a, err := LoadAuth()
+		if err != nil {
+			return nil, err
+		}
+		return logging.NewClient(ctx, option.WithTokenSource(a.TokenSource()))
 	} else {
 		return logging.NewClient(ctx)
 	}
 }
 
+// LoadAuth reads the credentials saved by the last login.
+func LoadAuth() (*Auth, error) {
+	b, err := os.ReadFile(authFile())
+	if err != nil {
+		return nil, err
+	}
+	a := &Auth{}
+	if err := json.Unmarshal(b, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Renew refreshes the access token of the saved credentials, returning
+// ErrReauth if logging in again is required. The gcloud credentials are
+// renewed by the client libraries themselves.
+func Renew(ctx context.Context) error {
+	if IsGCloud {
+		return nil
+	}
+	a, err := LoadAuth()
+	if err != nil {
+		return ErrReauth
+	}
+	ExpireToken()
+	_, err = a.Refresh(ctx)
+	return err
+}
+
 func authDir() string {
 	hd, _ := os.UserHomeDir()
 	dir := path.Join(hd, ".loggo", "auth")
@@ -61,6 +98,7 @@ func authFile() string {
 
 func Delete() {
 	_ = os.Remove(authFile())
+	ExpireToken()
 }
 
 func (a *Auth) Save() error {
@@ -72,12 +110,6 @@ func (a *Auth) Save() error {
 		return err
 	}
 
-	file, err := os.Create(authFile())
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	_, err = file.Write(b)
-	return err
+	ExpireToken()
+	return os.WriteFile(authFile(), b, 0600)
 }
@@ -0,0 +1,149 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/badaniya/loggo/internal/util"
+	"golang.org/x/oauth2"
+)
+
+const DeviceCodeEndpoint = "https://oauth2.googleapis.com/device/code"
+
+// deviceCode is the device authorization reply, telling which code the user
+// must enter at which address.
+type deviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationUrl string `json:"verification_url"`
+	ExpiresIn       int64  `json:"expires_in"`
+	Interval        int64  `json:"interval"`
+	Error           string `json:"error"`
+}
+
+// DeviceAuth logs in without a local browser: it prints an address and a code
+// to enter there from any device, then waits for the user to grant access.
+func DeviceAuth() error {
+	return deviceAuth(context.Background(), DefaultCredentialsDefaultClientId,
+		DefaultCredentialsDefaultClientSecret, PrintDeviceCode)
+}
+
+// PrintDeviceCode prompts the user to enter the code at the address.
+func PrintDeviceCode(verificationUrl, userCode string) {
+	fmt.Fprintf(os.Stderr, "To authenticate with GCP, visit %s from any browser and enter the code:\n\n    %s\n\n",
+		verificationUrl, userCode)
+}
+
+func deviceAuth(ctx context.Context, clientId, clientSecret string, prompt func(verificationUrl, userCode string)) error {
+	data := url.Values{}
+	data.Set("client_id", clientId)
+	data.Set("scope", strings.Join([]string{
+		"openid",
+		"https://www.googleapis.com/auth/userinfo.email",
+		"https://www.googleapis.com/auth/cloud-platform",
+	}, " "))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, DeviceCodeEndpoint, strings.NewReader(data.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	response, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	b, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	dc := &deviceCode{}
+	if err := json.Unmarshal(b, dc); err != nil {
+		return fmt.Errorf("unexpected device code response (%s): %w", response.Status, err)
+	}
+	if len(dc.Error) > 0 || len(dc.DeviceCode) == 0 {
+		return fmt.Errorf("device code request failed (%s): %s", response.Status, dc.Error)
+	}
+	util.Log().WithField("code", dc.VerificationUrl).Info("Awaiting device authorization.")
+	prompt(dc.VerificationUrl, dc.UserCode)
+
+	a, t, err := pollDeviceToken(ctx, dc, clientId, clientSecret)
+	if err != nil {
+		return err
+	}
+	if err := a.Save(); err != nil {
+		return err
+	}
+	return saveToken(t)
+}
+
+// pollDeviceToken polls the token endpoint at the requested interval until the
+// user grants or denies access, or the device code expires.
+func pollDeviceToken(ctx context.Context, dc *deviceCode, clientId, clientSecret string) (*Auth, *oauth2.Token, error) {
+	interval := time.Duration(dc.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	deadline := time.Now().Add(time.Duration(dc.ExpiresIn) * time.Second)
+	data := url.Values{}
+	data.Set("client_id", clientId)
+	data.Set("client_secret", clientSecret)
+	data.Set("device_code", dc.DeviceCode)
+	data.Set("grant_type", "urn:ietf:params:oauth:grant-type:device_code")
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(interval):
+		}
+		r, err := requestToken(ctx, data)
+		if err != nil {
+			return nil, nil, err
+		}
+		switch err := r.err(); {
+		case errors.Is(err, errAuthorizationPending):
+			continue
+		case errors.Is(err, errSlowDown):
+			interval += 5 * time.Second
+			continue
+		case err != nil:
+			return nil, nil, err
+		}
+		return &Auth{
+			ClientId:     clientId,
+			ClientSecret: clientSecret,
+			RefreshToken: r.RefreshToken,
+			Type:         "authorized_user",
+		}, r.token(time.Now()), nil
+	}
+	return nil, nil, fmt.Errorf("device code expired before access was granted")
+}
@@ -0,0 +1,392 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package gcp
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// knownFields are the top level LogEntry fields a filter may compare.
+var knownFields = []string{
+	"logName", "resource", "timestamp", "receiveTimestamp", "severity", "insertId",
+	"httpRequest", "labels", "operation", "trace", "spanId", "traceSampled",
+	"sourceLocation", "split", "textPayload", "jsonPayload", "protoPayload", "errorGroups",
+}
+
+// FilterError reports a malformed Cloud Logging filter and where it fails.
+type FilterError struct {
+	Filter string
+	// Pos is the byte offset of the offending token in Filter.
+	Pos int
+	Msg string
+}
+
+func (e *FilterError) Error() string {
+	line, col := e.Filter, e.Pos
+	if i := strings.LastIndexByte(e.Filter[:e.Pos], '\n'); i >= 0 {
+		line, col = e.Filter[i+1:], e.Pos-i-1
+	}
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	return fmt.Sprintf("invalid filter, %s at position %d:\n  %s\n  %s^", e.Msg, e.Pos+1, line, strings.Repeat(" ", col))
+}
+
+// ValidateFilter checks the syntax of a Cloud Logging filter before it's
+// sent, as well as the fields it compares, suggesting the closest known field
+// for typos. Malformed filters yield a *FilterError.
+func ValidateFilter(filter string) error {
+	toks, err := tokenizeFilter(filter)
+	if err != nil {
+		return err
+	}
+	p := &filterParser{filter: filter, toks: toks}
+	if err := p.sequence(false); err != nil {
+		return err
+	}
+	if p.pos < len(p.toks) {
+		return p.errorf(p.peek(), "unbalanced parenthesis")
+	}
+	return nil
+}
+
+var serverPosition = regexp.MustCompile(`line (\d+), column (\d+)`)
+
+// DescribeFilterError relates a server rejection of the filter to the
+// offending position when the server tells it, e.g. "Unparseable filter:
+// syntax error at line 1, column 23". Other errors are returned as is.
+func DescribeFilterError(filter string, err error) error {
+	if err == nil {
+		return nil
+	}
+	m := serverPosition.FindStringSubmatch(err.Error())
+	if m == nil {
+		return err
+	}
+	line, _ := strconv.Atoi(m[1])
+	col, _ := strconv.Atoi(m[2])
+	pos := 0
+	for i := 1; i < line; i++ {
+		next := strings.IndexByte(filter[pos:], '\n')
+		if next < 0 {
+			return err
+		}
+		pos += next + 1
+	}
+	pos += col - 1
+	if pos < 0 || pos > len(filter) {
+		return err
+	}
+	return &FilterError{Filter: filter, Pos: pos, Msg: "rejected by GCP (" + err.Error() + ")"}
+}
+
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota
+	tokString
+	tokOperator
+	tokOpen
+	tokClose
+	tokComma
+	tokNot
+	tokAnd
+	tokOr
+)
+
+type filterToken struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func tokenizeFilter(filter string) ([]filterToken, error) {
+	var toks []filterToken
+	for i := 0; i < len(filter); {
+		c := filter[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			toks = append(toks, filterToken{tokOpen, "(", i})
+			i++
+		case c == ')':
+			toks = append(toks, filterToken{tokClose, ")", i})
+			i++
+		case c == ',':
+			toks = append(toks, filterToken{tokComma, ",", i})
+			i++
+		case c == '"':
+			end, err := stringEnd(filter, i)
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, filterToken{tokString, filter[i:end], i})
+			i = end
+		case strings.IndexByte("=!<>:", c) >= 0:
+			op := string(c)
+			if i+1 < len(filter) {
+				switch filter[i : i+2] {
+				case "!=", "<=", ">=", "=~", "!~":
+					op = filter[i : i+2]
+				}
+			}
+			if op == "!" {
+				return nil, &FilterError{Filter: filter, Pos: i, Msg: `unexpected "!"`}
+			}
+			toks = append(toks, filterToken{tokOperator, op, i})
+			i += len(op)
+		case c == '-' && i+1 < len(filter) && !isDigit(filter[i+1]) &&
+			(len(toks) == 0 || toks[len(toks)-1].kind != tokOperator):
+			toks = append(toks, filterToken{tokNot, "-", i})
+			i++
+		default:
+			start := i
+			for i < len(filter) && !isWordEnd(filter[i]) {
+				// quoted path segments, e.g. labels."k8s-pod/app"
+				if filter[i] == '.' && i+1 < len(filter) && filter[i+1] == '"' {
+					end, err := stringEnd(filter, i+1)
+					if err != nil {
+						return nil, err
+					}
+					i = end
+					continue
+				}
+				i++
+			}
+			word := filter[start:i]
+			kind := tokWord
+			switch word {
+			case "AND":
+				kind = tokAnd
+			case "OR":
+				kind = tokOr
+			case "NOT":
+				kind = tokNot
+			}
+			toks = append(toks, filterToken{kind, word, start})
+		}
+	}
+	return toks, nil
+}
+
+func stringEnd(filter string, start int) (int, error) {
+	for i := start + 1; i < len(filter); i++ {
+		switch filter[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		}
+	}
+	return 0, &FilterError{Filter: filter, Pos: start, Msg: "unterminated string"}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isWordEnd(c byte) bool {
+	return strings.IndexByte(" \t\n\r()\",=!<>:", c) >= 0
+}
+
+type filterParser struct {
+	filter string
+	toks   []filterToken
+	pos    int
+}
+
+func (p *filterParser) peek() *filterToken {
+	if p.pos < len(p.toks) {
+		return &p.toks[p.pos]
+	}
+	return nil
+}
+
+func (p *filterParser) errorf(t *filterToken, format string, args ...interface{}) error {
+	pos := len(p.filter)
+	if t != nil {
+		pos = t.pos
+	}
+	return &FilterError{Filter: p.filter, Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// sequence parses terms joined by AND, OR or juxtaposition (implicit AND),
+// up to the closing parenthesis when nested. Values only sequences are the
+// right hand side of comparisons, e.g. severity=(ERROR OR WARNING).
+func (p *filterParser) sequence(values bool) error {
+	expectTerm := true
+	for t := p.peek(); t != nil && t.kind != tokClose; t = p.peek() {
+		switch t.kind {
+		case tokAnd, tokOr:
+			if expectTerm {
+				return p.errorf(t, "%s lacks its left operand", t.text)
+			}
+			p.pos++
+			expectTerm = true
+			continue
+		case tokComma:
+			return p.errorf(t, `unexpected ","`)
+		}
+		if err := p.term(values); err != nil {
+			return err
+		}
+		expectTerm = false
+	}
+	if expectTerm && p.pos > 0 {
+		if prev := &p.toks[p.pos-1]; prev.kind == tokAnd || prev.kind == tokOr {
+			return p.errorf(p.peek(), "%s lacks its right operand", prev.text)
+		}
+	}
+	return nil
+}
+
+func (p *filterParser) term(values bool) error {
+	t := p.peek()
+	if t.kind == tokNot {
+		p.pos++
+		if n := p.peek(); n == nil || n.kind == tokClose || n.kind == tokAnd || n.kind == tokOr {
+			return p.errorf(n, "%s lacks its operand", t.text)
+		}
+		return p.term(values)
+	}
+	switch t.kind {
+	case tokOpen:
+		p.pos++
+		if err := p.sequence(values); err != nil {
+			return err
+		}
+		if c := p.peek(); c == nil || c.kind != tokClose {
+			return p.errorf(t, "unbalanced parenthesis")
+		}
+		p.pos++
+		return nil
+	case tokOperator:
+		return p.errorf(t, "%q lacks the field to compare", t.text)
+	case tokString:
+		p.pos++
+		return nil
+	}
+	p.pos++
+	next := p.peek()
+	switch {
+	case next != nil && next.kind == tokOpen && next.pos == t.pos+len(t.text):
+		return p.call()
+	case next != nil && next.kind == tokOperator && !values:
+		return p.comparison(t)
+	case t.text == "and" || t.text == "or":
+		return p.errorf(t, "lowercase %q searches for the word, did you mean %q?", t.text, strings.ToUpper(t.text))
+	}
+	return nil
+}
+
+// call parses the arguments of a function, e.g. log_id("stdout") or
+// sample(insertId, 0.1).
+func (p *filterParser) call() error {
+	open := p.peek()
+	p.pos++
+	for t := p.peek(); t != nil && t.kind != tokClose; t = p.peek() {
+		if t.kind == tokOpen {
+			return p.errorf(t, `unexpected "("`)
+		}
+		p.pos++
+	}
+	if p.peek() == nil {
+		return p.errorf(open, "unbalanced parenthesis")
+	}
+	p.pos++
+	return nil
+}
+
+func (p *filterParser) comparison(field *filterToken) error {
+	if err := p.checkField(field); err != nil {
+		return err
+	}
+	op := p.peek()
+	p.pos++
+	v := p.peek()
+	if v == nil || v.kind == tokClose || v.kind == tokAnd || v.kind == tokOr || v.kind == tokOperator || v.kind == tokComma {
+		return p.errorf(v, "%s%s lacks the value to compare", field.text, op.text)
+	}
+	if v.kind == tokOpen {
+		p.pos++
+		if err := p.sequence(true); err != nil {
+			return err
+		}
+		if c := p.peek(); c == nil || c.kind != tokClose {
+			return p.errorf(v, "unbalanced parenthesis")
+		}
+	}
+	p.pos++
+	return nil
+}
+
+func (p *filterParser) checkField(field *filterToken) error {
+	name := field.text
+	if i := strings.IndexAny(name, ".["); i >= 0 {
+		name = name[:i]
+	}
+	norm := normaliseField(name)
+	best, bestDist := "", len(norm)
+	for _, k := range knownFields {
+		d := editDistance(norm, normaliseField(k))
+		if d == 0 {
+			return nil
+		}
+		if d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	if len(best) > 0 && bestDist <= 3 {
+		return p.errorf(field, "unknown field %q, did you mean %q?", name, best)
+	}
+	return p.errorf(field, "unknown field %q", name)
+}
+
+// normaliseField folds camelCase and snake_case names, which are both accepted.
+func normaliseField(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,88 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package gcp
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  string
+		wantPos int
+		wantMsg string
+	}{
+		{name: "empty", filter: ``},
+		{name: "comparisons", filter: `resource.labels.namespace_name="awesome-sit" AND resource.labels.container_name="some"`},
+		{name: "snake case", filter: `json_payload.message:"timeout" OR text_payload:"timeout"`},
+		{name: "nested and negated", filter: `severity>=ERROR -(labels."k8s-pod/app"="api" OR NOT trace:*)`},
+		{name: "values sequence", filter: `severity=(ERROR OR WARNING) timestamp>="2024-01-01T00:00:00Z"`},
+		{name: "functions and search", filter: `log_id("stdout") AND sample(insertId, 0.1) "unicorn"`},
+		{name: "unterminated string", filter: `severity="ERROR`, wantPos: 9, wantMsg: "unterminated string"},
+		{name: "missing value", filter: `severity= AND trace:*`, wantPos: 10, wantMsg: "severity= lacks the value to compare"},
+		{name: "missing field", filter: `="ERROR"`, wantPos: 0, wantMsg: `"=" lacks the field to compare`},
+		{name: "dangling AND", filter: `severity=ERROR AND`, wantPos: 18, wantMsg: "AND lacks its right operand"},
+		{name: "leading OR", filter: `OR severity=ERROR`, wantPos: 0, wantMsg: "OR lacks its left operand"},
+		{name: "missing parenthesis", filter: `(severity=ERROR OR trace:*`, wantPos: 0, wantMsg: "unbalanced parenthesis"},
+		{name: "extra parenthesis", filter: `severity=ERROR)`, wantPos: 14, wantMsg: "unbalanced parenthesis"},
+		{name: "lowercase and", filter: `severity=ERROR and trace:*`, wantPos: 15, wantMsg: `lowercase "and" searches for the word, did you mean "AND"?`},
+		{name: "typo", filter: `severty=ERROR`, wantPos: 0, wantMsg: `unknown field "severty", did you mean "severity"?`},
+		{name: "unknown", filter: `resource.type="k8s_container" AND foo.bar="x"`, wantPos: 34, wantMsg: `unknown field "foo"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateFilter(tt.filter)
+			if len(tt.wantMsg) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			var fe *FilterError
+			assert.True(t, errors.As(err, &fe))
+			assert.Equal(t, tt.wantMsg, fe.Msg)
+			assert.Equal(t, tt.wantPos, fe.Pos)
+		})
+	}
+}
+
+func TestFilterError_Error(t *testing.T) {
+	err := &FilterError{Filter: `severty=ERROR`, Pos: 0, Msg: `unknown field "severty"`}
+	assert.Equal(t, "invalid filter, unknown field \"severty\" at position 1:\n  severty=ERROR\n  ^", err.Error())
+}
+
+func TestDescribeFilterError(t *testing.T) {
+	filter := `timestamp > "2024-01-01T00:00:00Z" AND (severity=)`
+	err := DescribeFilterError(filter, errors.New("rpc error: code = InvalidArgument desc = "+
+		"Unparseable filter: syntax error at line 1, column 50, token ')'"))
+	var fe *FilterError
+	assert.True(t, errors.As(err, &fe))
+	assert.Equal(t, 49, fe.Pos)
+	assert.Contains(t, err.Error(), "rejected by GCP")
+
+	other := errors.New("permission denied")
+	assert.Equal(t, other, DescribeFilterError(filter, other))
+	assert.Nil(t, DescribeFilterError(filter, nil))
+}
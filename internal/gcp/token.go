@@ -0,0 +1,232 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/badaniya/loggo/internal/util"
+	"golang.org/x/oauth2"
+)
+
+const TokenEndpoint = "https://oauth2.googleapis.com/token"
+
+// tokenExpiryMargin is how long before its expiry a cached access token is
+// already considered stale, so that it doesn't expire mid request.
+const tokenExpiryMargin = time.Minute
+
+// ErrReauth is returned when the refresh token was revoked or expired, and
+// only logging in again can renew the credentials.
+var ErrReauth = errors.New("GCP credentials revoked or expired, please authenticate again")
+
+// authError matches the errors of requests rejected for their credentials,
+// as gRPC, REST and the oauth2 library word them.
+var authError = regexp.MustCompile(`Unauthenticated|UNAUTHENTICATED|Error 401|invalid_grant|oauth2: token expired`)
+
+// IsAuthError tells whether err is due to expired or revoked credentials.
+func IsAuthError(err error) bool {
+	if err == nil {
+		return false
+	}
+	return errors.Is(err, ErrReauth) || authError.MatchString(err.Error())
+}
+
+// tokenResponse is the token endpoint reply, either to an authorization code,
+// a refresh token or a device code grant.
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+var (
+	errAuthorizationPending = errors.New("authorization pending")
+	errSlowDown             = errors.New("slow down")
+)
+
+// err maps the OAuth error codes of the reply, if any.
+func (r *tokenResponse) err() error {
+	switch r.Error {
+	case "":
+		if len(r.AccessToken) == 0 {
+			return fmt.Errorf("token response without access token")
+		}
+		return nil
+	case "authorization_pending":
+		return errAuthorizationPending
+	case "slow_down":
+		return errSlowDown
+	case "invalid_grant", "expired_token":
+		return ErrReauth
+	}
+	if len(r.ErrorDescription) > 0 {
+		return fmt.Errorf("%s: %s", r.Error, r.ErrorDescription)
+	}
+	return errors.New(r.Error)
+}
+
+func (r *tokenResponse) token(now time.Time) *oauth2.Token {
+	t := &oauth2.Token{
+		AccessToken:  r.AccessToken,
+		TokenType:    r.TokenType,
+		RefreshToken: r.RefreshToken,
+	}
+	if r.ExpiresIn > 0 {
+		t.Expiry = now.Add(time.Duration(r.ExpiresIn) * time.Second)
+	}
+	return t
+}
+
+// requestToken posts the form to the token endpoint.
+func requestToken(ctx context.Context, data url.Values) (*tokenResponse, error) {
+	encodedData := data.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, TokenEndpoint, strings.NewReader(encodedData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	response, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	b, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	r := &tokenResponse{}
+	if err := json.Unmarshal(b, r); err != nil {
+		return nil, fmt.Errorf("unexpected token response (%s): %w", response.Status, err)
+	}
+	return r, nil
+}
+
+func tokenFile() string {
+	return path.Join(authDir(), "gcp-token.json")
+}
+
+// cachedToken returns the access token saved by a previous run, provided it's
+// still valid for a while.
+func cachedToken() *oauth2.Token {
+	b, err := os.ReadFile(tokenFile())
+	if err != nil {
+		return nil
+	}
+	t := &oauth2.Token{}
+	if err := json.Unmarshal(b, t); err != nil || len(t.AccessToken) == 0 {
+		return nil
+	}
+	if !t.Expiry.IsZero() && time.Now().Add(tokenExpiryMargin).After(t.Expiry) {
+		return nil
+	}
+	return t
+}
+
+// saveToken caches the access token for the next runs, unless in read-only
+// mode, the token then being reused by this run only.
+func saveToken(t *oauth2.Token) error {
+	if util.ReadOnly() {
+		return nil
+	}
+	if err := os.MkdirAll(authDir(), os.ModePerm); err != nil {
+		return err
+	}
+	// the refresh token lives in the auth file already
+	b, err := json.MarshalIndent(&oauth2.Token{
+		AccessToken: t.AccessToken,
+		TokenType:   t.TokenType,
+		Expiry:      t.Expiry,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(tokenFile(), b, 0600)
+}
+
+// ExpireToken drops the cached access token, so that the next request
+// refreshes it.
+func ExpireToken() {
+	_ = os.Remove(tokenFile())
+}
+
+// authTokenSource hands out the cached access token, refreshing and caching
+// it again once expired.
+type authTokenSource struct {
+	auth *Auth
+	mu   sync.Mutex
+}
+
+func (s *authTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t := cachedToken(); t != nil {
+		return t, nil
+	}
+	return s.auth.Refresh(context.Background())
+}
+
+// TokenSource returns the access tokens of the saved credentials, reusing
+// them across runs until they expire.
+func (a *Auth) TokenSource() oauth2.TokenSource {
+	return oauth2.ReuseTokenSource(cachedToken(), &authTokenSource{auth: a})
+}
+
+// Refresh exchanges the refresh token for a new access token and caches it.
+// It returns ErrReauth if the refresh token is no longer valid.
+func (a *Auth) Refresh(ctx context.Context) (*oauth2.Token, error) {
+	if len(a.RefreshToken) == 0 {
+		return nil, ErrReauth
+	}
+	data := url.Values{}
+	data.Set("client_id", a.ClientId)
+	data.Set("client_secret", a.ClientSecret)
+	data.Set("refresh_token", a.RefreshToken)
+	data.Set("grant_type", "refresh_token")
+	r, err := requestToken(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.err(); err != nil {
+		return nil, err
+	}
+	t := r.token(time.Now())
+	if err := saveToken(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
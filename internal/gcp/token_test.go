@@ -0,0 +1,134 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package gcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/badaniya/loggo/internal/util"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+func TestIsAuthError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil"},
+		{name: "grpc", err: errors.New("rpc error: code = Unauthenticated desc = Request had invalid authentication credentials."), want: true},
+		{name: "rest", err: errors.New("googleapi: Error 401: Request had invalid authentication credentials."), want: true},
+		{name: "revoked", err: errors.New(`oauth2: "invalid_grant" "Token has been expired or revoked."`), want: true},
+		{name: "reauth", err: fmt.Errorf("stream: %w", ErrReauth), want: true},
+		{name: "quota", err: errors.New("rpc error: code = ResourceExhausted desc = Quota exceeded")},
+		{name: "filter", err: errors.New("rpc error: code = InvalidArgument desc = Unparseable filter")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, IsAuthError(test.err))
+		})
+	}
+}
+
+func TestTokenResponse(t *testing.T) {
+	now := time.Date(2022, 7, 30, 15, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		body       string
+		wantErr    error
+		wantMsg    string
+		wantExpiry time.Time
+	}{
+		{
+			name:       "granted",
+			body:       `{"access_token":"ya29.abc","expires_in":3599,"refresh_token":"1//xyz","token_type":"Bearer"}`,
+			wantExpiry: now.Add(3599 * time.Second),
+		},
+		{
+			name: "no expiry",
+			body: `{"access_token":"ya29.abc","token_type":"Bearer"}`,
+		},
+		{
+			name:    "pending",
+			body:    `{"error":"authorization_pending","error_description":"Precondition Required"}`,
+			wantErr: errAuthorizationPending,
+		},
+		{
+			name:    "slow down",
+			body:    `{"error":"slow_down"}`,
+			wantErr: errSlowDown,
+		},
+		{
+			name:    "revoked",
+			body:    `{"error":"invalid_grant","error_description":"Token has been expired or revoked."}`,
+			wantErr: ErrReauth,
+		},
+		{
+			name:    "denied",
+			body:    `{"error":"access_denied","error_description":"Forbidden"}`,
+			wantMsg: "access_denied: Forbidden",
+		},
+		{
+			name:    "empty",
+			body:    `{}`,
+			wantMsg: "token response without access token",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &tokenResponse{}
+			assert.NoError(t, json.Unmarshal([]byte(test.body), r))
+			err := r.err()
+			switch {
+			case test.wantErr != nil:
+				assert.ErrorIs(t, err, test.wantErr)
+			case len(test.wantMsg) > 0:
+				assert.EqualError(t, err, test.wantMsg)
+			default:
+				assert.NoError(t, err)
+				tok := r.token(now)
+				assert.Equal(t, "ya29.abc", tok.AccessToken)
+				assert.Equal(t, test.wantExpiry, tok.Expiry)
+			}
+		})
+	}
+}
+
+func TestSaveToken_ReadOnly(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	util.SetReadOnly(true)
+	defer util.SetReadOnly(false)
+	assert.NoError(t, saveToken(&oauth2.Token{AccessToken: "ya29.abc", Expiry: time.Now().Add(time.Hour)}))
+	_, err := os.Stat(tokenFile())
+	assert.True(t, os.IsNotExist(err))
+
+	util.SetReadOnly(false)
+	assert.NoError(t, saveToken(&oauth2.Token{AccessToken: "ya29.abc", Expiry: time.Now().Add(time.Hour)}))
+	assert.Equal(t, "ya29.abc", cachedToken().AccessToken)
+}
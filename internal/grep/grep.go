@@ -0,0 +1,122 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package grep
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/enrich"
+	"github.com/badaniya/loggo/internal/filter"
+)
+
+// Options drive which entries Run selects and how they're written.
+type Options struct {
+	// Expression selects the entries, all of them if nil.
+	Expression *filter.Expression
+	// Config is the template rendering the selected entries, one tab
+	// separated column per key. Entries are written raw if it has no keys.
+	Config *config.Config
+	// Raw writes the selected lines as they were read.
+	Raw bool
+	// Invert selects the entries not satisfying the expression.
+	Invert bool
+	// Count only tells the number of selected entries.
+	Count bool
+}
+
+// Run applies the filter expression to every line read, as l'oGGo does
+// interactively, writing the selected ones. Lines which aren't JSON are
+// matched as text payloads. It returns the number of selected entries.
+func Run(in io.Reader, out io.Writer, opts Options) (int, error) {
+	cfg := opts.Config
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	severities, err := config.MakeSeverityMapper(cfg.Severity)
+	if err != nil {
+		return 0, err
+	}
+	extractor, err := config.MakeExtractor(cfg.Extract)
+	if err != nil {
+		return 0, err
+	}
+	enricher, err := enrich.MakeEnricher(cfg.Enrich)
+	if err != nil {
+		return 0, err
+	}
+	keyMap := cfg.KeyMap()
+	raw := opts.Raw || len(cfg.Keys) == 0
+	br := bufio.NewReader(in)
+	count := 0
+	for {
+		line, rerr := br.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if len(line) > 0 {
+			m, _ := config.ParseLine(line)
+			config.Flatten(m, cfg.FlattenDepth())
+			if extractor != nil {
+				extractor.Apply(m)
+			}
+			if enricher != nil {
+				enricher.Apply(m)
+			}
+			if !config.IsText(m) && severities != nil {
+				severities.Apply(m)
+			}
+			selected := true
+			if opts.Expression != nil {
+				if selected, err = opts.Expression.Apply(m, keyMap); err != nil {
+					return count, err
+				}
+			}
+			if selected != opts.Invert {
+				count++
+				if !opts.Count {
+					if err := write(out, line, m, cfg.Keys, raw); err != nil {
+						return count, err
+					}
+				}
+			}
+		}
+		if rerr == io.EOF {
+			return count, nil
+		} else if rerr != nil {
+			return count, rerr
+		}
+	}
+}
+
+func write(out io.Writer, line string, m map[string]interface{}, keys []config.Key, raw bool) error {
+	if !raw {
+		values := make([]string, len(keys))
+		for i := range keys {
+			values[i] = keys[i].Humanize(keys[i].ExtractValue(m))
+		}
+		line = strings.Join(values, "\t")
+	}
+	_, err := io.WriteString(out, line+"\n")
+	return err
+}
@@ -0,0 +1,110 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package grep
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/filter"
+	"github.com/stretchr/testify/assert"
+)
+
+const input = `{"severity":"INFO","latency":"0.2s","message":"ok"}
+{"severity":"ERROR","latency":"1.5s","message":"timeout"}
+not json at all
+{"severity":"ERROR","latency":"0.1s","message":"refused"}
+`
+
+func TestRun(t *testing.T) {
+	cfg := &config.Config{Keys: []config.Key{
+		{Name: "severity", Type: config.TypeString},
+		{Name: "latency", Type: config.TypeDuration},
+		{Name: "message", Type: config.TypeString},
+	}}
+	tests := []struct {
+		name      string
+		filter    string
+		opts      Options
+		wantCount int
+		wantOut   string
+	}{
+		{
+			name:      "rendered",
+			filter:    `severity == "ERROR" AND latency > 1s`,
+			opts:      Options{Config: cfg},
+			wantCount: 1,
+			wantOut:   "ERROR\t1.5s\ttimeout\n",
+		},
+		{
+			name:      "raw",
+			filter:    `severity == "ERROR"`,
+			opts:      Options{Config: cfg, Raw: true},
+			wantCount: 2,
+			wantOut: `{"severity":"ERROR","latency":"1.5s","message":"timeout"}` + "\n" +
+				`{"severity":"ERROR","latency":"0.1s","message":"refused"}` + "\n",
+		},
+		{
+			name:      "no template is raw",
+			filter:    `message CONTAINS "json"`,
+			wantCount: 1,
+			wantOut:   "not json at all\n",
+		},
+		{
+			name:      "invert count",
+			filter:    `severity == "ERROR"`,
+			opts:      Options{Invert: true, Count: true},
+			wantCount: 2,
+		},
+		{
+			name:   "extracted key",
+			filter: `word == "json"`,
+			opts: Options{Config: &config.Config{
+				Keys:    []config.Key{{Name: "word", Type: config.TypeString}},
+				Extract: []config.Extraction{{Key: "message", Regex: `^not (?P<word>\w+)`}},
+			}},
+			wantCount: 1,
+			wantOut:   "json\n",
+		},
+		{
+			name:      "no filter",
+			opts:      Options{Count: true},
+			wantCount: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.filter) > 0 {
+				expr, err := filter.ParseFilterExpression(tt.filter)
+				assert.NoError(t, err)
+				tt.opts.Expression = expr
+			}
+			out := &strings.Builder{}
+			count, err := Run(strings.NewReader(input), out, tt.opts)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantCount, count)
+			assert.Equal(t, tt.wantOut, out.String())
+		})
+	}
+}
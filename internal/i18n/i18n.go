@@ -0,0 +1,193 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+// Package i18n translates the UI strings through message catalogs, so that
+// teams can use loggo in their own language. Messages are identified by their
+// English text, as in gettext, and catalogs are YAML files mapping them to
+// their translation, either embedded or read from the user's own directory.
+package i18n
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed locales/*.yaml
+var locales embed.FS
+
+// ErrUnknownLocale is returned when there is no catalog for a locale.
+var ErrUnknownLocale = errors.New("no catalog for locale")
+
+type catalog struct {
+	locale   string
+	messages map[string]string
+	lock     sync.Mutex
+	markups  map[string]string
+}
+
+var current atomic.Pointer[catalog]
+
+// Locale returns the selected locale, en unless set.
+func Locale() string {
+	if c := current.Load(); c != nil {
+		return c.locale
+	}
+	return "en"
+}
+
+// DetectLocale reads the locale of the environment, given as key=value pairs
+// such as os.Environ, from LC_ALL, LC_MESSAGES or LANG, e.g. pt_BR for
+// pt_BR.UTF-8, or returns en.
+func DetectLocale(environ []string) string {
+	env := make(map[string]string)
+	for _, kv := range environ {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			env[k] = v
+		}
+	}
+	for _, k := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := env[k]; len(v) > 0 {
+			return normalize(v)
+		}
+	}
+	return "en"
+}
+
+// normalize strips the encoding and modifier of a locale, e.g. de_DE for
+// de_DE.UTF-8@euro, and maps the C and POSIX locales to en.
+func normalize(locale string) string {
+	locale, _, _ = strings.Cut(locale, ".")
+	locale, _, _ = strings.Cut(locale, "@")
+	locale = strings.ReplaceAll(locale, "-", "_")
+	if locale == "C" || locale == "POSIX" || len(locale) == 0 {
+		return "en"
+	}
+	return locale
+}
+
+// SetLocale selects the catalog of the locale, e.g. pt_BR, merging those of
+// its language (pt) and of the locale itself, embedded ones first and then
+// those of dir, if any, e.g. ~/.loggo/locales/pt_BR.yaml. English needs none.
+func SetLocale(locale, dir string) error {
+	locale = normalize(locale)
+	c := &catalog{locale: locale, messages: make(map[string]string), markups: make(map[string]string)}
+	if locale == "en" || strings.HasPrefix(locale, "en_") {
+		current.Store(c)
+		return nil
+	}
+	names := []string{locale}
+	if lang, _, ok := strings.Cut(locale, "_"); ok {
+		names = []string{lang, locale}
+	}
+	found := false
+	for _, fromDir := range []bool{false, true} {
+		for _, name := range names {
+			var b []byte
+			var err error
+			file := name + ".yaml"
+			if fromDir {
+				if len(dir) == 0 {
+					continue
+				}
+				file = filepath.Join(dir, file)
+				b, err = os.ReadFile(file)
+			} else {
+				b, err = locales.ReadFile("locales/" + file)
+			}
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			messages := make(map[string]string)
+			if err := yaml.Unmarshal(b, &messages); err != nil {
+				return fmt.Errorf("%s: %w", file, err)
+			}
+			for k, v := range messages {
+				c.messages[k] = v
+			}
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("%w %s", ErrUnknownLocale, locale)
+	}
+	current.Store(c)
+	return nil
+}
+
+// T translates the message, or returns it as is if it has no translation.
+func T(message string) string {
+	if c := current.Load(); c != nil {
+		if t, ok := c.messages[message]; ok && len(t) > 0 {
+			return t
+		}
+	}
+	return message
+}
+
+// markupTag matches the color, style and region tags of tview markup.
+var markupTag = regexp.MustCompile(`\[[^\[\]]*\]`)
+
+// Markup translates the text of tview markup, leaving its tags, e.g.
+// [yellow::b], and the spaces surrounding each text run untouched, so that
+// `[yellow::b] ^t  [-::u]Template` only gets Template translated.
+func Markup(markup string) string {
+	c := current.Load()
+	if c == nil || len(c.messages) == 0 {
+		return markup
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if t, ok := c.markups[markup]; ok {
+		return t
+	}
+	sb := strings.Builder{}
+	at := 0
+	for _, tag := range markupTag.FindAllStringIndex(markup, -1) {
+		sb.WriteString(translateRun(markup[at:tag[0]]))
+		sb.WriteString(markup[tag[0]:tag[1]])
+		at = tag[1]
+	}
+	sb.WriteString(translateRun(markup[at:]))
+	c.markups[markup] = sb.String()
+	return sb.String()
+}
+
+func translateRun(run string) string {
+	text := strings.TrimSpace(run)
+	if len(text) == 0 {
+		return run
+	}
+	at := strings.Index(run, text)
+	return run[:at] + T(text) + run[at+len(text):]
+}
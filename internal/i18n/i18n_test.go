@@ -0,0 +1,78 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectLocale(t *testing.T) {
+	tests := []struct {
+		name    string
+		environ []string
+		wants   string
+	}{
+		{name: "none", wants: "en"},
+		{name: "LANG", environ: []string{"LANG=pt_BR.UTF-8"}, wants: "pt_BR"},
+		{name: "LC_ALL first", environ: []string{"LANG=pt_BR.UTF-8", "LC_ALL=es_ES.UTF-8@euro"}, wants: "es_ES"},
+		{name: "POSIX", environ: []string{"LC_MESSAGES=POSIX"}, wants: "en"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.wants, DetectLocale(test.environ))
+		})
+	}
+}
+
+func TestSetLocale(t *testing.T) {
+	defer current.Store(nil)
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "pt_BR.yaml"), []byte(`"Quit": "Cair fora"`), 0644))
+
+	assert.NoError(t, SetLocale("pt_BR.UTF-8", dir))
+	assert.Equal(t, "pt_BR", Locale())
+	assert.Equal(t, "Cair fora", T("Quit"))
+	assert.Equal(t, "Fechar", T("Close"))
+	assert.Equal(t, "Untranslated", T("Untranslated"))
+
+	assert.ErrorIs(t, SetLocale("xx", dir), ErrUnknownLocale)
+	assert.Equal(t, "pt_BR", Locale())
+
+	assert.NoError(t, SetLocale("en_US", ""))
+	assert.Equal(t, "Quit", T("Quit"))
+}
+
+func TestMarkup(t *testing.T) {
+	defer current.Store(nil)
+	assert.NoError(t, SetLocale("pt", ""))
+	assert.Equal(t, `[yellow:default:b] ^c      [-:default:u]["1"]Sair[""]`,
+		Markup(`[yellow:default:b] ^c      [-:default:u]["1"]Quit[""]`))
+	assert.Equal(t, `[yellow:default:b] v       [-:default:u]["1"]Humanizar[:default:-] [green:default:bi]LIG[-:default:-][""]`,
+		Markup(`[yellow:default:b] v       [-:default:u]["1"]Humanize[:default:-] [green:default:bi]ON[-:default:-][""]`))
+	assert.Equal(t, `[yellow:default:b] ⚠       [-:default:u]["1"]%d chave(s) divergindo[""]`,
+		Markup(`[yellow:default:b] ⚠       [-:default:u]["1"]%d key(s) drifting[""]`))
+}
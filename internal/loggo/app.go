@@ -23,6 +23,9 @@ THE SOFTWARE.
 package loggo
 
 import (
+	"fmt"
+
+	"github.com/badaniya/loggo/internal/color"
 	"github.com/badaniya/loggo/internal/config"
 	"github.com/badaniya/loggo/internal/reader"
 	"github.com/badaniya/loggo/internal/util"
@@ -34,12 +37,23 @@ var BuildVersion string
 
 type LoggoApp struct {
 	appScaffold
-	chanReader reader.Reader
-	logView    *LogView
+	chanReader    reader.Reader
+	logView       *LogView
+	peerView      *LogView
+	split         *tview.Flex
+	views         []*LogView
+	activeView    int
+	snapshotCount int
+	pasteCount    int
+	notifier      notifier
+	onFilter      func(expression string)
+	onDetach      func()
+	stateSource   string
 }
 
 type Loggo interface {
 	Draw()
+	QueueUpdateDraw(f func())
 	SetInputCapture(cap func(event *tcell.EventKey) *tcell.EventKey)
 	Stop()
 	SetFocus(primitive tview.Primitive)
@@ -60,6 +74,8 @@ func NewLoggoApp(reader reader.Reader, configFile string) *LoggoApp {
 	}
 
 	lapp.logView = NewLogReader(lapp, reader)
+	lapp.views = []*LogView{lapp.logView}
+	lapp.logView.updateTabsView(lapp.views, 0)
 
 	lapp.pages = tview.NewPages().
 		AddPage("background", lapp.logView, true, true)
@@ -67,7 +83,88 @@ func NewLoggoApp(reader reader.Reader, configFile string) *LoggoApp {
 	return lapp
 }
 
+// NameStream names the live tab after its stream, e.g. within a workspace.
+func (a *LoggoApp) NameStream(name string) {
+	a.logView.streamName = name
+	a.logView.updateTabsView(a.views, a.activeView)
+}
+
+// AddStream opens another live tab streaming from the reader, laid out by its
+// own template, e.g. for a workspace of streams.
+func (a *LoggoApp) AddStream(name string, reader reader.Reader, configFile string) error {
+	cfg, err := config.MakeConfig(configFile)
+	if err != nil {
+		return err
+	}
+	lv := newLogViewWithConfig(a, reader, cfg)
+	lv.streamName = name
+	lv.read()
+	lv.filter()
+	lv.filterChannel <- nil
+	a.views = append(a.views, lv)
+	for _, tab := range a.views {
+		tab.updateTabsView(a.views, a.activeView)
+	}
+	return nil
+}
+
+func (a *LoggoApp) addSnapshot(rows []map[string]interface{}, source *LogView) {
+	a.snapshotCount++
+	sv := NewSnapshotView(a, fmt.Sprintf("Snapshot %d", a.snapshotCount), rows, source)
+	a.views = append(a.views, sv)
+	a.showView(len(a.views) - 1)
+}
+
+// showView brings the view at the given tab index to the foreground.
+func (a *LoggoApp) showView(index int) {
+	if index < 0 || index >= len(a.views) {
+		return
+	}
+	a.activeView = index
+	v := a.views[index]
+	var page tview.Primitive = v
+	if v == a.logView && a.split != nil {
+		page = a.split
+	}
+	a.pages.AddPage("background", page, true, true).SendToBack("background")
+	v.keyEvents()
+	for _, tab := range a.views {
+		tab.updateTabsView(a.views, index)
+	}
+	if a.peerView != nil {
+		a.peerView.updateTabsView(a.views, index)
+	}
+	a.SetFocus(v.table)
+}
+
+func (a *LoggoApp) nextView(step int) {
+	if len(a.views) < 2 {
+		return
+	}
+	a.showView((a.activeView + step + len(a.views)) % len(a.views))
+}
+
+// closeActiveView discards the active snapshot or internals tab. The live tab
+// cannot be closed.
+func (a *LoggoApp) closeActiveView() {
+	v := a.views[a.activeView]
+	if !v.isSnapshot() && !v.internals {
+		return
+	}
+	v.close()
+	a.views = append(a.views[:a.activeView], a.views[a.activeView+1:]...)
+	a.showView(a.activeView - 1)
+}
+
 func (a *LoggoApp) Run() {
+	if theme := color.CurrentTheme(); theme != color.ThemeDefault {
+		screen, err := tcell.NewScreen()
+		if err != nil {
+			util.Log().Error(err)
+			panic(err)
+		}
+		a.app.SetScreen(theme.Themed(screen))
+	}
 	if err := a.app.
 		SetRoot(a.pages, true).
 		EnableMouse(true).
@@ -75,4 +172,38 @@ func (a *LoggoApp) Run() {
 		util.Log().Error(err)
 		panic(err)
 	}
+	a.saveState()
+}
+
+// OnFilterChange registers a callback called with the live view filter
+// expression whenever it changes, empty once lifted.
+func (a *LoggoApp) OnFilterChange(onFilter func(expression string)) {
+	a.onFilter = onFilter
+}
+
+// RunOn runs the app on the given screen rather than the process terminal,
+// e.g. for a remote session, closing its views and reader once quit.
+func (a *LoggoApp) RunOn(screen tcell.Screen) error {
+	err := a.app.
+		SetScreen(color.CurrentTheme().Themed(screen)).
+		SetRoot(a.pages, true).
+		EnableMouse(true).
+		Run()
+	for _, v := range a.views {
+		v.close()
+	}
+	a.chanReader.Close()
+	return err
+}
+
+// OnDetach lets the detach key leave the app running, calling back to release
+// its screen, e.g. to be reattached over a later SSH session.
+func (a *LoggoApp) OnDetach(onDetach func()) {
+	a.onDetach = onDetach
+}
+
+// Reattach moves the app started by RunOn onto a new screen once the previous
+// one was finalized, redrawing everything buffered meanwhile.
+func (a *LoggoApp) Reattach(screen tcell.Screen) {
+	a.app.SetScreen(color.CurrentTheme().Themed(screen))
 }
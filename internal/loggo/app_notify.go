@@ -0,0 +1,116 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/gdamore/tcell/v2"
+)
+
+// notifier reflects the stream state outside the app, so a backgrounded
+// terminal pane can still get attention: the terminal title carries the
+// source and its error count, and the bell rings upon alerts, e.g. the stream
+// disconnecting.
+type notifier struct {
+	lock      sync.Mutex
+	source    string
+	title     bool
+	bell      bool
+	ring      bool
+	lastTitle string
+}
+
+// EnableTerminalTitle keeps the terminal title updated with the source name
+// and the number of ERROR entries streamed so far.
+func (a *LoggoApp) EnableTerminalTitle(source string) {
+	a.notifier.lock.Lock()
+	a.notifier.source = source
+	a.notifier.title = true
+	a.notifier.lock.Unlock()
+	a.app.SetAfterDrawFunc(a.notify)
+}
+
+// EnableBell rings the terminal bell upon alerts.
+func (a *LoggoApp) EnableBell() {
+	a.notifier.lock.Lock()
+	a.notifier.bell = true
+	a.notifier.lock.Unlock()
+	a.app.SetAfterDrawFunc(a.notify)
+}
+
+// alert rings the bell, if enabled, on the next draw.
+func (a *LoggoApp) alert() {
+	a.notifier.lock.Lock()
+	a.notifier.ring = a.notifier.bell
+	a.notifier.lock.Unlock()
+	go a.Draw()
+}
+
+// notify runs after every draw, so its escape sequences never interleave with
+// the ones drawing the screen.
+func (a *LoggoApp) notify(screen tcell.Screen) {
+	n := &a.notifier
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if n.ring {
+		n.ring = false
+		_ = screen.Beep()
+	}
+	if !n.title {
+		return
+	}
+	errors := a.logView.errorCount()
+	if a.peerView != nil {
+		errors += a.peerView.errorCount()
+	}
+	title := fmt.Sprintf("loggo: %s", n.source)
+	if errors > 0 {
+		title = fmt.Sprintf("loggo: %s (%d errors)", n.source, errors)
+	}
+	if title != n.lastTitle {
+		n.lastTitle = title
+		// the screen's own terminal, which is the client's one when serving
+		// over SSH rather than the process' stdout
+		if tty, ok := screen.Tty(); ok {
+			fmt.Fprintf(tty, "\033]0;%s\007", title)
+		}
+	}
+}
+
+// countError tallies ERROR entries for the terminal title.
+func (l *LogView) countError(row map[string]interface{}) {
+	if l.severities.Severity(row) == config.SeverityError {
+		atomic.AddInt64(&l.errors, 1)
+		if l.bursts != nil {
+			l.bursts.ObserveError(row)
+		}
+	}
+}
+
+func (l *LogView) errorCount() int64 {
+	return atomic.LoadInt64(&l.errors)
+}
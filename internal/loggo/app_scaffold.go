@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/util"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
@@ -50,6 +51,7 @@ type App interface {
 func NewApp(configFile string) *appScaffold {
 	cfg, err := config.MakeConfig(configFile)
 	if err != nil {
+		util.Log().WithField("code", err).Error("Unable to load template ", configFile)
 		panic(err)
 	}
 	return NewAppWithConfig(cfg)
@@ -75,6 +77,12 @@ func (a *appScaffold) Draw() {
 	a.app.Draw()
 }
 
+// QueueUpdateDraw runs f on the event loop, e.g. a UI update from another
+// goroutine, and redraws. It must not be called from the event loop.
+func (a *appScaffold) QueueUpdateDraw(f func()) {
+	a.app.QueueUpdateDraw(f)
+}
+
 func (a *appScaffold) SetInputCapture(cap func(event *tcell.EventKey) *tcell.EventKey) {
 	a.app.SetInputCapture(cap)
 }
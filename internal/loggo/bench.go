@@ -0,0 +1,118 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/badaniya/loggo/internal/reader"
+	"github.com/gdamore/tcell/v2"
+)
+
+// BenchReport summarises a benchmark run of the ingest and rendering pipeline.
+type BenchReport struct {
+	Duration   time.Duration
+	TargetRate int
+	Generated  int64
+	Ingested   int64
+	Frames     int
+	FrameAvg   time.Duration
+	FrameP95   time.Duration
+	FrameMax   time.Duration
+}
+
+// IngestRate returns the lines per second ingested by the app.
+func (r *BenchReport) IngestRate() float64 {
+	return float64(r.Ingested) / r.Duration.Seconds()
+}
+
+// Sustained tells whether the app kept up with the target rate. Without a
+// target rate lines are generated as fast as ingested, so the ingest rate is
+// the max sustainable one.
+func (r *BenchReport) Sustained() bool {
+	return r.TargetRate == 0 || r.IngestRate() >= 0.95*float64(r.TargetRate)
+}
+
+// RunBench drives the whole app over a simulated screen, fed with synthetic
+// lines of the given size at the given rate (0 for as fast as possible), and
+// measures the ingest rate and frame times after the warmup.
+func RunBench(rate, size int, templateFile string, warmup, duration time.Duration) *BenchReport {
+	rd := reader.MakeSyntheticReader(rate, size, nil)
+	lapp := NewLoggoApp(rd, templateFile)
+
+	screen := tcell.NewSimulationScreen("UTF-8")
+	lapp.app.SetScreen(screen)
+	screen.SetSize(200, 60)
+
+	var lock sync.Mutex
+	var frames []time.Duration
+	var drawStart time.Time
+	measuring := false
+	lapp.app.SetBeforeDrawFunc(func(screen tcell.Screen) bool {
+		drawStart = time.Now()
+		return false
+	})
+	lapp.app.SetAfterDrawFunc(func(screen tcell.Screen) {
+		lock.Lock()
+		defer lock.Unlock()
+		if measuring {
+			frames = append(frames, time.Since(drawStart))
+		}
+	})
+
+	go lapp.Run()
+	time.Sleep(warmup)
+
+	lock.Lock()
+	measuring = true
+	lock.Unlock()
+	generated, ingested := rd.Generated(), int64(len(lapp.logView.inSlice))
+	time.Sleep(duration)
+	lock.Lock()
+	measuring = false
+	lock.Unlock()
+	report := &BenchReport{
+		Duration:   duration,
+		TargetRate: rate,
+		Generated:  rd.Generated() - generated,
+		Ingested:   int64(len(lapp.logView.inSlice)) - ingested,
+	}
+	lapp.Stop()
+
+	lock.Lock()
+	defer lock.Unlock()
+	report.Frames = len(frames)
+	if len(frames) > 0 {
+		sort.Slice(frames, func(i, j int) bool { return frames[i] < frames[j] })
+		var total time.Duration
+		for _, f := range frames {
+			total += f
+		}
+		report.FrameAvg = total / time.Duration(len(frames))
+		report.FrameP95 = frames[len(frames)*95/100]
+		report.FrameMax = frames[len(frames)-1]
+	}
+	return report
+}
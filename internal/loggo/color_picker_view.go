@@ -27,6 +27,7 @@ import (
 	"sort"
 
 	"github.com/badaniya/loggo/internal/color"
+	"github.com/badaniya/loggo/internal/i18n"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
@@ -105,7 +106,7 @@ func (t *ColorPickerView) makeUIComponents() {
 	t.contextMenu = tview.NewList()
 	t.contextMenu.
 		SetBorder(true).
-		SetTitle("Context Menu").
+		SetTitle(i18n.T("Context Menu")).
 		SetBackgroundColor(color.ColorBackgroundField)
 
 	t.table = tview.NewTable().
@@ -155,19 +156,19 @@ func (t *ColorPickerView) makeContextMenu() {
 	t.contextMenu.
 		ShowSecondaryText(false)
 	if t.toggleFullScreenCallback != nil {
-		t.contextMenu.AddItem("Toggle Full Screen", "", 'f', func() {
+		t.contextMenu.AddItem(i18n.T("Toggle Full Screen"), "", 'f', func() {
 			t.toggleFullScreenCallback()
 		})
 	}
 	if t.onSelect != nil {
-		t.contextMenu.AddItem("/ [yellow::](ENTER)[-::-] Select Color", "", 's', func() {
+		t.contextMenu.AddItem(i18n.Markup("/ [yellow::](ENTER)[-::-] Select Color"), "", 's', func() {
 			r, c := t.table.GetSelection()
 			col := t.colors[r][c]
 			t.onSelect(col)
 		})
 	}
 	if t.closeCallback != nil {
-		t.contextMenu.AddItem("Close", "", 'x', func() {
+		t.contextMenu.AddItem(i18n.T("Close"), "", 'x', func() {
 			t.closeCallback()
 		})
 	}
@@ -25,6 +25,7 @@ package loggo
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/badaniya/loggo/internal/char"
 
@@ -42,9 +43,15 @@ type FilterView struct {
 	buttonSearch    *tview.Button
 	buttonClear     *tview.Button
 	keyFinderField  *tview.InputField
+	matchingView    *tview.TextView
 	filterCallback  func(*filter.Expression)
+	typing          *time.Timer
 }
 
+// typingDelay is the pause in typing after which the expression is applied,
+// the latest keystroke winning.
+const typingDelay = 300 * time.Millisecond
+
 func NewFilterView(app Loggo, filterCallback func(*filter.Expression)) *FilterView {
 	tv := &FilterView{
 		Flex:           *tview.NewFlex(),
@@ -63,17 +70,20 @@ func (t *FilterView) makeUIComponents() {
 		SetPlaceholderStyle(color.PlaceholderStyle)
 	t.expressionField.
 		SetBackgroundColor(color.ColorBackgroundField)
+	t.expressionField.SetChangedFunc(func(text string) {
+		t.filterAsYouType(text)
+	})
 	t.buttonSearch = tview.NewButton("Search").SetSelectedFunc(func() {
 		t.search()
 	})
 	t.buttonClear = tview.NewButton("Clear").SetSelectedFunc(func() {
-		t.expressionField.SetText("")
+		t.Clear()
 		t.app.SetFocus(t.expressionField)
-		if t.filterCallback != nil {
-			t.filterCallback(nil)
-		}
 	})
 
+	t.matchingView = tview.NewTextView().SetDynamicColors(true)
+	t.showMatching(filter.CurrentMatching())
+
 	t.keyFinderField = tview.NewInputField().SetPlaceholder("Start typing to find a key...")
 	t.keyFinderField.SetAutocompleteFunc(func(currentText string) (entries []string) {
 		matches := make([]string, 0)
@@ -121,7 +131,35 @@ func (t *FilterView) makeUIComponents() {
 	})
 }
 
+// filterAsYouType applies the expression once typing pauses, unless it's
+// incomplete yet; Enter still reports why. The expression is parsed off the
+// event loop, on which the callback then runs.
+func (t *FilterView) filterAsYouType(text string) {
+	t.stopTyping()
+	t.typing = time.AfterFunc(typingDelay, func() {
+		var exp *filter.Expression
+		if len(strings.TrimSpace(text)) > 0 {
+			var err error
+			if exp, err = filter.ParseFilterExpression(text); err != nil {
+				return
+			}
+		}
+		if t.filterCallback != nil {
+			t.app.QueueUpdateDraw(func() {
+				t.filterCallback(exp)
+			})
+		}
+	})
+}
+
+func (t *FilterView) stopTyping() {
+	if t.typing != nil {
+		t.typing.Stop()
+	}
+}
+
 func (t *FilterView) search() {
+	t.stopTyping()
 	exp, err := filter.ParseFilterExpression(t.expressionField.GetText())
 	if err != nil {
 		t.app.ShowPrefabModal(fmt.Sprintf("[yellow::b]Invalid filter expression:[-::-]\n[::i]%v", err), 50, 10,
@@ -148,6 +186,36 @@ func (t *FilterView) search() {
 	}
 }
 
+// showMatching tells how free text and terms are matched, see
+// filter.Matching.
+func (t *FilterView) showMatching(m filter.Matching) {
+	word := "[gray]"
+	if m.WholeWord {
+		word = "[yellow::b]"
+	}
+	t.matchingView.SetText(fmt.Sprintf(" [yellow::b]%s[-::-] · %swhole word[-::-]", m.Case, word))
+}
+
+// Expression returns the filter expression as typed in.
+func (t *FilterView) Expression() string {
+	return t.expressionField.GetText()
+}
+
+// Apply replaces the filter expression and applies it, as if typed in.
+func (t *FilterView) Apply(expression string) {
+	t.expressionField.SetText(expression)
+	t.search()
+}
+
+// Clear empties the expression, lifting the filter.
+func (t *FilterView) Clear() {
+	t.expressionField.SetText("")
+	t.stopTyping()
+	if t.filterCallback != nil {
+		t.filterCallback(nil)
+	}
+}
+
 func (t *FilterView) addKey() {
 	tex := t.expressionField.GetText()
 	t.expressionField.SetText(tex + " " + t.keyFinderField.GetText())
@@ -196,7 +264,8 @@ func (t *FilterView) makeLayouts() {
 	actionBar.AddItem(tview.NewTextView().SetText(" |"), 2, 0, false)
 	t.addButton(actionBar, "AND")
 	t.addButton(actionBar, "OR")
-	actionBar.AddItem(tview.NewBox(), 24, 1, false)
+	actionBar.AddItem(tview.NewTextView().SetText(" |"), 2, 0, false).
+		AddItem(t.matchingView, 26, 1, false)
 
 	t.Flex.Clear().SetDirection(tview.FlexRow).
 		AddItem(filterRow, 3, 1, false).
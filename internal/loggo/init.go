@@ -32,11 +32,27 @@ import (
 const (
 	parentPath = ".loggo"
 	logsPath   = "logs"
+	draftsPath = "drafts"
+	statePath  = "state"
+	ringFile   = "recording.ring"
 	currentLog = "latest.log"
+	keysFile   = "keys.yaml"
 )
 
 var LatestLog string
 
+// DraftsDir holds the unsaved template edits, see config.SaveDraft.
+var DraftsDir string
+
+// StateDir holds the UI state left over each source, see LoggoApp.RememberState.
+var StateDir string
+
+// RingFile is the default ring file for recording streams, see reader.RingFile.
+var RingFile string
+
+// KeysFile remaps the keybindings, see LoadKeyMap.
+var KeysFile string
+
 func init() {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -54,4 +70,16 @@ func init() {
 	// os.Rename(LatestLog, prev)
 
 	util.InitializeLogging(LatestLog)
+
+	RingFile = path.Join(home, parentPath, ringFile)
+	KeysFile = path.Join(home, parentPath, keysFile)
+
+	DraftsDir = path.Join(home, parentPath, draftsPath)
+	if err := os.MkdirAll(DraftsDir, os.ModePerm); err != nil {
+		util.Log().WithField("code", err).Error("Unable to create template drafts dir")
+	}
+	StateDir = path.Join(home, parentPath, statePath)
+	if err := os.MkdirAll(StateDir, os.ModePerm); err != nil {
+		util.Log().WithField("code", err).Error("Unable to create UI state dir")
+	}
 }
@@ -29,7 +29,11 @@ import (
 	"strings"
 
 	"github.com/atotto/clipboard"
+	"github.com/badaniya/loggo/internal/char"
 	"github.com/badaniya/loggo/internal/color"
+	"github.com/badaniya/loggo/internal/filter"
+	"github.com/badaniya/loggo/internal/i18n"
+	"github.com/badaniya/loggo/internal/payload"
 	"github.com/badaniya/loggo/internal/search"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
@@ -46,12 +50,21 @@ type JsonView struct {
 	jText                    []byte
 	searchWord               string
 	isSearching              bool
+	searchRegex              bool
+	showResults              bool
+	results                  *tview.List
+	resultIndexes            []int
 	indent                   string
 	searchStrategy           search.Searchable
+	matching                 filter.Matching
 	withSearchTag            string
 	wordWrap                 bool
 	showQuit                 bool
 	isCopyMode               bool
+	prettyPayloads           bool
+	pages                    []string
+	page                     int
+	generation               int
 	toggleFullScreenCallback func()
 	closeCallback            func()
 }
@@ -65,6 +78,8 @@ func NewJsonView(app Loggo, showQuit bool,
 		indent:                   "  ",
 		isCopyMode:               true,
 		wordWrap:                 true,
+		prettyPayloads:           true,
+		matching:                 filter.CurrentMatching(),
 		showQuit:                 showQuit,
 		toggleFullScreenCallback: toggleFullScreenCallback,
 		closeCallback:            closeCallback,
@@ -77,10 +92,13 @@ func NewJsonView(app Loggo, showQuit bool,
 }
 
 // SetJson sets a JSON and colourise accordingly, replacing any existing content. If it
-// fails to parse the json, it displays the text as plain text.
+// fails to parse the json, it displays the text as plain text. Large entries
+// are formatted in the background and paginated, see largeEntrySize.
 func (j *JsonView) SetJson(jText []byte) *JsonView {
 	j.jText = jText
-	return j.setJson()
+	j.page = 0
+	j.reformat()
+	return j
 }
 
 func (j *JsonView) makeUIComponents() {
@@ -105,6 +123,9 @@ func (j *JsonView) makeUIComponents() {
 				case 'c', 'C':
 					j.clearSearch()
 					return nil
+				case 'l', 'L':
+					j.toggleResults()
+					return nil
 				}
 				switch event.Key() {
 				case tcell.KeyEsc:
@@ -143,6 +164,15 @@ func (j *JsonView) makeUIComponents() {
 				j.wordWrap = !j.wordWrap
 				j.textView.SetWrap(j.wordWrap)
 				return nil
+			case 'p', 'P':
+				j.togglePrettyPayloads()
+				return nil
+			case '>':
+				j.turnPage(1)
+				return nil
+			case '<':
+				j.turnPage(-1)
+				return nil
 			}
 			switch event.Key() {
 			case tcell.KeyEsc:
@@ -161,7 +191,7 @@ func (j *JsonView) makeUIComponents() {
 	j.contextMenu = tview.NewList()
 	j.contextMenu.
 		SetBorder(true).
-		SetTitle("Context Menu").
+		SetTitle(i18n.T("Context Menu")).
 		SetBackgroundColor(color.ColorBackgroundField)
 
 	j.searchInput = tview.NewInputField()
@@ -194,6 +224,7 @@ func (j *JsonView) makeUIComponents() {
 
 	j.statusBar = tview.NewTextView()
 	j.statusBar.SetBackgroundColor(color.ColorBackgroundField).SetBorder(true)
+	j.makeResults()
 }
 
 func (j *JsonView) makeLayouts(search bool) {
@@ -201,6 +232,9 @@ func (j *JsonView) makeLayouts(search bool) {
 		SetDirection(tview.FlexColumn).
 		AddItem(j.contextMenu, 30, 1, false).
 		AddItem(j.textView, 0, 2, false)
+	if search && j.showResults {
+		mainContent.AddItem(j.results, searchResultsWidth, 1, false)
+	}
 
 	j.Flex.Clear().SetDirection(tview.FlexRow)
 	j.Flex.AddItem(mainContent, 0, 2, false)
@@ -223,51 +257,67 @@ func (j *JsonView) makeContextMenu() {
 		SetMainTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField))
 	if j.isSearching {
 		j.contextMenu.
-			AddItem("Next Result", "", 'n', func() {
+			AddItem(i18n.T("Next Result"), "", 'n', func() {
 				j.next()
 			}).
-			AddItem("Previous Result", "", 'p', func() {
+			AddItem(i18n.T("Previous Result"), "", 'p', func() {
 				j.prev()
 			}).
-			AddItem("Clear Search", "", 'c', func() {
+			AddItem(i18n.T("Clear Search"), "", 'c', func() {
 				j.clearSearch()
+			}).
+			AddItem(i18n.T("Toggle Results List"), "", 'l', func() {
+				j.toggleResults()
+			})
+	}
+
+	if len(j.pages) > 1 {
+		j.contextMenu.
+			AddItem(i18n.T("Next Page"), "", '>', func() {
+				j.turnPage(1)
+			}).
+			AddItem(i18n.T("Previous Page"), "", '<', func() {
+				j.turnPage(-1)
 			})
 	}
 
 	if j.toggleFullScreenCallback != nil {
-		j.contextMenu.AddItem("Toggle Full Screen", "", 'f', func() {
+		j.contextMenu.AddItem(i18n.T("Toggle Full Screen"), "", 'f', func() {
 			j.toggleFullScreenCallback()
 		})
 	}
 
 	j.contextMenu.
-		AddItem("Copy to Clipboard", "", '`', func() {
+		AddItem(i18n.T("Copy to Clipboard"), "", '`', func() {
 			j.copyToClipboard()
 		}).
-		AddItem("Search Word", "", 's', func() {
+		AddItem(i18n.T("Search Word"), "", 's', func() {
 			j.prepareCaseInsensitiveSearch()
 		}).
-		AddItem("Search Regex", "", 'r', func() {
+		AddItem(i18n.T("Search Regex"), "", 'r', func() {
 			j.prepareRegexSearch()
 		}).
-		AddItem("Go to Top", "", 'g', func() {
+		AddItem(i18n.T("Go to Top"), "", 'g', func() {
 			j.textView.ScrollToBeginning()
 		}).
-		AddItem("Go to Bottom", "", 'G', func() {
+		AddItem(i18n.T("Go to Bottom"), "", 'G', func() {
 			j.textView.ScrollToEnd()
 		}).
-		AddItem("Toggle word wrap", "", 'w', func() {
+		AddItem(i18n.T("Toggle word wrap"), "", 'w', func() {
 			j.wordWrap = !j.wordWrap
 			j.textView.SetWrap(j.wordWrap)
+		}).
+		AddItem(i18n.T("Toggle pretty payloads"), "", 'p', func() {
+			j.togglePrettyPayloads()
 		})
 
 	if j.closeCallback != nil {
-		j.contextMenu.AddItem("Close", "", 'x', func() {
+		j.contextMenu.AddItem(i18n.T("Close"), "", 'x', func() {
 			j.closeCallback()
 		})
 	}
 	if j.showQuit {
-		j.contextMenu.AddItem("Quit", "", 'q', func() {
+		j.contextMenu.AddItem(i18n.T("Quit"), "", 'q', func() {
 			j.app.Stop()
 		})
 	}
@@ -302,9 +352,10 @@ func (j *JsonView) prepareCaseInsensitiveSearch() {
 	if j.searchStrategy != nil {
 		j.searchStrategy.Clear()
 	}
-	j.searchStrategy = search.MakeCaseInsensitiveSearch(j.statusBar)
+	j.searchStrategy = search.MakeTextSearch(j.statusBar, j.matching)
+	j.searchRegex = false
 	j.makeLayouts(true)
-	j.searchInput.SetTitle("Search Word")
+	j.searchInput.SetTitle(i18n.T("Search Word"))
 	j.app.SetFocus(j.searchInput)
 	if len(j.searchInput.GetText()) > 0 {
 		j.search(j.searchInput.GetText())
@@ -316,8 +367,9 @@ func (j *JsonView) prepareRegexSearch() {
 		j.searchStrategy.Clear()
 	}
 	j.searchStrategy = search.MakeRegexSearch(j.statusBar)
+	j.searchRegex = true
 	j.makeLayouts(true)
-	j.searchInput.SetTitle("Search Regex")
+	j.searchInput.SetTitle(i18n.T("Search Regex"))
 	j.app.SetFocus(j.searchInput)
 	if len(j.searchInput.GetText()) > 0 {
 		j.search(j.searchInput.GetText())
@@ -331,9 +383,8 @@ func (j *JsonView) search(word string) []int {
 	j.searchStrategy.Clear()
 	j.withSearchTag = word
 	j.setJson()
-	j.textView.
-		Highlight(fmt.Sprintf(`%d`, j.searchStrategy.GetSearchPosition()-1)).
-		ScrollToHighlight()
+	j.highlight(fmt.Sprintf(`%d`, j.searchStrategy.GetSearchPosition()-1))
+	j.updateResults()
 
 	j.searchStrategy.SetCurrentStatus()
 	return nil
@@ -341,22 +392,31 @@ func (j *JsonView) search(word string) []int {
 
 func (j *JsonView) next() {
 	j.searchStrategy.Next()
-	j.textView.
-		Highlight(fmt.Sprintf(`%d`, j.searchStrategy.GetSearchPosition()-1)).
-		ScrollToHighlight()
+	j.highlight(fmt.Sprintf(`%d`, j.searchStrategy.GetSearchPosition()-1))
+	j.syncResults()
 
 	j.searchStrategy.SetCurrentStatus()
 }
 
 func (j *JsonView) prev() {
 	j.searchStrategy.Prev()
-	j.textView.
-		Highlight(fmt.Sprintf(`%d`, j.searchStrategy.GetSearchPosition()-1)).
-		ScrollToHighlight()
+	j.highlight(fmt.Sprintf(`%d`, j.searchStrategy.GetSearchPosition()-1))
+	j.syncResults()
 
 	j.searchStrategy.SetCurrentStatus()
 }
 
+// rematch searches the word again once the filter matching options changed,
+// regular expressions being matched as written.
+func (j *JsonView) rematch(m filter.Matching) {
+	j.matching = m
+	if !j.isSearching || j.searchRegex {
+		return
+	}
+	j.searchStrategy = search.MakeTextSearch(j.statusBar, m)
+	j.search(j.searchInput.GetText())
+}
+
 func (j *JsonView) clearSearch() {
 	j.app.SetFocus(j.textView)
 	j.searchInput.SetText("")
@@ -369,12 +429,26 @@ func (j *JsonView) clearSearch() {
 }
 
 func (j *JsonView) setJson() *JsonView {
+	j.generation++
+	text, isJson := j.formatJson()
+	j.showText(text, isJson)
+	return j
+}
+
+// formatJson colourises the JSON, or tells it isn't one and returns the
+// (search tagged) plain text.
+func (j *JsonView) formatJson() (string, bool) {
 	jMap := make(map[string]interface{})
 	if err := json.Unmarshal(j.jText, &jMap); err != nil {
 		tex := string(j.jText)
 		sb := strings.Builder{}
 		wordList := strings.Split(tex, " ")
 		for i, w := range wordList {
+			lines := strings.Split(w, "\n")
+			for n, line := range lines {
+				lines[n] = tview.Escape(char.Printable(line, 0))
+			}
+			w = strings.Join(lines, "\n")
 			if word := j.captureWordSection(w, j.withSearchTag); len(word) > 0 {
 				sb.WriteString(word)
 			} else {
@@ -384,41 +458,54 @@ func (j *JsonView) setJson() *JsonView {
 				sb.WriteString(" ")
 			}
 		}
+		return sb.String(), false
+	}
+	text := &strings.Builder{}
+	text.WriteString("{" + j.newLine())
+	kc := len(jMap)
+	i := 0
+	keys := j.extractKeys(jMap)
+	for _, k := range keys {
+		v := jMap[k]
+		j.processNode(k, v, j.indent, text, i+1 == kc)
+		text.WriteString(j.newLine())
+		i++
+	}
+	text.WriteString("}" + j.newLine())
+	return text.String(), true
+}
+
+func (j *JsonView) showText(text string, isJson bool) {
+	if !isJson {
 		j.wordWrap = true
 		j.textView.SetWrap(j.wordWrap)
-		j.textView.SetText(sb.String()).SetTextColor(tcell.ColorRed)
-	} else {
-		text := &strings.Builder{}
-		text.WriteString("{" + j.newLine())
-		kc := len(jMap)
-		i := 0
-		keys := j.extractKeys(jMap)
-		for _, k := range keys {
-			v := jMap[k]
-			j.processNode(k, v, j.indent, text, i+1 == kc)
-			text.WriteString(j.newLine())
-			i++
-		}
-		text.WriteString("}" + j.newLine())
-		markedText := text.String()
-		j.textView.SetText(markedText)
+		j.textView.SetTextColor(tcell.ColorRed)
 	}
-
-	return j
+	j.pages = paginate(text, jsonPageSize)
+	if j.page >= len(j.pages) {
+		j.page = len(j.pages) - 1
+	}
+	j.showPage()
+	j.makeContextMenu()
 }
 
 func (j *JsonView) processNode(k, v interface{}, indent string, text *strings.Builder, last bool) {
-	word := j.captureWordSection(k, j.withSearchTag)
-	if word != "" {
-		k = word
+	name := tview.Escape(char.Printable(fmt.Sprint(k), 0))
+	if word := j.captureWordSection(name, j.withSearchTag); word != "" {
+		name = word
 	}
-	key := fmt.Sprintf(`%s%s"%v"%s: `, indent, color.ClField, k, color.ClWhite)
+	key := fmt.Sprintf(`%s%s"%s"%s: `, indent, color.ClField, name, color.ClWhite)
 	text.WriteString(key)
+	v = j.decodePayload(v)
 	switch tp := v.(type) {
 	case int, float64, bool:
 		j.processNumeric(text, v, "")
 	case string:
-		j.processString(text, v, "")
+		if pretty, ok := j.formatPayload(tp); ok {
+			j.processMultiline(text, pretty, indent+j.indent)
+		} else {
+			j.processString(text, v, "")
+		}
 	case map[string]interface{}:
 		j.processObject(text, v, j.indent+indent)
 	case []interface{}:
@@ -460,10 +547,8 @@ func (j *JsonView) processObject(text *strings.Builder, val interface{}, indent
 }
 
 func (j *JsonView) processString(text *strings.Builder, v interface{}, indent string) {
-	val := fmt.Sprintf(`%v`, v)
-	// val = strings.ReplaceAll(val, "\"", "\\\"")
-	// val = strings.ReplaceAll(val, "\n", "\\n")
-	if word := j.captureWordSection(v, j.withSearchTag); len(word) > 0 {
+	val := tview.Escape(char.Printable(fmt.Sprintf(`%v`, v), 0))
+	if word := j.captureWordSection(val, j.withSearchTag); len(word) > 0 {
 		val = word
 	}
 	text.WriteString(color.ClString)
@@ -481,11 +566,17 @@ func (j *JsonView) processNumeric(text *strings.Builder, v interface{}, indent s
 }
 
 func (j *JsonView) processArrayItem(v interface{}, indent string, text *strings.Builder, last bool) {
+	v = j.decodePayload(v)
 	switch tp := v.(type) {
 	case int, float64, bool:
 		j.processNumeric(text, v, indent)
 	case string:
-		j.processString(text, v, indent)
+		if pretty, ok := j.formatPayload(tp); ok {
+			text.WriteString(j.computeIndent(indent))
+			j.processMultiline(text, pretty, indent+j.indent)
+		} else {
+			j.processString(text, v, indent)
+		}
 	case map[string]interface{}:
 		j.processObject(text, v, indent)
 	case []interface{}:
@@ -529,3 +620,46 @@ func (j *JsonView) captureWordSection(text interface{}, withTag string) string {
 	}
 	return sel
 }
+
+func (j *JsonView) togglePrettyPayloads() {
+	j.prettyPayloads = !j.prettyPayloads
+	j.reformat()
+}
+
+// decodePayload expands JSON documents embedded as strings so they're rendered
+// as nested nodes rather than a single escaped line.
+func (j *JsonView) decodePayload(v interface{}) interface{} {
+	if str, ok := v.(string); ok && j.prettyPayloads {
+		if decoded, ok := payload.DecodeJSON(str); ok {
+			return decoded
+		}
+	}
+	return v
+}
+
+// formatPayload formats stack traces, SQL statements and URL encoded forms
+// embedded as strings.
+func (j *JsonView) formatPayload(v string) (string, bool) {
+	if !j.prettyPayloads || len(j.indent) == 0 {
+		return v, false
+	}
+	formatted, kind := payload.Format(v)
+	return formatted, kind != payload.KindNone && kind != payload.KindJSON
+}
+
+func (j *JsonView) processMultiline(text *strings.Builder, v string, indent string) {
+	text.WriteString(color.ClString)
+	text.WriteString(`"`)
+	for i, line := range strings.Split(v, "\n") {
+		if i > 0 {
+			text.WriteString(j.newLine() + indent)
+		}
+		line = tview.Escape(char.Printable(line, 0))
+		if word := j.captureWordSection(line, j.withSearchTag); len(word) > 0 {
+			line = word
+		}
+		text.WriteString(line)
+	}
+	text.WriteString(`"`)
+	text.WriteString(color.ClWhite)
+}
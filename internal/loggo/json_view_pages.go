@@ -0,0 +1,115 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// largeEntrySize is the size from which entries are formatted in the
+	// background rather than freezing the UI.
+	largeEntrySize = 256 * 1024
+	// jsonPageSize is the approximate size of each page of a formatted entry,
+	// keeping the text view responsive with multi-MB entries.
+	jsonPageSize = 64 * 1024
+)
+
+// reformat renders the entry again, in the background for large entries.
+func (j *JsonView) reformat() {
+	if len(j.jText) < largeEntrySize {
+		j.setJson()
+		return
+	}
+	j.generation++
+	gen := j.generation
+	j.pages = nil
+	j.textView.SetText(fmt.Sprintf("[yellow]Formatting %.2fMB entry...[-]", float64(len(j.jText))/1000000.0))
+	go func() {
+		text, isJson := j.formatJson()
+		if gen != j.generation {
+			return
+		}
+		j.showText(text, isJson)
+		j.app.Draw()
+	}()
+}
+
+func (j *JsonView) showPage() {
+	if len(j.pages) <= 1 {
+		j.textView.SetText(strings.Join(j.pages, ""))
+		return
+	}
+	j.textView.SetText(fmt.Sprintf("[yellow::b]Page %d/%d[-::-] ([yellow]<[-] previous, [yellow]>[-] next page)\n%s",
+		j.page+1, len(j.pages), j.pages[j.page])).
+		ScrollToBeginning()
+}
+
+func (j *JsonView) turnPage(delta int) {
+	page := j.page + delta
+	if page < 0 || page >= len(j.pages) {
+		return
+	}
+	j.page = page
+	j.showPage()
+}
+
+// highlight highlights the search region, turning to the page holding it.
+func (j *JsonView) highlight(region string) {
+	if len(j.pages) > 1 {
+		tag := fmt.Sprintf(`["%s"]`, region)
+		for i, p := range j.pages {
+			if strings.Contains(p, tag) {
+				if i != j.page {
+					j.page = i
+					j.showPage()
+				}
+				break
+			}
+		}
+	}
+	j.textView.Highlight(region).ScrollToHighlight()
+}
+
+// paginate splits the text into pages of about size bytes, breaking at line
+// ends, or else at spaces, where possible.
+func paginate(text string, size int) []string {
+	var pages []string
+	for len(text) > size {
+		cut := strings.LastIndexByte(text[:size], '\n') + 1
+		if cut == 0 {
+			cut = strings.LastIndexByte(text[:size], ' ') + 1
+		}
+		if cut == 0 {
+			cut = size
+			for cut > 1 && !utf8.RuneStart(text[cut]) {
+				cut--
+			}
+		}
+		pages = append(pages, text[:cut])
+		text = text[cut:]
+	}
+	return append(pages, text)
+}
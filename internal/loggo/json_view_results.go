@@ -0,0 +1,184 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/badaniya/loggo/internal/color"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	// searchResultsWidth is the width of the search results panel.
+	searchResultsWidth = 50
+	// maxSearchResults caps the matches listed by the results panel.
+	maxSearchResults = 1000
+	// snippetLead is the text kept before the match in a result snippet.
+	snippetLead = 12
+)
+
+var (
+	// regionTag opens a search match region, e.g. ["3"].
+	regionTag = regexp.MustCompile(`\["([0-9]+)"\]`)
+	// markupTag is a color or region tag, or an escaped bracket, e.g. [x[].
+	markupTag = regexp.MustCompile(`\[([a-zA-Z0-9_,;: \-.#]*|"[^"]*")(\[)?\]`)
+)
+
+// searchResult is a match of the entry search, as listed by the results
+// panel.
+type searchResult struct {
+	index   int
+	line    int
+	snippet string
+}
+
+// toggleResults shows or hides the panel listing every match of the search,
+// which is scrolled independently of the entry and jumped to from.
+func (j *JsonView) toggleResults() {
+	j.showResults = !j.showResults
+	j.updateResults()
+	j.makeLayouts(true)
+	j.makeContextMenu()
+	if j.showResults {
+		j.app.SetFocus(j.results)
+	} else {
+		j.app.SetFocus(j.textView)
+	}
+}
+
+func (j *JsonView) makeResults() {
+	j.results = tview.NewList().
+		ShowSecondaryText(false).
+		SetHighlightFullLine(true).
+		SetMainTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
+		SetSelectedStyle(color.FieldStyle)
+	j.results.SetBorder(true).
+		SetBackgroundColor(color.ColorBackgroundField)
+	j.results.SetSelectedFunc(func(i int, _ string, _ string, _ rune) {
+		if i < len(j.resultIndexes) {
+			j.jumpToResult(j.resultIndexes[i])
+		}
+	})
+	j.results.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc, tcell.KeyTAB:
+			j.app.SetFocus(j.textView)
+			return nil
+		}
+		if event.Rune() == 'l' {
+			j.toggleResults()
+			return nil
+		}
+		return event
+	})
+}
+
+// updateResults lists the matches of the search, if the panel is shown.
+func (j *JsonView) updateResults() {
+	if !j.showResults {
+		return
+	}
+	results := searchResults(j.pages)
+	j.results.Clear().SetTitle(fmt.Sprintf(" %d results ", len(results)))
+	j.resultIndexes = j.resultIndexes[:0]
+	for n, r := range results {
+		if n == maxSearchResults {
+			j.results.AddItem(fmt.Sprintf("[gray]… %d more[-]", len(results)-n), "", 0, nil)
+			break
+		}
+		j.results.AddItem(fmt.Sprintf("[yellow]%4d[-] %s", r.line, tview.Escape(r.snippet)), "", 0, nil)
+		j.resultIndexes = append(j.resultIndexes, r.index)
+	}
+	j.syncResults()
+}
+
+// syncResults selects the current match in the results panel.
+func (j *JsonView) syncResults() {
+	if !j.showResults {
+		return
+	}
+	current := j.searchStrategy.GetSearchPosition() - 1
+	for i, index := range j.resultIndexes {
+		if index == current {
+			j.results.SetCurrentItem(i)
+			return
+		}
+	}
+}
+
+// jumpToResult makes the match the current one, scrolling the entry to it.
+func (j *JsonView) jumpToResult(index int) {
+	j.searchStrategy.Select(index)
+	j.highlight(strconv.Itoa(index))
+	j.searchStrategy.SetCurrentStatus()
+}
+
+// searchResults finds the matches tagged in the rendered entry, telling the
+// line they're on, counted from the start of the entry, and the text around
+// them.
+func searchResults(pages []string) []searchResult {
+	var results []searchResult
+	for n, line := range strings.Split(strings.Join(pages, ""), "\n") {
+		for _, m := range regionTag.FindAllStringSubmatchIndex(line, -1) {
+			index, err := strconv.Atoi(line[m[2]:m[3]])
+			if err != nil {
+				continue
+			}
+			at := len([]rune(plainText(line[:m[0]])))
+			results = append(results, searchResult{
+				index:   index,
+				line:    n + 1,
+				snippet: snippet(plainText(line), at),
+			})
+		}
+	}
+	return results
+}
+
+// plainText strips the color and region tags off the rendered text,
+// unescaping brackets.
+func plainText(s string) string {
+	return markupTag.ReplaceAllStringFunc(s, func(tag string) string {
+		if strings.HasSuffix(tag, "[]") {
+			return tag[:len(tag)-2] + "]"
+		}
+		return ""
+	})
+}
+
+// snippet is the text around the rune at the given position, trimmed to fit
+// the results panel.
+func snippet(text string, at int) string {
+	r := []rune(text)
+	start := max(at-snippetLead, 0)
+	s := strings.TrimLeft(string(r[start:]), " ")
+	if len(strings.TrimSpace(string(r[:start]))) > 0 {
+		s = "…" + s
+	}
+	return ellipsize(s, searchResultsWidth-8)
+}
@@ -26,14 +26,23 @@ import (
 	"encoding/json"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/badaniya/loggo/internal/filter"
+	"github.com/badaniya/loggo/internal/i18n"
+	"github.com/badaniya/loggo/internal/payload"
+	"github.com/badaniya/loggo/internal/pipeline"
 
 	"github.com/badaniya/loggo/internal/reader"
 
+	"github.com/badaniya/loggo/internal/char"
 	"github.com/badaniya/loggo/internal/color"
+	"github.com/badaniya/loggo/internal/compress"
 	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/enrich"
+	"github.com/badaniya/loggo/internal/secrets"
+	"github.com/badaniya/loggo/internal/util"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
@@ -53,35 +62,150 @@ type LogView struct {
 	mainMenu           *tview.Flex
 	filterView         *FilterView
 	linesView          *tview.TextView
+	memView            *tview.TextView
 	followingView      *tview.TextView
+	humanizeView       *tview.TextView
+	aggregatesView     *tview.TextView
+	heatmapMenuView    *tview.TextView
+	followErrorsView   *tview.TextView
+	footerView         *tview.TextView
+	heatmapView        *tview.TextView
+	chartMenuView      *tview.TextView
+	chartView          *tview.TextView
+	minimapView        *tview.TextView
+	watchesView        *tview.TextView
+	pinsView           *tview.List
 	logFullScreen      bool
 	templateFullScreen bool
 	inSlice            []map[string]interface{}
+	rawLines           []*compress.Text
+	lineBuffer         []byte
 	finSlice           []map[string]interface{}
+	bufferStart        int
+	backfills          chan []string
+	backfilling        atomic.Bool
+	backfilledAll      atomic.Bool
+	texts              *compress.Store
+	pins               []map[string]interface{}
+	marks              []map[string]interface{}
+	arrivals           map[uintptr]time.Time
+	arrivalsLock       sync.Mutex
+	peer               *LogView
 	filterChannel      chan *filter.Expression
 	filterLock         sync.RWMutex
+	filterExpression   *filter.Expression
 	globalCount        int64
+	errors             int64
 	isFollowing        bool
 	hideFilter         bool
 	rebufferFilter     bool
 	selectionEnabled   bool
 	mouseSel           *tview.TextView
+	tabsView           *tview.TextView
+	driftView          *tview.TextView
+	coverage           *config.KeyCoverage
+	novelty            *config.NoveltyTracker
+	bursts             *config.BurstDetector
+	burstView          *tview.TextView
+	secretTally        *secrets.Tally
+	secretsView        *tview.TextView
+	tutorialView       *tview.TextView
+	loadView           *tview.TextView
+	ended              bool
+	tutorial           tutorialStep
+	history            *config.History[viewEdit]
+	lastFilter         filterState
+	lastKeys           []config.Key
+	restoring          bool
+	savedState         *config.UIState
+	stickies           []stickyFilter
+	stickyBase         string
+	stickyView         *tview.TextView
+	tableContent       *tview.Flex
+	sources            *config.SourceStats
+	widths             *config.ColumnWidths
+	decoders           []payload.FieldDecoder
+	severities         *config.SeverityMapper
+	extractor          *config.Extractor
+	pipeline           *pipeline.Pipeline
+	pipelineStale      atomic.Bool
+	fieldsAsRead       bool
+	enricher           *enrich.Enricher
+	watches            []*watchRule
+	watchCounts        *config.WatchCounter
+	showWatches        bool
+	captures           *config.CaptureBuffer
+	rawValues          bool
+	showAggregates     bool
+	heatmapKey         string
+	chartKey           string
+	showMinimap        bool
+	minimap            atomic.Pointer[config.Minimap]
+	matching           atomic.Pointer[filter.Matching]
+	followErrors       bool
+	gluing             bool
+	snapshotName       string
+	streamName         string
+	sortedBy           string
+	sortDesc           bool
+	internals          bool
+	closed             atomic.Bool
 }
 
 func NewLogReader(app *LoggoApp, reader reader.Reader) *LogView {
+	lv := newLogView(app, reader)
+	go func() {
+		lv.app.ShowModal(NewSplashScreen(lv.app), 71, 16, color.ColorBackgroundField, nil)
+		lv.app.Draw()
+		time.Sleep(2 * time.Second)
+		lv.app.DismissModal(lv.table)
+		lv.app.Draw()
+
+		time.Sleep(10 * time.Millisecond)
+		lv.isFollowing = true
+		lv.app.SetFocus(lv.table)
+		lv.templateView.offerDraft(func(d *config.Config) {
+			lv.config.Keys = d.Keys
+			lv.config.Decoders = d.Decoders
+			lv.coverage.Reset()
+			lv.makeLayoutsWithTemplateView()
+		})
+		lv.restoreState()
+	}()
+	return lv
+}
+
+// newLogView builds a live log view streaming from the reader.
+func newLogView(app *LoggoApp, reader reader.Reader) *LogView {
+	return newLogViewWithConfig(app, reader, app.Config())
+}
+
+func newLogViewWithConfig(app *LoggoApp, reader reader.Reader, cfg *config.Config) *LogView {
 	lv := &LogView{
 		Flex:          *tview.NewFlex(),
 		app:           app,
-		config:        app.Config(),
+		config:        cfg,
 		chanReader:    reader,
 		filterChannel: make(chan *filter.Expression, 1),
 		filterLock:    sync.RWMutex{},
 		hideFilter:    true,
 		isFollowing:   true,
+		coverage:      config.NewKeyCoverage(),
+		widths:        config.NewColumnWidths(),
+		bursts:        config.NewBurstDetector(config.DefaultBurstFactor),
+		sources:       config.NewSourceStats(config.DefaultNoisyWindow),
+		secretTally:   secrets.NewTally(),
 	}
 	lv.makeUIComponents()
 	lv.makeLayouts()
+	lv.watchSchemaDrift()
+	lv.watchFlash()
+	lv.watchBursts()
+	lv.watchSecrets()
+	lv.watchMemory()
 	reader.ErrorNotifier(func(err error) {
+		util.Log().WithField("code", err).Error("Input stream failed")
+		lv.app.alert()
 		go func() {
 			time.Sleep(time.Second)
 			lv.app.Draw()
@@ -113,28 +237,18 @@ func NewLogReader(app *LoggoApp, reader reader.Reader) *LogView {
 	lv.read()
 	lv.filter()
 	lv.filterChannel <- nil
-
-	go func() {
-		lv.app.ShowModal(NewSplashScreen(lv.app), 71, 16, color.ColorBackgroundField, nil)
-		lv.app.Draw()
-		time.Sleep(2 * time.Second)
-		lv.app.DismissModal(lv.table)
-		lv.app.Draw()
-
-		time.Sleep(10 * time.Millisecond)
-		lv.isFollowing = true
-		lv.app.SetFocus(lv.table)
-	}()
 	return lv
 }
 
 func (l *LogView) makeUIComponents() {
+	l.history = config.NewHistory[viewEdit](config.DefaultHistoryLimit)
 	l.templateView = NewTemplateView(l.app, false, func() {
 		// Toggle full screen func
 		l.templateFullScreen = !l.templateFullScreen
 		l.makeLayoutsWithTemplateView()
 	}, l.makeLayouts)
-	l.templateView.SetBorder(true).SetTitle("Template Editor")
+	l.templateView.onChange = l.recordTemplateEdit
+	l.templateView.SetBorder(true).SetTitle(i18n.T("Template Editor"))
 	l.data = &LogData{
 		logView: l,
 	}
@@ -146,16 +260,24 @@ func (l *LogView) makeUIComponents() {
 					l.logFullScreen = !l.logFullScreen
 					l.makeLayoutsWithJsonView()
 				}, l.makeLayouts)
-			l.jsonView.SetBorder(true).SetTitle("Log Entry").SetBackgroundColor(color.ColorBackgroundField)
+			l.jsonView.SetBorder(true).SetTitle(i18n.T("Log Entry")).SetBackgroundColor(color.ColorBackgroundField)
+			l.jsonView.matching = l.currentMatching()
 			var b []byte
-			if _, ok := l.finSlice[row-1][config.ParseErr]; ok {
+			if config.IsText(l.finSlice[row-1]) {
 				b = []byte(fmt.Sprintf(`%v`, l.finSlice[row-1][config.TextPayload]))
+				if note, ok := l.finSlice[row-1][config.Note]; ok {
+					b = append(b, []byte(fmt.Sprintf("\n\n%s %v", char.SymNote, note))...)
+				}
+			} else if len(l.decoders) > 0 {
+				b, _ = json.Marshal(payload.DecodeFields(config.Unflatten(l.finSlice[row-1]), l.decoders))
 			} else {
-				b, _ = json.Marshal(l.finSlice[row-1])
+				// flattened entries are shown as read
+				b, _ = json.Marshal(config.Unflatten(l.finSlice[row-1]))
 			}
 			l.jsonView.SetJson(b)
 			l.makeLayoutsWithJsonView()
 			l.updateBottomBarMenu()
+			l.tutorialDone(tutorialEntry)
 		} else {
 			l.makeLayouts()
 		}
@@ -167,13 +289,27 @@ func (l *LogView) makeUIComponents() {
 		SetContent(l.data)
 	l.table.
 		SetFocusFunc(func() {
+			if l.peer != nil {
+				// compared streams take turns on the key bindings
+				l.keyEvents()
+			}
 			if l.isJsonViewShown() {
 				l.updateBottomBarMenu()
 			}
 		})
 	l.table.SetSelectedFunc(selection).
 		SetBackgroundColor(color.ColorBackgroundField)
+	l.table.SetMouseCapture(l.scrollWheel)
 	l.table.SetSelectionChangedFunc(func(row, column int) {
+		if l.gluing {
+			// following the newest error, keep scrolling
+			l.updateLineView()
+			return
+		}
+		if row == 1 {
+			// scrolled to the top, like less, load what precedes it
+			l.backfill()
+		}
 		// stop scrolling!
 		if l.isFollowing {
 			l.isFollowing = false
@@ -196,11 +332,13 @@ func (l *LogView) makeUIComponents() {
 				}()
 			}
 		}
+		l.alignPeer(row)
 	})
 
 	l.keyEvents()
 
 	l.linesView = tview.NewTextView().SetDynamicColors(true).SetTextAlign(tview.AlignRight)
+	l.memView = tview.NewTextView().SetDynamicColors(true).SetTextAlign(tview.AlignRight)
 	l.followingView = tview.NewTextView().
 		SetRegions(true).
 		SetDynamicColors(true)
@@ -210,12 +348,59 @@ func (l *LogView) makeUIComponents() {
 	l.followingView.SetBlurFunc(func() {
 		l.followingView.Highlight("")
 	})
+	l.humanizeView = tview.NewTextView().
+		SetRegions(true).
+		SetDynamicColors(true).
+		SetText(i18n.Markup(humanizeOnMenu))
+	l.aggregatesView = tview.NewTextView().
+		SetRegions(true).
+		SetDynamicColors(true).
+		SetText(i18n.Markup(aggregatesOffMenu))
+	l.followErrorsView = tview.NewTextView().
+		SetRegions(true).
+		SetDynamicColors(true).
+		SetText(i18n.Markup(followErrorsOffMenu))
+	l.footerView = tview.NewTextView().
+		SetDynamicColors(true).
+		SetWrap(false)
+	l.footerView.SetBackgroundColor(color.ColorBackgroundField)
+	l.tabsView = tview.NewTextView().
+		SetRegions(true).
+		SetDynamicColors(true)
+	l.driftView = tview.NewTextView().
+		SetRegions(true).
+		SetDynamicColors(true)
+	l.makePinsView()
+	l.makeTutorialView()
+	l.makeLoadView()
+	l.makeBurstView()
+	l.makeSecretsView()
+	l.makeStickyView()
+	l.makeHeatmapView()
+	l.makeChartView()
+	l.makeMinimapView()
+	l.makeWatchesView()
 	l.populateMenu()
 	l.updateLineView()
 
 	l.filterView = NewFilterView(l.app, func(expression *filter.Expression) {
-		l.rebufferFilter = true
-		l.filterChannel <- expression
+		l.filterExpression = expression
+		if expression == nil && len(l.stickies) > 0 {
+			l.stickies, l.stickyBase = nil, ""
+			l.updateStickyView()
+		}
+		l.requestFilter(expression)
+		if expression != nil {
+			l.tutorialDone(tutorialFilter)
+		}
+		l.recordFilterEdit()
+		if l == l.app.logView && l.app.onFilter != nil {
+			if expression == nil {
+				l.app.onFilter("")
+			} else {
+				l.app.onFilter(l.filterView.Expression())
+			}
+		}
 		go func() {
 			time.Sleep(200 * time.Millisecond)
 			l.app.Draw()
@@ -235,10 +420,69 @@ func (l *LogView) toggleFilter() {
 	}
 }
 
+// currentMatching returns the options this view matches free text and terms
+// with, the default ones until toggled, see filter.CurrentMatching.
+func (l *LogView) currentMatching() filter.Matching {
+	if m := l.matching.Load(); m != nil {
+		return *m
+	}
+	return filter.CurrentMatching()
+}
+
+// cycleCase cycles the local filter and searches between smart, ignoring and
+// matching case.
+func (l *LogView) cycleCase() {
+	m := l.currentMatching()
+	m.Case = m.Case.Next()
+	l.setMatching(m)
+}
+
+// toggleWholeWord toggles matching whole words only in the local filter and
+// searches.
+func (l *LogView) toggleWholeWord() {
+	m := l.currentMatching()
+	m.WholeWord = !m.WholeWord
+	l.setMatching(m)
+}
+
+func (l *LogView) setMatching(m filter.Matching) {
+	l.matching.Store(&m)
+	l.filterView.showMatching(m)
+	l.jsonView.rematch(m)
+	if l.filterExpression != nil {
+		l.requestFilter(l.filterExpression)
+	}
+}
+
 func (l *LogView) makeLayouts() {
+	l.tableContent = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(l.tutorialView, l.tutorialHeight(), 0, false).
+		AddItem(l.loadView, l.loadHeight(), 0, false).
+		AddItem(l.burstView, l.burstHeight(), 0, false).
+		AddItem(l.secretsView, l.secretsHeight(), 0, false).
+		AddItem(l.stickyView, l.stickyHeight(), 0, false).
+		AddItem(l.table, 0, 1, true)
+	if l.heatmapKey != "" {
+		l.tableContent.AddItem(l.heatmapView, heatmapRows+1, 0, false)
+	}
+	if l.chartKey != "" {
+		l.tableContent.AddItem(l.chartView, chartRows+1, 0, false)
+	}
+	if l.showWatches {
+		l.tableContent.AddItem(l.watchesView, l.watchesHeight(), 0, false)
+	}
+	if l.showAggregates {
+		l.tableContent.AddItem(l.footerView, 1, 1, false)
+	}
 	mainContent := tview.NewFlex().SetDirection(tview.FlexColumn).
-		AddItem(l.table, 0, 2, true).
-		AddItem(l.navMenu, 26, 1, false)
+		AddItem(l.tableContent, 0, 2, true)
+	if l.showMinimap {
+		mainContent.AddItem(l.minimapView, minimapWidth, 0, false)
+	}
+	if l.pinsView.GetItemCount() > 0 {
+		mainContent.AddItem(l.pinsView, pinsWidth, 1, false)
+	}
+	mainContent.AddItem(l.navMenu, 26, 1, false)
 
 	l.Flex.Clear().SetDirection(tview.FlexRow)
 	if !l.hideFilter {
@@ -271,8 +515,12 @@ func (l *LogView) isJsonViewShown() bool {
 }
 
 func (l *LogView) toggledFollowing() {
+	if l.isSnapshot() {
+		return
+	}
 	l.isFollowing = !l.isFollowing
 	l.updateLineView()
+	l.tutorialDone(tutorialAutoScroll)
 	go l.app.Draw()
 }
 
@@ -296,15 +544,21 @@ func (l *LogView) makeLayoutsWithJsonView() {
 }
 
 func (l *LogView) makeLayoutsWithTemplateView() {
+	if util.ReadOnly() {
+		go l.app.ShowPopMessage("Template editing is disabled in read-only mode.", 2, l.table)
+		return
+	}
 	l.isFollowing = false
 	l.Flex.Clear().SetDirection(tview.FlexRow)
 	if !l.templateFullScreen {
 		l.Flex.AddItem(l.table, 0, 1, false)
 	}
 	l.templateView.config = l.config
+	l.lastKeys = copyKeys(l.config.Keys)
 	l.Flex.
 		AddItem(l.templateView, 0, 2, false).
 		AddItem(l.mainMenu, 1, 1, false)
 
 	l.app.SetFocus(l.templateView.table)
+	l.tutorialDone(tutorialTemplate)
 }
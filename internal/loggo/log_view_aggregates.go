@@ -0,0 +1,79 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/badaniya/loggo/internal/char"
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/i18n"
+	"github.com/rivo/tview"
+)
+
+// toggleAggregates shows or hides the footer summarising each column over
+// the filtered entries.
+func (l *LogView) toggleAggregates() {
+	l.showAggregates = !l.showAggregates
+	if l.showAggregates {
+		l.aggregatesView.SetText(i18n.Markup(aggregatesOnMenu))
+		l.updateAggregates()
+		l.watchAggregates()
+	} else {
+		l.aggregatesView.SetText(i18n.Markup(aggregatesOffMenu))
+	}
+	if !l.isTemplateViewShown() && !l.isJsonViewShown() {
+		l.makeLayouts()
+	}
+	go l.app.Draw()
+}
+
+// watchAggregates refreshes the footer periodically for as long as it's shown.
+func (l *LogView) watchAggregates() {
+	go func() {
+		for l.showAggregates && !l.closed.Load() {
+			time.Sleep(2 * time.Second)
+			if l.showAggregates && l.updateAggregates() {
+				l.app.Draw()
+			}
+		}
+	}()
+}
+
+func (l *LogView) updateAggregates() bool {
+	l.filterLock.RLock()
+	aggs := config.Aggregate(l.config.Keys, l.finSlice)
+	l.filterLock.RUnlock()
+	parts := make([]string, len(aggs))
+	for i := range aggs {
+		parts[i] = fmt.Sprintf("[::b]%s[::-] %s", tview.Escape(char.Printable(aggs[i].Key.Name, 0)), aggs[i].String())
+	}
+	text := " " + strings.Join(parts, " [::d]│[::-] ")
+	if text == l.footerView.GetText(false) {
+		return false
+	}
+	l.footerView.SetText(text)
+	return true
+}
@@ -0,0 +1,110 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+
+	"github.com/badaniya/loggo/internal/compress"
+	"github.com/badaniya/loggo/internal/i18n"
+	"github.com/badaniya/loggo/internal/reader"
+	"github.com/badaniya/loggo/internal/util"
+)
+
+// backfillLines is how many older lines are fetched at once when scrolling to
+// the top of a tailed file.
+const backfillLines = 1000
+
+// backfill fetches the lines preceding the oldest one buffered, if the source
+// was started past its beginning, e.g. with --tail-lines, handing them to the
+// read routine to be prepended, see prepend.
+func (l *LogView) backfill() {
+	backfiller, ok := l.chanReader.(reader.Backfiller)
+	if !ok || l.backfills == nil || l.backfilledAll.Load() || !l.backfilling.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer l.backfilling.Store(false)
+		lines, err := backfiller.Backfill(backfillLines)
+		if err != nil {
+			util.Log().WithField("code", err).Error("Unable to back-fill older lines")
+			l.app.ShowPopMessage(fmt.Sprintf("%s: %v", i18n.T("Unable to load older lines"), err), 5, l.table)
+			return
+		}
+		if len(lines) == 0 {
+			l.backfilledAll.Store(true)
+			return
+		}
+		l.backfills <- lines
+	}()
+}
+
+// prepend runs back-filled lines through the pipeline, placing the entries
+// before those buffered, along with the filtered ones, so the selection stays
+// onto the same entry with the older ones above it.
+func (l *LogView) prepend(lines []string) {
+	var entries []map[string]interface{}
+	var rawLines []*compress.Text
+	for _, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		if e := l.pipeline.Process(line); e != nil {
+			m, raw := l.retain(e)
+			entries = append(entries, m)
+			rawLines = append(rawLines, raw)
+		}
+	}
+	var filtered []map[string]interface{}
+	for i, m := range entries {
+		var line []byte
+		if rawLines[i] != nil {
+			line = rawLines[i].AppendTo(nil)
+		}
+		if l.filterExpression == nil {
+			filtered = append(filtered, m)
+		} else if a, err := l.filterExpression.ApplyLine(m, line, l.keyMap, l.currentMatching()); err == nil && a {
+			filtered = append(filtered, m)
+		}
+	}
+	l.filterLock.Lock()
+	if l.backfilledAll.Load() {
+		// evicted meanwhile
+		l.filterLock.Unlock()
+		return
+	}
+	l.inSlice = append(entries, l.inSlice...)
+	l.rawLines = append(rawLines, l.rawLines...)
+	l.finSlice = append(filtered, l.finSlice...)
+	l.bufferStart -= len(entries)
+	l.globalCount += int64(len(filtered))
+	l.filterLock.Unlock()
+	if len(filtered) > 0 {
+		row, column := l.table.GetOffset()
+		l.table.SetOffset(row+len(filtered), column)
+		r, c := l.table.GetSelection()
+		l.table.Select(r+len(filtered), c)
+	}
+	l.updateLineView()
+	l.app.Draw()
+}
@@ -0,0 +1,209 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/badaniya/loggo/internal/bundle"
+	"github.com/badaniya/loggo/internal/color"
+	"github.com/badaniya/loggo/internal/parquet"
+	"github.com/badaniya/loggo/internal/secrets"
+	"github.com/badaniya/loggo/internal/util"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// exportFormats are the formats entries can be exported into: a self-contained
+// HTML bundle to share findings with people who don't run loggo, or a Parquet
+// file to analyse them further with e.g. DuckDB or Spark.
+var exportFormats = []struct {
+	name string
+	ext  string
+}{
+	{"HTML bundle", ".html"},
+	{"Parquet", ".parquet"},
+}
+
+// exportSet tells which entries are exported.
+type exportSet int
+
+const (
+	exportFiltered exportSet = iota
+	exportPinned
+	exportMarked
+)
+
+var exportSetLabels = map[exportSet]string{
+	exportFiltered: "Filtered entries (%d)",
+	exportPinned:   "Pinned entries (%d)",
+	exportMarked:   "Marked entries (%d)",
+}
+
+// exportBundle prompts for the format and the file to export the filtered,
+// the pinned or the marked entries into, redacting secrets unless told
+// otherwise.
+func (l *LogView) exportBundle() {
+	l.exportEntries(exportFiltered)
+}
+
+// exportEntries prompts as exportBundle does, the given entries being offered
+// first.
+func (l *LogView) exportEntries(first exportSet) {
+	if util.ReadOnly() {
+		go l.app.ShowPopMessage("Exports are disabled in read-only mode.", 2, l.table)
+		return
+	}
+	l.filterLock.RLock()
+	counts := map[exportSet]int{
+		exportFiltered: len(l.finSlice),
+		exportPinned:   len(l.pins),
+		exportMarked:   len(l.marks),
+	}
+	l.filterLock.RUnlock()
+	if counts[exportFiltered] == 0 && counts[exportPinned] == 0 && counts[exportMarked] == 0 {
+		go l.app.ShowPopMessage("Nothing to export, the buffer is empty.", 2, l.table)
+		return
+	}
+	var options []string
+	var sets []exportSet
+	for _, set := range []exportSet{first, exportFiltered, exportPinned, exportMarked} {
+		if slices.Contains(sets, set) || set != exportFiltered && counts[set] == 0 {
+			continue
+		}
+		options = append(options, fmt.Sprintf(exportSetLabels[set], counts[set]))
+		sets = append(sets, set)
+	}
+	var formatNames []string
+	for _, f := range exportFormats {
+		formatNames = append(formatNames, f.name)
+	}
+	set, format, redact := sets[0], 0, true
+	baseName := fmt.Sprintf("loggo-%s", time.Now().Format("20060102-150405"))
+	form := tview.NewForm()
+	form.AddDropDown("Export", options, 0, func(_ string, index int) {
+		set = sets[index]
+	}).
+		AddDropDown("Format", formatNames, 0, func(_ string, index int) {
+			format = index
+			// the file field isn't added yet upon the initial selection
+			if file, ok := form.GetFormItemByLabel("File").(*tview.InputField); ok {
+				name := file.GetText()
+				file.SetText(strings.TrimSuffix(name, filepath.Ext(name)) + exportFormats[index].ext)
+			}
+		}).
+		AddInputField("File", baseName+exportFormats[0].ext, 50, nil, nil).
+		AddCheckbox("Redact secrets", redact, func(checked bool) {
+			redact = checked
+		}).
+		AddButton("Export", func() {
+			fileName := form.GetFormItemByLabel("File").(*tview.InputField).GetText()
+			l.app.DismissModal(l.table)
+			go l.writeBundle(fileName, set, exportFormats[format].ext == ".parquet", redact)
+		}).
+		AddButton("Cancel", func() {
+			l.app.DismissModal(l.table)
+		})
+	form.SetFieldBackgroundColor(color.ColorBackgroundField).
+		SetFieldTextColor(color.ColorForegroundField).
+		SetBackgroundColor(tcell.ColorDarkBlue)
+	modal := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(tview.NewTextView().
+			SetDynamicColors(true).
+			SetText(`[yellow::b]Export[-::-]`).
+			SetTextAlign(tview.AlignCenter), 1, 1, false).
+		AddItem(form, 0, 1, true)
+	modal.SetBackgroundColor(tcell.ColorDarkBlue)
+	l.app.ShowModal(modal, 70, 14, tcell.ColorDarkBlue, func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			l.app.DismissModal(l.table)
+			return nil
+		}
+		return event
+	})
+	l.app.SetFocus(form)
+}
+
+// writeBundle writes either the self-contained HTML bundle, rendered as the
+// table is, or the Parquet file with a column per template key.
+func (l *LogView) writeBundle(fileName string, set exportSet, asParquet, redact bool) {
+	f, err := os.Create(fileName)
+	if err == nil {
+		entries := l.exportedEntries(set)
+		if redact {
+			entries = secrets.RedactEntries(entries)
+		}
+		if asParquet {
+			err = parquet.Write(f, l.config.Keys, entries)
+		} else {
+			err = bundle.WriteHTML(f, entries, bundle.Options{
+				Title:     l.bundleTitle(),
+				Config:    l.config,
+				Raw:       l.rawValues,
+				ColorWhen: l.colorWhen,
+			})
+		}
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		util.Log().WithField("code", err).Error("Unable to export")
+		l.app.ShowPopMessage(fmt.Sprintf("Unable to export: %v", err), 5, l.table)
+		return
+	}
+	l.app.ShowPopMessage(fmt.Sprintf("Exported to %s", fileName), 3, l.table)
+}
+
+// exportedEntries copies the entries of the set, so that neither the stream nor
+// notes taken meanwhile wait for the file to be written.
+func (l *LogView) exportedEntries(set exportSet) []map[string]interface{} {
+	l.filterLock.RLock()
+	defer l.filterLock.RUnlock()
+	entries := l.finSlice
+	switch set {
+	case exportPinned:
+		entries = l.pins
+	case exportMarked:
+		entries = l.marks
+	}
+	copied := make([]map[string]interface{}, len(entries))
+	for i, e := range entries {
+		// notes are set on the entry itself
+		copied[i] = maps.Clone(e)
+	}
+	return copied
+}
+
+func (l *LogView) bundleTitle() string {
+	if l.isSnapshot() {
+		return fmt.Sprintf("l'oGGo %s", l.snapshotName)
+	}
+	return "l'oGGo export"
+}
@@ -0,0 +1,138 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/rivo/tview"
+)
+
+const burstBanner = `[white:darkred:b] ⚡ Error burst [::-]%d errors within %s (%s) since %s   ` +
+	`["jump"][yellow::b]b[white::-] [::u]jump to its start[::-][""]   ["dismiss"][yellow::b]B[white::-] [::u]dismiss[::-][""] `
+
+// makeBurstView builds the banner shown above the table upon an error burst,
+// clicking its actions either jumps to the burst start or dismisses it.
+func (l *LogView) makeBurstView() {
+	l.burstView = tview.NewTextView().
+		SetRegions(true).
+		SetDynamicColors(true).
+		SetWrap(false)
+	l.burstView.SetHighlightedFunc(func(added, removed, remaining []string) {
+		if len(added) == 0 {
+			return
+		}
+		l.burstView.Highlight()
+		switch added[0] {
+		case "jump":
+			l.jumpToBurst()
+		case "dismiss":
+			l.dismissBurst()
+		}
+	})
+}
+
+// watchBursts periodically checks for error bursts, showing the banner (and
+// alerting, see LoggoApp.alert) whenever one is detected.
+func (l *LogView) watchBursts() {
+	go func() {
+		var shown *config.Burst
+		for !l.closed.Load() {
+			time.Sleep(time.Second)
+			burst := l.bursts.Burst()
+			if shown == nil && burst == nil ||
+				shown != nil && burst != nil && sameEntry(shown.Start, burst.Start) && shown.Errors == burst.Errors {
+				continue
+			}
+			if burst != nil && (shown == nil || !sameEntry(shown.Start, burst.Start)) {
+				l.app.alert()
+			}
+			shown = burst
+			l.updateBurstView(burst)
+			l.app.Draw()
+		}
+	}()
+}
+
+func (l *LogView) updateBurstView(burst *config.Burst) {
+	if burst == nil {
+		l.burstView.SetText("")
+		l.tableContent.ResizeItem(l.burstView, 0, 0)
+		return
+	}
+	rate := "no errors before"
+	if !math.IsInf(burst.Ratio, 1) {
+		rate = fmt.Sprintf("%.1f× the usual rate", burst.Ratio)
+	}
+	l.burstView.SetText(fmt.Sprintf(burstBanner, burst.Errors, config.BurstWindow, rate, burst.At.Format("15:04:05")))
+	l.tableContent.ResizeItem(l.burstView, 1, 0)
+}
+
+// burstHeight is the banner height, none unless a burst is shown.
+func (l *LogView) burstHeight() int {
+	if len(l.burstView.GetText(false)) == 0 {
+		return 0
+	}
+	return 1
+}
+
+// jumpToBurst selects the oldest error of the burst window and dismisses the
+// banner, unless the entry is filtered out.
+func (l *LogView) jumpToBurst() {
+	if l.bursts == nil {
+		return
+	}
+	burst := l.bursts.Burst()
+	if burst == nil {
+		return
+	}
+	l.filterLock.RLock()
+	line := -1
+	for n, row := range l.finSlice {
+		if sameEntry(row, burst.Start) {
+			line = n + 1
+			break
+		}
+	}
+	l.filterLock.RUnlock()
+	if line < 0 {
+		go l.app.ShowPopMessage("The burst start is filtered out of view.", 2, l.table)
+		return
+	}
+	l.dismissBurst()
+	l.isFollowing = false
+	l.updateLineView()
+	l.table.Select(line, 0)
+	l.app.SetFocus(l.table)
+}
+
+func (l *LogView) dismissBurst() {
+	if l.bursts == nil {
+		return
+	}
+	l.bursts.Dismiss()
+	l.updateBurstView(nil)
+}
@@ -0,0 +1,153 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/badaniya/loggo/internal/char"
+	"github.com/badaniya/loggo/internal/color"
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/i18n"
+	"github.com/rivo/tview"
+)
+
+const (
+	chartRows       = 5
+	chartLabelWidth = 9
+	chartMinColumns = 10
+	chartColumns    = 60
+)
+
+func (l *LogView) makeChartView() {
+	l.chartView = tview.NewTextView().
+		SetDynamicColors(true).
+		SetWrap(false)
+	l.chartView.SetBackgroundColor(color.ColorBackgroundField)
+	l.chartMenuView = tview.NewTextView().
+		SetRegions(true).
+		SetDynamicColors(true).
+		SetText(i18n.Markup(chartOffMenu))
+}
+
+// cycleChart charts the next number, byte size or duration key of the
+// template, hiding the chart past the last one.
+func (l *LogView) cycleChart() {
+	keys := l.config.HeatmapKeys()
+	if len(keys) == 0 {
+		go l.app.ShowPopMessage("The template has no number, bytes or duration key.", 2, l.table)
+		return
+	}
+	wasShown := l.chartKey != ""
+	next := ""
+	if !wasShown {
+		next = keys[0].Name
+	} else {
+		for i := range keys[:len(keys)-1] {
+			if keys[i].Name == l.chartKey {
+				next = keys[i+1].Name
+			}
+		}
+	}
+	l.chartKey = next
+	if next == "" {
+		l.chartMenuView.SetText(i18n.Markup(chartOffMenu))
+	} else {
+		l.chartMenuView.SetText(fmt.Sprintf(i18n.Markup(chartOnMenu), ellipsize(next, 10)))
+		l.updateChart()
+		if !wasShown {
+			l.watchChart()
+		}
+	}
+	if !l.isTemplateViewShown() && !l.isJsonViewShown() {
+		l.makeLayouts()
+	}
+	go l.app.Draw()
+}
+
+// watchChart refreshes the chart every second for as long as it's shown.
+func (l *LogView) watchChart() {
+	go func() {
+		for l.chartKey != "" && !l.closed.Load() {
+			time.Sleep(time.Second)
+			if l.chartKey != "" && l.updateChart() {
+				l.app.Draw()
+			}
+		}
+	}()
+}
+
+func (l *LogView) updateChart() bool {
+	var key *config.Key
+	for i := range l.config.Keys {
+		if l.config.Keys[i].Name == l.chartKey {
+			key = &l.config.Keys[i]
+		}
+	}
+	if key == nil {
+		return false
+	}
+	_, _, width, _ := l.chartView.GetInnerRect()
+	cols := width - chartLabelWidth - 2
+	if cols < chartMinColumns {
+		cols = chartColumns
+	}
+	// two values per braille cell
+	l.filterLock.RLock()
+	ch := l.config.MakeChart(key, l.finSlice, cols*2)
+	l.filterLock.RUnlock()
+	text := fmt.Sprintf("[::d] Not enough timed %s values to draw a chart.", key.Name)
+	if ch != nil {
+		text = renderChart(ch, cols)
+	}
+	if text == l.chartView.GetText(false) {
+		return false
+	}
+	l.chartView.SetText(text)
+	return true
+}
+
+// renderChart draws the averages as a braille line, labelling the top and
+// bottom rows with the highest and lowest averages, the time range under the
+// line and the latest value after it.
+func renderChart(ch *config.Chart, cols int) string {
+	var b strings.Builder
+	lines := char.BrailleLine(ch.Values, ch.Min, ch.Max, cols, chartRows)
+	for i, line := range lines {
+		label := ""
+		switch i {
+		case 0:
+			label = ch.Format(ch.Max)
+		case len(lines) - 1:
+			label = ch.Format(ch.Min)
+		}
+		b.WriteString(fmt.Sprintf("[::d]%*s ┤[-::-][green]%s[-]\n", chartLabelWidth, ellipsize(label, chartLabelWidth), line))
+	}
+	from, to := ch.From.Format("15:04:05"), ch.To.Format("15:04:05")
+	gap := max(cols-len(from)-len(to), 1)
+	b.WriteString(fmt.Sprintf("[::b]%*s[::d] └%s%s%s [-::b]%s",
+		chartLabelWidth, ellipsize(ch.Key.Name, chartLabelWidth), from, strings.Repeat(" ", gap), to, ch.Format(ch.Last)))
+	return b.String()
+}
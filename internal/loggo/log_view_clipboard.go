@@ -0,0 +1,87 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/badaniya/loggo/internal/config"
+)
+
+// copySelected copies the selected entry to the clipboard, without relying
+// on the terminal selecting text, which Windows consoles can't do while the
+// app reads the mouse.
+func (l *LogView) copySelected() {
+	r, _ := l.table.GetSelection()
+	l.filterLock.RLock()
+	if r <= 0 || r-1 >= len(l.finSlice) {
+		l.filterLock.RUnlock()
+		return
+	}
+	row := l.finSlice[r-1]
+	l.filterLock.RUnlock()
+	l.copyEntries([]map[string]interface{}{row})
+}
+
+// copyEntries copies the entries to the clipboard, see entryLines.
+func (l *LogView) copyEntries(rows []map[string]interface{}) {
+	l.filterLock.RLock()
+	lines := entryLines(rows)
+	l.filterLock.RUnlock()
+	if err := clipboard.WriteAll(lines); err != nil {
+		go l.app.ShowPopMessage(fmt.Sprintf("Unable to copy: %v", err), 3, l.table)
+		return
+	}
+	if len(rows) == 1 {
+		go l.app.ShowPopMessage("Copied the entry to clipboard", 2, l.table)
+		return
+	}
+	go l.app.ShowPopMessage(fmt.Sprintf("Copied %d entries to clipboard", len(rows)), 2, l.table)
+}
+
+// entryLines renders the entries as JSON lines, or as the text read for lines
+// which weren't JSON.
+func entryLines(rows []map[string]interface{}) string {
+	var sb strings.Builder
+	for _, row := range rows {
+		if config.IsText(row) {
+			sb.WriteString(fmt.Sprintf("%v", row[config.TextPayload]))
+		} else {
+			b, _ := json.Marshal(config.Unflatten(row))
+			sb.Write(b)
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// selectionCopies tells whether the selection mode copies the selected entry
+// rather than letting the terminal select text: Windows consoles don't select
+// text while the app reads the input.
+func selectionCopies() bool {
+	return runtime.GOOS == "windows"
+}
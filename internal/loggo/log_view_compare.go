@@ -0,0 +1,111 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/badaniya/loggo/internal/reader"
+	"github.com/rivo/tview"
+)
+
+// NewLoggoCompareApp builds an app streaming two sources side by side, e.g. a
+// canary and a baseline deployment. Selecting an entry on one side selects
+// the entry closest in time on the other, according to the first datetime key
+// of their templates.
+func NewLoggoCompareApp(left, right reader.Reader, leftName, rightName, configFile string) *LoggoApp {
+	app := NewApp(configFile)
+	lapp := &LoggoApp{
+		appScaffold: *app,
+		chanReader:  left,
+	}
+
+	lapp.logView = NewLogReader(lapp, left)
+	lapp.peerView = newLogView(lapp, right)
+	lapp.logView.peer, lapp.peerView.peer = lapp.peerView, lapp.logView
+	lapp.logView.setCompareTitle(leftName)
+	lapp.peerView.setCompareTitle(rightName)
+	lapp.split = tview.NewFlex().SetDirection(tview.FlexColumn).
+		AddItem(lapp.logView, 0, 1, true).
+		AddItem(lapp.peerView, 0, 1, false)
+	lapp.views = []*LogView{lapp.logView}
+	lapp.logView.updateTabsView(lapp.views, 0)
+	lapp.peerView.updateTabsView(lapp.views, 0)
+	lapp.logView.keyEvents()
+
+	lapp.pages = tview.NewPages().
+		AddPage("background", lapp.split, true, true)
+
+	return lapp
+}
+
+func (l *LogView) setCompareTitle(name string) {
+	l.table.SetBorder(true).
+		SetTitle(fmt.Sprintf(" %s [yellow::b]^o[-::-] switch ", tview.Escape(name)))
+}
+
+// alignPeer selects, on the compared stream, the entry closest in time to the
+// one selected here.
+func (l *LogView) alignPeer(row int) {
+	if l.peer == nil || !l.table.HasFocus() {
+		return
+	}
+	l.filterLock.RLock()
+	var at time.Time
+	ok := false
+	if row > 0 && row-1 < len(l.finSlice) {
+		at, ok = l.config.EntryTime(l.finSlice[row-1])
+	}
+	l.filterLock.RUnlock()
+	if ok {
+		l.peer.alignTo(at)
+	}
+}
+
+// alignTo selects the entry closest in time to at, pausing the auto-scroll.
+func (l *LogView) alignTo(at time.Time) {
+	l.filterLock.RLock()
+	line := -1
+	var closest time.Duration
+	for i, row := range l.finSlice {
+		t, ok := l.config.EntryTime(row)
+		if !ok {
+			continue
+		}
+		gap := t.Sub(at)
+		if gap < 0 {
+			gap = -gap
+		}
+		if line < 0 || gap < closest {
+			line, closest = i+1, gap
+		}
+	}
+	l.filterLock.RUnlock()
+	if line < 0 {
+		return
+	}
+	l.isFollowing = false
+	l.updateLineView()
+	l.table.Select(line, 0)
+}
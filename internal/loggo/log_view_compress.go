@@ -0,0 +1,68 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"strings"
+
+	"github.com/badaniya/loggo/internal/compress"
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/pipeline"
+)
+
+// retain keeps the line of a text entry compressed as it's buffered, as it
+// stays around for as long as the stream is open and text-heavy streams
+// retain mostly that. The line is decompressed whenever rendered, see
+// compress.Text. It also returns the line the entry was read from, free text
+// being searched in it rather than in the entry, unless the pipeline altered
+// the keys or values or the line escapes characters, see
+// filter.Expression.ApplyLine.
+func (l *LogView) retain(e *pipeline.Entry) (map[string]interface{}, *compress.Text) {
+	m := e.Fields
+	if l.texts == nil {
+		l.texts = compress.NewStore()
+	}
+	if !config.IsText(m) {
+		if !l.fieldsAsRead || strings.IndexByte(e.Line, '\\') >= 0 {
+			return m, nil
+		}
+		return m, l.texts.Add(e.Line)
+	}
+	line, ok := m[config.TextPayload].(string)
+	if !ok {
+		return m, nil
+	}
+	text := l.texts.Add(line)
+	m[config.TextPayload] = text
+	return m, text
+}
+
+// rawLine returns the line the buffered entry at index was read from, nil
+// if not kept, see retain.
+func (l *LogView) rawLine(index int) []byte {
+	if index >= len(l.rawLines) || l.rawLines[index] == nil {
+		return nil
+	}
+	l.lineBuffer = l.rawLines[index].AppendTo(l.lineBuffer[:0])
+	return l.lineBuffer
+}
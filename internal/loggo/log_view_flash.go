@@ -0,0 +1,136 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/i18n"
+	"github.com/gdamore/tcell/v2"
+)
+
+// flashDuration is how long newly arrived rows stay highlighted while
+// auto-scrolling.
+const flashDuration = time.Second
+
+// flashColors fade the background of newly arrived rows, brightest first,
+// over flashDuration.
+var flashColors = []tcell.Color{
+	tcell.NewRGBColor(96, 96, 32),
+	tcell.NewRGBColor(64, 64, 28),
+	tcell.NewRGBColor(40, 40, 24),
+}
+
+// markArrival records when the entry was received, so it can be flashed.
+func (l *LogView) markArrival(row map[string]interface{}) {
+	l.arrivalsLock.Lock()
+	defer l.arrivalsLock.Unlock()
+	if l.arrivals == nil {
+		l.arrivals = make(map[uintptr]time.Time)
+	}
+	l.arrivals[reflect.ValueOf(row).Pointer()] = time.Now()
+}
+
+// flashColor returns the background of the row if it's newly arrived and
+// auto-scroll is on.
+func (l *LogView) flashColor(row map[string]interface{}) (tcell.Color, bool) {
+	if !l.isFollowing {
+		return tcell.ColorDefault, false
+	}
+	l.arrivalsLock.Lock()
+	at, ok := l.arrivals[reflect.ValueOf(row).Pointer()]
+	l.arrivalsLock.Unlock()
+	if !ok {
+		return tcell.ColorDefault, false
+	}
+	age := time.Since(at)
+	if age >= flashDuration {
+		return tcell.ColorDefault, false
+	}
+	return flashColors[int(age*time.Duration(len(flashColors))/flashDuration)], true
+}
+
+// watchFlash redraws the flashing rows as they fade, forgetting them once
+// they did.
+func (l *LogView) watchFlash() {
+	go func() {
+		step := flashDuration / time.Duration(len(flashColors))
+		for !l.closed.Load() {
+			time.Sleep(step)
+			l.arrivalsLock.Lock()
+			flashing := len(l.arrivals) > 0
+			for p, at := range l.arrivals {
+				if time.Since(at) >= flashDuration {
+					delete(l.arrivals, p)
+				}
+			}
+			l.arrivalsLock.Unlock()
+			if flashing && l.isFollowing {
+				l.app.Draw()
+			}
+		}
+	}()
+}
+
+// toggleFollowErrors glues, while auto-scrolling, the selection to the newest
+// ERROR entry rather than to the bottom of the stream.
+func (l *LogView) toggleFollowErrors() {
+	l.followErrors = !l.followErrors
+	if l.followErrors {
+		l.followErrorsView.SetText(i18n.Markup(followErrorsOnMenu))
+	} else {
+		l.followErrorsView.SetText(i18n.Markup(followErrorsOffMenu))
+	}
+	go l.app.Draw()
+}
+
+// followLatest scrolls to the newest entry or, when following errors, selects
+// the newest ERROR entry.
+func (l *LogView) followLatest() {
+	if l.followErrors {
+		if row := l.newestError(); row > 0 {
+			r, _ := l.table.GetSelection()
+			if r != row {
+				l.gluing = true
+				l.table.Select(row, 0)
+				l.gluing = false
+			}
+			return
+		}
+	}
+	l.table.ScrollToEnd()
+}
+
+// newestError returns the table row of the newest ERROR entry, or -1.
+func (l *LogView) newestError() int {
+	l.filterLock.RLock()
+	defer l.filterLock.RUnlock()
+	for i := len(l.finSlice) - 1; i >= 0; i-- {
+		if l.severities.Severity(l.finSlice[i]) == config.SeverityError {
+			return i + 1
+		}
+	}
+	return -1
+}
@@ -0,0 +1,155 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/badaniya/loggo/internal/color"
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/i18n"
+	"github.com/rivo/tview"
+)
+
+const (
+	heatmapRows       = 6
+	heatmapLabelWidth = 9
+	heatmapMinColumns = 10
+	heatmapColumns    = 60
+)
+
+// heatmapShades renders cells from the least to the most populated.
+var heatmapShades = []string{"[green]░", "[yellow]▒", "[orange]▓", "[red]█"}
+
+func (l *LogView) makeHeatmapView() {
+	l.heatmapView = tview.NewTextView().
+		SetDynamicColors(true).
+		SetWrap(false)
+	l.heatmapView.SetBackgroundColor(color.ColorBackgroundField)
+	l.heatmapMenuView = tview.NewTextView().
+		SetRegions(true).
+		SetDynamicColors(true).
+		SetText(i18n.Markup(heatmapOffMenu))
+}
+
+// cycleHeatmap shows the heatmap of the next number, byte size or duration
+// key of the template, hiding it past the last one.
+func (l *LogView) cycleHeatmap() {
+	keys := l.config.HeatmapKeys()
+	if len(keys) == 0 {
+		go l.app.ShowPopMessage("The template has no number, bytes or duration key.", 2, l.table)
+		return
+	}
+	wasShown := l.heatmapKey != ""
+	next := ""
+	if !wasShown {
+		next = keys[0].Name
+	} else {
+		for i := range keys[:len(keys)-1] {
+			if keys[i].Name == l.heatmapKey {
+				next = keys[i+1].Name
+			}
+		}
+	}
+	l.heatmapKey = next
+	if next == "" {
+		l.heatmapMenuView.SetText(i18n.Markup(heatmapOffMenu))
+	} else {
+		l.heatmapMenuView.SetText(fmt.Sprintf(i18n.Markup(heatmapOnMenu), ellipsize(next, 8)))
+		l.updateHeatmap()
+		if !wasShown {
+			l.watchHeatmap()
+		}
+	}
+	if !l.isTemplateViewShown() && !l.isJsonViewShown() {
+		l.makeLayouts()
+	}
+	go l.app.Draw()
+}
+
+// watchHeatmap refreshes the heatmap periodically for as long as it's shown.
+func (l *LogView) watchHeatmap() {
+	go func() {
+		for l.heatmapKey != "" && !l.closed.Load() {
+			time.Sleep(2 * time.Second)
+			if l.heatmapKey != "" && l.updateHeatmap() {
+				l.app.Draw()
+			}
+		}
+	}()
+}
+
+func (l *LogView) updateHeatmap() bool {
+	var key *config.Key
+	for i := range l.config.Keys {
+		if l.config.Keys[i].Name == l.heatmapKey {
+			key = &l.config.Keys[i]
+		}
+	}
+	if key == nil {
+		return false
+	}
+	_, _, width, _ := l.heatmapView.GetInnerRect()
+	cols := width - heatmapLabelWidth - 2
+	if cols < heatmapMinColumns {
+		cols = heatmapColumns
+	}
+	l.filterLock.RLock()
+	h := l.config.MakeHeatmap(key, l.finSlice, cols, heatmapRows)
+	l.filterLock.RUnlock()
+	text := fmt.Sprintf("[::d] Not enough timed %s values to draw a heatmap.", key.Name)
+	if h != nil {
+		text = renderHeatmap(h)
+	}
+	if text == l.heatmapView.GetText(false) {
+		return false
+	}
+	l.heatmapView.SetText(text)
+	return true
+}
+
+// renderHeatmap draws the highest values on top, with each row labelled by
+// its lowest value and the time range under the last one.
+func renderHeatmap(h *config.Heatmap) string {
+	var b strings.Builder
+	for row := len(h.Counts) - 1; row >= 0; row-- {
+		label := ellipsize(h.Format(h.RowFloor(row)), heatmapLabelWidth)
+		b.WriteString(fmt.Sprintf("[::d]%*s │[-::-]", heatmapLabelWidth, label))
+		for _, count := range h.Counts[row] {
+			if count == 0 {
+				b.WriteString(" ")
+				continue
+			}
+			level := (count*len(heatmapShades) - 1) / h.MaxCount
+			b.WriteString(heatmapShades[level])
+		}
+		b.WriteString("[-]\n")
+	}
+	from, to := h.From.Format("15:04:05"), h.To.Format("15:04:05")
+	gap := max(len(h.Counts[0])-len(from)-len(to), 1)
+	b.WriteString(fmt.Sprintf("[::b]%*s[::d] └%s%s%s",
+		heatmapLabelWidth, ellipsize(h.Key.Name, heatmapLabelWidth), from, strings.Repeat(" ", gap), to))
+	return b.String()
+}
@@ -0,0 +1,146 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/badaniya/loggo/internal/config"
+)
+
+// filterState is the local filter of a view along with the values it follows.
+type filterState struct {
+	expression string
+	stickies   []stickyFilter
+	stickyBase string
+}
+
+// viewEdit is an undoable edit of either the filter or the template keys,
+// holding the state before and after it.
+type viewEdit struct {
+	template                bool
+	filterBefore, filterNow filterState
+	keysBefore, keysNow     []config.Key
+}
+
+func (l *LogView) currentFilter() filterState {
+	return filterState{
+		expression: strings.TrimSpace(l.filterView.Expression()),
+		stickies:   append([]stickyFilter(nil), l.stickies...),
+		stickyBase: l.stickyBase,
+	}
+}
+
+// copyKeys copies the template keys deep enough for later edits, made in
+// place by the template editor, not to alter the copy.
+func copyKeys(keys []config.Key) []config.Key {
+	c := make([]config.Key, len(keys))
+	for i, k := range keys {
+		k.ColorWhen = append([]config.ColorWhen(nil), k.ColorWhen...)
+		c[i] = k
+	}
+	return c
+}
+
+// recordFilterEdit makes the filter change undoable, unless it's being
+// restored or didn't change.
+func (l *LogView) recordFilterEdit() {
+	if l.restoring {
+		return
+	}
+	now := l.currentFilter()
+	if reflect.DeepEqual(now, l.lastFilter) {
+		return
+	}
+	l.history.Push(viewEdit{filterBefore: l.lastFilter, filterNow: now})
+	l.lastFilter = now
+}
+
+// recordTemplateEdit makes the template change, e.g. a deleted column,
+// undoable unless it didn't change.
+func (l *LogView) recordTemplateEdit() {
+	now := copyKeys(l.config.Keys)
+	if reflect.DeepEqual(now, l.lastKeys) {
+		return
+	}
+	l.history.Push(viewEdit{template: true, keysBefore: l.lastKeys, keysNow: now})
+	l.lastKeys = now
+	l.coverage.Reset()
+	l.templateChanged()
+}
+
+// undo reverts the latest filter or template edit.
+func (l *LogView) undo() {
+	edit, ok := l.history.Undo()
+	if !ok {
+		go l.app.ShowPopMessage("Nothing to undo.", 1, l.table)
+		return
+	}
+	if edit.template {
+		l.restoreKeys(edit.keysBefore)
+	} else {
+		l.restoreFilter(edit.filterBefore)
+	}
+}
+
+// redo reapplies the latest undone edit.
+func (l *LogView) redo() {
+	edit, ok := l.history.Redo()
+	if !ok {
+		go l.app.ShowPopMessage("Nothing to redo.", 1, l.table)
+		return
+	}
+	if edit.template {
+		l.restoreKeys(edit.keysNow)
+	} else {
+		l.restoreFilter(edit.filterNow)
+	}
+}
+
+func (l *LogView) restoreFilter(state filterState) {
+	l.restoring = true
+	defer func() { l.restoring = false }()
+	if len(state.expression) == 0 {
+		l.filterView.Clear()
+	} else {
+		l.filterView.Apply(state.expression)
+	}
+	l.stickies = append([]stickyFilter(nil), state.stickies...)
+	l.stickyBase = state.stickyBase
+	l.updateStickyView()
+	l.lastFilter = state
+	go l.app.Draw()
+}
+
+func (l *LogView) restoreKeys(keys []config.Key) {
+	l.config.Keys = copyKeys(keys)
+	l.lastKeys = keys
+	l.coverage.Reset()
+	l.templateChanged()
+	l.templateView.saveDraft()
+	if l.isTemplateViewShown() {
+		l.templateView.makeLayouts()
+	}
+	go l.app.Draw()
+}
@@ -0,0 +1,100 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/badaniya/loggo/internal/char"
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// lineNumberWidth is about the width of the line number column, which is
+// fixed while scrolling horizontally.
+const lineNumberWidth = 12
+
+// scrollColumns scrolls the table by the given number of columns, the line
+// number one staying in place, so that wide rows can be read without a mouse.
+func (l *LogView) scrollColumns(delta int) {
+	r, c := l.table.GetOffset()
+	c = max(min(c+delta, len(l.config.Keys)-1), 0)
+	l.table.SetOffset(r, c)
+}
+
+// scrollWheel scrolls the table columns with the mouse wheel while shift is
+// held, e.g. on Windows where there's no option key to scroll with.
+func (l *LogView) scrollWheel(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+	if event == nil || event.Modifiers()&tcell.ModShift == 0 {
+		return action, event
+	}
+	switch action {
+	case tview.MouseScrollUp, tview.MouseScrollLeft:
+		l.scrollColumns(-1)
+		return action, nil
+	case tview.MouseScrollDown, tview.MouseScrollRight:
+		l.scrollColumns(1)
+		return action, nil
+	}
+	return action, event
+}
+
+// columnsHidden tells whether columns are scrolled out of view on the left
+// or, as estimated from the column widths, on the right.
+func (l *LogView) columnsHidden() (left, right bool) {
+	_, _, width, _ := l.table.GetInnerRect()
+	_, offset := l.table.GetOffset()
+	used := lineNumberWidth
+	for i := offset; i < len(l.config.Keys); i++ {
+		used += l.columnWidth(&l.config.Keys[i]) + 1
+		if used > width {
+			return offset > 0, true
+		}
+	}
+	return offset > 0, false
+}
+
+// columnWidth estimates the width of the key column, unlimited ones taking
+// as much as the widest auto-width ones.
+func (l *LogView) columnWidth(k *config.Key) int {
+	width := l.widths.Width(k)
+	if width == 0 {
+		width = config.AutoWidthMax
+	}
+	return max(width, utf8.RuneCountInString(k.Name)) + 2
+}
+
+// lineNumberHeader heads the line number column, pointing at the columns
+// scrolled out of view, if any.
+func (l *LogView) lineNumberHeader() string {
+	left, right := l.columnsHidden()
+	arrow := func(hidden bool, sym string) string {
+		if hidden {
+			return sym
+		}
+		return " "
+	}
+	return fmt.Sprintf("[yellow]%s Line # %s", arrow(left, char.SymLeft), arrow(right, char.SymRight))
+}
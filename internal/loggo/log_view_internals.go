@@ -0,0 +1,79 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"sync"
+
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/filter"
+	"github.com/badaniya/loggo/internal/reader"
+	"github.com/rivo/tview"
+)
+
+const (
+	internalsMenu      = `[yellow:default:b] ^d      [-:default:u]["1"]Internals[""]`
+	closeInternalsMenu = `[yellow:default:b] ^w      [-:default:u]["1"]Close Internals[""]`
+	internalsTabName   = "Internals"
+)
+
+// NewInternalsView builds a live log view over l'oGGo's own log, so reader
+// disconnections or template errors can be investigated without leaving the app.
+func NewInternalsView(app *LoggoApp) *LogView {
+	cfg, keyMap := config.MakeConfigFromSample([]map[string]interface{}{{
+		"timestamp": "", "level": "", "func": "", "line": 0, "msg": "", "code": "",
+	}})
+	lv := &LogView{
+		Flex:          *tview.NewFlex(),
+		app:           app,
+		config:        cfg,
+		keyMap:        keyMap,
+		chanReader:    reader.MakeInternalReader(nil),
+		filterChannel: make(chan *filter.Expression, 1),
+		filterLock:    sync.RWMutex{},
+		hideFilter:    true,
+		isFollowing:   true,
+		internals:     true,
+		coverage:      config.NewKeyCoverage(),
+		widths:        config.NewColumnWidths(),
+	}
+	lv.makeUIComponents()
+	lv.makeLayouts()
+	lv.watchFlash()
+	lv.read()
+	lv.filter()
+	lv.filterChannel <- nil
+	return lv
+}
+
+// showInternals brings the internals tab to the foreground, opening it if needed.
+func (a *LoggoApp) showInternals() {
+	for i, v := range a.views {
+		if v.internals {
+			a.showView(i)
+			return
+		}
+	}
+	a.views = append(a.views, NewInternalsView(a))
+	a.showView(len(a.views) - 1)
+}
@@ -29,57 +29,30 @@ import (
 	"github.com/rivo/tview"
 )
 
+// keyEvents dispatches the keys to the actions they are bound to, see
+// keyBindings.
 func (l *LogView) keyEvents() {
+	handlers := l.keyHandlers()
 	l.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		if l.app.inputCapture != nil {
 			return l.app.inputCapture(event)
 		}
-		switch event.Key() {
-		case tcell.KeyCtrlN:
-			l.toggleSelectionMouse()
-			return nil
-		case tcell.KeyCtrlA:
-			go func() {
-				l.showAbout()
-			}()
-			return nil
-		case tcell.KeyCtrlT:
-			l.makeLayoutsWithTemplateView()
-			return nil
-		case tcell.KeyCtrlSpace:
-			l.toggledFollowing()
-			return nil
-		case tcell.KeyTAB:
-			if l.isJsonViewShown() {
-				if l.jsonView.textView.HasFocus() {
-					l.app.SetFocus(l.table)
-					go func() {
-						time.Sleep(time.Millisecond)
-						l.updateBottomBarMenu()
-					}()
-				} else {
-					l.app.SetFocus(l.jsonView.textView)
-					go func() {
-						time.Sleep(time.Millisecond)
-						l.updateBottomBarMenu()
-					}()
-				}
+		prim := l.app.app.GetFocus()
+		_, typing := prim.(*tview.InputField)
+		for _, b := range keyBindings {
+			if !b.matches(event) ||
+				b.scope == scopeView && typing ||
+				b.scope == scopeTable && prim != l.table ||
+				b.scope == scopeInput && !typing {
+				continue
+			}
+			if handlers[b.action]() {
 				return nil
 			}
-			return event
-		}
-		prim := l.app.app.GetFocus()
-		if _, ok := prim.(*tview.InputField); ok {
-			return event
-		}
-		switch event.Rune() {
-		case ':':
-			l.toggleFilter()
-			return nil
 		}
 		if prim == l.table && l.isJsonViewShown() {
 			switch event.Rune() {
-			case 'f', '`', 's', 'r', 'g', 'G', 'w', 'x':
+			case 'f', '`', 's', 'r', 'g', 'G', 'w', 'x', 'p':
 				return l.jsonView.textView.GetInputCapture()(event)
 			}
 		}
@@ -87,3 +60,16 @@ func (l *LogView) keyEvents() {
 		return event
 	})
 }
+
+// switchFocus moves the focus between the stream table and the log entry.
+func (l *LogView) switchFocus() {
+	if l.jsonView.textView.HasFocus() {
+		l.app.SetFocus(l.table)
+	} else {
+		l.app.SetFocus(l.jsonView.textView)
+	}
+	go func() {
+		time.Sleep(time.Millisecond)
+		l.updateBottomBarMenu()
+	}()
+}
@@ -0,0 +1,358 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/badaniya/loggo/internal/i18n"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"gopkg.in/yaml.v3"
+)
+
+const keymapMenu = `[yellow:default:b] ?       [-:default:u]["1"]Keybindings[""]`
+
+// keyScope tells where a key binding is active.
+type keyScope int
+
+const (
+	// scopeGlobal bindings are active anywhere, even while typing.
+	scopeGlobal keyScope = iota
+	// scopeView bindings are active unless typing into an input field.
+	scopeView
+	// scopeTable bindings are active while the stream table has focus.
+	scopeTable
+	// scopeInput bindings are active only while typing into an input field.
+	scopeInput
+)
+
+func (s keyScope) String() string {
+	switch s {
+	case scopeView:
+		return "Anywhere but input fields"
+	case scopeTable:
+		return "Stream table"
+	case scopeInput:
+		return "Input fields"
+	}
+	return "Anywhere"
+}
+
+// keyBinding binds a key, either a special key or a rune, to a log view
+// action, see LogView.keyHandlers.
+type keyBinding struct {
+	action string
+	scope  keyScope
+	key    tcell.Key
+	ch     rune
+	help   string
+}
+
+// keyBindings is the keymap registry, listing the key bound to every action
+// in the order keys are matched, which RemapKeys rebinds.
+var keyBindings = []*keyBinding{
+	{action: "selection", scope: scopeGlobal, key: tcell.KeyCtrlN, help: "Toggle mouse or text selection"},
+	{action: "about", scope: scopeGlobal, key: tcell.KeyCtrlA, help: "About l'oGGo"},
+	{action: "template", scope: scopeGlobal, key: tcell.KeyCtrlT, help: "Open the template editor"},
+	{action: "auto-scroll", scope: scopeGlobal, key: tcell.KeyCtrlSpace, help: "Toggle auto-scroll"},
+	{action: "snapshot", scope: scopeGlobal, key: tcell.KeyCtrlS, help: "Snapshot the filtered entries into a tab"},
+	{action: "server-filter", scope: scopeGlobal, key: tcell.KeyCtrlG, help: "Push the filter to the server"},
+	{action: "internals", scope: scopeView, key: tcell.KeyCtrlD, help: "Show l'oGGo's own logs"},
+	{action: "pins", scope: scopeGlobal, key: tcell.KeyCtrlP, help: "Focus the pinned entries"},
+	{action: "export", scope: scopeView, key: tcell.KeyCtrlE, help: "Export the entries to a file"},
+	{action: "paste", scope: scopeView, key: tcell.KeyCtrlV, help: "Paste log lines into a tab"},
+	{action: "peer", scope: scopeGlobal, key: tcell.KeyCtrlO, help: "Focus the compared stream"},
+	{action: "focus", scope: scopeGlobal, key: tcell.KeyTAB, help: "Switch focus between the table and the entry"},
+	{action: "redo", scope: scopeGlobal, key: tcell.KeyCtrlR, help: "Redo the latest undone filter or template edit"},
+	{action: "match-case", scope: scopeView, key: tcell.KeyCtrlL, help: "Cycle smart, ignoring or matching case in filters and searches"},
+	{action: "whole-word", scope: scopeInput, key: tcell.KeyCtrlB, help: "Toggle matching whole words in filters and searches"},
+	{action: "detach", scope: scopeGlobal, key: tcell.KeyCtrlBackslash, help: "Detach from the session, which keeps buffering"},
+	{action: "close-tab", scope: scopeView, key: tcell.KeyCtrlW, help: "Close the snapshot or internals tab"},
+	{action: "filter", scope: scopeView, key: tcell.KeyRune, ch: ':', help: "Toggle the local filter"},
+	{action: "previous-tab", scope: scopeView, key: tcell.KeyRune, ch: '[', help: "Switch to the previous tab"},
+	{action: "next-tab", scope: scopeView, key: tcell.KeyRune, ch: ']', help: "Switch to the next tab"},
+	{action: "keymap", scope: scopeView, key: tcell.KeyRune, ch: '?', help: "List the keybindings"},
+	{action: "annotate", scope: scopeTable, key: tcell.KeyRune, ch: 'n', help: "Annotate the entry"},
+	{action: "pin", scope: scopeTable, key: tcell.KeyRune, ch: 'P', help: "Pin or unpin the entry"},
+	{action: "copy", scope: scopeTable, key: tcell.KeyRune, ch: 'y', help: "Copy the entry to the clipboard"},
+	{action: "mark", scope: scopeTable, key: tcell.KeyRune, ch: ' ', help: "Mark or unmark the entry for bulk actions"},
+	{action: "mark-actions", scope: scopeTable, key: tcell.KeyRune, ch: 'm', help: "Copy, export, annotate or open the marked entries"},
+	{action: "humanize", scope: scopeTable, key: tcell.KeyRune, ch: 'v', help: "Toggle humanized values"},
+	{action: "scroll-left", scope: scopeTable, key: tcell.KeyRune, ch: 'H', help: "Scroll the columns left"},
+	{action: "scroll-right", scope: scopeTable, key: tcell.KeyRune, ch: 'L', help: "Scroll the columns right"},
+	{action: "aggregates", scope: scopeTable, key: tcell.KeyRune, ch: 'a', help: "Toggle the column aggregates"},
+	{action: "heatmap", scope: scopeTable, key: tcell.KeyRune, ch: 'd', help: "Cycle the distribution heatmap key"},
+	{action: "chart", scope: scopeTable, key: tcell.KeyRune, ch: 'c', help: "Cycle the charted key"},
+	{action: "minimap", scope: scopeTable, key: tcell.KeyRune, ch: 'M', help: "Toggle the timeline minimap"},
+	{action: "watches", scope: scopeTable, key: tcell.KeyRune, ch: 'W', help: "Toggle the watch counters"},
+	{action: "follow-value", scope: scopeTable, key: tcell.KeyRune, ch: 'F', help: "Follow a value of the entry"},
+	{action: "undo", scope: scopeTable, key: tcell.KeyRune, ch: 'u', help: "Undo the latest filter or template edit"},
+	{action: "unfollow-value", scope: scopeTable, key: tcell.KeyRune, ch: '-', help: "Drop the latest followed value"},
+	{action: "clear-followed", scope: scopeTable, key: tcell.KeyRune, ch: 'U', help: "Drop all followed values"},
+	{action: "follow-errors", scope: scopeTable, key: tcell.KeyRune, ch: 'e', help: "Toggle following errors"},
+	{action: "jump-burst", scope: scopeTable, key: tcell.KeyRune, ch: 'b', help: "Jump to the start of the burst"},
+	{action: "dismiss-burst", scope: scopeTable, key: tcell.KeyRune, ch: 'B', help: "Dismiss the burst banner"},
+	{action: "jump-secret", scope: scopeTable, key: tcell.KeyRune, ch: '!', help: "Jump to the last entry holding secrets"},
+	{action: "noisy-sources", scope: scopeTable, key: tcell.KeyRune, ch: 's', help: "List the noisiest sources"},
+	{action: "source-details", scope: scopeTable, key: tcell.KeyRune, ch: 'i', help: "Show the details of the source"},
+	{action: "sort-snapshot", scope: scopeTable, key: tcell.KeyRune, ch: 'o', help: "Sort the snapshot"},
+	{action: "dismiss-tutorial", scope: scopeTable, key: tcell.KeyRune, ch: 'X', help: "End the tutorial"},
+}
+
+// matches tells whether the event is of the bound key.
+func (b *keyBinding) matches(event *tcell.EventKey) bool {
+	if b.key == tcell.KeyRune {
+		return event.Key() == tcell.KeyRune && event.Rune() == b.ch
+	}
+	return event.Key() == b.key
+}
+
+// spaceName names the space bar, which a single character can't be told for.
+const spaceName = "Space"
+
+// name is the key as written in the keymap file, e.g. Ctrl-N, P or Space.
+func (b *keyBinding) name() string {
+	if b.key == tcell.KeyRune && b.ch == ' ' {
+		return spaceName
+	}
+	if b.key == tcell.KeyRune {
+		return string(b.ch)
+	}
+	if name, ok := tcell.KeyNames[b.key]; ok {
+		return name
+	}
+	return fmt.Sprintf("Key[%d]", b.key)
+}
+
+// boundKey names the key bound to the action, e.g. Ctrl-T.
+func boundKey(action string) string {
+	for _, b := range keyBindings {
+		if b.action == action {
+			return b.name()
+		}
+	}
+	return ""
+}
+
+// parseKey reads a key name, either a single character or a special key
+// as named by tcell, e.g. Ctrl-N, F5 or Tab, or Space, regardless of case.
+func parseKey(name string) (tcell.Key, rune, error) {
+	if strings.EqualFold(name, spaceName) {
+		return tcell.KeyRune, ' ', nil
+	}
+	if utf8.RuneCountInString(name) == 1 {
+		ch, _ := utf8.DecodeRuneInString(name)
+		return tcell.KeyRune, ch, nil
+	}
+	for key, n := range tcell.KeyNames {
+		if strings.EqualFold(n, name) {
+			return key, 0, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("unknown key %q", name)
+}
+
+// LoadKeyMap rebinds the actions of the keymap file, if any, see RemapKeys.
+func LoadKeyMap(file string) error {
+	b, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	remap := make(map[string]string)
+	if err := yaml.Unmarshal(b, &remap); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	if err := RemapKeys(remap); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return nil
+}
+
+// RemapKeys binds the actions to the given keys, e.g. snapshot: Ctrl-X,
+// failing, with no binding changed, on unknown actions or keys and on keys
+// bound to more than one action.
+func RemapKeys(remap map[string]string) error {
+	bindings := make([]*keyBinding, len(keyBindings))
+	for i, b := range keyBindings {
+		rebound := *b
+		if name, ok := remap[b.action]; ok {
+			key, ch, err := parseKey(name)
+			if err != nil {
+				return fmt.Errorf("%s: %w", b.action, err)
+			}
+			rebound.key, rebound.ch = key, ch
+			delete(remap, b.action)
+		}
+		bindings[i] = &rebound
+	}
+	for action := range remap {
+		return fmt.Errorf("unknown action %q", action)
+	}
+	bound := make(map[string]string)
+	for _, b := range bindings {
+		if action, ok := bound[b.name()]; ok {
+			return fmt.Errorf("%s is bound to both %s and %s", b.name(), action, b.action)
+		}
+		bound[b.name()] = b.action
+	}
+	keyBindings = bindings
+	return nil
+}
+
+// keyHandlers maps each action of the keymap to its handler, which tells
+// whether it handled the key, letting it through otherwise.
+func (l *LogView) keyHandlers() map[string]func() bool {
+	run := func(f func()) func() bool {
+		return func() bool {
+			f()
+			return true
+		}
+	}
+	return map[string]func() bool{
+		"selection":     run(l.toggleSelectionMouse),
+		"about":         run(func() { go l.showAbout() }),
+		"template":      run(l.makeLayoutsWithTemplateView),
+		"auto-scroll":   run(l.toggledFollowing),
+		"snapshot":      run(l.snapshot),
+		"server-filter": run(l.pushFilterToServer),
+		"internals":     run(l.app.showInternals),
+		"pins":          run(l.focusPins),
+		"export":        run(l.exportBundle),
+		"paste":         run(l.showPaste),
+		"peer": func() bool {
+			if l.peer == nil {
+				return false
+			}
+			l.app.SetFocus(l.peer.table)
+			return true
+		},
+		"focus": func() bool {
+			if !l.isJsonViewShown() {
+				return false
+			}
+			l.switchFocus()
+			return true
+		},
+		"detach": func() bool {
+			if l.app.onDetach == nil {
+				return false
+			}
+			l.app.onDetach()
+			return true
+		},
+		"redo":           run(l.redo),
+		"undo":           run(l.undo),
+		"match-case":     run(l.cycleCase),
+		"whole-word":     run(l.toggleWholeWord),
+		"close-tab":      run(l.app.closeActiveView),
+		"filter":         run(l.toggleFilter),
+		"previous-tab":   run(func() { l.app.nextView(-1) }),
+		"next-tab":       run(func() { l.app.nextView(1) }),
+		"keymap":         run(l.showKeymap),
+		"annotate":       run(l.annotateSelected),
+		"pin":            run(l.togglePinSelected),
+		"copy":           run(l.copySelected),
+		"mark":           run(l.toggleMarkSelected),
+		"mark-actions":   run(l.showMarkActions),
+		"humanize":       run(l.toggleRawValues),
+		"aggregates":     run(l.toggleAggregates),
+		"scroll-left":    run(func() { l.scrollColumns(-1) }),
+		"scroll-right":   run(func() { l.scrollColumns(1) }),
+		"heatmap":        run(l.cycleHeatmap),
+		"chart":          run(l.cycleChart),
+		"minimap":        run(l.toggleMinimap),
+		"watches":        run(l.toggleWatches),
+		"follow-value":   run(l.showFollowValue),
+		"unfollow-value": run(l.unfollowLastValue),
+		"clear-followed": run(l.clearStickies),
+		"follow-errors":  run(l.toggleFollowErrors),
+		"jump-burst":     run(l.jumpToBurst),
+		"dismiss-burst":  run(l.dismissBurst),
+		"jump-secret":    run(l.jumpToSecret),
+		"noisy-sources": func() bool {
+			if l.isJsonViewShown() {
+				return false
+			}
+			l.showNoisySources()
+			return true
+		},
+		"source-details": run(l.showSourceDetails),
+		"sort-snapshot": func() bool {
+			if !l.isSnapshot() {
+				return false
+			}
+			l.showSortSnapshot()
+			return true
+		},
+		"dismiss-tutorial": func() bool {
+			if l.tutorial == tutorialOff {
+				return false
+			}
+			l.dismissTutorial()
+			return true
+		},
+	}
+}
+
+// showKeymap lists the keybindings, as remapped, grouped by where they are
+// active.
+func (l *LogView) showKeymap() {
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("[::bu]%s[::-]\n", i18n.T("Keybindings")))
+	for _, scope := range []keyScope{scopeGlobal, scopeView, scopeTable, scopeInput} {
+		sb.WriteString(fmt.Sprintf("\n[yellow::b]%s[-::-]\n", i18n.T(scope.String())))
+		for _, b := range keyBindings {
+			if b.scope == scope {
+				sb.WriteString(fmt.Sprintf(" [::b]%-10s[::-] %s\n", tview.Escape(b.name()), i18n.T(b.help)))
+			}
+		}
+	}
+	sb.WriteString(fmt.Sprintf("\n[::i]%s[::-]", i18n.T("Remap keys in ~/.loggo/keys.yaml, e.g. snapshot: Ctrl-X")))
+	text := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(sb.String())
+	text.SetBackgroundColor(tcell.ColorDarkBlue).SetBorderPadding(0, 0, 1, 1)
+	_, _, _, height := l.table.GetInnerRect()
+	l.app.ShowModal(text, 64, max(10, min(len(keyBindings)+10, height)), tcell.ColorDarkBlue,
+		func(event *tcell.EventKey) *tcell.EventKey {
+			switch event.Key() {
+			case tcell.KeyEnter, tcell.KeyEsc:
+				l.app.DismissModal(l.table)
+				return nil
+			}
+			if event.Rune() == '?' || event.Rune() == 'q' {
+				l.app.DismissModal(l.table)
+				return nil
+			}
+			text.InputHandler()(event, func(p tview.Primitive) {})
+			return nil
+		})
+}
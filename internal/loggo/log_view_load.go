@@ -0,0 +1,113 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package loggo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/badaniya/loggo/internal/i18n"
+	"github.com/badaniya/loggo/internal/reader"
+	"github.com/rivo/tview"
+)
+
+const (
+	loadBanner   = `[black:lightblue:b] ⏳ %s [::-]%s %d %s `
+	loadBarWidth = 30
+	endOfInput   = `[gray::i]■ %s `
+)
+
+// makeLoadView builds the banner shown above the table while a bounded
+// source, e.g. a whole file, is initially loaded.
+func (l *LogView) makeLoadView() {
+	l.loadView = tview.NewTextView().
+		SetDynamicColors(true).
+		SetWrap(false)
+}
+
+// watchLoad tracks the initial load of a bounded source, if so, showing its
+// progress and, once a source that isn't followed is through, flagging the
+// end of input past the last row.
+func (l *LogView) watchLoad() {
+	bounded, ok := l.chanReader.(reader.Bounded)
+	if !ok {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(250 * time.Millisecond)
+		defer ticker.Stop()
+		for !l.closed.Load() {
+			select {
+			case <-bounded.Loaded():
+				l.updateLoadView(-1)
+				if !bounded.Follows() {
+					l.filterLock.Lock()
+					l.ended = true
+					l.filterLock.Unlock()
+				}
+				l.app.Draw()
+				return
+			case <-ticker.C:
+				read, total := bounded.Progress()
+				ratio := 0.0
+				if total > 0 {
+					ratio = float64(read) / float64(total)
+				}
+				l.updateLoadView(ratio)
+				l.app.Draw()
+			}
+		}
+	}()
+}
+
+// updateLoadView shows the loaded ratio of the source, unknown if 0, or hides
+// the banner if negative.
+func (l *LogView) updateLoadView(ratio float64) {
+	if ratio < 0 {
+		l.loadView.SetText("")
+		l.tableContent.ResizeItem(l.loadView, 0, 0)
+		return
+	}
+	l.loadView.SetText(fmt.Sprintf(loadBanner, i18n.T("Loading"), progressBar(ratio, loadBarWidth),
+		len(l.inSlice), i18n.T("lines")))
+	l.tableContent.ResizeItem(l.loadView, 1, 0)
+}
+
+// progressBar renders the ratio as a bar of the given width followed by its
+// percentage, or as an ellipsis if unknown.
+func progressBar(ratio float64, width int) string {
+	if ratio <= 0 {
+		return "…"
+	}
+	filled := min(int(ratio*float64(width)), width)
+	return fmt.Sprintf("▕%s%s▏ %3.0f%%", strings.Repeat("█", filled), strings.Repeat("░", width-filled),
+		min(ratio, 1)*100)
+}
+
+// loadHeight is the banner height, none unless loading.
+func (l *LogView) loadHeight() int {
+	if len(l.loadView.GetText(false)) == 0 {
+		return 0
+	}
+	return 1
+}
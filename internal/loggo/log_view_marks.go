@@ -0,0 +1,121 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+
+	"github.com/badaniya/loggo/internal/char"
+	"github.com/badaniya/loggo/internal/color"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// toggleMarkSelected marks the selected entry for bulk actions, or unmarks it
+// if it's already marked, moving on to the next one so that consecutive
+// entries are marked by holding space. Marks survive re-filtering, so that
+// the results of several searches can be gathered.
+func (l *LogView) toggleMarkSelected() {
+	r, c := l.table.GetSelection()
+	l.filterLock.Lock()
+	if r <= 0 || r-1 >= len(l.finSlice) {
+		l.filterLock.Unlock()
+		return
+	}
+	row := l.finSlice[r-1]
+	if i := l.markIndex(row); i >= 0 {
+		l.marks = append(l.marks[:i], l.marks[i+1:]...)
+	} else {
+		l.marks = append(l.marks, row)
+	}
+	last := r >= len(l.finSlice)
+	l.filterLock.Unlock()
+	if !last {
+		l.isFollowing = false
+		l.updateLineView()
+		l.table.Select(r+1, c)
+	}
+}
+
+// markIndex returns the position of the entry amongst the marked ones, or -1.
+// Callers must hold the filterLock.
+func (l *LogView) markIndex(row map[string]interface{}) int {
+	for i, m := range l.marks {
+		if sameEntry(m, row) {
+			return i
+		}
+	}
+	return -1
+}
+
+// markedEntries returns the marked entries, in the order they were marked.
+func (l *LogView) markedEntries() []map[string]interface{} {
+	l.filterLock.RLock()
+	defer l.filterLock.RUnlock()
+	return append([]map[string]interface{}(nil), l.marks...)
+}
+
+// showMarkActions lists the actions applying to the marked entries at once.
+func (l *LogView) showMarkActions() {
+	marked := l.markedEntries()
+	if len(marked) == 0 {
+		go l.app.ShowPopMessage("No marked entries, press space to mark the selected one.", 2, l.table)
+		return
+	}
+	list := tview.NewList().
+		ShowSecondaryText(false).
+		SetHighlightFullLine(true).
+		SetMainTextStyle(tcell.StyleDefault.Background(tcell.ColorDarkBlue)).
+		SetSelectedStyle(color.FieldStyle)
+	list.SetBackgroundColor(tcell.ColorDarkBlue).
+		SetBorder(true).
+		SetTitle(fmt.Sprintf(" %s %d marked ", char.SymMark, len(marked)))
+	action := func(f func()) func() {
+		return func() {
+			l.app.DismissModal(l.table)
+			f()
+		}
+	}
+	list.AddItem("Copy to clipboard", "", 'c', action(func() { l.copyEntries(marked) })).
+		AddItem("Export", "", 'e', action(func() { l.exportEntries(exportMarked) })).
+		AddItem("Annotate", "", 'n', action(func() {
+			l.showNoteEditor(fmt.Sprintf("Note for %d marked entries", len(marked)), "", marked...)
+		})).
+		AddItem("Open in a tab", "", 't', action(func() { l.app.addSnapshot(marked, l) })).
+		AddItem("Clear marks", "", 'x', action(l.clearMarks))
+	l.app.ShowModal(list, 40, list.GetItemCount()+2, tcell.ColorDarkBlue, func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			l.app.DismissModal(l.table)
+			return nil
+		}
+		return event
+	})
+	l.app.SetFocus(list)
+}
+
+// clearMarks unmarks all the entries.
+func (l *LogView) clearMarks() {
+	l.filterLock.Lock()
+	l.marks = nil
+	l.filterLock.Unlock()
+}
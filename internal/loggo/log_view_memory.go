@@ -0,0 +1,136 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package loggo
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/badaniya/loggo/internal/compress"
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/i18n"
+	"github.com/badaniya/loggo/internal/reader"
+	"github.com/badaniya/loggo/internal/util"
+)
+
+// memoryPollInterval is how often the memory in use is sampled, both for the
+// status bar and to evict entries past the limit, see util.SetMemoryLimit.
+const memoryPollInterval = 2 * time.Second
+
+// watchMemory periodically updates the memory indicator of the status bar,
+// evicting the oldest entries whenever the memory limit is neared, and those
+// received longer ago than the retention period, if any (see
+// util.SetRetention), snapshots aside.
+func (l *LogView) watchMemory() {
+	go func() {
+		var retention *config.Retention
+		if period := util.Retention(); period > 0 {
+			retention = config.NewRetention(period)
+		}
+		for !l.closed.Load() {
+			time.Sleep(memoryPollInterval)
+			inUse := util.MemoryInUse()
+			first, end := l.bufferedRange()
+			entries := end - first
+			if retention != nil && !l.isSnapshot() {
+				retention.Mark(end)
+				if expired, ok := retention.Expired(); ok && expired > first {
+					l.evict(expired - first)
+					entries -= min(expired-first, entries)
+				}
+			}
+			if n := config.Evictions(entries, inUse, util.MemoryLimit()); n > 0 {
+				util.Log().WithField("code", n).Info("Memory limit neared, evicting the oldest entries")
+				l.evict(n)
+				entries -= n
+			}
+			l.updateMemView(entries, inUse)
+			l.app.Draw()
+		}
+	}()
+}
+
+// evict drops the n oldest entries of the buffer along with the filtered
+// ones, keeping the selection onto the same entry unless following.
+func (l *LogView) evict(n int) {
+	l.filterLock.Lock()
+	n = min(n, len(l.inSlice))
+	evicted := make(map[uintptr]struct{}, n)
+	for _, row := range l.inSlice[:n] {
+		evicted[reflect.ValueOf(row).Pointer()] = struct{}{}
+	}
+	// the filtered entries keep the buffer order, those evicted come first
+	filtered := 0
+	for filtered < len(l.finSlice) {
+		if _, ok := evicted[reflect.ValueOf(l.finSlice[filtered]).Pointer()]; !ok {
+			break
+		}
+		filtered++
+	}
+	// copied rather than resliced for the evicted entries to be collected
+	l.inSlice = append([]map[string]interface{}(nil), l.inSlice[n:]...)
+	l.rawLines = append([]*compress.Text(nil), l.rawLines[min(n, len(l.rawLines)):]...)
+	l.finSlice = append([]map[string]interface{}(nil), l.finSlice[filtered:]...)
+	l.bufferStart += n
+	// older entries can't be back-filled past the gap left
+	l.backfilledAll.Store(true)
+	l.globalCount -= int64(filtered)
+	l.filterLock.Unlock()
+	if filtered > 0 && !l.isFollowing {
+		r, c := l.table.GetSelection()
+		l.table.Select(max(r-filtered, 1), c)
+	}
+}
+
+// bufferedRange returns the index of the oldest entry buffered and past the
+// newest one, counting those evicted, the back-filled ones being numbered
+// below 0.
+func (l *LogView) bufferedRange() (first, end int) {
+	l.filterLock.RLock()
+	defer l.filterLock.RUnlock()
+	return l.bufferStart, l.bufferStart + len(l.inSlice)
+}
+
+func (l *LogView) updateMemView(entries int, inUse int64) {
+	status := fmt.Sprintf("[gray:default:]%d %s · %s", entries, i18n.T("entries"), config.HumanizeBytes(float64(inUse)))
+	if limit := util.MemoryLimit(); limit > 0 {
+		status += " / " + config.HumanizeBytes(float64(limit))
+	}
+	if period := util.Retention(); period > 0 && !l.isSnapshot() {
+		status += fmt.Sprintf(" · %s %s", i18n.T("last"), period)
+	}
+	l.memView.SetText(l.retryStatus() + status)
+}
+
+// retryStatus returns the retries of a network source for the status bar,
+// once it was retried, highlighted while reconnecting, see reader.Retrier.
+func (l *LogView) retryStatus() string {
+	retrier, ok := l.chanReader.(reader.Retrier)
+	if !ok || retrier.Retries() == 0 {
+		return ""
+	}
+	if retrier.Reconnecting() {
+		return fmt.Sprintf("[yellow::b]↻ %s (%d)[-::-] · ", i18n.T("reconnecting"), retrier.Retries())
+	}
+	return fmt.Sprintf("[gray:default:]↻ %d %s · ", retrier.Retries(), i18n.T("retries"))
+}
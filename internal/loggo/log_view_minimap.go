@@ -0,0 +1,163 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"strings"
+	"time"
+
+	"github.com/badaniya/loggo/internal/char"
+	"github.com/badaniya/loggo/internal/color"
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	minimapWidth = 8
+	// minimapLabelEvery is how often rows are labelled with their time.
+	minimapLabelEvery = 5
+	minimapRows       = 20
+)
+
+// minimapShades renders rows from the least to the most populated.
+var minimapShades = []string{"░", "▒", "▓", "█"}
+
+func (l *LogView) makeMinimapView() {
+	l.minimapView = tview.NewTextView().
+		SetDynamicColors(true).
+		SetWrap(false)
+	l.minimapView.SetBackgroundColor(color.ColorBackgroundField)
+	l.minimapView.SetMouseCapture(func(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+		if action != tview.MouseLeftClick {
+			return action, event
+		}
+		_, y := event.Position()
+		_, top, _, _ := l.minimapView.GetInnerRect()
+		l.jumpToMinimapRow(y - top)
+		return action, nil
+	})
+}
+
+// toggleMinimap shows or hides the minimap of the buffer over time aside the
+// table.
+func (l *LogView) toggleMinimap() {
+	l.showMinimap = !l.showMinimap
+	if l.showMinimap {
+		l.updateMinimap()
+		l.watchMinimap()
+	}
+	if !l.isTemplateViewShown() && !l.isJsonViewShown() {
+		l.makeLayouts()
+	}
+	go l.app.Draw()
+}
+
+// watchMinimap refreshes the minimap for as long as it's shown, following the
+// entries and the selection.
+func (l *LogView) watchMinimap() {
+	go func() {
+		for l.showMinimap && !l.closed.Load() {
+			time.Sleep(time.Second)
+			if l.showMinimap && l.updateMinimap() {
+				l.app.Draw()
+			}
+		}
+	}()
+}
+
+func (l *LogView) updateMinimap() bool {
+	_, _, _, rows := l.minimapView.GetInnerRect()
+	if rows <= 0 {
+		rows = minimapRows
+	}
+	l.filterLock.RLock()
+	m := l.config.MakeMinimap(l.finSlice, l.severities, rows)
+	l.filterLock.RUnlock()
+	l.minimap.Store(m)
+	text := ""
+	if m != nil {
+		selected, _ := l.table.GetSelection()
+		text = renderMinimap(m, m.RowOf(selected-1))
+	}
+	if text == l.minimapView.GetText(false) {
+		return false
+	}
+	l.minimapView.SetText(text)
+	return true
+}
+
+// jumpToMinimapRow selects the first entry of the row of the minimap.
+func (l *LogView) jumpToMinimapRow(row int) {
+	m := l.minimap.Load()
+	if m == nil || row < 0 || row >= len(m.Rows) || m.Rows[row].First < 0 {
+		return
+	}
+	l.isFollowing = false
+	l.updateLineView()
+	l.table.Select(m.Rows[row].First+1, 0)
+	l.app.SetFocus(l.table)
+	l.updateMinimap()
+}
+
+// renderMinimap draws a row per line, shaded by its number of entries and
+// coloured by their worst severity, labelled with its time every few rows and
+// pointing at the row holding the selected entry.
+func renderMinimap(m *config.Minimap, selected int) string {
+	var b strings.Builder
+	for i, r := range m.Rows {
+		label := strings.Repeat(" ", 5)
+		if i%minimapLabelEvery == 0 && !m.From.IsZero() {
+			label = m.RowTime(i).Format("15:04")
+		}
+		marker := " "
+		if i == selected {
+			marker = "[yellow::b]" + char.SymRight + "[-::-]"
+		}
+		tick := " "
+		if r.Count > 0 {
+			level := (r.Count*len(minimapShades) - 1) / m.MaxCount
+			tick = "[" + severityColor(r.Severity) + "]" + minimapShades[level] + "[-]"
+		}
+		b.WriteString("[::d]" + label + "[-::-]" + marker + tick)
+		if i < len(m.Rows)-1 {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// severityColor names the colour of the ticks of a severity.
+func severityColor(severity string) string {
+	switch severity {
+	case config.SeverityError:
+		return "red"
+	case config.SeverityWarn:
+		return "yellow"
+	case config.SeverityInfo:
+		return "green"
+	case config.SeverityDebug:
+		return "gray"
+	}
+	return "white"
+}
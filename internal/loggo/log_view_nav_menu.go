@@ -27,6 +27,7 @@ import (
 	"runtime"
 
 	"github.com/badaniya/loggo/internal/color"
+	"github.com/badaniya/loggo/internal/i18n"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
@@ -34,9 +35,19 @@ import (
 const (
 	selectionMouseEnabledMenu  = `[yellow:default:b] ^n      [-:default:u]["1"]Enable Selection[""]`
 	selectionMouseDisabledMenu = `[yellow:default:b] ^n      [-:default:u]["1"]Enable Mouse[""]`
+	selectionCopyMenu          = `[yellow:default:b] ^n      [-:default:u]["1"]Copy Entry[""]`
 	templateMenu               = `[yellow:default:b] ^t      [-:default:u]["1"]Template[""]`
 	localFilterMenu            = `[yellow:default:b] :       [-:default:u]["1"]Local Filter[""]`
+	snapshotMenu               = `[yellow:default:b] ^s      [-:default:u]["1"]Snapshot[""]`
+	pasteMenu                  = `[yellow:default:b] ^v      [-:default:u]["1"]Paste[""]`
+	switchTabMenu              = `[yellow:default:b] [ ]     [-:default:u]["1"]Switch Tab[""]`
+	closeSnapshotMenu          = `[yellow:default:b] ^w      [-:default:u]["1"]Close Snapshot[""]`
+	frozenMenu                 = `[yellow:default:b] ❄       [-:default:-]%s [red:default:bi]FROZEN[-:default:-]`
 	viewEntryMenu              = `[yellow:default:b] Enter[-:default:-]   View Entry`
+	annotateMenu               = `[yellow:default:b] n       [-:default:u]["1"]Annotate Entry[""]`
+	pinMenu                    = `[yellow:default:b] P       [-:default:u]["1"]Pin Entry[""]`
+	pinnedMenu                 = `[yellow:default:b] ^p      [-:default:u]["1"]Pinned Entries[""]`
+	exportBundleMenu           = `[yellow:default:b] ^e      [-:default:u]["1"]Export[""]`
 	navigateMenu               = `[yellow:default:b] ↓ ← ↑ →[-:default:-] Navigate`
 	goTopMenu                  = `[yellow:default:b] g       [-:default:u]["1"]Top[""]`
 	goBottomMenu               = `[yellow:default:b] G       [-:default:u]["1"]Bottom[""]`
@@ -44,16 +55,32 @@ const (
 	pageDownMenu               = `[yellow:default:b] ^f      [-:default:u]["1"]Pg Down[""]`
 	mouseHoMenu                = `[yellow:default:b] ⌥ 🖱    [-:default:-]Horizontal`
 	mouseVeMenu                = `[yellow:default:b] ⌥ ⌘ 🖱  [-:default:-]Vertical`
+	wheelHoMenu                = `[yellow:default:b] ⇧ Wheel [-:default:-]Horizontal`
+	wheelVeMenu                = `[yellow:default:b] Wheel   [-:default:-]Vertical`
+	scrollColumnsMenu          = `[yellow:default:b] H L     [-:default:-]Scroll Columns`
 	aboutMenu                  = `[yellow:default:b] ^a      [-:default:u]["1"]About[""]`
 	quitMenu                   = `[yellow:default:b] ^c      [-:default:u]["1"]Quit[""]`
 	autoScrollOnMenu           = `[yellow:default:b] ^Space  [-:default:u]["1"]Auto-Scroll[:default:-] [green:default:bi]ON[-:default:-][""]`
 	autoScrollOffMenu          = `[yellow:default:b] ^Space  [-:default:u]["1"]Auto-Scroll[:default:-] [red:default:bi]OFF[-:default:-][""]`
+	humanizeOnMenu             = `[yellow:default:b] v       [-:default:u]["1"]Humanize[:default:-] [green:default:bi]ON[-:default:-][""]`
+	humanizeOffMenu            = `[yellow:default:b] v       [-:default:u]["1"]Humanize[:default:-] [red:default:bi]OFF[-:default:-][""]`
+	aggregatesOnMenu           = `[yellow:default:b] a       [-:default:u]["1"]Aggregates[:default:-] [green:default:bi]ON[-:default:-][""]`
+	aggregatesOffMenu          = `[yellow:default:b] a       [-:default:u]["1"]Aggregates[:default:-] [red:default:bi]OFF[-:default:-][""]`
+	heatmapOnMenu              = `[yellow:default:b] d       [-:default:u]["1"]Heatmap[:default:-] [green:default:bi]%s[-:default:-][""]`
+	heatmapOffMenu             = `[yellow:default:b] d       [-:default:u]["1"]Heatmap[:default:-] [red:default:bi]OFF[-:default:-][""]`
+	chartOnMenu                = `[yellow:default:b] c       [-:default:u]["1"]Chart[:default:-] [green:default:bi]%s[-:default:-][""]`
+	chartOffMenu               = `[yellow:default:b] c       [-:default:u]["1"]Chart[:default:-] [red:default:bi]OFF[-:default:-][""]`
+	followErrorsOnMenu         = `[yellow:default:b] e       [-:default:u]["1"]Follow Errors[:default:-] [green:default:bi]ON[-:default:-][""]`
+	followErrorsOffMenu        = `[yellow:default:b] e       [-:default:u]["1"]Follow Errors[:default:-] [red:default:bi]OFF[-:default:-][""]`
 )
 
 func (l *LogView) populateMenu() {
 	l.mouseSel = tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
 		SetDynamicColors(true).SetRegions(true).
-		SetText(selectionMouseEnabledMenu)
+		SetText(i18n.Markup(selectionMouseEnabledMenu))
+	if selectionCopies() {
+		l.mouseSel.SetText(i18n.Markup(selectionCopyMenu))
+	}
 
 	l.navMenu = tview.NewFlex().SetDirection(tview.FlexRow)
 	l.navMenu.
@@ -64,11 +91,12 @@ func (l *LogView) populateMenu() {
 		//////////////////////////////////////////////////////////////////
 		// Stream Menu
 		//////////////////////////////////////////////////////////////////
-		AddItem(NewHorizontalSeparator(sepStyle, LineHThick, "Stream", sepForeground), 1, 2, false).
+		AddItem(NewHorizontalSeparator(sepStyle, LineHThick, i18n.T("Stream"), sepForeground), 1, 2, false).
+		AddItem(l.tabsView.SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)), 1, 2, false).
 		AddItem(l.followingView, 1, 2, false).
 		AddItem(l.textViewMenuControl(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
 			SetDynamicColors(true).SetRegions(true).
-			SetText(templateMenu), func() {
+			SetText(i18n.Markup(templateMenu)), func() {
 			if l.isTemplateViewShown() {
 				// TODO: Find a reliable way to respond to external closure
 			} else {
@@ -77,23 +105,90 @@ func (l *LogView) populateMenu() {
 		}), 1, 2, false).
 		AddItem(l.textViewMenuControl(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
 			SetDynamicColors(true).SetRegions(true).
-			SetText(localFilterMenu), func() {
+			SetText(i18n.Markup(localFilterMenu)), func() {
 			l.toggleFilter()
 		}), 1, 2, false).
+		AddItem(l.textViewMenuControl(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
+			SetDynamicColors(true).SetRegions(true).
+			SetText(i18n.Markup(snapshotMenu)), func() {
+			l.snapshot()
+		}), 1, 2, false).
+		AddItem(l.textViewMenuControl(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
+			SetDynamicColors(true).SetRegions(true).
+			SetText(i18n.Markup(pasteMenu)), func() {
+			l.showPaste()
+		}), 1, 2, false).
+		AddItem(l.textViewMenuControl(l.humanizeView.SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)), func() {
+			l.toggleRawValues()
+		}), 1, 2, false).
+		AddItem(l.textViewMenuControl(l.aggregatesView.SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)), func() {
+			l.toggleAggregates()
+		}), 1, 2, false).
+		AddItem(l.textViewMenuControl(l.heatmapMenuView.SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)), func() {
+			l.cycleHeatmap()
+		}), 1, 2, false).
+		AddItem(l.textViewMenuControl(l.chartMenuView.SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)), func() {
+			l.cycleChart()
+		}), 1, 2, false).
+		AddItem(l.textViewMenuControl(l.followErrorsView.SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)), func() {
+			l.toggleFollowErrors()
+		}), 1, 2, false).
+		AddItem(l.textViewMenuControl(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
+			SetDynamicColors(true).SetRegions(true).
+			SetText(i18n.Markup(switchTabMenu)), func() {
+			l.app.nextView(1)
+		}), 1, 2, false)
+	if l.isSnapshot() {
+		l.navMenu.
+			AddItem(l.textViewMenuControl(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
+				SetDynamicColors(true).SetRegions(true).
+				SetText(i18n.Markup(closeSnapshotMenu)), func() {
+				l.app.closeActiveView()
+			}), 1, 2, false).
+			AddItem(l.textViewMenuControl(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
+				SetDynamicColors(true).SetRegions(true).
+				SetText(i18n.Markup(sortSnapshotMenu)), func() {
+				l.showSortSnapshot()
+			}), 1, 2, false)
+	}
+	if l.internals {
+		l.navMenu.
+			AddItem(l.textViewMenuControl(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
+				SetDynamicColors(true).SetRegions(true).
+				SetText(i18n.Markup(closeInternalsMenu)), func() {
+				l.app.closeActiveView()
+			}), 1, 2, false)
+	} else {
+		l.navMenu.
+			AddItem(l.textViewMenuControl(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
+				SetDynamicColors(true).SetRegions(true).
+				SetText(i18n.Markup(internalsMenu)), func() {
+				l.app.showInternals()
+			}), 1, 2, false)
+	}
+	if _, ok := l.serverFilterer(); ok {
+		l.navMenu.
+			AddItem(l.textViewMenuControl(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
+				SetDynamicColors(true).SetRegions(true).
+				SetText(i18n.Markup(serverFilterMenu)), func() {
+				l.pushFilterToServer()
+			}), 1, 2, false)
+	}
+	l.navMenu.
 		//////////////////////////////////////////////////////////////////
 		// Navigation Menu
 		//////////////////////////////////////////////////////////////////
 		AddItem(
-			NewHorizontalSeparator(sepStyle, LineHThick, "Navigation", sepForeground), 1, 2, false).
+			NewHorizontalSeparator(sepStyle, LineHThick, i18n.T("Navigation"), sepForeground), 1, 2, false).
 		AddItem(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
 			SetDynamicColors(true).
-			SetText(viewEntryMenu), 1, 3, false).
+			SetText(i18n.Markup(viewEntryMenu)), 1, 3, false).
 		AddItem(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
 			SetDynamicColors(true).
-			SetText(navigateMenu), 1, 3, false).
+			SetText(i18n.Markup(navigateMenu)), 1, 3, false).
 		AddItem(l.textViewMenuControl(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
 			SetDynamicColors(true).SetRegions(true).
-			SetText(goTopMenu), func() {
+			SetText(i18n.Markup(goTopMenu)), func() {
 			l.isFollowing = false
 			l.table.ScrollToBeginning()
 			if len(l.inSlice) > 1 {
@@ -102,20 +197,20 @@ func (l *LogView) populateMenu() {
 		}), 1, 1, false).
 		AddItem(l.textViewMenuControl(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
 			SetDynamicColors(true).SetRegions(true).
-			SetText(goBottomMenu), func() {
+			SetText(i18n.Markup(goBottomMenu)), func() {
 			l.isFollowing = false
 			l.table.ScrollToEnd()
 			go l.table.Select(len(l.inSlice), 0)
 		}), 1, 2, false).
 		AddItem(l.textViewMenuControl(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
 			SetDynamicColors(true).SetRegions(true).
-			SetText(pageUpMenu), func() {
+			SetText(i18n.Markup(pageUpMenu)), func() {
 			l.isFollowing = false
 			l.table.InputHandler()(tcell.NewEventKey(tcell.KeyPgUp, '0', 0), func(p tview.Primitive) {})
 		}), 1, 2, false).
 		AddItem(l.textViewMenuControl(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
 			SetDynamicColors(true).SetRegions(true).
-			SetText(pageDownMenu), func() {
+			SetText(i18n.Markup(pageDownMenu)), func() {
 			l.isFollowing = false
 			l.table.InputHandler()(tcell.NewEventKey(tcell.KeyPgDn, '0', 0), func(p tview.Primitive) {})
 		}), 1, 2, false)
@@ -123,36 +218,73 @@ func (l *LogView) populateMenu() {
 	// Selection Menu
 	//////////////////////////////////////////////////////////////////
 	l.navMenu.
-		AddItem(NewHorizontalSeparator(sepStyle, LineHThick, "Selection", sepForeground), 1, 2, false).
-		AddItem(l.textViewMenuControl(l.mouseSel, l.toggleSelectionMouse), 1, 2, false)
-	if runtime.GOOS != "windows" {
-		l.navMenu.
-			AddItem(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
-				SetDynamicColors(true).
-				SetText(mouseHoMenu), 1, 3, false).
-			AddItem(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
-				SetDynamicColors(true).
-				SetText(mouseVeMenu), 1, 3, false)
+		AddItem(NewHorizontalSeparator(sepStyle, LineHThick, i18n.T("Selection"), sepForeground), 1, 2, false).
+		AddItem(l.textViewMenuControl(l.mouseSel, l.toggleSelectionMouse), 1, 2, false).
+		AddItem(l.textViewMenuControl(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
+			SetDynamicColors(true).SetRegions(true).
+			SetText(i18n.Markup(annotateMenu)), func() {
+			l.annotateSelected()
+		}), 1, 2, false).
+		AddItem(l.textViewMenuControl(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
+			SetDynamicColors(true).SetRegions(true).
+			SetText(i18n.Markup(pinMenu)), func() {
+			l.togglePinSelected()
+		}), 1, 2, false).
+		AddItem(l.textViewMenuControl(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
+			SetDynamicColors(true).SetRegions(true).
+			SetText(i18n.Markup(pinnedMenu)), func() {
+			l.focusPins()
+		}), 1, 2, false).
+		AddItem(l.textViewMenuControl(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
+			SetDynamicColors(true).SetRegions(true).
+			SetText(i18n.Markup(exportBundleMenu)), func() {
+			l.exportBundle()
+		}), 1, 2, false)
+	mouseHo, mouseVe := mouseHoMenu, mouseVeMenu
+	if runtime.GOOS == "windows" {
+		// no option key to scroll with, see scrollWheel
+		mouseHo, mouseVe = wheelHoMenu, wheelVeMenu
 	}
+	l.navMenu.
+		AddItem(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
+			SetDynamicColors(true).
+			SetText(i18n.Markup(mouseHo)), 1, 3, false).
+		AddItem(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
+			SetDynamicColors(true).
+			SetText(i18n.Markup(mouseVe)), 1, 3, false).
+		AddItem(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
+			SetDynamicColors(true).
+			SetText(i18n.Markup(scrollColumnsMenu)), 1, 3, false)
 	//////////////////////////////////////////////////////////////////
 	// Application Menu
 	//////////////////////////////////////////////////////////////////
 	l.navMenu.
-		AddItem(NewHorizontalSeparator(sepStyle, LineHThick, "Application", sepForeground), 1, 2, false).
+		AddItem(NewHorizontalSeparator(sepStyle, LineHThick, i18n.T("Application"), sepForeground), 1, 2, false).
 		AddItem(l.textViewMenuControl(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
 			SetDynamicColors(true).SetRegions(true).
-			SetText(aboutMenu), func() {
+			SetText(i18n.Markup(keymapMenu)), func() {
+			l.showKeymap()
+		}), 1, 2, false).
+		AddItem(l.textViewMenuControl(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
+			SetDynamicColors(true).SetRegions(true).
+			SetText(i18n.Markup(aboutMenu)), func() {
 			go func() {
 				l.showAbout()
 			}()
 		}), 1, 2, false).
 		AddItem(l.textViewMenuControl(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).SetRegions(true).
 			SetDynamicColors(true).
-			SetText(quitMenu), func() {
+			SetText(i18n.Markup(quitMenu)), func() {
 			l.app.Stop()
 		}), 1, 1, false).
 		AddItem(NewHorizontalSeparator(sepStyle, LineHThick, "", sepForeground), 1, 2, false).
-		AddItem(tview.NewBox().SetBackgroundColor(color.ColorBackgroundField), 0, 1, false).
+		AddItem(tview.NewBox().SetBackgroundColor(color.ColorBackgroundField), 0, 1, false)
+	if l.coverage != nil {
+		l.navMenu.
+			AddItem(l.textViewMenuControl(l.driftView.SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)),
+				l.showDriftReport), 1, 1, false)
+	}
+	l.navMenu.
 		AddItem(l.linesView.SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)), 1, 1, false)
 
 	l.mainMenu = tview.NewFlex().SetDirection(tview.FlexColumn)
@@ -165,7 +297,7 @@ func (l *LogView) updateBottomBarMenu() {
 	l.mainMenu.
 		AddItem(l.textViewMenuControl(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
 			SetDynamicColors(true).SetRegions(true).
-			SetText(`[yellow:default:b](^t) [-:default:u]["1"]Template[""]`), func() {
+			SetText(i18n.Markup(`[yellow:default:b](^t) [-:default:u]["1"]Template[""]`)), func() {
 			if l.isTemplateViewShown() {
 				// TODO: Find a reliable way to respond to external closure
 			} else {
@@ -178,23 +310,24 @@ func (l *LogView) updateBottomBarMenu() {
 		l.mainMenu.
 			AddItem(l.textViewMenuControl(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
 				SetDynamicColors(true).SetRegions(true).
-				SetText(`[yellow:default:b](TAB) [-:default:u]["1"]Focus Log Entry[""]`), func() {
+				SetText(i18n.Markup(`[yellow:default:b](TAB) [-:default:u]["1"]Focus Log Entry[""]`)), func() {
 				go l.app.SetFocus(l.jsonView.textView)
 			}), 0, 3, false)
 	} else if l.isJsonViewShown() && l.jsonView.HasFocus() {
 		l.mainMenu.
 			AddItem(l.textViewMenuControl(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
 				SetDynamicColors(true).SetRegions(true).
-				SetText(`[yellow:default:b](TAB) [-:default:u]["1"]Focus Stream Table[""]`), func() {
+				SetText(i18n.Markup(`[yellow:default:b](TAB) [-:default:u]["1"]Focus Stream Table[""]`)), func() {
 				go l.app.SetFocus(l.table)
 			}), 0, 3, false)
 	}
 	l.mainMenu.
 		AddItem(l.textViewMenuControl(tview.NewTextView().SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
 			SetDynamicColors(true).SetRegions(true).
-			SetText(`[yellow:default:b](^c) [-:default:u]["1"]Quit[""]`), func() {
+			SetText(i18n.Markup(`[yellow:default:b](^c) [-:default:u]["1"]Quit[""]`)), func() {
 			l.app.Stop()
 		}), 0, 2, false).
+		AddItem(l.memView.SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)), 0, 2, false).
 		AddItem(l.linesView.SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)), 0, 3, false)
 }
 
@@ -235,23 +368,29 @@ func (l *LogView) updateLineView() {
 				Sprintf(`[green:default:b]%d[yellow:default:-] lines`,
 					l.globalCount))
 	}
-	if l.isFollowing {
-		l.followingView.SetText(autoScrollOnMenu).SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField))
+	if l.isSnapshot() {
+		l.followingView.SetText(fmt.Sprintf(i18n.Markup(frozenMenu), l.snapshotName)).SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField))
+	} else if l.isFollowing {
+		l.followingView.SetText(i18n.Markup(autoScrollOnMenu)).SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField))
 	} else {
-		l.followingView.SetText(autoScrollOffMenu).SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField))
+		l.followingView.SetText(i18n.Markup(autoScrollOffMenu)).SetTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField))
 	}
 }
 
 func (l *LogView) toggleSelectionMouse() {
+	if selectionCopies() {
+		l.copySelected()
+		return
+	}
 	l.selectionEnabled = !l.selectionEnabled
 	l.app.app.EnableMouse(!l.selectionEnabled)
 	go func() {
 		if l.selectionEnabled {
-			l.app.ShowPopMessage("Mouse disabled! Click and drag to select...", 2, l.table)
-			l.mouseSel.SetText(selectionMouseDisabledMenu)
+			l.app.ShowPopMessage(i18n.T("Mouse disabled! Click and drag to select..."), 2, l.table)
+			l.mouseSel.SetText(i18n.Markup(selectionMouseDisabledMenu))
 		} else {
-			l.app.ShowPopMessage("Selection disabled! Mouse input active...", 2, l.table)
-			l.mouseSel.SetText(selectionMouseEnabledMenu)
+			l.app.ShowPopMessage(i18n.T("Selection disabled! Mouse input active..."), 2, l.table)
+			l.mouseSel.SetText(i18n.Markup(selectionMouseEnabledMenu))
 		}
 		l.app.Draw()
 	}()
@@ -0,0 +1,98 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/badaniya/loggo/internal/char"
+	"github.com/badaniya/loggo/internal/color"
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// annotateSelected opens a note editor for the currently selected entry. The
+// note is stored alongside the entry itself so it survives re-filtering and is
+// carried over whenever the entry is copied or exported.
+func (l *LogView) annotateSelected() {
+	r, _ := l.table.GetSelection()
+	l.filterLock.RLock()
+	if r <= 0 || r-1 >= len(l.finSlice) {
+		l.filterLock.RUnlock()
+		return
+	}
+	row := l.finSlice[r-1]
+	current, _ := row[config.Note].(string)
+	l.filterLock.RUnlock()
+	l.showNoteEditor(fmt.Sprintf("Note for line %d", r), current, row)
+}
+
+// showNoteEditor opens a note editor, setting the note of all the entries
+// once done.
+func (l *LogView) showNoteEditor(title, current string, rows ...map[string]interface{}) {
+	input := tview.NewInputField().
+		SetText(current).
+		SetPlaceholder("Type a note, empty to remove...").
+		SetFieldStyle(color.FieldStyle).
+		SetPlaceholderStyle(color.PlaceholderStyle)
+	input.SetBackgroundColor(tcell.ColorDarkBlue)
+	input.SetDoneFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyEnter:
+			for _, row := range rows {
+				l.setNote(row, input.GetText())
+			}
+			l.app.DismissModal(l.table)
+		case tcell.KeyEsc:
+			l.app.DismissModal(l.table)
+		}
+	})
+	modal := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(tview.NewTextView().
+			SetDynamicColors(true).
+			SetText(fmt.Sprintf(`[yellow::b]%s %s[-::-]`, char.SymNote, title)).
+			SetTextAlign(tview.AlignCenter), 1, 1, false).
+		AddItem(tview.NewBox().SetBackgroundColor(tcell.ColorDarkBlue), 1, 1, false).
+		AddItem(input, 1, 1, true)
+	modal.SetBackgroundColor(tcell.ColorDarkBlue).SetBorderPadding(1, 1, 2, 2)
+	l.app.ShowModal(modal, 70, 7, tcell.ColorDarkBlue, nil)
+	l.app.SetFocus(input)
+}
+
+func (l *LogView) setNote(row map[string]interface{}, note string) {
+	l.filterLock.Lock()
+	defer l.filterLock.Unlock()
+	note = strings.TrimSpace(note)
+	if len(note) == 0 {
+		delete(row, config.Note)
+	} else {
+		row[config.Note] = note
+	}
+}
+
+func hasNote(row map[string]interface{}) bool {
+	_, ok := row[config.Note]
+	return ok
+}
@@ -0,0 +1,127 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+
+	"github.com/atotto/clipboard"
+	"github.com/badaniya/loggo/internal/color"
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/format"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// pasteLines is the format option for JSON lines, or plain text ones.
+const pasteLines = "JSON / text lines"
+
+// showPaste prompts for log lines to paste, e.g. off a ticket, opening them
+// in a new tab with all the usual features rather than going through a file.
+func (l *LogView) showPaste() {
+	text := tview.NewTextArea().
+		SetPlaceholder("Paste log lines here (^v pastes the clipboard)...").
+		SetClipboard(func(s string) {
+			_ = clipboard.WriteAll(s)
+		}, func() string {
+			s, _ := clipboard.ReadAll()
+			return s
+		})
+	text.SetTextStyle(color.FieldStyle)
+	formats := append([]string{pasteLines}, format.Names()...)
+	formatIndex := 0
+	dismiss := func() {
+		l.app.DismissModal(l.table)
+	}
+	form := tview.NewForm().
+		SetHorizontal(true).
+		AddDropDown("Format", formats, 0, func(_ string, index int) {
+			formatIndex = index
+		}).
+		AddButton("Open", func() {
+			var parser format.Parser
+			if formatIndex > 0 {
+				parser, _ = format.NewParser(formats[formatIndex])
+			}
+			rows := format.ParseText(text.GetText(), parser)
+			if len(rows) == 0 {
+				go l.app.ShowPopMessage("Nothing to open, paste some log lines first.", 2, text)
+				return
+			}
+			dismiss()
+			l.app.addPaste(rows, l)
+		}).
+		AddButton("Cancel", dismiss)
+	form.SetFieldBackgroundColor(color.ColorBackgroundField).
+		SetFieldTextColor(color.ColorForegroundField).
+		SetBackgroundColor(tcell.ColorDarkBlue)
+	modal := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(tview.NewTextView().
+			SetDynamicColors(true).
+			SetText(`[yellow::b]Paste Log Lines[-::-]`).
+			SetTextAlign(tview.AlignCenter), 1, 1, false).
+		AddItem(text, 0, 1, true).
+		AddItem(form, 3, 1, false)
+	modal.SetBackgroundColor(tcell.ColorDarkBlue)
+	l.app.ShowModal(modal, 100, 24, tcell.ColorDarkBlue, func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc:
+			dismiss()
+			return nil
+		case tcell.KeyTAB:
+			if text.HasFocus() {
+				l.app.SetFocus(form)
+				return nil
+			}
+		case tcell.KeyBacktab:
+			if form.HasFocus() {
+				l.app.SetFocus(text)
+				return nil
+			}
+		}
+		return event
+	})
+	l.app.SetFocus(text)
+}
+
+// addPaste opens the pasted entries in a read-only tab, laid out per the
+// source's template if saved, or per one sampled off the entries otherwise.
+func (a *LoggoApp) addPaste(rows []map[string]interface{}, source *LogView) {
+	a.pasteCount++
+	cfg, keyMap := source.config, source.keyMap
+	if len(cfg.LastSavedName) == 0 {
+		cfg, keyMap = config.MakeConfigFromSample(rows)
+	}
+	widths := config.NewColumnWidths()
+	for _, row := range rows {
+		widths.Observe(cfg.Keys, row)
+	}
+	pv := NewSnapshotView(a, fmt.Sprintf("Paste %d", a.pasteCount), rows, &LogView{
+		config:   cfg,
+		keyMap:   keyMap,
+		decoders: source.decoders,
+		widths:   widths,
+	})
+	a.views = append(a.views, pv)
+	a.showView(len(a.views) - 1)
+}
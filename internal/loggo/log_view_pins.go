@@ -0,0 +1,188 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/badaniya/loggo/internal/char"
+	"github.com/badaniya/loggo/internal/color"
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// pinsWidth is the width of the pinned entries side panel.
+const pinsWidth = 40
+
+// togglePinSelected pins the selected entry to the side panel, keeping it
+// visible while scrolling on, or unpins it if it's already pinned. Pinned
+// entries stay there even once evicted from the buffer.
+func (l *LogView) togglePinSelected() {
+	r, _ := l.table.GetSelection()
+	l.filterLock.Lock()
+	if r <= 0 || r-1 >= len(l.finSlice) {
+		l.filterLock.Unlock()
+		return
+	}
+	row := l.finSlice[r-1]
+	if i := l.pinIndex(row); i >= 0 {
+		l.pins = append(l.pins[:i], l.pins[i+1:]...)
+	} else {
+		l.pins = append(l.pins, row)
+	}
+	l.filterLock.Unlock()
+	l.updatePins()
+	l.relayoutPins()
+}
+
+// unpin removes the i-th pinned entry.
+func (l *LogView) unpin(i int) {
+	l.filterLock.Lock()
+	if i >= 0 && i < len(l.pins) {
+		l.pins = append(l.pins[:i], l.pins[i+1:]...)
+	}
+	empty := len(l.pins) == 0
+	l.filterLock.Unlock()
+	l.updatePins()
+	if empty {
+		l.relayoutPins()
+	}
+}
+
+// relayoutPins shows or hides the side panel, unless an entry or the template
+// is being looked at.
+func (l *LogView) relayoutPins() {
+	if !l.isJsonViewShown() && !l.isTemplateViewShown() {
+		l.makeLayouts()
+	}
+}
+
+// pinIndex returns the position of the entry amongst the pinned ones, or -1.
+// Callers must hold the filterLock.
+func (l *LogView) pinIndex(row map[string]interface{}) int {
+	for i, p := range l.pins {
+		if sameEntry(p, row) {
+			return i
+		}
+	}
+	return -1
+}
+
+// jumpToPin selects the i-th pinned entry in the table, unless it's been
+// filtered out or evicted from the buffer.
+func (l *LogView) jumpToPin(i int) {
+	l.filterLock.RLock()
+	line := -1
+	if i >= 0 && i < len(l.pins) {
+		for n, row := range l.finSlice {
+			if sameEntry(row, l.pins[i]) {
+				line = n + 1
+				break
+			}
+		}
+	}
+	l.filterLock.RUnlock()
+	if line < 0 {
+		l.pinsView.SetTitle(fmt.Sprintf("%s Pinned [red::]not in view[-::]", char.SymPin))
+		return
+	}
+	l.pinsView.SetTitle(fmt.Sprintf("%s Pinned", char.SymPin))
+	l.isFollowing = false
+	l.updateLineView()
+	l.table.Select(line, 0)
+	l.app.SetFocus(l.table)
+}
+
+// focusPins moves the focus onto the pinned entries side panel, if any.
+func (l *LogView) focusPins() {
+	if l.pinsView.GetItemCount() > 0 {
+		l.app.SetFocus(l.pinsView)
+	}
+}
+
+// updatePins re-renders the pinned entries side panel.
+func (l *LogView) updatePins() {
+	l.filterLock.RLock()
+	defer l.filterLock.RUnlock()
+	current := l.pinsView.GetCurrentItem()
+	l.pinsView.Clear().SetTitle(fmt.Sprintf("%s Pinned", char.SymPin))
+	for _, row := range l.pins {
+		l.pinsView.AddItem(l.pinSummary(row), "", 0, nil)
+	}
+	if current < l.pinsView.GetItemCount() {
+		l.pinsView.SetCurrentItem(current)
+	}
+}
+
+// pinSummary renders the template key values of the entry on a single line.
+func (l *LogView) pinSummary(row map[string]interface{}) string {
+	if config.IsText(row) {
+		return renderCell(fmt.Sprintf("%v", row[config.TextPayload]))
+	}
+	var values []string
+	for i := range l.config.Keys {
+		k := &l.config.Keys[i]
+		if v := k.ExtractValue(row); len(v) > 0 {
+			values = append(values, l.displayValue(k, v))
+		}
+	}
+	return renderCell(strings.Join(values, " "))
+}
+
+func (l *LogView) makePinsView() {
+	l.pinsView = tview.NewList().
+		ShowSecondaryText(false).
+		SetHighlightFullLine(true).
+		SetSelectedFunc(func(i int, _ string, _ string, _ rune) {
+			l.jumpToPin(i)
+		})
+	l.pinsView.SetMainTextStyle(tcell.StyleDefault.Background(color.ColorBackgroundField)).
+		SetBorder(true).
+		SetTitle(fmt.Sprintf("%s Pinned", char.SymPin)).
+		SetBackgroundColor(color.ColorBackgroundField)
+	l.pinsView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc:
+			l.app.SetFocus(l.table)
+			return nil
+		case tcell.KeyDelete, tcell.KeyBackspace, tcell.KeyBackspace2:
+			l.unpin(l.pinsView.GetCurrentItem())
+			return nil
+		}
+		switch event.Rune() {
+		case 'd', 'P':
+			l.unpin(l.pinsView.GetCurrentItem())
+			return nil
+		}
+		return event
+	})
+}
+
+// sameEntry tells whether both refer to the very same entry, rather than to
+// equal ones, e.g. repeated health checks.
+func sameEntry(a, b map[string]interface{}) bool {
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
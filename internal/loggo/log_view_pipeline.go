@@ -0,0 +1,123 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"strings"
+
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/metrics"
+	"github.com/badaniya/loggo/internal/pipeline"
+	"github.com/badaniya/loggo/internal/reader"
+	"github.com/badaniya/loggo/internal/secrets"
+)
+
+// makePipeline composes the stages every ingested line goes through, as per
+// the template loaded, see pipeline.Stage.
+func (l *LogView) makePipeline() *pipeline.Pipeline {
+	p := pipeline.New()
+	p.Register(pipeline.Parse, "json", pipeline.ParseJSON)
+	depth := l.config.FlattenDepth()
+	l.fieldsAsRead = depth == 0 && l.extractor == nil && l.enricher == nil && l.severities == nil
+	if depth > 0 {
+		p.Register(pipeline.Parse, "flatten", pipeline.Parsed(pipeline.Fields(func(m map[string]interface{}) {
+			config.Flatten(m, depth)
+		})))
+	}
+	if l.extractor != nil {
+		p.Register(pipeline.Enrich, "extract", pipeline.Fields(l.extractor.Apply))
+	}
+	if l.enricher != nil {
+		p.Register(pipeline.Enrich, "enrich", pipeline.Fields(l.enricher.Apply))
+	}
+	if l.severities != nil {
+		p.Register(pipeline.Enrich, "severity", pipeline.Parsed(pipeline.Fields(l.severities.Apply)))
+	}
+	// novelty alters the entry, flagging it, so it isn't a mere watch
+	p.Register(pipeline.Enrich, "novelty", pipeline.Fields(func(m map[string]interface{}) {
+		if l.novelty.Observe(m) {
+			m[config.Novel] = true
+		}
+	}))
+	if l.secretTally != nil {
+		p.Register(pipeline.Redact, "secrets", pipeline.Fields(l.flagSecrets))
+	}
+	p.Register(pipeline.Watch, "errors", pipeline.Parsed(pipeline.Fields(l.countError)))
+	p.Register(pipeline.Watch, "coverage", pipeline.Parsed(pipeline.Fields(func(m map[string]interface{}) {
+		l.coverage.Observe(l.config.Keys, m)
+	})))
+	p.Register(pipeline.Watch, "widths", pipeline.Fields(func(m map[string]interface{}) {
+		l.widths.Observe(l.config.Keys, m)
+	}))
+	if l.sources != nil {
+		p.Register(pipeline.Watch, "sources", pipeline.Fields(l.sources.Observe))
+	}
+	if len(l.watches) > 0 {
+		p.Register(pipeline.Watch, "watches", pipeline.Fields(l.watch))
+	}
+	if !l.internals {
+		p.Register(pipeline.Watch, "metrics", func(e *pipeline.Entry) bool {
+			if e.Err != nil {
+				metrics.Default().Observe(e.Line, nil)
+			} else {
+				metrics.Default().Observe(e.Line, e.Fields)
+			}
+			return true
+		})
+	}
+	p.Register(pipeline.View, "arrival", pipeline.Fields(l.markArrival))
+	return p
+}
+
+// templateChanged has the pipeline rebuilt before the next line, as per the
+// template as edited.
+func (l *LogView) templateChanged() {
+	l.pipelineStale.Store(true)
+}
+
+// refreshPipeline rebuilds the pipeline if the template changed since it was
+// made, from the goroutine reading the stream, which alone runs it.
+func (l *LogView) refreshPipeline() {
+	if l.pipelineStale.Swap(false) {
+		l.pipeline = l.makePipeline()
+	}
+}
+
+// process runs the line through the pipeline, as parsed ahead by the reader
+// if it was, returning nil if dropped.
+func (l *LogView) process(line string) *pipeline.Entry {
+	if p, ok := l.chanReader.(reader.Preparser); ok {
+		if fields, err := p.Parsed(line); fields != nil {
+			return l.pipeline.ProcessParsed(line, fields, err)
+		}
+	}
+	return l.pipeline.Process(line)
+}
+
+// flagSecrets flags the entries holding secrets, tallying them.
+func (l *LogView) flagSecrets(m map[string]interface{}) {
+	if findings := secrets.Scan(m); len(findings) > 0 {
+		m[config.Secret] = strings.Join(secrets.Kinds(findings), ", ")
+		l.secretTally.Observe(m, findings)
+	}
+}
@@ -23,8 +23,8 @@ THE SOFTWARE.
 package loggo
 
 import (
-	"encoding/json"
 	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
@@ -32,12 +32,21 @@ import (
 	"github.com/badaniya/loggo/internal/filter"
 
 	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/enrich"
+	"github.com/badaniya/loggo/internal/payload"
+	"github.com/badaniya/loggo/internal/reader"
+	"github.com/badaniya/loggo/internal/util"
 	"github.com/rivo/tview"
 )
 
 func (l *LogView) read() {
+	l.backfills = make(chan []string)
+	if p, ok := l.chanReader.(reader.Preparser); ok {
+		p.ParseAhead(config.ParseLine)
+	}
 	go func() {
 		if err := l.chanReader.StreamInto(); err != nil {
+			l.app.alert()
 			l.app.ShowPrefabModal(fmt.Sprintf("Unable to start stream: %v", err), 40, 10,
 				func(event *tcell.EventKey) *tcell.EventKey {
 					switch event.Key() {
@@ -56,77 +65,187 @@ func (l *LogView) read() {
 					l.app.Stop()
 				}))
 		} else {
+			util.Log().Debug("Input stream started")
+			l.watchLoad()
 			if len(l.config.LastSavedName) > 0 {
 				l.keyMap = l.config.KeyMap()
+				l.loadDecoders()
+				l.loadSeverities()
+				l.loadExtractor()
+				l.loadEnricher()
+				l.loadWatches()
+			}
+			l.novelty = config.NewNoveltyTracker(l.config.NoveltyWarmUpDuration())
+			if l.bursts != nil {
+				l.bursts.SetFactor(l.config.BurstFactorValue())
+			}
+			if l.sources != nil {
+				l.sources.Configure(l.config.SourceKey, l.config.NoisyWindowDuration())
 			}
+			l.pipeline = l.makePipeline()
 			for {
-				t := <-l.chanReader.ChanReader()
-				if len(t) > 0 {
-					m := make(map[string]interface{})
-					err := json.Unmarshal([]byte(t), &m)
-					if err != nil {
-						m[config.ParseErr] = err.Error()
-						m[config.TextPayload] = t
-					}
+				var t string
+				select {
+				case t = <-l.chanReader.ChanReader():
+				case lines := <-l.backfills:
+					l.refreshPipeline()
+					l.prepend(lines)
+					continue
+				}
+				if l.closed.Load() {
+					return
+				}
+				if len(t) == 0 {
+					continue
+				}
+				l.refreshPipeline()
+				if e := l.process(t); e != nil {
+					l.filterLock.Lock()
+					m, line := l.retain(e)
 					l.inSlice = append(l.inSlice, m)
+					l.rawLines = append(l.rawLines, line)
+					l.filterLock.Unlock()
 				}
 			}
 		}
 	}()
 }
 
+func (l *LogView) loadDecoders() {
+	decoders, err := payload.MakeFieldDecoders(l.config.Decoders)
+	if err != nil {
+		util.Log().WithField("code", err).Error("Unable to load payload decoders")
+		go l.app.ShowPopMessage(fmt.Sprintf("Unable to load payload decoders: %v", err), 5, l.table)
+		return
+	}
+	l.decoders = decoders
+}
+
+func (l *LogView) loadSeverities() {
+	severities, err := config.MakeSeverityMapper(l.config.Severity)
+	if err != nil {
+		util.Log().WithField("code", err).Error("Unable to load severity mapping")
+		go l.app.ShowPopMessage(fmt.Sprintf("Unable to load severity mapping: %v", err), 5, l.table)
+		return
+	}
+	l.severities = severities
+}
+
+func (l *LogView) loadExtractor() {
+	extractor, err := config.MakeExtractor(l.config.Extract)
+	if err != nil {
+		util.Log().WithField("code", err).Error("Unable to load extractions")
+		go l.app.ShowPopMessage(fmt.Sprintf("Unable to load extractions: %v", err), 5, l.table)
+		return
+	}
+	l.extractor = extractor
+}
+
+func (l *LogView) loadEnricher() {
+	enricher, err := enrich.MakeEnricher(l.config.Enrich)
+	if err != nil {
+		util.Log().WithField("code", err).Error("Unable to load enrichments")
+		go l.app.ShowPopMessage(fmt.Sprintf("Unable to load enrichments: %v", err), 5, l.table)
+		return
+	}
+	l.enricher = enricher
+}
+
 func (l *LogView) processSampleForConfig(sampling []map[string]interface{}) {
-	if len(l.config.LastSavedName) > 0 || l.isTemplateViewShown() {
+	if len(l.config.LastSavedName) > 0 || l.isTemplateViewShown() || l.isSnapshot() || l.internals {
 		return
 	}
+	shared := l.config == l.app.config
+	keys := l.config.Keys
 	l.config, l.keyMap = config.MakeConfigFromSample(sampling, l.config.Keys...)
-	l.app.config = l.config
+	if !reflect.DeepEqual(keys, l.config.Keys) {
+		l.coverage.Reset()
+		l.templateChanged()
+	}
+	if shared {
+		l.app.config = l.config
+	}
 }
 
 func (l *LogView) filter() {
 	go func() {
-		for {
+		for !l.closed.Load() {
 			l.rebufferFilter = false
 			exp := <-l.filterChannel
 			l.clearFilterBuffer()
 			l.globalCount = 0
 			l.updateLineView()
 			l.app.Draw()
-			for i := 0; ; {
-				lastUpdate := time.Now().Add(-time.Minute)
-				if l.rebufferFilter {
+			var lastUpdate time.Time
+			pending := false
+			for i, _ := l.bufferedRange(); ; {
+				if l.rebufferFilter || l.closed.Load() {
 					break
 				}
-				size := len(l.inSlice)
-				if i < size {
-					if err := l.filterLine(exp, i); err != nil {
-						break
+				first, size := l.bufferedRange()
+				i = max(i, first)
+				if i >= size {
+					if pending {
+						pending = false
+						l.refreshFiltered()
 					}
-					i++
-				} else {
 					time.Sleep(100 * time.Millisecond)
 					continue
 				}
-				now := time.Now()
-				if now.Sub(lastUpdate)*time.Millisecond > 500 {
-					lastUpdate = now
-					l.app.Draw()
-					if l.isFollowing {
-						l.table.ScrollToEnd()
-					}
+				if err := l.filterLine(exp, i); err != nil {
+					break
+				}
+				i++
+				pending = true
+				// redraw progressively rather than upon every line
+				if time.Since(lastUpdate) > filterRedrawInterval {
+					lastUpdate = time.Now()
+					pending = false
+					l.refreshFiltered()
 				}
 			}
 		}
 	}()
 }
 
+// filterRedrawInterval is how often the table is redrawn while filtering.
+const filterRedrawInterval = 200 * time.Millisecond
+
+// requestFilter has the filter routine start over with the expression, in
+// place of any request it hasn't picked up yet, so the caller never waits for
+// it to be done with the current one.
+func (l *LogView) requestFilter(exp *filter.Expression) {
+	l.rebufferFilter = true
+	for {
+		select {
+		case l.filterChannel <- exp:
+			return
+		default:
+		}
+		// latest wins, drop the stale request
+		select {
+		case <-l.filterChannel:
+		default:
+		}
+	}
+}
+
+// refreshFiltered shows the entries filtered so far.
+func (l *LogView) refreshFiltered() {
+	l.updateLineView()
+	if l.isFollowing {
+		l.followLatest()
+	}
+	l.app.Draw()
+}
+
 func (l *LogView) clearFilterBuffer() {
 	l.filterLock.Lock()
 	defer l.filterLock.Unlock()
 	l.finSlice = l.finSlice[:0]
 }
 
-func (l *LogView) sampleAndCount() {
+func (l *LogView) sample() {
 	if len(l.config.LastSavedName) == 0 {
 		if len(l.finSlice) > 20 {
 			l.processSampleForConfig(l.finSlice[len(l.finSlice)-20:])
@@ -134,20 +253,23 @@ func (l *LogView) sampleAndCount() {
 			l.processSampleForConfig(l.finSlice)
 		}
 	}
-	l.updateLineView()
 }
 
 func (l *LogView) filterLine(e *filter.Expression, index int) error {
 	l.filterLock.Lock()
 	defer l.filterLock.Unlock()
-	row := l.inSlice[index]
+	if index < l.bufferStart {
+		// evicted meanwhile
+		return nil
+	}
+	row := l.inSlice[index-l.bufferStart]
 	if e == nil {
 		l.finSlice = append(l.finSlice, row)
 		l.globalCount++
-		l.sampleAndCount()
+		l.sample()
 		return nil
 	}
-	a, err := e.Apply(row, l.keyMap)
+	a, err := e.ApplyLine(row, l.rawLine(index-l.bufferStart), l.keyMap, l.currentMatching())
 	if err != nil {
 		l.app.ShowPrefabModal(fmt.Sprintf("[yellow:default:b]Error interpreting filter expression:[-:default:-]\n"+
 			"Filter stream has reset. Please adjust the filter expression"+
@@ -168,13 +290,13 @@ func (l *LogView) filterLine(e *filter.Expression, index int) error {
 			tview.NewButton("[darkred:default:bu]C[-:default:-]ancel").SetSelectedFunc(func() {
 				l.app.DismissModal(l.table)
 			}))
-		l.filterChannel <- nil
+		l.requestFilter(nil)
 		return err
 	}
 	if a {
 		l.finSlice = append(l.finSlice, row)
 		l.globalCount++
-		l.sampleAndCount()
+		l.sample()
 	}
 	return nil
 }
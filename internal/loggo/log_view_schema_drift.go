@@ -0,0 +1,90 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/badaniya/loggo/internal/i18n"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const schemaDriftMenu = `[yellow:default:b] ⚠       [-:default:u]["1"]%d key(s) drifting[""]`
+
+// watchSchemaDrift periodically refreshes the drift indicator so that a
+// template that no longer matches the incoming entries gets noticed.
+func (l *LogView) watchSchemaDrift() {
+	go func() {
+		for !l.closed.Load() {
+			time.Sleep(2 * time.Second)
+			if l.updateDriftView() {
+				l.app.Draw()
+			}
+		}
+	}()
+}
+
+func (l *LogView) updateDriftView() bool {
+	text := ""
+	if drift := l.coverage.Drifting(); len(drift) > 0 {
+		text = fmt.Sprintf(i18n.Markup(schemaDriftMenu), len(drift))
+	}
+	if text == l.driftView.GetText(false) {
+		return false
+	}
+	l.driftView.SetText(text)
+	return true
+}
+
+func (l *LogView) showDriftReport() {
+	report := l.coverage.Report()
+	sb := strings.Builder{}
+	sb.WriteString("[yellow::b]Template keys lacking from incoming entries[-::-]\n\n")
+	if len(report) == 0 {
+		sb.WriteString("All template keys are present in every entry.")
+	}
+	for _, d := range report {
+		sb.WriteString(fmt.Sprintf("[::b]%s[::-]: %.1f%% (%d of %d)\n",
+			d.Name, d.MissingRatio*100, d.Missing, d.Observed))
+	}
+	l.app.ShowPrefabModal(sb.String(), 70, len(report)+8,
+		func(event *tcell.EventKey) *tcell.EventKey {
+			switch event.Key() {
+			case tcell.KeyEnter, tcell.KeyEsc:
+				l.app.DismissModal(l.table)
+				return nil
+			}
+			switch event.Rune() {
+			case 'O', 'o':
+				l.app.DismissModal(l.table)
+				return nil
+			}
+			return event
+		},
+		tview.NewButton("[darkred::bu]O[-::-]k").SetSelectedFunc(func() {
+			l.app.DismissModal(l.table)
+		}))
+}
@@ -0,0 +1,138 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/badaniya/loggo/internal/char"
+	"github.com/badaniya/loggo/internal/secrets"
+	"github.com/rivo/tview"
+)
+
+const secretsBanner = `[white:red:b] %s Secrets logged [::-]%d entries with %s, last at %s   ` +
+	`["jump"][yellow::b]![white::-] [::u]jump to the last one[::-][""]   ["dismiss"][::u]dismiss[::-][""] `
+
+// makeSecretsView builds the banner shown above the table once entries
+// holding secrets are ingested, clicking its actions either jumps to the last
+// one or dismisses it.
+func (l *LogView) makeSecretsView() {
+	l.secretsView = tview.NewTextView().
+		SetRegions(true).
+		SetDynamicColors(true).
+		SetWrap(false)
+	l.secretsView.SetHighlightedFunc(func(added, removed, remaining []string) {
+		if len(added) == 0 {
+			return
+		}
+		l.secretsView.Highlight()
+		switch added[0] {
+		case "jump":
+			l.jumpToSecret()
+		case "dismiss":
+			l.dismissSecrets()
+		}
+	})
+}
+
+// watchSecrets periodically checks for entries holding secrets, showing the
+// banner, and alerting, whenever further ones are ingested.
+func (l *LogView) watchSecrets() {
+	go func() {
+		var shown *secrets.Alert
+		for !l.closed.Load() {
+			time.Sleep(time.Second)
+			alert := l.secretTally.Alert()
+			if shown == nil && alert == nil ||
+				shown != nil && alert != nil && sameEntry(shown.Last, alert.Last) {
+				continue
+			}
+			if alert != nil {
+				l.app.alert()
+			}
+			shown = alert
+			l.updateSecretsView(alert)
+			l.app.Draw()
+		}
+	}()
+}
+
+func (l *LogView) updateSecretsView(alert *secrets.Alert) {
+	if alert == nil {
+		l.secretsView.SetText("")
+		l.tableContent.ResizeItem(l.secretsView, 0, 0)
+		return
+	}
+	l.secretsView.SetText(fmt.Sprintf(secretsBanner, char.SymSecret, alert.Entries,
+		strings.Join(alert.Kinds, ", "), alert.At.Format("15:04:05")))
+	l.tableContent.ResizeItem(l.secretsView, 1, 0)
+}
+
+// secretsHeight is the banner height, none unless entries holding secrets
+// were ingested since the last dismissal.
+func (l *LogView) secretsHeight() int {
+	if len(l.secretsView.GetText(false)) == 0 {
+		return 0
+	}
+	return 1
+}
+
+// jumpToSecret selects the last entry holding secrets and dismisses the
+// banner, unless the entry is filtered out.
+func (l *LogView) jumpToSecret() {
+	if l.secretTally == nil {
+		return
+	}
+	alert := l.secretTally.Alert()
+	if alert == nil {
+		return
+	}
+	l.filterLock.RLock()
+	line := -1
+	for n := len(l.finSlice) - 1; n >= 0; n-- {
+		if sameEntry(l.finSlice[n], alert.Last) {
+			line = n + 1
+			break
+		}
+	}
+	l.filterLock.RUnlock()
+	if line < 0 {
+		go l.app.ShowPopMessage("The entry holding secrets is filtered out of view.", 2, l.table)
+		return
+	}
+	l.dismissSecrets()
+	l.isFollowing = false
+	l.updateLineView()
+	l.table.Select(line, 0)
+	l.app.SetFocus(l.table)
+}
+
+func (l *LogView) dismissSecrets() {
+	if l.secretTally == nil {
+		return
+	}
+	l.secretTally.Dismiss()
+	l.updateSecretsView(nil)
+}
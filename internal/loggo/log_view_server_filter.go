@@ -0,0 +1,106 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+
+	"github.com/badaniya/loggo/internal/reader"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const serverFilterMenu = `[yellow:default:b] ^g      [-:default:u]["1"]Push Filter to GCP[""]`
+
+func (l *LogView) serverFilterer() (reader.ServerFilterer, bool) {
+	if l.isSnapshot() || l.chanReader == nil {
+		return nil, false
+	}
+	sf, ok := l.chanReader.(reader.ServerFilterer)
+	return sf, ok
+}
+
+// pushFilterToServer translates the current local filter into a Cloud Logging
+// filter and, once confirmed, restarts the GCP stream narrowed server side.
+// Pushing an empty local filter restores the initial GCP query.
+func (l *LogView) pushFilterToServer() {
+	sf, ok := l.serverFilterer()
+	if !ok {
+		return
+	}
+	query := ""
+	text := "Restore the initial GCP filter?"
+	if l.filterExpression != nil {
+		var err error
+		if query, err = l.filterExpression.ToCloudLoggingMatching(l.currentMatching()); err != nil {
+			l.app.ShowPrefabModal(fmt.Sprintf("[yellow::b]Filter can't be applied by GCP:[-::-]\n[::i]%s",
+				tview.Escape(err.Error())), 60, 10,
+				func(event *tcell.EventKey) *tcell.EventKey {
+					switch event.Key() {
+					case tcell.KeyEnter, tcell.KeyEsc:
+						l.app.DismissModal(l.table)
+						return nil
+					}
+					return event
+				},
+				tview.NewButton("[darkred::bu]C[-::-]ancel").SetSelectedFunc(func() {
+					l.app.DismissModal(l.table)
+				}))
+			return
+		}
+		text = fmt.Sprintf("[yellow::b]Restart the GCP stream with the server side filter:[-::-]\n\n[::i]%s",
+			tview.Escape(query))
+	}
+
+	apply := func() {
+		l.app.DismissModal(l.table)
+		go func() {
+			if err := sf.ServerFilter(query); err != nil {
+				l.app.ShowPopMessage(fmt.Sprintf("Unable to apply server side filter: %v", err), 3, l.table)
+				return
+			}
+			l.app.ShowPopMessage("GCP stream restarted with the server side filter", 2, l.table)
+		}()
+	}
+	l.app.ShowPrefabModal(text, 70, 14,
+		func(event *tcell.EventKey) *tcell.EventKey {
+			switch event.Key() {
+			case tcell.KeyEsc:
+				l.app.DismissModal(l.table)
+				return nil
+			}
+			switch event.Rune() {
+			case 'A', 'a':
+				apply()
+				return nil
+			case 'C', 'c':
+				l.app.DismissModal(l.table)
+				return nil
+			}
+			return event
+		},
+		tview.NewButton("[darkgreen::bu]A[-::-]pply").SetSelectedFunc(apply),
+		tview.NewButton("[darkred::bu]C[-::-]ancel").SetSelectedFunc(func() {
+			l.app.DismissModal(l.table)
+		}))
+}
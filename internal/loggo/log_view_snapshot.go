@@ -0,0 +1,165 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/badaniya/loggo/internal/color"
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/filter"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const sortSnapshotMenu = `[yellow:default:b] o       [-:default:u]["1"]Sort By[""]`
+
+// NewSnapshotView builds a read-only log view over a frozen copy of entries.
+// Snapshots are never fed by a reader, so the captured window can be browsed,
+// filtered and annotated while the live stream continues in its own tab.
+func NewSnapshotView(app *LoggoApp, name string, rows []map[string]interface{}, source *LogView) *LogView {
+	lv := &LogView{
+		Flex:          *tview.NewFlex(),
+		app:           app,
+		config:        source.config,
+		keyMap:        source.keyMap,
+		decoders:      source.decoders,
+		widths:        source.widths,
+		inSlice:       rows,
+		pins:          append([]map[string]interface{}(nil), source.pins...),
+		snapshotName:  name,
+		filterChannel: make(chan *filter.Expression, 1),
+		filterLock:    sync.RWMutex{},
+		hideFilter:    true,
+	}
+	lv.makeUIComponents()
+	lv.updatePins()
+	lv.makeLayouts()
+	lv.filter()
+	lv.filterChannel <- nil
+	return lv
+}
+
+func (l *LogView) isSnapshot() bool {
+	return len(l.snapshotName) > 0
+}
+
+// snapshot freezes the currently filtered buffer into a new tab.
+func (l *LogView) snapshot() {
+	l.filterLock.RLock()
+	rows := make([]map[string]interface{}, len(l.finSlice))
+	copy(rows, l.finSlice)
+	l.filterLock.RUnlock()
+	if len(rows) == 0 {
+		go l.app.ShowPopMessage("Nothing to snapshot, the buffer is empty.", 2, l.table)
+		return
+	}
+	l.app.addSnapshot(rows, l)
+}
+
+// close stops the view's background routines so it can be discarded.
+func (l *LogView) close() {
+	l.closed.Store(true)
+	if l.internals {
+		l.chanReader.Close()
+	}
+	l.rebufferFilter = true
+	select {
+	case l.filterChannel <- nil:
+	default:
+	}
+}
+
+func (l *LogView) updateTabsView(views []*LogView, active int) {
+	sb := strings.Builder{}
+	for i, v := range views {
+		name := "Live"
+		if v.isSnapshot() {
+			name = v.snapshotName
+		} else if v.internals {
+			name = internalsTabName
+		} else if len(v.streamName) > 0 {
+			name = v.streamName
+		}
+		if i == active {
+			sb.WriteString(fmt.Sprintf(`[black:yellow:b] %s [-:-:-]`, name))
+		} else {
+			sb.WriteString(fmt.Sprintf(`[yellow::-] %s [-:-:-]`, name))
+		}
+	}
+	l.tabsView.SetText(sb.String())
+}
+
+// showSortSnapshot prompts for the template key to order the snapshot by.
+func (l *LogView) showSortSnapshot() {
+	if len(l.config.Keys) == 0 {
+		return
+	}
+	list := tview.NewList().ShowSecondaryText(false)
+	list.SetBackgroundColor(color.ColorBackgroundField).SetTitle("Sort By")
+	for i := range l.config.Keys {
+		k := l.config.Keys[i]
+		name := k.Name
+		if k.Name == l.sortedBy && l.sortDesc {
+			name += " (desc)"
+		} else if k.Name == l.sortedBy {
+			name += " (asc)"
+		}
+		list.AddItem(name, "", 0, func() {
+			l.app.DismissModal(l.table)
+			l.sortSnapshot(&k)
+		})
+	}
+	l.app.ShowModal(list, 50, len(l.config.Keys)+2, color.ColorBackgroundField,
+		func(event *tcell.EventKey) *tcell.EventKey {
+			if event.Key() == tcell.KeyEsc {
+				l.app.DismissModal(l.table)
+				return nil
+			}
+			return event
+		})
+	l.app.SetFocus(list)
+}
+
+// sortSnapshot orders the snapshot entries by the given key, comparing values
+// according to the key type (see config.Key.Compare). Sorting again by the
+// same key reverses the order.
+func (l *LogView) sortSnapshot(k *config.Key) {
+	desc := l.sortedBy == k.Name && !l.sortDesc
+	l.rebufferFilter = true
+	rows := make([]map[string]interface{}, len(l.inSlice))
+	copy(rows, l.inSlice)
+	sort.SliceStable(rows, func(i, j int) bool {
+		c := k.Compare(k.ExtractValue(rows[i]), k.ExtractValue(rows[j]))
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+	l.inSlice = rows
+	l.sortedBy, l.sortDesc = k.Name, desc
+	l.requestFilter(l.filterExpression)
+}
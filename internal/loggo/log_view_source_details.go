@@ -0,0 +1,113 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/badaniya/loggo/internal/char"
+	"github.com/badaniya/loggo/internal/reader"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// sourceDetails returns the details of the source the view streams, e.g. a
+// file's path and inode or a GCP project and filter, followed by those of
+// the template parsing it, so that streams open side by side can be told
+// apart.
+func (l *LogView) sourceDetails() []reader.Detail {
+	var details []reader.Detail
+	if l.isSnapshot() {
+		details = append(details, reader.Detail{Label: "Source", Value: "Snapshot"},
+			reader.Detail{Label: "Name", Value: l.snapshotName})
+	} else {
+		if len(l.streamName) > 0 {
+			details = append(details, reader.Detail{Label: "Stream", Value: l.streamName})
+		}
+		details = append(details, reader.Describe(l.chanReader)...)
+	}
+	template := "inferred from the stream"
+	if len(l.config.LastSavedName) > 0 {
+		template = l.config.LastSavedName
+	}
+	details = append(details, reader.Detail{Label: "Template", Value: template})
+	if len(l.config.Extends) > 0 {
+		details = append(details, reader.Detail{Label: "Extends", Value: l.config.Extends})
+	}
+	details = append(details, reader.Detail{Label: "Columns", Value: fmt.Sprintf("%d keys", len(l.config.Keys))})
+	for _, d := range l.config.Decoders {
+		details = append(details, reader.Detail{Label: "Decoder", Value: fmt.Sprintf("%s as %s", d.Key, d.Format)})
+	}
+	if len(l.config.Extract) > 0 {
+		details = append(details, reader.Detail{Label: "Extractions", Value: fmt.Sprintf("%d", len(l.config.Extract))})
+	}
+	return details
+}
+
+// showSourceDetails pops up the details of the source, see sourceDetails.
+func (l *LogView) showSourceDetails() {
+	details := l.sourceDetails()
+	width := 0
+	for _, d := range details {
+		width = max(width, len(d.Label))
+	}
+	sb := strings.Builder{}
+	for _, d := range details {
+		sb.WriteString(fmt.Sprintf("[yellow::b]%-*s[-::-] %s\n", width, d.Label,
+			tview.Escape(char.Printable(d.Value, 0))))
+	}
+	text := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWordWrap(true).
+		SetText(sb.String())
+	text.SetBackgroundColor(tcell.ColorDarkBlue)
+	modal := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(tview.NewTextView().
+			SetDynamicColors(true).
+			SetText(`[yellow::b]Source details[-::-]`).
+			SetTextAlign(tview.AlignCenter), 1, 1, false).
+		AddItem(text, 0, 1, true).
+		AddItem(tview.NewTextView().
+			SetDynamicColors(true).
+			SetText(`[yellow::b]Esc[-::-] close`).
+			SetTextAlign(tview.AlignCenter), 1, 1, false)
+	modal.SetBackgroundColor(tcell.ColorDarkBlue)
+	dismiss := func() {
+		l.app.DismissModal(l.table)
+	}
+	l.app.ShowModal(modal, 80, min(len(details)+4, 24), tcell.ColorDarkBlue, func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc:
+			dismiss()
+			return nil
+		}
+		switch event.Rune() {
+		case 'q', 'Q', 'i':
+			dismiss()
+			return nil
+		}
+		return event
+	})
+	l.app.SetFocus(text)
+}
@@ -0,0 +1,141 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/badaniya/loggo/internal/char"
+	"github.com/badaniya/loggo/internal/color"
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// noisyTopN is the number of sources listed by the noisy sources panel.
+const noisyTopN = 10
+
+// showNoisySources opens the panel listing the sources (pods, files,
+// logNames...) logging the most lines lately, refreshed while open, from
+// which a chatty source can be excluded from, or isolated in, the view.
+func (l *LogView) showNoisySources() {
+	if l.sources == nil {
+		return
+	}
+	var top []config.SourceCount
+	list := tview.NewList().
+		ShowSecondaryText(false).
+		SetHighlightFullLine(true).
+		SetMainTextStyle(tcell.StyleDefault.Background(tcell.ColorDarkBlue)).
+		SetSelectedStyle(color.FieldStyle)
+	list.SetBackgroundColor(tcell.ColorDarkBlue)
+	refresh := func() {
+		top = l.sources.Top(noisyTopN)
+		current := list.GetCurrentItem()
+		list.Clear()
+		for _, s := range top {
+			list.AddItem(fmt.Sprintf("%-44s %8d %5.1f%%", tview.Escape(char.Printable(ellipsize(s.Value, 44), 0)),
+				s.Lines, s.Share*100), "", 0, nil)
+		}
+		if len(top) == 0 {
+			list.AddItem("No source key (pod, file, logName...) found lately", "", 0, nil)
+		}
+		list.SetCurrentItem(current)
+	}
+	refresh()
+	open := true
+	go func() {
+		for open && !l.closed.Load() {
+			time.Sleep(2 * time.Second)
+			if open {
+				refresh()
+				l.app.Draw()
+			}
+		}
+	}()
+	dismiss := func() {
+		open = false
+		l.app.DismissModal(l.table)
+	}
+	filterSource := func(operator string) {
+		i := list.GetCurrentItem()
+		if i < 0 || i >= len(top) {
+			return
+		}
+		dismiss()
+		l.applySourceFilter(top[i], operator)
+	}
+	modal := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(tview.NewTextView().
+			SetDynamicColors(true).
+			SetText(fmt.Sprintf(`[yellow::b]Noisiest sources, last %s[-::-]`, gapLabel(l.sources.Window()))).
+			SetTextAlign(tview.AlignCenter), 1, 1, false).
+		AddItem(list, 0, 1, true).
+		AddItem(tview.NewTextView().
+			SetDynamicColors(true).
+			SetText(`[yellow::b]x[-::-] exclude   [yellow::b]i[-::-] isolate   [yellow::b]Esc[-::-] close`).
+			SetTextAlign(tview.AlignCenter), 1, 1, false)
+	modal.SetBackgroundColor(tcell.ColorDarkBlue)
+	l.app.ShowModal(modal, 70, noisyTopN+4, tcell.ColorDarkBlue, func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc:
+			dismiss()
+			return nil
+		}
+		switch event.Rune() {
+		case 'x', 'X':
+			filterSource("!=")
+			return nil
+		case 'i', 'I':
+			filterSource("==")
+			return nil
+		case 'q', 'Q':
+			dismiss()
+			return nil
+		}
+		return event
+	})
+	l.app.SetFocus(list)
+}
+
+// applySourceFilter narrows the current filter expression, if any, to either
+// exclude (!=) or isolate (==) the source.
+func (l *LogView) applySourceFilter(source config.SourceCount, operator string) {
+	condition := filterCondition(source.Key, operator, source.Value)
+	if current := strings.TrimSpace(l.filterView.Expression()); len(current) > 0 {
+		condition = fmt.Sprintf("(%s) AND %s", current, condition)
+	}
+	if l.hideFilter {
+		l.toggleFilter()
+	}
+	l.filterView.Apply(condition)
+}
+
+func ellipsize(value string, width int) string {
+	if r := []rune(value); len(r) > width {
+		return string(r[:width-1]) + "…"
+	}
+	return value
+}
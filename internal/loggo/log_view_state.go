@@ -0,0 +1,116 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package loggo
+
+import (
+	"strings"
+	"time"
+
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/util"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// restoreStateWait bounds how long to wait for the source to reach the row
+// left selected, e.g. while a large file loads, before settling for less.
+const restoreStateWait = 10 * time.Second
+
+// RememberState restores the scroll position, filter, columns and detail pane
+// left over the source of the given signature last time, if any, persisting
+// them again once the app quits.
+func (a *LoggoApp) RememberState(source string) {
+	a.stateSource = source
+	s, err := config.LoadUIState(StateDir, source)
+	if err != nil {
+		util.Log().WithField("code", err).Error("Unable to load the UI state")
+		return
+	}
+	a.logView.savedState = s
+}
+
+func (a *LoggoApp) saveState() {
+	if len(a.stateSource) == 0 || util.ReadOnly() {
+		return
+	}
+	if err := config.SaveUIState(StateDir, a.stateSource, a.logView.uiState()); err != nil {
+		util.Log().WithField("code", err).Error("Unable to save the UI state")
+	}
+}
+
+func (l *LogView) uiState() *config.UIState {
+	row, _ := l.table.GetSelection()
+	offset, _ := l.table.GetOffset()
+	return &config.UIState{
+		Filter:    strings.TrimSpace(l.filterView.Expression()),
+		Keys:      copyKeys(l.config.Keys),
+		Row:       row,
+		Offset:    offset,
+		Following: l.isFollowing,
+		Detail:    l.isJsonViewShown(),
+	}
+}
+
+// restoreState brings the view back to the saved state, if any, waiting for
+// the source to stream enough lines to select the row left selected.
+func (l *LogView) restoreState() {
+	s := l.savedState
+	if s == nil {
+		return
+	}
+	l.savedState = nil
+	if len(s.Keys) > 0 {
+		l.config.Keys = copyKeys(s.Keys)
+		l.lastKeys = copyKeys(s.Keys)
+		l.coverage.Reset()
+	}
+	if len(s.Filter) > 0 {
+		l.restoreFilter(filterState{expression: s.Filter})
+	}
+	if s.Following || s.Row < 1 {
+		go l.app.Draw()
+		return
+	}
+	l.isFollowing = false
+	go func() {
+		row := s.Row
+		for deadline := time.Now().Add(restoreStateWait); ; time.Sleep(100 * time.Millisecond) {
+			l.filterLock.RLock()
+			count := len(l.finSlice)
+			l.filterLock.RUnlock()
+			if count >= row || time.Now().After(deadline) {
+				row = min(row, count)
+				break
+			}
+		}
+		if row < 1 {
+			return
+		}
+		l.updateLineView()
+		l.table.SetOffset(min(s.Offset, row), 0)
+		l.table.Select(row, 0)
+		if s.Detail {
+			l.table.InputHandler()(tcell.NewEventKey(tcell.KeyEnter, 0, 0), func(p tview.Primitive) {})
+		}
+		l.app.Draw()
+	}()
+}
@@ -0,0 +1,247 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/badaniya/loggo/internal/color"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	stickyBanner = `[black:lightskyblue] ⚲ %s   ` +
+		`[darkblue::b]-[black::-] drop last   ["clear"][darkblue::b]U[black::-] [::u]clear all[::-][""] `
+	stickyCrumb = `["%d"]%s = [::b]%s[::-] [darkblue]✕[black][""]`
+)
+
+// stickyFilter is a key value the local filter follows, stacked with others
+// while drilling down, e.g. service=api > region=eu > user=123.
+type stickyFilter struct {
+	key   string
+	value string
+}
+
+// makeStickyView builds the breadcrumb bar shown above the table while
+// following values, clicking a crumb removes it and clicking "clear all"
+// removes them all.
+func (l *LogView) makeStickyView() {
+	l.stickyView = tview.NewTextView().
+		SetRegions(true).
+		SetDynamicColors(true).
+		SetWrap(false)
+	l.stickyView.SetHighlightedFunc(func(added, removed, remaining []string) {
+		if len(added) == 0 {
+			return
+		}
+		l.stickyView.Highlight()
+		if added[0] == "clear" {
+			l.clearStickies()
+		} else if i, err := strconv.Atoi(added[0]); err == nil {
+			l.unfollowValue(i)
+		}
+	})
+}
+
+// showFollowValue lists the values of the selected entry, adding the chosen
+// one to the sticky filters, e.g. to see everything about an order ID.
+func (l *LogView) showFollowValue() {
+	r, _ := l.table.GetSelection()
+	l.filterLock.RLock()
+	if r <= 0 || r-1 >= len(l.finSlice) {
+		l.filterLock.RUnlock()
+		return
+	}
+	row := l.finSlice[r-1]
+	l.filterLock.RUnlock()
+	var keys, values []string
+	for i := range l.config.Keys {
+		k := &l.config.Keys[i]
+		if v := k.ExtractValue(row); len(v) > 0 {
+			keys, values = append(keys, k.Name), append(values, v)
+		}
+	}
+	if len(keys) == 0 {
+		go l.app.ShowPopMessage("The selected entry has no value to follow.", 2, l.table)
+		return
+	}
+	list := tview.NewList().
+		ShowSecondaryText(false).
+		SetHighlightFullLine(true).
+		SetMainTextStyle(tcell.StyleDefault.Background(tcell.ColorDarkBlue)).
+		SetSelectedStyle(color.FieldStyle)
+	list.SetBackgroundColor(tcell.ColorDarkBlue)
+	for i := range keys {
+		list.AddItem(fmt.Sprintf("%-20s %s", ellipsize(keys[i], 20), ellipsize(strings.TrimSpace(values[i]), 44)),
+			"", shortcut(i), nil)
+	}
+	list.SetSelectedFunc(func(i int, _ string, _ string, _ rune) {
+		l.app.DismissModal(l.table)
+		l.followValue(keys[i], values[i])
+	})
+	// Preselect the first key not followed yet, to drill further down.
+	for i := len(keys) - 1; i >= 0; i-- {
+		if !l.isFollowed(keys[i]) {
+			list.SetCurrentItem(i)
+		}
+	}
+	modal := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(tview.NewTextView().
+			SetDynamicColors(true).
+			SetText(`[yellow::b]Follow value[-::-]`).
+			SetTextAlign(tview.AlignCenter), 1, 1, false).
+		AddItem(list, 0, 1, true)
+	modal.SetBackgroundColor(tcell.ColorDarkBlue)
+	l.app.ShowModal(modal, 72, min(len(keys), 15)+3, tcell.ColorDarkBlue, func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc:
+			l.app.DismissModal(l.table)
+			return nil
+		}
+		return event
+	})
+	l.app.SetFocus(list)
+}
+
+// followValue narrows the sticky filters down to the key value, replacing
+// the value of that key if already followed. The first sticky filter replaces
+// any filter in place, restored once they're all removed.
+func (l *LogView) followValue(key, value string) {
+	if len(l.stickies) == 0 {
+		l.stickyBase = l.filterView.Expression()
+	}
+	for i := range l.stickies {
+		if l.stickies[i].key == key {
+			l.stickies[i].value = value
+		}
+	}
+	if !l.isFollowed(key) {
+		l.stickies = append(l.stickies, stickyFilter{key: key, value: value})
+	}
+	l.applyStickies()
+}
+
+func (l *LogView) isFollowed(key string) bool {
+	for _, sticky := range l.stickies {
+		if sticky.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// unfollowValue removes the i-th sticky filter.
+func (l *LogView) unfollowValue(i int) {
+	if i < 0 || i >= len(l.stickies) {
+		return
+	}
+	l.stickies = append(l.stickies[:i], l.stickies[i+1:]...)
+	l.applyStickies()
+	go l.app.Draw()
+}
+
+// unfollowLastValue removes the latest sticky filter, stepping back up the
+// drill-down.
+func (l *LogView) unfollowLastValue() {
+	l.unfollowValue(len(l.stickies) - 1)
+}
+
+// clearStickies removes all sticky filters.
+func (l *LogView) clearStickies() {
+	if len(l.stickies) == 0 {
+		return
+	}
+	l.stickies = nil
+	l.applyStickies()
+	go l.app.Draw()
+}
+
+// applyStickies sets the local filter to all sticky filters, or back to the
+// filter they replaced once there are none left.
+func (l *LogView) applyStickies() {
+	l.updateStickyView()
+	if len(l.stickies) == 0 {
+		base := l.stickyBase
+		l.stickyBase = ""
+		if len(strings.TrimSpace(base)) == 0 {
+			l.filterView.Clear()
+		} else {
+			l.filterView.Apply(base)
+		}
+		return
+	}
+	conditions := make([]string, len(l.stickies))
+	for i, sticky := range l.stickies {
+		conditions[i] = filterCondition(sticky.key, "==", sticky.value)
+	}
+	if l.hideFilter {
+		l.toggleFilter()
+	}
+	l.filterView.Apply(strings.Join(conditions, " AND "))
+}
+
+func (l *LogView) updateStickyView() {
+	if len(l.stickies) == 0 {
+		l.stickyView.SetText("")
+		l.tableContent.ResizeItem(l.stickyView, 0, 0)
+		return
+	}
+	crumbs := make([]string, len(l.stickies))
+	for i, sticky := range l.stickies {
+		crumbs[i] = fmt.Sprintf(stickyCrumb, i, sticky.key, tview.Escape(ellipsize(sticky.value, 30)))
+	}
+	l.stickyView.SetText(fmt.Sprintf(stickyBanner, strings.Join(crumbs, " › ")))
+	l.tableContent.ResizeItem(l.stickyView, 1, 0)
+}
+
+// stickyHeight is the breadcrumb bar height, none unless following values.
+func (l *LogView) stickyHeight() int {
+	if len(l.stickies) == 0 {
+		return 0
+	}
+	return 1
+}
+
+// filterCondition compares the key to the value, quoted as the filter
+// language expects.
+func filterCondition(key, operator, value string) string {
+	quote := `"`
+	if strings.Contains(value, quote) {
+		quote = `'`
+	}
+	return fmt.Sprintf("%s %s %s%s%s", key, operator, quote, value, quote)
+}
+
+// shortcut returns the list shortcut of the i-th item: 1 to 9, then a to z.
+func shortcut(i int) rune {
+	switch {
+	case i < 9:
+		return rune('1' + i)
+	case i < 9+26:
+		return rune('a' + i - 9)
+	}
+	return 0
+}
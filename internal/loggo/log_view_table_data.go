@@ -26,9 +26,13 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
+	"github.com/badaniya/loggo/internal/char"
 	"github.com/badaniya/loggo/internal/color"
 	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/filter"
+	"github.com/badaniya/loggo/internal/i18n"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
@@ -44,23 +48,71 @@ func (d *LogData) GetCell(row, column int) *tview.TableCell {
 	if row == -1 || len(d.logView.finSlice) < row-1 || column == -1 {
 		return nil
 	}
+	if row == len(d.logView.finSlice)+1 {
+		// past the last entry, once the source ended
+		if !d.logView.ended {
+			return nil
+		}
+		text := ""
+		if column == 0 {
+			text = fmt.Sprintf(endOfInput, i18n.T("End of input"))
+		}
+		return tview.NewTableCell(text).
+			SetAlign(tview.AlignRight).
+			SetBackgroundColor(color.ColorBackgroundField).
+			SetSelectable(false)
+	}
 	if column == 0 {
 		if row == 0 {
-			tc := tview.NewTableCell("[yellow] Line # ").
+			tc := tview.NewTableCell(d.logView.lineNumberHeader()).
 				SetAlign(tview.AlignCenter).
 				SetBackgroundColor(color.ColorBackgroundField).
 				SetSelectable(false)
 			return tc
 		} else {
+			lineNumber := fmt.Sprintf("%d ", row)
+			if hasNote(d.logView.finSlice[row-1]) {
+				lineNumber = fmt.Sprintf("%s %d ", char.SymNote, row)
+			}
+			if d.logView.pinIndex(d.logView.finSlice[row-1]) >= 0 {
+				lineNumber = fmt.Sprintf("%s %s", char.SymPin, lineNumber)
+			}
+			if d.logView.markIndex(d.logView.finSlice[row-1]) >= 0 {
+				lineNumber = fmt.Sprintf("%s %s", char.SymMark, lineNumber)
+			}
+			gapColor := tcell.ColorYellow
+			if _, ok := d.logView.finSlice[row-1][config.Novel]; ok {
+				lineNumber = fmt.Sprintf("%s %s", char.SymNew, lineNumber)
+				gapColor = tcell.ColorLime
+			}
+			if color.CurrentTheme() != color.ThemeDefault {
+				lineNumber = fmt.Sprintf("%s %s", severitySymbol(d.logView.severities.Severity(d.logView.finSlice[row-1])),
+					lineNumber)
+			}
+			if _, ok := d.logView.finSlice[row-1][config.Secret]; ok {
+				lineNumber = fmt.Sprintf("%s %s", char.SymSecret, lineNumber)
+				gapColor = tcell.ColorRed
+			}
+			if row > 1 {
+				prev, entry := d.logView.finSlice[row-2], d.logView.finSlice[row-1]
+				if gap, ok := d.logView.config.TimeGap(prev, entry); ok {
+					lineNumber = fmt.Sprintf("%s +%s %s", char.SymGap, gapLabel(gap), lineNumber)
+					gapColor = tcell.ColorFuchsia
+				}
+				if label, ok := d.logView.config.TimeBoundary(prev, entry); ok {
+					lineNumber = fmt.Sprintf("── %s ── %s", label, lineNumber)
+					gapColor = tcell.ColorFuchsia
+				}
+			}
 			if _, ok := d.logView.finSlice[row-1][config.ParseErr]; ok {
-				tc := tview.NewTableCell(fmt.Sprintf("%d ", row)).
+				tc := tview.NewTableCell(lineNumber).
 					SetTextColor(tcell.ColorRed).
 					SetAlign(tview.AlignRight).
 					SetBackgroundColor(color.ColorBackgroundField)
 				return tc
 			} else {
-				tc := tview.NewTableCell(fmt.Sprintf("%d ", row)).
-					SetTextColor(tcell.ColorYellow).
+				tc := tview.NewTableCell(lineNumber).
+					SetTextColor(gapColor).
 					SetAlign(tview.AlignRight).
 					SetBackgroundColor(color.ColorBackgroundField)
 				return tc
@@ -72,10 +124,13 @@ func (d *LogData) GetCell(row, column int) *tview.TableCell {
 		return nil
 	}
 	k := c.Keys[column-1]
-	tc := tview.NewTableCell(" " + k.Name + " ")
-	if k.MaxWidth > 0 && k.MaxWidth-len(k.Name) >= len(k.Name) {
-		spaces := strings.Repeat(" ", k.MaxWidth-len(k.Name))
-		tc.SetText(" " + k.Name + spaces)
+	width := d.logView.widths.Width(&k)
+	// key names may come from the entries themselves, see config.MakeConfigFromSample
+	name := tview.Escape(char.Printable(k.Name, char.MaxCellRunes))
+	tc := tview.NewTableCell(" " + name + " ")
+	if width > 0 && width-len(k.Name) >= len(k.Name) {
+		spaces := strings.Repeat(" ", width-len(k.Name))
+		tc.SetText(" " + name + spaces)
 	}
 	// Set Headers
 	if row == 0 {
@@ -87,6 +142,10 @@ func (d *LogData) GetCell(row, column int) *tview.TableCell {
 	}
 	// Set Body Cells
 	cellValue := k.ExtractValue(d.logView.finSlice[row-1])
+	if column == 1 && config.IsText(d.logView.finSlice[row-1]) && !c.HasKey(config.TextPayload) {
+		// text lines would show blank without a message column
+		cellValue = fmt.Sprintf("%v", d.logView.finSlice[row-1][config.TextPayload])
+	}
 	var bgColor, fgColor tcell.Color
 	if len(k.Color.Foreground) == 0 {
 		fgColor = k.Type.GetColor()
@@ -97,20 +156,22 @@ func (d *LogData) GetCell(row, column int) *tview.TableCell {
 	if len(k.ColorWhen) > 0 {
 	OUT:
 		for _, kv := range k.ColorWhen {
-			reg, err := regexp.Compile(kv.MatchValue)
-			if err == nil && reg.FindIndex([]byte(cellValue)) != nil {
+			if d.logView.colorWhen(&kv, cellValue, d.logView.finSlice[row-1]) {
 				bgColor = kv.Color.GetBackgroundColor()
 				fgColor = kv.Color.GetForegroundColor()
 				break OUT
 			}
 		}
 	}
+	if flash, ok := d.logView.flashColor(d.logView.finSlice[row-1]); ok && bgColor == tcell.ColorDefault {
+		bgColor = flash
+	}
 	switch k.Type {
-	case config.TypeNumber, config.TypeBool:
+	case config.TypeNumber, config.TypeBool, config.TypeDuration, config.TypeBytes:
 		tc.SetAlign(tview.AlignRight)
 	}
-	if k.MaxWidth > 0 {
-		tc.MaxWidth = k.MaxWidth
+	if width > 0 {
+		tc.MaxWidth = width
 	}
 
 	if k.Name == config.TextPayload {
@@ -122,12 +183,85 @@ func (d *LogData) GetCell(row, column int) *tview.TableCell {
 	return tc.
 		SetBackgroundColor(bgColor).
 		SetTextColor(fgColor).
-		SetText(fmt.Sprintf("%s", cellValue))
+		SetText(renderCell(d.logView.displayValue(&k, cellValue)))
+}
+
+// renderCell renders untrusted log content as a cell, so that it can neither
+// inject color tags nor control characters, and huge values are capped.
+func renderCell(value string) string {
+	return tview.Escape(char.Printable(value, char.MaxCellRunes))
+}
+
+// severitySymbol flags the entry severity without relying on colors, for the
+// high-contrast and mono themes.
+func severitySymbol(severity string) string {
+	switch severity {
+	case config.SeverityError:
+		return char.SymError
+	case config.SeverityWarn:
+		return char.SymWarn
+	case config.SeverityInfo:
+		return char.SymInfo
+	case config.SeverityDebug:
+		return char.SymDebug
+	}
+	return " "
+}
+
+// gapLabel renders a time gap to the second, e.g. "5m" rather than "5m0s".
+func gapLabel(gap time.Duration) string {
+	label := gap.Round(time.Second).String()
+	if strings.HasSuffix(label, "m0s") {
+		label = strings.TrimSuffix(label, "0s")
+	}
+	if strings.HasSuffix(label, "h0m") {
+		label = strings.TrimSuffix(label, "0m")
+	}
+	return label
+}
+
+// displayValue humanizes the value according to the key type (see
+// config.Key.Humanize), unless raw values were toggled on.
+func (l *LogView) displayValue(k *config.Key, value string) string {
+	if l.rawValues {
+		return value
+	}
+	return k.Humanize(value)
+}
+
+// colorWhen tells whether the highlight rule applies, evaluating its filter
+// expression against the entry if it has one, or else matching its regex
+// against the value.
+func (l *LogView) colorWhen(cw *config.ColorWhen, value string, entry map[string]interface{}) bool {
+	if len(cw.When) > 0 {
+		expr, err := filter.Compile(cw.When)
+		if err != nil {
+			return false
+		}
+		ok, err := expr.ApplyMatching(entry, l.keyMap, l.currentMatching())
+		return err == nil && ok
+	}
+	reg, err := regexp.Compile(cw.MatchValue)
+	return err == nil && reg.FindIndex([]byte(value)) != nil
+}
+
+// toggleRawValues switches between humanized and raw cell values.
+func (l *LogView) toggleRawValues() {
+	l.rawValues = !l.rawValues
+	if l.rawValues {
+		l.humanizeView.SetText(i18n.Markup(humanizeOffMenu))
+	} else {
+		l.humanizeView.SetText(i18n.Markup(humanizeOnMenu))
+	}
+	go l.app.Draw()
 }
 
 func (d *LogData) GetRowCount() int {
 	d.logView.filterLock.RLock()
 	defer d.logView.filterLock.RUnlock()
+	if d.logView.ended {
+		return len(d.logView.finSlice) + 2
+	}
 	return len(d.logView.finSlice) + 1
 }
 
@@ -0,0 +1,148 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"github.com/badaniya/loggo/internal/i18n"
+	"github.com/rivo/tview"
+)
+
+//go:embed tutorial.log
+var tutorialLog string
+
+// TutorialLog returns the lines of the sample log the tutorial walks through.
+func TutorialLog() []string {
+	return strings.Split(strings.TrimSpace(tutorialLog), "\n")
+}
+
+// tutorialStep is the step of the guided tour, none unless touring.
+type tutorialStep int
+
+const (
+	tutorialOff tutorialStep = iota
+	tutorialTemplate
+	tutorialFilter
+	tutorialEntry
+	tutorialAutoScroll
+	tutorialDone
+)
+
+const tutorialBanner = `[black:yellow:b] 🎓 %s [::-]%s   ` +
+	`["next"][::u]%s[::-][""]   ["dismiss"][::b]%s[::-] [::u]%s[::-][""] `
+
+// tutorialText is the hint of the step, naming keys as remapped.
+func tutorialText(step tutorialStep) string {
+	switch step {
+	case tutorialTemplate:
+		return fmt.Sprintf(i18n.T("Press %s to open the template editor, picking the keys shown as columns and their colors"),
+			boundKey("template"))
+	case tutorialFilter:
+		return fmt.Sprintf(i18n.T(`Press %s and type a filter, e.g. severity == "ERROR", then Enter`),
+			boundKey("filter"))
+	case tutorialEntry:
+		return i18n.T("Select a line and press Enter to view the whole entry")
+	case tutorialAutoScroll:
+		return fmt.Sprintf(i18n.T("Press %s to toggle auto-scroll, which selecting a line also stops"),
+			boundKey("auto-scroll"))
+	}
+	return fmt.Sprintf(i18n.T("All set! Press %s anytime to list the keybindings"), boundKey("keymap"))
+}
+
+// makeTutorialView builds the banner shown above the table while touring,
+// clicking its actions either skips the step or ends the tour.
+func (l *LogView) makeTutorialView() {
+	l.tutorialView = tview.NewTextView().
+		SetRegions(true).
+		SetDynamicColors(true).
+		SetWrap(false)
+	l.tutorialView.SetHighlightedFunc(func(added, removed, remaining []string) {
+		if len(added) == 0 {
+			return
+		}
+		l.tutorialView.Highlight()
+		switch added[0] {
+		case "next":
+			l.tutorialDone(l.tutorial)
+		case "dismiss":
+			l.dismissTutorial()
+		}
+	})
+}
+
+// EnableTutorial starts the guided tour of the live view, walking through
+// picking a template, filtering, viewing an entry and auto-scrolling.
+func (a *LoggoApp) EnableTutorial() {
+	a.logView.tutorial = tutorialTemplate
+	a.logView.updateTutorialView()
+}
+
+// tutorialDone moves on to the next step of the tour once the given one is
+// done, or ends it past the last one.
+func (l *LogView) tutorialDone(step tutorialStep) {
+	if l.tutorial == tutorialOff || l.tutorial != step {
+		return
+	}
+	if step == tutorialDone {
+		l.dismissTutorial()
+		return
+	}
+	l.tutorial++
+	l.updateTutorialView()
+	go l.app.Draw()
+}
+
+func (l *LogView) dismissTutorial() {
+	l.tutorial = tutorialOff
+	l.updateTutorialView()
+}
+
+func (l *LogView) updateTutorialView() {
+	if l.tutorial == tutorialOff {
+		l.tutorialView.SetText("")
+		l.tableContent.ResizeItem(l.tutorialView, 0, 0)
+		return
+	}
+	next := i18n.T("next")
+	if l.tutorial == tutorialDone {
+		next = i18n.T("close")
+	}
+	progress := fmt.Sprintf("%d/%d", l.tutorial, tutorialDone-1)
+	if l.tutorial == tutorialDone {
+		progress = "✔"
+	}
+	l.tutorialView.SetText(fmt.Sprintf(tutorialBanner, progress, tview.Escape(tutorialText(l.tutorial)),
+		next, tview.Escape(boundKey("dismiss-tutorial")), i18n.T("end the tour")))
+	l.tableContent.ResizeItem(l.tutorialView, 1, 0)
+}
+
+// tutorialHeight is the banner height, none unless touring.
+func (l *LogView) tutorialHeight() int {
+	if l.tutorial == tutorialOff {
+		return 0
+	}
+	return 1
+}
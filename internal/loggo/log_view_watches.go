@@ -0,0 +1,207 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/badaniya/loggo/internal/color"
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/filter"
+	"github.com/badaniya/loggo/internal/secrets"
+	"github.com/badaniya/loggo/internal/util"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// watchesMaxRows caps the rows of the watch counters panel.
+const watchesMaxRows = 8
+
+// watchRule is a template watch, along with its compiled expression.
+type watchRule struct {
+	config.Watch
+	expr *filter.Expression
+}
+
+// loadWatches compiles the template watches, buffering the entries their
+// captures need, unless in read-only mode.
+func (l *LogView) loadWatches() {
+	var rules []*watchRule
+	for _, w := range l.config.Watch {
+		expr, err := filter.Compile(w.When)
+		if err != nil {
+			util.Log().WithField("code", err).Error("Unable to load watches")
+			go l.app.ShowPopMessage(fmt.Sprintf("Unable to load watch %q: %v", w.Name, err), 5, l.table)
+			return
+		}
+		rules = append(rules, &watchRule{Watch: w, expr: expr})
+	}
+	l.watches = rules
+	if len(rules) > 0 {
+		l.watchCounts = config.NewWatchCounter(l.config.Watch)
+	}
+	if util.ReadOnly() {
+		return
+	}
+	if l.captures = config.NewCaptureBuffer(l.config.Watch); l.captures != nil {
+		l.writeCaptures()
+	}
+}
+
+// watch matches the entry against the watches, opening the captures of the
+// matching ones.
+func (l *LogView) watch(m map[string]interface{}) {
+	if l.captures != nil {
+		l.captures.Observe(m)
+	}
+	for _, w := range l.watches {
+		if ok, err := w.expr.ApplyMatching(m, l.keyMap, l.currentMatching()); err != nil || !ok {
+			continue
+		}
+		l.watchCounts.Match(w.Name)
+		if l.captures != nil && l.captures.Trigger(&w.Watch) {
+			util.Log().WithField("watch", w.Name).Debug("Capture started")
+		}
+	}
+}
+
+// writeCaptures periodically writes the captures whose period after the match
+// elapsed, as JSON lines, secrets redacted since nobody is there to tell.
+func (l *LogView) writeCaptures() {
+	go func() {
+		for !l.closed.Load() {
+			time.Sleep(time.Second)
+			for _, c := range l.captures.Due() {
+				l.writeCapture(c)
+			}
+		}
+	}()
+}
+
+var captureNameReg = regexp.MustCompile(`[^\w.-]+`)
+
+func (l *LogView) writeCapture(c config.Captured) {
+	fileName := filepath.Join(c.Dir, fmt.Sprintf("loggo-capture-%s-%s.log",
+		captureNameReg.ReplaceAllString(c.Watch, "_"), c.At.Format("20060102-150405")))
+	// notes can't change meanwhile
+	l.filterLock.RLock()
+	lines := entryLines(secrets.RedactEntries(c.Entries))
+	l.filterLock.RUnlock()
+	if err := os.WriteFile(fileName, []byte(lines), 0o644); err != nil {
+		util.Log().WithField("code", err).Error("Unable to write capture")
+		l.app.ShowPopMessage(fmt.Sprintf("Unable to capture %q: %v", c.Watch, err), 5, l.table)
+		return
+	}
+	l.app.alert()
+	l.app.ShowPopMessage(fmt.Sprintf("Captured %d entries around %q into %s", len(c.Entries), c.Watch, fileName), 3, l.table)
+}
+
+// makeWatchesView builds the watch counters panel, clicking it starts
+// counting over, e.g. upon deploying.
+func (l *LogView) makeWatchesView() {
+	l.watchesView = tview.NewTextView().
+		SetDynamicColors(true).
+		SetWrap(false)
+	l.watchesView.SetBackgroundColor(color.ColorBackgroundField)
+	l.watchesView.SetMouseCapture(func(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+		if action != tview.MouseLeftClick || l.watchCounts == nil {
+			return action, event
+		}
+		l.watchCounts.Reset()
+		l.updateWatches()
+		return action, nil
+	})
+}
+
+// toggleWatches shows or hides the panel of the template watches, with their
+// live match counts and latest match times.
+func (l *LogView) toggleWatches() {
+	if !l.showWatches && l.watchCounts == nil {
+		go l.app.ShowPopMessage("The template declares no watches.", 2, l.table)
+		return
+	}
+	l.showWatches = !l.showWatches
+	if l.showWatches {
+		l.updateWatches()
+		l.watchWatches()
+	}
+	if !l.isTemplateViewShown() && !l.isJsonViewShown() {
+		l.makeLayouts()
+	}
+	go l.app.Draw()
+}
+
+// watchWatches refreshes the panel for as long as it's shown, so the times
+// since the latest matches keep running.
+func (l *LogView) watchWatches() {
+	go func() {
+		for l.showWatches && !l.closed.Load() {
+			time.Sleep(time.Second)
+			if l.showWatches && l.updateWatches() {
+				l.app.Draw()
+			}
+		}
+	}()
+}
+
+func (l *LogView) updateWatches() bool {
+	counts, since := l.watchCounts.Counts()
+	text := renderWatches(counts, since, time.Now())
+	if text == l.watchesView.GetText(false) {
+		return false
+	}
+	l.watchesView.SetText(text)
+	return true
+}
+
+// watchesHeight is the panel height, a row per watch below its header.
+func (l *LogView) watchesHeight() int {
+	return min(len(l.watches), watchesMaxRows) + 1
+}
+
+// renderWatches lists the watches, green while they matched nothing since the
+// counting started, red with the time of their latest match otherwise.
+func renderWatches(counts []config.WatchCount, since, now time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[yellow::b]Watches[-::-] since %s [::d](click to reset)[::-]", since.Format("15:04:05"))
+	width := 0
+	for _, c := range counts {
+		width = max(width, len(c.Name))
+	}
+	for _, c := range counts {
+		b.WriteString("\n")
+		if c.Matches == 0 {
+			fmt.Fprintf(&b, " [green]●[-] %-*s %6d  clean for %s", width, tview.Escape(c.Name), 0,
+				now.Sub(since).Truncate(time.Second))
+			continue
+		}
+		fmt.Fprintf(&b, " [red]●[-] %-*s %6d  last at %s, %s ago", width, tview.Escape(c.Name), c.Matches,
+			c.Last.Format("15:04:05"), now.Sub(c.Last).Truncate(time.Second))
+	}
+	return b.String()
+}
@@ -99,6 +99,8 @@ func (t *TemplateItemView) makeUIComponents() {
 		AddOption(config.TypeDateTime+"  ", nil).
 		AddOption(config.TypeBool+"  ", nil).
 		AddOption(config.TypeNumber+"  ", nil).
+		AddOption(config.TypeDuration+"  ", nil).
+		AddOption(config.TypeBytes+"  ", nil).
 		SetSelectedFunc(func(text string, index int) {
 			t.key.Type = config.Type(strings.TrimSpace(text))
 			t.key.Color.Foreground = t.key.Type.GetColorName()
@@ -116,6 +118,10 @@ func (t *TemplateItemView) makeUIComponents() {
 		currOpt = 2
 	case config.TypeNumber:
 		currOpt = 3
+	case config.TypeDuration:
+		currOpt = 4
+	case config.TypeBytes:
+		currOpt = 5
 	}
 	typeDD.SetCurrentOption(currOpt)
 
@@ -143,7 +149,10 @@ func (t *TemplateItemView) makeUIComponents() {
 			func(text string) {
 				w, _ := strconv.ParseInt(text, 10, 64)
 				t.key.MaxWidth = int(w)
-			})
+			}).
+		AddCheckbox("Auto Width", t.key.AutoWidth, func(checked bool) {
+			t.key.AutoWidth = checked
+		})
 
 	t.makeCaseWhenForm()
 	t.caseWhenLayout = tview.NewFlex().SetDirection(tview.FlexRow)
@@ -180,6 +189,9 @@ func (t *TemplateItemView) makeCaseWhenForm() {
 		AddInputField("[:default:iu]when[:default:-] Value Matches", t.caseWhenCurrent.MatchValue, maxFieldWidth, nil, func(text string) {
 			t.caseWhenCurrent.MatchValue = strings.TrimSpace(text)
 		}).
+		AddInputField("[:default:iu]or[:default:-] Entry Satisfies", t.caseWhenCurrent.When, maxFieldWidth, nil, func(text string) {
+			t.caseWhenCurrent.When = strings.TrimSpace(text)
+		}).
 		AddFormItem(caseWhenTextColor).
 		AddFormItem(caseWhenTextBgColor).
 		AddButton("Add", func() {
@@ -213,7 +225,7 @@ func (t *TemplateItemView) makeLayouts() {
 	t.makeContextMenu()
 
 	t.caseWhenLayout.Clear().
-		AddItem(t.caseWhenForm, 9, 1, false).
+		AddItem(t.caseWhenForm, 11, 1, false).
 		AddItem(t.caseWhenTable, 0, 1, false)
 
 	mainForm := tview.NewFlex().SetDirection(tview.FlexRow).
@@ -237,7 +249,7 @@ func (t *TemplateItemView) makeCaseWhenData() {
 		SetFixed(1, 1).
 		SetSeparator(tview.Borders.Vertical)
 	t.caseWhenTable.SetCell(0, 0,
-		tview.NewTableCell(" Match Value ").
+		tview.NewTableCell(" Match Value / When ").
 			SetTextColor(tcell.ColorLightGray).
 			SetSelectable(false).
 			SetAlign(tview.AlignCenter))
@@ -257,8 +269,12 @@ func (t *TemplateItemView) makeCaseWhenData() {
 			SetSelectable(false).
 			SetAlign(tview.AlignCenter))
 	for i, k := range t.key.ColorWhen {
+		match := k.MatchValue
+		if len(k.When) > 0 {
+			match = k.When
+		}
 		t.caseWhenTable.SetCell(i+1, 0,
-			tview.NewTableCell(k.Color.SetTextTagColor(k.MatchValue)).
+			tview.NewTableCell(k.Color.SetTextTagColor(match)).
 				SetTextColor(tcell.ColorYellow).
 				SetSelectable(false).
 				SetAlign(tview.AlignCenter))
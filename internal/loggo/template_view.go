@@ -25,11 +25,13 @@ package loggo
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/badaniya/loggo/internal/color"
 	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/util"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
@@ -44,6 +46,7 @@ type TemplateView struct {
 	showQuit                 bool
 	toggleFullScreenCallback func()
 	closeCallback            func()
+	onChange                 func()
 }
 
 func NewTemplateView(app Loggo, showQuit bool, toggleFullScreenCallback, closeCallback func()) *TemplateView {
@@ -63,6 +66,13 @@ func NewTemplateView(app Loggo, showQuit bool, toggleFullScreenCallback, closeCa
 			tv.table.Select(1, 0)
 			tv.app.Draw()
 		}
+		if showQuit {
+			tv.offerDraft(func(d *config.Config) {
+				tv.config.Keys = d.Keys
+				tv.config.Decoders = d.Decoders
+				tv.makeLayouts()
+			})
+		}
 	}()
 	return tv
 }
@@ -93,6 +103,9 @@ func (t *TemplateView) makeUIComponents() {
 			case 's', 'S':
 				t.saveForm()
 				return nil
+			case 'w', 'W':
+				t.saveAsForm()
+				return nil
 			case 'e', 'E':
 				if selected {
 					t.editEntry()
@@ -146,23 +159,32 @@ func (t *TemplateView) makeLayouts() {
 	go t.app.SetFocus(t.contextMenu)
 }
 
-func (t *TemplateView) makeSaveLayouts() {
-	bar, input := t.makeSaveUI()
+func (t *TemplateView) makeSaveLayouts(saveAs bool) {
+	bar, input := t.makeSaveUI(saveAs)
 	t.Flex.Clear().SetDirection(tview.FlexRow).
 		AddItem(bar, 3, 1, false).
 		AddItem(t.table, 0, 1, false)
 	t.app.SetFocus(input)
 }
 
-func (t *TemplateView) makeSaveUI() (*tview.Flex, *tview.InputField) {
+// makeSaveUI builds the save bar. Save suggests overwriting the loaded
+// template, whereas save as only suggests its directory so that a shared
+// template isn't clobbered by mistake.
+func (t *TemplateView) makeSaveUI(saveAs bool) (*tview.Flex, *tview.InputField) {
 	dirName, _ := os.UserHomeDir()
-	if len(t.config.LastSavedName) > 0 {
+	title := "Save Template"
+	if saveAs {
+		title = "Save Template As..."
+	}
+	if len(t.config.LastSavedName) > 0 && !saveAs {
 		dirName = t.config.LastSavedName
+	} else if len(t.config.LastSavedName) > 0 {
+		dirName = fmt.Sprintf(`%s%c`, filepath.Dir(t.config.LastSavedName), os.PathSeparator)
 	} else {
 		dirName = fmt.Sprintf(`%s%c`, dirName, os.PathSeparator)
 	}
 	saveBar := tview.NewFlex().SetDirection(tview.FlexColumn)
-	saveBar.SetBackgroundColor(color.ColorBackgroundField).SetBorder(true).SetTitle("Save Template As...")
+	saveBar.SetBackgroundColor(color.ColorBackgroundField).SetBorder(true).SetTitle(title)
 
 	saveInput := tview.NewInputField().SetText(dirName)
 	saveInput.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
@@ -226,6 +248,9 @@ func (t *TemplateView) makeContextMenu() {
 	t.contextMenu.AddItem("Save", "", 's', func() {
 		t.saveForm()
 	})
+	t.contextMenu.AddItem("Save As...", "", 'w', func() {
+		t.saveAsForm()
+	})
 	if t.closeCallback != nil {
 		t.contextMenu.AddItem("Done", "", 'x', func() {
 			t.closeCallback()
@@ -239,7 +264,12 @@ func (t *TemplateView) makeContextMenu() {
 }
 
 func (t *TemplateView) save(fileName string) {
-	if err := t.config.Save(fileName); err != nil {
+	previous := t.config.LastSavedName
+	err := util.ErrReadOnly
+	if !util.ReadOnly() {
+		err = t.config.Save(fileName)
+	}
+	if err != nil {
 		t.app.ShowPrefabModal(
 			fmt.Sprintf(`Failed to save! Error: %v`, err), 40, 10,
 			func(event *tcell.EventKey) *tcell.EventKey {
@@ -259,6 +289,9 @@ func (t *TemplateView) save(fileName string) {
 				t.app.DismissModal(t.table)
 			}))
 	} else {
+		if err := config.DiscardDraft(DraftsDir, previous); err != nil {
+			util.Log().WithField("code", err).Error("Unable to discard template draft")
+		}
 		t.app.ShowPrefabModal(
 			fmt.Sprintf(`File %v saved successfully!`, fileName), 40, 10,
 			func(event *tcell.EventKey) *tcell.EventKey {
@@ -287,7 +320,76 @@ func (t *TemplateView) Close() {
 }
 
 func (t *TemplateView) saveForm() {
-	t.makeSaveLayouts()
+	t.makeSaveLayouts(false)
+}
+
+func (t *TemplateView) saveAsForm() {
+	t.makeSaveLayouts(true)
+}
+
+// changed saves a draft of the edited template and notifies onChange, e.g. to
+// make the edit undoable.
+func (t *TemplateView) changed() {
+	t.saveDraft()
+	if t.onChange != nil {
+		t.onChange()
+	}
+}
+
+// saveDraft persists the unsaved edits so they survive an unexpected exit.
+func (t *TemplateView) saveDraft() {
+	if util.ReadOnly() {
+		return
+	}
+	if err := t.config.SaveDraft(DraftsDir); err != nil {
+		util.Log().WithField("code", err).Error("Unable to save template draft")
+	}
+}
+
+// offerDraft prompts to restore the unsaved edits left over from a previous
+// session, if any, handing them to apply.
+func (t *TemplateView) offerDraft(apply func(d *config.Config)) {
+	if util.ReadOnly() {
+		return
+	}
+	d, err := config.LoadDraft(DraftsDir, t.config.LastSavedName)
+	if err != nil {
+		util.Log().WithField("code", err).Error("Unable to load template draft")
+		return
+	}
+	if d == nil {
+		return
+	}
+	name := t.config.LastSavedName
+	if len(name) == 0 {
+		name = "an untitled template"
+	}
+	restore := func() {
+		t.app.DismissModal(t.table)
+		apply(d)
+	}
+	discard := func() {
+		if err := config.DiscardDraft(DraftsDir, t.config.LastSavedName); err != nil {
+			util.Log().WithField("code", err).Error("Unable to discard template draft")
+		}
+		t.app.DismissModal(t.table)
+	}
+	t.app.ShowPrefabModal(
+		fmt.Sprintf("Unsaved edits of %s were found from a previous session. Restore them?", name), 50, 12,
+		func(event *tcell.EventKey) *tcell.EventKey {
+			switch event.Rune() {
+			case 'R', 'r':
+				restore()
+				return nil
+			case 'D', 'd':
+				discard()
+				return nil
+			}
+			return event
+		},
+		tview.NewButton("[darkred::bu]R[-::-]estore").SetSelectedFunc(restore),
+		tview.NewButton("[darkred::bu]D[-::-]iscard").SetSelectedFunc(discard))
+	t.app.Draw()
 }
 
 func (t *TemplateView) addEntry() {
@@ -305,6 +407,7 @@ func (t *TemplateView) addEntry() {
 			v.Name = kn
 			t.config.Keys = append(t.config.Keys, *v)
 			t.table.Select(len(t.config.Keys), 0)
+			t.changed()
 		}
 	}))
 }
@@ -313,6 +416,7 @@ func (t *TemplateView) editEntry() {
 	r, _ := t.table.GetSelection()
 	t.app.StackView(NewTemplateItemView(t.app, &t.config.Keys[r-1], nil, func() {
 		t.app.PopView()
+		t.changed()
 	}))
 }
 
@@ -330,6 +434,7 @@ func (t *TemplateView) moveUp() {
 		t.config.Keys = append(keys[1:], keys[r])
 		finalRow = len(t.config.Keys)
 	}
+	t.changed()
 	t.makeLayouts()
 	t.table.Select(finalRow, 0)
 }
@@ -348,6 +453,7 @@ func (t *TemplateView) moveDown() {
 		t.config.Keys = append([]config.Key{keys[r]}, keys[:len(keys)-1]...)
 		finalRow = 1
 	}
+	t.changed()
 	t.makeLayouts()
 	t.table.Select(finalRow, 0)
 }
@@ -390,6 +496,7 @@ func (t *TemplateView) delete() {
 		}
 	}
 	t.config.Keys = newKeys
+	t.changed()
 	t.makeLayouts()
 	t.app.DismissModal(t.contextMenu)
 }
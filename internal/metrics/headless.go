@@ -0,0 +1,79 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package metrics
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/reader"
+)
+
+// RunHeadless consumes the reader without any UI, only feeding the default
+// stats, until the stream ends or the process is interrupted.
+func RunHeadless(r reader.Reader) error {
+	if err := r.StreamInto(); err != nil {
+		return err
+	}
+	errChan := make(chan error, 1)
+	r.ErrorNotifier(func(err error) {
+		select {
+		case errChan <- err:
+		default:
+		}
+	})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	lines := r.ChanReader()
+	for {
+		select {
+		case <-signals:
+			r.Close()
+			return nil
+		case err := <-errChan:
+			return err
+		case line, ok := <-lines:
+			if !ok {
+				return nil
+			}
+			defaultStats.ObserveLine(line)
+		}
+	}
+}
+
+// ObserveLine parses and records a raw line as received from a reader.
+func (s *Stats) ObserveLine(line string) {
+	if len(line) == 0 {
+		s.Drop()
+		return
+	}
+	m, err := config.ParseLine(line)
+	if err != nil {
+		m = nil
+	}
+	s.Observe(line, m)
+}
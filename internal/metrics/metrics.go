@@ -0,0 +1,163 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/badaniya/loggo/internal/config"
+)
+
+// severityKeys are the entry keys checked, in order, for the entry severity.
+var severityKeys = [][]string{
+	{"severity"}, {"level"}, {"lvl"}, {"loglevel"}, {"status"},
+	{"jsonPayload", "severity"}, {"jsonPayload", "level"},
+}
+
+// Stats holds the stream counters exposed by the metrics endpoint.
+type Stats struct {
+	started      time.Time
+	ingested     atomic.Int64
+	bytes        atomic.Int64
+	parseErrors  atomic.Int64
+	dropped      atomic.Int64
+	severityLock sync.Mutex
+	severities   map[string]int64
+	errorGroups  map[string]int64
+}
+
+func NewStats() *Stats {
+	return &Stats{
+		started:     time.Now(),
+		severities:  make(map[string]int64),
+		errorGroups: make(map[string]int64),
+	}
+}
+
+var defaultStats = NewStats()
+
+// Default returns the process wide stats fed by the readers.
+func Default() *Stats {
+	return defaultStats
+}
+
+// Observe records an ingested line. The entry is the parsed line, or nil if it
+// could not be parsed. Plain text lines have no severity to record.
+func (s *Stats) Observe(line string, entry map[string]interface{}) {
+	s.ingested.Add(1)
+	s.bytes.Add(int64(len(line)))
+	if entry == nil {
+		s.parseErrors.Add(1)
+		return
+	}
+	if config.IsText(entry) {
+		return
+	}
+	severity := "unknown"
+	for _, path := range severityKeys {
+		if v, ok := lookup(entry, path).(string); ok && len(v) > 0 {
+			severity = strings.ToLower(v)
+			break
+		}
+	}
+	s.severityLock.Lock()
+	s.severities[severity]++
+	s.severityLock.Unlock()
+	s.observeError(severity, entry)
+}
+
+// Drop records a line discarded before reaching the buffer, e.g. blank lines.
+func (s *Stats) Drop() {
+	s.dropped.Add(1)
+}
+
+func lookup(entry map[string]interface{}, path []string) interface{} {
+	var v interface{} = entry
+	for _, p := range path {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[p]
+	}
+	return v
+}
+
+// WriteTo writes the stats in the Prometheus text exposition format.
+func (s *Stats) WriteTo(w io.Writer) (int64, error) {
+	b := &strings.Builder{}
+	counter := func(name, help string, value int64) {
+		fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, value)
+	}
+	counter("loggo_lines_ingested_total", "Lines received from the input stream.", s.ingested.Load())
+	counter("loggo_bytes_ingested_total", "Bytes received from the input stream.", s.bytes.Load())
+	counter("loggo_parse_failures_total", "Lines that looked like JSON but could not be parsed.", s.parseErrors.Load())
+	counter("loggo_dropped_lines_total", "Lines discarded before being buffered.", s.dropped.Load())
+
+	s.severityLock.Lock()
+	severities := make([]string, 0, len(s.severities))
+	for k := range s.severities {
+		severities = append(severities, k)
+	}
+	sort.Strings(severities)
+	name := "loggo_entries_by_severity_total"
+	fmt.Fprintf(b, "# HELP %s Parsed entries by severity.\n# TYPE %s counter\n", name, name)
+	for _, k := range severities {
+		fmt.Fprintf(b, "%s{severity=%q} %d\n", name, k, s.severities[k])
+	}
+	s.severityLock.Unlock()
+
+	fmt.Fprintf(b, "# HELP loggo_start_time_seconds Start time of the process since unix epoch.\n"+
+		"# TYPE loggo_start_time_seconds gauge\nloggo_start_time_seconds %d\n", s.started.Unix())
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// Handler serves the stats in the Prometheus text exposition format.
+func (s *Stats) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = s.WriteTo(w)
+	})
+}
+
+// Serve exposes the default stats at addr under /metrics. It blocks until
+// the server fails.
+func Serve(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", defaultStats.Handler())
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return server.ListenAndServe()
+}
@@ -0,0 +1,79 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats_WriteTo(t *testing.T) {
+	s := NewStats()
+	s.ObserveLine(`{"severity":"ERROR"}`)
+	s.ObserveLine(`{"level":"info"}`)
+	s.ObserveLine(`{"jsonPayload":{"level":"info"}}`)
+	s.ObserveLine(`{"msg":"no severity"}`)
+	s.ObserveLine(`{"not":`)
+	s.ObserveLine(`plain text`)
+	s.ObserveLine(``)
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+	assert.Contains(t, body, "loggo_lines_ingested_total 6\n")
+	assert.Contains(t, body, "loggo_parse_failures_total 1\n")
+	assert.Contains(t, body, "loggo_dropped_lines_total 1\n")
+	assert.Contains(t, body, `loggo_entries_by_severity_total{severity="error"} 1`+"\n")
+	assert.Contains(t, body, `loggo_entries_by_severity_total{severity="info"} 2`+"\n")
+	assert.Contains(t, body, `loggo_entries_by_severity_total{severity="unknown"} 1`+"\n")
+	assert.Contains(t, body, "# TYPE loggo_lines_ingested_total counter\n")
+}
+
+func TestStats_WriteSummary(t *testing.T) {
+	s := NewStats()
+	s.ObserveLine(`{"severity":"ERROR","message":"timeout calling db-3 after 500ms"}`)
+	s.ObserveLine(`{"severity":"ERROR","message":"timeout calling db-7 after 1200ms"}`)
+	s.ObserveLine(`{"level":"fatal","msg":"order 4f1c2b9a-1d2e-4c3b-9a8f-0e1d2c3b4a59 rejected"}`)
+	s.ObserveLine(`{"level":"error"}`)
+	s.ObserveLine(`{"level":"info","msg":"timeout calling db-1 after 10ms"}`)
+	s.ObserveLine(`{"not json`)
+
+	assert.Equal(t, []ErrorGroup{
+		{Message: "timeout calling db-# after #ms", Count: 2},
+		{Message: "(no message)", Count: 1},
+	}, s.TopErrors(2))
+
+	sb := &strings.Builder{}
+	assert.NoError(t, s.WriteSummary(sb, 5))
+	out := sb.String()
+	assert.Contains(t, out, "  Lines ingested: 6 (")
+	assert.Contains(t, out, "  Parse failures: 1\n")
+	assert.Contains(t, out, "  By severity:    error 3, fatal 1, info 1\n")
+	assert.Contains(t, out, "         2  timeout calling db-# after #ms\n")
+	assert.Contains(t, out, "         1  order # rejected\n")
+}
@@ -0,0 +1,133 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/badaniya/loggo/internal/config"
+)
+
+const (
+	// maxErrorGroups caps the distinct error messages tracked for the summary.
+	maxErrorGroups = 1000
+	// maxErrorGroupLen truncates the grouped error messages.
+	maxErrorGroupLen = 100
+)
+
+// errorSeverities are the (lower cased) severities whose entries are grouped
+// for the summary.
+var errorSeverities = map[string]bool{
+	"error": true, "err": true, "fatal": true, "critical": true, "crit": true,
+	"alert": true, "emerg": true, "emergency": true, "panic": true, "severe": true,
+}
+
+// ErrorGroup counts the occurrences of an error message, its variable parts
+// masked with '#'.
+type ErrorGroup struct {
+	Message string
+	Count   int64
+}
+
+// observeError groups the message of an error entry.
+func (s *Stats) observeError(severity string, entry map[string]interface{}) {
+	if !errorSeverities[severity] {
+		return
+	}
+	group := errorGroup(config.EntryMessage(entry))
+	s.severityLock.Lock()
+	defer s.severityLock.Unlock()
+	if _, ok := s.errorGroups[group]; ok || len(s.errorGroups) < maxErrorGroups {
+		s.errorGroups[group]++
+	}
+}
+
+func errorGroup(msg string) string {
+	msg = config.NormalizeMessage(msg)
+	if len(msg) == 0 {
+		return "(no message)"
+	}
+	if r := []rune(msg); len(r) > maxErrorGroupLen {
+		msg = string(r[:maxErrorGroupLen-1]) + "…"
+	}
+	return msg
+}
+
+// TopErrors returns the n most frequent error groups, most frequent first.
+func (s *Stats) TopErrors(n int) []ErrorGroup {
+	s.severityLock.Lock()
+	groups := make([]ErrorGroup, 0, len(s.errorGroups))
+	for msg, count := range s.errorGroups {
+		groups = append(groups, ErrorGroup{Message: msg, Count: count})
+	}
+	s.severityLock.Unlock()
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Message < groups[j].Message
+	})
+	if len(groups) > n {
+		groups = groups[:n]
+	}
+	return groups
+}
+
+// WriteSummary writes a plain text summary of the session, meant to be pasted
+// e.g. into an incident channel: its duration, the lines ingested, parse
+// failures, entries by severity and the top grouped errors.
+func (s *Stats) WriteSummary(w io.Writer, topErrors int) error {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "l'oGGo session summary\n")
+	fmt.Fprintf(b, "  Duration:       %s\n", time.Since(s.started).Round(time.Second))
+	fmt.Fprintf(b, "  Lines ingested: %d (%s)\n", s.ingested.Load(), config.HumanizeBytes(float64(s.bytes.Load())))
+	fmt.Fprintf(b, "  Parse failures: %d\n", s.parseErrors.Load())
+
+	s.severityLock.Lock()
+	severities := make([]string, 0, len(s.severities))
+	for k := range s.severities {
+		severities = append(severities, k)
+	}
+	sort.Strings(severities)
+	counts := make([]string, len(severities))
+	for i, k := range severities {
+		counts[i] = fmt.Sprintf("%s %d", k, s.severities[k])
+	}
+	s.severityLock.Unlock()
+	if len(counts) > 0 {
+		fmt.Fprintf(b, "  By severity:    %s\n", strings.Join(counts, ", "))
+	}
+
+	if top := s.TopErrors(topErrors); len(top) > 0 {
+		fmt.Fprintf(b, "  Top errors:\n")
+		for _, g := range top {
+			fmt.Fprintf(b, "    %6d  %s\n", g.Count, g.Message)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,265 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+// Package parquet writes log entries as an Apache Parquet file with a column
+// per template key, typed after the key type, so captured windows can be
+// loaded into analytics tools such as DuckDB or Spark. Files hold a single
+// row group of uncompressed, plain encoded, optional columns.
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/badaniya/loggo/internal/config"
+)
+
+const magic = "PAR1"
+
+// Parquet physical types, converted types and enums used by the writer.
+const (
+	typeBoolean   = 0
+	typeInt64     = 2
+	typeDouble    = 5
+	typeByteArray = 6
+
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+
+	repetitionOptional = 1
+	encodingPlain      = 0
+	encodingRLE        = 3
+	codecUncompressed  = 0
+	pageData           = 0
+)
+
+// ErrNoColumns is returned when none of the keys can become a column.
+var ErrNoColumns = errors.New("no keys to export as columns")
+
+type column struct {
+	key       config.Key
+	name      string
+	kind      int32
+	converted int32
+	defined   []bool
+	values    bytes.Buffer
+	bools     []bool
+}
+
+// Write writes the entries as a Parquet file with a column per key. Numbers
+// and byte sizes become doubles, durations doubles in seconds, booleans
+// booleans and datetimes millisecond timestamps, while any other key is a
+// string. Missing values, and values which can't be parsed as their key
+// type, are null.
+func Write(w io.Writer, keys []config.Key, entries []map[string]interface{}) error {
+	columns := makeColumns(keys)
+	if len(columns) == 0 {
+		return ErrNoColumns
+	}
+	for _, entry := range entries {
+		for _, col := range columns {
+			col.add(col.key.ExtractValue(entry))
+		}
+	}
+	out := &bytes.Buffer{}
+	out.WriteString(magic)
+	meta := &thrift{}
+	meta.begin()
+	meta.i32(1, 1)
+	meta.list(2, ctStruct, len(columns)+1)
+	meta.begin()
+	meta.str(4, "schema")
+	meta.i32(5, int32(len(columns)))
+	meta.end()
+	for _, col := range columns {
+		meta.begin()
+		meta.i32(1, col.kind)
+		meta.i32(3, repetitionOptional)
+		meta.str(4, col.name)
+		if col.converted >= 0 {
+			meta.i32(6, col.converted)
+		}
+		meta.end()
+	}
+	meta.i64(3, int64(len(entries)))
+	meta.list(4, ctStruct, 1)
+	meta.begin()
+	meta.list(1, ctStruct, len(columns))
+	var total int64
+	for _, col := range columns {
+		offset := int64(out.Len())
+		col.writePage(out, len(entries))
+		size := int64(out.Len()) - offset
+		total += size
+		meta.begin()
+		meta.i64(2, offset)
+		meta.structField(3)
+		meta.i32(1, col.kind)
+		meta.list(2, ctI32, 2)
+		meta.rawI32(encodingPlain)
+		meta.rawI32(encodingRLE)
+		meta.list(3, ctBinary, 1)
+		meta.rawStr(col.name)
+		meta.i32(4, codecUncompressed)
+		meta.i64(5, int64(len(entries)))
+		meta.i64(6, size)
+		meta.i64(7, size)
+		meta.i64(9, offset)
+		meta.end()
+		meta.end()
+	}
+	meta.i64(2, total)
+	meta.i64(3, int64(len(entries)))
+	meta.end()
+	meta.str(6, "loggo")
+	meta.end()
+	out.Write(meta.buf.Bytes())
+	out.Write(binary.LittleEndian.AppendUint32(nil, uint32(meta.buf.Len())))
+	out.WriteString(magic)
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// makeColumns maps the keys to columns, skipping the ones whose name was
+// already taken.
+func makeColumns(keys []config.Key) []*column {
+	seen := make(map[string]bool)
+	var columns []*column
+	for _, k := range keys {
+		name := strings.TrimSpace(k.Name)
+		if len(name) == 0 || seen[name] {
+			continue
+		}
+		seen[name] = true
+		col := &column{key: k, name: name, converted: -1}
+		switch k.Type {
+		case config.TypeNumber, config.TypeBytes, config.TypeDuration:
+			col.kind = typeDouble
+		case config.TypeBool:
+			col.kind = typeBoolean
+		case config.TypeDateTime:
+			col.kind = typeInt64
+			col.converted = convertedTimestampMillis
+		default:
+			col.kind = typeByteArray
+			col.converted = convertedUTF8
+		}
+		columns = append(columns, col)
+	}
+	return columns
+}
+
+// add appends the value, plain encoded, or a null if it's empty or can't be
+// parsed as the column type.
+func (c *column) add(value string) {
+	ok := len(value) > 0
+	if ok {
+		switch c.kind {
+		case typeDouble:
+			f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if c.key.Type == config.TypeDuration {
+				var d time.Duration
+				d, err = config.ParseDuration(value)
+				f = d.Seconds()
+			}
+			if ok = err == nil; ok {
+				c.values.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(f)))
+			}
+		case typeBoolean:
+			b, err := strconv.ParseBool(strings.TrimSpace(value))
+			if ok = err == nil; ok {
+				c.bools = append(c.bools, b)
+			}
+		case typeInt64:
+			t, err := config.ParseTime(c.key.Layout, value)
+			if ok = err == nil; ok {
+				c.values.Write(binary.LittleEndian.AppendUint64(nil, uint64(t.UnixMilli())))
+			}
+		default:
+			c.values.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(value))))
+			c.values.WriteString(value)
+		}
+	}
+	c.defined = append(c.defined, ok)
+}
+
+// writePage writes the column as a single data page: its header, the
+// definition levels telling null values apart and the non null values.
+func (c *column) writePage(out *bytes.Buffer, rows int) {
+	data := encodeLevels(c.defined)
+	if c.kind == typeBoolean {
+		data = append(data, packBools(c.bools)...)
+	} else {
+		data = append(data, c.values.Bytes()...)
+	}
+	header := &thrift{}
+	header.begin()
+	header.i32(1, pageData)
+	header.i32(2, int32(len(data)))
+	header.i32(3, int32(len(data)))
+	header.structField(5)
+	header.i32(1, int32(rows))
+	header.i32(2, encodingPlain)
+	header.i32(3, encodingRLE)
+	header.i32(4, encodingRLE)
+	header.end()
+	header.end()
+	out.Write(header.buf.Bytes())
+	out.Write(data)
+}
+
+// encodeLevels encodes the definition levels (1 bit wide) as runs of the
+// RLE/bit-packing hybrid encoding, prefixed by their length.
+func encodeLevels(defined []bool) []byte {
+	var runs []byte
+	for i := 0; i < len(defined); {
+		j := i
+		for j < len(defined) && defined[j] == defined[i] {
+			j++
+		}
+		runs = binary.AppendUvarint(runs, uint64(j-i)<<1)
+		if defined[i] {
+			runs = append(runs, 1)
+		} else {
+			runs = append(runs, 0)
+		}
+		i = j
+	}
+	return append(binary.LittleEndian.AppendUint32(nil, uint32(len(runs))), runs...)
+}
+
+// packBools plain encodes booleans, one bit each, least significant first.
+func packBools(bools []bool) []byte {
+	packed := make([]byte, (len(bools)+7)/8)
+	for i, b := range bools {
+		if b {
+			packed[i/8] |= 1 << (i % 8)
+		}
+	}
+	return packed
+}
@@ -0,0 +1,134 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColumnAdd(t *testing.T) {
+	tests := []struct {
+		name        string
+		key         config.Key
+		values      []string
+		wantDefined []bool
+		wantValues  []byte
+	}{
+		{
+			name:        "String",
+			key:         config.Key{Name: "level"},
+			values:      []string{"INFO", ""},
+			wantDefined: []bool{true, false},
+			wantValues:  []byte{4, 0, 0, 0, 'I', 'N', 'F', 'O'},
+		},
+		{
+			name:        "Number",
+			key:         config.Key{Name: "status", Type: config.TypeNumber},
+			values:      []string{"x", " 2 "},
+			wantDefined: []bool{false, true},
+			wantValues:  binary.LittleEndian.AppendUint64(nil, math.Float64bits(2)),
+		},
+		{
+			name:        "Duration in seconds",
+			key:         config.Key{Name: "latency", Type: config.TypeDuration},
+			values:      []string{"500ms"},
+			wantDefined: []bool{true},
+			wantValues:  binary.LittleEndian.AppendUint64(nil, math.Float64bits(0.5)),
+		},
+		{
+			name:        "Datetime in milliseconds",
+			key:         config.Key{Name: "ts", Type: config.TypeDateTime},
+			values:      []string{"2024-06-01T10:00:00.5Z", "yesterday"},
+			wantDefined: []bool{true, false},
+			wantValues:  binary.LittleEndian.AppendUint64(nil, 1717236000500),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			col := makeColumns([]config.Key{test.key})[0]
+			for _, v := range test.values {
+				col.add(v)
+			}
+			assert.Equal(t, test.wantDefined, col.defined)
+			assert.Equal(t, test.wantValues, col.values.Bytes())
+		})
+	}
+}
+
+func TestEncodeLevels(t *testing.T) {
+	tests := []struct {
+		name    string
+		defined []bool
+		want    []byte
+	}{
+		{
+			name: "Empty",
+			want: []byte{0, 0, 0, 0},
+		},
+		{
+			name:    "Runs",
+			defined: []bool{true, true, true, false, true},
+			want:    []byte{6, 0, 0, 0, 3 << 1, 1, 1 << 1, 0, 1 << 1, 1},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, encodeLevels(test.defined))
+		})
+	}
+}
+
+func TestPackBools(t *testing.T) {
+	assert.Equal(t, []byte{0b00000101, 0b1}, packBools([]bool{true, false, true, false, false, false, false, false, true}))
+}
+
+func TestWrite(t *testing.T) {
+	keys := []config.Key{
+		{Name: "level"},
+		{Name: "level"},
+		{Name: "ok", Type: config.TypeBool},
+	}
+	entries := []map[string]interface{}{
+		{"level": "INFO", "ok": true},
+		{"level": "ERROR"},
+	}
+	buf := &bytes.Buffer{}
+	assert.NoError(t, Write(buf, keys, entries))
+	b := buf.Bytes()
+	assert.Equal(t, magic, string(b[:4]))
+	assert.Equal(t, magic, string(b[len(b)-4:]))
+	footer := int(binary.LittleEndian.Uint32(b[len(b)-8:]))
+	assert.Less(t, footer, len(b)-12)
+	meta := b[len(b)-8-footer : len(b)-8]
+	assert.Contains(t, string(meta), "level")
+	assert.Contains(t, string(meta), "loggo")
+	assert.Equal(t, 2, bytes.Count(meta, []byte("\x05level")), "the duplicate key is skipped")
+
+	assert.ErrorIs(t, Write(buf, nil, entries), ErrNoColumns)
+}
@@ -0,0 +1,116 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Thrift compact protocol type ids, as found in field and list headers.
+const (
+	ctI32    = 5
+	ctI64    = 6
+	ctBinary = 8
+	ctList   = 9
+	ctStruct = 12
+)
+
+// thrift encodes the Parquet metadata structs with the Thrift compact
+// protocol. Structs are opened with begin and closed with end, and their
+// fields must be written in ascending id order.
+type thrift struct {
+	buf  bytes.Buffer
+	last []int16
+}
+
+func (t *thrift) begin() {
+	t.last = append(t.last, 0)
+}
+
+func (t *thrift) end() {
+	t.buf.WriteByte(0)
+	t.last = t.last[:len(t.last)-1]
+}
+
+func (t *thrift) field(id int16, typ byte) {
+	last := &t.last[len(t.last)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.varint(int64(id))
+	}
+	*last = id
+}
+
+func (t *thrift) i32(id int16, v int32) {
+	t.field(id, ctI32)
+	t.varint(int64(v))
+}
+
+func (t *thrift) i64(id int16, v int64) {
+	t.field(id, ctI64)
+	t.varint(v)
+}
+
+func (t *thrift) str(id int16, s string) {
+	t.field(id, ctBinary)
+	t.rawStr(s)
+}
+
+// structField opens a struct valued field, to be closed with end.
+func (t *thrift) structField(id int16) {
+	t.field(id, ctStruct)
+	t.begin()
+}
+
+// list starts a list field of n elements of the given type, each to be
+// written with rawI32, rawStr or begin/end.
+func (t *thrift) list(id int16, elemType byte, n int) {
+	t.field(id, ctList)
+	if n < 15 {
+		t.buf.WriteByte(byte(n)<<4 | elemType)
+	} else {
+		t.buf.WriteByte(0xf0 | elemType)
+		t.uvarint(uint64(n))
+	}
+}
+
+func (t *thrift) rawI32(v int32) {
+	t.varint(int64(v))
+}
+
+func (t *thrift) rawStr(s string) {
+	t.uvarint(uint64(len(s)))
+	t.buf.WriteString(s)
+}
+
+// varint writes v zigzag encoded.
+func (t *thrift) varint(v int64) {
+	t.uvarint(uint64(v<<1) ^ uint64(v>>63))
+}
+
+func (t *thrift) uvarint(v uint64) {
+	t.buf.Write(binary.AppendUvarint(nil, v))
+}
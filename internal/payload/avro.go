@@ -0,0 +1,321 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package payload
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+)
+
+// avroSingleObjectMagic prefixes Avro single object encoded messages, followed
+// by the 8 bytes schema fingerprint.
+var avroSingleObjectMagic = []byte{0xC3, 0x01}
+
+type avroSchema struct {
+	Type    string
+	Name    string
+	Fields  []avroField
+	Symbols []string
+	Items   *avroSchema
+	Values  *avroSchema
+	Size    int
+	Union   []*avroSchema
+}
+
+type avroField struct {
+	Name   string
+	Schema *avroSchema
+}
+
+type avroDecoder struct {
+	schema *avroSchema
+}
+
+// NewAvroDecoder builds a decoder for Avro binary encoded payloads from a JSON
+// schema (.avsc) file. Values are rendered as plain JSON, meaning unions are
+// unwrapped into the selected branch value.
+func NewAvroDecoder(schemaFile string) (Decoder, error) {
+	b, err := os.ReadFile(schemaFile)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := ParseAvroSchema(b)
+	if err != nil {
+		return nil, err
+	}
+	return &avroDecoder{schema: schema}, nil
+}
+
+// ParseAvroSchema parses an Avro JSON schema definition.
+func ParseAvroSchema(b []byte) (*avroSchema, error) {
+	var raw interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+	return parseAvroSchema(raw, make(map[string]*avroSchema), "")
+}
+
+func parseAvroSchema(raw interface{}, named map[string]*avroSchema, namespace string) (*avroSchema, error) {
+	switch v := raw.(type) {
+	case string:
+		switch v {
+		case "null", "boolean", "int", "long", "float", "double", "bytes", "string":
+			return &avroSchema{Type: v}, nil
+		}
+		if s, ok := named[v]; ok {
+			return s, nil
+		}
+		if s, ok := named[namespace+"."+v]; ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("unknown avro type %q", v)
+	case []interface{}:
+		s := &avroSchema{Type: "union"}
+		for _, branch := range v {
+			bs, err := parseAvroSchema(branch, named, namespace)
+			if err != nil {
+				return nil, err
+			}
+			s.Union = append(s.Union, bs)
+		}
+		return s, nil
+	case map[string]interface{}:
+		tp, _ := v["type"].(string)
+		s := &avroSchema{Type: tp}
+		switch tp {
+		case "record", "error", "enum", "fixed":
+			if tp == "error" {
+				s.Type = "record"
+			}
+			name, _ := v["name"].(string)
+			if ns, ok := v["namespace"].(string); ok {
+				namespace = ns
+			}
+			s.Name = name
+			named[name] = s
+			if len(namespace) > 0 {
+				named[namespace+"."+name] = s
+			}
+		}
+		switch s.Type {
+		case "record":
+			fields, _ := v["fields"].([]interface{})
+			for _, f := range fields {
+				fm, ok := f.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("invalid field definition in record %s", s.Name)
+				}
+				fs, err := parseAvroSchema(fm["type"], named, namespace)
+				if err != nil {
+					return nil, err
+				}
+				name, _ := fm["name"].(string)
+				s.Fields = append(s.Fields, avroField{Name: name, Schema: fs})
+			}
+		case "enum":
+			symbols, _ := v["symbols"].([]interface{})
+			for _, sym := range symbols {
+				s.Symbols = append(s.Symbols, fmt.Sprintf("%v", sym))
+			}
+		case "fixed":
+			size, _ := v["size"].(float64)
+			s.Size = int(size)
+		case "array":
+			items, err := parseAvroSchema(v["items"], named, namespace)
+			if err != nil {
+				return nil, err
+			}
+			s.Items = items
+		case "map":
+			values, err := parseAvroSchema(v["values"], named, namespace)
+			if err != nil {
+				return nil, err
+			}
+			s.Values = values
+		default:
+			// primitive types declared as objects, e.g. with a logical type.
+			return parseAvroSchema(tp, named, namespace)
+		}
+		return s, nil
+	}
+	return nil, fmt.Errorf("invalid avro schema definition %v", raw)
+}
+
+func (a *avroDecoder) Decode(b []byte) (interface{}, error) {
+	if len(b) > 10 && b[0] == avroSingleObjectMagic[0] && b[1] == avroSingleObjectMagic[1] {
+		b = b[10:]
+	}
+	r := &avroReader{buf: b}
+	v, err := r.read(a.schema)
+	if err != nil {
+		return nil, err
+	}
+	if r.pos != len(r.buf) {
+		return nil, fmt.Errorf("avro payload has %d trailing bytes", len(r.buf)-r.pos)
+	}
+	return v, nil
+}
+
+type avroReader struct {
+	buf []byte
+	pos int
+}
+
+func (r *avroReader) read(s *avroSchema) (interface{}, error) {
+	switch s.Type {
+	case "null":
+		return nil, nil
+	case "boolean":
+		b, err := r.readBytes(1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0] != 0, nil
+	case "int", "long":
+		return r.readLong()
+	case "float":
+		b, err := r.readBytes(4)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(b)), nil
+	case "double":
+		b, err := r.readBytes(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+	case "bytes", "string":
+		n, err := r.readLong()
+		if err != nil {
+			return nil, err
+		}
+		b, err := r.readBytes(int(n))
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	case "fixed":
+		b, err := r.readBytes(s.Size)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	case "enum":
+		i, err := r.readLong()
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || int(i) >= len(s.Symbols) {
+			return nil, fmt.Errorf("enum index %d out of range", i)
+		}
+		return s.Symbols[i], nil
+	case "union":
+		i, err := r.readLong()
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || int(i) >= len(s.Union) {
+			return nil, fmt.Errorf("union index %d out of range", i)
+		}
+		return r.read(s.Union[i])
+	case "record":
+		m := make(map[string]interface{})
+		for _, f := range s.Fields {
+			v, err := r.read(f.Schema)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", s.Name, f.Name, err)
+			}
+			m[f.Name] = v
+		}
+		return m, nil
+	case "array":
+		var arr []interface{}
+		err := r.readBlocks(func() error {
+			v, err := r.read(s.Items)
+			arr = append(arr, v)
+			return err
+		})
+		return arr, err
+	case "map":
+		m := make(map[string]interface{})
+		err := r.readBlocks(func() error {
+			k, err := r.read(&avroSchema{Type: "string"})
+			if err != nil {
+				return err
+			}
+			v, err := r.read(s.Values)
+			m[k.(string)] = v
+			return err
+		})
+		return m, err
+	}
+	return nil, fmt.Errorf("unsupported avro type %q", s.Type)
+}
+
+// readBlocks iterates over the blocks of arrays and maps, which are prefixed
+// with their item count and terminated by a zero count block.
+func (r *avroReader) readBlocks(item func() error) error {
+	for {
+		n, err := r.readLong()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+		if n < 0 {
+			n = -n
+			// skip the block size in bytes
+			if _, err := r.readLong(); err != nil {
+				return err
+			}
+		}
+		for i := int64(0); i < n; i++ {
+			if err := item(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (r *avroReader) readLong() (int64, error) {
+	v, n := binary.Varint(r.buf[r.pos:])
+	if n <= 0 {
+		return 0, fmt.Errorf("invalid avro varint at offset %d", r.pos)
+	}
+	r.pos += n
+	return v, nil
+}
+
+func (r *avroReader) readBytes(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.buf) {
+		return nil, fmt.Errorf("avro payload too short, wants %d bytes at offset %d", n, r.pos)
+	}
+	b := r.buf[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
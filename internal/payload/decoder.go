@@ -0,0 +1,177 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package payload
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/badaniya/loggo/internal/config"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Decoder turns binary encoded payloads into JSON compatible values.
+type Decoder interface {
+	Decode(b []byte) (interface{}, error)
+}
+
+// FieldDecoder binds a Decoder to the key holding the base64 encoded payload.
+type FieldDecoder struct {
+	Key     config.Key
+	Decoder Decoder
+}
+
+// MakeFieldDecoders builds the decoders declared in a template.
+func MakeFieldDecoders(decoders []config.PayloadDecoder) ([]FieldDecoder, error) {
+	var fds []FieldDecoder
+	for _, d := range decoders {
+		var dec Decoder
+		var err error
+		switch strings.ToLower(d.Format) {
+		case config.FormatProtobuf:
+			dec, err = NewProtobufDecoder(d.Schema, d.Message)
+		case config.FormatAvro:
+			dec, err = NewAvroDecoder(d.Schema)
+		default:
+			err = fmt.Errorf("unsupported decoder format %q", d.Format)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decoder for key %s: %w", d.Key, err)
+		}
+		fds = append(fds, FieldDecoder{
+			Key:     config.Key{Name: d.Key},
+			Decoder: dec,
+		})
+	}
+	return fds, nil
+}
+
+// DecodeFields returns a copy of the entry where every field with a matching
+// decoder is replaced by its decoded value. Fields that fail to decode are
+// kept as they are. The original entry is never modified.
+func DecodeFields(entry map[string]interface{}, decoders []FieldDecoder) map[string]interface{} {
+	for _, fd := range decoders {
+		raw := fd.Key.ExtractValue(entry)
+		b, ok := DecodeBase64(raw)
+		if !ok {
+			continue
+		}
+		v, err := fd.Decoder.Decode(b)
+		if err != nil {
+			continue
+		}
+		entry = setPath(entry, strings.Split(fd.Key.Name, "/"), v)
+	}
+	return entry
+}
+
+// DecodeBase64 decodes standard or URL safe base64 values, padded or not.
+func DecodeBase64(value string) ([]byte, bool) {
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return nil, false
+	}
+	for _, enc := range []*base64.Encoding{
+		base64.StdEncoding, base64.URLEncoding,
+		base64.RawStdEncoding, base64.RawURLEncoding,
+	} {
+		if b, err := enc.DecodeString(value); err == nil {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+// setPath copies each level of the path so the value can be replaced without
+// affecting the original entry.
+func setPath(m map[string]interface{}, path []string, v interface{}) map[string]interface{} {
+	cp := make(map[string]interface{}, len(m))
+	for k, val := range m {
+		cp[k] = val
+	}
+	if len(path) == 1 {
+		cp[path[0]] = v
+		return cp
+	}
+	child, ok := m[path[0]].(map[string]interface{})
+	if !ok {
+		return m
+	}
+	cp[path[0]] = setPath(child, path[1:], v)
+	return cp
+}
+
+type protobufDecoder struct {
+	desc protoreflect.MessageDescriptor
+}
+
+// NewProtobufDecoder builds a decoder for protobuf encoded payloads of the given
+// fully qualified message name, described in a FileDescriptorSet file such as
+// the one generated by `protoc --include_imports --descriptor_set_out`.
+func NewProtobufDecoder(descriptorSetFile, messageName string) (Decoder, error) {
+	b, err := os.ReadFile(descriptorSetFile)
+	if err != nil {
+		return nil, err
+	}
+	fds := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(b, fds); err != nil {
+		return nil, err
+	}
+	files, err := protodesc.NewFiles(fds)
+	if err != nil {
+		return nil, err
+	}
+	d, err := files.FindDescriptorByName(protoreflect.FullName(messageName))
+	if err != nil {
+		return nil, err
+	}
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a message", messageName)
+	}
+	return &protobufDecoder{desc: md}, nil
+}
+
+func (p *protobufDecoder) Decode(b []byte) (interface{}, error) {
+	msg := dynamicpb.NewMessage(p.desc)
+	if err := proto.Unmarshal(b, msg); err != nil {
+		return nil, err
+	}
+	jb, err := protojson.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := json.Unmarshal(jb, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
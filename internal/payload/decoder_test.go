@@ -0,0 +1,162 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package payload
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const eventSchema = `{
+  "type": "record",
+  "name": "Event",
+  "namespace": "acme",
+  "fields": [
+    {"name": "id", "type": "long"},
+    {"name": "name", "type": "string"},
+    {"name": "level", "type": {"type": "enum", "name": "Level", "symbols": ["INFO", "ERROR"]}},
+    {"name": "tags", "type": {"type": "array", "items": "string"}},
+    {"name": "owner", "type": ["null", "string"]},
+    {"name": "score", "type": "double"}
+  ]
+}`
+
+func avroLong(v int64) []byte {
+	b := make([]byte, binary.MaxVarintLen64)
+	return b[:binary.PutVarint(b, v)]
+}
+
+func avroString(s string) []byte {
+	return append(avroLong(int64(len(s))), []byte(s)...)
+}
+
+func encodedEvent() []byte {
+	var b []byte
+	b = append(b, avroLong(42)...)
+	b = append(b, avroString("checkout")...)
+	b = append(b, avroLong(1)...)
+	b = append(b, avroLong(2)...)
+	b = append(b, avroString("a")...)
+	b = append(b, avroString("b")...)
+	b = append(b, avroLong(0)...)
+	b = append(b, avroLong(1)...)
+	b = append(b, avroString("team")...)
+	score := make([]byte, 8)
+	binary.LittleEndian.PutUint64(score, 0x3FF8000000000000) // 1.5
+	return append(b, score...)
+}
+
+func TestAvroDecoder_Decode(t *testing.T) {
+	schema, err := ParseAvroSchema([]byte(eventSchema))
+	assert.NoError(t, err)
+	dec := &avroDecoder{schema: schema}
+	v, err := dec.Decode(encodedEvent())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"id":    int64(42),
+		"name":  "checkout",
+		"level": "ERROR",
+		"tags":  []interface{}{"a", "b"},
+		"owner": "team",
+		"score": 1.5,
+	}, v)
+
+	_, err = dec.Decode(encodedEvent()[:5])
+	assert.Error(t, err)
+}
+
+func TestDecodeFields(t *testing.T) {
+	file := path.Join(t.TempDir(), "event.avsc")
+	assert.NoError(t, os.WriteFile(file, []byte(eventSchema), 0o644))
+	decoders, err := MakeFieldDecoders([]config.PayloadDecoder{
+		{Key: "message/data", Format: config.FormatAvro, Schema: file},
+	})
+	assert.NoError(t, err)
+	data := base64.StdEncoding.EncodeToString(encodedEvent())
+	entry := map[string]interface{}{
+		"message": map[string]interface{}{"data": data},
+		"other":   "value",
+	}
+	decoded := DecodeFields(entry, decoders)
+	assert.Equal(t, "checkout", decoded["message"].(map[string]interface{})["data"].(map[string]interface{})["name"])
+	assert.Equal(t, "value", decoded["other"])
+	// original entry remains untouched
+	assert.Equal(t, data, entry["message"].(map[string]interface{})["data"])
+
+	entry["message"].(map[string]interface{})["data"] = "not base64!"
+	assert.Equal(t, entry, DecodeFields(entry, decoders))
+}
+
+func TestMakeFieldDecoders_UnknownFormat(t *testing.T) {
+	_, err := MakeFieldDecoders([]config.PayloadDecoder{{Key: "data", Format: "xml"}})
+	assert.Error(t, err)
+}
+
+func TestProtobufDecoder_Decode(t *testing.T) {
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("event.proto"),
+		Package: proto.String("acme"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Event"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{
+					Name:     proto.String("name"),
+					JsonName: proto.String("name"),
+					Number:   proto.Int32(1),
+					Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				},
+				{
+					Name:     proto.String("count"),
+					JsonName: proto.String("count"),
+					Number:   proto.Int32(2),
+					Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					Type:     descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum(),
+				},
+			},
+		}},
+	}
+	b, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{fdp}})
+	assert.NoError(t, err)
+	file := path.Join(t.TempDir(), "event.pb")
+	assert.NoError(t, os.WriteFile(file, b, 0o644))
+
+	dec, err := NewProtobufDecoder(file, "acme.Event")
+	assert.NoError(t, err)
+	// name: "hi", count: 5
+	v, err := dec.Decode([]byte{0x0a, 0x02, 'h', 'i', 0x10, 0x05})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "hi", "count": float64(5)}, v)
+
+	_, err = NewProtobufDecoder(file, "acme.Missing")
+	assert.Error(t, err)
+}
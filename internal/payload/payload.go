@@ -0,0 +1,179 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+// Package payload detects and formats payloads commonly embedded as plain
+// strings within log entries, such as JSON documents, stack traces, SQL
+// statements and URL encoded forms.
+package payload
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+type Kind int
+
+const (
+	KindNone Kind = iota
+	KindJSON
+	KindStackTrace
+	KindSQL
+	KindForm
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindJSON:
+		return "json"
+	case KindStackTrace:
+		return "stack trace"
+	case KindSQL:
+		return "sql"
+	case KindForm:
+		return "form"
+	}
+	return "none"
+}
+
+var (
+	stackTraceReg  = regexp.MustCompile(`(?m)(^\s+at [\w$.<>/]+\(|^goroutine \d+ \[|^Traceback \(most recent call last\)|^\s+File ".+", line \d+)`)
+	sqlReg         = regexp.MustCompile(`(?is)^\s*(SELECT\s.+\sFROM\s|INSERT\s+INTO\s|UPDATE\s+\S+\s+SET\s|DELETE\s+FROM\s|WITH\s+\w+\s+AS\s*\()`)
+	formReg        = regexp.MustCompile(`^[\w.\-%+\[\]]+=[^&\s]*(&[\w.\-%+\[\]]+=[^&\s]*)+$`)
+	sqlClauseReg   = regexp.MustCompile(`(?i)\s+\b(FROM|WHERE|GROUP\s+BY|ORDER\s+BY|HAVING|LIMIT|OFFSET|VALUES|SET|RETURNING|UNION(\s+ALL)?|((LEFT|RIGHT|FULL|INNER|CROSS)(\s+OUTER)?\s+)?JOIN|ON\s+CONFLICT)\b`)
+	sqlLogicalReg  = regexp.MustCompile(`(?i)\s+\b(AND|OR)\b\s+`)
+	escapedLineReg = regexp.MustCompile(`(\\r)?\\n`)
+)
+
+// Detect inspects the given value and reports which kind of payload it holds.
+func Detect(value string) Kind {
+	trimmed := strings.TrimSpace(value)
+	if len(trimmed) < 2 {
+		return KindNone
+	}
+	if _, ok := DecodeJSON(trimmed); ok {
+		return KindJSON
+	}
+	if stackTraceReg.MatchString(unescapeLines(trimmed)) {
+		return KindStackTrace
+	}
+	if sqlReg.MatchString(trimmed) {
+		return KindSQL
+	}
+	if formReg.MatchString(trimmed) {
+		return KindForm
+	}
+	return KindNone
+}
+
+// DecodeJSON decodes a JSON object or array embedded as a string.
+func DecodeJSON(value string) (interface{}, bool) {
+	trimmed := strings.TrimSpace(value)
+	if len(trimmed) < 2 ||
+		!(trimmed[0] == '{' && trimmed[len(trimmed)-1] == '}') &&
+			!(trimmed[0] == '[' && trimmed[len(trimmed)-1] == ']') {
+		return nil, false
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(trimmed), &v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// Format renders a human friendly, possibly multi-line, version of the value.
+// It returns KindNone and the original value if no known payload is detected.
+// JSON payloads are returned indented; use DecodeJSON to obtain the structure.
+func Format(value string) (string, Kind) {
+	kind := Detect(value)
+	switch kind {
+	case KindJSON:
+		v, _ := DecodeJSON(value)
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err == nil {
+			return string(b), kind
+		}
+	case KindStackTrace:
+		return FormatStackTrace(value), kind
+	case KindSQL:
+		return FormatSQL(value), kind
+	case KindForm:
+		if f, ok := FormatForm(value); ok {
+			return f, kind
+		}
+	}
+	return value, KindNone
+}
+
+// FormatStackTrace expands escaped line breaks and tabs and trims trailing blanks.
+func FormatStackTrace(value string) string {
+	value = unescapeLines(value)
+	lines := strings.Split(value, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " \r")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// FormatSQL breaks the statement before its main clauses, indenting logical operators.
+func FormatSQL(value string) string {
+	value = strings.Join(strings.Fields(value), " ")
+	value = sqlClauseReg.ReplaceAllString(value, "\n$1")
+	value = sqlLogicalReg.ReplaceAllString(value, "\n  $1 ")
+	return value
+}
+
+// FormatForm decodes an URL encoded form, placing each field in its own line
+// while retaining the original field order.
+func FormatForm(value string) (string, bool) {
+	if _, err := url.ParseQuery(value); err != nil {
+		return value, false
+	}
+	sb := strings.Builder{}
+	for i, pair := range strings.Split(value, "&") {
+		k, v, _ := strings.Cut(pair, "=")
+		dk, err := url.QueryUnescape(k)
+		if err != nil {
+			return value, false
+		}
+		dv, err := url.QueryUnescape(v)
+		if err != nil {
+			return value, false
+		}
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(dk + " = " + dv)
+	}
+	return sb.String(), true
+}
+
+// unescapeLines expands escaped line breaks and tabs of values that have been
+// encoded twice, leaving values that already span multiple lines untouched.
+func unescapeLines(value string) string {
+	if strings.Contains(value, "\n") {
+		return value
+	}
+	value = escapedLineReg.ReplaceAllString(value, "\n")
+	return strings.ReplaceAll(value, `\t`, "\t")
+}
@@ -0,0 +1,140 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package payload
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name       string
+		givenValue string
+		wantsKind  Kind
+	}{
+		{
+			name:       "Embedded json object",
+			givenValue: `{"a": {"b": 1}}`,
+			wantsKind:  KindJSON,
+		},
+		{
+			name:       "Embedded json array",
+			givenValue: ` [1, 2, 3] `,
+			wantsKind:  KindJSON,
+		},
+		{
+			name:       "Java stack trace",
+			givenValue: "java.lang.NullPointerException: boom\n\tat com.acme.Foo.bar(Foo.java:12)\n\tat com.acme.Main.main(Main.java:3)",
+			wantsKind:  KindStackTrace,
+		},
+		{
+			name:       "Escaped go stack trace",
+			givenValue: `panic: boom\n\ngoroutine 1 [running]:\nmain.main()\n\t/app/main.go:5 +0x25`,
+			wantsKind:  KindStackTrace,
+		},
+		{
+			name:       "Python traceback",
+			givenValue: "Traceback (most recent call last):\n  File \"app.py\", line 3, in <module>\nValueError: boom",
+			wantsKind:  KindStackTrace,
+		},
+		{
+			name:       "SQL select",
+			givenValue: "select id, name from users where id = 1 and active = true",
+			wantsKind:  KindSQL,
+		},
+		{
+			name:       "URL encoded form",
+			givenValue: "name=John+Doe&email=john%40acme.com&tags%5B%5D=a",
+			wantsKind:  KindForm,
+		},
+		{
+			name:       "Plain text",
+			givenValue: "user logged in from the office",
+			wantsKind:  KindNone,
+		},
+		{
+			name:       "Single assignment is not a form",
+			givenValue: "retries=3",
+			wantsKind:  KindNone,
+		},
+		{
+			name:       "Broken json",
+			givenValue: `{"a": `,
+			wantsKind:  KindNone,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.wantsKind, Detect(test.givenValue))
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name       string
+		givenValue string
+		wantsValue string
+		wantsKind  Kind
+	}{
+		{
+			name:       "Indent json",
+			givenValue: `{"a":1}`,
+			wantsValue: "{\n  \"a\": 1\n}",
+			wantsKind:  KindJSON,
+		},
+		{
+			name:       "Break sql clauses",
+			givenValue: "SELECT id FROM users u LEFT JOIN orders o ON o.user_id = u.id WHERE u.id = 1 AND o.total > 2 ORDER BY o.id LIMIT 5",
+			wantsValue: "SELECT id\nFROM users u\nLEFT JOIN orders o ON o.user_id = u.id\nWHERE u.id = 1\n  AND o.total > 2\nORDER BY o.id\nLIMIT 5",
+			wantsKind:  KindSQL,
+		},
+		{
+			name:       "Decode form",
+			givenValue: "name=John+Doe&email=john%40acme.com",
+			wantsValue: "name = John Doe\nemail = john@acme.com",
+			wantsKind:  KindForm,
+		},
+		{
+			name:       "Expand escaped stack trace",
+			givenValue: `Exception: boom\n\tat com.acme.Foo.bar(Foo.java:12)`,
+			wantsValue: "Exception: boom\n\tat com.acme.Foo.bar(Foo.java:12)",
+			wantsKind:  KindStackTrace,
+		},
+		{
+			name:       "Leave plain text untouched",
+			givenValue: "hello world",
+			wantsValue: "hello world",
+			wantsKind:  KindNone,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, k := Format(test.givenValue)
+			assert.Equal(t, test.wantsKind, k)
+			assert.Equal(t, test.wantsValue, v)
+		})
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+// Package pipeline composes the stages every ingested line goes through,
+// from the raw line read off the source to the entry buffered for the view.
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/badaniya/loggo/internal/config"
+)
+
+// Stage is a step of the pipeline, the stages running in their declared order.
+type Stage int
+
+const (
+	// Parse turns the raw line into the fields of the entry.
+	Parse Stage = iota
+	// Enrich adds keys to the entry, e.g. extractions or GeoIP lookups.
+	Enrich
+	// Redact flags or masks sensitive values.
+	Redact
+	// Watch observes the entries, e.g. counting errors, without altering them.
+	Watch
+	// View prepares the entry for the table, last before it's buffered.
+	View
+	stages
+)
+
+var stageNames = [stages]string{"parse", "enrich", "redact", "watch", "view"}
+
+func (s Stage) String() string {
+	if s < 0 || s >= stages {
+		return fmt.Sprintf("stage(%d)", int(s))
+	}
+	return stageNames[s]
+}
+
+// Entry is a line going through the pipeline.
+type Entry struct {
+	Line   string
+	Fields map[string]interface{}
+	// Err is why the line couldn't be parsed, if so, the fields then holding
+	// the raw line, see config.TextPayload, as they do for plain text lines.
+	Err error
+}
+
+// Processor processes the entry in place, returning false to drop it.
+type Processor func(e *Entry) bool
+
+type processor struct {
+	name    string
+	process Processor
+}
+
+// Pipeline runs lines through the processors registered to each stage.
+type Pipeline struct {
+	stages [stages][]processor
+}
+
+// New returns a pipeline without processors, see Register.
+func New() *Pipeline {
+	return &Pipeline{}
+}
+
+// Register appends a processor to the stage, the processors of a stage
+// running in the order they were registered.
+func (p *Pipeline) Register(stage Stage, name string, process Processor) {
+	if stage < 0 || stage >= stages {
+		panic(fmt.Sprintf("pipeline: unknown %v of %q", stage, name))
+	}
+	p.stages[stage] = append(p.stages[stage], processor{name, process})
+}
+
+// Process runs the line through every stage, returning the entry, or nil if
+// a processor dropped it.
+func (p *Pipeline) Process(line string) *Entry {
+	return p.run(&Entry{Line: line})
+}
+
+// ProcessParsed runs the line already parsed into its fields, e.g. ahead by
+// its reader, through every stage, see Process and config.ParseLine.
+func (p *Pipeline) ProcessParsed(line string, fields map[string]interface{}, err error) *Entry {
+	return p.run(&Entry{Line: line, Fields: fields, Err: err})
+}
+
+func (p *Pipeline) run(e *Entry) *Entry {
+	for _, processors := range p.stages {
+		for _, pr := range processors {
+			if !pr.process(e) {
+				return nil
+			}
+		}
+	}
+	if e.Fields == nil {
+		e.Fields = make(map[string]interface{})
+	}
+	return e
+}
+
+// Names lists the registered processors in the order they run, prefixed by
+// their stage, e.g. parse/json.
+func (p *Pipeline) Names() []string {
+	var names []string
+	for stage, processors := range p.stages {
+		for _, pr := range processors {
+			names = append(names, Stage(stage).String()+"/"+pr.name)
+		}
+	}
+	return names
+}
+
+// ParseJSON parses the line as a JSON object, or keeps it as the text
+// payload, along with the parse error if it's malformed JSON rather than plain
+// text, see config.ParseLine.
+func ParseJSON(e *Entry) bool {
+	if e.Fields != nil {
+		return true
+	}
+	e.Fields, e.Err = config.ParseLine(e.Line)
+	return true
+}
+
+// Fields adapts a function processing the fields in place, e.g. an
+// enricher's, into a processor keeping every entry.
+func Fields(process func(m map[string]interface{})) Processor {
+	return func(e *Entry) bool {
+		process(e.Fields)
+		return true
+	}
+}
+
+// Parsed restricts the processor to the entries parsed into keys, keeping the
+// text ones.
+func Parsed(process Processor) Processor {
+	return func(e *Entry) bool {
+		return e.Err != nil || config.IsText(e.Fields) || process(e)
+	}
+}
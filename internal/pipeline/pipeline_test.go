@@ -0,0 +1,121 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package pipeline
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/badaniya/loggo/internal/config"
+)
+
+func TestPipeline_Process(t *testing.T) {
+	p := New()
+	var watched []string
+	// registered out of order, run by stage
+	p.Register(Watch, "watch", func(e *Entry) bool {
+		watched = append(watched, e.Fields["message"].(string))
+		return true
+	})
+	p.Register(Redact, "mask", Fields(func(m map[string]interface{}) {
+		m["message"] = strings.ReplaceAll(m["message"].(string), "hunter2", "***")
+	}))
+	p.Register(Parse, "json", ParseJSON)
+	p.Register(Enrich, "upper", Parsed(Fields(func(m map[string]interface{}) {
+		m["upper"] = strings.ToUpper(m["message"].(string))
+	})))
+	p.Register(Enrich, "drop-debug", func(e *Entry) bool {
+		return e.Fields["level"] != "DEBUG"
+	})
+
+	assert.Equal(t, []string{"parse/json", "enrich/upper", "enrich/drop-debug", "redact/mask", "watch/watch"}, p.Names())
+
+	e := p.Process(`{"message":"password hunter2"}`)
+	assert.NoError(t, e.Err)
+	assert.Equal(t, map[string]interface{}{"message": "password ***", "upper": "PASSWORD HUNTER2"}, e.Fields)
+
+	assert.Nil(t, p.Process(`{"message":"noise","level":"DEBUG"}`))
+	assert.Equal(t, []string{"password ***"}, watched)
+}
+
+func TestPipeline_ProcessParsed(t *testing.T) {
+	p := New()
+	p.Register(Parse, "json", ParseJSON)
+	p.Register(Enrich, "parsed", Parsed(Fields(func(m map[string]interface{}) {
+		m["parsed"] = true
+	})))
+
+	// the fields parsed ahead aren't parsed again
+	e := p.ProcessParsed(`{"a":1}`, map[string]interface{}{"a": "ahead"}, nil)
+	assert.NoError(t, e.Err)
+	assert.Equal(t, map[string]interface{}{"a": "ahead", "parsed": true}, e.Fields)
+
+	fields, err := config.ParseLine(`{"a":`)
+	e = p.ProcessParsed(`{"a":`, fields, err)
+	assert.Error(t, e.Err)
+	assert.NotContains(t, e.Fields, "parsed")
+}
+
+func TestParseJSON(t *testing.T) {
+	p := New()
+	p.Register(Parse, "json", ParseJSON)
+	p.Register(Enrich, "parsed", Parsed(Fields(func(m map[string]interface{}) {
+		m["parsed"] = true
+	})))
+
+	e := p.Process(`{"a":`)
+	assert.Error(t, e.Err)
+	assert.Equal(t, `{"a":`, e.Fields[config.TextPayload])
+	assert.Equal(t, e.Err.Error(), e.Fields[config.ParseErr])
+	assert.NotContains(t, e.Fields, "parsed")
+
+	// plain text lines aren't parse errors, yet aren't parsed into keys either
+	e = p.Process(`plain text`)
+	assert.NoError(t, e.Err)
+	assert.Equal(t, map[string]interface{}{config.PlainText: true, config.TextPayload: "plain text"}, e.Fields)
+
+	e = p.Process(`{"a":1}`)
+	assert.Equal(t, map[string]interface{}{"a": float64(1), "parsed": true}, e.Fields)
+
+	// an earlier parser having parsed the line, it's left as is
+	p = New()
+	p.Register(Parse, "kv", func(e *Entry) bool {
+		e.Fields = map[string]interface{}{"kv": e.Line}
+		return true
+	})
+	p.Register(Parse, "json", ParseJSON)
+	assert.Equal(t, map[string]interface{}{"kv": "a=1"}, p.Process("a=1").Fields)
+
+	// no parser at all still yields fields
+	assert.NotNil(t, New().Process("x").Fields)
+}
+
+func TestPipeline_RegisterUnknownStage(t *testing.T) {
+	assert.Panics(t, func() {
+		New().Register(Stage(42), "nope", ParseJSON)
+	})
+	assert.Equal(t, "stage(42)", Stage(42).String())
+	assert.Equal(t, "redact", Redact.String())
+}
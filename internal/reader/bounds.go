@@ -0,0 +1,64 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package reader
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// bounds tracks the streaming progress of a bounded source, see Bounded.
+type bounds struct {
+	read    atomic.Int64
+	total   atomic.Int64
+	follows bool
+	loaded  chan struct{}
+	once    sync.Once
+}
+
+func (b *bounds) Progress() (read, total int64) {
+	return b.read.Load(), b.total.Load()
+}
+
+func (b *bounds) Loaded() <-chan struct{} {
+	return b.loaded
+}
+
+func (b *bounds) Follows() bool {
+	return b.follows
+}
+
+// advance accounts for n more units streamed, flagging the source as loaded
+// once its known total is reached.
+func (b *bounds) advance(n int64) {
+	read := b.read.Add(n)
+	if total := b.total.Load(); total > 0 && read >= total {
+		b.markLoaded()
+	}
+}
+
+// markLoaded flags the source as loaded, e.g. once a query returns.
+func (b *bounds) markLoaded() {
+	b.once.Do(func() {
+		close(b.loaded)
+	})
+}
@@ -0,0 +1,163 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import "sync"
+
+// broadcastBuffer bounds the live lines queued for a slow subscriber, beyond
+// which they're dropped rather than holding back the other subscribers.
+const broadcastBuffer = 1000
+
+// Broadcast fans a reader out to any number of subscribers, each replaying
+// the latest lines before following the new ones, so several views (e.g.
+// server mode sessions) can share a single source.
+type Broadcast struct {
+	source      Reader
+	backlog     int
+	lock        sync.Mutex
+	lines       []string
+	subscribers map[*broadcastStream]struct{}
+}
+
+// MakeBroadcast builds a broadcast of the source keeping the given number of
+// lines for new subscribers.
+func MakeBroadcast(source Reader, backlog int) *Broadcast {
+	return &Broadcast{
+		source:      source,
+		backlog:     backlog,
+		subscribers: make(map[*broadcastStream]struct{}),
+	}
+}
+
+// Start streams the source, fanning out its lines until it ends.
+func (b *Broadcast) Start() error {
+	b.source.ErrorNotifier(b.fail)
+	if err := b.source.StreamInto(); err != nil {
+		return err
+	}
+	go func() {
+		for line := range b.source.ChanReader() {
+			b.publish(line)
+		}
+	}()
+	return nil
+}
+
+// Close stops the source. Subscribers keep browsing what they received.
+func (b *Broadcast) Close() {
+	b.source.Close()
+}
+
+// Subscribe returns a reader replaying the latest lines, followed by every
+// new one. Closing it unsubscribes.
+func (b *Broadcast) Subscribe() Reader {
+	return &broadcastStream{
+		reader: reader{
+			strChan:    make(chan string, 1),
+			readerType: TypeBroadcast,
+		},
+		broadcast: b,
+		lines:     make(chan string, b.backlog+broadcastBuffer),
+		stopped:   make(chan struct{}),
+	}
+}
+
+func (b *Broadcast) publish(line string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.backlog > 0 {
+		if len(b.lines) == b.backlog {
+			b.lines = b.lines[1:]
+		}
+		b.lines = append(b.lines, line)
+	}
+	for sub := range b.subscribers {
+		select {
+		case sub.lines <- line:
+		default:
+			// slow subscriber, drop rather than block the others
+		}
+	}
+}
+
+func (b *Broadcast) fail(err error) {
+	b.lock.Lock()
+	var notify []func(err error)
+	for sub := range b.subscribers {
+		if sub.onError != nil {
+			notify = append(notify, sub.onError)
+		}
+	}
+	b.lock.Unlock()
+	for _, onError := range notify {
+		onError(err)
+	}
+}
+
+type broadcastStream struct {
+	reader
+	broadcast *Broadcast
+	lines     chan string
+	once      sync.Once
+	stopped   chan struct{}
+}
+
+func (s *broadcastStream) StreamInto() error {
+	b := s.broadcast
+	b.lock.Lock()
+	for _, line := range b.lines {
+		s.lines <- line
+	}
+	b.subscribers[s] = struct{}{}
+	b.lock.Unlock()
+	go func() {
+		defer close(s.strChan)
+		for {
+			select {
+			case line := <-s.lines:
+				select {
+				case s.strChan <- line:
+				case <-s.stopped:
+					return
+				}
+			case <-s.stopped:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *broadcastStream) Close() {
+	s.once.Do(func() {
+		close(s.stopped)
+		s.broadcast.lock.Lock()
+		delete(s.broadcast.subscribers, s)
+		s.broadcast.lock.Unlock()
+	})
+}
+
+// Details tells the details of the shared source.
+func (s *broadcastStream) Details() []Detail {
+	return Describe(s.broadcast.source)
+}
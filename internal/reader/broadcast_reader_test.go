@@ -0,0 +1,93 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStream struct {
+	reader
+}
+
+func (s *fakeStream) StreamInto() error { return nil }
+
+func (s *fakeStream) Close() { close(s.strChan) }
+
+func receive(t *testing.T, r Reader, n int) []string {
+	var lines []string
+	for i := 0; i < n; i++ {
+		select {
+		case line := <-r.ChanReader():
+			lines = append(lines, line)
+		case <-time.After(time.Second):
+			t.Fatalf("received %v, want %d lines", lines, n)
+		}
+	}
+	return lines
+}
+
+func TestBroadcast(t *testing.T) {
+	source := &fakeStream{reader: reader{strChan: make(chan string)}}
+	b := MakeBroadcast(source, 2)
+	assert.NoError(t, b.Start())
+
+	first := b.Subscribe()
+	assert.NoError(t, first.StreamInto())
+	for _, line := range []string{"a", "b", "c"} {
+		source.strChan <- line
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, receive(t, first, 3))
+
+	// late subscribers replay the backlog first
+	second := b.Subscribe()
+	assert.NoError(t, second.StreamInto())
+	source.strChan <- "d"
+	assert.Equal(t, []string{"b", "c", "d"}, receive(t, second, 3))
+	assert.Equal(t, []string{"d"}, receive(t, first, 1))
+
+	var failed error
+	second.ErrorNotifier(func(err error) { failed = err })
+	b.fail(errors.New("boom"))
+	assert.EqualError(t, failed, "boom")
+
+	first.Close()
+	source.strChan <- "e"
+	assert.Equal(t, []string{"e"}, receive(t, second, 1))
+	_, open := <-first.ChanReader()
+	assert.False(t, open)
+
+	second.Close()
+	b.Close()
+}
+
+func TestCommandReader(t *testing.T) {
+	r := MakeCommandReader(`echo '{"msg":"a"}'; echo b`, nil)
+	assert.NoError(t, r.StreamInto())
+	assert.Equal(t, []string{`{"msg":"a"}`, "b"}, receive(t, r, 2))
+	r.Close()
+}
@@ -0,0 +1,108 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"context"
+	"time"
+
+	"github.com/badaniya/loggo/internal/util"
+)
+
+// commandRetryInterval is the pause before running an exited command again.
+const commandRetryInterval = 5 * time.Second
+
+type commandStream struct {
+	reader
+	command string
+	ctx     context.Context
+	cancel  context.CancelFunc
+	started bool
+	stopped chan struct{}
+}
+
+// MakeCommandReader streams the output lines of a shell command, running it
+// again whenever it exits, e.g. once `kubectl logs -f` disconnects, so that
+// long-running instances keep following their sources.
+func MakeCommandReader(command string, strChan chan string) *commandStream {
+	if strChan == nil {
+		strChan = make(chan string, 1)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &commandStream{
+		reader: reader{
+			strChan:    strChan,
+			readerType: TypeCommand,
+		},
+		command: command,
+		ctx:     ctx,
+		cancel:  cancel,
+		stopped: make(chan struct{}),
+	}
+}
+
+func (s *commandStream) StreamInto() error {
+	s.started = true
+	go func() {
+		defer close(s.stopped)
+		for {
+			c, err := startCommand(s.ctx, "sh", "-c", s.command)
+			if err == nil {
+				err = c.consume(s.send)
+			}
+			if s.ctx.Err() != nil {
+				return
+			}
+			util.Log().WithField("code", err).Warnf("Command %q exited, running it again", s.command)
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-time.After(commandRetryInterval):
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *commandStream) send(line string) bool {
+	select {
+	case <-s.ctx.Done():
+		return false
+	case s.strChan <- line:
+		return true
+	}
+}
+
+func (s *commandStream) Close() {
+	s.closeOnce(func() {
+		s.cancel()
+		if s.started {
+			<-s.stopped
+		}
+		close(s.strChan)
+	})
+}
+
+func (s *commandStream) Details() []Detail {
+	return []Detail{{Label: "Source", Value: "Command"}, {Label: "Command", Value: s.command}}
+}
@@ -0,0 +1,292 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	datadogSearchPath = "/api/v2/logs/events/search"
+	datadogPageLimit  = 1000
+	// datadogIndexingLag re-queries this window on every poll, as Datadog
+	// may index entries a few seconds after their timestamp.
+	datadogIndexingLag = 30 * time.Second
+	datadogPollPeriod  = 5 * time.Second
+)
+
+type datadogStream struct {
+	reader
+	retrying
+	endpoint string
+	apiKey   string
+	appKey   string
+	query    string
+	from     string
+	http     *http.Client
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	seen     map[string]time.Time
+}
+
+type datadogSearchRequest struct {
+	Filter struct {
+		Query string `json:"query"`
+		From  string `json:"from"`
+		To    string `json:"to"`
+	} `json:"filter"`
+	Sort string `json:"sort"`
+	Page struct {
+		Limit  int    `json:"limit"`
+		Cursor string `json:"cursor,omitempty"`
+	} `json:"page"`
+}
+
+type datadogLog struct {
+	ID         string `json:"id"`
+	Attributes struct {
+		Timestamp  time.Time              `json:"timestamp"`
+		Status     string                 `json:"status"`
+		Service    string                 `json:"service"`
+		Host       string                 `json:"host"`
+		Message    string                 `json:"message"`
+		Tags       []string               `json:"tags"`
+		Attributes map[string]interface{} `json:"attributes"`
+	} `json:"attributes"`
+}
+
+type datadogSearchResponse struct {
+	Data []datadogLog `json:"data"`
+	Meta struct {
+		Page struct {
+			After string `json:"after"`
+		} `json:"page"`
+	} `json:"meta"`
+	Errors []interface{} `json:"errors"`
+}
+
+// MakeDatadogReader builds a reader querying the Datadog Logs search API for
+// the given site (e.g. datadoghq.com, datadoghq.eu) and query. The from
+// parameter accepts "tail" or an RFC3339 timestamp (see ParseFrom); once the
+// history is consumed, new matches are live tailed by polling.
+func MakeDatadogReader(site, apiKey, appKey, query, from string, strChan chan string) *datadogStream {
+	if strChan == nil {
+		strChan = make(chan string, 1)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &datadogStream{
+		reader: reader{
+			strChan:    strChan,
+			readerType: TypeDatadog,
+		},
+		retrying: retrying{policy: defaultRetryPolicy},
+		endpoint: "https://api." + site,
+		apiKey:   apiKey,
+		appKey:   appKey,
+		query:    query,
+		from:     from,
+		http:     http.DefaultClient,
+		ctx:      ctx,
+		cancel:   cancel,
+		seen:     make(map[string]time.Time),
+	}
+}
+
+func (s *datadogStream) StreamInto() error {
+	since := time.Now()
+	if s.from != "tail" {
+		t, err := time.Parse(time.RFC3339, s.from)
+		if err != nil {
+			return err
+		}
+		since = t
+	}
+	// Validate the keys and query upfront so errors surface at startup.
+	if _, err := s.search(since, "", 1); err != nil {
+		return err
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.stream(since); err != nil && s.ctx.Err() == nil && s.onError != nil {
+			s.onError(err)
+		}
+	}()
+	return nil
+}
+
+func (s *datadogStream) stream(since time.Time) error {
+	start := since
+	failures := s.backoff()
+	for s.ctx.Err() == nil {
+		latest := since
+		cursor := ""
+		for {
+			resp, err := s.search(since.Add(-datadogIndexingLag), cursor, datadogPageLimit)
+			if err != nil && transient(err) && failures.retry(s.ctx.Done(), err) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			failures.reset()
+			for _, l := range resp.Data {
+				if _, ok := s.seen[l.ID]; ok || l.Attributes.Timestamp.Before(start) {
+					continue
+				}
+				s.seen[l.ID] = l.Attributes.Timestamp
+				if l.Attributes.Timestamp.After(latest) {
+					latest = l.Attributes.Timestamp
+				}
+				b, _ := json.Marshal(massageDatadogLog(l))
+				select {
+				case <-s.ctx.Done():
+					return nil
+				case s.strChan <- string(b):
+				}
+			}
+			cursor = resp.Meta.Page.After
+			if len(cursor) == 0 {
+				break
+			}
+		}
+		since = latest
+		for id, ts := range s.seen {
+			if ts.Before(since.Add(-2 * datadogIndexingLag)) {
+				delete(s.seen, id)
+			}
+		}
+		s.sleep(datadogPollPeriod)
+	}
+	return nil
+}
+
+func (s *datadogStream) search(from time.Time, cursor string, limit int) (*datadogSearchResponse, error) {
+	for {
+		in := datadogSearchRequest{Sort: "timestamp"}
+		in.Filter.Query = s.query
+		in.Filter.From = from.UTC().Format(time.RFC3339Nano)
+		in.Filter.To = "now"
+		in.Page.Limit = limit
+		in.Page.Cursor = cursor
+		body, _ := json.Marshal(in)
+		req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, s.endpoint+datadogSearchPath, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("DD-API-KEY", s.apiKey)
+		req.Header.Set("DD-APPLICATION-KEY", s.appKey)
+		resp, err := s.http.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		b, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			reset, _ := strconv.Atoi(resp.Header.Get("X-RateLimit-Reset"))
+			s.sleep(time.Duration(max(reset, 1)) * time.Second)
+			if s.ctx.Err() != nil {
+				return nil, s.ctx.Err()
+			}
+			continue
+		}
+		out := &datadogSearchResponse{}
+		if err := json.Unmarshal(b, out); err != nil || resp.StatusCode/100 != 2 {
+			return nil, &statusError{"datadog search", resp.StatusCode, strings.TrimSpace(string(b))}
+		}
+		return out, nil
+	}
+}
+
+func (s *datadogStream) sleep(d time.Duration) {
+	select {
+	case <-s.ctx.Done():
+	case <-time.After(d):
+	}
+}
+
+// massageDatadogLog flattens the reserved Datadog attributes to the top level
+// and turns the "key:value" tags into a map, keeping custom attributes under
+// the attributes key.
+func massageDatadogLog(l datadogLog) map[string]interface{} {
+	a := l.Attributes
+	m := map[string]interface{}{
+		"id":        l.ID,
+		"timestamp": a.Timestamp.Local().Format(time.RFC3339),
+		"status":    a.Status,
+		"service":   a.Service,
+		"host":      a.Host,
+		"message":   a.Message,
+	}
+	if len(a.Attributes) > 0 {
+		m["attributes"] = a.Attributes
+	}
+	if len(a.Tags) > 0 {
+		tags := make(map[string]interface{})
+		for _, t := range a.Tags {
+			k, v, ok := strings.Cut(t, ":")
+			if !ok {
+				tags[t] = true
+				continue
+			}
+			switch prev := tags[k].(type) {
+			case nil:
+				tags[k] = v
+			case []interface{}:
+				tags[k] = append(prev, v)
+			default:
+				tags[k] = []interface{}{prev, v}
+			}
+		}
+		m["tags"] = tags
+	}
+	return m
+}
+
+func (s *datadogStream) Close() {
+	s.closeOnce(func() {
+		s.cancel()
+		s.wg.Wait()
+		close(s.strChan)
+	})
+}
+
+// Details tells the API endpoint and query, never the keys.
+func (s *datadogStream) Details() []Detail {
+	details := []Detail{{Label: "Source", Value: "Datadog"}, {Label: "Endpoint", Value: s.endpoint}}
+	details = append(details, optional("Query", s.query)...)
+	details = append(details, fromDetail(s.from))
+	return append(details, s.retrying.details()...)
+}
@@ -0,0 +1,127 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMassageDatadogLog(t *testing.T) {
+	var l datadogLog
+	err := json.Unmarshal([]byte(`{
+		"id": "AQAAAY",
+		"attributes": {
+			"timestamp": "2024-01-30T15:00:00Z",
+			"status": "error",
+			"service": "checkout",
+			"host": "i-123",
+			"message": "payment failed",
+			"tags": ["env:prod", "team:a", "team:b", "canary"],
+			"attributes": {"http": {"status_code": 500}}
+		}
+	}`), &l)
+	assert.NoError(t, err)
+
+	m := massageDatadogLog(l)
+	assert.Equal(t, "AQAAAY", m["id"])
+	assert.Equal(t, time.Date(2024, 1, 30, 15, 0, 0, 0, time.UTC).Local().Format(time.RFC3339), m["timestamp"])
+	assert.Equal(t, "error", m["status"])
+	assert.Equal(t, "checkout", m["service"])
+	assert.Equal(t, "payment failed", m["message"])
+	assert.Equal(t, map[string]interface{}{
+		"env":    "prod",
+		"team":   []interface{}{"a", "b"},
+		"canary": true,
+	}, m["tags"])
+	assert.Equal(t, map[string]interface{}{
+		"http": map[string]interface{}{"status_code": float64(500)},
+	}, m["attributes"])
+}
+
+func TestDatadogStream_StreamInto(t *testing.T) {
+	from := time.Now().Add(-time.Hour).UTC()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, datadogSearchPath, r.URL.Path)
+		assert.Equal(t, "api-key", r.Header.Get("DD-API-KEY"))
+		assert.Equal(t, "app-key", r.Header.Get("DD-APPLICATION-KEY"))
+		var in datadogSearchRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		assert.Equal(t, "service:checkout", in.Filter.Query)
+		var out datadogSearchResponse
+		if len(in.Page.Cursor) == 0 {
+			out.Data = []datadogLog{{ID: "1"}}
+			out.Data[0].Attributes.Timestamp = from.Add(time.Minute)
+			out.Meta.Page.After = "next"
+		} else {
+			out.Data = []datadogLog{{ID: "2"}}
+			out.Data[0].Attributes.Timestamp = from.Add(2 * time.Minute)
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	}))
+	defer server.Close()
+
+	s := MakeDatadogReader("", "api-key", "app-key", "service:checkout", from.Format(time.RFC3339), nil)
+	s.endpoint = server.URL
+	s.ErrorNotifier(func(err error) {
+		assert.NoError(t, err)
+	})
+	assert.NoError(t, s.StreamInto())
+	assert.Contains(t, <-s.ChanReader(), `"id":"1"`)
+	assert.Contains(t, <-s.ChanReader(), `"id":"2"`)
+	s.Close()
+}
+
+func TestDatadogStream_Retry(t *testing.T) {
+	from := time.Now().Add(-time.Hour).UTC()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 2 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		var out datadogSearchResponse
+		out.Data = []datadogLog{{ID: "1"}}
+		out.Data[0].Attributes.Timestamp = from.Add(time.Minute)
+		_ = json.NewEncoder(w).Encode(out)
+	}))
+	defer server.Close()
+
+	s := MakeDatadogReader("", "api-key", "app-key", "service:checkout", from.Format(time.RFC3339), nil)
+	s.endpoint = server.URL
+	s.policy = RetryPolicy{Initial: time.Millisecond, Max: time.Millisecond}
+	s.ErrorNotifier(func(err error) {
+		assert.NoError(t, err)
+	})
+	assert.NoError(t, s.StreamInto())
+	assert.Contains(t, <-s.ChanReader(), `"id":"1"`)
+	assert.Equal(t, int64(1), s.Retries())
+	assert.False(t, s.Reconnecting())
+	s.Close()
+}
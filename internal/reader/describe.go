@@ -0,0 +1,105 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/badaniya/loggo/internal/config"
+)
+
+// Detail is a labelled fact about the source a reader streams, e.g. the
+// inode of a file or the project of a GCP query.
+type Detail struct {
+	Label string
+	Value string
+}
+
+// Describer is implemented by readers telling what exactly they stream, so
+// that streams open side by side can be told apart.
+type Describer interface {
+	// Details returns the facts about the source as of now, the kind of
+	// source first.
+	Details() []Detail
+}
+
+// Describe returns the details of the source the reader streams, through
+// the readers wrapping it, e.g. a format parser, none if it can't tell.
+func Describe(r Reader) []Detail {
+	if d, ok := r.(Describer); ok {
+		return d.Details()
+	}
+	return nil
+}
+
+// details returns the retry policy of a network source and how it fared so
+// far.
+func (r *retrying) details() []Detail {
+	p := r.policy.overriddenBy(RetryOverride)
+	attempts := "indefinitely"
+	if p.Attempts > 0 {
+		attempts = fmt.Sprintf("up to %d times", p.Attempts)
+	}
+	retries := strconv.FormatInt(r.Retries(), 10)
+	if r.Reconnecting() {
+		retries += ", reconnecting"
+	}
+	return []Detail{
+		{Label: "Retry policy", Value: fmt.Sprintf("%s, backing off %v to %v", attempts, p.Initial, p.Max)},
+		{Label: "Retries", Value: retries},
+	}
+}
+
+// fileDetails describes the file at path: its inode, if the platform has
+// any, and size.
+func fileDetails(path string) []Detail {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return []Detail{{Label: "Status", Value: err.Error()}}
+	}
+	var details []Detail
+	if ino, ok := inode(fi); ok {
+		details = append(details, Detail{Label: "Inode", Value: strconv.FormatUint(ino, 10)})
+	}
+	return append(details, Detail{Label: "Size", Value: config.HumanizeBytes(float64(fi.Size()))})
+}
+
+// optional returns the detail unless its value is empty, e.g. a filter the
+// source was started without.
+func optional(label, value string) []Detail {
+	if len(strings.TrimSpace(value)) == 0 {
+		return nil
+	}
+	return []Detail{{Label: label, Value: value}}
+}
+
+// fromDetail describes where a source started from, see ParseFrom.
+func fromDetail(from string) Detail {
+	if len(from) == 0 || from == "tail" {
+		return Detail{Label: "From", Value: "tail, new entries only"}
+	}
+	return Detail{Label: "From", Value: from}
+}
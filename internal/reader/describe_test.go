@@ -0,0 +1,64 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/badaniya/loggo/internal/format"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribe(t *testing.T) {
+	detail := func(details []Detail, label string) string {
+		for _, d := range details {
+			if d.Label == label {
+				return d.Value
+			}
+		}
+		return ""
+	}
+	file := filepath.Join(t.TempDir(), "app.log")
+	assert.NoError(t, os.WriteFile(file, []byte("line 1\nline 2\n"), 0600))
+	details := Describe(MakeReader(file, nil))
+	assert.Equal(t, "File", detail(details, "Source"))
+	assert.Equal(t, file, detail(details, "Path"))
+	assert.Equal(t, "14 B", detail(details, "Size"))
+
+	parser, err := format.NewParser(format.PostgresLog)
+	assert.NoError(t, err)
+	details = Describe(WithThrottle(WithFormat(MakeReader(file, nil), parser), 10, 0))
+	assert.Equal(t, file, detail(details, "Path"))
+	assert.Equal(t, format.PostgresLog, detail(details, "Format"))
+	assert.Equal(t, "10 lines per second", detail(details, "Replay"))
+
+	details = Describe(MakeDatadogReader("datadoghq.eu", "api-key", "app-key", "service:api", "tail", nil))
+	assert.Equal(t, "https://api.datadoghq.eu", detail(details, "Endpoint"))
+	assert.Equal(t, "service:api", detail(details, "Query"))
+	assert.Equal(t, "0", detail(details, "Retries"))
+	for _, d := range details {
+		assert.NotContains(t, d.Value, "key")
+	}
+}
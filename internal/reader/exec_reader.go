@@ -0,0 +1,97 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// command is a started external CLI (e.g. heroku, log) whose standard output
+// lines are fed to the logger.
+type command struct {
+	cmd    *exec.Cmd
+	stdout io.ReadCloser
+	stderr *bytes.Buffer
+}
+
+// startCommand starts the given command, which is killed when ctx is done.
+func startCommand(ctx context.Context, name string, args ...string) (*command, error) {
+	c := &command{
+		cmd:    exec.CommandContext(ctx, name, args...),
+		stderr: &bytes.Buffer{},
+	}
+	c.cmd.Stderr = c.stderr
+	var err error
+	if c.stdout, err = c.cmd.StdoutPipe(); err != nil {
+		return nil, err
+	}
+	if err := c.cmd.Start(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// consume calls onLine for every output line until the command exits or
+// onLine returns false. The returned error carries the command's stderr.
+func (c *command) consume(onLine func(line string) bool) error {
+	scanner := bufio.NewScanner(c.stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if !onLine(scanner.Text()) {
+			break
+		}
+	}
+	return c.wait()
+}
+
+// decode calls onValue for every JSON value of the output, which may span
+// lines (e.g. kubectl -o json), until the command exits or onValue returns
+// false.
+func (c *command) decode(onValue func(value json.RawMessage) bool) error {
+	decoder := json.NewDecoder(c.stdout)
+	for {
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil || !onValue(value) {
+			break
+		}
+	}
+	return c.wait()
+}
+
+// wait waits for the command to exit, the error carrying its stderr.
+func (c *command) wait() error {
+	err := c.cmd.Wait()
+	if err != nil {
+		if stderr := strings.TrimSpace(c.stderr.String()); len(stderr) > 0 {
+			return fmt.Errorf("%w: %s", err, stderr)
+		}
+	}
+	return err
+}
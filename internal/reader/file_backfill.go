@@ -0,0 +1,110 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// backfillChunkSize is how much of the file is scanned backwards at once
+// looking for line starts.
+var backfillChunkSize = 64 << 10
+
+// MakeTailReader is MakeReader starting a file at its last n lines, the older
+// ones being back-filled on demand, see Backfiller.
+func MakeTailReader(fileName string, n int, strChan chan string) Reader {
+	r := MakeReader(fileName, strChan)
+	if s, ok := r.(*fileStream); ok {
+		s.tailLines = n
+	}
+	return r
+}
+
+// tailStart returns the offset of the last tailLines lines of the file, up to
+// extent.
+func (s *fileStream) tailStart(target string, extent int64) int64 {
+	f, err := os.Open(target)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	start, err := linesBefore(f, extent, s.tailLines)
+	if err != nil {
+		return 0
+	}
+	return start
+}
+
+// Backfill reads the lines preceding the first one tailed, as long as the file
+// wasn't retargeted since.
+func (s *fileStream) Backfill(n int) ([]string, error) {
+	s.headLock.Lock()
+	defer s.headLock.Unlock()
+	s.lock.Lock()
+	target := s.target
+	s.lock.Unlock()
+	if s.head == 0 || target != s.headTarget {
+		return nil, nil
+	}
+	f, err := os.Open(target)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	start, err := linesBefore(f, s.head, n)
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, s.head-start)
+	if _, err := f.ReadAt(b, start); err != nil && err != io.EOF {
+		return nil, err
+	}
+	s.head = start
+	return strings.Split(strings.TrimSuffix(string(b), "\n"), "\n"), nil
+}
+
+// linesBefore returns the offset of the n-th line before end, a line start,
+// i.e. past the n+1-th line break before it, or 0 if there are fewer lines.
+func linesBefore(f io.ReaderAt, end int64, n int) (int64, error) {
+	buf := make([]byte, backfillChunkSize)
+	breaks := 0
+	for pos := end; pos > 0; {
+		size := min(int64(len(buf)), pos)
+		pos -= size
+		if _, err := f.ReadAt(buf[:size], pos); err != nil && err != io.EOF {
+			return 0, err
+		}
+		for i := size - 1; i >= 0; i-- {
+			if buf[i] != '\n' {
+				continue
+			}
+			if breaks == n {
+				return pos + i + 1, nil
+			}
+			breaks++
+		}
+	}
+	return 0, nil
+}
@@ -0,0 +1,98 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinesBefore(t *testing.T) {
+	defer func(size int) { backfillChunkSize = size }(backfillChunkSize)
+	backfillChunkSize = 4
+
+	content := strings.NewReader("one\ntwo\nthree\nfour\n")
+	for _, test := range []struct {
+		end  int64
+		n    int
+		want int64
+	}{
+		{19, 1, 14},
+		{19, 2, 8},
+		{19, 4, 0},
+		{19, 10, 0},
+		{8, 1, 4},
+		{4, 1, 0},
+		{0, 1, 0},
+	} {
+		start, err := linesBefore(content, test.end, test.n)
+		assert.NoError(t, err)
+		assert.Equal(t, test.want, start, "%d lines before %d", test.n, test.end)
+	}
+}
+
+func TestFileStream_TailBackfill(t *testing.T) {
+	var content strings.Builder
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(&content, "line %d\n", i)
+	}
+	fileName := path.Join(t.TempDir(), "app.log")
+	assert.NoError(t, os.WriteFile(fileName, []byte(content.String()), 0644))
+
+	r := MakeTailReader(fileName, 3, nil)
+	assert.NoError(t, r.StreamInto())
+	defer r.Close()
+	for i := 7; i < 10; i++ {
+		select {
+		case line := <-r.ChanReader():
+			assert.Equal(t, fmt.Sprintf("line %d", i), line)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	backfiller := r.(Backfiller)
+	lines, err := backfiller.Backfill(4)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"line 3", "line 4", "line 5", "line 6"}, lines)
+	lines, err = backfiller.Backfill(10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"line 0", "line 1", "line 2"}, lines)
+	lines, err = backfiller.Backfill(10)
+	assert.NoError(t, err)
+	assert.Empty(t, lines)
+
+	// not tailed, there's nothing to back-fill
+	r = MakeReader(fileName, nil)
+	assert.NoError(t, r.StreamInto())
+	defer r.Close()
+	lines, err = r.(Backfiller).Backfill(10)
+	assert.NoError(t, err)
+	assert.Empty(t, lines)
+}
@@ -0,0 +1,195 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// parallelLoadMin is the file size from which its existing content is read by
+// several workers at once, before tailing what's appended to it.
+var parallelLoadMin int64 = 16 << 20
+
+// loadChunkSize is the size of the file chunks read by each worker, cut at
+// line boundaries.
+var loadChunkSize int64 = 4 << 20
+
+// loadWorkers is the number of chunks read at once, ahead of those streamed.
+var loadWorkers = runtime.NumCPU()
+
+// loadedExtent returns the offset past the last complete line within the
+// first size bytes of the file, a trailing partial line being left to the
+// tail.
+func loadedExtent(f *os.File, size int64) (int64, error) {
+	buf := make([]byte, 64<<10)
+	for end := size; end > 0; {
+		start := max(end-int64(len(buf)), 0)
+		n, err := f.ReadAt(buf[:end-start], start)
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			return start + int64(i) + 1, nil
+		}
+		end = start
+	}
+	return 0, nil
+}
+
+// readChunk returns the lines starting within [start, end) of the file, up
+// to extent. The line running into start belongs to the previous chunk.
+func readChunk(f *os.File, start, end, extent int64) ([]string, error) {
+	offset := max(start-1, 0)
+	r := bufio.NewReader(io.NewSectionReader(f, offset, extent-offset))
+	pos := offset
+	if start > 0 {
+		// skip up to the first line break, just the one ending the previous
+		// chunk if it ends on a line boundary
+		skipped, err := r.ReadString('\n')
+		pos += int64(len(skipped))
+		if err == io.EOF {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	var lines []string
+	for pos < end {
+		line, err := r.ReadString('\n')
+		pos += int64(len(line))
+		if strings.HasSuffix(line, "\n") {
+			lines = append(lines, strings.TrimRight(line, "\n"))
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return lines, nil
+}
+
+// parsedLine is a line parsed by a load worker, ahead of the consumer.
+type parsedLine struct {
+	line   string
+	fields map[string]interface{}
+	err    error
+}
+
+// ParseAhead has the load workers parse the lines they read, see Preparser.
+func (s *fileStream) ParseAhead(parse func(line string) (map[string]interface{}, error)) {
+	s.parse = parse
+}
+
+// Parsed returns what the line just streamed was parsed into by the load
+// workers, dropping the lines parsed ahead yet skipped by the consumer.
+func (s *fileStream) Parsed(line string) (map[string]interface{}, error) {
+	s.aheadLock.Lock()
+	defer s.aheadLock.Unlock()
+	for len(s.ahead) > 0 {
+		p := s.ahead[0]
+		s.ahead[0] = parsedLine{}
+		s.ahead = s.ahead[1:]
+		if p.line == line {
+			return p.fields, p.err
+		}
+	}
+	return nil, nil
+}
+
+// parseChunk parses the lines of a chunk in the load worker reading it,
+// leaving the empty ones, which are skipped by the consumer.
+func parseChunk(lines []string, parse func(line string) (map[string]interface{}, error)) []parsedLine {
+	parsed := make([]parsedLine, len(lines))
+	for i, line := range lines {
+		if len(line) > 0 {
+			fields, err := parse(line)
+			parsed[i] = parsedLine{line: line, fields: fields, err: err}
+		}
+	}
+	return parsed
+}
+
+// loadParallel streams the lines within the first extent bytes of the file,
+// read in chunks by several workers yet streamed in their original order.
+// The workers also parse the lines if told to, see ParseAhead.
+func (s *fileStream) loadParallel(fileName string, extent int64) error {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	type chunk struct {
+		lines  []string
+		parsed []parsedLine
+		err    error
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	pending := make(chan chan chunk, loadWorkers)
+	go func() {
+		defer close(pending)
+		for start := int64(0); start < extent; start += loadChunkSize {
+			result := make(chan chunk, 1)
+			select {
+			case pending <- result:
+			case <-stop:
+				return
+			case <-s.done:
+				return
+			}
+			go func(start int64) {
+				lines, err := readChunk(f, start, min(start+loadChunkSize, extent), extent)
+				var parsed []parsedLine
+				if err == nil && s.parse != nil {
+					parsed = parseChunk(lines, s.parse)
+				}
+				result <- chunk{lines: lines, parsed: parsed, err: err}
+			}(start)
+		}
+	}()
+	for result := range pending {
+		c := <-result
+		if c.err != nil {
+			return c.err
+		}
+		for i, line := range c.lines {
+			if c.parsed != nil && c.parsed[i].fields != nil {
+				s.aheadLock.Lock()
+				s.ahead = append(s.ahead, c.parsed[i])
+				s.aheadLock.Unlock()
+			}
+			select {
+			case <-s.done:
+				return nil
+			case s.strChan <- line:
+				s.advance(int64(len(line)) + 1)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package reader
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadChunk(t *testing.T) {
+	fileName := path.Join(t.TempDir(), "app.log")
+	assert.NoError(t, os.WriteFile(fileName, []byte("one\ntwo\nthree\nfour\npartial"), 0644))
+	f, err := os.Open(fileName)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer f.Close()
+
+	extent, err := loadedExtent(f, 27)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 19, extent)
+
+	var lines []string
+	for start := int64(0); start < extent; start += 5 {
+		chunk, err := readChunk(f, start, min(start+5, extent), extent)
+		assert.NoError(t, err)
+		lines = append(lines, chunk...)
+	}
+	assert.Equal(t, []string{"one", "two", "three", "four"}, lines)
+}
+
+func TestFileStream_ParallelLoad(t *testing.T) {
+	defer func(min, size int64, workers int) {
+		parallelLoadMin, loadChunkSize, loadWorkers = min, size, workers
+	}(parallelLoadMin, loadChunkSize, loadWorkers)
+	parallelLoadMin, loadChunkSize, loadWorkers = 1, 16, 3
+
+	var want []string
+	var content strings.Builder
+	for i := 0; i < 100; i++ {
+		want = append(want, fmt.Sprintf(`{"n":%d}`, i))
+		content.WriteString(want[i] + "\n")
+	}
+	content.WriteString(`{"n":`)
+	fileName := path.Join(t.TempDir(), "app.log")
+	assert.NoError(t, os.WriteFile(fileName, []byte(content.String()), 0644))
+
+	r := MakeReader(fileName, nil)
+	assert.NoError(t, r.StreamInto())
+	defer r.Close()
+	next := func() string {
+		select {
+		case line := <-r.ChanReader():
+			return line
+		case <-time.After(5 * time.Second):
+			return "timed out"
+		}
+	}
+	var got []string
+	for range want {
+		got = append(got, next())
+	}
+	assert.Equal(t, want, got)
+	select {
+	case <-r.(Bounded).Loaded():
+	case <-time.After(5 * time.Second):
+		t.Fatal("not loaded once the file was read")
+	}
+
+	// the partial line is tailed once complete
+	f, err := os.OpenFile(fileName, os.O_APPEND|os.O_WRONLY, 0644)
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = f.WriteString("100}\n")
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+	assert.Equal(t, `{"n":100}`, next())
+}
+
+func TestFileStream_ParseAhead(t *testing.T) {
+	defer func(min, size int64, workers int) {
+		parallelLoadMin, loadChunkSize, loadWorkers = min, size, workers
+	}(parallelLoadMin, loadChunkSize, loadWorkers)
+	parallelLoadMin, loadChunkSize, loadWorkers = 1, 16, 3
+
+	var content strings.Builder
+	for i := 0; i < 50; i++ {
+		content.WriteString(fmt.Sprintf("{\"n\":%d}\n\n", i))
+	}
+	fileName := path.Join(t.TempDir(), "app.log")
+	assert.NoError(t, os.WriteFile(fileName, []byte(content.String()), 0644))
+
+	r := MakeReader(fileName, nil)
+	r.(Preparser).ParseAhead(func(line string) (map[string]interface{}, error) {
+		return map[string]interface{}{"line": line}, nil
+	})
+	assert.NoError(t, r.StreamInto())
+	defer r.Close()
+	for i := 0; i < 50; {
+		var line string
+		select {
+		case line = <-r.ChanReader():
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out")
+		}
+		if len(line) == 0 {
+			continue
+		}
+		// every other line is claimed, the skipped ones being dropped
+		fields, err := r.(Preparser).Parsed(line)
+		assert.NoError(t, err)
+		if i%2 == 0 {
+			assert.Equal(t, map[string]interface{}{"line": fmt.Sprintf(`{"n":%d}`, i)}, fields)
+		}
+		i++
+	}
+	fields, err := r.(Preparser).Parsed(`{"n":50}`)
+	assert.NoError(t, err)
+	assert.Nil(t, fields)
+}
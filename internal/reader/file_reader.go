@@ -24,32 +24,225 @@ package reader
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
 
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/util"
 	"github.com/nxadm/tail"
 )
 
+// symlinkPollInterval is how often a symlinked file is checked for being
+// retargeted, e.g. current -> app-2024-06-01.log.
+const symlinkPollInterval = time.Second
+
 type fileStream struct {
 	reader
+	bounds
 	fileName string
+	lock     sync.Mutex
 	tail     *tail.Tail
+	target   string
+	done     chan struct{}
+	wg       sync.WaitGroup
+	// tailLines starts the file at its last lines, the older ones before
+	// head, the offset of the first one tailed, being back-filled
+	tailLines  int
+	headLock   sync.Mutex
+	head       int64
+	headTarget string
+	// parse parses the loaded lines in the load workers, ahead being those
+	// streamed yet to be claimed through Parsed
+	parse     func(line string) (map[string]interface{}, error)
+	aheadLock sync.Mutex
+	ahead     []parsedLine
 }
 
 func (s *fileStream) StreamInto() error {
-	var err error
-	s.tail, err = tail.TailFile(s.fileName, tail.Config{Follow: true, Poll: true})
+	target := s.resolve()
+	extent := s.extent(target)
+	if s.tailLines > 0 {
+		s.head, s.headTarget = s.tailStart(target, extent), target
+	}
+	s.total.Store(extent - s.head)
+	if extent == s.head {
+		s.markLoaded()
+	}
+	if s.tailLines > 0 || extent < parallelLoadMin {
+		return s.startFollowing(target, s.head)
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		err := s.loadParallel(target, extent)
+		if err == nil && !s.stopped() {
+			err = s.startFollowing(target, extent)
+		}
+		if err != nil {
+			util.Log().WithField("code", err).Error("Unable to load the file")
+			if s.onError != nil {
+				s.onError(err)
+			}
+		}
+	}()
+	return nil
+}
+
+// extent returns the size of the complete lines the file holds when opened,
+// none if it can't be read.
+func (s *fileStream) extent(target string) int64 {
+	f, err := os.Open(target)
 	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return 0
+	}
+	extent, err := loadedExtent(f, fi.Size())
+	if err != nil {
+		return 0
+	}
+	return extent
+}
+
+// startFollowing tails the file from the given offset on, switching to its new
+// target whenever a symlinked file is retargeted.
+func (s *fileStream) startFollowing(target string, offset int64) error {
+	if err := s.follow(target, offset); err != nil {
 		return err
 	}
+	if fi, err := os.Lstat(s.fileName); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+		s.wg.Add(1)
+		go s.watchSymlink()
+	}
+	return nil
+}
 
+// resolve returns the file the (possibly symlinked) file name points to.
+func (s *fileStream) resolve() string {
+	if target, err := filepath.EvalSymlinks(s.fileName); err == nil {
+		return target
+	}
+	return s.fileName
+}
+
+func (s *fileStream) follow(target string, offset int64) error {
+	t, err := tail.TailFile(target, tail.Config{
+		Follow:        true,
+		Poll:          true,
+		CompleteLines: true,
+		Location:      &tail.SeekInfo{Offset: offset, Whence: io.SeekStart},
+	})
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	if s.stopped() {
+		// closed meanwhile
+		s.lock.Unlock()
+		t.Kill(fmt.Errorf("stopped by Close method"))
+		return nil
+	}
+	s.tail, s.target = t, target
+	s.lock.Unlock()
+
+	s.wg.Add(1)
 	go func() {
-		for line := range s.tail.Lines {
-			s.strChan <- line.Text
+		defer s.wg.Done()
+		for line := range t.Lines {
+			select {
+			case <-s.done:
+				return
+			case s.strChan <- line.Text:
+				s.advance(int64(len(line.Text)) + 1)
+			}
 		}
 	}()
 	return nil
 }
 
+// watchSymlink switches to the new target of the symlinked file once it's
+// retargeted, after reading what's left of the previous one.
+func (s *fileStream) watchSymlink() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(symlinkPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+		target := s.resolve()
+		s.lock.Lock()
+		prev, current := s.tail, s.target
+		s.lock.Unlock()
+		if target == current {
+			continue
+		}
+		if _, err := os.Stat(target); err != nil {
+			// dangling while being retargeted
+			continue
+		}
+		util.Log().WithField("code", target).Info("Symlinked log retargeted, switching to the new target")
+		_ = prev.StopAtEOF()
+		select {
+		case <-s.done:
+			return
+		default:
+		}
+		if err := s.follow(target, 0); err != nil {
+			util.Log().WithField("code", err).Error("Unable to follow the retargeted log")
+			if s.onError != nil {
+				s.onError(err)
+			}
+			return
+		}
+	}
+}
+
+func (s *fileStream) stopped() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops tailing the file, waiting for the lines being fed to be done
+// before closing the channel.
 func (s *fileStream) Close() {
-	s.tail.Kill(fmt.Errorf("stopped by Close method"))
-	close(s.strChan)
+	s.closeOnce(func() {
+		close(s.done)
+		s.lock.Lock()
+		if s.tail != nil {
+			s.tail.Kill(fmt.Errorf("stopped by Close method"))
+		}
+		s.lock.Unlock()
+		s.wg.Wait()
+		close(s.strChan)
+	})
+}
+
+// Details tells the path of the file, the one it links to if symlinked, its
+// inode and size, and how much of it was loaded.
+func (s *fileStream) Details() []Detail {
+	details := []Detail{{Label: "Source", Value: "File"}, {Label: "Path", Value: s.fileName}}
+	target := s.resolve()
+	if target != s.fileName {
+		details = append(details, Detail{Label: "Links to", Value: target})
+	}
+	details = append(details, fileDetails(target)...)
+	if s.tailLines > 0 {
+		details = append(details, Detail{Label: "From", Value: fmt.Sprintf("last %d lines", s.tailLines)})
+	}
+	read, total := s.Progress()
+	return append(details, Detail{Label: "Loaded", Value: fmt.Sprintf("%s of %s, following",
+		config.HumanizeBytes(float64(read)), config.HumanizeBytes(float64(total)))})
 }
@@ -80,3 +80,69 @@ func TestFileStream_StreamInto(t *testing.T) {
 		assert.True(t, diff >= int64(1))
 	})
 }
+
+func TestFileStream_Bounded(t *testing.T) {
+	filePath := path.Join(t.TempDir(), "app.log")
+	assert.NoError(t, os.WriteFile(filePath, []byte("line 1\nline 2\n"), 0644))
+
+	r := MakeReader(filePath, nil)
+	bounded, ok := r.(Bounded)
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.NoError(t, r.StreamInto())
+	defer r.Close()
+	_, total := bounded.Progress()
+	assert.EqualValues(t, 14, total)
+	assert.True(t, bounded.Follows())
+	for i := 0; i < 2; i++ {
+		select {
+		case <-r.ChanReader():
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out reading the file")
+		}
+	}
+	select {
+	case <-bounded.Loaded():
+	case <-time.After(5 * time.Second):
+		t.Fatal("not loaded once the file was read")
+	}
+	read, _ := bounded.Progress()
+	assert.EqualValues(t, 14, read)
+}
+
+func TestFileStream_SymlinkRetargeted(t *testing.T) {
+	dir := t.TempDir()
+	first := path.Join(dir, "app-1.log")
+	second := path.Join(dir, "app-2.log")
+	current := path.Join(dir, "current")
+	assert.NoError(t, os.WriteFile(first, []byte("first 1\n"), 0644))
+	assert.NoError(t, os.Symlink(first, current))
+
+	streamReceiver := make(chan string, 1)
+	reader := MakeReader(current, streamReceiver)
+	assert.NoError(t, reader.StreamInto())
+	defer reader.Close()
+	next := func() string {
+		select {
+		case line := <-streamReceiver:
+			return line
+		case <-time.After(5 * time.Second):
+			return "timed out"
+		}
+	}
+	assert.Equal(t, "first 1", next())
+
+	// retarget atomically, as log rotators do, leaving a last line behind
+	assert.NoError(t, os.WriteFile(second, []byte("second 1\n"), 0644))
+	f, err := os.OpenFile(first, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.NoError(t, err)
+	_, err = f.WriteString("first 2\n")
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+	assert.NoError(t, os.Symlink(second, current+".tmp"))
+	assert.NoError(t, os.Rename(current+".tmp", current))
+
+	assert.Equal(t, "first 2", next())
+	assert.Equal(t, "second 1", next())
+}
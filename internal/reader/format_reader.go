@@ -0,0 +1,114 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/badaniya/loggo/internal/format"
+)
+
+// formatFlushDelay is how long a pending multi-line entry waits for more
+// lines before being emitted.
+const formatFlushDelay = 500 * time.Millisecond
+
+type formatStream struct {
+	Reader
+	strChan chan string
+	parser  format.Parser
+	once    sync.Once
+	stopped chan struct{}
+}
+
+// WithFormat wraps a reader so its raw lines are parsed into JSON entries by
+// the given format parser (e.g. slow query logs).
+func WithFormat(r Reader, parser format.Parser) Reader {
+	return &formatStream{
+		Reader:  r,
+		strChan: make(chan string, 1),
+		parser:  parser,
+		stopped: make(chan struct{}),
+	}
+}
+
+func (s *formatStream) StreamInto() error {
+	if err := s.Reader.StreamInto(); err != nil {
+		return err
+	}
+	go func() {
+		defer close(s.strChan)
+		in := s.Reader.ChanReader()
+		flush := time.NewTimer(formatFlushDelay)
+		defer flush.Stop()
+		for {
+			select {
+			case line, ok := <-in:
+				if !ok {
+					s.emit(s.parser.Flush())
+					return
+				}
+				if !s.emit(s.parser.Feed(line)) {
+					return
+				}
+				flush.Reset(formatFlushDelay)
+			case <-flush.C:
+				if !s.emit(s.parser.Flush()) {
+					return
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// emit sends the entries unless the stream is closed first, which it tells.
+func (s *formatStream) emit(entries []map[string]interface{}) bool {
+	for _, e := range entries {
+		b, _ := json.Marshal(e)
+		select {
+		case s.strChan <- string(b):
+		case <-s.stopped:
+			return false
+		}
+	}
+	return true
+}
+
+func (s *formatStream) Close() {
+	s.once.Do(func() {
+		close(s.stopped)
+	})
+	s.Reader.Close()
+}
+
+func (s *formatStream) ChanReader() <-chan string {
+	return s.strChan
+}
+
+// Details tells the details of the wrapped reader and the format its lines
+// are parsed as.
+func (s *formatStream) Details() []Detail {
+	return append(Describe(s.Reader), Detail{Label: "Format", Value: format.NameOf(s.parser)})
+}
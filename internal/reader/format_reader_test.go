@@ -0,0 +1,70 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"testing"
+
+	"github.com/badaniya/loggo/internal/format"
+	"github.com/stretchr/testify/assert"
+)
+
+type linesStream struct {
+	reader
+	lines []string
+}
+
+func (s *linesStream) StreamInto() error {
+	go func() {
+		for _, l := range s.lines {
+			s.strChan <- l
+		}
+	}()
+	return nil
+}
+
+func (s *linesStream) Close() {
+	close(s.strChan)
+}
+
+func TestFormatStream_StreamInto(t *testing.T) {
+	parser, err := format.NewParser(format.MySQLSlow)
+	assert.NoError(t, err)
+	inner := &linesStream{
+		reader: reader{strChan: make(chan string, 1)},
+		lines: []string{
+			"# User@Host: app[app] @ localhost [127.0.0.1]  Id:    12",
+			"# Query_time: 1.000000  Lock_time: 0.000000 Rows_sent: 1  Rows_examined: 1",
+			"SELECT 1;",
+		},
+	}
+	r := WithFormat(inner, parser)
+	assert.NoError(t, r.StreamInto())
+	// the pending entry is flushed after formatFlushDelay without new lines
+	line := <-r.ChanReader()
+	assert.Contains(t, line, `"normalized_query":"SELECT ?"`)
+	assert.Contains(t, line, `"user":"app"`)
+	r.Close()
+	_, ok := <-r.ChanReader()
+	assert.False(t, ok)
+}
@@ -25,12 +25,15 @@ package reader
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/badaniya/loggo/internal/util"
@@ -45,13 +48,46 @@ import (
 
 type gcpStream struct {
 	reader
-	projectID string
-	filter    string
-	freshness string
-	isTail    bool
-	stop      bool
+	retrying
+	projectID    string
+	filter       string
+	serverFilter string
+	sentFilter   string
+	freshness    string
+	isTail       bool
+	lastTime     string
+	// quotaHits counts the requests exceeding the read quota, and pageSize is
+	// the latest one polled with, see Details
+	quotaHits atomic.Int64
+	pageSize  atomic.Int32
+	client    *logging.Client
+	cancel    context.CancelFunc
+	done      chan struct{}
 }
 
+const (
+	gcpMinPageSize = 100
+	gcpMaxPageSize = 1000
+	// gcpPollInterval is the pause after a poll that didn't fill a page, so that
+	// a trickle of entries doesn't burn the read quota.
+	gcpPollInterval = time.Second
+)
+
+// gcpRetryPolicy backs off requests exceeding the read quota for as long as
+// they do, and reconnects dropped streams.
+var gcpRetryPolicy = RetryPolicy{
+	Initial: 2 * time.Second,
+	Max:     2 * time.Minute,
+}
+
+// gcpUnavailableError matches the errors of streams dropped by the server or
+// the network, which are reconnected, as quota exceeding ones are.
+var gcpUnavailableError = regexp.MustCompile(`Unavailable|UNAVAILABLE|connection reset|Error 50[0234]`)
+
+// gcpQuotaError matches the errors of requests exceeding the read quota, as
+// gRPC and REST word them.
+var gcpQuotaError = regexp.MustCompile(`ResourceExhausted|RESOURCE_EXHAUSTED|Error 429|[Qq]uota exceeded`)
+
 var scopes = []string{
 	"https://www.googleapis.com/auth/logging.read",
 	"https://www.googleapis.com/auth/cloud-platform.read-only",
@@ -66,6 +102,7 @@ func MakeGCPReader(project, filter, freshness string, strChan chan string) *gcpS
 			strChan:    strChan,
 			readerType: TypeGCP,
 		},
+		retrying:  retrying{policy: gcpRetryPolicy},
 		projectID: project,
 		filter:    filter,
 		freshness: freshness,
@@ -84,66 +121,213 @@ func (s *gcpStream) StreamInto() (err error) {
 			}
 		}
 	}()
-	ctx := context.Background()
-	var c *logging.Client
-	c, err = gcp.LoggingClient(ctx)
+	s.client, err = gcp.LoggingClient(context.Background())
 	if err != nil {
 		return err
 	}
+	s.start()
+	return nil
+}
 
+func (s *gcpStream) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
 	go func() {
-		defer c.Close()
-		if s.isTail {
-			err = s.streamTail(ctx, c)
-		} else {
-			err = s.streamFrom(ctx, c)
-			// fallback to tail if from returns
-			if err == nil {
-				err = s.streamTail(ctx, c)
+		defer close(s.done)
+		renewed, renewedAt := false, ""
+		failures, failedAt := s.backoff(), ""
+		for {
+			var err error
+			if s.isTail {
+				err = s.streamTail(ctx, s.client)
+			} else {
+				err = s.streamFrom(ctx, s.client)
+				// fallback to tail if from returns
+				if err == nil {
+					err = s.streamTail(ctx, s.client)
+				}
+			}
+			if err == nil || ctx.Err() != nil {
+				return
+			}
+			// renew the credentials once per received entry, so that a
+			// rejected renewal doesn't loop
+			if gcp.IsAuthError(err) && (!renewed || renewedAt != s.lastTime) {
+				renewed, renewedAt = true, s.lastTime
+				if err = s.reauth(ctx); err == nil {
+					continue
+				}
+			}
+			if gcpQuotaError.MatchString(err.Error()) {
+				s.quotaHits.Add(1)
+			}
+			if gcpUnavailableError.MatchString(err.Error()) || gcpQuotaError.MatchString(err.Error()) {
+				// the stream recovered if it received entries since failing
+				if failedAt != s.lastTime {
+					failures.reset()
+				}
+				failedAt = s.lastTime
+				if failures.retry(ctx.Done(), err) {
+					s.resume()
+					continue
+				}
+				if ctx.Err() != nil {
+					return
+				}
 			}
-		}
-		if err != nil {
 			if s.onError != nil {
-				s.onError(err)
+				s.onError(gcp.DescribeFilterError(s.sentFilter, err))
 			}
+			return
 		}
 	}()
+}
+
+// reauth renews the expired credentials, logging in again if the refresh
+// token was revoked, and resumes from the last received entry so the buffered
+// logs are kept.
+func (s *gcpStream) reauth(ctx context.Context) error {
+	util.Log().Warn("GCP credentials expired, renewing them")
+	err := gcp.Renew(ctx)
+	if errors.Is(err, gcp.ErrReauth) && !gcp.NoBrowser {
+		// the browser flow doesn't need the terminal the UI holds
+		err = login()
+	}
+	if err != nil {
+		return err
+	}
+	c, err := gcp.LoggingClient(ctx)
+	if err != nil {
+		return err
+	}
+	_ = s.client.Close()
+	s.client = c
+	s.resume()
 	return nil
 }
 
+// resume makes the next stream start from the last received entry, if any,
+// so the buffered logs are kept.
+func (s *gcpStream) resume() {
+	if len(s.lastTime) > 0 {
+		s.freshness = s.lastTime
+		s.isTail = false
+	}
+}
+
+// ServerFilter restarts the stream narrowing the initial GCP filter with the
+// given one (or restoring it when empty), resuming from the last received
+// entry so the buffered logs are kept.
+func (s *gcpStream) ServerFilter(filter string) error {
+	if s.client == nil {
+		return fmt.Errorf("stream not started")
+	}
+	if err := gcp.ValidateFilter(filter); err != nil {
+		return err
+	}
+	s.cancel()
+	<-s.done
+	s.serverFilter = filter
+	s.resume()
+	s.start()
+	return nil
+}
+
+func (s *gcpStream) effectiveFilter() string {
+	switch {
+	case len(s.serverFilter) == 0:
+		return s.filter
+	case len(s.filter) == 0:
+		return s.serverFilter
+	}
+	return fmt.Sprintf("(%s) AND (%s)", s.filter, s.serverFilter)
+}
+
+// streamFrom polls the entries since freshness until caught up, i.e. once a
+// poll returns nothing new, pacing polls as per gcpPacer and backing off
+// upon quota errors.
 func (s *gcpStream) streamFrom(ctx context.Context, c *logging.Client) error {
 	lastTime := s.freshness
-	lastFilter := ""
-	for !s.stop {
+	pacer := newGCPPacer()
+	failures := s.backoff()
+	for ctx.Err() == nil {
 		filter := fmt.Sprintf(`timestamp > "%s"`, lastTime)
-		if filter == lastFilter {
-			return nil
-		}
-		lastFilter = filter
-		if len(s.filter) > 0 {
-			filter = fmt.Sprintf(`timestamp > "%s" AND (%s)`, lastTime, s.filter)
+		if f := s.effectiveFilter(); len(f) > 0 {
+			filter = fmt.Sprintf(`timestamp > "%s" AND (%s)`, lastTime, f)
 		}
 
+		s.sentFilter = filter
 		it := c.ListLogEntries(ctx, &loggingpb.ListLogEntriesRequest{
 			ResourceNames: []string{"projects/" + s.projectID},
 			Filter:        filter,
-			PageSize:      100,
+			PageSize:      pacer.pageSize,
 		})
+		n := 0
+		var err error
 		for {
-			resp, err := it.Next()
+			var resp *loggingpb.LogEntry
+			resp, err = it.Next()
 			if iterator.Done == err {
+				err = nil
 				break
 			} else if err != nil {
-				return err
+				break
 			}
 			var b []byte
 			b, lastTime = massageEntryLog(resp)
-			s.strChan <- string(b)
+			s.lastTime = lastTime
+			if !s.feed(ctx.Done(), string(b)) {
+				return nil
+			}
+			n++
+		}
+		var pause time.Duration
+		switch {
+		case err != nil && gcpQuotaError.MatchString(err.Error()):
+			if !failures.retry(ctx.Done(), err) {
+				return err
+			}
+			s.quotaHits.Add(1)
+			continue
+		case err != nil:
+			return err
+		case n == 0:
+			return nil
+		default:
+			failures.reset()
+			pause = pacer.polled(n)
+			s.pageSize.Store(pacer.pageSize)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(pause):
 		}
 	}
 	return nil
 }
 
+// gcpPacer adapts the page size of ListLogEntries to the volume of entries.
+type gcpPacer struct {
+	pageSize int32
+}
+
+func newGCPPacer() *gcpPacer {
+	return &gcpPacer{pageSize: gcpMinPageSize}
+}
+
+// polled adapts to a poll which returned n entries, returning the pause
+// before the next one: none while pages fill up, as more are likely due, in
+// which case the page size grows so fewer requests are needed.
+func (p *gcpPacer) polled(n int) time.Duration {
+	if n < int(p.pageSize) {
+		return gcpPollInterval
+	}
+	p.pageSize = min(p.pageSize*2, gcpMaxPageSize)
+	return 0
+}
+
 func (s *gcpStream) streamTail(ctx context.Context, c *logging.Client) error {
 	stream, err := c.TailLogEntries(ctx)
 	if err != nil {
@@ -151,9 +335,10 @@ func (s *gcpStream) streamTail(ctx context.Context, c *logging.Client) error {
 	}
 	defer stream.CloseSend()
 
+	s.sentFilter = s.effectiveFilter()
 	req := &loggingpb.TailLogEntriesRequest{
 		ResourceNames: []string{"projects/" + s.projectID},
-		Filter:        s.filter,
+		Filter:        s.sentFilter,
 	}
 	if err := stream.Send(req); err != nil {
 		return err
@@ -174,8 +359,10 @@ func (s *gcpStream) streamTail(ctx context.Context, c *logging.Client) error {
 				return err
 			}
 			var b []byte
-			b, _ = massageEntryLog(resp)
-			s.strChan <- string(b)
+			b, s.lastTime = massageEntryLog(resp)
+			if !s.feed(ctx.Done(), string(b)) {
+				return nil
+			}
 		}
 	}
 	return nil
@@ -200,9 +387,19 @@ func massageEntryLog(resp *loggingpb.LogEntry) ([]byte, string) {
 	return b, lastTime
 }
 
+// Close stops the stream, waiting for it to be done feeding the channel before
+// closing it.
 func (s *gcpStream) Close() {
-	s.stop = true
-	close(s.strChan)
+	s.closeOnce(func() {
+		if s.cancel != nil {
+			s.cancel()
+			<-s.done
+		}
+		if s.client != nil {
+			_ = s.client.Close()
+		}
+		close(s.strChan)
+	})
 }
 
 func CheckAuth(ctx context.Context, projectID string) error {
@@ -215,18 +412,17 @@ func CheckAuth(ctx context.Context, projectID string) error {
 		_, err = it.Next()
 	}
 	if err != nil {
+		if gcp.NoBrowser {
+			// the device code is printed to the terminal
+			return login()
+		}
 		app := tview.NewApplication()
 		modal := tview.NewModal().
 			SetText("Authenticating with gcloud... \nRedirecting to your browser.")
 		go func() {
 			defer app.Stop()
-			if !gcp.IsGCloud {
-				gcp.OAuth()
-			} else {
-				cmd := exec.Command("gcloud", "auth", "application-default", "login")
-				if err := cmd.Run(); err != nil {
-					util.Log().Fatal(err)
-				}
+			if err := login(); err != nil {
+				util.Log().Fatal(err)
 			}
 		}()
 		if err := app.SetRoot(modal, false).EnableMouse(true).Run(); err != nil {
@@ -237,6 +433,24 @@ func CheckAuth(ctx context.Context, projectID string) error {
 	return nil
 }
 
+// login authenticates with either the native connector or gcloud, without a
+// local browser if so requested.
+func login() error {
+	if !gcp.IsGCloud {
+		if gcp.NoBrowser {
+			return gcp.DeviceAuth()
+		}
+		gcp.OAuth()
+		return nil
+	}
+	cmd := exec.Command("gcloud", "auth", "application-default", "login")
+	if gcp.NoBrowser {
+		cmd.Args = append(cmd.Args, "--no-launch-browser")
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	}
+	return cmd.Run()
+}
+
 func ParseFrom(str string) string {
 	str = strings.TrimSpace(str)
 	if str == "tail" {
@@ -271,3 +485,18 @@ func ParseFrom(str string) string {
 	}
 	return ""
 }
+
+// Details tells the project and filters queried, the quota errors met and
+// the page size polled with.
+func (s *gcpStream) Details() []Detail {
+	details := []Detail{{Label: "Source", Value: "Google Cloud Logging"}, {Label: "Project", Value: s.projectID}}
+	details = append(details, optional("Filter", s.filter)...)
+	details = append(details, optional("Server filter", s.serverFilter)...)
+	details = append(details, fromDetail(s.freshness))
+	quota := fmt.Sprintf("exceeded %d times", s.quotaHits.Load())
+	if size := s.pageSize.Load(); size > 0 {
+		quota += fmt.Sprintf(", polling %d entries per request", size)
+	}
+	details = append(details, Detail{Label: "Read quota", Value: quota})
+	return append(details, s.retrying.details()...)
+}
@@ -78,3 +78,52 @@ func TestParseFrom(t *testing.T) {
 		})
 	}
 }
+
+func TestGCPPacer(t *testing.T) {
+	p := newGCPPacer()
+
+	assert.Equal(t, gcpPollInterval, p.polled(10))
+	assert.Equal(t, int32(gcpMinPageSize), p.pageSize)
+
+	// full pages grow the page size, with no pause as more entries are likely due
+	for _, want := range []int32{200, 400, 800, 1000, 1000} {
+		assert.Equal(t, time.Duration(0), p.polled(5000))
+		assert.Equal(t, want, p.pageSize)
+	}
+}
+
+func TestGCPQuotaError(t *testing.T) {
+	tests := []struct {
+		err   string
+		quota bool
+	}{
+		{"rpc error: code = ResourceExhausted desc = Quota exceeded for quota metric 'Read requests'", true},
+		{"googleapi: Error 429: Quota exceeded, rateLimitExceeded", true},
+		{"RESOURCE_EXHAUSTED", true},
+		{"rpc error: code = InvalidArgument desc = Unparseable filter", false},
+		{"rpc error: code = PermissionDenied desc = The caller does not have permission", false},
+	}
+	for _, test := range tests {
+		t.Run(test.err, func(t *testing.T) {
+			assert.Equal(t, test.quota, gcpQuotaError.MatchString(test.err))
+		})
+	}
+}
+
+func TestGCPUnavailableError(t *testing.T) {
+	tests := []struct {
+		err         string
+		unavailable bool
+	}{
+		{"rpc error: code = Unavailable desc = connection closed before server preface received", true},
+		{"googleapi: Error 503: The service is currently unavailable., backendError", true},
+		{"read tcp 10.0.0.2:50110->142.250.74.42:443: read: connection reset by peer", true},
+		{"rpc error: code = InvalidArgument desc = Unparseable filter", false},
+		{"googleapi: Error 403: The caller does not have permission, forbidden", false},
+	}
+	for _, test := range tests {
+		t.Run(test.err, func(t *testing.T) {
+			assert.Equal(t, test.unavailable, gcpUnavailableError.MatchString(test.err))
+		})
+	}
+}
@@ -0,0 +1,251 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	graylogSearchPath = "/api/search/universal/absolute"
+	graylogTimeFormat = "2006-01-02T15:04:05.000Z"
+	graylogPageLimit  = 500
+	graylogPollPeriod = 2 * time.Second
+)
+
+type graylogStream struct {
+	reader
+	retrying
+	baseURL  string
+	token    string
+	query    string
+	streamID string
+	from     string
+	follow   bool
+	http     *http.Client
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	seen     map[string]time.Time
+}
+
+type graylogSearchResponse struct {
+	Messages []struct {
+		Message map[string]interface{} `json:"message"`
+		Index   string                 `json:"index"`
+	} `json:"messages"`
+	TotalResults int `json:"total_results"`
+}
+
+// MakeGraylogReader builds a reader querying the Graylog search API. The from
+// parameter accepts "tail" or an RFC3339 timestamp (see ParseFrom); when
+// follow is set, new messages keep being polled once the history is consumed.
+// An optional streamID narrows the search to a single Graylog stream.
+func MakeGraylogReader(baseURL, token, query, streamID, from string, follow bool, strChan chan string) *graylogStream {
+	if strChan == nil {
+		strChan = make(chan string, 1)
+	}
+	if len(query) == 0 {
+		query = "*"
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &graylogStream{
+		reader: reader{
+			strChan:    strChan,
+			readerType: TypeGraylog,
+		},
+		retrying: retrying{policy: defaultRetryPolicy},
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		token:    token,
+		query:    query,
+		streamID: streamID,
+		from:     from,
+		follow:   follow,
+		http:     http.DefaultClient,
+		ctx:      ctx,
+		cancel:   cancel,
+		seen:     make(map[string]time.Time),
+	}
+}
+
+func (s *graylogStream) StreamInto() error {
+	since := time.Now()
+	if s.from != "tail" {
+		t, err := time.Parse(time.RFC3339, s.from)
+		if err != nil {
+			return err
+		}
+		since = t
+	}
+	// Validate the token and query upfront so errors surface at startup.
+	if _, err := s.search(since, time.Now(), 0, 1); err != nil {
+		return err
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.stream(since); err != nil && s.ctx.Err() == nil && s.onError != nil {
+			s.onError(err)
+		}
+	}()
+	return nil
+}
+
+func (s *graylogStream) stream(since time.Time) error {
+	failures := s.backoff()
+	for s.ctx.Err() == nil {
+		until := time.Now()
+		latest := since
+		for offset := 0; ; {
+			resp, err := s.search(since, until, offset, graylogPageLimit)
+			if err != nil && transient(err) && failures.retry(s.ctx.Done(), err) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			failures.reset()
+			for _, m := range resp.Messages {
+				id, _ := m.Message["_id"].(string)
+				if _, ok := s.seen[id]; ok && len(id) > 0 {
+					continue
+				}
+				entry, ts := massageGraylogMessage(m.Message)
+				s.seen[id] = ts
+				if ts.After(latest) {
+					latest = ts
+				}
+				b, _ := json.Marshal(entry)
+				select {
+				case <-s.ctx.Done():
+					return nil
+				case s.strChan <- string(b):
+				}
+			}
+			if len(resp.Messages) < graylogPageLimit {
+				break
+			}
+			offset += graylogPageLimit
+		}
+		if !s.follow {
+			return nil
+		}
+		// Graylog boundaries are inclusive, the seen ids drop the duplicates.
+		since = latest
+		for id, ts := range s.seen {
+			if ts.Before(since) {
+				delete(s.seen, id)
+			}
+		}
+		select {
+		case <-s.ctx.Done():
+		case <-time.After(graylogPollPeriod):
+		}
+	}
+	return nil
+}
+
+func (s *graylogStream) search(from, to time.Time, offset, limit int) (*graylogSearchResponse, error) {
+	params := url.Values{
+		"query":  {s.query},
+		"from":   {from.UTC().Format(graylogTimeFormat)},
+		"to":     {to.UTC().Format(graylogTimeFormat)},
+		"offset": {strconv.Itoa(offset)},
+		"limit":  {strconv.Itoa(limit)},
+		"sort":   {"timestamp:asc"},
+	}
+	if len(s.streamID) > 0 {
+		params.Set("filter", "streams:"+s.streamID)
+	}
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodGet,
+		s.baseURL+graylogSearchPath+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Requested-By", "loggo")
+	// Graylog access tokens are passed as the basic auth user.
+	req.SetBasicAuth(s.token, "token")
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	out := &graylogSearchResponse{}
+	if err := json.Unmarshal(b, out); err != nil || resp.StatusCode/100 != 2 {
+		return nil, &statusError{"graylog search", resp.StatusCode, strings.TrimSpace(string(b))}
+	}
+	return out, nil
+}
+
+// massageGraylogMessage drops the Graylog internal gl2_ fields, converts the
+// timestamp to local time and adds the syslog severity name of the GELF level.
+func massageGraylogMessage(message map[string]interface{}) (map[string]interface{}, time.Time) {
+	m := make(map[string]interface{})
+	for k, v := range message {
+		if !strings.HasPrefix(k, "gl2_") {
+			m[k] = v
+		}
+	}
+	var ts time.Time
+	if str, ok := message["timestamp"].(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, str); err == nil {
+			ts = t
+			m["timestamp"] = t.Local().Format(time.RFC3339)
+		}
+	}
+	if level, ok := message["level"].(float64); ok && level >= 0 && int(level) < len(syslogSeverities) {
+		m["severity"] = syslogSeverities[int(level)]
+	}
+	return m, ts
+}
+
+func (s *graylogStream) Close() {
+	s.closeOnce(func() {
+		s.cancel()
+		s.wg.Wait()
+		close(s.strChan)
+	})
+}
+
+// Details tells the server, stream and query, never the token.
+func (s *graylogStream) Details() []Detail {
+	details := []Detail{{Label: "Source", Value: "Graylog"}, {Label: "URL", Value: s.baseURL}}
+	details = append(details, optional("Stream", s.streamID)...)
+	details = append(details, Detail{Label: "Query", Value: s.query}, fromDetail(s.from))
+	if !s.follow {
+		details = append(details, Detail{Label: "Follow", Value: "no, history only"})
+	}
+	return append(details, s.retrying.details()...)
+}
@@ -0,0 +1,91 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMassageGraylogMessage(t *testing.T) {
+	m, ts := massageGraylogMessage(map[string]interface{}{
+		"_id":                "abc",
+		"timestamp":          "2024-01-30T15:00:00.000Z",
+		"source":             "web-1",
+		"message":            "boom",
+		"level":              float64(3),
+		"gl2_source_input":   "x",
+		"gl2_accounted_size": float64(10),
+	})
+	assert.Equal(t, time.Date(2024, 1, 30, 15, 0, 0, 0, time.UTC), ts.UTC())
+	assert.Equal(t, map[string]interface{}{
+		"_id":       "abc",
+		"timestamp": ts.Local().Format(time.RFC3339),
+		"source":    "web-1",
+		"message":   "boom",
+		"level":     float64(3),
+		"severity":  "error",
+	}, m)
+}
+
+func TestGraylogStream_StreamInto(t *testing.T) {
+	from := time.Now().Add(-time.Hour).UTC()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, graylogSearchPath, r.URL.Path)
+		user, pass, _ := r.BasicAuth()
+		assert.Equal(t, "my-token", user)
+		assert.Equal(t, "token", pass)
+		assert.Equal(t, "loggo", r.Header.Get("X-Requested-By"))
+		assert.Equal(t, "streams:s1", r.URL.Query().Get("filter"))
+		since, _ := time.Parse(graylogTimeFormat, r.URL.Query().Get("from"))
+		var messages []interface{}
+		for i, text := range []string{"first", "second"} {
+			ts := from.Add(time.Duration(i+1) * time.Minute)
+			if !ts.Before(since) {
+				messages = append(messages, map[string]interface{}{"message": map[string]interface{}{
+					"_id": text, "timestamp": ts.Format(graylogTimeFormat), "message": text,
+				}})
+			}
+		}
+		out := map[string]interface{}{"messages": messages}
+		_ = json.NewEncoder(w).Encode(out)
+	}))
+	defer server.Close()
+
+	s := MakeGraylogReader(server.URL, "my-token", "", "s1", from.Format(time.RFC3339), true, nil)
+	assert.NoError(t, s.StreamInto())
+	assert.Contains(t, <-s.ChanReader(), `"message":"first"`)
+	assert.Contains(t, <-s.ChanReader(), `"message":"second"`)
+	// following polls return the same messages, which must not be repeated
+	select {
+	case l := <-s.ChanReader():
+		assert.Empty(t, l)
+	case <-time.After(graylogPollPeriod + time.Second):
+	}
+	s.Close()
+}
@@ -0,0 +1,324 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// herokuLineReg matches `heroku logs` output, e.g.:
+	// 2024-01-30T15:00:00.123456+00:00 app[web.1]: some message
+	herokuLineReg = regexp.MustCompile(`^(\S+) ([\w-]+)\[([^\]]+)\]: ?(.*)$`)
+	logfmtReg     = regexp.MustCompile(`([\w.-]+)=("(?:[^"\\]|\\.)*"|\S*)`)
+)
+
+var syslogSeverities = []string{
+	"emergency", "alert", "critical", "error", "warning", "notice", "info", "debug",
+}
+
+type herokuStream struct {
+	reader
+	app     string
+	args    []string
+	drain   *http.Server
+	cert    string
+	key     string
+	auth    string
+	ctx     context.Context
+	cancel  context.CancelFunc
+	started bool
+	stopped chan struct{}
+}
+
+// MakeHerokuReader attaches to `heroku logs --tail` for the given app. Any
+// additional args (e.g. --source app, --dyno web) are passed through to the
+// heroku CLI.
+func MakeHerokuReader(app string, args []string, strChan chan string) *herokuStream {
+	s := makeHerokuStream(strChan)
+	s.app = app
+	s.args = args
+	return s
+}
+
+// MakeHerokuDrainReader serves a Heroku HTTPS log drain at the given address.
+// If certFile and keyFile are empty the endpoint is served over plain HTTP,
+// which is only meant to sit behind a TLS terminating tunnel. If auth is
+// provided as "user:password" requests must carry matching basic auth
+// credentials, as configured in the drain URL.
+func MakeHerokuDrainReader(addr, certFile, keyFile, auth string, strChan chan string) *herokuStream {
+	s := makeHerokuStream(strChan)
+	s.cert = certFile
+	s.key = keyFile
+	s.auth = auth
+	s.drain = &http.Server{
+		Addr:              addr,
+		Handler:           http.HandlerFunc(s.serveDrain),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+func makeHerokuStream(strChan chan string) *herokuStream {
+	if strChan == nil {
+		strChan = make(chan string, 1)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &herokuStream{
+		reader: reader{
+			strChan:    strChan,
+			readerType: TypeHeroku,
+		},
+		ctx:     ctx,
+		cancel:  cancel,
+		stopped: make(chan struct{}),
+	}
+}
+
+func (s *herokuStream) StreamInto() error {
+	if s.drain != nil {
+		return s.serve()
+	}
+	return s.tail()
+}
+
+func (s *herokuStream) tail() error {
+	args := append([]string{"logs", "--tail", "--app", s.app}, s.args...)
+	c, err := startCommand(s.ctx, "heroku", args...)
+	if err != nil {
+		return err
+	}
+	s.started = true
+	go func() {
+		defer close(s.stopped)
+		err := c.consume(func(line string) bool {
+			if m := parseHerokuLine(line); m != nil {
+				b, _ := json.Marshal(m)
+				line = string(b)
+			}
+			return s.send(line)
+		})
+		if s.ctx.Err() == nil && s.onError != nil {
+			if err == nil {
+				err = fmt.Errorf("heroku logs stream ended")
+			}
+			s.onError(err)
+		}
+	}()
+	return nil
+}
+
+func (s *herokuStream) serve() error {
+	ln, err := net.Listen("tcp", s.drain.Addr)
+	if err != nil {
+		return err
+	}
+	s.started = true
+	go func() {
+		defer close(s.stopped)
+		var err error
+		if len(s.cert) > 0 {
+			err = s.drain.ServeTLS(ln, s.cert, s.key)
+		} else {
+			err = s.drain.Serve(ln)
+		}
+		if err != http.ErrServerClosed && s.onError != nil {
+			s.onError(err)
+		}
+	}()
+	return nil
+}
+
+func (s *herokuStream) serveDrain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if len(s.auth) > 0 {
+		user, pass, _ := r.BasicAuth()
+		if subtle.ConstantTimeCompare([]byte(user+":"+pass), []byte(s.auth)) != 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	for _, m := range parseLogplexFrames(body) {
+		b, _ := json.Marshal(m)
+		if !s.send(string(b)) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+	}
+	// Heroku drops the frame on anything other than a 2xx.
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *herokuStream) send(line string) bool {
+	return s.feed(s.ctx.Done(), line)
+}
+
+// parseHerokuLine structures a line from `heroku logs` output, returning nil
+// if the line does not follow the heroku format.
+func parseHerokuLine(line string) map[string]interface{} {
+	match := herokuLineReg.FindStringSubmatch(line)
+	if match == nil {
+		return nil
+	}
+	return massageHerokuEntry(match[1], match[2], match[3], match[4])
+}
+
+// parseLogplexFrames parses an application/logplex-1 body: a sequence of
+// octet counted RFC5424 syslog messages, e.g.:
+// 83 <40>1 2012-11-30T06:45:29+00:00 host app web.3 - State changed from starting to up
+func parseLogplexFrames(body []byte) []map[string]interface{} {
+	var entries []map[string]interface{}
+	for len(body) > 0 {
+		body = bytes.TrimLeft(body, " \r\n")
+		sp := bytes.IndexByte(body, ' ')
+		if sp < 0 {
+			break
+		}
+		size, err := strconv.Atoi(string(body[:sp]))
+		if err != nil || size < 0 || sp+1+size > len(body) {
+			break
+		}
+		frame := string(body[sp+1 : sp+1+size])
+		body = body[sp+1+size:]
+
+		// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID MSG
+		fields := strings.SplitN(strings.TrimRight(frame, "\r\n"), " ", 7)
+		if len(fields) < 6 {
+			continue
+		}
+		msg := ""
+		if len(fields) == 7 {
+			msg = fields[6]
+		}
+		m := massageHerokuEntry(fields[1], fields[3], fields[4], msg)
+		if end := strings.IndexByte(fields[0], '>'); strings.HasPrefix(fields[0], "<") && end > 0 {
+			if pri, err := strconv.Atoi(fields[0][1:end]); err == nil {
+				m["severity"] = syslogSeverities[pri&7]
+			}
+		}
+		entries = append(entries, m)
+	}
+	return entries
+}
+
+func massageHerokuEntry(timestamp, source, dyno, message string) map[string]interface{} {
+	m := map[string]interface{}{
+		"timestamp": timestamp,
+		"source":    source,
+		"dyno":      dyno,
+	}
+	if t, err := time.Parse(time.RFC3339Nano, timestamp); err == nil {
+		m["timestamp"] = t.Local().Format(time.RFC3339)
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal([]byte(message), &payload); err == nil {
+		m["jsonPayload"] = payload
+		return m
+	}
+	m["message"] = message
+	if fields := parseLogfmt(message); fields != nil {
+		m["fields"] = fields
+	}
+	return m
+}
+
+// parseLogfmt parses messages made exclusively of key=value pairs, such as the
+// heroku router logs. It returns nil for free text messages.
+func parseLogfmt(message string) map[string]interface{} {
+	matches := logfmtReg.FindAllStringSubmatchIndex(message, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+	fields := make(map[string]interface{})
+	last := 0
+	for _, idx := range matches {
+		if len(strings.TrimSpace(message[last:idx[0]])) > 0 {
+			return nil
+		}
+		value := message[idx[4]:idx[5]]
+		if uq, err := strconv.Unquote(value); err == nil {
+			value = uq
+		}
+		fields[message[idx[2]:idx[3]]] = value
+		last = idx[1]
+	}
+	if len(strings.TrimSpace(message[last:])) > 0 {
+		return nil
+	}
+	return fields
+}
+
+func (s *herokuStream) Close() {
+	s.closeOnce(func() {
+		s.cancel()
+		if s.drain != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = s.drain.Shutdown(ctx)
+		}
+		if s.started {
+			select {
+			case <-s.stopped:
+			case <-time.After(5 * time.Second):
+			}
+		}
+		s.closeFeed()
+	})
+}
+
+// Details tells either the app tailed through the heroku CLI, or the address
+// the drain is served at and how it's secured, never the credentials.
+func (s *herokuStream) Details() []Detail {
+	if s.drain == nil {
+		details := []Detail{{Label: "Source", Value: "Heroku"}, {Label: "App", Value: s.app}}
+		return append(details, optional("Arguments", strings.Join(s.args, " "))...)
+	}
+	tls, auth := "no, behind a TLS terminating tunnel", "none"
+	if len(s.cert) > 0 {
+		tls = s.cert
+	}
+	if len(s.auth) > 0 {
+		auth = "basic"
+	}
+	return []Detail{{Label: "Source", Value: "Heroku log drain"}, {Label: "Address", Value: s.drain.Addr},
+		{Label: "TLS", Value: tls}, {Label: "Authentication", Value: auth}}
+}
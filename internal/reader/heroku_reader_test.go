@@ -0,0 +1,132 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHerokuLine(t *testing.T) {
+	ts := "2024-01-30T15:00:00.123456+00:00"
+	localTs := func() string {
+		tv, _ := time.Parse(time.RFC3339Nano, ts)
+		return tv.Local().Format(time.RFC3339)
+	}()
+	tests := []struct {
+		name  string
+		line  string
+		wants map[string]interface{}
+	}{
+		{
+			name: "Text message",
+			line: ts + " app[web.1]: Listening on port 3000",
+			wants: map[string]interface{}{
+				"timestamp": localTs,
+				"source":    "app",
+				"dyno":      "web.1",
+				"message":   "Listening on port 3000",
+			},
+		},
+		{
+			name: "JSON message",
+			line: ts + ` app[worker.2]: {"level":"error","msg":"boom"}`,
+			wants: map[string]interface{}{
+				"timestamp":   localTs,
+				"source":      "app",
+				"dyno":        "worker.2",
+				"jsonPayload": map[string]interface{}{"level": "error", "msg": "boom"},
+			},
+		},
+		{
+			name: "Router message",
+			line: ts + ` heroku[router]: at=info method=GET path="/a b" status=200`,
+			wants: map[string]interface{}{
+				"timestamp": localTs,
+				"source":    "heroku",
+				"dyno":      "router",
+				"message":   `at=info method=GET path="/a b" status=200`,
+				"fields": map[string]interface{}{
+					"at":     "info",
+					"method": "GET",
+					"path":   "/a b",
+					"status": "200",
+				},
+			},
+		},
+		{
+			name:  "Not a heroku line",
+			line:  "random text",
+			wants: nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.wants, parseHerokuLine(test.line))
+		})
+	}
+}
+
+func TestParseLogplexFrames(t *testing.T) {
+	msg1 := "<43>1 2012-11-30T06:45:29+00:00 host app web.3 - State changed from starting to up"
+	msg2 := "<158>1 2012-11-30T06:45:26+00:00 host heroku router - at=info status=200"
+	body := []byte(frame(msg1) + frame(msg2))
+
+	entries := parseLogplexFrames(body)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "app", entries[0]["source"])
+	assert.Equal(t, "web.3", entries[0]["dyno"])
+	assert.Equal(t, "error", entries[0]["severity"])
+	assert.Equal(t, "State changed from starting to up", entries[0]["message"])
+	assert.Equal(t, "router", entries[1]["dyno"])
+	assert.Equal(t, "info", entries[1]["severity"])
+	assert.Equal(t, map[string]interface{}{"at": "info", "status": "200"}, entries[1]["fields"])
+}
+
+func frame(msg string) string {
+	return strconv.Itoa(len(msg)) + " " + msg
+}
+
+func TestHerokuStream_ServeDrain(t *testing.T) {
+	s := MakeHerokuDrainReader(":0", "", "", "user:pass", nil)
+	msg := "<190>1 2012-11-30T06:45:29+00:00 host app web.1 - hello"
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(frame(msg)))
+	rec := httptest.NewRecorder()
+	s.serveDrain(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(frame(msg)))
+	req.SetBasicAuth("user", "pass")
+	rec = httptest.NewRecorder()
+	go s.serveDrain(rec, req)
+	line := <-s.ChanReader()
+	assert.Contains(t, line, `"message":"hello"`)
+	assert.Contains(t, line, `"severity":"info"`)
+}
@@ -0,0 +1,203 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxHTTPBody caps a single batch, after decompression.
+const maxHTTPBody = 32 * 1024 * 1024
+
+type httpStream struct {
+	reader
+	server  *http.Server
+	path    string
+	ctx     context.Context
+	cancel  context.CancelFunc
+	started bool
+	stopped chan struct{}
+}
+
+// MakeHTTPReader serves an HTTP ingestion endpoint compatible with the Vector
+// and Fluent Bit HTTP sinks. Request bodies may be newline delimited JSON or
+// JSON arrays (batches), optionally gzip encoded.
+func MakeHTTPReader(addr, path string, strChan chan string) *httpStream {
+	if strChan == nil {
+		strChan = make(chan string, 1)
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &httpStream{
+		reader: reader{
+			strChan:    strChan,
+			readerType: TypeHTTP,
+		},
+		path:    path,
+		ctx:     ctx,
+		cancel:  cancel,
+		stopped: make(chan struct{}),
+	}
+	s.server = &http.Server{
+		Addr:              addr,
+		Handler:           http.HandlerFunc(s.serveIngest),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+func (s *httpStream) StreamInto() error {
+	ln, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return err
+	}
+	s.started = true
+	go func() {
+		defer close(s.stopped)
+		if err := s.server.Serve(ln); err != http.ErrServerClosed && s.onError != nil {
+			s.onError(err)
+		}
+	}()
+	return nil
+}
+
+func (s *httpStream) serveIngest(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != s.path {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var body io.Reader = r.Body
+	if strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+	b, err := io.ReadAll(io.LimitReader(body, maxHTTPBody+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(b) > maxHTTPBody {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+	lines, err := splitHTTPBatch(b)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, l := range lines {
+		if !s.feed(s.ctx.Done(), l) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// splitHTTPBatch splits a request body into log lines. JSON arrays are
+// unrolled into one line per element, anything else is split by line.
+func splitHTTPBatch(b []byte) ([]string, error) {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 {
+		return nil, nil
+	}
+	if b[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(b, &batch); err != nil {
+			return nil, err
+		}
+		lines := make([]string, 0, len(batch))
+		for _, e := range batch {
+			lines = append(lines, massageHTTPEntry(e))
+		}
+		return lines, nil
+	}
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	scanner.Buffer(make([]byte, 64*1024), maxHTTPBody)
+	for scanner.Scan() {
+		if l := bytes.TrimSpace(scanner.Bytes()); len(l) > 0 {
+			lines = append(lines, massageHTTPEntry(l))
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// massageHTTPEntry adds a timestamp to Fluent Bit records, which carry the
+// event time as a numeric "date" key (epoch seconds).
+func massageHTTPEntry(b []byte) string {
+	if !bytes.Contains(b, []byte(`"date"`)) {
+		return string(b)
+	}
+	m := make(map[string]interface{})
+	if err := json.Unmarshal(b, &m); err != nil {
+		return string(b)
+	}
+	date, ok := m["date"].(float64)
+	if _, exists := m["timestamp"]; !ok || exists {
+		return string(b)
+	}
+	m["timestamp"] = time.UnixMilli(int64(date * 1000)).Local().Format(time.RFC3339)
+	out, _ := json.Marshal(m)
+	return string(out)
+}
+
+func (s *httpStream) Close() {
+	s.closeOnce(func() {
+		s.cancel()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = s.server.Shutdown(ctx)
+		if s.started {
+			<-s.stopped
+		}
+		// handlers still running past the shutdown timeout stop feeding
+		s.closeFeed()
+	})
+}
+
+// Details tells the address and path the endpoint is served at.
+func (s *httpStream) Details() []Detail {
+	return []Detail{{Label: "Source", Value: "HTTP ingestion"}, {Label: "Address", Value: s.server.Addr},
+		{Label: "Path", Value: s.path}}
+}
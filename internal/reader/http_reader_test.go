@@ -0,0 +1,109 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitHTTPBatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		wants []string
+	}{
+		{
+			name:  "NDJSON",
+			body:  "{\"a\":1}\n\n{\"a\":2}\n",
+			wants: []string{`{"a":1}`, `{"a":2}`},
+		},
+		{
+			name:  "JSON array",
+			body:  `[{"a":1}, {"a":2}]`,
+			wants: []string{`{"a":1}`, `{"a":2}`},
+		},
+		{
+			name:  "Fluent Bit date",
+			body:  `[{"date":0,"log":"x"}]`,
+			wants: []string{`{"date":0,"log":"x","timestamp":"` + time.Unix(0, 0).Local().Format(time.RFC3339) + `"}`},
+		},
+		{
+			name:  "Plain text",
+			body:  "hello\nworld",
+			wants: []string{"hello", "world"},
+		},
+		{
+			name:  "Empty",
+			body:  " ",
+			wants: nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lines, err := splitHTTPBatch([]byte(test.body))
+			assert.NoError(t, err)
+			assert.Equal(t, test.wants, lines)
+		})
+	}
+}
+
+func TestHTTPStream_ServeIngest(t *testing.T) {
+	s := MakeHTTPReader(":0", "logs", nil)
+
+	rec := httptest.NewRecorder()
+	s.serveIngest(rec, httptest.NewRequest(http.MethodGet, "/logs", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	rec = httptest.NewRecorder()
+	s.serveIngest(rec, httptest.NewRequest(http.MethodPost, "/other", strings.NewReader("{}")))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	s.serveIngest(rec, httptest.NewRequest(http.MethodPost, "/logs", strings.NewReader("[1")))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	gz := &bytes.Buffer{}
+	w := gzip.NewWriter(gz)
+	_, _ = w.Write([]byte(`[{"message":"one"},{"message":"two"}]`))
+	_ = w.Close()
+	req := httptest.NewRequest(http.MethodPost, "/logs", gz)
+	req.Header.Set("Content-Encoding", "gzip")
+	rec = httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.serveIngest(rec, req)
+	}()
+	assert.Equal(t, `{"message":"one"}`, <-s.ChanReader())
+	assert.Equal(t, `{"message":"two"}`, <-s.ChanReader())
+	<-done
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package reader
+
+import (
+	"os"
+	"syscall"
+)
+
+// inode returns the inode number of the file, telling a rotated file from
+// the new one created under the same name.
+func inode(fi os.FileInfo) (uint64, bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(st.Ino), true
+}
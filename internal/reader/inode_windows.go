@@ -0,0 +1,10 @@
+//go:build windows
+
+package reader
+
+import "os"
+
+// inode returns false, files having no inode number on Windows.
+func inode(os.FileInfo) (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,80 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"sync"
+
+	"github.com/badaniya/loggo/internal/util"
+)
+
+type internalStream struct {
+	reader
+	unsubscribe func()
+	once        sync.Once
+	stopped     chan struct{}
+}
+
+// MakeInternalReader builds a reader over loggo's own log (see util.Log), so
+// it can be browsed in-app rather than from the log file on disk.
+func MakeInternalReader(strChan chan string) *internalStream {
+	if strChan == nil {
+		strChan = make(chan string, 1)
+	}
+	return &internalStream{
+		reader: reader{
+			strChan:    strChan,
+			readerType: TypeInternal,
+		},
+		stopped: make(chan struct{}),
+	}
+}
+
+func (s *internalStream) StreamInto() error {
+	lines, unsubscribe := util.SubscribeLog()
+	s.unsubscribe = unsubscribe
+	go func() {
+		defer close(s.strChan)
+		for line := range lines {
+			select {
+			case s.strChan <- line:
+			case <-s.stopped:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *internalStream) Close() {
+	s.once.Do(func() {
+		close(s.stopped)
+		if s.unsubscribe != nil {
+			s.unsubscribe()
+		}
+	})
+}
+
+func (s *internalStream) Details() []Detail {
+	return []Detail{{Label: "Source", Value: "l'oGGo's own log"}}
+}
@@ -0,0 +1,62 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/badaniya/loggo/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInternalStream(t *testing.T) {
+	util.Log().Info("before subscribing")
+	r := MakeInternalReader(nil)
+	assert.NoError(t, r.StreamInto())
+	util.Log().Info("after subscribing")
+
+	// the backlog replays the logs of the tests run earlier too
+	var got []string
+	timeout := time.After(time.Second)
+	for len(got) == 0 || !strings.Contains(got[len(got)-1], "after subscribing") {
+		select {
+		case line := <-r.ChanReader():
+			got = append(got, line)
+		case <-timeout:
+			t.Fatalf("timed out, got %v", got)
+		}
+	}
+	before := -1
+	for i, line := range got {
+		if strings.Contains(line, "before subscribing") {
+			before = i
+		}
+	}
+	assert.True(t, before >= 0, "the backlog lacks the line logged before subscribing: %v", got)
+
+	r.Close()
+	for range r.ChanReader() {
+	}
+}
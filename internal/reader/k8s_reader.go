@@ -0,0 +1,495 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// k8sLogLineReg matches `kubectl logs --prefix --timestamps` output, e.g.:
+// [pod/api-7d9c6-x2tq/app] 2024-01-30T15:00:00.123456789Z some message
+var k8sLogLineReg = regexp.MustCompile(`^(?:\[pod/([^/\]]+)/([^\]]+)\] )?(\d{4}-\d{2}-\d{2}T\S+) ?(.*)$`)
+
+// k8sRetryInterval is the pause before reattaching to logs or watches kubectl
+// ended, e.g. once the followed containers restarted.
+const k8sRetryInterval = 2 * time.Second
+
+type k8sStream struct {
+	reader
+	args      []string
+	target    string
+	selector  string
+	container string
+	from      string
+	events    bool
+	since     time.Time
+	mu        sync.Mutex
+	pods      map[string]bool
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// K8sArgs builds the kubectl arguments selecting the context and namespace,
+// shared by the logs and the watches.
+func K8sArgs(kubeContext, namespace string) []string {
+	var args []string
+	if len(kubeContext) > 0 {
+		args = append(args, "--context", kubeContext)
+	}
+	if len(namespace) > 0 {
+		args = append(args, "--namespace", namespace)
+	}
+	return args
+}
+
+// MakeK8sReader follows the logs of a workload (e.g. deploy/api or a pod name)
+// or of the pods matching a label selector, wrapping `kubectl logs`. If events
+// is set, the namespace events about those pods and their owners, as well as
+// container restarts (e.g. OOMKilled), are interleaved with the logs. If from
+// is an RFC3339 timestamp (see ParseFrom), the history since then is streamed
+// first.
+func MakeK8sReader(args []string, target, selector, container, from string, events bool, strChan chan string) *k8sStream {
+	if strChan == nil {
+		strChan = make(chan string, 1)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &k8sStream{
+		reader: reader{
+			strChan:    strChan,
+			readerType: TypeK8s,
+		},
+		args:      args,
+		target:    target,
+		selector:  selector,
+		container: container,
+		from:      from,
+		events:    events,
+		pods:      make(map[string]bool),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+func (s *k8sStream) StreamInto() error {
+	if _, err := exec.LookPath("kubectl"); err != nil {
+		return err
+	}
+	s.since = time.Now()
+	if s.from != "tail" {
+		t, err := time.Parse(time.RFC3339, s.from)
+		if err != nil {
+			return err
+		}
+		s.since = t
+	}
+	s.wg.Add(1)
+	go s.follow()
+	if s.events {
+		s.wg.Add(2)
+		restarts := make(map[string]int64)
+		go s.watch(s.podsArgs(), func(value json.RawMessage) bool {
+			return s.forwardPod(value, restarts)
+		})
+		versions := make(map[string]string)
+		go s.watch(append([]string{"get", "events", "--watch", "--output", "json"}, s.args...),
+			func(value json.RawMessage) bool {
+				return s.forwardEvent(value, versions)
+			})
+	}
+	return nil
+}
+
+// follow streams the logs, reattaching from the last received line whenever
+// kubectl ends, as it does once all the followed containers exited.
+func (s *k8sStream) follow() {
+	defer s.wg.Done()
+	since, tail := s.since, s.from == "tail"
+	latest := make(map[string]time.Time)
+	for {
+		// lines up to these times were sent before reattaching
+		replayed := make(map[string]time.Time, len(latest))
+		for k, v := range latest {
+			replayed[k] = v
+		}
+		c, err := startCommand(s.ctx, "kubectl", s.logsArgs(since, tail)...)
+		if err == nil {
+			err = c.consume(func(line string) bool {
+				m, t := parseK8sLogLine(line)
+				if m == nil {
+					return s.send(map[string]interface{}{"kind": "log", "message": line})
+				}
+				key := fmt.Sprintf("%v/%v", m["pod"], m["container"])
+				if r, ok := replayed[key]; ok && !t.After(r) {
+					return true
+				}
+				latest[key] = t
+				if t.After(since) {
+					since = t
+				}
+				if pod, ok := m["pod"].(string); ok {
+					s.mu.Lock()
+					s.pods[pod] = true
+					s.mu.Unlock()
+				}
+				return s.send(m)
+			})
+		}
+		if s.ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			s.fail(err)
+			return
+		}
+		tail = false
+		if !s.pause() {
+			return
+		}
+	}
+}
+
+func (s *k8sStream) logsArgs(since time.Time, tail bool) []string {
+	args := append([]string{"logs", "--follow", "--timestamps", "--prefix"}, s.args...)
+	if len(s.target) > 0 {
+		args = append(args, s.target)
+	} else {
+		args = append(args, "--selector", s.selector, "--max-log-requests", "50")
+	}
+	if len(s.container) > 0 {
+		args = append(args, "--container", s.container)
+	} else {
+		args = append(args, "--all-containers")
+	}
+	if tail {
+		return append(args, "--tail", "0")
+	}
+	return append(args, "--since-time", since.UTC().Format(time.RFC3339))
+}
+
+// podsArgs watches the pods of the selector or, as workloads can't be
+// selected by name, all the namespace pods, which forwardPod narrows down.
+func (s *k8sStream) podsArgs() []string {
+	args := append([]string{"get", "pods", "--watch", "--output", "json"}, s.args...)
+	if len(s.selector) > 0 {
+		args = append(args, "--selector", s.selector)
+	}
+	return args
+}
+
+// watch decodes the objects of a kubectl watch, restarting it whenever the
+// server closes it.
+func (s *k8sStream) watch(args []string, onValue func(value json.RawMessage) bool) {
+	defer s.wg.Done()
+	for {
+		c, err := startCommand(s.ctx, "kubectl", args...)
+		if err == nil {
+			err = c.decode(onValue)
+		}
+		if s.ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			s.fail(err)
+			return
+		}
+		if !s.pause() {
+			return
+		}
+	}
+}
+
+func (s *k8sStream) forwardPod(value json.RawMessage, restarts map[string]int64) bool {
+	pod := &k8sPod{}
+	if err := json.Unmarshal(value, pod); err != nil {
+		return true
+	}
+	if len(s.selector) == 0 && !k8sRelated(pod.Metadata.Name, []string{k8sName(s.target)}) {
+		return true
+	}
+	s.mu.Lock()
+	s.pods[pod.Metadata.Name] = true
+	s.mu.Unlock()
+	for _, m := range podRestarts(pod, restarts, s.since) {
+		if !s.send(m) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *k8sStream) forwardEvent(value json.RawMessage, versions map[string]string) bool {
+	e := &k8sEvent{}
+	if err := json.Unmarshal(value, e); err != nil {
+		return true
+	}
+	// restarted watches list the existing events again
+	if versions[e.Metadata.Name] == e.Metadata.ResourceVersion {
+		return true
+	}
+	versions[e.Metadata.Name] = e.Metadata.ResourceVersion
+	if e.time().Before(s.since) {
+		return true
+	}
+	if len(s.target) > 0 || len(s.selector) > 0 {
+		s.mu.Lock()
+		roots := make([]string, 0, len(s.pods)+1)
+		for pod := range s.pods {
+			roots = append(roots, pod)
+		}
+		s.mu.Unlock()
+		if len(s.target) > 0 {
+			roots = append(roots, k8sName(s.target))
+		}
+		if !k8sRelated(e.InvolvedObject.Name, roots) {
+			return true
+		}
+	}
+	return s.send(massageK8sEvent(e))
+}
+
+func (s *k8sStream) send(m map[string]interface{}) bool {
+	b, _ := json.Marshal(m)
+	select {
+	case <-s.ctx.Done():
+		return false
+	case s.strChan <- string(b):
+		return true
+	}
+}
+
+func (s *k8sStream) pause() bool {
+	select {
+	case <-s.ctx.Done():
+		return false
+	case <-time.After(k8sRetryInterval):
+		return true
+	}
+}
+
+func (s *k8sStream) fail(err error) {
+	if s.onError != nil {
+		s.onError(err)
+	}
+}
+
+func (s *k8sStream) Close() {
+	s.closeOnce(func() {
+		s.cancel()
+		s.wg.Wait()
+		close(s.strChan)
+	})
+}
+
+// parseK8sLogLine structures a line of `kubectl logs --prefix --timestamps`
+// output, returning nil if the line does not follow that format.
+func parseK8sLogLine(line string) (map[string]interface{}, time.Time) {
+	match := k8sLogLineReg.FindStringSubmatch(line)
+	if match == nil {
+		return nil, time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, match[3])
+	if err != nil {
+		return nil, time.Time{}
+	}
+	m := map[string]interface{}{
+		"timestamp": t.Local().Format(time.RFC3339),
+		"kind":      "log",
+	}
+	if len(match[1]) > 0 {
+		m["pod"] = match[1]
+		m["container"] = match[2]
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal([]byte(match[4]), &payload); err == nil {
+		m["jsonPayload"] = payload
+	} else {
+		m["message"] = match[4]
+	}
+	return m, t
+}
+
+// k8sName strips the resource type off a kubectl target, e.g. deploy/api.
+func k8sName(target string) string {
+	return target[strings.LastIndex(target, "/")+1:]
+}
+
+// k8sRelated tells whether an object belongs to the same workload as any of the
+// roots, going by the generated names: a deployment "api" owns the replica set
+// "api-7d9c6", which owns the pod "api-7d9c6-x2tq".
+func k8sRelated(name string, roots []string) bool {
+	for _, root := range roots {
+		if name == root || strings.HasPrefix(name, root+"-") || strings.HasPrefix(root, name+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+type k8sEvent struct {
+	Metadata struct {
+		Name              string    `json:"name"`
+		ResourceVersion   string    `json:"resourceVersion"`
+		CreationTimestamp time.Time `json:"creationTimestamp"`
+	} `json:"metadata"`
+	InvolvedObject struct {
+		Kind string `json:"kind"`
+		Name string `json:"name"`
+	} `json:"involvedObject"`
+	Type          string    `json:"type"`
+	Reason        string    `json:"reason"`
+	Message       string    `json:"message"`
+	Count         int64     `json:"count"`
+	LastTimestamp time.Time `json:"lastTimestamp"`
+	EventTime     time.Time `json:"eventTime"`
+	Series        struct {
+		LastObservedTime time.Time `json:"lastObservedTime"`
+	} `json:"series"`
+	Source struct {
+		Component string `json:"component"`
+	} `json:"source"`
+	ReportingComponent string `json:"reportingComponent"`
+}
+
+// time returns when the event last occurred, as set by either the core/v1 or
+// the events.k8s.io reporters.
+func (e *k8sEvent) time() time.Time {
+	for _, t := range []time.Time{e.Series.LastObservedTime, e.LastTimestamp, e.EventTime} {
+		if !t.IsZero() {
+			return t
+		}
+	}
+	return e.Metadata.CreationTimestamp
+}
+
+func massageK8sEvent(e *k8sEvent) map[string]interface{} {
+	m := map[string]interface{}{
+		"timestamp": e.time().Local().Format(time.RFC3339),
+		"kind":      "event",
+		"severity":  "info",
+		"reason":    e.Reason,
+		"object":    e.InvolvedObject.Kind + "/" + e.InvolvedObject.Name,
+		"message":   strings.TrimSpace(e.Message),
+	}
+	if e.Type == "Warning" {
+		m["severity"] = "warning"
+	}
+	if e.InvolvedObject.Kind == "Pod" {
+		m["pod"] = e.InvolvedObject.Name
+	}
+	if len(e.Source.Component) > 0 {
+		m["component"] = e.Source.Component
+	} else if len(e.ReportingComponent) > 0 {
+		m["component"] = e.ReportingComponent
+	}
+	if e.Count > 1 {
+		m["count"] = e.Count
+	}
+	return m
+}
+
+type k8sPod struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Status struct {
+		ContainerStatuses []struct {
+			Name         string `json:"name"`
+			RestartCount int64  `json:"restartCount"`
+			LastState    struct {
+				Terminated *struct {
+					Reason     string    `json:"reason"`
+					ExitCode   int64     `json:"exitCode"`
+					FinishedAt time.Time `json:"finishedAt"`
+				} `json:"terminated"`
+			} `json:"lastState"`
+		} `json:"containerStatuses"`
+	} `json:"status"`
+}
+
+// podRestarts returns an entry for each container that restarted since the
+// last update of the pod, as per the restart counts kept in restarts. Restarts
+// before the pod was first seen are reported only if they happened after
+// since.
+func podRestarts(pod *k8sPod, restarts map[string]int64, since time.Time) []map[string]interface{} {
+	var entries []map[string]interface{}
+	for _, c := range pod.Status.ContainerStatuses {
+		key := pod.Metadata.Name + "/" + c.Name
+		prev, seen := restarts[key]
+		restarts[key] = c.RestartCount
+		if c.RestartCount == 0 || (seen && c.RestartCount <= prev) {
+			continue
+		}
+		t := c.LastState.Terminated
+		if !seen && (t == nil || t.FinishedAt.Before(since)) {
+			continue
+		}
+		m := map[string]interface{}{
+			"timestamp":    time.Now().Format(time.RFC3339),
+			"kind":         "restart",
+			"severity":     "error",
+			"pod":          pod.Metadata.Name,
+			"container":    c.Name,
+			"restartCount": c.RestartCount,
+			"message":      fmt.Sprintf("Container %s restarted", c.Name),
+		}
+		if t != nil {
+			if !t.FinishedAt.IsZero() {
+				m["timestamp"] = t.FinishedAt.Local().Format(time.RFC3339)
+			}
+			m["reason"] = t.Reason
+			m["exitCode"] = t.ExitCode
+			m["message"] = fmt.Sprintf("Container %s restarted (%s, exit code %d)", c.Name, t.Reason, t.ExitCode)
+			if t.ExitCode == 0 {
+				m["severity"] = "warning"
+			}
+		}
+		entries = append(entries, m)
+	}
+	return entries
+}
+
+// Details tells the workload or selector followed, the kubectl context and
+// namespace arguments, and how many pods were attached to so far.
+func (s *k8sStream) Details() []Detail {
+	details := []Detail{{Label: "Source", Value: "Kubernetes"}}
+	details = append(details, optional("Target", s.target)...)
+	details = append(details, optional("Selector", s.selector)...)
+	details = append(details, optional("Container", s.container)...)
+	details = append(details, optional("Arguments", strings.Join(s.args, " "))...)
+	s.mu.Lock()
+	pods := len(s.pods)
+	s.mu.Unlock()
+	details = append(details, Detail{Label: "Pods", Value: fmt.Sprintf("%d", pods)}, fromDetail(s.from))
+	if s.events {
+		details = append(details, Detail{Label: "Events", Value: "interleaved"})
+	}
+	return details
+}
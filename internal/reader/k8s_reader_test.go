@@ -0,0 +1,167 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestK8sArgs(t *testing.T) {
+	assert.Equal(t, []string{"--context", "prod", "--namespace", "shop"}, K8sArgs("prod", "shop"))
+	assert.Empty(t, K8sArgs("", ""))
+
+	s := MakeK8sReader(K8sArgs("", "shop"), "", "app=api", "", "tail", true, nil)
+	since := time.Date(2024, 1, 30, 15, 0, 0, 0, time.UTC)
+	assert.Equal(t, []string{"logs", "--follow", "--timestamps", "--prefix", "--namespace", "shop",
+		"--selector", "app=api", "--max-log-requests", "50", "--all-containers", "--tail", "0"},
+		s.logsArgs(since, true))
+	s = MakeK8sReader(nil, "deploy/api", "", "app", "", false, nil)
+	assert.Equal(t, []string{"logs", "--follow", "--timestamps", "--prefix", "deploy/api",
+		"--container", "app", "--since-time", "2024-01-30T15:00:00Z"},
+		s.logsArgs(since, false))
+}
+
+func TestParseK8sLogLine(t *testing.T) {
+	m, ts := parseK8sLogLine(`[pod/api-7d9c6-x2tq/app] 2024-01-30T15:00:00.123456789Z {"level":"info","msg":"ready"}`)
+	assert.Equal(t, time.Date(2024, 1, 30, 15, 0, 0, 123456789, time.UTC), ts)
+	assert.Equal(t, map[string]interface{}{
+		"timestamp":   ts.Local().Format(time.RFC3339),
+		"kind":        "log",
+		"pod":         "api-7d9c6-x2tq",
+		"container":   "app",
+		"jsonPayload": map[string]interface{}{"level": "info", "msg": "ready"},
+	}, m)
+
+	m, _ = parseK8sLogLine(`2024-01-30T15:00:00Z plain text`)
+	assert.Equal(t, "plain text", m["message"])
+	assert.Nil(t, m["pod"])
+
+	m, _ = parseK8sLogLine(`error: timed out waiting for the condition`)
+	assert.Nil(t, m)
+}
+
+func TestK8sRelated(t *testing.T) {
+	tests := []struct {
+		name  string
+		roots []string
+		want  bool
+	}{
+		{name: "api", roots: []string{"api"}, want: true},
+		{name: "api-7d9c6", roots: []string{"api"}, want: true},
+		{name: "api-7d9c6-x2tq", roots: []string{"api"}, want: true},
+		{name: "api-7d9c6", roots: []string{"api-7d9c6-x2tq"}, want: true},
+		{name: "web-5f4d8-k9zp", roots: []string{"api", "api-7d9c6-x2tq"}},
+		{name: "apiserver", roots: []string{"api"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, k8sRelated(test.name, test.roots))
+		})
+	}
+}
+
+func TestMassageK8sEvent(t *testing.T) {
+	e := &k8sEvent{}
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"metadata": {"name": "api-7d9c6-x2tq.17ae", "resourceVersion": "42", "creationTimestamp": "2024-01-30T14:00:00Z"},
+		"involvedObject": {"kind": "Pod", "name": "api-7d9c6-x2tq"},
+		"type": "Warning",
+		"reason": "BackOff",
+		"message": "Back-off restarting failed container app\n",
+		"count": 3,
+		"firstTimestamp": "2024-01-30T14:00:00Z",
+		"lastTimestamp": "2024-01-30T15:00:00Z",
+		"eventTime": null,
+		"source": {"component": "kubelet"}
+	}`), e))
+	ts := time.Date(2024, 1, 30, 15, 0, 0, 0, time.UTC)
+	assert.Equal(t, ts, e.time())
+	assert.Equal(t, map[string]interface{}{
+		"timestamp": ts.Local().Format(time.RFC3339),
+		"kind":      "event",
+		"severity":  "warning",
+		"reason":    "BackOff",
+		"object":    "Pod/api-7d9c6-x2tq",
+		"pod":       "api-7d9c6-x2tq",
+		"message":   "Back-off restarting failed container app",
+		"component": "kubelet",
+		"count":     int64(3),
+	}, massageK8sEvent(e))
+
+	e = &k8sEvent{}
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"metadata": {"name": "api.17af", "creationTimestamp": "2024-01-30T14:00:00Z"},
+		"involvedObject": {"kind": "Deployment", "name": "api"},
+		"type": "Normal",
+		"reason": "ScalingReplicaSet",
+		"eventTime": "2024-01-30T15:30:00.000001Z",
+		"reportingComponent": "deployment-controller"
+	}`), e))
+	m := massageK8sEvent(e)
+	assert.Equal(t, time.Date(2024, 1, 30, 15, 30, 0, 1000, time.UTC), e.time())
+	assert.Equal(t, "info", m["severity"])
+	assert.Equal(t, "deployment-controller", m["component"])
+	assert.Nil(t, m["pod"])
+	assert.Nil(t, m["count"])
+}
+
+func TestPodRestarts(t *testing.T) {
+	since := time.Date(2024, 1, 30, 15, 0, 0, 0, time.UTC)
+	pod := func(restarts int, reason string, exitCode int, finishedAt string) *k8sPod {
+		p := &k8sPod{}
+		assert.NoError(t, json.Unmarshal([]byte(`{"metadata":{"name":"api-7d9c6-x2tq"},"status":{"containerStatuses":[`+
+			`{"name":"app","restartCount":`+fmt.Sprint(restarts)+`,"lastState":{"terminated":`+
+			`{"reason":"`+reason+`","exitCode":`+fmt.Sprint(exitCode)+`,"finishedAt":"`+finishedAt+`"}}},`+
+			`{"name":"sidecar","restartCount":0,"lastState":{}}]}}`), p))
+		return p
+	}
+	restarts := make(map[string]int64)
+
+	// restarted before since when first seen
+	assert.Empty(t, podRestarts(pod(1, "Error", 1, "2024-01-30T14:00:00Z"), restarts, since))
+	// unchanged
+	assert.Empty(t, podRestarts(pod(1, "Error", 1, "2024-01-30T14:00:00Z"), restarts, since))
+
+	entries := podRestarts(pod(2, "OOMKilled", 137, "2024-01-30T15:10:00Z"), restarts, since)
+	assert.Equal(t, []map[string]interface{}{{
+		"timestamp":    time.Date(2024, 1, 30, 15, 10, 0, 0, time.UTC).Local().Format(time.RFC3339),
+		"kind":         "restart",
+		"severity":     "error",
+		"pod":          "api-7d9c6-x2tq",
+		"container":    "app",
+		"restartCount": int64(2),
+		"reason":       "OOMKilled",
+		"exitCode":     int64(137),
+		"message":      "Container app restarted (OOMKilled, exit code 137)",
+	}}, entries)
+
+	// restarted after since when first seen
+	entries = podRestarts(pod(1, "Completed", 0, "2024-01-30T15:05:00Z"), make(map[string]int64), since)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "warning", entries[0]["severity"])
+}
@@ -0,0 +1,523 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/badaniya/loggo/internal/aws"
+)
+
+const (
+	kinesisTargetPrefix = "Kinesis_20131202"
+	// kinesisPollInterval keeps each shard under the 5 GetRecords/sec limit.
+	kinesisPollInterval = 200 * time.Millisecond
+	kinesisIdleInterval = time.Second
+
+	iteratorLatest      = "LATEST"
+	iteratorTrimHorizon = "TRIM_HORIZON"
+	iteratorAtTimestamp = "AT_TIMESTAMP"
+	iteratorAfterSeq    = "AFTER_SEQUENCE_NUMBER"
+
+	FromTrimHorizon = "trim-horizon"
+)
+
+// kinesisRetryPolicy backs off shards exceeding their provisioned throughput
+// for as long as they do.
+var kinesisRetryPolicy = RetryPolicy{
+	Initial: 2 * kinesisPollInterval,
+	Max:     10 * time.Second,
+}
+
+type kinesisStream struct {
+	reader
+	retrying
+	client      *aws.Client
+	streamName  string
+	from        string
+	consumer    string
+	consumerARN string
+	registered  bool
+	ctx         context.Context
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+	shardsLock  sync.Mutex
+	shards      map[string]bool
+	errOnce     sync.Once
+}
+
+type shardPosition struct {
+	Type           string   `json:"Type"`
+	SequenceNumber string   `json:"SequenceNumber,omitempty"`
+	Timestamp      *float64 `json:"Timestamp,omitempty"`
+}
+
+type kinesisShard struct {
+	ShardId        string `json:"ShardId"`
+	ParentShardId  string `json:"ParentShardId,omitempty"`
+	SequenceNumber struct {
+		EndingSequenceNumber string `json:"EndingSequenceNumber,omitempty"`
+	} `json:"SequenceNumberRange"`
+}
+
+type kinesisChildShard struct {
+	ShardId string `json:"ShardId"`
+}
+
+type kinesisRecord struct {
+	SequenceNumber              string  `json:"SequenceNumber"`
+	ApproximateArrivalTimestamp float64 `json:"ApproximateArrivalTimestamp"`
+	PartitionKey                string  `json:"PartitionKey"`
+	Data                        []byte  `json:"Data"`
+}
+
+type getRecordsOutput struct {
+	Records            []kinesisRecord     `json:"Records"`
+	NextShardIterator  string              `json:"NextShardIterator"`
+	MillisBehindLatest int64               `json:"MillisBehindLatest"`
+	ChildShards        []kinesisChildShard `json:"ChildShards"`
+}
+
+type subscribeToShardEvent struct {
+	Records                    []kinesisRecord     `json:"Records"`
+	ContinuationSequenceNumber string              `json:"ContinuationSequenceNumber"`
+	ChildShards                []kinesisChildShard `json:"ChildShards"`
+}
+
+// cloudWatchLogsData is the (gzipped) payload delivered by a CloudWatch Logs
+// subscription filter into a Kinesis stream.
+type cloudWatchLogsData struct {
+	MessageType string `json:"messageType"`
+	LogGroup    string `json:"logGroup"`
+	LogStream   string `json:"logStream"`
+	LogEvents   []struct {
+		ID        string `json:"id"`
+		Timestamp int64  `json:"timestamp"`
+		Message   string `json:"message"`
+	} `json:"logEvents"`
+}
+
+// MakeKinesisReader builds a reader consuming every shard of an AWS Kinesis
+// data stream. The from parameter accepts "tail", "trim-horizon" or an
+// RFC3339 timestamp (see ParseFrom). If consumer is provided, records are
+// pushed through enhanced fan-out (SubscribeToShard) under that consumer name,
+// otherwise shards are polled with GetRecords.
+func MakeKinesisReader(client *aws.Client, streamName, from, consumer string, strChan chan string) *kinesisStream {
+	if strChan == nil {
+		strChan = make(chan string, 1)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &kinesisStream{
+		reader: reader{
+			strChan:    strChan,
+			readerType: TypeKinesis,
+		},
+		retrying:   retrying{policy: kinesisRetryPolicy},
+		client:     client,
+		streamName: streamName,
+		from:       from,
+		consumer:   consumer,
+		ctx:        ctx,
+		cancel:     cancel,
+		shards:     make(map[string]bool),
+	}
+}
+
+func (s *kinesisStream) StreamInto() error {
+	shards, err := s.listShards()
+	if err != nil {
+		return err
+	}
+	if len(s.consumer) > 0 {
+		if err := s.registerConsumer(); err != nil {
+			return err
+		}
+	}
+	closed := make(map[string]bool)
+	for _, shard := range shards {
+		if len(shard.SequenceNumber.EndingSequenceNumber) > 0 {
+			closed[shard.ShardId] = true
+		}
+	}
+	for _, shard := range shards {
+		// Closed shards only matter when reading history; their open children
+		// are started as soon as the parent is exhausted.
+		if closed[shard.ShardId] && s.from == "tail" {
+			continue
+		}
+		if len(shard.ParentShardId) > 0 && closed[shard.ParentShardId] && s.from != "tail" {
+			continue
+		}
+		s.startShard(shard.ShardId, s.initialPosition())
+	}
+	return nil
+}
+
+func (s *kinesisStream) initialPosition() shardPosition {
+	switch s.from {
+	case "tail":
+		return shardPosition{Type: iteratorLatest}
+	case FromTrimHorizon:
+		return shardPosition{Type: iteratorTrimHorizon}
+	}
+	t, err := time.Parse(time.RFC3339, s.from)
+	if err != nil {
+		return shardPosition{Type: iteratorLatest}
+	}
+	ts := float64(t.Unix())
+	return shardPosition{Type: iteratorAtTimestamp, Timestamp: &ts}
+}
+
+func (s *kinesisStream) startShard(shardID string, pos shardPosition) {
+	s.shardsLock.Lock()
+	defer s.shardsLock.Unlock()
+	if s.shards[shardID] {
+		return
+	}
+	s.shards[shardID] = true
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		var err error
+		if len(s.consumerARN) > 0 {
+			err = s.subscribeShard(shardID, pos)
+		} else {
+			err = s.pollShard(shardID, pos)
+		}
+		if err != nil && s.ctx.Err() == nil {
+			s.errOnce.Do(func() {
+				if s.onError != nil {
+					s.onError(fmt.Errorf("shard %s: %w", shardID, err))
+				}
+			})
+		}
+	}()
+}
+
+func (s *kinesisStream) startChildren(children []kinesisChildShard) {
+	for _, c := range children {
+		s.startShard(c.ShardId, shardPosition{Type: iteratorTrimHorizon})
+	}
+}
+
+func (s *kinesisStream) listShards() ([]kinesisShard, error) {
+	var shards []kinesisShard
+	in := map[string]interface{}{"StreamName": s.streamName}
+	for {
+		var out struct {
+			Shards    []kinesisShard `json:"Shards"`
+			NextToken string         `json:"NextToken"`
+		}
+		if err := s.client.Call(s.ctx, "ListShards", in, &out); err != nil {
+			return nil, err
+		}
+		shards = append(shards, out.Shards...)
+		if len(out.NextToken) == 0 {
+			return shards, nil
+		}
+		// StreamName and NextToken are mutually exclusive.
+		in = map[string]interface{}{"NextToken": out.NextToken}
+	}
+}
+
+func (s *kinesisStream) shardIterator(shardID string, pos shardPosition) (string, error) {
+	var out struct {
+		ShardIterator string `json:"ShardIterator"`
+	}
+	in := map[string]interface{}{
+		"StreamName":        s.streamName,
+		"ShardId":           shardID,
+		"ShardIteratorType": pos.Type,
+	}
+	if len(pos.SequenceNumber) > 0 {
+		in["StartingSequenceNumber"] = pos.SequenceNumber
+	}
+	if pos.Timestamp != nil {
+		in["Timestamp"] = *pos.Timestamp
+	}
+	err := s.client.Call(s.ctx, "GetShardIterator", in, &out)
+	return out.ShardIterator, err
+}
+
+func (s *kinesisStream) pollShard(shardID string, pos shardPosition) error {
+	iterator, err := s.shardIterator(shardID, pos)
+	if err != nil {
+		return err
+	}
+	failures := s.backoff()
+	for s.ctx.Err() == nil {
+		var out getRecordsOutput
+		err := s.client.Call(s.ctx, "GetRecords", map[string]interface{}{
+			"ShardIterator": iterator,
+			"Limit":         1000,
+		}, &out)
+		switch {
+		case aws.IsErrorType(err, "ExpiredIteratorException"):
+			if iterator, err = s.shardIterator(shardID, pos); err != nil {
+				return err
+			}
+			continue
+		case aws.IsErrorType(err, "ProvisionedThroughputExceededException") || transient(err):
+			if !failures.retry(s.ctx.Done(), err) {
+				return err
+			}
+			continue
+		case err != nil:
+			return err
+		}
+		failures.reset()
+		for _, r := range out.Records {
+			if !s.emit(shardID, r) {
+				return nil
+			}
+			pos = shardPosition{Type: iteratorAfterSeq, SequenceNumber: r.SequenceNumber}
+		}
+		if len(out.NextShardIterator) == 0 {
+			s.startChildren(out.ChildShards)
+			return nil
+		}
+		iterator = out.NextShardIterator
+		if len(out.Records) == 0 || out.MillisBehindLatest == 0 {
+			s.sleep(kinesisIdleInterval)
+		} else {
+			s.sleep(kinesisPollInterval)
+		}
+	}
+	return nil
+}
+
+func (s *kinesisStream) registerConsumer() error {
+	var summary struct {
+		StreamDescriptionSummary struct {
+			StreamARN string `json:"StreamARN"`
+		} `json:"StreamDescriptionSummary"`
+	}
+	if err := s.client.Call(s.ctx, "DescribeStreamSummary",
+		map[string]string{"StreamName": s.streamName}, &summary); err != nil {
+		return err
+	}
+	streamARN := summary.StreamDescriptionSummary.StreamARN
+
+	type consumer struct {
+		ConsumerARN    string `json:"ConsumerARN"`
+		ConsumerStatus string `json:"ConsumerStatus"`
+	}
+	var registered struct {
+		Consumer consumer `json:"Consumer"`
+	}
+	err := s.client.Call(s.ctx, "RegisterStreamConsumer", map[string]string{
+		"StreamARN":    streamARN,
+		"ConsumerName": s.consumer,
+	}, &registered)
+	if err != nil && !aws.IsErrorType(err, "ResourceInUseException") {
+		return err
+	}
+	s.registered = err == nil
+	c := registered.Consumer
+	for c.ConsumerStatus != "ACTIVE" {
+		if len(c.ConsumerStatus) > 0 {
+			s.sleep(2 * time.Second)
+		}
+		if s.ctx.Err() != nil {
+			return s.ctx.Err()
+		}
+		var described struct {
+			ConsumerDescription consumer `json:"ConsumerDescription"`
+		}
+		if err := s.client.Call(s.ctx, "DescribeStreamConsumer", map[string]string{
+			"StreamARN":    streamARN,
+			"ConsumerName": s.consumer,
+		}, &described); err != nil {
+			return err
+		}
+		c = described.ConsumerDescription
+	}
+	s.consumerARN = c.ConsumerARN
+	return nil
+}
+
+func (s *kinesisStream) subscribeShard(shardID string, pos shardPosition) error {
+	for s.ctx.Err() == nil {
+		resp, err := s.client.Do(s.ctx, "SubscribeToShard", map[string]interface{}{
+			"ConsumerARN":      s.consumerARN,
+			"ShardId":          shardID,
+			"StartingPosition": pos,
+		})
+		if aws.IsErrorType(err, "ResourceInUseException") ||
+			aws.IsErrorType(err, "LimitExceededException") {
+			// A previous subscription for this shard may still be winding down.
+			s.sleep(5 * time.Second)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		done, err := s.consumeSubscription(shardID, resp.Body, &pos)
+		resp.Body.Close()
+		if err != nil || done {
+			return err
+		}
+		// Subscriptions expire after 5 minutes; resume from the continuation.
+	}
+	return nil
+}
+
+func (s *kinesisStream) consumeSubscription(shardID string, body io.Reader, pos *shardPosition) (bool, error) {
+	events := aws.NewEventStreamReader(body)
+	for {
+		m, err := events.Next()
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if m.Header(":message-type") == "exception" {
+			return false, fmt.Errorf("%s: %s", m.Header(":exception-type"), string(m.Payload))
+		}
+		if m.Header(":event-type") != "SubscribeToShardEvent" {
+			continue
+		}
+		var event subscribeToShardEvent
+		if err := json.Unmarshal(m.Payload, &event); err != nil {
+			return false, err
+		}
+		for _, r := range event.Records {
+			if !s.emit(shardID, r) {
+				return true, nil
+			}
+		}
+		if len(event.ContinuationSequenceNumber) == 0 {
+			s.startChildren(event.ChildShards)
+			return true, nil
+		}
+		*pos = shardPosition{Type: iteratorAfterSeq, SequenceNumber: event.ContinuationSequenceNumber}
+	}
+}
+
+func (s *kinesisStream) emit(shardID string, r kinesisRecord) bool {
+	for _, line := range massageKinesisRecord(shardID, r) {
+		select {
+		case <-s.ctx.Done():
+			return false
+		case s.strChan <- line:
+		}
+	}
+	return true
+}
+
+func (s *kinesisStream) sleep(d time.Duration) {
+	select {
+	case <-s.ctx.Done():
+	case <-time.After(d):
+	}
+}
+
+// massageKinesisRecord turns a record into log lines. CloudWatch Logs
+// subscription payloads are unzipped and split into one line per log event;
+// any other payload is forwarded line by line as is.
+func massageKinesisRecord(shardID string, r kinesisRecord) []string {
+	data := r.Data
+	if len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b {
+		if gz, err := gzip.NewReader(bytes.NewReader(data)); err == nil {
+			if b, err := io.ReadAll(gz); err == nil {
+				data = b
+			}
+		}
+	}
+
+	var cw cloudWatchLogsData
+	if err := json.Unmarshal(data, &cw); err == nil && len(cw.MessageType) > 0 {
+		if cw.MessageType != "DATA_MESSAGE" {
+			return nil
+		}
+		lines := make([]string, 0, len(cw.LogEvents))
+		for _, e := range cw.LogEvents {
+			m := map[string]interface{}{
+				"timestamp": time.UnixMilli(e.Timestamp).Local().Format(time.RFC3339),
+				"logGroup":  cw.LogGroup,
+				"logStream": cw.LogStream,
+				"shardId":   shardID,
+			}
+			var msg map[string]interface{}
+			if err := json.Unmarshal([]byte(e.Message), &msg); err == nil {
+				m["jsonPayload"] = msg
+			} else {
+				m["textPayload"] = e.Message
+			}
+			b, _ := json.Marshal(m)
+			lines = append(lines, string(b))
+		}
+		return lines
+	}
+
+	var lines []string
+	for _, l := range strings.Split(strings.TrimRight(string(data), "\r\n"), "\n") {
+		if len(strings.TrimSpace(l)) > 0 {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+func (s *kinesisStream) Close() {
+	s.closeOnce(func() {
+		s.cancel()
+		s.wg.Wait()
+		if s.registered {
+			// Enhanced fan-out consumers are billed while registered.
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = s.client.Call(ctx, "DeregisterStreamConsumer",
+				map[string]string{"ConsumerARN": s.consumerARN}, nil)
+		}
+		close(s.strChan)
+	})
+}
+
+// Details tells the stream and region, how records are consumed and from
+// how many shards.
+func (s *kinesisStream) Details() []Detail {
+	details := []Detail{{Label: "Source", Value: "AWS Kinesis"}, {Label: "Stream", Value: s.streamName}}
+	if s.client != nil {
+		details = append(details, optional("Region", s.client.Region)...)
+	}
+	consumer := "polling GetRecords"
+	if len(s.consumer) > 0 {
+		consumer = fmt.Sprintf("enhanced fan-out as %s", s.consumer)
+	}
+	s.shardsLock.Lock()
+	shards := len(s.shards)
+	s.shardsLock.Unlock()
+	details = append(details, Detail{Label: "Consumer", Value: consumer},
+		Detail{Label: "Shards", Value: fmt.Sprintf("%d", shards)}, fromDetail(s.from))
+	return append(details, s.retrying.details()...)
+}
@@ -0,0 +1,124 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/badaniya/loggo/internal/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func gzipped(s string) []byte {
+	b := &bytes.Buffer{}
+	w := gzip.NewWriter(b)
+	_, _ = w.Write([]byte(s))
+	_ = w.Close()
+	return b.Bytes()
+}
+
+func TestMassageKinesisRecord(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  []byte
+		wants []string
+	}{
+		{
+			name:  "Plain JSON",
+			data:  []byte(`{"level":"info"}` + "\n"),
+			wants: []string{`{"level":"info"}`},
+		},
+		{
+			name:  "Multiple lines",
+			data:  []byte("one\ntwo\n\n"),
+			wants: []string{"one", "two"},
+		},
+		{
+			name:  "CloudWatch control message",
+			data:  gzipped(`{"messageType":"CONTROL_MESSAGE","logEvents":[]}`),
+			wants: nil,
+		},
+		{
+			name: "CloudWatch data message",
+			data: gzipped(`{"messageType":"DATA_MESSAGE","logGroup":"g","logStream":"s","logEvents":[` +
+				`{"id":"1","timestamp":0,"message":"{\"level\":\"warn\"}"},` +
+				`{"id":"2","timestamp":0,"message":"text"}]}`),
+			wants: []string{
+				`{"jsonPayload":{"level":"warn"},"logGroup":"g","logStream":"s","shardId":"shard-0",` +
+					`"timestamp":"` + time.UnixMilli(0).Local().Format(time.RFC3339) + `"}`,
+				`{"logGroup":"g","logStream":"s","shardId":"shard-0","textPayload":"text",` +
+					`"timestamp":"` + time.UnixMilli(0).Local().Format(time.RFC3339) + `"}`,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lines := massageKinesisRecord("shard-0", kinesisRecord{Data: test.data})
+			assert.Equal(t, test.wants, lines)
+		})
+	}
+}
+
+func TestKinesisStream_Poll(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256"))
+		switch strings.TrimPrefix(r.Header.Get("X-Amz-Target"), kinesisTargetPrefix+".") {
+		case "ListShards":
+			_, _ = w.Write([]byte(`{"Shards":[{"ShardId":"shard-0"}]}`))
+		case "GetShardIterator":
+			_, _ = w.Write([]byte(`{"ShardIterator":"it-0"}`))
+		case "GetRecords":
+			b, _ := json.Marshal(getRecordsOutput{
+				Records: []kinesisRecord{
+					{SequenceNumber: "1", Data: []byte(`{"n":1}`)},
+					{SequenceNumber: "2", Data: []byte(`{"n":2}`)},
+				},
+			})
+			_, _ = w.Write(b)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	client := aws.NewClient(&aws.Credentials{AccessKeyID: "id", SecretAccessKey: "secret"},
+		"us-east-1", "kinesis", kinesisTargetPrefix)
+	client.Endpoint = server.URL
+	client.HTTP = server.Client()
+
+	r := MakeKinesisReader(client, "stream", "tail", "", nil)
+	r.ErrorNotifier(func(err error) {
+		assert.NoError(t, err)
+	})
+	assert.NoError(t, r.StreamInto())
+	assert.Equal(t, `{"n":1}`, <-r.ChanReader())
+	assert.Equal(t, `{"n":2}`, <-r.ChanReader())
+	r.Close()
+}
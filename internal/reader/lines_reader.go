@@ -0,0 +1,88 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"context"
+	"strconv"
+)
+
+type sliceStream struct {
+	reader
+	bounds
+	lines   []string
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stopped chan struct{}
+}
+
+// MakeLinesReader builds a reader feeding the given lines, e.g. those of a
+// bundled sample log, and then idling until closed, as a tail would.
+func MakeLinesReader(lines []string, strChan chan string) *sliceStream {
+	if strChan == nil {
+		strChan = make(chan string, 1)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &sliceStream{
+		reader: reader{
+			strChan:    strChan,
+			readerType: TypeLines,
+		},
+		bounds:  bounds{loaded: make(chan struct{})},
+		lines:   lines,
+		ctx:     ctx,
+		cancel:  cancel,
+		stopped: make(chan struct{}),
+	}
+}
+
+func (s *sliceStream) StreamInto() error {
+	s.total.Store(int64(len(s.lines)))
+	if len(s.lines) == 0 {
+		s.markLoaded()
+	}
+	go func() {
+		defer close(s.stopped)
+		for _, line := range s.lines {
+			select {
+			case s.strChan <- line:
+				s.advance(1)
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *sliceStream) Close() {
+	s.closeOnce(func() {
+		s.cancel()
+		<-s.stopped
+		close(s.strChan)
+	})
+}
+
+func (s *sliceStream) Details() []Detail {
+	return []Detail{{Label: "Source", Value: "Lines"}, {Label: "Lines", Value: strconv.Itoa(len(s.lines))}}
+}
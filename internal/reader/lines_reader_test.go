@@ -0,0 +1,75 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinesStream(t *testing.T) {
+	r := MakeLinesReader([]string{`{"message":"first"}`, "second"}, nil)
+	assert.NoError(t, r.StreamInto())
+	var got []string
+	timeout := time.After(100 * time.Millisecond)
+loop:
+	for {
+		select {
+		case line := <-r.ChanReader():
+			got = append(got, line)
+		case <-timeout:
+			break loop
+		}
+	}
+	assert.Equal(t, []string{`{"message":"first"}`, "second"}, got)
+	r.Close()
+	_, ok := <-r.ChanReader()
+	assert.False(t, ok)
+}
+
+func TestLinesStream_CloseUnread(t *testing.T) {
+	r := MakeLinesReader([]string{"a", "b", "c"}, nil)
+	assert.NoError(t, r.StreamInto())
+	r.Close()
+}
+
+func TestLinesStream_Bounded(t *testing.T) {
+	r := MakeLinesReader([]string{"a", "b"}, nil)
+	var _ Bounded = r
+	assert.NoError(t, r.StreamInto())
+	_, total := r.Progress()
+	assert.EqualValues(t, 2, total)
+	<-r.ChanReader()
+	<-r.ChanReader()
+	select {
+	case <-r.Loaded():
+	case <-time.After(time.Second):
+		t.Fatal("not loaded once all lines were streamed")
+	}
+	read, _ := r.Progress()
+	assert.EqualValues(t, 2, read)
+	assert.False(t, r.Follows())
+	r.Close()
+}
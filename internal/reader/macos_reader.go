@@ -0,0 +1,202 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+const macOSTimeLayout = "2006-01-02 15:04:05.999999-0700"
+
+type macOSStream struct {
+	reader
+	args    []string
+	from    string
+	ctx     context.Context
+	cancel  context.CancelFunc
+	started bool
+	stopped chan struct{}
+}
+
+// MacOSLogArgs builds the `log` CLI filtering arguments. The process and
+// subsystem shortcuts are combined with the predicate, if any.
+func MacOSLogArgs(predicate, process, subsystem, level string) []string {
+	var predicates []string
+	if len(predicate) > 0 {
+		predicates = append(predicates, "("+predicate+")")
+	}
+	if len(process) > 0 {
+		predicates = append(predicates, fmt.Sprintf("process == %q", process))
+	}
+	if len(subsystem) > 0 {
+		predicates = append(predicates, fmt.Sprintf("subsystem == %q", subsystem))
+	}
+	var args []string
+	if len(predicates) > 0 {
+		args = append(args, "--predicate", strings.Join(predicates, " AND "))
+	}
+	if len(level) > 0 {
+		args = append(args, "--level", level)
+	}
+	return args
+}
+
+// MakeMacOSReader builds a reader over the macOS unified log, wrapping
+// `log stream --style ndjson` with the given filtering args (see MacOSLogArgs).
+// If from is an RFC3339 timestamp (see ParseFrom), `log show` first replays
+// the history since then.
+func MakeMacOSReader(args []string, from string, strChan chan string) *macOSStream {
+	if strChan == nil {
+		strChan = make(chan string, 1)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &macOSStream{
+		reader: reader{
+			strChan:    strChan,
+			readerType: TypeMacOS,
+		},
+		args:    args,
+		from:    from,
+		ctx:     ctx,
+		cancel:  cancel,
+		stopped: make(chan struct{}),
+	}
+}
+
+func (s *macOSStream) StreamInto() error {
+	var history *command
+	if s.from != "tail" {
+		t, err := time.Parse(time.RFC3339, s.from)
+		if err != nil {
+			return err
+		}
+		args := append([]string{"show", "--style", "ndjson", "--start", t.Local().Format("2006-01-02 15:04:05")},
+			showArgs(s.args)...)
+		if history, err = startCommand(s.ctx, "log", args...); err != nil {
+			return err
+		}
+	}
+	stream, err := startCommand(s.ctx, "log", append([]string{"stream", "--style", "ndjson"}, s.args...)...)
+	if err != nil {
+		return err
+	}
+	s.started = true
+	go func() {
+		defer close(s.stopped)
+		if history != nil {
+			if err := history.consume(s.forward); err != nil && s.ctx.Err() == nil && s.onError != nil {
+				s.onError(err)
+			}
+		}
+		err := stream.consume(s.forward)
+		if s.ctx.Err() == nil && s.onError != nil {
+			if err == nil {
+				err = fmt.Errorf("log stream ended")
+			}
+			s.onError(err)
+		}
+	}()
+	return nil
+}
+
+// showArgs adapts the stream args to `log show`, which selects levels with
+// --info/--debug flags.
+func showArgs(args []string) []string {
+	var out []string
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--level" && i+1 < len(args) {
+			switch args[i+1] {
+			case "debug":
+				out = append(out, "--info", "--debug")
+			case "info":
+				out = append(out, "--info")
+			}
+			i++
+			continue
+		}
+		out = append(out, args[i])
+	}
+	return out
+}
+
+func (s *macOSStream) forward(line string) bool {
+	m := massageMacOSEntry(line)
+	if m == nil {
+		return true
+	}
+	b, _ := json.Marshal(m)
+	select {
+	case <-s.ctx.Done():
+		return false
+	case s.strChan <- string(b):
+		return true
+	}
+}
+
+// massageMacOSEntry converts the timestamp and adds the process name and a
+// severity derived from the messageType. Banners and summaries printed by the
+// log CLI are skipped by returning nil.
+func massageMacOSEntry(line string) map[string]interface{} {
+	if !strings.HasPrefix(strings.TrimSpace(line), "{") {
+		return nil
+	}
+	m := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(line), &m); err != nil {
+		return nil
+	}
+	ts, ok := m["timestamp"].(string)
+	if !ok {
+		return nil
+	}
+	if t, err := time.Parse(macOSTimeLayout, ts); err == nil {
+		m["timestamp"] = t.Local().Format(time.RFC3339)
+	}
+	if p, ok := m["processImagePath"].(string); ok && len(p) > 0 {
+		m["process"] = path.Base(p)
+	}
+	if t, ok := m["messageType"].(string); ok {
+		m["severity"] = strings.ToLower(t)
+	}
+	return m
+}
+
+func (s *macOSStream) Close() {
+	s.closeOnce(func() {
+		s.cancel()
+		if s.started {
+			<-s.stopped
+		}
+		close(s.strChan)
+	})
+}
+
+func (s *macOSStream) Details() []Detail {
+	details := []Detail{{Label: "Source", Value: "macOS unified log"}}
+	details = append(details, optional("Arguments", strings.Join(s.args, " "))...)
+	return append(details, fromDetail(s.from))
+}
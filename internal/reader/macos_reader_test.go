@@ -0,0 +1,52 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMacOSLogArgs(t *testing.T) {
+	assert.Equal(t, []string{
+		"--predicate", `(eventMessage CONTAINS "error") AND process == "Safari" AND subsystem == "com.apple.network"`,
+		"--level", "debug",
+	}, MacOSLogArgs(`eventMessage CONTAINS "error"`, "Safari", "com.apple.network", "debug"))
+	assert.Empty(t, MacOSLogArgs("", "", "", ""))
+	assert.Equal(t, []string{"--predicate", "x", "--info", "--debug"},
+		showArgs([]string{"--predicate", "x", "--level", "debug"}))
+}
+
+func TestMassageMacOSEntry(t *testing.T) {
+	assert.Nil(t, massageMacOSEntry(`Filtering the log data using "process == \"Safari\""`))
+	assert.Nil(t, massageMacOSEntry(`{"count":12,"finished":1}`))
+
+	m := massageMacOSEntry(`{"timestamp":"2024-01-30 15:00:00.123456+0100","messageType":"Error",` +
+		`"processImagePath":"/Applications/Safari.app/Contents/MacOS/Safari","eventMessage":"boom"}`)
+	assert.Equal(t, time.Date(2024, 1, 30, 14, 0, 0, 0, time.UTC).Local().Format(time.RFC3339), m["timestamp"])
+	assert.Equal(t, "Safari", m["process"])
+	assert.Equal(t, "error", m["severity"])
+	assert.Equal(t, "boom", m["eventMessage"])
+}
@@ -24,6 +24,7 @@ package reader
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"time"
@@ -31,7 +32,8 @@ import (
 
 type readPipeStream struct {
 	reader
-	stop bool
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 func (s *readPipeStream) StreamInto() error {
@@ -46,17 +48,32 @@ func (s *readPipeStream) StreamInto() error {
 	reader := bufio.NewReader(os.Stdin)
 
 	go func() {
-		for !s.stop {
+		for s.ctx.Err() == nil {
 			str, err := reader.ReadString('\n')
 			if err != nil {
-				time.Sleep(time.Second)
+				select {
+				case <-s.ctx.Done():
+					return
+				case <-time.After(time.Second):
+				}
+			}
+			if !s.feed(s.ctx.Done(), str) {
+				return
 			}
-			s.strChan <- str
 		}
 	}()
 	return nil
 }
+
+// Close stops reading the standard input, abandoning a read blocked until
+// more input comes.
 func (s *readPipeStream) Close() {
-	s.stop = true
-	close(s.strChan)
+	s.closeOnce(func() {
+		s.cancel()
+		s.closeFeed()
+	})
+}
+
+func (s *readPipeStream) Details() []Detail {
+	return []Detail{{Label: "Source", Value: "Standard input"}}
 }
@@ -22,10 +22,17 @@ THE SOFTWARE.
 
 package reader
 
+import (
+	"context"
+	"sync"
+)
+
 type reader struct {
 	strChan    chan string
 	readerType Type
 	onError    func(err error)
+	closing    sync.Once
+	feeding    sync.RWMutex
 }
 
 type Type = int64
@@ -34,28 +41,53 @@ const (
 	TypeFile = Type(iota)
 	TypePipe
 	TypeGCP
+	TypeKinesis
+	TypeHeroku
+	TypeDatadog
+	TypeSplunk
+	TypeGraylog
+	TypeMacOS
+	TypeHTTP
+	TypeInternal
+	TypeSynthetic
+	TypeSocket
+	TypeSQLite
+	TypeK8s
+	TypeCommand
+	TypeBroadcast
+	TypeLines
 )
 
 // MakeReader builds a continues file/pipe streamer used to feed the logger. If
 // fileName is not provided, it will attempt to consume the input from the stdin.
+// Named pipes and unix sockets are read as they're written, being reopened
+// whenever their writer disconnects.
 func MakeReader(fileName string, strChan chan string) Reader {
 	if strChan == nil {
 		strChan = make(chan string, 1)
 	}
 	if len(fileName) > 0 {
+		if r := special(fileName, strChan); r != nil {
+			return r
+		}
 		return &fileStream{
 			reader: reader{
 				strChan:    strChan,
 				readerType: TypeFile,
 			},
+			bounds:   bounds{follows: true, loaded: make(chan struct{})},
 			fileName: fileName,
+			done:     make(chan struct{}),
 		}
 	}
+	ctx, cancel := context.WithCancel(context.Background())
 	return &readPipeStream{
 		reader: reader{
 			strChan:    strChan,
 			readerType: TypePipe,
 		},
+		ctx:    ctx,
+		cancel: cancel,
 	}
 }
 
@@ -71,13 +103,100 @@ func (s *reader) Type() Type {
 	return s.readerType
 }
 
+// feed sends the line to the channel unless done first, which it tells. The
+// channel being closed by closeFeed only, a producer that can't be waited
+// for, e.g. blocked reading the standard input, never sends on it once
+// closed.
+func (s *reader) feed(done <-chan struct{}, line string) bool {
+	s.feeding.RLock()
+	defer s.feeding.RUnlock()
+	select {
+	case <-done:
+		return false
+	default:
+	}
+	select {
+	case s.strChan <- line:
+		return true
+	case <-done:
+		return false
+	}
+}
+
+// closeFeed closes the channel once no line is being fed, done having been
+// closed beforehand, see feed.
+func (s *reader) closeFeed() {
+	s.feeding.Lock()
+	defer s.feeding.Unlock()
+	close(s.strChan)
+}
+
+// closeOnce runs the closing of the reader the first time only, so that Close
+// can be called more than once, e.g. upon Ctrl-C while the view closes it.
+func (s *reader) closeOnce(closing func()) {
+	s.closing.Do(closing)
+}
+
 type Reader interface {
 	// StreamInto feeds the strChan channel for every streamed line.
 	StreamInto() error
-	// Close finalises and invalidates this stream reader.
+	// Close finalises and invalidates this stream reader, once its producers
+	// stopped feeding the channel. It may be called more than once.
 	Close()
 	// ChanReader returns the outbound channel reader
 	ChanReader() <-chan string
 	// ErrorNotifier registers a callback func that's called upon fatal streaming log.
 	ErrorNotifier(onError func(err error))
 }
+
+// ServerFilterer is implemented by readers able to narrow their source query
+// server side, e.g. GCP, reducing the streamed volume.
+type ServerFilterer interface {
+	// ServerFilter restarts the stream applying the given source specific filter
+	// on top of the initial one. An empty filter restores the initial query.
+	ServerFilter(filter string) error
+}
+
+// Bounded is implemented by readers over sources of a known extent, e.g. a
+// whole file or a finished query, as opposed to live tails.
+type Bounded interface {
+	// Progress returns how much of the source was streamed so far out of its
+	// total, in bytes or lines, the total being 0 while unknown.
+	Progress() (read, total int64)
+	// Loaded is closed once the whole source, as it was when opened, has been
+	// streamed.
+	Loaded() <-chan struct{}
+	// Follows reports whether lines appended past the initial extent are
+	// streamed too, as a followed file's are, rather than the input ending.
+	Follows() bool
+}
+
+// Retrier is implemented by readers over network sources, retrying their
+// failed requests and connections as per a RetryPolicy.
+type Retrier interface {
+	// Retries returns how many times the source was retried so far.
+	Retries() int64
+	// Reconnecting reports whether the source is currently failing, waiting to
+	// be retried.
+	Reconnecting() bool
+}
+
+// Backfiller is implemented by readers started past the beginning of their
+// source, e.g. a file tailed from its last lines, fetching the older lines on
+// demand.
+type Backfiller interface {
+	// Backfill returns up to n lines preceding the oldest one streamed so far,
+	// in their order, none once the beginning of the source is reached.
+	Backfill(n int) ([]string, error)
+}
+
+// Preparser is implemented by readers able to parse lines ahead of their
+// consumer, e.g. by the workers loading a large file in parallel.
+type Preparser interface {
+	// ParseAhead has the lines parsed by parse before they're streamed, if set
+	// before StreamInto.
+	ParseAhead(parse func(line string) (map[string]interface{}, error))
+	// Parsed returns what the line just streamed was parsed into, nil fields
+	// if it wasn't parsed ahead.
+	Parsed(line string) (map[string]interface{}, error)
+}
@@ -0,0 +1,90 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"sync"
+	"time"
+
+	"github.com/badaniya/loggo/internal/util"
+)
+
+type recordingStream struct {
+	Reader
+	strChan chan string
+	ring    *RingFile
+	once    sync.Once
+	stopped chan struct{}
+}
+
+// WithRecording wraps a reader so every streamed line is also recorded into
+// the ring file, which is closed once the stream ends.
+func WithRecording(r Reader, ring *RingFile) Reader {
+	return &recordingStream{
+		Reader:  r,
+		strChan: make(chan string, 1),
+		ring:    ring,
+		stopped: make(chan struct{}),
+	}
+}
+
+func (s *recordingStream) StreamInto() error {
+	if err := s.Reader.StreamInto(); err != nil {
+		return err
+	}
+	go func() {
+		defer close(s.strChan)
+		defer s.ring.Close()
+		failed := false
+		for line := range s.Reader.ChanReader() {
+			if err := s.ring.Record(time.Now(), line); err != nil && !failed {
+				// keep streaming, the recording is secondary
+				util.Log().WithField("code", err).Error("Unable to record into the ring file")
+				failed = true
+			}
+			select {
+			case s.strChan <- line:
+			case <-s.stopped:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *recordingStream) Close() {
+	s.once.Do(func() {
+		close(s.stopped)
+	})
+	s.Reader.Close()
+}
+
+func (s *recordingStream) ChanReader() <-chan string {
+	return s.strChan
+}
+
+// Details tells the details of the wrapped reader and the file it's recorded
+// into.
+func (s *recordingStream) Details() []Detail {
+	return append(Describe(s.Reader), Detail{Label: "Recording", Value: s.ring.path})
+}
@@ -0,0 +1,53 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordingStream_StreamInto(t *testing.T) {
+	p := path.Join(t.TempDir(), "ring.log")
+	ring, err := OpenRingFile(p, 1024)
+	assert.NoError(t, err)
+	inner := &linesStream{
+		reader: reader{strChan: make(chan string, 1)},
+		lines:  []string{`{"a":1}`, `{"a":2}`},
+	}
+	r := WithRecording(inner, ring)
+	assert.NoError(t, r.StreamInto())
+	assert.Equal(t, `{"a":1}`, <-r.ChanReader())
+	assert.Equal(t, `{"a":2}`, <-r.ChanReader())
+	r.Close()
+	_, ok := <-r.ChanReader()
+	assert.False(t, ok)
+
+	sb := &strings.Builder{}
+	assert.NoError(t, ExportRing(p, time.Time{}, sb))
+	assert.Equal(t, "{\"a\":1}\n{\"a\":2}\n", sb.String())
+}
@@ -0,0 +1,164 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/badaniya/loggo/internal/util"
+)
+
+// RetryPolicy is how a network reader retries a failed request or
+// connection: pausing Initial after the first failure, doubling the pause
+// upon each consecutive one up to Max, and giving up after Attempts of them,
+// 0 retrying indefinitely.
+type RetryPolicy struct {
+	Initial  time.Duration
+	Max      time.Duration
+	Attempts int
+}
+
+// RetryOverride overrides the non-zero fields of the policy of the network
+// reader streamed, e.g. as per the command line flags.
+var RetryOverride RetryPolicy
+
+// defaultRetryPolicy applies to the network readers without a policy of
+// their own.
+var defaultRetryPolicy = RetryPolicy{
+	Initial:  time.Second,
+	Max:      time.Minute,
+	Attempts: 10,
+}
+
+// overriddenBy returns the policy with the non-zero fields of o instead.
+func (p RetryPolicy) overriddenBy(o RetryPolicy) RetryPolicy {
+	if o.Initial > 0 {
+		p.Initial = o.Initial
+	}
+	if o.Max > 0 {
+		p.Max = o.Max
+	}
+	if o.Attempts > 0 {
+		p.Attempts = o.Attempts
+	}
+	p.Max = max(p.Max, p.Initial)
+	return p
+}
+
+// statusError is a failed HTTP response of a source API.
+type statusError struct {
+	api  string
+	code int
+	body string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s failed (%d): %s", e.api, e.code, e.body)
+}
+
+// transient reports whether a failure may go away by retrying, i.e. network
+// errors and throttled or server side HTTP failures, as opposed to e.g.
+// rejected credentials or queries.
+func transient(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code == 429 || se.code/100 == 5
+	}
+	var ne net.Error
+	return errors.As(err, &ne) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// retrying retries the failures of a network source as per its policy,
+// accounting for them, see Retrier.
+type retrying struct {
+	policy  RetryPolicy
+	retries atomic.Int64
+	failing atomic.Int32
+}
+
+func (r *retrying) Retries() int64 {
+	return r.retries.Load()
+}
+
+func (r *retrying) Reconnecting() bool {
+	return r.failing.Load() > 0
+}
+
+// backoff returns the retry state of a request loop, concurrent loops, e.g.
+// one per shard, each having theirs.
+func (r *retrying) backoff() *backoff {
+	return &backoff{
+		retrying: r,
+		policy:   r.policy.overriddenBy(RetryOverride),
+		jitter: func(d time.Duration) time.Duration {
+			return d/2 + rand.N(d/2+1)
+		},
+	}
+}
+
+// backoff tracks the consecutive failures of a request loop.
+type backoff struct {
+	*retrying
+	policy   RetryPolicy
+	pause    time.Duration
+	failures int
+	jitter   func(time.Duration) time.Duration
+}
+
+// retry pauses before the request failing with err is retried, jittered so
+// that concurrent streams don't retry all at once, returning false when
+// giving up instead, either as the attempts are exhausted or done is closed.
+func (b *backoff) retry(done <-chan struct{}, err error) bool {
+	if b.policy.Attempts > 0 && b.failures >= b.policy.Attempts {
+		b.reset()
+		return false
+	}
+	if b.failures == 0 {
+		b.failing.Add(1)
+	}
+	b.failures++
+	b.retries.Add(1)
+	b.pause = min(max(b.pause*2, b.policy.Initial), b.policy.Max)
+	pause := b.jitter(b.pause)
+	util.Log().WithField("code", err).Warnf("Log source failed, retrying in %v (%d)", pause, b.failures)
+	select {
+	case <-done:
+		return false
+	case <-time.After(pause):
+		return true
+	}
+}
+
+// reset clears the failures once a request went through.
+func (b *backoff) reset() {
+	if b.failures > 0 {
+		b.failing.Add(-1)
+	}
+	b.failures, b.pause = 0, 0
+}
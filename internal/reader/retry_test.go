@@ -0,0 +1,119 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy_OverriddenBy(t *testing.T) {
+	p := RetryPolicy{Initial: time.Second, Max: time.Minute, Attempts: 10}
+	assert.Equal(t, p, p.overriddenBy(RetryPolicy{}))
+	assert.Equal(t, RetryPolicy{Initial: time.Second, Max: time.Minute, Attempts: 3},
+		p.overriddenBy(RetryPolicy{Attempts: 3}))
+	// the max pause is never below the initial one
+	assert.Equal(t, RetryPolicy{Initial: 2 * time.Minute, Max: 2 * time.Minute, Attempts: 10},
+		p.overriddenBy(RetryPolicy{Initial: 2 * time.Minute}))
+}
+
+func TestTransient(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&statusError{"search", 429, "slow down"}, true},
+		{&statusError{"search", 503, "unavailable"}, true},
+		{fmt.Errorf("polling: %w", &statusError{"search", 502, ""}), true},
+		{&statusError{"search", 401, "unauthorized"}, false},
+		{&statusError{"search", 400, "bad query"}, false},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, true},
+		{io.ErrUnexpectedEOF, true},
+		{errors.New("bad query"), false},
+		{nil, false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, transient(test.err), "%v", test.err)
+	}
+	assert.Equal(t, "search failed (400): bad query", (&statusError{"search", 400, "bad query"}).Error())
+}
+
+func TestBackoff(t *testing.T) {
+	r := &retrying{policy: RetryPolicy{Initial: time.Millisecond, Max: 4 * time.Millisecond, Attempts: 5}}
+	b := r.backoff()
+	var pauses []time.Duration
+	b.jitter = func(d time.Duration) time.Duration {
+		pauses = append(pauses, d)
+		return 0
+	}
+	done := make(chan struct{})
+	err := errors.New("unavailable")
+
+	assert.False(t, r.Reconnecting())
+	for i := 0; i < 5; i++ {
+		assert.True(t, b.retry(done, err))
+		assert.True(t, r.Reconnecting())
+	}
+	// doubling the pause up to the max, then giving up
+	assert.Equal(t, []time.Duration{1, 2, 4, 4, 4}, scaled(pauses, time.Millisecond))
+	assert.False(t, b.retry(done, err))
+	assert.False(t, r.Reconnecting())
+	assert.Equal(t, int64(5), r.Retries())
+
+	// a request going through resets the pause
+	pauses = nil
+	assert.True(t, b.retry(done, err))
+	b.reset()
+	assert.False(t, r.Reconnecting())
+	assert.True(t, b.retry(done, err))
+	assert.Equal(t, []time.Duration{1, 1}, scaled(pauses, time.Millisecond))
+	assert.Equal(t, int64(7), r.Retries())
+
+	// no retry once done
+	close(done)
+	b.jitter = func(d time.Duration) time.Duration { return time.Minute }
+	assert.False(t, b.retry(done, err))
+}
+
+func TestBackoff_Override(t *testing.T) {
+	defer func(o RetryPolicy) { RetryOverride = o }(RetryOverride)
+	RetryOverride = RetryPolicy{Attempts: 1}
+	r := &retrying{policy: RetryPolicy{Initial: time.Millisecond, Max: time.Millisecond}}
+	b := r.backoff()
+	assert.True(t, b.retry(nil, errors.New("unavailable")))
+	assert.False(t, b.retry(nil, errors.New("unavailable")))
+}
+
+func scaled(durations []time.Duration, unit time.Duration) []time.Duration {
+	var out []time.Duration
+	for _, d := range durations {
+		out = append(out, d/unit)
+	}
+	return out
+}
@@ -0,0 +1,167 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ringPreviousSuffix names the segment holding the older half of a ring file.
+const ringPreviousSuffix = ".1"
+
+// RingFile keeps the latest streamed lines on disk within a bounded size,
+// regardless of how many entries are kept in memory. It's made of two
+// segments of half the size each: once the current one is full it replaces
+// the previous one, so between half and the whole size is always retained.
+// Each line is prefixed with the time it was received, see ExportRing.
+type RingFile struct {
+	lock    sync.Mutex
+	path    string
+	segment int64
+	written int64
+	file    *os.File
+}
+
+// OpenRingFile opens (or resumes) the ring file at path bounded to size bytes.
+func OpenRingFile(path string, size int64) (*RingFile, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid ring file size %d", size)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &RingFile{
+		path:    path,
+		segment: size / 2,
+		written: st.Size(),
+		file:    f,
+	}, nil
+}
+
+// Record appends the line received at the given time, rotating the segments
+// when the current one is full.
+func (r *RingFile) Record(at time.Time, line string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.file == nil {
+		return os.ErrClosed
+	}
+	record := fmt.Sprintf("%d\t%s\n", at.UnixNano(), strings.ReplaceAll(line, "\n", " "))
+	if r.written > 0 && r.written+int64(len(record)) > r.segment {
+		if err := r.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := r.file.WriteString(record)
+	r.written += int64(n)
+	return err
+}
+
+func (r *RingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(r.path, r.path+ringPreviousSuffix); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		r.file = nil
+		return err
+	}
+	r.file = f
+	r.written = 0
+	return nil
+}
+
+// Close closes the ring file, keeping its contents for ExportRing.
+func (r *RingFile) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// ExportRing writes, oldest first, the lines of the ring file at path received
+// since the given time (all of them if zero), without their time prefix.
+func ExportRing(path string, since time.Time, w io.Writer) error {
+	found := false
+	for _, p := range []string{path + ringPreviousSuffix, path} {
+		f, err := os.Open(p)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		found = true
+		err = exportSegment(f, since, w)
+		_ = f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	if !found {
+		return fmt.Errorf("no ring file found at %s", path)
+	}
+	return nil
+}
+
+func exportSegment(f *os.File, since time.Time, w io.Writer) error {
+	br := bufio.NewReader(f)
+	for {
+		record, err := br.ReadString('\n')
+		if len(record) > 0 {
+			if ts, line, ok := strings.Cut(strings.TrimSuffix(record, "\n"), "\t"); ok {
+				nanos, perr := strconv.ParseInt(ts, 10, 64)
+				if perr == nil && (since.IsZero() || !time.Unix(0, nanos).Before(since)) {
+					if _, werr := io.WriteString(w, line+"\n"); werr != nil {
+						return werr
+					}
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
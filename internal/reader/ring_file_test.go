@@ -0,0 +1,94 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRingFile_Rotation(t *testing.T) {
+	p := path.Join(t.TempDir(), "ring.log")
+	ring, err := OpenRingFile(p, 200)
+	assert.NoError(t, err)
+	start := time.Unix(1700000000, 0)
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, ring.Record(start.Add(time.Duration(i)*time.Second), fmt.Sprintf(`{"n":%d}`, i)))
+	}
+	assert.NoError(t, ring.Close())
+	assert.ErrorIs(t, ring.Record(start, "closed"), os.ErrClosed)
+
+	for _, p := range []string{p, p + ringPreviousSuffix} {
+		st, err := os.Stat(p)
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, st.Size(), int64(100))
+	}
+
+	sb := &strings.Builder{}
+	assert.NoError(t, ExportRing(p, time.Time{}, sb))
+	lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+	assert.Greater(t, len(lines), 3)
+	// the latest lines are kept, oldest first
+	assert.Equal(t, `{"n":19}`, lines[len(lines)-1])
+	for i := 1; i < len(lines); i++ {
+		assert.Less(t, lines[i-1], lines[i])
+	}
+}
+
+func TestExportRing_Since(t *testing.T) {
+	p := path.Join(t.TempDir(), "ring.log")
+	ring, err := OpenRingFile(p, 1024*1024)
+	assert.NoError(t, err)
+	start := time.Unix(1700000000, 0)
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, ring.Record(start.Add(time.Duration(i)*time.Minute), fmt.Sprintf("line\n%d", i)))
+	}
+	assert.NoError(t, ring.Close())
+
+	tests := []struct {
+		name     string
+		since    time.Time
+		expected string
+	}{
+		{"all", time.Time{}, "line 0\nline 1\nline 2\nline 3\nline 4\n"},
+		{"since", start.Add(3 * time.Minute), "line 3\nline 4\n"},
+		{"none", start.Add(time.Hour), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sb := &strings.Builder{}
+			assert.NoError(t, ExportRing(p, tt.since, sb))
+			assert.Equal(t, tt.expected, sb.String())
+		})
+	}
+}
+
+func TestExportRing_Missing(t *testing.T) {
+	assert.Error(t, ExportRing(path.Join(t.TempDir(), "none.log"), time.Time{}, &strings.Builder{}))
+}
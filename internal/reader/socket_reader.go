@@ -0,0 +1,171 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/badaniya/loggo/internal/util"
+)
+
+// reopenInterval is how long to wait before reopening a named pipe or unix
+// socket whose writer went away.
+const reopenInterval = time.Second
+
+// socketStream streams lines out of a named pipe (FIFO) or a unix domain
+// socket, reopening it every time the writer disconnects so long-running
+// daemons can be tailed across their restarts.
+type socketStream struct {
+	reader
+	fileName string
+	open     func(name string) (io.ReadCloser, error)
+	reopen   time.Duration
+	lock     sync.Mutex
+	conn     io.ReadCloser
+	done     chan struct{}
+}
+
+// special returns a reader for fileName when it's a named pipe or a unix
+// socket, otherwise nil.
+func special(fileName string, strChan chan string) Reader {
+	fi, err := os.Stat(fileName)
+	if err != nil {
+		return nil
+	}
+	s := &socketStream{
+		reader:   reader{strChan: strChan},
+		fileName: fileName,
+		reopen:   reopenInterval,
+		done:     make(chan struct{}),
+	}
+	switch mode := fi.Mode(); {
+	case mode&os.ModeNamedPipe != 0:
+		s.readerType = TypePipe
+		s.open = openFIFO
+	case mode&os.ModeSocket != 0:
+		s.readerType = TypeSocket
+		s.open = dialSocket
+	default:
+		return nil
+	}
+	return s
+}
+
+// openFIFO opens a named pipe for reading, blocking until a writer shows up.
+func openFIFO(name string) (io.ReadCloser, error) {
+	return os.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// dialSocket connects to the unix stream socket the daemon is listening on.
+func dialSocket(name string) (io.ReadCloser, error) {
+	return net.Dial("unix", name)
+}
+
+func (s *socketStream) StreamInto() error {
+	if _, err := os.Stat(s.fileName); err != nil {
+		return err
+	}
+	go func() {
+		for !s.stopped() {
+			if err := s.consume(); err != nil && !s.stopped() {
+				util.Log().WithField("code", err).Error("Log source disconnected, reopening")
+			}
+			select {
+			case <-s.done:
+				return
+			case <-time.After(s.reopen):
+			}
+		}
+	}()
+	return nil
+}
+
+// consume opens the source and feeds its lines until the writer disconnects.
+func (s *socketStream) consume() error {
+	conn, err := s.open(s.fileName)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	if s.stopped() {
+		s.lock.Unlock()
+		return conn.Close()
+	}
+	s.conn = conn
+	s.lock.Unlock()
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if !s.feed(s.done, scanner.Text()) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+func (s *socketStream) stopped() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close disconnects from the source, abandoning an open still waiting for a
+// writer.
+func (s *socketStream) Close() {
+	s.closeOnce(func() {
+		s.lock.Lock()
+		close(s.done)
+		if s.conn != nil {
+			_ = s.conn.Close()
+		}
+		s.lock.Unlock()
+		s.closeFeed()
+	})
+}
+
+// Details tells the path of the named pipe or socket, and whether its writer
+// is connected.
+func (s *socketStream) Details() []Detail {
+	kind := "Named pipe"
+	if s.readerType == TypeSocket {
+		kind = "Unix socket"
+	}
+	s.lock.Lock()
+	connected := s.conn != nil
+	s.lock.Unlock()
+	status := "waiting for a writer"
+	if connected {
+		status = "connected"
+	}
+	return []Detail{{Label: "Source", Value: kind}, {Label: "Path", Value: s.fileName}, {Label: "Status", Value: status}}
+}
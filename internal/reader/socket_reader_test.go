@@ -0,0 +1,111 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSocketStream_StreamInto(t *testing.T) {
+	tests := []struct {
+		name   string
+		typ    Type
+		listen func(t *testing.T, path string) func(lines ...string)
+	}{
+		{
+			name: "named pipe reopened after writer disconnects",
+			typ:  TypePipe,
+			listen: func(t *testing.T, path string) func(lines ...string) {
+				assert.NoError(t, syscall.Mkfifo(path, 0600))
+				return func(lines ...string) {
+					w, err := os.OpenFile(path, os.O_WRONLY, 0)
+					assert.NoError(t, err)
+					for _, l := range lines {
+						_, _ = fmt.Fprintln(w, l)
+					}
+					assert.NoError(t, w.Close())
+				}
+			},
+		},
+		{
+			name: "unix socket reconnected after writer disconnects",
+			typ:  TypeSocket,
+			listen: func(t *testing.T, path string) func(lines ...string) {
+				l, err := net.Listen("unix", path)
+				assert.NoError(t, err)
+				t.Cleanup(func() { _ = l.Close() })
+				return func(lines ...string) {
+					c, err := l.Accept()
+					assert.NoError(t, err)
+					for _, l := range lines {
+						_, _ = fmt.Fprintln(c, l)
+					}
+					assert.NoError(t, c.Close())
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "log.sock")
+			write := test.listen(t, path)
+			strChan := make(chan string, 1)
+			r := MakeReader(path, strChan)
+			assert.Equal(t, test.typ, r.(*socketStream).Type())
+			r.(*socketStream).reopen = 10 * time.Millisecond
+			assert.NoError(t, r.StreamInto())
+
+			go func() {
+				write("first 1", "first 2")
+				write("second 1")
+			}()
+			var lines []string
+			timeout := time.After(5 * time.Second)
+			for len(lines) < 3 {
+				select {
+				case line := <-strChan:
+					lines = append(lines, line)
+				case <-timeout:
+					t.Fatalf("timed out, got %v", lines)
+				}
+			}
+			r.Close()
+			assert.Equal(t, []string{"first 1", "first 2", "second 1"}, lines)
+		})
+	}
+}
+
+func TestMakeReader_RegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	assert.NoError(t, os.WriteFile(path, nil, 0600))
+	assert.Nil(t, special(path, nil))
+	assert.Nil(t, special(path+".missing", nil))
+}
@@ -0,0 +1,279 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const splunkExportPath = "/services/search/v2/jobs/export"
+
+type splunkStream struct {
+	reader
+	retrying
+	baseURL string
+	token   string
+	search  string
+	from    string
+	http    *http.Client
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+type splunkExportRow struct {
+	Preview bool                   `json:"preview"`
+	Result  map[string]interface{} `json:"result"`
+	LastRow bool                   `json:"lastrow"`
+	// Messages carries search errors/warnings, e.g. a malformed query.
+	Messages []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"messages"`
+}
+
+// MakeSplunkReader builds a reader streaming results of a Splunk export job
+// from the management endpoint (e.g. https://splunk:8089). The from parameter
+// accepts "tail" or an RFC3339 timestamp (see ParseFrom); once the history is
+// exported, new events keep streaming through a real-time search.
+func MakeSplunkReader(baseURL, token, search, from string, insecure bool, strChan chan string) *splunkStream {
+	if strChan == nil {
+		strChan = make(chan string, 1)
+	}
+	client := http.DefaultClient
+	if insecure {
+		// Splunk management ports commonly use self-signed certificates.
+		client = &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &splunkStream{
+		reader: reader{
+			strChan:    strChan,
+			readerType: TypeSplunk,
+		},
+		retrying: retrying{policy: defaultRetryPolicy},
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		token:    token,
+		search:   search,
+		from:     from,
+		http:     client,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// SplunkSearch composes an SPL search from the index/sourcetype filters and an
+// optional query, e.g. `search index="web" sourcetype="access_combined" status=500`.
+func SplunkSearch(index, sourceType, query string) string {
+	parts := []string{"search"}
+	if len(index) > 0 {
+		parts = append(parts, fmt.Sprintf("index=%q", index))
+	}
+	if len(sourceType) > 0 {
+		parts = append(parts, fmt.Sprintf("sourcetype=%q", sourceType))
+	}
+	query = strings.TrimSpace(query)
+	query = strings.TrimSpace(strings.TrimPrefix(query, "search "))
+	if len(query) > 0 {
+		parts = append(parts, query)
+	}
+	return strings.Join(parts, " ")
+}
+
+func (s *splunkStream) StreamInto() error {
+	var body io.ReadCloser
+	var err error
+	realtime := s.from == "tail"
+	if realtime {
+		body, err = s.export(splunkRealtime())
+	} else {
+		t, perr := time.Parse(time.RFC3339, s.from)
+		if perr != nil {
+			return perr
+		}
+		body, err = s.export(url.Values{
+			"earliest_time": {fmt.Sprintf("%d", t.Unix())},
+			"latest_time":   {"now"},
+		})
+	}
+	if err != nil {
+		return err
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		err := s.consume(body)
+		if realtime && err == nil {
+			err = errSplunkEnded
+		}
+		if realtime || err == nil {
+			// continue with a real-time search once the history is exported
+			err = s.streamRealtime(err)
+		}
+		if err != nil && s.ctx.Err() == nil && s.onError != nil {
+			s.onError(err)
+		}
+	}()
+	return nil
+}
+
+// errSplunkEnded is how a real-time search ends once Splunk drops it.
+var errSplunkEnded = errors.New("splunk search ended")
+
+func splunkRealtime() url.Values {
+	return url.Values{"earliest_time": {"rt"}, "latest_time": {"rt"}, "search_mode": {"realtime"}}
+}
+
+// streamRealtime keeps a real-time search streaming, searching again as per
+// the retry policy whenever the previous search, which ended with err, drops.
+func (s *splunkStream) streamRealtime(err error) error {
+	failures := s.backoff()
+	for s.ctx.Err() == nil {
+		if err != nil && !((transient(err) || err == errSplunkEnded) && failures.retry(s.ctx.Done(), err)) {
+			return err
+		}
+		var body io.ReadCloser
+		if body, err = s.export(splunkRealtime()); err != nil {
+			continue
+		}
+		failures.reset()
+		if err = s.consume(body); err == nil {
+			err = errSplunkEnded
+		}
+	}
+	return nil
+}
+
+func (s *splunkStream) export(params url.Values) (io.ReadCloser, error) {
+	params.Set("search", s.search)
+	params.Set("output_mode", "json")
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, s.baseURL+splunkExportPath,
+		strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return nil, &statusError{"splunk export", resp.StatusCode, strings.TrimSpace(string(b))}
+	}
+	return resp.Body, nil
+}
+
+func (s *splunkStream) consume(body io.ReadCloser) error {
+	defer body.Close()
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+		var row splunkExportRow
+		if err := json.Unmarshal([]byte(line), &row); err != nil {
+			return err
+		}
+		for _, m := range row.Messages {
+			if m.Type == "FATAL" || m.Type == "ERROR" {
+				return fmt.Errorf("splunk: %s", m.Text)
+			}
+		}
+		if row.Result == nil {
+			continue
+		}
+		b, _ := json.Marshal(massageSplunkResult(row.Result))
+		select {
+		case <-s.ctx.Done():
+			return nil
+		case s.strChan <- string(b):
+		}
+	}
+	if s.ctx.Err() != nil {
+		return nil
+	}
+	return scanner.Err()
+}
+
+// massageSplunkResult keeps the default Splunk fields and any extracted field,
+// dropping the internal "_" prefixed ones. The raw event is exposed as
+// jsonPayload when it's JSON, otherwise as message.
+func massageSplunkResult(result map[string]interface{}) map[string]interface{} {
+	m := make(map[string]interface{})
+	for k, v := range result {
+		if !strings.HasPrefix(k, "_") {
+			m[k] = v
+		}
+	}
+	if ts, ok := result["_time"].(string); ok {
+		m["timestamp"] = ts
+		for _, layout := range []string{"2006-01-02T15:04:05.000-07:00", time.RFC3339Nano} {
+			if t, err := time.Parse(layout, ts); err == nil {
+				m["timestamp"] = t.Local().Format(time.RFC3339)
+				break
+			}
+		}
+	}
+	if raw, ok := result["_raw"].(string); ok {
+		var payload map[string]interface{}
+		if err := json.Unmarshal([]byte(raw), &payload); err == nil {
+			m["jsonPayload"] = payload
+		} else {
+			m["message"] = raw
+		}
+	}
+	return m
+}
+
+func (s *splunkStream) Close() {
+	s.closeOnce(func() {
+		s.cancel()
+		s.wg.Wait()
+		close(s.strChan)
+	})
+}
+
+// Details tells the server and search, never the token.
+func (s *splunkStream) Details() []Detail {
+	details := []Detail{{Label: "Source", Value: "Splunk"}, {Label: "URL", Value: s.baseURL},
+		{Label: "Search", Value: s.search}, fromDetail(s.from)}
+	return append(details, s.retrying.details()...)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplunkSearch(t *testing.T) {
+	tests := []struct {
+		name       string
+		index      string
+		sourceType string
+		query      string
+		wants      string
+	}{
+		{
+			name:  "Index only",
+			index: "web",
+			wants: `search index="web"`,
+		},
+		{
+			name:       "Index, sourcetype and query",
+			index:      "web",
+			sourceType: "access_combined",
+			query:      "search status=500",
+			wants:      `search index="web" sourcetype="access_combined" status=500`,
+		},
+		{
+			name:  "Query only",
+			query: "error OR fatal",
+			wants: `search error OR fatal`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.wants, SplunkSearch(test.index, test.sourceType, test.query))
+		})
+	}
+}
+
+func TestMassageSplunkResult(t *testing.T) {
+	m := massageSplunkResult(map[string]interface{}{
+		"_raw":       `{"level":"error"}`,
+		"_time":      "2024-01-30T15:00:00.000+00:00",
+		"_cd":        "1:2",
+		"host":       "web-1",
+		"sourcetype": "_json",
+	})
+	assert.Equal(t, map[string]interface{}{
+		"timestamp":   time.Date(2024, 1, 30, 15, 0, 0, 0, time.UTC).Local().Format(time.RFC3339),
+		"host":        "web-1",
+		"sourcetype":  "_json",
+		"jsonPayload": map[string]interface{}{"level": "error"},
+	}, m)
+
+	m = massageSplunkResult(map[string]interface{}{"_raw": "plain text"})
+	assert.Equal(t, "plain text", m["message"])
+}
+
+func TestSplunkStream_StreamInto(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, splunkExportPath, r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, `search index="web"`, r.Form.Get("search"))
+		assert.Equal(t, "json", r.Form.Get("output_mode"))
+		if r.Form.Get("search_mode") == "realtime" {
+			_, _ = fmt.Fprintln(w, `{"preview":false,"result":{"_raw":"live"}}`)
+			return
+		}
+		_, _ = fmt.Fprintln(w, `{"preview":false,"offset":0,"result":{"_raw":"historic"}}`)
+		_, _ = fmt.Fprintln(w, `{"preview":false,"offset":1,"lastrow":true}`)
+	}))
+	defer server.Close()
+
+	s := MakeSplunkReader(server.URL, "token", SplunkSearch("web", "", ""),
+		time.Now().Add(-time.Hour).Format(time.RFC3339), false, nil)
+	assert.NoError(t, s.StreamInto())
+	assert.Contains(t, <-s.ChanReader(), `"message":"historic"`)
+	assert.Contains(t, <-s.ChanReader(), `"message":"live"`)
+	s.Close()
+}
@@ -0,0 +1,104 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/badaniya/loggo/internal/sqlite"
+)
+
+type sqliteStream struct {
+	reader
+	bounds
+	dbFile  string
+	ctx     context.Context
+	cancel  context.CancelFunc
+	started bool
+	stopped chan struct{}
+}
+
+// MakeSQLiteReader reads back, in their original order, the lines exported
+// into the SQLite database at dbFile by sqlite.Export.
+func MakeSQLiteReader(dbFile string, strChan chan string) *sqliteStream {
+	if strChan == nil {
+		strChan = make(chan string, 1)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &sqliteStream{
+		reader: reader{
+			strChan:    strChan,
+			readerType: TypeSQLite,
+		},
+		bounds:  bounds{loaded: make(chan struct{})},
+		dbFile:  dbFile,
+		ctx:     ctx,
+		cancel:  cancel,
+		stopped: make(chan struct{}),
+	}
+}
+
+func (s *sqliteStream) StreamInto() error {
+	c, err := startCommand(s.ctx, sqlite.CLI, "-batch", "-readonly", "-noheader", "-list", s.dbFile,
+		fmt.Sprintf("SELECT raw FROM %s ORDER BY id;", sqlite.Table))
+	if err != nil {
+		return err
+	}
+	s.started = true
+	go func() {
+		defer close(s.stopped)
+		err := c.consume(func(line string) bool {
+			select {
+			case <-s.ctx.Done():
+				return false
+			case s.strChan <- line:
+				s.advance(1)
+				return true
+			}
+		})
+		if err != nil && s.ctx.Err() == nil && s.onError != nil {
+			s.onError(err)
+		} else if err == nil {
+			s.markLoaded()
+		}
+	}()
+	return nil
+}
+
+func (s *sqliteStream) Close() {
+	s.closeOnce(func() {
+		s.cancel()
+		if s.started {
+			// the query may still be sending lines, closing strChan before it
+			// returns would panic
+			<-s.stopped
+		}
+		close(s.strChan)
+	})
+}
+
+func (s *sqliteStream) Details() []Detail {
+	details := []Detail{{Label: "Source", Value: "SQLite export"}, {Label: "Path", Value: s.dbFile}}
+	return append(details, fileDetails(s.dbFile)...)
+}
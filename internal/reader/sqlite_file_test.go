@@ -0,0 +1,76 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/sqlite"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLiteRoundTrip(t *testing.T) {
+	if _, err := exec.LookPath(sqlite.CLI); err != nil {
+		t.Skip("sqlite3 is not installed")
+	}
+	dbFile := filepath.Join(t.TempDir(), "out.db")
+	lines := []string{`{"level":"INFO","msg":"a"}`, `{"level":"ERROR","msg":"b|c"}`, "plain 'text'"}
+	n, err := sqlite.Export(dbFile, []config.Key{{Name: "level"}}, strings.NewReader(strings.Join(lines, "\n")))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 3, n)
+
+	out, err := exec.Command(sqlite.CLI, dbFile, `SELECT count(*) FROM entries WHERE "level" = 'ERROR';`).Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "1", strings.TrimSpace(string(out)))
+
+	r := MakeSQLiteReader(dbFile, nil)
+	if !assert.NoError(t, r.StreamInto()) {
+		return
+	}
+	var got []string
+	for len(got) < len(lines) {
+		select {
+		case line := <-r.ChanReader():
+			got = append(got, line)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out reading the database back")
+		}
+	}
+	select {
+	case <-r.Loaded():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the query to finish")
+	}
+	read, _ := r.Progress()
+	assert.EqualValues(t, len(lines), read)
+	assert.False(t, r.Follows())
+	r.Close()
+	assert.Equal(t, lines, got)
+}
@@ -0,0 +1,141 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+var syntheticSeverities = []string{"INFO", "DEBUG", "INFO", "WARNING", "INFO", "ERROR"}
+
+type syntheticStream struct {
+	reader
+	rate      int
+	size      int
+	generated atomic.Int64
+	ctx       context.Context
+	cancel    context.CancelFunc
+	stopped   chan struct{}
+}
+
+// MakeSyntheticReader builds a reader generating GCP like JSON log lines of
+// roughly size bytes, at rate lines per second. A zero rate generates lines
+// as fast as they are consumed.
+func MakeSyntheticReader(rate, size int, strChan chan string) *syntheticStream {
+	if strChan == nil {
+		strChan = make(chan string, 1)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &syntheticStream{
+		reader: reader{
+			strChan:    strChan,
+			readerType: TypeSynthetic,
+		},
+		rate:    rate,
+		size:    size,
+		ctx:     ctx,
+		cancel:  cancel,
+		stopped: make(chan struct{}),
+	}
+}
+
+func (s *syntheticStream) StreamInto() error {
+	go func() {
+		defer close(s.stopped)
+		start := time.Now()
+		for i := int64(0); ; i++ {
+			if s.rate > 0 {
+				due := start.Add(time.Duration(i) * time.Second / time.Duration(s.rate))
+				if wait := time.Until(due); wait > 0 {
+					select {
+					case <-time.After(wait):
+					case <-s.ctx.Done():
+						return
+					}
+				}
+			}
+			select {
+			case s.strChan <- SyntheticLine(i, s.size):
+				s.generated.Add(1)
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Generated returns the number of lines fed into the stream so far.
+func (s *syntheticStream) Generated() int64 {
+	return s.generated.Load()
+}
+
+func (s *syntheticStream) Close() {
+	s.closeOnce(func() {
+		s.cancel()
+		<-s.stopped
+		close(s.strChan)
+	})
+}
+
+// SyntheticLine builds the i-th synthetic log line, padding its message so
+// the line is roughly size bytes long.
+func SyntheticLine(i int64, size int) string {
+	entry := map[string]interface{}{
+		"timestamp": time.Now().UTC().Format(time.RFC3339Nano),
+		"severity":  syntheticSeverities[i%int64(len(syntheticSeverities))],
+		"insertId":  fmt.Sprintf("%016x", i),
+		"trace":     fmt.Sprintf("projects/bench/traces/%032x", i/10),
+		"resource": map[string]interface{}{
+			"type":   "k8s_container",
+			"labels": map[string]interface{}{"pod_name": fmt.Sprintf("bench-%d", i%5)},
+		},
+		"jsonPayload": map[string]interface{}{
+			"message": fmt.Sprintf("request %d handled", i),
+		},
+	}
+	b, _ := json.Marshal(entry)
+	if pad := size - len(b); pad > 0 {
+		message := fmt.Sprintf("request %d handled ", i)
+		entry["jsonPayload"] = map[string]interface{}{
+			"message": message + strings.Repeat("x", pad-1),
+		}
+		b, _ = json.Marshal(entry)
+	}
+	return string(b)
+}
+
+func (s *syntheticStream) Details() []Detail {
+	rate := "as fast as consumed"
+	if s.rate > 0 {
+		rate = fmt.Sprintf("%d lines per second", s.rate)
+	}
+	return []Detail{{Label: "Source", Value: "Synthetic"}, {Label: "Rate", Value: rate},
+		{Label: "Line size", Value: fmt.Sprintf("%d bytes", s.size)},
+		{Label: "Generated", Value: fmt.Sprintf("%d lines", s.generated.Load())}}
+}
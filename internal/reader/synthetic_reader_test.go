@@ -0,0 +1,87 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyntheticLine(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      int
+		wantsSize int
+	}{
+		{
+			name:      "Smaller than the line skeleton",
+			size:      10,
+			wantsSize: 0,
+		},
+		{
+			name:      "Padded",
+			size:      1024,
+			wantsSize: 1024,
+		},
+		{
+			name:      "Largely padded",
+			size:      16384,
+			wantsSize: 16384,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := SyntheticLine(42, tt.size)
+			m := make(map[string]interface{})
+			assert.NoError(t, json.Unmarshal([]byte(line), &m))
+			assert.Equal(t, "000000000000002a", m["insertId"])
+			assert.Equal(t, "INFO", m["severity"])
+			if tt.wantsSize > 0 {
+				assert.Equal(t, tt.wantsSize, len(line))
+			}
+		})
+	}
+}
+
+func TestSyntheticStream_Rate(t *testing.T) {
+	r := MakeSyntheticReader(200, 256, nil)
+	assert.NoError(t, r.StreamInto())
+	timeout := time.After(250 * time.Millisecond)
+	count := 0
+loop:
+	for {
+		select {
+		case <-r.ChanReader():
+			count++
+		case <-timeout:
+			break loop
+		}
+	}
+	r.Close()
+	assert.True(t, count >= 30 && count <= 60, count)
+	// the channel buffer may hold one more generated line
+	assert.True(t, r.Generated()-int64(count) <= 1, r.Generated())
+}
@@ -0,0 +1,163 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/badaniya/loggo/internal/config"
+)
+
+// maxReplayGap caps the wait between two lines replayed at their timestamps'
+// pace, so quiet hours in the log don't stall the replay.
+const maxReplayGap = 10 * time.Second
+
+// replayTimeKeys are the entry keys probed, in order, for the timestamp
+// driving a speed scaled replay.
+var replayTimeKeys = []string{"timestamp", "@timestamp", "time", "ts", "date"}
+
+type throttleStream struct {
+	Reader
+	strChan chan string
+	rate    float64
+	speed   float64
+	sleep   func(d time.Duration)
+	once    sync.Once
+	stopped chan struct{}
+}
+
+// WithThrottle wraps a reader so its lines are replayed at a fixed rate of
+// lines per second or, if speed is set, spaced as the timestamps they embed,
+// scaled by speed (e.g. 2 replays twice as fast). Lines lacking a timestamp
+// are forwarded straight away.
+func WithThrottle(r Reader, rate, speed float64) Reader {
+	return &throttleStream{
+		Reader:  r,
+		strChan: make(chan string, 1),
+		rate:    rate,
+		speed:   speed,
+		sleep:   time.Sleep,
+		stopped: make(chan struct{}),
+	}
+}
+
+func (s *throttleStream) StreamInto() error {
+	if err := s.Reader.StreamInto(); err != nil {
+		return err
+	}
+	go func() {
+		defer close(s.strChan)
+		var last time.Time
+		for line := range s.Reader.ChanReader() {
+			s.sleep(s.wait(line, &last))
+			select {
+			case s.strChan <- line:
+			case <-s.stopped:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *throttleStream) Close() {
+	s.once.Do(func() {
+		close(s.stopped)
+	})
+	s.Reader.Close()
+}
+
+// wait returns how long to hold line back, last being the timestamp of the
+// previous timestamped line when replaying at speed.
+func (s *throttleStream) wait(line string, last *time.Time) time.Duration {
+	if s.speed <= 0 {
+		if s.rate <= 0 {
+			return 0
+		}
+		return time.Duration(float64(time.Second) / s.rate)
+	}
+	ts, ok := lineTime(line)
+	if !ok {
+		return 0
+	}
+	prev := *last
+	*last = ts
+	if prev.IsZero() || !ts.After(prev) {
+		return 0
+	}
+	gap := time.Duration(float64(ts.Sub(prev)) / s.speed)
+	if gap > maxReplayGap {
+		gap = maxReplayGap
+	}
+	return gap
+}
+
+func (s *throttleStream) ChanReader() <-chan string {
+	return s.strChan
+}
+
+// lineTime extracts the timestamp of a JSON entry, either a datetime string
+// or a unix epoch in seconds or milliseconds.
+func lineTime(line string) (time.Time, bool) {
+	m := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(line), &m); err != nil {
+		return time.Time{}, false
+	}
+	for _, k := range replayTimeKeys {
+		switch v := m[k].(type) {
+		case string:
+			if t, err := config.ParseTime("", v); err == nil {
+				return t, true
+			}
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return epochTime(f), true
+			}
+		case float64:
+			return epochTime(v), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// epochTime takes epochs too large to be seconds (beyond year 5000) as
+// milliseconds.
+func epochTime(f float64) time.Time {
+	if f > 1e11 {
+		return time.UnixMilli(int64(f))
+	}
+	return time.Unix(0, int64(f*float64(time.Second)))
+}
+
+// Details tells the details of the wrapped reader and the pace it's replayed
+// at.
+func (s *throttleStream) Details() []Detail {
+	replay := fmt.Sprintf("%g lines per second", s.rate)
+	if s.speed > 0 {
+		replay = fmt.Sprintf("%gx the logged pace", s.speed)
+	}
+	return append(Describe(s.Reader), Detail{Label: "Replay", Value: replay})
+}
@@ -0,0 +1,113 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrottleStream_StreamInto(t *testing.T) {
+	tests := []struct {
+		name  string
+		rate  float64
+		speed float64
+		lines []string
+		waits []time.Duration
+	}{
+		{
+			name:  "fixed rate",
+			rate:  4,
+			lines: []string{`{"a":1}`, `{"a":2}`},
+			waits: []time.Duration{250 * time.Millisecond, 250 * time.Millisecond},
+		},
+		{
+			name:  "no throttle",
+			lines: []string{`{"a":1}`, `{"a":2}`},
+			waits: []time.Duration{0, 0},
+		},
+		{
+			name:  "timestamps scaled by speed",
+			speed: 2,
+			lines: []string{
+				`{"timestamp":"2024-06-01T10:00:00Z"}`,
+				`{"timestamp":"2024-06-01T10:00:01Z"}`,
+				`not json`,
+				`{"timestamp":"2024-06-01T10:00:04Z"}`,
+			},
+			waits: []time.Duration{0, 500 * time.Millisecond, 0, 1500 * time.Millisecond},
+		},
+		{
+			name:  "epochs, out of order and capped gaps",
+			speed: 1,
+			lines: []string{
+				`{"ts":1717236000}`,
+				`{"ts":1717236000500}`,
+				`{"ts":1717235999}`,
+				`{"time":"1717239600"}`,
+			},
+			waits: []time.Duration{0, 500 * time.Millisecond, 0, maxReplayGap},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			inner := &linesStream{
+				reader: reader{strChan: make(chan string, 1)},
+				lines:  test.lines,
+			}
+			r := WithThrottle(inner, test.rate, test.speed)
+			var waits []time.Duration
+			r.(*throttleStream).sleep = func(d time.Duration) {
+				waits = append(waits, d)
+			}
+			assert.NoError(t, r.StreamInto())
+			for _, l := range test.lines {
+				assert.Equal(t, l, <-r.ChanReader())
+			}
+			r.Close()
+			assert.Equal(t, test.waits, waits)
+		})
+	}
+}
+
+func TestThrottleStream_Close(t *testing.T) {
+	inner := &linesStream{reader: reader{strChan: make(chan string, 2)}}
+	inner.strChan <- `{"a":1}`
+	inner.strChan <- `{"a":2}`
+	r := WithThrottle(inner, 0, 0)
+	assert.NoError(t, r.StreamInto())
+	// the first line is buffered and the second held back
+	assert.Eventually(t, func() bool {
+		return len(inner.strChan) == 0 && len(r.ChanReader()) == 1
+	}, time.Second, 10*time.Millisecond)
+	r.Close()
+	assert.Equal(t, `{"a":1}`, <-r.ChanReader())
+	select {
+	case _, ok := <-r.ChanReader():
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("the held back line kept the stream open")
+	}
+}
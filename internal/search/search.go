@@ -46,6 +46,7 @@ type Searchable interface {
 	GetSearchPosition() int
 	Next() int
 	Prev() int
+	Select(i int) int
 }
 
 func (s *search) Clear() {
@@ -80,6 +81,14 @@ func (s *search) Prev() int {
 	return s.searchWordIdx
 }
 
+// Select makes the i-th result the current one, unless out of range.
+func (s *search) Select(i int) int {
+	if i >= 0 && i < s.selectionCount {
+		s.searchWordIdx = i
+	}
+	return s.searchWordIdx
+}
+
 func (s *search) GetSearchPosition() int {
 	return s.searchWordIdx + 1
 }
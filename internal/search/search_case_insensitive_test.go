@@ -27,6 +27,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/badaniya/loggo/internal/filter"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -82,3 +83,54 @@ func TestCaseInsensitiveSearch_Search(t *testing.T) {
 		})
 	}
 }
+
+func TestTextSearch_Search(t *testing.T) {
+	text := "Error: errors in error_log, ERROR again"
+	tests := []struct {
+		name     string
+		word     string
+		matching filter.Matching
+		want     []string
+	}{
+		{
+			name: "smart case ignores case",
+			word: "error",
+			want: []string{"Error", "error", "error", "ERROR"},
+		},
+		{
+			name: "smart case matches upper case",
+			word: "ERROR",
+			want: []string{"ERROR"},
+		},
+		{
+			name:     "match case",
+			word:     "error",
+			matching: filter.Matching{Case: filter.MatchCase},
+			want:     []string{"error", "error"},
+		},
+		{
+			name:     "whole word",
+			word:     "error",
+			matching: filter.Matching{WholeWord: true},
+			want:     []string{"Error", "ERROR"},
+		},
+		{
+			name:     "whole word ignoring case",
+			word:     "Error",
+			matching: filter.Matching{Case: filter.IgnoreCase, WholeWord: true},
+			want:     []string{"Error", "ERROR"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := MakeTextSearch(nil, test.matching)
+			idx, err := s.Search(test.word, text)
+			assert.NoError(t, err)
+			var got []string
+			for _, i := range idx {
+				got = append(got, text[i[0]:i[1]])
+			}
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package search
+
+import (
+	"testing"
+
+	"github.com/badaniya/loggo/internal/filter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearch_Select(t *testing.T) {
+	s := MakeTextSearch(nil, filter.Matching{})
+	s.TagWord("a", "banana")
+	assert.Equal(t, 3, s.GetSearchCount())
+	assert.Equal(t, 2, s.Select(2))
+	assert.Equal(t, 3, s.GetSearchPosition())
+	assert.Equal(t, 2, s.Select(3))
+	assert.Equal(t, 2, s.Select(-1))
+	assert.Equal(t, 0, s.Next())
+}
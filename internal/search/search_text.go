@@ -24,36 +24,50 @@ package search
 
 import (
 	"strings"
+	"unicode/utf8"
 
+	"github.com/badaniya/loggo/internal/filter"
 	"github.com/rivo/tview"
 )
 
-type caseInsensitiveSearch struct {
+type textSearch struct {
 	search
+	matching filter.Matching
 }
 
-func MakeCaseInsensitiveSearch(statusBar *tview.TextView) Searchable {
-	s := &caseInsensitiveSearch{}
+// MakeTextSearch searches words as filters match free text, e.g. ignoring
+// case unless the word holds an upper case letter.
+func MakeTextSearch(statusBar *tview.TextView, m filter.Matching) Searchable {
+	s := &textSearch{matching: m}
 	s.searchStrategy = s
 	s.search.statusBar = statusBar
 	s.search.Clear()
 	return s
 }
 
-func (c *caseInsensitiveSearch) Search(word, text string) ([][]int, error) {
-	_, _ = c.search.Search(word, text)
-	word = strings.ToLower(word)
-	c.startIndexes = [][]int{}
-	text = strings.ToLower(text)
-	c.searchAll(word, text, 0)
-	return c.startIndexes, nil
+func MakeCaseInsensitiveSearch(statusBar *tview.TextView) Searchable {
+	return MakeTextSearch(statusBar, filter.Matching{Case: filter.IgnoreCase})
 }
 
-func (c *caseInsensitiveSearch) searchAll(word, text string, currPointerIdx int) {
-	idx := strings.Index(text, word)
-	if idx > -1 {
-		newPointerIdx := currPointerIdx + idx
-		c.startIndexes = append(c.startIndexes, []int{newPointerIdx, newPointerIdx + len(word)})
-		c.searchAll(word, text[idx+len(word):], newPointerIdx+len(word))
+func (c *textSearch) Search(word, text string) ([][]int, error) {
+	_, _ = c.search.Search(word, text)
+	if !c.matching.Sensitive(word) {
+		word, text = strings.ToLower(word), strings.ToLower(text)
+	}
+	c.startIndexes = [][]int{}
+	for i := 0; len(word) > 0 && i <= len(text)-len(word); {
+		idx := strings.Index(text[i:], word)
+		if idx < 0 {
+			break
+		}
+		start, end := i+idx, i+idx+len(word)
+		if c.matching.WholeWord && !filter.WordBounded(text, start, end) {
+			_, n := utf8.DecodeRuneInString(text[start:])
+			i = start + n
+			continue
+		}
+		c.startIndexes = append(c.startIndexes, []int{start, end})
+		i = end
 	}
+	return c.startIndexes, nil
 }
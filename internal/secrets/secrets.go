@@ -0,0 +1,256 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+// Package secrets detects credentials accidentally logged by services, e.g.
+// AWS access keys, JWTs or private keys, so that they get flagged as they are
+// ingested and redacted from exports.
+package secrets
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type rule struct {
+	kind string
+	// hints are substrings any match holds, sparing the regex on most values.
+	hints []string
+	regex *regexp.Regexp
+}
+
+var rules = []rule{
+	{
+		kind:  "AWS access key",
+		hints: []string{"AKIA", "ASIA"},
+		regex: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	},
+	{
+		kind:  "JWT",
+		hints: []string{"eyJ"},
+		regex: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`),
+	},
+	{
+		kind:  "private key",
+		hints: []string{"PRIVATE KEY-----"},
+		regex: regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY-----(?s:.*?)(?:-----END (?:[A-Z0-9]+ )*PRIVATE KEY-----|$)`),
+	},
+	{
+		kind:  "GitHub token",
+		hints: []string{"ghp_", "gho_", "ghu_", "ghs_", "ghr_", "github_pat_"},
+		regex: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36}|github_pat_[A-Za-z0-9_]{22,})\b`),
+	},
+	{
+		kind:  "Slack token",
+		hints: []string{"xox"},
+		regex: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`),
+	},
+	{
+		kind:  "Google API key",
+		hints: []string{"AIza"},
+		regex: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),
+	},
+	{
+		kind:  "Stripe key",
+		hints: []string{"_live_"},
+		regex: regexp.MustCompile(`\b[sr]k_live_[0-9A-Za-z]{24,}\b`),
+	},
+}
+
+// Finding is a secret found in an entry.
+type Finding struct {
+	// Key is the path of the key holding the secret, e.g. jsonPayload/env.
+	Key string
+	// Kind is the kind of secret, e.g. JWT.
+	Kind string
+}
+
+// Scan returns the secrets found in the string values of the entry, nested
+// ones included. Internal keys, prefixed with $_, are ignored.
+func Scan(entry map[string]interface{}) []Finding {
+	var findings []Finding
+	scanValue("", entry, &findings)
+	return findings
+}
+
+func scanValue(path string, v interface{}, findings *[]Finding) {
+	switch v := v.(type) {
+	case string:
+		for _, r := range rules {
+			if r.applies(v) && r.regex.MatchString(v) {
+				*findings = append(*findings, Finding{Key: path, Kind: r.kind})
+			}
+		}
+	case map[string]interface{}:
+		for k, nested := range v {
+			if strings.HasPrefix(k, "$_") {
+				continue
+			}
+			if len(path) > 0 {
+				k = path + "/" + k
+			}
+			scanValue(k, nested, findings)
+		}
+	case []interface{}:
+		for _, nested := range v {
+			scanValue(path, nested, findings)
+		}
+	}
+}
+
+func (r *rule) applies(s string) bool {
+	for _, h := range r.hints {
+		if strings.Contains(s, h) {
+			return true
+		}
+	}
+	return false
+}
+
+// Kinds returns the distinct, sorted, kinds of the findings.
+func Kinds(findings []Finding) []string {
+	seen := make(map[string]bool)
+	var kinds []string
+	for _, f := range findings {
+		if !seen[f.Kind] {
+			seen[f.Kind] = true
+			kinds = append(kinds, f.Kind)
+		}
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Redact replaces the secrets held by s with a [REDACTED kind] placeholder.
+func Redact(s string) string {
+	for _, r := range rules {
+		if r.applies(s) {
+			s = r.regex.ReplaceAllLiteralString(s, "[REDACTED "+r.kind+"]")
+		}
+	}
+	return s
+}
+
+// RedactEntries returns the entries with their secrets redacted. Entries
+// holding none are returned as is, while the others are copied, so that the
+// buffered entries are left untouched.
+func RedactEntries(entries []map[string]interface{}) []map[string]interface{} {
+	redacted := make([]map[string]interface{}, len(entries))
+	for i, e := range entries {
+		redacted[i] = e
+		if len(Scan(e)) > 0 {
+			redacted[i] = redactValue(e).(map[string]interface{})
+		}
+	}
+	return redacted
+}
+
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return Redact(v)
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, nested := range v {
+			if strings.HasPrefix(k, "$_") {
+				m[k] = nested
+				continue
+			}
+			m[k] = redactValue(nested)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(v))
+		for i, nested := range v {
+			a[i] = redactValue(nested)
+		}
+		return a
+	}
+	return v
+}
+
+// Alert summarizes the entries holding secrets observed since the last
+// dismissal.
+type Alert struct {
+	// Entries is the number of entries holding secrets.
+	Entries int
+	// Kinds are the kinds of the secrets, sorted.
+	Kinds []string
+	// Last is the latest entry holding secrets.
+	Last map[string]interface{}
+	// At is when Last was observed.
+	At time.Time
+}
+
+// Tally keeps track of the entries holding secrets, so that they are flagged
+// until dismissed, and flagged again as further ones arrive.
+type Tally struct {
+	lock  sync.Mutex
+	alert *Alert
+	now   func() time.Time
+}
+
+func NewTally() *Tally {
+	return &Tally{now: time.Now}
+}
+
+// Observe records an entry along with the secrets it holds.
+func (t *Tally) Observe(entry map[string]interface{}, findings []Finding) {
+	if len(findings) == 0 {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.alert == nil {
+		t.alert = &Alert{}
+	}
+	t.alert.Entries++
+	t.alert.Last = entry
+	t.alert.At = t.now()
+	for _, k := range Kinds(findings) {
+		i := sort.SearchStrings(t.alert.Kinds, k)
+		if i == len(t.alert.Kinds) || t.alert.Kinds[i] != k {
+			t.alert.Kinds = append(t.alert.Kinds[:i], append([]string{k}, t.alert.Kinds[i:]...)...)
+		}
+	}
+}
+
+// Alert returns a copy of the pending alert, or nil if none.
+func (t *Tally) Alert() *Alert {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.alert == nil {
+		return nil
+	}
+	a := *t.alert
+	a.Kinds = append([]string(nil), a.Kinds...)
+	return &a
+}
+
+// Dismiss clears the pending alert.
+func (t *Tally) Dismiss() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.alert = nil
+}
@@ -0,0 +1,160 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/badaniya/loggo/internal/reader"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DefaultAddr    = ":2222"
+	DefaultBacklog = 5000
+	// DefaultDetachTimeout is how long detached sessions are kept running.
+	DefaultDetachTimeout = 12 * time.Hour
+)
+
+// Config describes a server mode instance: where sessions are accepted and the
+// curated streams they attach to, e.g.
+//
+//	ssh:
+//	  addr: :2222
+//	  hostKey: /etc/loggo/ssh_host_ed25519_key
+//	  authorizedKeys: /etc/loggo/authorized_keys
+//	backlog: 5000
+//	detachTimeout: 12h
+//	streams:
+//	  - name: api
+//	    command: kubectl logs -f deploy/api -n shop
+//	    template: /etc/loggo/api.yaml
+//	  - name: nginx
+//	    file: /var/log/nginx/access.log
+type Config struct {
+	SSH SSHConfig `yaml:"ssh"`
+	// Backlog is the number of latest lines of each stream replayed to new
+	// sessions.
+	Backlog int `yaml:"backlog,omitempty"`
+	// DetachTimeout is how long a detached session keeps buffering its stream
+	// until reattached, before it's closed.
+	DetachTimeout time.Duration `yaml:"detachTimeout,omitempty"`
+	Streams       []Stream      `yaml:"streams"`
+}
+
+type SSHConfig struct {
+	Addr string `yaml:"addr,omitempty"`
+	// HostKey is the server private key file, generated if missing.
+	HostKey string `yaml:"hostKey,omitempty"`
+	// AuthorizedKeys lists the public keys allowed in, in the OpenSSH
+	// authorized_keys format.
+	AuthorizedKeys string `yaml:"authorizedKeys"`
+}
+
+// Stream is a source ingested for as long as the server runs, either the
+// output of a shell command (run again whenever it exits) or a followed file.
+type Stream struct {
+	Name     string `yaml:"name"`
+	Command  string `yaml:"command,omitempty"`
+	File     string `yaml:"file,omitempty"`
+	Template string `yaml:"template,omitempty"`
+}
+
+// LoadConfig reads and checks the server configuration file.
+func LoadConfig(file string) (*Config, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	c := &Config{}
+	decoder := yaml.NewDecoder(bytes.NewReader(b))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if len(c.SSH.Addr) == 0 {
+		c.SSH.Addr = DefaultAddr
+	}
+	if c.Backlog == 0 {
+		c.Backlog = DefaultBacklog
+	}
+	if c.DetachTimeout == 0 {
+		c.DetachTimeout = DefaultDetachTimeout
+	}
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return c, nil
+}
+
+func (c *Config) validate() error {
+	if len(c.SSH.AuthorizedKeys) == 0 {
+		return fmt.Errorf("ssh.authorizedKeys is required")
+	}
+	if c.Backlog < 0 {
+		return fmt.Errorf("backlog must not be negative")
+	}
+	if c.DetachTimeout < 0 {
+		return fmt.Errorf("detachTimeout must not be negative")
+	}
+	if len(c.Streams) == 0 {
+		return fmt.Errorf("no streams configured")
+	}
+	names := make(map[string]bool)
+	for i, s := range c.Streams {
+		switch {
+		case len(s.Name) == 0:
+			return fmt.Errorf("stream %d has no name", i+1)
+		case names[s.Name]:
+			return fmt.Errorf("stream %q is defined twice", s.Name)
+		case (len(s.Command) == 0) == (len(s.File) == 0):
+			return fmt.Errorf("stream %q needs either a command or a file", s.Name)
+		}
+		names[s.Name] = true
+	}
+	return nil
+}
+
+// Stream returns the named stream, or the only one if name is empty.
+func (c *Config) Stream(name string) *Stream {
+	if len(name) == 0 && len(c.Streams) == 1 {
+		return &c.Streams[0]
+	}
+	for i := range c.Streams {
+		if c.Streams[i].Name == name {
+			return &c.Streams[i]
+		}
+	}
+	return nil
+}
+
+// Reader builds the reader ingesting the stream.
+func (s *Stream) Reader() reader.Reader {
+	if len(s.Command) > 0 {
+		return reader.MakeCommandReader(s.Command, nil)
+	}
+	return reader.MakeReader(s.File, nil)
+}
@@ -0,0 +1,142 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package server
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{
+			name: "defaults",
+			config: `
+ssh:
+  authorizedKeys: /etc/loggo/authorized_keys
+streams:
+  - name: api
+    command: kubectl logs -f deploy/api
+`,
+		},
+		{
+			name: "unknown option",
+			config: `
+ssh:
+  authorizedKeys: keys
+  port: 22
+streams:
+  - name: api
+    file: api.log
+`,
+			wantErr: "line 4: field port not found",
+		},
+		{
+			name: "no authorized keys",
+			config: `
+streams:
+  - name: api
+    file: api.log
+`,
+			wantErr: "ssh.authorizedKeys is required",
+		},
+		{
+			name: "no streams",
+			config: `
+ssh:
+  authorizedKeys: keys
+`,
+			wantErr: "no streams configured",
+		},
+		{
+			name: "duplicate",
+			config: `
+ssh:
+  authorizedKeys: keys
+streams:
+  - name: api
+    file: api.log
+  - name: api
+    file: api2.log
+`,
+			wantErr: `stream "api" is defined twice`,
+		},
+		{
+			name: "command and file",
+			config: `
+ssh:
+  authorizedKeys: keys
+streams:
+  - name: api
+    file: api.log
+    command: cat api.log
+`,
+			wantErr: `stream "api" needs either a command or a file`,
+		},
+		{
+			name: "negative detach timeout",
+			config: `
+ssh:
+  authorizedKeys: keys
+detachTimeout: -1h
+streams:
+  - name: api
+    file: api.log
+`,
+			wantErr: "detachTimeout must not be negative",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file := path.Join(t.TempDir(), "server.yaml")
+			assert.NoError(t, os.WriteFile(file, []byte(test.config), 0600))
+			c, err := LoadConfig(file)
+			if len(test.wantErr) > 0 {
+				assert.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, DefaultAddr, c.SSH.Addr)
+			assert.Equal(t, DefaultBacklog, c.Backlog)
+			assert.Equal(t, DefaultDetachTimeout, c.DetachTimeout)
+		})
+	}
+}
+
+func TestConfig_Stream(t *testing.T) {
+	c := &Config{Streams: []Stream{{Name: "api", File: "api.log"}}}
+	assert.Equal(t, "api", c.Stream("").Name)
+	assert.Equal(t, "api", c.Stream("api").Name)
+	assert.Nil(t, c.Stream("web"))
+
+	c.Streams = append(c.Streams, Stream{Name: "web", File: "web.log"})
+	assert.Nil(t, c.Stream(""))
+	assert.Equal(t, "web", c.Stream("web").Name)
+}
@@ -0,0 +1,104 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package server
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/badaniya/loggo/internal/reader"
+	"github.com/badaniya/loggo/internal/util"
+	"golang.org/x/crypto/ssh"
+)
+
+// Server ingests the configured streams for as long as it runs, and serves
+// each SSH session the TUI over the stream it asks for, resuming the one its
+// key detached from if any.
+type Server struct {
+	config     *Config
+	sshConfig  *ssh.ServerConfig
+	broadcasts map[string]*reader.Broadcast
+	lock       sync.Mutex
+	// detached lists the sessions parked by key fingerprint and stream.
+	detached map[string][]*session
+}
+
+// MakeServer prepares the SSH configuration, loading or generating the host
+// key and loading the authorized keys.
+func MakeServer(config *Config) (*Server, error) {
+	sshConfig, err := makeSSHConfig(&config.SSH)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{
+		config:     config,
+		sshConfig:  sshConfig,
+		broadcasts: make(map[string]*reader.Broadcast),
+		detached:   make(map[string][]*session),
+	}, nil
+}
+
+// Serve starts ingesting the streams and accepts SSH sessions until the
+// listener fails.
+func (s *Server) Serve() error {
+	for _, stream := range s.config.Streams {
+		b := reader.MakeBroadcast(stream.Reader(), s.config.Backlog)
+		if err := b.Start(); err != nil {
+			return fmt.Errorf("stream %q: %w", stream.Name, err)
+		}
+		s.broadcasts[stream.Name] = b
+		util.Log().WithField("code", stream.Name).Info("Ingesting stream")
+	}
+	defer s.close()
+	ln, err := net.Listen("tcp", s.config.SSH.Addr)
+	if err != nil {
+		return err
+	}
+	util.Log().WithField("code", s.config.SSH.Addr).Info("Serving SSH sessions")
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *Server) close() {
+	for _, b := range s.broadcasts {
+		b.Close()
+	}
+}
+
+// streamNames lists the streams sessions can attach to.
+func (s *Server) streamNames() string {
+	var names []string
+	for _, stream := range s.config.Streams {
+		names = append(names, stream.Name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,110 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package server
+
+import (
+	"time"
+
+	"github.com/badaniya/loggo/internal/loggo"
+	"github.com/badaniya/loggo/internal/util"
+)
+
+// session is the TUI of a stream, kept running once detached, with the detach
+// key or when the connection drops, so its owner can reattach to it later
+// without losing what it buffered meanwhile.
+type session struct {
+	app *loggo.LoggoApp
+	// done receives the app result once quit.
+	done chan error
+	// detach is signalled by the detach key.
+	detach chan struct{}
+	expiry *time.Timer
+}
+
+func (s *Server) newSession(stream *Stream) *session {
+	sess := &session{
+		app:    loggo.NewLoggoApp(s.broadcasts[stream.Name].Subscribe(), stream.Template),
+		done:   make(chan error, 1),
+		detach: make(chan struct{}, 1),
+	}
+	sess.app.OnDetach(func() {
+		select {
+		case sess.detach <- struct{}{}:
+		default:
+		}
+	})
+	return sess
+}
+
+// park keeps the detached session for its owner to resume, closing it once
+// the detach timeout elapses.
+func (s *Server) park(owner string, sess *session) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.detached[owner] = append(s.detached[owner], sess)
+	sess.expiry = time.AfterFunc(s.config.DetachTimeout, func() {
+		if s.unpark(owner, sess) {
+			util.Log().WithField("code", owner).Info("Closing expired detached session")
+			sess.app.Stop()
+		}
+	})
+}
+
+// resume takes back the session the owner detached from last, if any.
+func (s *Server) resume(owner string) *session {
+	s.lock.Lock()
+	var sess *session
+	if parked := s.detached[owner]; len(parked) > 0 {
+		sess = parked[len(parked)-1]
+	}
+	s.lock.Unlock()
+	if sess == nil || !s.unpark(owner, sess) {
+		return nil
+	}
+	sess.expiry.Stop()
+	// a detach key pressed twice mustn't detach the resumed session at once
+	select {
+	case <-sess.detach:
+	default:
+	}
+	return sess
+}
+
+// unpark removes the parked session, telling whether it was still parked.
+func (s *Server) unpark(owner string, sess *session) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	parked := s.detached[owner]
+	for i, p := range parked {
+		if p == sess {
+			parked = append(parked[:i], parked[i+1:]...)
+			if len(parked) == 0 {
+				delete(s.detached, owner)
+			} else {
+				s.detached[owner] = parked
+			}
+			return true
+		}
+	}
+	return false
+}
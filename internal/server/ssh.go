@@ -0,0 +1,335 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package server
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/badaniya/loggo/internal/util"
+	"github.com/gdamore/tcell/v2"
+	"github.com/gdamore/tcell/v2/terminfo"
+	"golang.org/x/crypto/ssh"
+)
+
+// defaultTerm is assumed for clients not telling their terminal type.
+const defaultTerm = "xterm-256color"
+
+func makeSSHConfig(c *SSHConfig) (*ssh.ServerConfig, error) {
+	authorized, err := loadAuthorizedKeys(c.AuthorizedKeys)
+	if err != nil {
+		return nil, err
+	}
+	hostKey, err := loadHostKey(c.HostKey)
+	if err != nil {
+		return nil, err
+	}
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if !authorized[string(key.Marshal())] {
+				return nil, fmt.Errorf("unknown public key for %s", conn.User())
+			}
+			return &ssh.Permissions{
+				Extensions: map[string]string{"fingerprint": ssh.FingerprintSHA256(key)},
+			}, nil
+		},
+	}
+	config.AddHostKey(hostKey)
+	return config, nil
+}
+
+// loadAuthorizedKeys reads the keys of an OpenSSH authorized_keys file.
+func loadAuthorizedKeys(file string) (map[string]bool, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]bool)
+	for len(bytes.TrimSpace(b)) > 0 {
+		key, _, _, rest, err := ssh.ParseAuthorizedKey(b)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		keys[string(key.Marshal())] = true
+		b = rest
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s: no authorized keys", file)
+	}
+	return keys, nil
+}
+
+// loadHostKey reads the host private key, generating an ed25519 one if the
+// file doesn't exist. Without a file, the key only lasts for this run.
+func loadHostKey(file string) (ssh.Signer, error) {
+	if len(file) > 0 {
+		b, err := os.ReadFile(file)
+		if err == nil {
+			return ssh.ParsePrivateKey(b)
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	if len(file) == 0 {
+		util.Log().Warn("No SSH host key configured, clients will see a new one every run")
+	} else {
+		block, err := ssh.MarshalPrivateKey(key, "loggo")
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(path.Dir(file), os.ModePerm); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(file, pem.EncodeToMemory(block), 0600); err != nil {
+			return nil, err
+		}
+		util.Log().WithField("code", file).Info("Generated SSH host key")
+	}
+	return ssh.NewSignerFromKey(key)
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	sconn, channels, requests, err := ssh.NewServerConn(conn, s.sshConfig)
+	if err != nil {
+		util.Log().WithField("code", err).Warn("SSH handshake failed")
+		return
+	}
+	defer sconn.Close()
+	util.Log().WithField("code", sconn.Permissions.Extensions["fingerprint"]).
+		Infof("SSH session opened by %s from %s", sconn.User(), sconn.RemoteAddr())
+	owner := sconn.Permissions.Extensions["fingerprint"]
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "only sessions are supported")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			util.Log().WithField("code", err).Warn("Unable to accept SSH channel")
+			continue
+		}
+		go s.handleSession(channel, requests, owner)
+	}
+}
+
+// handleSession runs the TUI over the stream named by the exec command, e.g.
+// `ssh -t host api`, or the only stream configured.
+func (s *Server) handleSession(channel ssh.Channel, requests <-chan *ssh.Request, owner string) {
+	defer channel.Close()
+	var tty *sshTty
+	for req := range requests {
+		switch req.Type {
+		case "pty-req":
+			var pty struct {
+				Term          string
+				Columns, Rows uint32
+				Width, Height uint32
+				Modes         string
+			}
+			if err := ssh.Unmarshal(req.Payload, &pty); err != nil {
+				_ = req.Reply(false, nil)
+				continue
+			}
+			tty = newSSHTty(channel, pty.Term, int(pty.Columns), int(pty.Rows))
+			_ = req.Reply(true, nil)
+		case "window-change":
+			var size struct {
+				Columns, Rows uint32
+				Width, Height uint32
+			}
+			if tty != nil && ssh.Unmarshal(req.Payload, &size) == nil {
+				tty.resize(int(size.Columns), int(size.Rows))
+			}
+		case "shell", "exec":
+			var exec struct {
+				Command string
+			}
+			if req.Type == "exec" {
+				_ = ssh.Unmarshal(req.Payload, &exec)
+			}
+			_ = req.Reply(true, nil)
+			go func() {
+				status := s.run(channel, tty, strings.TrimSpace(exec.Command), owner)
+				_, _ = channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+				_ = channel.Close()
+			}()
+		default:
+			if req.WantReply {
+				_ = req.Reply(false, nil)
+			}
+		}
+	}
+}
+
+// run serves the TUI of the named stream until quit or detached, returning the
+// exit status. The owner's latest detached session of the stream is resumed
+// rather than starting a new one.
+func (s *Server) run(channel ssh.Channel, tty *sshTty, name, owner string) uint32 {
+	stream := s.config.Stream(name)
+	if stream == nil {
+		fmt.Fprintf(channel.Stderr(), "Unknown stream %q, pick one of: %s\r\nE.g. ssh -t <host> %s\r\n",
+			name, s.streamNames(), s.config.Streams[0].Name)
+		return 1
+	}
+	if tty == nil {
+		fmt.Fprint(channel.Stderr(), "A terminal is required, connect with ssh -t\r\n")
+		return 1
+	}
+	ti, err := terminfo.LookupTerminfo(tty.term)
+	if err != nil {
+		ti, err = terminfo.LookupTerminfo(defaultTerm)
+	}
+	if err != nil {
+		fmt.Fprintf(channel.Stderr(), "Unsupported terminal: %v\r\n", err)
+		return 1
+	}
+	screen, err := tcell.NewTerminfoScreenFromTtyTerminfo(tty, ti)
+	if err != nil {
+		fmt.Fprintf(channel.Stderr(), "Unable to open the terminal: %v\r\n", err)
+		return 1
+	}
+	key := owner + "/" + stream.Name
+	sess := s.resume(key)
+	if sess == nil {
+		sess = s.newSession(stream)
+		go func() {
+			sess.done <- sess.app.RunOn(screen)
+		}()
+	} else {
+		util.Log().WithField("code", key).Info("SSH session reattached")
+		sess.app.Reattach(screen)
+	}
+	select {
+	case err := <-sess.done:
+		if err != nil {
+			util.Log().WithField("code", err).Error("SSH session failed")
+			return 1
+		}
+		return 0
+	case <-sess.detach:
+	case <-tty.hangup:
+	}
+	// releasing the screen leaves the app buffering until reattached
+	screen.Fini()
+	s.park(key, sess)
+	util.Log().WithField("code", key).Info("SSH session detached")
+	fmt.Fprintf(channel, "Detached from %s, reattach within %s with ssh -t <host> %s\r\n",
+		stream.Name, s.config.DetachTimeout, stream.Name)
+	return 0
+}
+
+// sshTty adapts an SSH session channel with a pseudo terminal to tcell.
+type sshTty struct {
+	channel  ssh.Channel
+	input    *io.PipeReader
+	term     string
+	lock     sync.Mutex
+	width    int
+	height   int
+	onResize func()
+	// hangup is closed once the client input ends, e.g. the connection dropped.
+	hangup chan struct{}
+}
+
+func newSSHTty(channel ssh.Channel, term string, width, height int) *sshTty {
+	if len(term) == 0 {
+		term = defaultTerm
+	}
+	// reads go through a pipe so Drain can interrupt them, rather than the end
+	// of the input, which detaches the session instead of quitting it
+	input, output := io.Pipe()
+	hangup := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(output, channel)
+		close(hangup)
+	}()
+	return &sshTty{
+		channel: channel,
+		input:   input,
+		hangup:  hangup,
+		term:    term,
+		width:   width,
+		height:  height,
+	}
+}
+
+func (t *sshTty) Start() error {
+	return nil
+}
+
+func (t *sshTty) Stop() error {
+	return nil
+}
+
+func (t *sshTty) Drain() error {
+	return t.input.CloseWithError(io.EOF)
+}
+
+func (t *sshTty) NotifyResize(cb func()) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.onResize = cb
+}
+
+func (t *sshTty) WindowSize() (tcell.WindowSize, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return tcell.WindowSize{Width: t.width, Height: t.height}, nil
+}
+
+func (t *sshTty) resize(width, height int) {
+	t.lock.Lock()
+	t.width, t.height = width, height
+	cb := t.onResize
+	t.lock.Unlock()
+	if cb != nil {
+		cb()
+	}
+}
+
+func (t *sshTty) Read(p []byte) (int, error) {
+	return t.input.Read(p)
+}
+
+func (t *sshTty) Write(p []byte) (int, error) {
+	return t.channel.Write(p)
+}
+
+func (t *sshTty) Close() error {
+	return t.input.Close()
+}
@@ -0,0 +1,153 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+// Package sqlite exports log lines into an SQLite database, one row per line
+// with a column per template key, for ad hoc SQL analysis. Databases are
+// written through the sqlite3 command line shell, so no database driver has
+// to be linked in.
+package sqlite
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/badaniya/loggo/internal/config"
+)
+
+// CLI is the sqlite3 command line shell, through which databases are written
+// and read.
+const CLI = "sqlite3"
+
+// Table holds one row per exported line: its order, the raw line and a column
+// per template key, e.g.:
+//
+//	SELECT level, count(*) FROM entries GROUP BY level;
+const Table = "entries"
+
+// Export writes the lines read from r into the entries table of the SQLite
+// database at dbFile, replacing any previous export, with a TEXT column
+// holding each key's value. It returns the number of exported lines.
+func Export(dbFile string, keys []config.Key, r io.Reader) (int, error) {
+	if _, err := exec.LookPath(CLI); err != nil {
+		return 0, fmt.Errorf("the %s command line shell is required: %w", CLI, err)
+	}
+	c := exec.Command(CLI, "-batch", "-bail", dbFile)
+	stdin, err := c.StdinPipe()
+	if err != nil {
+		return 0, err
+	}
+	stderr := &strings.Builder{}
+	c.Stderr = stderr
+	if err := c.Start(); err != nil {
+		return 0, err
+	}
+	w := bufio.NewWriter(stdin)
+	n, err := writeScript(w, keys, r)
+	if err == nil {
+		err = w.Flush()
+	}
+	_ = stdin.Close()
+	if werr := c.Wait(); werr != nil && err == nil {
+		err = werr
+		if msg := strings.TrimSpace(stderr.String()); len(msg) > 0 {
+			err = fmt.Errorf("%w: %s", werr, msg)
+		}
+	}
+	return n, err
+}
+
+// writeScript writes the SQL statements creating the entries table and
+// inserting every line of r, within a single transaction.
+func writeScript(w io.Writer, keys []config.Key, r io.Reader) (int, error) {
+	columns := keyColumns(keys)
+	names := []string{"id", "raw"}
+	defs := []string{"id INTEGER PRIMARY KEY", "raw TEXT NOT NULL"}
+	for _, col := range columns {
+		names = append(names, col.name)
+		defs = append(defs, col.name+" TEXT")
+	}
+	if _, err := fmt.Fprintf(w, "BEGIN;\nDROP TABLE IF EXISTS %s;\nCREATE TABLE %s (%s);\n",
+		Table, Table, strings.Join(defs, ", ")); err != nil {
+		return 0, err
+	}
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (", Table, strings.Join(names, ", "))
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	n := 0
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		n++
+		values := []string{fmt.Sprint(n), quote(line)}
+		m := make(map[string]interface{})
+		isJSON := json.Unmarshal([]byte(line), &m) == nil
+		for _, col := range columns {
+			if v := col.key.ExtractValue(m); isJSON && len(v) > 0 {
+				values = append(values, quote(v))
+			} else {
+				values = append(values, "NULL")
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s%s);\n", insert, strings.Join(values, ", ")); err != nil {
+			return n, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return n, err
+	}
+	_, err := fmt.Fprintln(w, "COMMIT;")
+	return n, err
+}
+
+type column struct {
+	name string
+	key  config.Key
+}
+
+// keyColumns maps the keys to quoted column names, skipping the ones
+// clashing (case insensitively, as SQLite does) with a previous column.
+func keyColumns(keys []config.Key) []column {
+	seen := map[string]bool{"id": true, "raw": true}
+	var columns []column
+	for _, k := range keys {
+		name := strings.TrimSpace(k.Name)
+		if len(name) == 0 || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		columns = append(columns, column{
+			name: `"` + strings.ReplaceAll(name, `"`, `""`) + `"`,
+			key:  k,
+		})
+	}
+	return columns
+}
+
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
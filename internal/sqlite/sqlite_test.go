@@ -0,0 +1,84 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package sqlite
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteScript(t *testing.T) {
+	tests := []struct {
+		name  string
+		keys  []config.Key
+		input string
+		wants []string
+		count int
+	}{
+		{
+			name:  "Key columns",
+			keys:  []config.Key{{Name: "level"}, {Name: "jsonPayload/message"}},
+			input: `{"level":"INFO","jsonPayload":{"message":"it's up"}}` + "\n",
+			wants: []string{
+				`CREATE TABLE entries (id INTEGER PRIMARY KEY, raw TEXT NOT NULL, "level" TEXT, "jsonPayload/message" TEXT);`,
+				`INSERT INTO entries (id, raw, "level", "jsonPayload/message") VALUES (1, '{"level":"INFO","jsonPayload":{"message":"it''s up"}}', 'INFO', 'it''s up');`,
+			},
+			count: 1,
+		},
+		{
+			name:  "Plain text and missing keys",
+			keys:  []config.Key{{Name: "level"}},
+			input: "{\"msg\":\"a\"}\n\nplain text\n",
+			wants: []string{
+				`VALUES (1, '{"msg":"a"}', NULL);`,
+				`VALUES (2, 'plain text', NULL);`,
+			},
+			count: 2,
+		},
+		{
+			name:  "Clashing keys",
+			keys:  []config.Key{{Name: "ID"}, {Name: "a\"b"}, {Name: "A\"B"}},
+			input: `{"ID":"1"}`,
+			wants: []string{
+				`CREATE TABLE entries (id INTEGER PRIMARY KEY, raw TEXT NOT NULL, "a""b" TEXT);`,
+			},
+			count: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sb := &strings.Builder{}
+			n, err := writeScript(sb, test.keys, strings.NewReader(test.input))
+			assert.NoError(t, err)
+			assert.Equal(t, test.count, n)
+			assert.True(t, strings.HasPrefix(sb.String(), "BEGIN;\n"))
+			assert.True(t, strings.HasSuffix(sb.String(), "COMMIT;\n"))
+			for _, want := range test.wants {
+				assert.Contains(t, sb.String(), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package util
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// EnvPrefix prefixes the environment variables setting flag defaults, e.g.
+	// LOGGO_TEMPLATE for --template.
+	EnvPrefix = "LOGGO_"
+	// DefaultsFileEnv overrides the location of the flag defaults file.
+	DefaultsFileEnv = EnvPrefix + "CONFIG"
+)
+
+// FlagDefaults resolves the default values of the command's flags, as
+// overridden by LOGGO_* environment variables (within environ, as returned
+// by os.Environ) or by the flag defaults file content, e.g.
+//
+//	template: /etc/loggo/gcp.yaml
+//	gcp-stream:
+//	  project: my-project
+//
+// where top level flags apply to every command and nested ones to the named
+// command only. Environment variables take precedence over the command
+// section, which takes precedence over top level flags. Flags given on the
+// command line take precedence over all of them.
+func FlagDefaults(command string, environ []string, file []byte) (map[string]string, error) {
+	defaults := make(map[string]string)
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(file, &doc); err != nil {
+		return nil, err
+	}
+	var section map[string]interface{}
+	for name, v := range doc {
+		if nested, ok := v.(map[string]interface{}); ok {
+			if name == command {
+				section = nested
+			}
+			continue
+		}
+		value, err := flagValue(name, v)
+		if err != nil {
+			return nil, err
+		}
+		defaults[name] = value
+	}
+	for name, v := range section {
+		value, err := flagValue(command+"/"+name, v)
+		if err != nil {
+			return nil, err
+		}
+		defaults[name] = value
+	}
+	for _, kv := range environ {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(k, EnvPrefix) || k == DefaultsFileEnv {
+			continue
+		}
+		name := strings.ReplaceAll(strings.ToLower(strings.TrimPrefix(k, EnvPrefix)), "_", "-")
+		defaults[name] = v
+	}
+	return defaults, nil
+}
+
+// flagValue renders a defaults file value as given on the command line, lists
+// being comma separated.
+func flagValue(name string, v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case []interface{}:
+		values := make([]string, len(v))
+		for i := range v {
+			if _, ok := v[i].(map[string]interface{}); ok {
+				return "", fmt.Errorf("%s: expected a list of values", name)
+			}
+			values[i] = fmt.Sprint(v[i])
+		}
+		return strings.Join(values, ","), nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return "", fmt.Errorf("%s: expected a value, got %s", name, strings.Join(keys, ", "))
+	}
+	return fmt.Sprint(v), nil
+}
@@ -0,0 +1,116 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlagDefaults(t *testing.T) {
+	file := `
+template: /etc/loggo/gcp.yaml
+record-ring: 64
+bell: true
+gcp-stream:
+  project: my-project
+  template: /etc/loggo/gcp-stream.yaml
+  freshness: 30s
+stream:
+  file: app.log
+`
+	tests := []struct {
+		name    string
+		command string
+		environ []string
+		file    string
+		wants   map[string]string
+		err     bool
+	}{
+		{
+			name:    "top level flags",
+			command: "stream",
+			file:    file,
+			wants: map[string]string{
+				"template": "/etc/loggo/gcp.yaml", "record-ring": "64", "bell": "true", "file": "app.log",
+			},
+		},
+		{
+			name:    "command section takes precedence",
+			command: "gcp-stream",
+			file:    file,
+			wants: map[string]string{
+				"template": "/etc/loggo/gcp-stream.yaml", "record-ring": "64", "bell": "true",
+				"project": "my-project", "freshness": "30s",
+			},
+		},
+		{
+			name:    "environment takes precedence",
+			command: "gcp-stream",
+			environ: []string{
+				"LOGGO_PROJECT=other", "LOGGO_RECORD_RING=8", "LOGGO_CONFIG=/tmp/x.yaml", "HOME=/root", "LOGGO_BROKEN",
+			},
+			file: file,
+			wants: map[string]string{
+				"template": "/etc/loggo/gcp-stream.yaml", "record-ring": "8", "bell": "true",
+				"project": "other", "freshness": "30s",
+			},
+		},
+		{
+			name:    "no file",
+			command: "stream",
+			environ: []string{"LOGGO_TEMPLATE=t.yaml"},
+			wants:   map[string]string{"template": "t.yaml"},
+		},
+		{
+			name:    "lists",
+			command: "stream",
+			file:    "keys: [a, b]\n",
+			wants:   map[string]string{"keys": "a,b"},
+		},
+		{
+			name:    "nested values",
+			command: "stream",
+			file:    "stream:\n  file:\n    name: app.log\n",
+			err:     true,
+		},
+		{
+			name:    "invalid YAML",
+			command: "stream",
+			file:    "template: [",
+			err:     true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defaults, err := FlagDefaults(test.command, test.environ, []byte(test.file))
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.wants, defaults)
+		})
+	}
+}
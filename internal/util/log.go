@@ -27,6 +27,7 @@ import (
 	. "os"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/badaniya/loggo/internal/char"
@@ -43,6 +44,78 @@ func InitializeLogging(logFile string) {
 	Log().Info("l'oggo Init!\n" + char.NewCanvas().WithWord(char.LoggoLogo...).PrintCanvasAsString())
 }
 
+// SetDebug raises the logging verbosity to debug level.
+func SetDebug(debug bool) {
+	if debug {
+		log.SetLevel(log.DebugLevel)
+	} else {
+		log.SetLevel(log.InfoLevel)
+	}
+}
+
+// memoryLogSize caps the internal log lines kept for the in-app viewer.
+const memoryLogSize = 1000
+
+var memoryLog = &memoryHook{subscribers: make(map[chan string]struct{})}
+
+func init() {
+	log.AddHook(memoryLog)
+}
+
+// memoryHook keeps the latest log lines in memory and fans them out to
+// subscribers, so they can be browsed from within the app.
+type memoryHook struct {
+	lock        sync.Mutex
+	lines       []string
+	subscribers map[chan string]struct{}
+}
+
+func (h *memoryHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (h *memoryHook) Fire(entry *log.Entry) error {
+	b, err := (&log.JSONFormatter{}).Format(entry)
+	if err != nil {
+		return err
+	}
+	line := strings.TrimSpace(string(b))
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if len(h.lines) == memoryLogSize {
+		h.lines = h.lines[1:]
+	}
+	h.lines = append(h.lines, line)
+	for sub := range h.subscribers {
+		select {
+		case sub <- line:
+		default:
+			// slow subscriber, drop rather than block the logger
+		}
+	}
+	return nil
+}
+
+// SubscribeLog returns a channel replaying the latest internal log lines,
+// followed by every new one. The returned func must be called to unsubscribe.
+func SubscribeLog() (<-chan string, func()) {
+	memoryLog.lock.Lock()
+	defer memoryLog.lock.Unlock()
+	sub := make(chan string, 2*memoryLogSize)
+	for _, line := range memoryLog.lines {
+		sub <- line
+	}
+	memoryLog.subscribers[sub] = struct{}{}
+	return sub, func() {
+		memoryLog.lock.Lock()
+		defer memoryLog.lock.Unlock()
+		if _, ok := memoryLog.subscribers[sub]; ok {
+			delete(memoryLog.subscribers, sub)
+			close(sub)
+		}
+	}
+}
+
 func Log() *log.Entry {
 	pc := make([]uintptr, 15)
 	n := runtime.Callers(2, pc)
@@ -0,0 +1,109 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package util
+
+import (
+	"math"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"runtime/pprof"
+	"sync/atomic"
+	"time"
+)
+
+var memLimit atomic.Int64
+
+// SetMemoryLimit bounds the memory used to the given bytes, none if 0: the
+// garbage collector runs more eagerly as it's neared and the views evict
+// their oldest entries, see config.Evictions.
+func SetMemoryLimit(bytes int64) {
+	memLimit.Store(bytes)
+	if bytes > 0 {
+		debug.SetMemoryLimit(bytes)
+	} else {
+		debug.SetMemoryLimit(math.MaxInt64)
+	}
+}
+
+// MemoryLimit returns the memory limit in bytes, 0 if unlimited.
+func MemoryLimit() int64 {
+	return memLimit.Load()
+}
+
+var retention atomic.Int64
+
+// SetRetention bounds the entries buffered to those received within the
+// given period, none if 0, see config.Retention.
+func SetRetention(period time.Duration) {
+	retention.Store(int64(period))
+}
+
+// Retention returns the period entries are retained for, 0 if unlimited.
+func Retention() time.Duration {
+	return time.Duration(retention.Load())
+}
+
+// MemoryInUse returns the bytes of the heap objects retained, i.e. not yet
+// released by the garbage collector.
+func MemoryInUse() int64 {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return int64(m.HeapAlloc)
+}
+
+// StartProfiling records a CPU profile into cpuFile, if set, until the
+// returned func is called, which then writes a heap profile into memFile, if
+// set, for go tool pprof.
+func StartProfiling(cpuFile, memFile string) (stop func() error, err error) {
+	var cpu *os.File
+	if len(cpuFile) > 0 {
+		if cpu, err = os.Create(cpuFile); err != nil {
+			return nil, err
+		}
+		if err = pprof.StartCPUProfile(cpu); err != nil {
+			_ = cpu.Close()
+			return nil, err
+		}
+	}
+	return func() error {
+		if cpu != nil {
+			pprof.StopCPUProfile()
+			if err := cpu.Close(); err != nil {
+				return err
+			}
+		}
+		if len(memFile) == 0 {
+			return nil
+		}
+		f, err := os.Create(memFile)
+		if err != nil {
+			return err
+		}
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			_ = f.Close()
+			return err
+		}
+		return f.Close()
+	}, nil
+}
@@ -0,0 +1,44 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package util
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ErrReadOnly is returned by the actions persisting state in read-only mode.
+var ErrReadOnly = errors.New("disabled in read-only mode")
+
+var readOnly atomic.Bool
+
+// SetReadOnly disables template editing, exports and any other action
+// persisting state, e.g. when running on a shared production bastion.
+func SetReadOnly(ro bool) {
+	readOnly.Store(ro)
+}
+
+// ReadOnly tells whether the read-only mode is on, see SetReadOnly.
+func ReadOnly() bool {
+	return readOnly.Load()
+}
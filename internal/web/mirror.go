@@ -0,0 +1,221 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/enrich"
+	"github.com/badaniya/loggo/internal/filter"
+	"github.com/badaniya/loggo/internal/reader"
+)
+
+// Backlog is the number of latest lines a newly opened page replays.
+const Backlog = 1000
+
+// Mirror serves a live stream read-only as a web page, for those who can't
+// attach to the terminal. Pages follow the filter applied in the terminal,
+// unless viewers type in their own, both in the filter expression language.
+type Mirror struct {
+	broadcast *reader.Broadcast
+	config    *config.Config
+	extractor *config.Extractor
+	enricher  *enrich.Enricher
+	lock      sync.Mutex
+	filter    string
+	watchers  map[chan string]struct{}
+}
+
+// MakeMirror mirrors the broadcast stream, rendering the keys of the config
+// template as columns.
+func MakeMirror(broadcast *reader.Broadcast, cfg *config.Config) *Mirror {
+	// invalid extractions and enrichments are reported in the terminal
+	extractor, _ := config.MakeExtractor(cfg.Extract)
+	enricher, _ := enrich.MakeEnricher(cfg.Enrich)
+	return &Mirror{
+		broadcast: broadcast,
+		config:    cfg,
+		extractor: extractor,
+		enricher:  enricher,
+		watchers:  make(map[chan string]struct{}),
+	}
+}
+
+// SetFilter updates the terminal filter expression, which following pages
+// reload with.
+func (m *Mirror) SetFilter(expression string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.filter = expression
+	for w := range m.watchers {
+		select {
+		case w <- expression:
+		default:
+		}
+	}
+}
+
+// watch returns the terminal filter, and a channel notifying its changes
+// until unwatched.
+func (m *Mirror) watch() (string, chan string, func()) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	w := make(chan string, 1)
+	m.watchers[w] = struct{}{}
+	return m.filter, w, func() {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+		delete(m.watchers, w)
+	}
+}
+
+// Handler serves the page at / and its event stream at /events.
+func (m *Mirror) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = io.WriteString(w, page)
+	})
+	mux.HandleFunc("/events", m.events)
+	return mux
+}
+
+// Serve serves the mirror at addr. It blocks until the server fails.
+func (m *Mirror) Serve(addr string) error {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           m.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return server.ListenAndServe()
+}
+
+// events streams the backlog and the new entries matching the filter as
+// server-sent events: a "columns" event naming the rendered keys (none for
+// raw lines), a "filter" event with the applied expression, then an entry per
+// message. Following pages get a "reload" event once the terminal filter
+// changes, upon which the stream ends.
+func (m *Mirror) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	expression, own := r.URL.Query().Get("filter"), r.URL.Query().Has("filter")
+	var changes chan string
+	if !own {
+		var unwatch func()
+		expression, changes, unwatch = m.watch()
+		defer unwatch()
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	var expr *filter.Expression
+	if len(expression) > 0 {
+		var err error
+		if expr, err = filter.Compile(expression); err != nil {
+			writeEvent(w, "failure", fmt.Sprintf("Invalid filter expression: %v", err))
+			flusher.Flush()
+			return
+		}
+	}
+	keys := m.config.Keys
+	columns := make([]string, 0, len(keys))
+	for _, k := range keys {
+		columns = append(columns, k.Name)
+	}
+	writeEvent(w, "columns", columns)
+	writeEvent(w, "filter", expression)
+	flusher.Flush()
+
+	sub := m.broadcast.Subscribe()
+	if err := sub.StreamInto(); err != nil {
+		return
+	}
+	defer sub.Close()
+	keyMap := m.config.KeyMap()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case f := <-changes:
+			writeEvent(w, "reload", f)
+			flusher.Flush()
+			return
+		case line, ok := <-sub.ChanReader():
+			if !ok {
+				return
+			}
+			if entry := m.project(line, keys, keyMap, expr); entry != nil {
+				writeEvent(w, "", entry)
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// project parses the line as the terminal does, returning the values of the
+// keys if it matches the filter, or the line itself without keys. It returns
+// nil if the line is filtered out.
+func (m *Mirror) project(line string, keys []config.Key, keyMap map[string]*config.Key, expr *filter.Expression) interface{} {
+	row, _ := config.ParseLine(line)
+	config.Flatten(row, m.config.FlattenDepth())
+	if m.extractor != nil {
+		m.extractor.Apply(row)
+	}
+	if m.enricher != nil {
+		m.enricher.Apply(row)
+	}
+	if expr != nil {
+		if ok, err := expr.Apply(row, keyMap); err != nil || !ok {
+			return nil
+		}
+	}
+	if len(keys) == 0 {
+		return line
+	}
+	values := make([]string, len(keys))
+	for i := range keys {
+		values[i] = keys[i].ExtractValue(row)
+	}
+	return values
+}
+
+func writeEvent(w io.Writer, event string, data interface{}) {
+	b, _ := json.Marshal(data)
+	if len(event) > 0 {
+		fmt.Fprintf(w, "event: %s\n", event)
+	}
+	fmt.Fprintf(w, "data: %s\n\n", b)
+}
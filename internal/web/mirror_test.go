@@ -0,0 +1,135 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package web
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/badaniya/loggo/internal/config"
+	"github.com/badaniya/loggo/internal/filter"
+	"github.com/badaniya/loggo/internal/reader"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProject(t *testing.T) {
+	keys := []config.Key{{Name: "severity", Type: config.TypeString}, {Name: "a/b", Type: config.TypeString}}
+	cfg := &config.Config{Keys: keys}
+	expr, err := filter.Compile(`severity == "ERROR"`)
+	assert.NoError(t, err)
+
+	extractor, err := config.MakeExtractor([]config.Extraction{{Key: "message", Regex: `order (?P<order_id>\d+)`}})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		line      string
+		keys      []config.Key
+		extractor *config.Extractor
+		expr      *filter.Expression
+		want      interface{}
+	}{
+		{name: "keys", line: `{"severity":"INFO","a":{"b":"c"}}`, keys: keys, want: []string{"INFO", "c"}},
+		{name: "raw", line: `{"severity":"INFO"}`, want: `{"severity":"INFO"}`},
+		{name: "matching", line: `{"severity":"ERROR"}`, keys: keys, expr: expr, want: []string{"ERROR", ""}},
+		{name: "filtered out", line: `{"severity":"INFO"}`, keys: keys, expr: expr},
+		{name: "text", line: `plain text`, want: `plain text`},
+		{
+			name:      "extracted",
+			line:      `order 7731 failed`,
+			keys:      []config.Key{{Name: "order_id", Type: config.TypeString}},
+			extractor: extractor,
+			want:      []string{"7731"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, (&Mirror{config: cfg, extractor: test.extractor}).project(test.line, test.keys, cfg.KeyMap(), test.expr))
+		})
+	}
+}
+
+// readEvents reads the event stream until n data lines are received.
+func readEvents(t *testing.T, server *httptest.Server, query string, n int) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/events"+query, nil)
+	assert.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	var lines []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() && n > 0 {
+		if len(scanner.Text()) == 0 {
+			continue
+		}
+		lines = append(lines, scanner.Text())
+		if strings.HasPrefix(scanner.Text(), "data:") {
+			n--
+		}
+	}
+	return lines
+}
+
+func TestMirror_Events(t *testing.T) {
+	source := reader.MakeCommandReader(`echo '{"severity":"INFO","message":"ok"}'; `+
+		`echo '{"severity":"ERROR","message":"boom"}'`, nil)
+	broadcast := reader.MakeBroadcast(source, Backlog)
+	assert.NoError(t, broadcast.Start())
+	defer broadcast.Close()
+	mirror := MakeMirror(broadcast, &config.Config{Keys: []config.Key{
+		{Name: "severity", Type: config.TypeString},
+		{Name: "message", Type: config.TypeString},
+	}})
+	server := httptest.NewServer(mirror.Handler())
+	defer server.Close()
+
+	assert.Equal(t, []string{
+		"event: columns", `data: ["severity","message"]`,
+		"event: filter", `data: "severity == \"ERROR\""`,
+		`data: ["ERROR","boom"]`,
+	}, readEvents(t, server, "?filter="+url.QueryEscape(`severity == "ERROR"`), 3))
+
+	mirror.SetFilter(`message == "ok"`)
+	assert.Equal(t, []string{
+		"event: columns", `data: ["severity","message"]`,
+		"event: filter", `data: "message == \"ok\""`,
+		`data: ["INFO","ok"]`,
+	}, readEvents(t, server, "", 3))
+
+	lines := readEvents(t, server, "?filter="+url.QueryEscape(`severity ==`), 1)
+	if assert.Len(t, lines, 2) {
+		assert.Equal(t, "event: failure", lines[0])
+		assert.True(t, strings.HasPrefix(lines[1], `data: "Invalid filter expression: `))
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package web
+
+// page renders the event stream as a table, keeping the latest maxRows
+// entries and following the bottom unless scrolled up.
+const page = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>l'oGGo live view</title>
+<style>
+body { margin: 0; font-family: monospace; font-size: 13px; background: #1a1a1a; color: #ddd; }
+header { position: sticky; top: 0; display: flex; gap: 8px; align-items: center; padding: 6px 10px; background: #00008b; }
+header b { color: yellow; }
+header input { flex: 1; font-family: monospace; background: #222; color: #fff; border: 1px solid #555; padding: 3px; }
+header span { color: #aaa; }
+#failure { color: #ff6b6b; padding: 4px 10px; }
+table { border-collapse: collapse; width: 100%; }
+th { position: sticky; top: 34px; background: #333; color: yellow; text-align: left; }
+td, th { padding: 1px 8px; white-space: pre; vertical-align: top; }
+tr:nth-child(even) { background: #222; }
+</style>
+</head>
+<body>
+<header>
+<b>l'oGGo</b> <span>read-only live view</span>
+<input id="filter" placeholder="Filter expression, e.g. severity == &quot;ERROR&quot;">
+<button id="apply">Apply</button>
+<button id="follow" title="Use the filter applied in the terminal">Follow terminal</button>
+<span id="status"></span>
+</header>
+<div id="failure"></div>
+<table><thead id="head"></thead><tbody id="rows"></tbody></table>
+<script>
+const maxRows = 5000;
+const filterInput = document.getElementById('filter');
+const head = document.getElementById('head');
+const rows = document.getElementById('rows');
+const status = document.getElementById('status');
+const failure = document.getElementById('failure');
+let source = null;
+let own = null;
+
+function cell(tag, text) {
+  const c = document.createElement(tag);
+  c.textContent = text;
+  return c;
+}
+
+function connect() {
+  if (source) source.close();
+  head.textContent = '';
+  rows.textContent = '';
+  failure.textContent = '';
+  source = new EventSource(own === null ? 'events' : 'events?filter=' + encodeURIComponent(own));
+  source.addEventListener('columns', e => {
+    const tr = document.createElement('tr');
+    JSON.parse(e.data).forEach(c => tr.appendChild(cell('th', c)));
+    head.appendChild(tr);
+  });
+  source.addEventListener('filter', e => {
+    filterInput.value = JSON.parse(e.data);
+    status.textContent = own === null ? 'following the terminal filter' : 'own filter';
+  });
+  source.addEventListener('reload', () => connect());
+  source.addEventListener('failure', e => {
+    failure.textContent = JSON.parse(e.data);
+    source.close();
+  });
+  source.onmessage = e => {
+    const follow = window.innerHeight + window.scrollY >= document.body.scrollHeight - 4;
+    const entry = JSON.parse(e.data);
+    const tr = document.createElement('tr');
+    (Array.isArray(entry) ? entry : [entry]).forEach(v => tr.appendChild(cell('td', v)));
+    rows.appendChild(tr);
+    while (rows.childElementCount > maxRows) rows.removeChild(rows.firstChild);
+    if (follow) window.scrollTo(0, document.body.scrollHeight);
+  };
+}
+
+document.getElementById('apply').onclick = () => { own = filterInput.value; connect(); };
+filterInput.onkeydown = e => { if (e.key === 'Enter') { own = filterInput.value; connect(); } };
+document.getElementById('follow').onclick = () => { own = null; connect(); };
+connect();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,141 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// Tmux lays a workspace out as a tmux session, one l'oGGo per pane or window.
+type Tmux struct {
+	// Exe is the l'oGGo executable run in each pane.
+	Exe string
+	// Nested is set when already within a tmux client, which then switches to
+	// the workspace session rather than attaching to it.
+	Nested bool
+	// ControlMode attaches in tmux control mode, rendered by iTerm2 as native
+	// tabs and split panes.
+	ControlMode bool
+}
+
+var (
+	sessionUnsafe = regexp.MustCompile(`[.:\s]`)
+	shellSafe     = regexp.MustCompile(`^[\w@%+=:,./-]+$`)
+)
+
+// MakeTmux returns the tmux launcher for the current terminal, or nil if tmux
+// is not installed.
+func MakeTmux(exe string) *Tmux {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		return nil
+	}
+	return &Tmux{
+		Exe:         exe,
+		Nested:      len(os.Getenv("TMUX")) > 0,
+		ControlMode: os.Getenv("TERM_PROGRAM") == "iTerm.app",
+	}
+}
+
+// Session is the tmux session name of the workspace.
+func (t *Tmux) Session(w *Workspace) string {
+	return "loggo-" + sessionUnsafe.ReplaceAllString(w.Name, "-")
+}
+
+// Commands returns the tmux arguments creating the workspace session.
+func (t *Tmux) Commands(w *Workspace) [][]string {
+	session := t.Session(w)
+	first := w.Streams[0]
+	window := w.Name
+	if w.Layout == LayoutWindows {
+		window = first.Name
+	}
+	cmds := [][]string{
+		{"new-session", "-d", "-s", session, "-n", window, t.command(first)},
+		{"set-option", "-t", session, "pane-border-status", "top"},
+		{"select-pane", "-t", session, "-T", first.Name},
+	}
+	for _, s := range w.Streams[1:] {
+		if w.Layout == LayoutWindows {
+			cmds = append(cmds, []string{"new-window", "-t", session, "-n", s.Name, t.command(s)})
+		} else {
+			// Re-tiling after each split keeps room for the next pane.
+			cmds = append(cmds,
+				[]string{"split-window", "-t", session, t.command(s)},
+				[]string{"select-layout", "-t", session, w.Layout})
+		}
+		cmds = append(cmds, []string{"select-pane", "-t", session, "-T", s.Name})
+	}
+	if w.Layout == LayoutWindows {
+		cmds = append(cmds, []string{"select-window", "-t", session + ":" + first.Name})
+	}
+	return cmds
+}
+
+// Attach returns the tmux arguments bringing the workspace session up.
+func (t *Tmux) Attach(w *Workspace) []string {
+	if t.Nested {
+		return []string{"switch-client", "-t", t.Session(w)}
+	}
+	if t.ControlMode {
+		return []string{"-CC", "attach-session", "-t", t.Session(w)}
+	}
+	return []string{"attach-session", "-t", t.Session(w)}
+}
+
+// Launch creates the workspace session, unless still running from a previous
+// launch, and brings it up.
+func (t *Tmux) Launch(w *Workspace) error {
+	if err := exec.Command("tmux", "has-session", "-t", "="+t.Session(w)).Run(); err != nil {
+		for _, args := range t.Commands(w) {
+			if out, err := exec.Command("tmux", args...).CombinedOutput(); err != nil {
+				if msg := strings.TrimSpace(string(out)); len(msg) > 0 {
+					return fmt.Errorf("tmux %s: %s", args[0], msg)
+				}
+				return fmt.Errorf("tmux %s: %w", args[0], err)
+			}
+		}
+	}
+	c := exec.Command("tmux", t.Attach(w)...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return c.Run()
+}
+
+// command is the shell command running the stream in a pane.
+func (t *Tmux) command(s Stream) string {
+	words := make([]string, 0, len(s.Args)+1)
+	for _, w := range append([]string{t.Exe}, s.Args...) {
+		words = append(words, shellQuote(w))
+	}
+	return strings.Join(words, " ")
+}
+
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
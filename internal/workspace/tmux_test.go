@@ -0,0 +1,109 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package workspace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTmuxCommands(t *testing.T) {
+	streams := []Stream{
+		{Name: "api", Args: []string{"k8s-stream", "deploy/api", "-n", "shop"}},
+		{Name: "errors", Args: []string{"stream", "-f", "/var/log/app.log", "--filter", `level == "error"`}},
+	}
+	tests := []struct {
+		name string
+		ws   *Workspace
+		want [][]string
+	}{
+		{
+			name: "panes",
+			ws:   &Workspace{Name: "check.out", Layout: LayoutTiled, Streams: streams},
+			want: [][]string{
+				{"new-session", "-d", "-s", "loggo-check-out", "-n", "check.out", "loggo k8s-stream deploy/api -n shop"},
+				{"set-option", "-t", "loggo-check-out", "pane-border-status", "top"},
+				{"select-pane", "-t", "loggo-check-out", "-T", "api"},
+				{"split-window", "-t", "loggo-check-out", `loggo stream -f /var/log/app.log --filter 'level == "error"'`},
+				{"select-layout", "-t", "loggo-check-out", "tiled"},
+				{"select-pane", "-t", "loggo-check-out", "-T", "errors"},
+			},
+		},
+		{
+			name: "windows",
+			ws:   &Workspace{Name: "checkout", Layout: LayoutWindows, Streams: streams},
+			want: [][]string{
+				{"new-session", "-d", "-s", "loggo-checkout", "-n", "api", "loggo k8s-stream deploy/api -n shop"},
+				{"set-option", "-t", "loggo-checkout", "pane-border-status", "top"},
+				{"select-pane", "-t", "loggo-checkout", "-T", "api"},
+				{"new-window", "-t", "loggo-checkout", "-n", "errors", `loggo stream -f /var/log/app.log --filter 'level == "error"'`},
+				{"select-pane", "-t", "loggo-checkout", "-T", "errors"},
+				{"select-window", "-t", "loggo-checkout:api"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm := &Tmux{Exe: "loggo"}
+			assert.Equal(t, tt.want, tm.Commands(tt.ws))
+		})
+	}
+}
+
+func TestTmuxAttach(t *testing.T) {
+	ws := &Workspace{Name: "checkout"}
+	tests := []struct {
+		name string
+		tmux Tmux
+		want []string
+	}{
+		{name: "terminal", want: []string{"attach-session", "-t", "loggo-checkout"}},
+		{name: "nested", tmux: Tmux{Nested: true}, want: []string{"switch-client", "-t", "loggo-checkout"}},
+		{name: "iterm2", tmux: Tmux{ControlMode: true}, want: []string{"-CC", "attach-session", "-t", "loggo-checkout"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.tmux.Attach(ws))
+		})
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "/usr/local/bin/loggo", want: "/usr/local/bin/loggo"},
+		{in: "--since=1h", want: "--since=1h"},
+		{in: "app=api,tier=web", want: "app=api,tier=web"},
+		{in: "", want: "''"},
+		{in: "a b", want: "'a b'"},
+		{in: "it's", want: `'it'\''s'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			assert.Equal(t, tt.want, shellQuote(tt.in))
+		})
+	}
+}
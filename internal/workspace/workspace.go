@@ -0,0 +1,142 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package workspace
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	parentPath     = ".loggo"
+	workspacesPath = "workspaces"
+)
+
+const (
+	LayoutTiled   = "tiled"
+	LayoutWindows = "windows"
+)
+
+var layouts = map[string]bool{
+	LayoutTiled:       true,
+	LayoutWindows:     true,
+	"even-horizontal": true,
+	"even-vertical":   true,
+	"main-horizontal": true,
+	"main-vertical":   true,
+}
+
+// Workspace describes streams watched side by side, each one a l'oGGo command
+// line, e.g.
+//
+//	layout: tiled
+//	streams:
+//	  - name: api
+//	    args: [k8s-stream, deploy/api, -n, shop, -t, /home/me/api.yaml]
+//	  - name: payments
+//	    args: [gcp-stream, --params-load, payments]
+//	  - name: nginx
+//	    args: [stream, -f, /var/log/nginx/access.log]
+type Workspace struct {
+	Name string `yaml:"-"`
+	// Layout arranges the streams as the panes of one tmux window (tiled,
+	// even-horizontal, even-vertical, main-horizontal or main-vertical) or as
+	// a window each (windows).
+	Layout  string   `yaml:"layout,omitempty"`
+	Streams []Stream `yaml:"streams"`
+}
+
+type Stream struct {
+	Name string `yaml:"name"`
+	// Args are the l'oGGo arguments, starting with the command.
+	Args []string `yaml:"args"`
+}
+
+// File resolves a workspace name to its file, ~/.loggo/workspaces/<name>.yaml,
+// unless already a path to a YAML file.
+func File(name string) (string, error) {
+	if strings.ContainsRune(name, os.PathSeparator) ||
+		strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml") {
+		return name, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(home, parentPath, workspacesPath, name+".yaml"), nil
+}
+
+// Load reads and checks the named workspace, see File.
+func Load(name string) (*Workspace, error) {
+	file, err := File(name)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	w := &Workspace{}
+	decoder := yaml.NewDecoder(bytes.NewReader(b))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(w); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	w.Name = strings.TrimSuffix(strings.TrimSuffix(path.Base(file), ".yaml"), ".yml")
+	if len(w.Layout) == 0 {
+		w.Layout = LayoutTiled
+	}
+	if err := w.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return w, nil
+}
+
+func (w *Workspace) validate() error {
+	if !layouts[w.Layout] {
+		return fmt.Errorf("unknown layout %q", w.Layout)
+	}
+	if len(w.Streams) == 0 {
+		return fmt.Errorf("no streams configured")
+	}
+	names := make(map[string]bool)
+	for i, s := range w.Streams {
+		switch {
+		case len(s.Name) == 0:
+			return fmt.Errorf("stream %d has no name", i+1)
+		case names[s.Name]:
+			return fmt.Errorf("stream %q is defined twice", s.Name)
+		case len(s.Args) == 0:
+			return fmt.Errorf("stream %q has no args", s.Name)
+		case s.Args[0] == "workspace":
+			return fmt.Errorf("stream %q cannot open a workspace", s.Name)
+		}
+		names[s.Name] = true
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package workspace
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     string
+		wantLayout string
+		wantErr    string
+	}{
+		{
+			name: "default layout",
+			config: `
+streams:
+  - name: api
+    args: [k8s-stream, deploy/api]`,
+			wantLayout: LayoutTiled,
+		},
+		{
+			name: "windows",
+			config: `
+layout: windows
+streams:
+  - name: api
+    args: [k8s-stream, deploy/api]
+  - name: nginx
+    args: [stream, -f, /var/log/nginx/access.log]`,
+			wantLayout: LayoutWindows,
+		},
+		{
+			name: "unknown layout",
+			config: `
+layout: grid
+streams:
+  - name: api
+    args: [k8s-stream, deploy/api]`,
+			wantErr: `unknown layout "grid"`,
+		},
+		{
+			name:    "no streams",
+			config:  `layout: tiled`,
+			wantErr: "no streams configured",
+		},
+		{
+			name: "unnamed stream",
+			config: `
+streams:
+  - args: [k8s-stream, deploy/api]`,
+			wantErr: "stream 1 has no name",
+		},
+		{
+			name: "duplicate stream",
+			config: `
+streams:
+  - name: api
+    args: [k8s-stream, deploy/api]
+  - name: api
+    args: [k8s-stream, deploy/web]`,
+			wantErr: `stream "api" is defined twice`,
+		},
+		{
+			name: "no args",
+			config: `
+streams:
+  - name: api`,
+			wantErr: `stream "api" has no args`,
+		},
+		{
+			name: "nested workspace",
+			config: `
+streams:
+  - name: more
+    args: [workspace, other]`,
+			wantErr: `stream "more" cannot open a workspace`,
+		},
+		{
+			name: "unknown field",
+			config: `
+streams:
+  - name: api
+    command: kubectl logs -f deploy/api`,
+			wantErr: "field command not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := path.Join(t.TempDir(), "checkout.yaml")
+			assert.NoError(t, os.WriteFile(file, []byte(tt.config), 0644))
+			w, err := Load(file)
+			if len(tt.wantErr) > 0 {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "checkout", w.Name)
+			assert.Equal(t, tt.wantLayout, w.Layout)
+		})
+	}
+}
+
+func TestFile(t *testing.T) {
+	home, err := os.UserHomeDir()
+	assert.NoError(t, err)
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "checkout", want: path.Join(home, ".loggo", "workspaces", "checkout.yaml")},
+		{name: "checkout.yml", want: "checkout.yml"},
+		{name: "./checkout", want: "./checkout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := File(tt.name)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}